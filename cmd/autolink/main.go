@@ -0,0 +1,69 @@
+// Package main provides the article-source auto-linking job. It searches
+// the sources closest to each article's embedding and stores the matches
+// as link suggestions pending curator review. Run it nightly (e.g. from
+// cron) or set KB_AUTOLINK_INTERVAL on the server instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/autolink"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	threshold := flag.Float64("threshold", autolink.DefaultThreshold, "Minimum similarity for a suggestion (maximum distance with the euclid metric)")
+	limit := flag.Int("limit", autolink.DefaultLimit, "Sources considered per article")
+	vector := flag.String("vector", "", "Vector space to search (default: the first configured)")
+	flag.Parse()
+
+	if err := run(*dbPath, autolink.Options{Threshold: float32(*threshold), Limit: *limit, Vector: *vector}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts autolink.Options) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+
+	start := time.Now()
+	stats, err := autolink.Run(ctx, db, vectorDB, opts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Auto-linking complete: %d articles searched, %d without embeddings, %d new suggestions (%s)",
+		stats.Articles, stats.Skipped, stats.Suggested, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package main provides the claim extraction job. It has the generation
+// model (GENERATION_MODEL) list the discrete claims of every source summary
+// without claims, or whose summary changed since they were extracted,
+// stores them in SQLite and embeds them into the claims collection
+// (QDRANT_CLAIMS). With -reembed it only re-embeds the stored claims, e.g.
+// after adding an embedding model.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/claims"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// options select the sources processed
+type options struct {
+	topic   string
+	limit   int  // Sources extracted per run, 0 for all
+	all     bool // Extract again even when the summary is unchanged
+	reembed bool // Re-embed the stored claims without extracting
+}
+
+// stats counts the outcomes of a run
+type stats struct {
+	sources, claims, skipped, failed int
+}
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	topic := flag.String("topic", "", "Only process the sources of this topic")
+	limit := flag.Int("limit", 0, "Sources to extract claims from in this run (0 for all)")
+	all := flag.Bool("all", false, "Extract claims again even where the summary is unchanged")
+	reembed := flag.Bool("reembed", false, "Re-embed the stored claims instead of extracting them")
+	flag.Parse()
+
+	opts := options{topic: slug.Make(*topic), limit: max(*limit, 0), all: *all, reembed: *reembed}
+	if err := run(*dbPath, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts options) error {
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	var generator generation.Generator
+	if !opts.reembed {
+		var err error
+		if generator, err = generation.FromEnv(); err != nil {
+			return err
+		}
+		if generator == nil {
+			return fmt.Errorf("GENERATION_MODEL is required to extract claims")
+		}
+		log.Printf("Generation model: %s", generator.Model())
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+	if !vectorDB.ClaimsEnabled() {
+		return fmt.Errorf("claims are not enabled (set QDRANT_CLAIMS=true)")
+	}
+	if err := vectorDB.EnsureCollections(ctx); err != nil {
+		return err
+	}
+
+	embedders := embedding.NewSet(vectorDB.VectorNames()...)
+	log.Printf("Embedding models: %s", strings.Join(embedders.Models(), ", "))
+
+	var ids []string
+	if opts.topic != "" {
+		ids, err = db.SourceIDsByTopic(ctx, opts.topic)
+	} else {
+		ids, err = db.SourceIDs(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	hashes, err := db.ClaimSummaryHashes(ctx)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var st stats
+	for _, id := range ids {
+		if opts.limit > 0 && st.sources == opts.limit {
+			break
+		}
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			return err
+		}
+		if src == nil || strings.TrimSpace(src.Summary) == "" {
+			continue
+		}
+
+		var texts []string
+		if opts.reembed {
+			set, err := db.SourceClaims(ctx, id)
+			if err != nil {
+				return err
+			}
+			if set == nil {
+				continue
+			}
+			texts = set.Claims
+		} else {
+			hash := database.SummaryHash(src.Summary)
+			if !opts.all && hashes[id] == hash {
+				st.skipped++
+				continue
+			}
+			texts, err = claims.Extract(ctx, generator, *src)
+			if err != nil {
+				log.Printf("Error extracting claims of %s: %v", id, err)
+				st.failed++
+				continue
+			}
+			set := database.ClaimSet{SourceID: id, Model: generator.Model(), SummaryHash: hash, Claims: texts}
+			if err := db.ReplaceClaims(ctx, set); err != nil {
+				return fmt.Errorf("failed to store claims of %s: %w", id, err)
+			}
+		}
+
+		points, err := claims.Points(ctx, embedders, *src, texts)
+		if err == nil {
+			err = vectorDB.ReplaceSourceClaims(ctx, id, points)
+		}
+		if err != nil {
+			// The claims are in SQLite; -reembed stores them later
+			log.Printf("Error storing claim embeddings of %s: %v", id, err)
+			st.failed++
+			continue
+		}
+		st.sources++
+		st.claims += len(texts)
+	}
+
+	log.Printf("Claim extraction complete: %d sources, %d claims, %d unchanged, %d errors (%s)",
+		st.sources, st.claims, st.skipped, st.failed, time.Since(start).Round(time.Millisecond))
+	return nil
+}
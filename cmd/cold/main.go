@@ -0,0 +1,105 @@
+// Package main provides the cold storage job. It drops from Qdrant the
+// vectors of the sources nobody has fetched or found in a search for longer
+// than an idle period (KB_COLD_AFTER, e.g. "5y"), keeping their records in
+// SQLite; a cold source is re-embedded when it is hit again. Run it with
+// -dry-run to see what would be moved, from cron, or set KB_COLD_INTERVAL
+// on the server instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/cold"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	idle := flag.String("idle", os.Getenv("KB_COLD_AFTER"), "How long a source goes without a hit before it is moved, e.g. 5y or 18mo (default: KB_COLD_AFTER)")
+	limit := flag.Int("limit", cold.DefaultLimit, "Maximum sources to move, longest idle first")
+	dryRun := flag.Bool("dry-run", false, "Report the idle sources without moving them")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	if *idle == "" {
+		log.Fatal("No idle period: set -idle or KB_COLD_AFTER")
+	}
+	period, err := retention.ParsePeriod(*idle)
+	if err != nil || period.Forever() {
+		log.Fatalf("Invalid -idle: %s", *idle)
+	}
+	opts := cold.Options{Idle: period, Limit: *limit, DryRun: *dryRun}
+	if err := run(*dbPath, opts, *jsonOut); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts cold.Options, jsonOut bool) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// A dry run only reads SQLite
+	var vectorDB *vectordb.Client
+	if !opts.DryRun {
+		if vectorDB, err = vectordb.NewClient(); err != nil {
+			return fmt.Errorf("failed to connect to Qdrant: %w", err)
+		}
+		defer vectorDB.Close()
+	}
+
+	start := time.Now()
+	report, err := cold.Run(ctx, db, vectorDB, opts)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	verb := "Moved"
+	if opts.DryRun {
+		verb = "Would move"
+	}
+	for _, src := range report.Sources {
+		lastHit := src.LastHitAt
+		if lastHit == "" {
+			lastHit = "never hit"
+		}
+		fmt.Printf("  %s %s  %s  %s\n", verb, src.ID, lastHit, src.Title)
+	}
+	moved := "moved to cold storage"
+	if opts.DryRun {
+		moved = "to move (dry run)"
+	}
+	log.Printf("Cold storage complete: %d sources idle since %s %s, %d failed, %d cold (%s)",
+		report.Moved, report.Cutoff, moved, report.Failed, report.Cold, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package main writes generated metadata back into Compendium frontmatter:
+// approved link suggestions into `sources:`, tags shared by an article's
+// curated sources into `tags:`, and an extractive summary where there is
+// none. Changes go to a patch file for review as a pull request, or with
+// -write straight into the files.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/frontmatter"
+)
+
+const (
+	// maxSuggestedTags caps the tags added to one article
+	maxSuggestedTags = 5
+	// summaryChars is the length budget of generated summaries
+	summaryChars = 300
+)
+
+// options select what is generated
+type options struct {
+	links, tags, summaries bool
+	minTagSources          int // Curated sources that must share a tag
+}
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	compendiumDir := flag.String("compendium", "", "Path to Compendium directory")
+	outPath := flag.String("out", "", "Patch file to write (default out/enrich.patch)")
+	write := flag.Bool("write", false, "Edit the files in place instead of writing a patch")
+	prefix := flag.String("prefix", "Compendium/", "Path of the Compendium within its repository, for patch file names")
+	links := flag.Bool("links", true, "Add approved link suggestions to sources:")
+	tags := flag.Bool("tags", true, "Add tags shared by the article's curated sources")
+	summaries := flag.Bool("summaries", true, "Add a summary from the first paragraph where missing")
+	minTagSources := flag.Int("min-tag-sources", 2, "Curated sources that must share a tag for it to be suggested")
+	flag.Parse()
+
+	opts := options{links: *links, tags: *tags, summaries: *summaries, minTagSources: max(*minTagSources, 1)}
+	if err := run(*dbPath, *compendiumDir, *outPath, *prefix, *write, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath, compendiumDir, outPath, prefix string, write bool, opts options) error {
+	ctx := context.Background()
+
+	kbRoot, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	if compendiumDir == "" {
+		compendiumDir = os.Getenv("GITOPEDIA_DIR")
+		if compendiumDir == "" {
+			compendiumDir = filepath.Join(kbRoot, "../gitopedia/Compendium")
+		}
+	}
+	if outPath == "" {
+		outPath = filepath.Join(kbRoot, "out", "enrich.patch")
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var patch strings.Builder
+	var changed, errors int
+	err = filepath.WalkDir(compendiumDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "_incoming" || d.Name() == "_debug" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		if !strings.HasSuffix(name, ".md") || name == "index.md" {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(compendiumDir, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		old, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		updated, err := enrich(ctx, db, relPath, old, opts)
+		if err != nil {
+			log.Printf("Error enriching %s: %v", relPath, err)
+			errors++
+			return nil
+		}
+		if updated == nil {
+			return nil
+		}
+
+		changed++
+		if write {
+			return os.WriteFile(p, updated, d.Type().Perm()|0644)
+		}
+		patch.WriteString(frontmatter.Diff(path.Join(prefix, relPath), old, updated))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !write && changed > 0 {
+		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outPath, []byte(patch.String()), 0644); err != nil {
+			return err
+		}
+		log.Printf("Patch written to %s (apply with: git apply %s)", outPath, filepath.Base(outPath))
+	}
+	log.Printf("Enrichment complete: %d articles changed, %d errors", changed, errors)
+	return nil
+}
+
+// enrich returns the updated content of an article, or nil if nothing
+// changed
+func enrich(ctx context.Context, db *database.DB, relPath string, content []byte, opts options) ([]byte, error) {
+	doc, err := frontmatter.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Articles are keyed like the indexer does: id frontmatter or path
+	id := doc.String("id")
+	if id == "" {
+		id = relPath
+	}
+	art, err := db.GetArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if art == nil {
+		return nil, nil // Not indexed yet
+	}
+
+	changed := false
+	apply := func(ok bool, err error) error {
+		changed = changed || ok
+		return err
+	}
+
+	if opts.links {
+		suggestions, err := db.LinkSuggestions(ctx, id, database.SuggestionApproved, 1000)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, s := range suggestions {
+			ids = append(ids, s.SourceID)
+		}
+		sort.Strings(ids)
+		if err := apply(doc.AddToList("sources", ids)); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.tags {
+		tags, err := sharedTags(ctx, db, art.Sources, doc.List("tags"), opts.minTagSources)
+		if err != nil {
+			return nil, err
+		}
+		if err := apply(doc.AddToList("tags", tags)); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.summaries && doc.String("summary") == "" {
+		if summary := frontmatter.Summary(doc.Body(), summaryChars); summary != "" {
+			if err := apply(doc.SetString("summary", summary)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !changed {
+		return nil, nil
+	}
+	return doc.Bytes(), nil
+}
+
+// sharedTags returns the tags carried by at least minSources of the given
+// sources that the article doesn't have yet, most shared first
+func sharedTags(ctx context.Context, db *database.DB, sourceIDs, have []string, minSources int) ([]string, error) {
+	existing := make(map[string]bool)
+	for _, t := range have {
+		existing[strings.ToLower(t)] = true
+	}
+
+	counts := make(map[string]int)
+	for _, id := range sourceIDs {
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil || src.Visibility != database.VisibilityPublic {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, t := range src.Tags {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t != "" && !existing[t] && !seen[t] {
+				seen[t] = true
+				counts[t]++
+			}
+		}
+	}
+
+	var tags []string
+	for t, n := range counts {
+		if n >= minSources {
+			tags = append(tags, t)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > maxSuggestedTags {
+		tags = tags[:maxSuggestedTags]
+	}
+	return tags, nil
+}
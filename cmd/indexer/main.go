@@ -1,6 +1,7 @@
 // Package main provides the article indexer for the knowledge-base.
 // It walks the Compendium directory, parses markdown files with frontmatter,
-// and builds a searchable SQLite index.
+// and builds a searchable SQLite index. The index.md of a directory becomes
+// the description of its category.
 package main
 
 import (
@@ -10,15 +11,26 @@ import (
 	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/gitopedia/knowledge-base/internal/chunk"
 	"github.com/gitopedia/knowledge-base/internal/database"
 	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/frontmatter"
 	"github.com/gitopedia/knowledge-base/internal/vectordb"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	// insertBatchSize is how many articles are written per transaction
+	insertBatchSize = 200
+	// descriptionChars is the length budget of category descriptions taken
+	// from the first paragraph of index.md
+	descriptionChars = 300
+)
+
 // FrontMatter represents the YAML front matter of an article
 type FrontMatter struct {
 	ID      string   `yaml:"id"`
@@ -26,23 +38,48 @@ type FrontMatter struct {
 	Author  string   `yaml:"author"`
 	Summary string   `yaml:"summary"`
 	Tags    []string `yaml:"tags"`
+	Sources []string `yaml:"sources"` // IDs of sources curated for the article
 	// Capture other fields for meta_json
 	Rest map[string]interface{} `yaml:",inline"`
 }
 
+// CategoryFrontMatter represents the YAML front matter of a directory's
+// index.md
+type CategoryFrontMatter struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Order       int      `yaml:"order"`
+	Highlights  []string `yaml:"highlights"` // Article IDs or paths, relative to the directory or the root
+}
+
 func main() {
 	// Flags
 	dbPath := flag.String("db", "", "Path to SQLite database")
 	compendiumDir := flag.String("compendium", "", "Path to Compendium directory")
 	withEmbeddings := flag.Bool("embeddings", false, "Generate embeddings and store in Qdrant")
+	full := flag.Bool("full", false, "Re-index every article, even files unchanged since the last run")
+	watch := flag.Bool("watch", false, "Keep running and index files as they are created, changed or deleted")
 	flag.Parse()
 
-	if err := run(*dbPath, *compendiumDir, *withEmbeddings); err != nil {
+	if err := run(*dbPath, *compendiumDir, *withEmbeddings, *full, *watch); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run(dbPath, compendiumDir string, withEmbeddings bool) error {
+// pendingArticle is a parsed article waiting to be written, with the state
+// of its file
+type pendingArticle struct {
+	article database.Article
+	file    database.IndexedFile
+	// moved is set for a file moved without changes: its embeddings are
+	// kept, with their path and category updated
+	moved bool
+}
+
+func run(dbPath, compendiumDir string, withEmbeddings, full, watch bool) error {
+	// Articles are indexed as bulk work, which waits out embedding outages
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
 	// Determine paths
 	kbRoot, err := os.Getwd()
 	if err != nil {
@@ -66,6 +103,7 @@ func run(dbPath, compendiumDir string, withEmbeddings bool) error {
 	log.Printf("Database path: %s", dbPath)
 	log.Printf("Compendium directory: %s", compendiumDir)
 	log.Printf("Generate embeddings: %v", withEmbeddings)
+	log.Printf("Full re-index: %v", full)
 
 	if _, err := os.Stat(compendiumDir); os.IsNotExist(err) {
 		return fmt.Errorf("compendium dir not found: %s", compendiumDir)
@@ -83,116 +121,422 @@ func run(dbPath, compendiumDir string, withEmbeddings bool) error {
 	if version == "" {
 		version = "unknown"
 	}
-	if err := db.SetInfo("version", version); err != nil {
+	if err := db.SetInfo(ctx, "version", version); err != nil {
 		log.Printf("Warning: failed to set version info: %v", err)
 	}
 
+	ix := &indexer{
+		ctx:            ctx,
+		root:           compendiumDir,
+		db:             db,
+		withEmbeddings: withEmbeddings,
+		articleIDs:     make(map[string]string),
+	}
+
 	// Initialize embedding and vector clients if needed
-	var embedder *embedding.Client
-	var vectorDB *vectordb.Client
 	if withEmbeddings {
-		embedder = embedding.NewClient()
-		log.Printf("Embedding model: %s", embedder.Model())
+		strategies, err := embedding.StrategiesFromEnv()
+		if err != nil {
+			return err
+		}
+		ix.strategy = strategies.Articles
+		log.Printf("Embedding text: %s", ix.strategy)
 
-		vectorDB, err = vectordb.NewClient()
+		ix.vectorDB, err = vectordb.NewClient()
 		if err != nil {
 			return fmt.Errorf("failed to connect to Qdrant: %w", err)
 		}
-		defer vectorDB.Close()
+		defer ix.vectorDB.Close()
+
+		ix.embedders = embedding.NewSet(ix.vectorDB.VectorNames()...)
+		log.Printf("Embedding models: %s", strings.Join(ix.embedders.Models(), ", "))
 
-		ctx := context.Background()
-		if err := vectorDB.EnsureCollections(ctx); err != nil {
+		if err := ix.vectorDB.EnsureCollections(ctx); err != nil {
 			return fmt.Errorf("failed to ensure Qdrant collections: %w", err)
 		}
+		if ix.vectorDB.ChunksEnabled() {
+			opts, err := chunk.OptionsFromEnv()
+			if err != nil {
+				return err
+			}
+			ix.chunks = &opts
+			log.Printf("Article chunks: %d bytes, %d overlap", opts.Size, opts.Overlap)
+		}
+
+		// Every article not yet embedded with it is embedded below
+		if err := embedding.RecordStrategy(ctx, db, "articles", ix.strategy); err != nil {
+			log.Printf("Warning: failed to record embedding text: %v", err)
+		}
+		ix.points = ix.vectorDB.NewBatch(ctx)
+		defer ix.points.Close()
+	}
+
+	// Files unchanged since the last run (same modification time and size,
+	// or same content) are skipped, unless they still need embedding
+	ix.indexed = make(map[string]database.IndexedFile)
+	if full {
+		if err := db.ClearIndexedFiles(ctx); err != nil {
+			return fmt.Errorf("failed to clear indexed files: %w", err)
+		}
+	} else if ix.indexed, err = db.IndexedFiles(ctx); err != nil {
+		return fmt.Errorf("failed to read indexed files: %w", err)
 	}
 
-	// Walk and index articles
-	var count, skipped, errors int
+	// Moved files keep their articles; git pairs renamed files with their
+	// old paths since the last indexed commit
+	head := gitHead(compendiumDir)
+	since := ""
+	if head != "" && !full {
+		if since, err = db.GetInfo(ctx, commitInfoKey); err != nil {
+			log.Printf("Warning: failed to read last indexed commit: %v", err)
+		}
+	}
+	ix.renames = gitRenames(compendiumDir, since)
+
+	// Walk and index articles, writing them in batches
+	seen := make(map[string]bool)
 	err = filepath.WalkDir(compendiumDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
-			// Skip _incoming and _debug directories
-			if d.Name() == "_incoming" || d.Name() == "_debug" {
+			if skipDir(d.Name()) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
-			return nil
-		}
-		if strings.ToLower(d.Name()) == "index.md" {
-			return nil
-		}
-
-		if err := processArticle(db, embedder, vectorDB, compendiumDir, path, withEmbeddings); err != nil {
-			log.Printf("Error processing %s: %v", filepath.Base(path), err)
-			errors++
-		} else {
-			count++
+		if relPath, ok := ix.relPath(path); ok {
+			seen[relPath] = true
 		}
+		ix.visit(path)
 		return nil
 	})
-
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Indexing complete: %d articles indexed, %d skipped, %d errors", count, skipped, errors)
+	// Articles whose files were deleted since the last run. Pending articles
+	// are written first, so that a moved file keeps its article.
+	ix.flush()
+	for relPath := range ix.indexed {
+		if !seen[relPath] {
+			ix.remove(relPath)
+		}
+	}
+	categories := len(ix.categories)
+	if err := ix.finish(); err != nil {
+		return err
+	}
+	if head != "" {
+		if err := db.SetInfo(ctx, commitInfoKey, head); err != nil {
+			log.Printf("Warning: failed to record indexed commit: %v", err)
+		}
+	}
+	ix.renames = nil
+
+	log.Printf("Indexing complete: %d articles indexed (%d moved), %d categories, %d unchanged articles skipped, %d removed, %d errors",
+		ix.count, ix.moved, categories, ix.skipped, ix.removed, ix.errors)
 
 	// Log stats
-	articleCount, _ := db.CountArticles()
-	sourceCount, _ := db.CountSources()
+	articleCount, _ := db.CountArticles(ctx)
+	sourceCount, _ := db.CountSources(ctx)
 	log.Printf("Database stats: %d articles, %d sources", articleCount, sourceCount)
 
+	if watch {
+		return ix.watch()
+	}
 	return nil
 }
 
-func processArticle(db *database.DB, embedder *embedding.Client, vectorDB *vectordb.Client, root, path string, withEmbeddings bool) error {
-	contentBytes, err := os.ReadFile(path)
+// indexer writes the articles and categories of a Compendium directory to
+// the database and, with embeddings, to Qdrant
+type indexer struct {
+	ctx            context.Context
+	root           string
+	db             *database.DB
+	withEmbeddings bool
+	embedders      *embedding.Set
+	strategy       embedding.Strategy
+	vectorDB       *vectordb.Client
+	points         *vectordb.Batch // Article points, upserted in batches
+	chunks         *chunk.Options  // Chunking of articles, nil when disabled
+
+	indexed    map[string]database.IndexedFile // File states by path, as of the last write
+	batch      []pendingArticle
+	files      []database.IndexedFile // States to record: written or touched files
+	categories []database.Category
+	articleIDs map[string]string // Article paths and IDs, to resolve highlights
+	renames    map[string]string // Old paths of files git reports as renamed, by new path
+
+	count, moved, skipped, removed, errors int
+}
+
+// skipDir reports whether a directory is left out of the index
+func skipDir(name string) bool {
+	return name == "_incoming" || name == "_debug"
+}
+
+// relPath returns the path of a markdown file relative to the Compendium
+// root, with slashes, and false for other files
+func (ix *indexer) relPath(path string) (string, bool) {
+	if !strings.HasSuffix(strings.ToLower(path), ".md") {
+		return "", false
+	}
+	relPath, err := filepath.Rel(ix.root, path)
 	if err != nil {
-		return err
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// upToDate reports whether an unchanged file needs no further work
+func (ix *indexer) upToDate(prev database.IndexedFile) bool {
+	return !ix.withEmbeddings || prev.EmbeddingText == ix.embeddingText()
+}
+
+// embeddingText names what the articles are embedded with, as recorded for
+// their files: the strategy, and whether they are also stored in chunks, so
+// that turning chunks on embeds every article again
+func (ix *indexer) embeddingText() string {
+	if ix.chunks != nil {
+		return string(ix.strategy) + "+chunks"
+	}
+	return string(ix.strategy)
+}
+
+// visit indexes the markdown file at path: an article, or the category of
+// its directory for index.md. Errors are logged and counted.
+func (ix *indexer) visit(path string) {
+	relPath, ok := ix.relPath(path)
+	if !ok {
+		return
+	}
+	if strings.ToLower(filepath.Base(path)) == "index.md" {
+		category, err := processCategory(ix.root, path)
+		if err != nil {
+			log.Printf("Error processing %s: %v", path, err)
+			ix.errors++
+			return
+		}
+		ix.categories = append(ix.categories, category)
+		return
 	}
 
-	fm, body, err := parse(contentBytes)
+	info, err := os.Stat(path)
 	if err != nil {
-		return err
+		log.Printf("Error processing %s: %v", filepath.Base(path), err)
+		ix.errors++
+		return
+	}
+	file := database.IndexedFile{Path: relPath, ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+	prev, known := ix.indexed[relPath]
+	if known && prev.ModTime == file.ModTime && prev.Size == file.Size && ix.upToDate(prev) {
+		ix.articleIDs[prev.ArticleID] = prev.ArticleID
+		ix.articleIDs[relPath] = prev.ArticleID
+		ix.skipped++
+		return
 	}
 
-	// Defaults
-	if fm.Title == "" {
-		// Fallback to H1
-		lines := strings.Split(body, "\n")
-		for _, l := range lines {
-			if strings.HasPrefix(l, "# ") {
-				fm.Title = strings.TrimSpace(l[2:])
-				break
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Error processing %s: %v", filepath.Base(path), err)
+		ix.errors++
+		return
+	}
+	file.Hash = database.ContentHash(content)
+	moved := false
+	if !known {
+		if from, ok := ix.movedFrom(relPath, file.Hash); ok {
+			log.Printf("%s moved from %s", relPath, from)
+			prev, known = ix.indexed[from], true
+			moved = prev.Hash == file.Hash && ix.upToDate(prev)
+			if moved {
+				file.EmbeddingText = prev.EmbeddingText
 			}
 		}
-		if fm.Title == "" {
-			fm.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	} else if prev.Hash == file.Hash {
+		// Touched but not changed
+		file.ArticleID, file.EmbeddingText = prev.ArticleID, prev.EmbeddingText
+		if ix.upToDate(prev) {
+			ix.articleIDs[prev.ArticleID] = prev.ArticleID
+			ix.articleIDs[relPath] = prev.ArticleID
+			ix.files = append(ix.files, file)
+			ix.skipped++
+			return
 		}
 	}
 
-	relPath, err := filepath.Rel(root, path)
+	article, err := processArticle(relPath, content)
 	if err != nil {
+		log.Printf("Error processing %s: %v", filepath.Base(path), err)
+		ix.errors++
+		return
+	}
+	if known && prev.ArticleID != "" && article.Meta["id"] == "" {
+		// Without a frontmatter ID, the article keeps the ID it was first
+		// indexed with, its path then, wherever the file moves
+		article.ID = prev.ArticleID
+	}
+	if known && prev.ArticleID != "" && prev.ArticleID != article.ID && !ix.claimed(prev.ArticleID, relPath) {
+		// The frontmatter ID changed: the old article is gone
+		ix.deleteArticle(prev.ArticleID)
+	}
+	file.ArticleID = article.ID
+	ix.articleIDs[article.ID] = article.ID
+	ix.articleIDs[article.Path] = article.ID
+	ix.batch = append(ix.batch, pendingArticle{article: article, file: file, moved: moved})
+	if len(ix.batch) >= insertBatchSize {
+		ix.flush()
+	}
+}
+
+// flush writes the pending articles, embeds them and records the state of
+// their files
+func (ix *indexer) flush() {
+	if len(ix.batch) > 0 {
+		articles := make([]database.Article, len(ix.batch))
+		for i, p := range ix.batch {
+			articles[i] = p.article
+		}
+		if err := ix.db.InsertArticles(ix.ctx, articles); err != nil {
+			// One bad article rolls back the whole batch: insert the
+			// articles one by one, so only the bad ones are lost
+			log.Printf("Error inserting batch of %d articles, retrying one at a time: %v", len(ix.batch), err)
+			var inserted []pendingArticle
+			for _, p := range ix.batch {
+				if err := ix.db.InsertArticles(ix.ctx, []database.Article{p.article}); err != nil {
+					log.Printf("Error inserting article %s (%s): %v", p.article.ID, p.file.Path, err)
+					ix.errors++
+					continue
+				}
+				inserted = append(inserted, p)
+			}
+			ix.batch = inserted
+		}
+		ix.count += len(ix.batch)
+		for _, p := range ix.batch {
+			if p.moved {
+				ix.moved++
+			}
+			if ix.withEmbeddings && !(p.moved && ix.movePoints(p.article.ID, p.article.Path)) {
+				p.file.EmbeddingText = ""
+				if embedArticle(ix.ctx, ix.embedders, ix.strategy, ix.points, p.article) && ix.embedChunks(p.article) {
+					p.file.EmbeddingText = ix.embeddingText()
+				}
+			}
+			ix.files = append(ix.files, p.file)
+		}
+		ix.batch = ix.batch[:0]
+	}
+	if err := ix.db.SetIndexedFiles(ix.ctx, ix.files); err != nil {
+		log.Printf("Warning: failed to record %d indexed files: %v", len(ix.files), err)
+	} else {
+		for _, f := range ix.files {
+			ix.indexed[f.Path] = f
+		}
+	}
+	ix.files = ix.files[:0]
+}
+
+// finish flushes the pending articles and their embeddings, then writes the
+// pending categories with their highlights resolved. It returns the error of
+// embeddings that couldn't be stored; their articles are recorded as
+// embedded, so cmd/reconcile -fix has to store them.
+func (ix *indexer) finish() error {
+	ix.flush()
+	var err error
+	if ix.points != nil {
+		if err = ix.points.Flush(); err != nil {
+			err = fmt.Errorf("failed to store embeddings (run cmd/reconcile -fix): %w", err)
+		}
+	}
+
+	if len(ix.categories) == 0 {
 		return err
 	}
-	// Normalize path separators to slash
-	relPath = filepath.ToSlash(relPath)
+	for i := range ix.categories {
+		ix.categories[i].Highlights = resolveHighlights(ix.categories[i], ix.articleIDs)
+	}
+	if err := ix.db.InsertCategories(ix.ctx, ix.categories); err != nil {
+		log.Printf("Error inserting %d categories: %v", len(ix.categories), err)
+		ix.errors += len(ix.categories)
+	}
+	ix.categories = ix.categories[:0]
+	return err
+}
+
+// remove deletes the article of a file that no longer exists
+func (ix *indexer) remove(relPath string) {
+	prev, ok := ix.indexed[relPath]
+	if !ok {
+		return
+	}
+	if prev.ArticleID != "" && !ix.claimed(prev.ArticleID, relPath) {
+		ix.deleteArticle(prev.ArticleID)
+	}
+	if err := ix.db.DeleteIndexedFile(ix.ctx, relPath); err != nil {
+		log.Printf("Warning: failed to forget indexed file %s: %v", relPath, err)
+	}
+	delete(ix.indexed, relPath)
+	delete(ix.articleIDs, relPath)
+	ix.removed++
+}
+
+// claimed reports whether a file other than relPath holds the article with
+// the given ID, e.g. after the file was moved
+func (ix *indexer) claimed(id, relPath string) bool {
+	for p, f := range ix.indexed {
+		if f.ArticleID == id && p != relPath {
+			return true
+		}
+	}
+	for _, p := range ix.batch {
+		if p.article.ID == id && p.file.Path != relPath {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteArticle removes an article from the database and Qdrant
+func (ix *indexer) deleteArticle(id string) {
+	if err := ix.db.DeleteArticle(ix.ctx, id); err != nil {
+		log.Printf("Error deleting article %s: %v", id, err)
+		ix.errors++
+		return
+	}
+	delete(ix.articleIDs, id)
+	if ix.vectorDB != nil {
+		if err := ix.vectorDB.DeleteArticle(ix.ctx, id); err != nil {
+			log.Printf("Warning: failed to delete embeddings of %s: %v", id, err)
+		}
+	}
+}
+
+// processArticle parses the content of the article file at relPath (relative
+// to the Compendium root, with slashes)
+func processArticle(relPath string, content []byte) (database.Article, error) {
+	var fm FrontMatter
+	body, err := parse(content, &fm)
+	if err != nil {
+		return database.Article{}, err
+	}
+
+	// Defaults
+	if fm.Title == "" {
+		// Fallback to H1
+		fm.Title = heading(body)
+		if fm.Title == "" {
+			fm.Title = strings.TrimSuffix(path.Base(relPath), path.Ext(relPath))
+		}
+	}
 
 	id := fm.ID
 	if id == "" {
 		id = relPath
 	}
 
-	// Extract category from path
-	category := ""
-	parts := strings.Split(relPath, "/")
-	if len(parts) > 1 {
-		category = strings.Join(parts[:len(parts)-1], "/")
-	}
+	category := pathCategory(relPath)
 
 	// Build meta map
 	meta := make(map[string]interface{})
@@ -206,8 +550,7 @@ func processArticle(db *database.DB, embedder *embedding.Client, vectorDB *vecto
 		meta[k] = v
 	}
 
-	// Insert into database
-	article := database.Article{
+	return database.Article{
 		ID:      id,
 		Title:   fm.Title,
 		Path:    relPath,
@@ -216,64 +559,155 @@ func processArticle(db *database.DB, embedder *embedding.Client, vectorDB *vecto
 		Tags:    fm.Tags,
 		Meta:    meta,
 		Content: body,
+		Sources: fm.Sources,
+	}, nil
+}
+
+// processCategory parses the index.md of a directory into its category.
+// Highlights are resolved to article IDs once all articles are known.
+func processCategory(root, path string) (database.Category, error) {
+	contentBytes, err := os.ReadFile(path)
+	if err != nil {
+		return database.Category{}, err
 	}
 
-	if err := db.InsertArticle(article); err != nil {
-		return fmt.Errorf("insert article failed: %w", err)
+	var fm CategoryFrontMatter
+	body, err := parse(contentBytes, &fm)
+	if err != nil {
+		return database.Category{}, err
+	}
+
+	relDir, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return database.Category{}, err
+	}
+	relDir = filepath.ToSlash(relDir)
+	if relDir == "." {
+		relDir = ""
 	}
 
-	// Generate and store embedding if enabled
-	if withEmbeddings && embedder != nil && vectorDB != nil {
-		// Create text for embedding (title + summary + first part of content)
-		embeddingText := fm.Title
-		if fm.Summary != "" {
-			embeddingText += " " + fm.Summary
+	if fm.Title == "" {
+		fm.Title = heading(body)
+		if fm.Title == "" {
+			fm.Title = filepath.Base(filepath.Dir(path))
 		}
-		if len(body) > 0 {
-			// Add first 1000 chars of body
-			bodyPreview := body
-			if len(bodyPreview) > 1000 {
-				bodyPreview = bodyPreview[:1000]
+	}
+	if fm.Description == "" {
+		fm.Description = frontmatter.Summary(body, descriptionChars)
+	}
+
+	return database.Category{
+		Path:        relDir,
+		Title:       fm.Title,
+		Description: fm.Description,
+		Order:       fm.Order,
+		Highlights:  fm.Highlights,
+		Content:     body,
+	}, nil
+}
+
+// resolveHighlights maps a category's highlights to article IDs. An entry
+// is an article ID or a path, with or without .md, relative to the category
+// directory or the Compendium root. Unknown entries are logged and dropped.
+func resolveHighlights(cat database.Category, articleIDs map[string]string) []string {
+	var ids []string
+	for _, h := range cat.Highlights {
+		id := ""
+		for _, candidate := range []string{h, path.Join(cat.Path, h), path.Join(cat.Path, h) + ".md", h + ".md"} {
+			if id = articleIDs[candidate]; id != "" {
+				break
 			}
-			embeddingText += " " + bodyPreview
 		}
+		if id == "" {
+			log.Printf("Warning: category %q highlights unknown article %q", cat.Path, h)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
 
-		ctx := context.Background()
-		emb, err := embedder.Embed(ctx, embeddingText)
-		if err != nil {
-			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
-		} else {
-			payload := vectordb.ArticlePayload{
-				ID:       id,
-				Title:    fm.Title,
-				Path:     relPath,
-				Summary:  fm.Summary,
-				Tags:     fm.Tags,
-				Category: category,
-			}
-			if err := vectorDB.UpsertArticle(ctx, id, emb, payload); err != nil {
-				log.Printf("Warning: failed to store embedding for %s: %v", id, err)
-			}
+// heading returns the text of the first H1 of a markdown body, or ""
+func heading(body string) string {
+	for _, l := range strings.Split(body, "\n") {
+		if strings.HasPrefix(l, "# ") {
+			return strings.TrimSpace(l[2:])
 		}
 	}
+	return ""
+}
 
-	return nil
+// embedArticle generates the embeddings of a stored article and adds its
+// point to the batch, reporting whether it succeeded. Failures are logged;
+// the article stays searchable through FTS.
+func embedArticle(ctx context.Context, embedders *embedding.Set, strategy embedding.Strategy, points *vectordb.Batch, art database.Article) bool {
+	vectors, err := embedders.EmbedDocument(ctx, strategy, embedding.Document{
+		Title:   art.Title,
+		Summary: art.Summary,
+		Body:    art.Content,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to generate embedding for %s: %v", art.ID, err)
+		return false
+	}
+
+	payload := vectordb.ArticlePayload{
+		ID:        art.ID,
+		Title:     art.Title,
+		Path:      art.Path,
+		Summary:   art.Summary,
+		Tags:      art.Tags,
+		Category:  pathCategory(art.Path),
+		WordCount: database.WordCount(art.Content),
+	}
+	if err := points.AddArticle(vectordb.ArticlePoint{ID: art.ID, Vectors: vectors, Payload: payload}); err != nil {
+		log.Printf("Warning: failed to store embeddings: %v", err)
+	}
+	return true
+}
+
+// embedChunks replaces the chunks of an article when chunks are enabled,
+// reporting whether it succeeded. Failures are logged.
+func (ix *indexer) embedChunks(art database.Article) bool {
+	if ix.chunks == nil {
+		return true
+	}
+	points, err := chunk.Points(ix.ctx, ix.embedders, art, *ix.chunks)
+	if err != nil {
+		log.Printf("Warning: failed to generate chunk embeddings for %s: %v", art.ID, err)
+		return false
+	}
+	if err := ix.vectorDB.ReplaceArticleChunks(ix.ctx, art.ID, points); err != nil {
+		log.Printf("Warning: failed to store chunk embeddings for %s: %v", art.ID, err)
+		return false
+	}
+	return true
+}
+
+// pathCategory extracts an article's category (its directory) from its path
+func pathCategory(relPath string) string {
+	parts := strings.Split(relPath, "/")
+	if len(parts) > 1 {
+		return strings.Join(parts[:len(parts)-1], "/")
+	}
+	return ""
 }
 
-func parse(content []byte) (FrontMatter, string, error) {
+// parse decodes the frontmatter of a markdown file into fm and returns the
+// body
+func parse(content []byte, fm interface{}) (string, error) {
 	s := string(content)
-	var fm FrontMatter
 	body := s
 
 	if strings.HasPrefix(s, "---") {
 		parts := strings.SplitN(s, "---", 3)
 		if len(parts) >= 3 {
-			if err := yaml.Unmarshal([]byte(parts[1]), &fm); err != nil {
+			if err := yaml.Unmarshal([]byte(parts[1]), fm); err != nil {
 				// Continue with empty frontmatter
 				log.Printf("Warning: failed to parse frontmatter: %v", err)
 			}
 			body = strings.TrimSpace(parts[2])
 		}
 	}
-	return fm, body, nil
+	return body, nil
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// commitInfoKey is the db_info key of the Compendium commit the index was
+// last built from, the base of the renames git reports
+const commitInfoKey = "compendium_commit"
+
+// gitHead returns the commit checked out in the Compendium, or "" when it
+// isn't a git repository (or git isn't installed)
+func gitHead(root string) string {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitRenames returns the files git sees as renamed since a commit, in the
+// working tree, by new path: their old path, both relative to the
+// Compendium root. Git pairs a renamed file with its old path even when it
+// was also edited, which content hashes can't.
+func gitRenames(root, since string) map[string]string {
+	renames := make(map[string]string)
+	if since == "" {
+		return renames
+	}
+	out, err := exec.Command("git", "-C", root, "diff", "--relative", "-M", "--name-status", "--diff-filter=R", since).Output()
+	if err != nil {
+		log.Printf("Warning: failed to list renames since %s: %v", since, err)
+		return renames
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// R<similarity>\t<old>\t<new>
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) == 3 && strings.HasPrefix(fields[0], "R") {
+			renames[fields[2]] = fields[1]
+		}
+	}
+	return renames
+}
+
+// movedFrom returns the indexed path a new file at relPath was moved from:
+// the old path git reports, or else the one file with the same content
+// that no longer exists (preferring one of the same name when several
+// match). The old file's article must not have been taken by another file.
+func (ix *indexer) movedFrom(relPath, hash string) (string, bool) {
+	if old, ok := ix.renames[relPath]; ok && ix.vacated(old) {
+		return old, true
+	}
+	var candidates []string
+	for p, f := range ix.indexed {
+		if f.Hash == hash && ix.vacated(p) {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.Strings(candidates)
+	for _, p := range candidates {
+		if path.Base(p) == path.Base(relPath) {
+			return p, true
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return "", false
+}
+
+// vacated reports whether an indexed file is gone from disk with an
+// article no pending file has taken over
+func (ix *indexer) vacated(relPath string) bool {
+	f, ok := ix.indexed[relPath]
+	if !ok || f.ArticleID == "" {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(ix.root, filepath.FromSlash(relPath))); !os.IsNotExist(err) {
+		return false
+	}
+	for _, p := range ix.batch {
+		if p.article.ID == f.ArticleID {
+			return false
+		}
+	}
+	return true
+}
+
+// movePoints updates the path and category of a moved article's points,
+// whose vectors are still those of its content
+func (ix *indexer) movePoints(id, relPath string) bool {
+	payload := map[string]any{"path": relPath, "category": pathCategory(relPath)}
+	if err := ix.vectorDB.SetPayload(ix.ctx, vectordb.ArticlesCollection, []string{id}, payload); err != nil {
+		log.Printf("Warning: failed to move embeddings of %s: %v", id, err)
+		return false
+	}
+	if ix.chunks != nil {
+		if err := ix.vectorDB.SetPayloadWhere(ix.ctx, vectordb.ArticleChunksCollection, vectordb.Filter{"article_id": id}, payload); err != nil {
+			log.Printf("Warning: failed to move chunk embeddings of %s: %v", id, err)
+			return false
+		}
+	}
+	return true
+}
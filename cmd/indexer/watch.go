@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce is how long the watcher waits for a burst of file events (an
+// editor saving, a git checkout) to settle before indexing the changes
+const debounce = 500 * time.Millisecond
+
+// watch indexes the files of the Compendium directory as they are created,
+// changed or deleted, until interrupted
+func (ix *indexer) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := addDirs(watcher, ix.root); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", ix.root, err)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	log.Printf("Watching %s for changes", ix.root)
+
+	changed := make(map[string]bool) // Paths of changed files and directories
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if ev.Has(fsnotify.Create) {
+				// Directories are watched one by one, so new ones are added
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() && !skipDir(info.Name()) {
+					if err := addDirs(watcher, ev.Name); err != nil {
+						log.Printf("Warning: failed to watch %s: %v", ev.Name, err)
+					}
+				}
+			}
+			changed[ev.Name] = true
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Warning: watcher: %v", err)
+		case <-timer.C:
+			ix.update(changed)
+			clear(changed)
+		case <-stop:
+			log.Println("Stopping watch")
+			return nil
+		}
+	}
+}
+
+// addDirs watches dir and its subdirectories, except skipped ones
+func addDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && skipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// update indexes a round of changed paths. New directories are indexed in
+// full, since their files may have been created before the directory was
+// watched. Deleted files are removed last, so that a file moved within the
+// round keeps its article.
+func (ix *indexer) update(changed map[string]bool) {
+	ix.count, ix.skipped, ix.removed, ix.errors = 0, 0, 0, 0
+
+	paths := make([]string, 0, len(changed))
+	for path := range changed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var files, gone []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			gone = append(gone, path)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error processing %s: %v", path, err)
+			ix.errors++
+			continue
+		}
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+		if skipDir(info.Name()) {
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			add(p)
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error processing %s: %v", path, err)
+			ix.errors++
+		}
+	}
+
+	for _, path := range files {
+		ix.visit(path)
+	}
+	ix.flush()
+	for _, path := range gone {
+		ix.removeUnder(path)
+	}
+	categories := len(ix.categories)
+	if err := ix.finish(); err != nil {
+		log.Printf("Error: %v", err)
+		ix.errors++
+	}
+
+	if ix.count+categories+ix.removed+ix.errors > 0 {
+		log.Printf("Indexed changes: %d articles indexed, %d categories, %d removed, %d errors",
+			ix.count, categories, ix.removed, ix.errors)
+	}
+}
+
+// removeUnder removes the articles of a deleted file, or of every file of
+// a deleted directory
+func (ix *indexer) removeUnder(path string) {
+	relPath, err := filepath.Rel(ix.root, path)
+	if err != nil {
+		return
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	var gone []string
+	for p := range ix.indexed {
+		if p == relPath || strings.HasPrefix(p, relPath+"/") {
+			gone = append(gone, p)
+		}
+	}
+	for _, p := range gone {
+		ix.remove(p)
+	}
+}
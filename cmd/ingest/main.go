@@ -1,6 +1,7 @@
 // Package main provides the source ingestion pipeline for the knowledge-base.
-// It reads source markdown files from _incoming/sources/, generates embeddings,
-// stores them in SQLite + Qdrant, and optionally deletes the source files.
+// It reads source markdown files (and JSONL/CSV batch files) from
+// _incoming/sources/, generates embeddings, stores them in SQLite + Qdrant,
+// and optionally deletes the source files.
 package main
 
 import (
@@ -9,17 +10,48 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gitopedia/knowledge-base/internal/database"
 	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/geo"
+	"github.com/gitopedia/knowledge-base/internal/redact"
+	"github.com/gitopedia/knowledge-base/internal/scholar"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/sourcebatch"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
 	"github.com/gitopedia/knowledge-base/internal/vectordb"
+	"github.com/gitopedia/knowledge-base/internal/webpage"
 	"gopkg.in/yaml.v3"
 )
 
+// insertBatchSize is how many sources are written per transaction
+const insertBatchSize = 100
+
+// sourceEntry is a source to ingest: a markdown file, or one record of a
+// JSONL or CSV batch file
+type sourceEntry struct {
+	fm   SourceFrontMatter
+	body string
+	path string
+	name string // For logs: the file name, with the line of batch records
+}
+
+// pendingSource is a parsed and embedded source waiting to be written
+type pendingSource struct {
+	src     database.Source
+	vectors vectordb.Vectors
+	path    string
+}
+
 // SourceFrontMatter represents the YAML front matter of a source file
 type SourceFrontMatter struct {
 	ID             string   `yaml:"id"`
@@ -29,6 +61,7 @@ type SourceFrontMatter struct {
 	Type           string   `yaml:"type"`
 	RelatedArticle string   `yaml:"related_article"`
 	Created        string   `yaml:"created"`
+	Published      string   `yaml:"published"`
 	Tags           []string `yaml:"tags"`
 	People         []string `yaml:"people"`
 	Orgs           []string `yaml:"orgs"`
@@ -36,6 +69,11 @@ type SourceFrontMatter struct {
 	Summary        string   `yaml:"summary"`
 	Model          string   `yaml:"model"`
 	Language       string   `yaml:"language"`
+	Visibility     string   `yaml:"visibility"`
+	Owner          string   `yaml:"owner"`
+	// Summary style profile the summary was written with (see
+	// KB_SUMMARY_STYLES)
+	SummaryStyle string `yaml:"summary_style"`
 }
 
 func main() {
@@ -44,8 +82,38 @@ func main() {
 	dbPath := flag.String("db", "", "Path to SQLite database")
 	deleteAfter := flag.Bool("delete", false, "Delete source files after ingestion")
 	dryRun := flag.Bool("dry-run", false, "Show what would be done without making changes")
+	scanFlag := flag.String("scan", "off", "Scan summaries for secrets and personal data: off, flag, or redact")
+	fetchMetadata := flag.Bool("metadata", true, "Fetch paper metadata from Crossref/arXiv for DOI and arXiv URLs")
+	geocode := flag.Bool("geocode", false, "Geocode the places of sources (GEOCODER_URL, default Nominatim)")
+	columnsFlag := flag.String("columns", "", "Map columns of JSONL/CSV batch files to source fields, e.g. Link=url,Name=title")
+	dedupeThreshold := flag.Float64("dedupe-threshold", 0, "Cosine similarity at or above which a source counts as a near-duplicate of an existing one (0 disables)")
+	dedupeFlag := flag.String("dedupe", "flag", "What to do with near-duplicates: flag (ingest and report) or skip")
+	fetchPages := flag.Bool("fetch", false, "Fetch the page of sources without a summary (e.g. files holding only a URL) and summarize it with GENERATION_MODEL")
 	flag.Parse()
 
+	scanMode, ok := redact.ParseMode(*scanFlag)
+	if !ok {
+		log.Fatalf("Invalid -scan mode: %s", *scanFlag)
+	}
+	strategies, err := embedding.StrategiesFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	columns, err := sourcebatch.ParseColumns(*columnsFlag)
+	if err != nil {
+		log.Fatalf("Invalid -columns: %v", err)
+	}
+	if *dedupeFlag != "flag" && *dedupeFlag != "skip" {
+		log.Fatalf("Invalid -dedupe mode: %s", *dedupeFlag)
+	}
+	if *dedupeThreshold < 0 || *dedupeThreshold > 1 {
+		log.Fatalf("Invalid -dedupe-threshold: %v (must be between 0 and 1)", *dedupeThreshold)
+	}
+	styles, err := summarystyle.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid KB_SUMMARY_STYLES: %v", err)
+	}
+
 	// Determine sources directory
 	if *sourcesDir == "" {
 		// Try to find it relative to current directory or via env var
@@ -71,6 +139,14 @@ func main() {
 	log.Printf("Database path: %s", *dbPath)
 	log.Printf("Delete after ingestion: %v", *deleteAfter)
 	log.Printf("Dry run: %v", *dryRun)
+	log.Printf("Sensitive data scan: %s", scanMode)
+	log.Printf("Fetch paper metadata: %v", *fetchMetadata)
+	log.Printf("Geocode places: %v", *geocode)
+	log.Printf("Embedding text: %s", strategies.Sources)
+	log.Printf("Fetch and summarize pages: %v", *fetchPages)
+	if *dedupeThreshold > 0 {
+		log.Printf("Near-duplicates: %s at similarity >= %.2f", *dedupeFlag, *dedupeThreshold)
+	}
 
 	// Check sources directory exists
 	if _, err := os.Stat(*sourcesDir); os.IsNotExist(err) {
@@ -79,13 +155,19 @@ func main() {
 		return
 	}
 
+	// A long ingestion waits out embedding provider restarts
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
 	// Initialize database
-	var db *database.DB
-	var vectorDB *vectordb.Client
-	var embedder *embedding.Client
+	var db store.Store
+	var vectorDB store.VectorStore
+	var embedders *embedding.Set
+	var scholarClient *scholar.Client
+	var geocoder *geo.Client
+	var fetcher *webpage.Fetcher
+	var summarizer generation.Generator
 
 	if !*dryRun {
-		var err error
 		db, err = database.Open(*dbPath)
 		if err != nil {
 			log.Fatalf("Failed to open database: %v", err)
@@ -99,26 +181,46 @@ func main() {
 		}
 		defer vectorDB.Close()
 
-		ctx := context.Background()
 		if err := vectorDB.EnsureCollections(ctx); err != nil {
 			log.Fatalf("Failed to ensure Qdrant collections: %v", err)
 		}
 
-		// Initialize embedding client
-		embedder = embedding.NewClient()
-		log.Printf("Embedding model: %s", embedder.Model())
+		// Initialize embedding clients (one per vector space)
+		embedders = embedding.NewSet(vectorDB.VectorNames()...)
+		log.Printf("Embedding models: %s", strings.Join(embedders.Models(), ", "))
+		if err := embedding.CheckStrategy(ctx, db, "sources", strategies.Sources); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		if *fetchMetadata {
+			scholarClient = scholar.NewClient()
+		}
+		if *geocode {
+			geocoder = geo.NewClient()
+		}
+		if *fetchPages {
+			summarizer, err = generation.FromEnv()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if summarizer == nil {
+				log.Fatal("-fetch needs a generation model to summarize pages (GENERATION_MODEL)")
+			}
+			log.Printf("Summarizing pages with %s", summarizer.Model())
+			fetcher = webpage.NewFetcher()
+		}
 	}
 
 	// Walk sources directory
 	var sourceFiles []string
-	err := filepath.WalkDir(*sourcesDir, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(*sourcesDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
 			return nil
 		}
-		if strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
+		if strings.HasSuffix(strings.ToLower(d.Name()), ".md") || sourcebatch.FormatOf(d.Name()) != "" {
 			sourceFiles = append(sourceFiles, path)
 		}
 		return nil
@@ -129,25 +231,120 @@ func main() {
 
 	log.Printf("Found %d source files", len(sourceFiles))
 
-	// Process each source file
 	var processed, skipped, errors int
 	var filesToDelete []string
+	scanReport := make(map[string][]redact.Finding)
+	var dupReport []string
+	// Files with a record that failed or was invalid are kept, even with
+	// -delete
+	keptFiles := make(map[string]bool)
+	fail := func(path string) {
+		errors++
+		keptFiles[path] = true
+	}
+	skip := func(path string) {
+		skipped++
+		keptFiles[path] = true
+	}
 
+	// Parse each source file into the sources to ingest
+	var entries []sourceEntry
 	for _, path := range sourceFiles {
-		log.Printf("Processing: %s", filepath.Base(path))
+		format := sourcebatch.FormatOf(path)
+		if format == "" {
+			fm, body, err := parseSourceFile(path)
+			if err != nil {
+				log.Printf("Error parsing %s: %v", filepath.Base(path), err)
+				fail(path)
+				continue
+			}
+			entries = append(entries, sourceEntry{fm: fm, body: body, path: path, name: filepath.Base(path)})
+			continue
+		}
 
-		// Parse source file
-		fm, body, err := parseSourceFile(path)
+		records, err := readBatchFile(path, format, columns)
 		if err != nil {
-			log.Printf("  Error parsing: %v", err)
-			errors++
+			log.Printf("Error parsing %s: %v", filepath.Base(path), err)
+			fail(path)
 			continue
 		}
+		log.Printf("%s: %d sources", filepath.Base(path), len(records))
+		for _, rec := range records {
+			entries = append(entries, sourceEntry{
+				fm:   frontMatterOf(rec),
+				path: path,
+				name: fmt.Sprintf("%s:%d", filepath.Base(path), rec.Line),
+			})
+		}
+	}
+
+	// Sources are written in batches: one SQLite transaction per batch, then
+	// one Qdrant upsert of the stored sources' points
+	var batch []pendingSource
+	batchURLs := make(map[string]bool)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		srcs := make([]database.Source, len(batch))
+		for i, p := range batch {
+			srcs[i] = p.src
+		}
+		if err := db.InsertSources(ctx, srcs); err != nil {
+			// One bad source rolls back the whole batch: store the sources
+			// one by one, so only the bad ones are lost
+			log.Printf("Error storing batch of %d sources in SQLite, retrying one at a time: %v", len(batch), err)
+			var stored []pendingSource
+			for _, p := range batch {
+				if err := db.InsertSources(ctx, []database.Source{p.src}); err != nil {
+					log.Printf("Error storing source %s (%s) in SQLite: %v", p.src.ID, filepath.Base(p.path), err)
+					fail(p.path)
+					continue
+				}
+				stored = append(stored, p)
+			}
+			batch = stored
+		}
+		if len(batch) > 0 {
+			points := make([]vectordb.SourcePoint, len(batch))
+			for i, p := range batch {
+				src := p.src
+				points[i] = vectordb.SourcePoint{ID: src.ID, Vectors: p.vectors, Payload: vectordb.NewSourcePayload(src)}
+
+				log.Printf("  Ingested: ID=%s", src.ID)
+				processed++
+
+				if *deleteAfter {
+					filesToDelete = append(filesToDelete, p.path)
+				}
+			}
+			if err := vectorDB.UpsertSources(ctx, points); err != nil {
+				log.Printf("  Warning: failed to store batch of %d sources in Qdrant: %v", len(points), err)
+				// Don't fail - SQLite has the data
+			}
+		}
+		batch = batch[:0]
+		clear(batchURLs)
+	}
+
+	for _, entry := range entries {
+		fm, body, path := entry.fm, entry.body, entry.path
+		log.Printf("Processing: %s", entry.name)
+
+		// With -fetch, a file holding only a URL is the source of that URL
+		if *fetchPages && fm.Summary == "" {
+			if u := bareURL(body); u != "" {
+				if fm.URL == "" {
+					fm.URL = u
+				}
+				body = ""
+			}
+		}
 
 		// Validate required fields
 		if fm.URL == "" {
 			log.Printf("  Skipping: no URL")
-			skipped++
+			skip(path)
 			continue
 		}
 
@@ -156,15 +353,59 @@ func main() {
 		if summary == "" {
 			summary = strings.TrimSpace(body)
 		}
-		if summary == "" {
+		// With -fetch, the summary is written from the page once the URL is
+		// known to be new
+		fetchPage := *fetchPages && summary == ""
+		if summary == "" && !fetchPage {
 			log.Printf("  Skipping: no summary content")
-			skipped++
+			skip(path)
+			continue
+		}
+
+		// Scan for secrets and personal data before anything is stored or embedded
+		summary, findings := redact.Apply(scanMode, summary)
+		if len(findings) > 0 {
+			scanReport[entry.name] = findings
+			log.Printf("  Sensitive data: %d finding(s) (%s)", len(findings), scanMode)
+		}
+
+		publishedAt, ok := database.NormalizePublishedAt(fm.Published)
+		if !ok {
+			log.Printf("  Warning: ignoring unparsable published date %q", fm.Published)
+		}
+
+		if !database.ValidVisibility(fm.Visibility) {
+			log.Printf("  Skipping: unknown visibility %q", fm.Visibility)
+			skip(path)
+			continue
+		}
+		if fm.Visibility == database.VisibilityPrivate && fm.Owner == "" {
+			log.Printf("  Skipping: private source without owner")
+			skip(path)
+			continue
+		}
+		fm.Type = database.NormalizeSourceType(fm.Type)
+		if !database.ValidSourceType(fm.Type) {
+			log.Printf("  Skipping: unknown type %q", fm.Type)
+			skip(path)
 			continue
 		}
+		// The profile is stored as resolved now, so later changes to the
+		// profile file don't change how the summary was written
+		var style *summarystyle.Profile
+		if fm.SummaryStyle != "" {
+			p, ok := styles.Get(fm.SummaryStyle)
+			if !ok {
+				log.Printf("  Skipping: unknown summary style %q", fm.SummaryStyle)
+				skip(path)
+				continue
+			}
+			style = &p
+		}
 
-		// Extract topic from related_article or filename
+		// Extract topic from related_article or the markdown filename
 		topic := fm.RelatedArticle
-		if topic == "" {
+		if topic == "" && sourcebatch.FormatOf(path) == "" {
 			// Try to extract from filename (e.g., "quantum-mechanics--example-com-1.md")
 			base := filepath.Base(path)
 			base = strings.TrimSuffix(base, ".md")
@@ -173,22 +414,41 @@ func main() {
 				topic = parts[0]
 			}
 		}
+		topic = slug.Make(topic)
+		if fetchPage && style == nil {
+			p, err := styles.Resolve("", topic)
+			if err != nil {
+				log.Printf("  Skipping: %v", err)
+				skip(path)
+				continue
+			}
+			style = &p
+		}
 
 		if *dryRun {
+			if fetchPage {
+				log.Printf("  Would fetch and summarize: URL=%s, Style=%s", fm.URL, style.Name)
+			}
 			log.Printf("  Would ingest: ID=%s, URL=%s, Topic=%s", fm.ID, fm.URL, topic)
 			processed++
 			continue
 		}
 
-		// Check if source already exists (by URL)
-		existing, err := db.GetSourceByURL(fm.URL)
+		// Check if source already exists (by URL, ignoring tracking
+		// parameters and other differences CanonicalURL drops)
+		existing, err := db.GetSourceByCanonicalURL(ctx, fm.URL)
 		if err != nil {
 			log.Printf("  Error checking existing: %v", err)
-			errors++
+			fail(path)
 			continue
 		}
-		if existing != nil {
-			log.Printf("  Skipping: URL already exists (ID=%s)", existing.ID)
+		canonicalURL := database.CanonicalURL(fm.URL)
+		if existing != nil || batchURLs[canonicalURL] {
+			if existing != nil {
+				log.Printf("  Skipping: URL already exists (ID=%s)", existing.ID)
+			} else {
+				log.Printf("  Skipping: URL already in this run")
+			}
 			skipped++
 			// Still mark for deletion if requested
 			if *deleteAfter {
@@ -198,7 +458,7 @@ func main() {
 		}
 
 		// Generate ID if not present
-		id := fm.ID
+		id := slug.ID(fm.ID)
 		if id == "" {
 			id = fmt.Sprintf("src-%d", time.Now().UnixNano())
 		}
@@ -209,58 +469,128 @@ func main() {
 			createdAt = time.Now().UTC().Format(time.RFC3339)
 		}
 
-		// Generate embedding
-		ctx := context.Background()
-		emb, err := embedder.Embed(ctx, summary)
-		if err != nil {
-			log.Printf("  Error generating embedding: %v", err)
-			errors++
-			continue
+		var content string
+		if fetchPage {
+			page, err := fetcher.Fetch(ctx, fm.URL)
+			if err != nil {
+				log.Printf("  Error fetching page: %v", err)
+				fail(path)
+				continue
+			}
+			if summary, err = webpage.Summarize(ctx, summarizer, *style, page); err != nil || summary == "" {
+				log.Printf("  Error summarizing page: %v", err)
+				fail(path)
+				continue
+			}
+			log.Printf("  Summarized %d words of %s with %s", database.WordCount(page.Text), page.URL, summarizer.Model())
+			// The summary and the page text are scanned like written summaries
+			var findings, contentFindings []redact.Finding
+			summary, findings = redact.Apply(scanMode, summary)
+			content, contentFindings = redact.Apply(scanMode, page.Text)
+			for i := range contentFindings {
+				contentFindings[i].Field = "content"
+			}
+			if findings = append(findings, contentFindings...); len(findings) > 0 {
+				scanReport[entry.name] = findings
+				log.Printf("  Sensitive data: %d finding(s) (%s)", len(findings), scanMode)
+			}
+			fm.Model = summarizer.Model()
+			if fm.Title == "" {
+				fm.Title = page.Title
+			}
+			if fm.Language == "" {
+				fm.Language = page.Language
+			}
+			if publishedAt == "" && page.PublishedAt != "" {
+				publishedAt, _ = database.NormalizePublishedAt(page.PublishedAt)
+			}
 		}
 
-		// Store in SQLite
 		src := database.Source{
-			ID:        id,
-			URL:       fm.URL,
-			Title:     fm.Title,
-			Topic:     topic,
-			Summary:   summary,
-			Language:  fm.Language,
-			Model:     fm.Model,
-			CreatedAt: createdAt,
-			Tags:      fm.Tags,
-		}
-		if err := db.InsertSource(src); err != nil {
-			log.Printf("  Error storing in SQLite: %v", err)
-			errors++
-			continue
+			ID:          id,
+			URL:         fm.URL,
+			Title:       fm.Title,
+			Topic:       topic,
+			Summary:     summary,
+			Language:    fm.Language,
+			Model:       fm.Model,
+			CreatedAt:   createdAt,
+			PublishedAt: publishedAt,
+			Tags:        fm.Tags,
+			Visibility:  fm.Visibility,
+			Owner:       fm.Owner,
+			Type:        fm.Type,
+			People:      fm.People,
+			Orgs:        fm.Orgs,
+			Places:      fm.Places,
+			Content:     content,
+
+			SummaryStyle: style,
 		}
-
-		// Store in Qdrant
-		payload := vectordb.SourcePayload{
-			ID:        id,
-			URL:       fm.URL,
-			Title:     fm.Title,
-			Topic:     topic,
-			Summary:   summary,
-			Language:  fm.Language,
-			Model:     fm.Model,
-			CreatedAt: createdAt,
+		// Links in the body count even when the summary comes from the
+		// frontmatter; redaction applies to them too
+		if linkText, _ := redact.Apply(scanMode, body); linkText != "" {
+			src.Links = database.ExtractLinks(linkText)
+		}
+		// Merge canonical metadata of papers (DOI or arXiv URLs)
+		if scholarClient != nil {
+			if found, err := scholarClient.Enrich(ctx, &src); err != nil {
+				log.Printf("  Warning: failed to fetch paper metadata: %v", err)
+			} else if found {
+				log.Printf("  Paper metadata: %s", src.Publication.Venue)
+			}
+		}
+		if geocoder != nil {
+			if n, err := geocoder.Locate(ctx, &src); err != nil {
+				log.Printf("  Warning: failed to geocode places: %v", err)
+			} else if n > 0 {
+				log.Printf("  Geocoded %d place(s)", n)
+			}
 		}
-		if err := vectorDB.UpsertSource(ctx, id, emb, payload); err != nil {
-			log.Printf("  Warning: failed to store in Qdrant: %v", err)
-			// Don't fail - SQLite has the data
+
+		// Generate embeddings, from the official abstract when there is one
+		vectors, err := embedders.EmbedDocument(ctx, strategies.Sources, embedding.Document{
+			Title:   src.Title,
+			Summary: src.EmbeddingText(),
+			Body:    src.Summary,
+		})
+		if err != nil {
+			log.Printf("  Error generating embedding: %v", err)
+			fail(path)
+			continue
 		}
 
-		log.Printf("  Ingested: ID=%s", id)
-		processed++
+		if *dedupeThreshold > 0 {
+			dupID, similarity, err := nearDuplicate(ctx, vectorDB, embedders.Default().Model(), vectors, src.Owner, batch)
+			if err != nil {
+				log.Printf("  Warning: failed to check for near-duplicates: %v", err)
+			} else if dupID != "" && float64(similarity) >= *dedupeThreshold {
+				dupReport = append(dupReport, fmt.Sprintf("%s: similar to %s (%.3f)", entry.name, dupID, similarity))
+				if *dedupeFlag == "skip" {
+					log.Printf("  Skipping: near-duplicate of %s (similarity %.3f)", dupID, similarity)
+					skip(path)
+					continue
+				}
+				log.Printf("  Near-duplicate of %s (similarity %.3f)", dupID, similarity)
+			}
+		}
 
-		if *deleteAfter {
-			filesToDelete = append(filesToDelete, path)
+		batch = append(batch, pendingSource{src: src, vectors: vectors, path: path})
+		batchURLs[canonicalURL] = true
+		if len(batch) >= insertBatchSize {
+			flush()
 		}
 	}
+	if !*dryRun {
+		flush()
+	}
 
-	// Delete processed files if requested
+	// Delete processed files if requested. A batch file is listed once per
+	// record, and only deleted when none of its records was kept back.
+	slices.Sort(filesToDelete)
+	filesToDelete = slices.DeleteFunc(slices.Compact(filesToDelete), func(path string) bool {
+		return keptFiles[path]
+	})
 	if *deleteAfter && len(filesToDelete) > 0 {
 		log.Printf("Deleting %d processed source files...", len(filesToDelete))
 		for _, path := range filesToDelete {
@@ -273,6 +603,131 @@ func main() {
 	}
 
 	log.Printf("Ingestion complete: %d processed, %d skipped, %d errors", processed, skipped, errors)
+
+	if len(scanReport) > 0 {
+		logScanReport(scanReport, scanMode)
+	}
+	if len(dupReport) > 0 {
+		action := "flagged"
+		if *dedupeFlag == "skip" {
+			action = "skipped"
+		}
+		log.Printf("Near-duplicate report: %d source(s) %s", len(dupReport), action)
+		for _, line := range dupReport {
+			log.Printf("  %s", line)
+		}
+	}
+}
+
+// nearDuplicate returns the source most similar to a new source's vectors,
+// and its cosine similarity: the nearest stored source the owner can see,
+// or a source still waiting in the batch. The default model's vector is
+// compared; the ID is empty when there is nothing to compare with.
+func nearDuplicate(ctx context.Context, vectorDB store.VectorStore, model string, vectors vectordb.Vectors, owner string, batch []pendingSource) (string, float32, error) {
+	emb := vectors[model]
+	if emb == nil {
+		return "", 0, nil
+	}
+
+	var bestID string
+	var best float32
+	for _, p := range batch {
+		if sim := embedding.CosineSimilarity(emb, p.vectors[model]); bestID == "" || sim > best {
+			bestID, best = p.src.ID, sim
+		}
+	}
+
+	opts := vectordb.SearchOptions{User: owner}
+	if len(vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = model
+	}
+	results, err := vectorDB.SearchSources(ctx, emb, 1, opts)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(results) == 0 {
+		return bestID, best, nil
+	}
+	// Scores depend on the collection's distance, so the similarity is
+	// computed from the stored vector
+	stored, err := vectorDB.GetSourceVector(ctx, results[0].ID, opts.Vector)
+	if err != nil {
+		return "", 0, err
+	}
+	if sim := embedding.CosineSimilarity(emb, stored); stored != nil && (bestID == "" || sim > best) {
+		bestID, best = results[0].ID, sim
+	}
+	return bestID, best, nil
+}
+
+// logScanReport summarizes sensitive data findings per file
+func logScanReport(report map[string][]redact.Finding, mode redact.Mode) {
+	files := make([]string, 0, len(report))
+	total := 0
+	for file, findings := range report {
+		files = append(files, file)
+		total += len(findings)
+	}
+	sort.Strings(files)
+
+	action := "flagged"
+	if mode == redact.ModeRedact {
+		action = "redacted"
+	}
+	log.Printf("Sensitive data report: %d finding(s) %s in %d file(s)", total, action, len(files))
+	for _, file := range files {
+		for _, f := range report[file] {
+			log.Printf("  %s: %s at offset %d (%s)", file, f.Rule, f.Offset, f.Hint)
+		}
+	}
+}
+
+// bareURL returns the URL a source body consists of, or "" if the body is
+// anything else
+func bareURL(body string) string {
+	body = strings.TrimSpace(body)
+	if strings.ContainsAny(body, " \t\n") {
+		return ""
+	}
+	u, err := url.Parse(body)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return body
+}
+
+// readBatchFile reads the records of a JSONL or CSV batch file
+func readBatchFile(path, format string, columns map[string]string) ([]sourcebatch.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return sourcebatch.Decode(f, format, columns)
+}
+
+// frontMatterOf converts a batch record to the frontmatter of the same
+// source in markdown
+func frontMatterOf(rec sourcebatch.Record) SourceFrontMatter {
+	return SourceFrontMatter{
+		ID:             rec.ID,
+		Title:          rec.Title,
+		URL:            rec.URL,
+		Type:           rec.Type,
+		RelatedArticle: rec.Topic,
+		Created:        rec.Created,
+		Published:      rec.Published,
+		Tags:           rec.Tags,
+		People:         rec.People,
+		Orgs:           rec.Orgs,
+		Places:         rec.Places,
+		Summary:        rec.Summary,
+		Model:          rec.Model,
+		Language:       rec.Language,
+		Visibility:     rec.Visibility,
+		Owner:          rec.Owner,
+		SummaryStyle:   rec.SummaryStyle,
+	}
 }
 
 // parseSourceFile reads and parses a source markdown file
@@ -299,4 +754,3 @@ func parseSourceFile(path string) (SourceFrontMatter, string, error) {
 
 	return fm, body, nil
 }
-
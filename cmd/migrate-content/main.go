@@ -0,0 +1,75 @@
+// Package main provides the content migration. It moves the full texts of
+// sources and articles between the storage layouts: inside SQLite, in its
+// FTS indexes (the default), in a directory (file:///path) or in an
+// S3-compatible bucket (s3://bucket/prefix). The layout is recorded in the
+// database, so the server and every other command find the texts where
+// they were moved. Stop the server and other writers first.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/content"
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	to := flag.String("to", "", "Where the texts go: sqlite, file:///dir or s3://bucket/prefix[?endpoint=URL&region=REGION]")
+	flag.Parse()
+
+	if *to == "" {
+		log.Fatal("Missing -to")
+	}
+	if err := run(*dbPath, *to); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath, to string) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	log.Printf("Moving texts from %s to %s", db.ContentLocation(), to)
+	start := time.Now()
+	move, err := db.MoveContent(ctx, to)
+	if err != nil {
+		return err
+	}
+	log.Printf("Content migration complete: %d sources and %d articles, %d bytes, moved to %s (%s)",
+		move.Sources, move.Articles, move.Bytes, move.To, time.Since(start).Round(time.Millisecond))
+	if move.From != content.SQLite {
+		log.Printf("The copies in %s are no longer used and can be deleted", move.From)
+		return nil
+	}
+
+	// The texts left SQLite; give their space back
+	if err := db.Vacuum(ctx); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	log.Printf("Vacuumed database")
+	return nil
+}
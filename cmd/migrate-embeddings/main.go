@@ -0,0 +1,411 @@
+// Package main provides the embedding migration for a change of
+// EMBEDDING_MODEL (or EMBEDDING_MODELS). It re-embeds every source and
+// article of SQLite into new Qdrant collections, created with the
+// dimensions of the new models, then atomically points the collection names
+// the server uses at them through aliases. An interrupted migration resumes
+// where it stopped when run again with the same -version.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/claims"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// progressEvery is how often progress is logged, in records
+const progressEvery = 100
+
+// versionChars are the characters kept in a version derived from model names
+var versionChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	version := flag.String("version", "", "Suffix of the new collections, e.g. v2 (default: derived from the embedding models); rerun with the same version to resume")
+	switchAliases := flag.Bool("switch", true, "Point the collection names at the new collections once they are complete")
+	dropOld := flag.Bool("drop-old", false, "Delete the collections the names pointed to before the switch")
+	restart := flag.Bool("restart", false, "Delete the new collections first instead of resuming")
+	flag.Parse()
+
+	if err := run(*dbPath, *version, *switchAliases, *dropOld, *restart); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath, version string, switchAliases, dropOld, restart bool) error {
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	cfg, err := vectordb.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	models := make([]string, len(cfg.VectorSpaces))
+	for i, space := range cfg.VectorSpaces {
+		models[i] = space.Name
+	}
+	embedders := embedding.NewSet(models...)
+	log.Printf("Embedding models: %s", strings.Join(embedders.Models(), ", "))
+	if err := checkDimensions(ctx, embedders, &cfg); err != nil {
+		return err
+	}
+	strategies, err := embedding.StrategiesFromEnv()
+	if err != nil {
+		return err
+	}
+	log.Printf("Embedding text: sources %s, articles %s", strategies.Sources, strategies.Articles)
+
+	if version == "" {
+		version = strings.Trim(versionChars.ReplaceAllString(strings.ToLower(strings.Join(embedders.Models(), "_")), "_"), "_")
+	}
+	vectorDB, err := vectordb.NewClientFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+	target := vectorDB.WithVersion(version)
+	log.Printf("Target collections: %s, %s", target.CollectionName(vectordb.SourcesCollection), target.CollectionName(vectordb.ArticlesCollection))
+
+	aliases, err := vectorDB.Aliases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list aliases: %w", err)
+	}
+	if aliases[vectordb.SourcesCollection] == target.CollectionName(vectordb.SourcesCollection) {
+		return fmt.Errorf("version %s is already live; choose another -version", version)
+	}
+
+	if restart {
+		if err := target.DropCollections(ctx); err != nil {
+			return err
+		}
+	}
+	// Existing target collections are those of an interrupted run
+	if err := target.EnsureCollections(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	sources, err := migrateSources(ctx, db, target, embedders, strategies.Sources)
+	if err != nil {
+		return err
+	}
+	articles, err := migrateArticles(ctx, db, target, embedders, strategies.Articles)
+	if err != nil {
+		return err
+	}
+	if failed := sources.failed + articles.failed; failed > 0 {
+		return fmt.Errorf("%d records failed to migrate; run again to retry them", failed)
+	}
+	log.Printf("Collections filled: %d sources, %d articles (%s)", sources.total, articles.total, time.Since(start).Round(time.Second))
+
+	if !switchAliases {
+		log.Printf("Aliases not switched; run again without -switch=false to switch them")
+		return nil
+	}
+	previous, err := vectorDB.SwitchAliases(ctx, version)
+	if err != nil {
+		return err
+	}
+	log.Printf("Collection names now point to version %s", version)
+	if err := embedding.RecordStrategy(ctx, db, "sources", strategies.Sources); err != nil {
+		return err
+	}
+	if err := embedding.RecordStrategy(ctx, db, "articles", strategies.Articles); err != nil {
+		return err
+	}
+	for _, name := range previous {
+		if !dropOld {
+			log.Printf("Previous collection %s kept; delete it once the new version is verified", name)
+			continue
+		}
+		if err := vectorDB.DeleteCollection(ctx, name); err != nil {
+			return fmt.Errorf("failed to delete collection %s: %w", name, err)
+		}
+		log.Printf("Deleted previous collection %s", name)
+	}
+
+	log.Printf("Migration complete: %d sources, %d articles re-embedded (%s)",
+		sources.embedded, articles.embedded, time.Since(start).Round(time.Second))
+	return nil
+}
+
+// checkDimensions embeds a probe text with every model to learn its
+// dimension. Named vector spaces must match it; the unnamed vector takes it.
+func checkDimensions(ctx context.Context, embedders *embedding.Set, cfg *vectordb.Config) error {
+	for _, model := range embedders.Models() {
+		emb, err := embedders.Get(model).Embed(ctx, "dimension probe")
+		if err != nil {
+			return fmt.Errorf("failed to embed with %s: %w", model, err)
+		}
+		size := uint64(len(emb))
+		log.Printf("Model %s: %d dimensions", model, size)
+		if len(cfg.VectorSpaces) == 0 {
+			if cfg.VectorSize != 0 && cfg.VectorSize != size {
+				log.Printf("Warning: QDRANT_VECTOR_SIZE is %d; set it to %d for collections created later", cfg.VectorSize, size)
+			}
+			cfg.VectorSize = size
+			continue
+		}
+		for _, space := range cfg.VectorSpaces {
+			if space.Name == model && space.Size != size {
+				return fmt.Errorf("EMBEDDING_MODELS gives %s size %d, but it returns %d dimensions", model, space.Size, size)
+			}
+		}
+	}
+	return nil
+}
+
+// progress counts the records of a collection and logs the rate and
+// remaining time of a migration
+type progress struct {
+	collection string
+	total      int
+	done       int // Records handled, including those migrated before
+	resumed    int // Records found in the target collection
+	embedded   int
+	failed     int
+	start      time.Time
+}
+
+func newProgress(collection string, total, resumed int) *progress {
+	log.Printf("%s: %d records, %d already migrated", collection, total, resumed)
+	return &progress{collection: collection, total: total, done: resumed, resumed: resumed, start: time.Now()}
+}
+
+// next counts a handled record, logging every progressEvery records
+func (p *progress) next() {
+	p.done++
+	if (p.done-p.resumed)%progressEvery != 0 && p.done != p.total {
+		return
+	}
+	elapsed := time.Since(p.start)
+	rate := float64(p.done-p.resumed) / elapsed.Seconds()
+	eta := "-"
+	if rate > 0 {
+		eta = (time.Duration(float64(p.total-p.done)/rate) * time.Second).Round(time.Second).String()
+	}
+	log.Printf("%s: %d/%d (%.0f%%), %.1f records/s, %d failed, %s left",
+		p.collection, p.done, p.total, 100*float64(p.done)/float64(p.total), rate, p.failed, eta)
+}
+
+// migrated returns the IDs of the points of a collection of the target
+func migrated(ctx context.Context, target *vectordb.Client, collection string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	err := vectordb.ScrollAll(ctx, target, collection, "", func(p vectordb.Point) error {
+		if id, ok := p.Payload["id"].(string); ok {
+			ids[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target.CollectionName(collection), err)
+	}
+	return ids, nil
+}
+
+// migrateSources embeds the sources missing from the target, with their
+// claims when the claims collection is enabled
+func migrateSources(ctx context.Context, db *database.DB, target *vectordb.Client, embedders *embedding.Set, strategy embedding.Strategy) (*progress, error) {
+	ids, err := db.SourceIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	done, err := migrated(ctx, target, vectordb.SourcesCollection)
+	if err != nil {
+		return nil, err
+	}
+	// Cold sources stay without vectors until they are hit
+	cold, err := db.ColdSourceIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cold sources: %w", err)
+	}
+	if len(cold) > 0 {
+		log.Printf("sources: %d cold sources skipped", len(cold))
+	}
+	for _, id := range cold {
+		done[id] = true
+	}
+	var resumed int
+	for _, id := range ids {
+		if done[id] {
+			resumed++
+		}
+	}
+	p := newProgress("sources", len(ids), resumed)
+
+	points := target.NewBatch(ctx)
+	for _, id := range ids {
+		if done[id] {
+			continue
+		}
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil {
+			p.next()
+			continue
+		}
+		// Claims go first: a source point marks the source as migrated
+		if target.ClaimsEnabled() {
+			if err := migrateClaims(ctx, db, target, embedders, *src); err != nil {
+				log.Printf("Warning: failed to store claim embeddings for %s: %v", id, err)
+				p.failed++
+				p.next()
+				continue
+			}
+		}
+		vectors, err := embedders.EmbedDocument(ctx, strategy, embedding.Document{
+			Title:   src.Title,
+			Summary: src.EmbeddingText(),
+			Body:    src.Summary,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			p.failed++
+			p.next()
+			continue
+		}
+		if err := points.AddSource(vectordb.SourcePoint{ID: id, Vectors: vectors, Payload: vectordb.NewSourcePayload(*src)}); err != nil {
+			log.Printf("Warning: failed to store embeddings: %v", err)
+			p.failed++
+		}
+		p.embedded++
+		p.next()
+	}
+	if err := points.Close(); err != nil {
+		log.Printf("Warning: failed to store embeddings: %v", err)
+		p.failed++
+	}
+	return p, nil
+}
+
+// migrateClaims embeds the claims extracted from a source, if any
+func migrateClaims(ctx context.Context, db *database.DB, target *vectordb.Client, embedders *embedding.Set, src database.Source) error {
+	set, err := db.SourceClaims(ctx, src.ID)
+	if err != nil || set == nil || len(set.Claims) == 0 {
+		return err
+	}
+	claimPoints, err := claims.Points(ctx, embedders, src, set.Claims)
+	if err != nil {
+		return err
+	}
+	return target.ReplaceSourceClaims(ctx, src.ID, claimPoints)
+}
+
+// migrateArticles embeds the articles missing from the target, with their
+// chunks when the article_chunks collection is enabled
+func migrateArticles(ctx context.Context, db *database.DB, target *vectordb.Client, embedders *embedding.Set, strategy embedding.Strategy) (*progress, error) {
+	var chunks *chunk.Options
+	if target.ChunksEnabled() {
+		opts, err := chunk.OptionsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		chunks = &opts
+	}
+
+	ids, err := db.ArticleIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list articles: %w", err)
+	}
+	done, err := migrated(ctx, target, vectordb.ArticlesCollection)
+	if err != nil {
+		return nil, err
+	}
+	var resumed int
+	for _, id := range ids {
+		if done[id] {
+			resumed++
+		}
+	}
+	p := newProgress("articles", len(ids), resumed)
+
+	points := target.NewBatch(ctx)
+	for _, id := range ids {
+		if done[id] {
+			continue
+		}
+		art, err := db.GetArticle(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if art == nil {
+			p.next()
+			continue
+		}
+		// Chunks go first: an article point marks the article as migrated
+		if chunks != nil {
+			chunkPoints, err := chunk.Points(ctx, embedders, *art, *chunks)
+			if err == nil {
+				err = target.ReplaceArticleChunks(ctx, id, chunkPoints)
+			}
+			if err != nil {
+				log.Printf("Warning: failed to store chunk embeddings for %s: %v", id, err)
+				p.failed++
+				p.next()
+				continue
+			}
+		}
+		vectors, err := embedders.EmbedDocument(ctx, strategy, embedding.Document{
+			Title:   art.Title,
+			Summary: art.Summary,
+			Body:    art.Content,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			p.failed++
+			p.next()
+			continue
+		}
+		category, _ := art.Meta["category"].(string)
+		payload := vectordb.ArticlePayload{
+			ID:        id,
+			Title:     art.Title,
+			Path:      art.Path,
+			Summary:   art.Summary,
+			Tags:      art.Tags,
+			Category:  category,
+			WordCount: database.WordCount(art.Content),
+		}
+		if err := points.AddArticle(vectordb.ArticlePoint{ID: id, Vectors: vectors, Payload: payload}); err != nil {
+			log.Printf("Warning: failed to store embeddings: %v", err)
+			p.failed++
+		}
+		p.embedded++
+		p.next()
+	}
+	if err := points.Close(); err != nil {
+		log.Printf("Warning: failed to store embeddings: %v", err)
+		p.failed++
+	}
+	return p, nil
+}
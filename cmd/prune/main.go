@@ -0,0 +1,105 @@
+// Package main provides the retention job. It prunes the sources past the
+// retention period of their topic (KB_RETENTION, e.g. "news=18mo") from
+// SQLite and Qdrant, archiving them first when an archive directory is
+// set. Run it with -dry-run to see what would be pruned, from cron, or set
+// KB_RETENTION_INTERVAL on the server instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	policies := flag.String("retention", os.Getenv("KB_RETENTION"), "Retention period by topic, e.g. news=18mo,*=forever (default: KB_RETENTION)")
+	archiveDir := flag.String("archive", os.Getenv("KB_RETENTION_ARCHIVE"), "Directory the pruned sources are archived to as NDJSON (default: KB_RETENTION_ARCHIVE; empty deletes without a copy)")
+	dryRun := flag.Bool("dry-run", false, "Report the expired sources without pruning them")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	p, err := retention.ParsePolicies(*policies)
+	if err != nil {
+		log.Fatalf("Invalid -retention: %v", err)
+	}
+	if len(p) == 0 {
+		log.Fatal("No retention policies: set -retention or KB_RETENTION")
+	}
+	opts := retention.Options{Policies: p, DryRun: *dryRun, ArchiveDir: *archiveDir}
+	if err := run(*dbPath, opts, *jsonOut); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts retention.Options, jsonOut bool) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// A dry run only reads SQLite
+	var vectorDB *vectordb.Client
+	if !opts.DryRun {
+		if vectorDB, err = vectordb.NewClient(); err != nil {
+			return fmt.Errorf("failed to connect to Qdrant: %w", err)
+		}
+		defer vectorDB.Close()
+	}
+
+	start := time.Now()
+	report, err := retention.Run(ctx, db, vectorDB, opts)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	verb := "Pruned"
+	if opts.DryRun {
+		verb = "Would prune"
+	}
+	for _, tr := range report.Topics {
+		fmt.Printf("%s (keep %s, before %s): %d expired, %d cited and kept\n", tr.Topic, tr.Period, tr.Cutoff, len(tr.Pruned)+tr.Cited, tr.Cited)
+		for _, src := range tr.Pruned {
+			fmt.Printf("  %s %s  %s  %s\n", verb, src.ID, src.Date, src.Title)
+		}
+	}
+	if report.Archive != "" {
+		log.Printf("Archived to %s", report.Archive)
+	}
+	pruned := "pruned"
+	if opts.DryRun {
+		pruned = "to prune (dry run)"
+	}
+	log.Printf("Retention complete: %d sources %s, %d expired but cited kept (%s)",
+		report.Pruned, pruned, report.Cited, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package main provides the purge job. It permanently removes from SQLite
+// and Qdrant the sources deleted (DELETE /sources/{id}) longer ago than a
+// retention window (KB_PURGE_AFTER, default 30d); until then they can be
+// restored. Run it with -dry-run to see what would be purged, from cron, or
+// set KB_PURGE_INTERVAL on the server instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/purge"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	after := flag.String("after", os.Getenv("KB_PURGE_AFTER"), "How long deleted sources are kept, e.g. 30d or 6mo (default: KB_PURGE_AFTER, or "+purge.DefaultAfter+")")
+	limit := flag.Int("limit", purge.DefaultLimit, "Maximum sources to purge, longest deleted first")
+	dryRun := flag.Bool("dry-run", false, "Report the sources due without purging them")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	if *after == "" {
+		*after = purge.DefaultAfter
+	}
+	period, err := retention.ParsePeriod(*after)
+	if err != nil || period.Forever() {
+		log.Fatalf("Invalid -after: %s", *after)
+	}
+	opts := purge.Options{After: period, Limit: *limit, DryRun: *dryRun}
+	if err := run(*dbPath, opts, *jsonOut); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts purge.Options, jsonOut bool) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// A dry run only reads SQLite
+	var vectorDB *vectordb.Client
+	if !opts.DryRun {
+		if vectorDB, err = vectordb.NewClient(); err != nil {
+			return fmt.Errorf("failed to connect to Qdrant: %w", err)
+		}
+		defer vectorDB.Close()
+	}
+
+	start := time.Now()
+	report, err := purge.Run(ctx, db, vectorDB, opts)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	verb := "Purged"
+	if opts.DryRun {
+		verb = "Would purge"
+	}
+	for _, src := range report.Sources {
+		fmt.Printf("  %s %s  deleted %s  %s\n", verb, src.ID, src.DeletedAt, src.Title)
+	}
+	purged := "purged"
+	if opts.DryRun {
+		purged = "to purge (dry run)"
+	}
+	log.Printf("Purge complete: %d sources deleted before %s %s, %d failed (%s)",
+		report.Purged, report.Cutoff, purged, report.Failed, time.Since(start).Round(time.Millisecond))
+	return nil
+}
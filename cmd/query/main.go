@@ -0,0 +1,138 @@
+// Package main provides point-in-time queries of the knowledge-base. It
+// loads the event log of a backup or export into memory up to a date and
+// searches that state, so researchers can reproduce what the KB knew when
+// an agent made a decision.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/store/memstore"
+)
+
+// errStop ends the event log read at the first event after the cutoff
+var errStop = errors.New("stop")
+
+// Result is the output of a query: the results and the log position of the
+// state they were computed on
+type Result struct {
+	AsOf     string      `json:"as_of,omitempty"`
+	Events   int         `json:"events"`             // Events applied
+	LastSeq  int64       `json:"last_seq,omitempty"` // Last applied event
+	LastHash string      `json:"last_hash,omitempty"`
+	Sources  int         `json:"sources"` // Live records at that point
+	Articles int         `json:"articles"`
+	Results  interface{} `json:"results"`
+	Count    int         `json:"count"`
+}
+
+func main() {
+	eventsPath := flag.String("events", "", "Event log: a SQLite backup or an NDJSON export of GET /events")
+	asOf := flag.String("as-of", "", "Date (2006-01-02, the whole day) or RFC 3339 time; default: the end of the log")
+	query := flag.String("q", "", "Keywords to search for")
+	topic := flag.String("topic", "", "List the sources of a topic instead of searching")
+	kind := flag.String("kind", "sources", "What to search: sources or articles")
+	limit := flag.Int("limit", 10, "Maximum number of results")
+	user := flag.String("user", "", "Caller identity for source visibility (as X-User-ID)")
+	flag.Parse()
+
+	if err := run(*eventsPath, *asOf, *query, *topic, *kind, *limit, *user); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseAsOf parses the cutoff time. A bare date covers the whole day (UTC).
+func parseAsOf(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -as-of %q: want 2006-01-02 or an RFC 3339 time", s)
+	}
+	return day.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+func run(eventsPath, asOf, query, topic, kind string, limit int, user string) error {
+	ctx := context.Background()
+
+	if eventsPath == "" {
+		return fmt.Errorf("-events is required")
+	}
+	if query == "" && topic == "" {
+		return fmt.Errorf("-q or -topic is required")
+	}
+	if kind != "sources" && kind != "articles" {
+		return fmt.Errorf("-kind must be sources or articles")
+	}
+	var cutoff time.Time
+	if asOf != "" {
+		var err error
+		if cutoff, err = parseAsOf(asOf); err != nil {
+			return err
+		}
+	}
+
+	// Load the state as of the cutoff into memory
+	st := memstore.New()
+	result := Result{}
+	err := database.ReadEventLog(ctx, eventsPath, func(ev database.Event) error {
+		if !cutoff.IsZero() {
+			at, err := time.Parse(time.RFC3339Nano, ev.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("event %d: invalid created_at: %w", ev.Seq, err)
+			}
+			if at.After(cutoff) {
+				return errStop
+			}
+		}
+		if err := st.ApplyEvent(ctx, ev); err != nil {
+			return err
+		}
+		result.Events++
+		result.LastSeq, result.LastHash = ev.Seq, ev.Hash
+		return nil
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+	if !cutoff.IsZero() {
+		result.AsOf = cutoff.UTC().Format(time.RFC3339Nano)
+	}
+	result.Sources, _ = st.CountSources(ctx)
+	result.Articles, _ = st.CountArticles(ctx)
+	log.Printf("Loaded %d events: %d sources, %d articles", result.Events, result.Sources, result.Articles)
+
+	switch {
+	case kind == "articles":
+		articles, err := st.SearchArticles(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		result.Results, result.Count = articles, len(articles)
+	case topic != "":
+		sources, err := st.ListSources(ctx, database.SourceFilter{Topic: topic}, limit, user)
+		if err != nil {
+			return err
+		}
+		result.Results, result.Count = sources, len(sources)
+	default:
+		sources, err := st.SearchSources(ctx, query, limit, user)
+		if err != nil {
+			return err
+		}
+		result.Results, result.Count = sources, len(sources)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
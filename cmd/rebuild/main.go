@@ -0,0 +1,345 @@
+// Package main provides disaster recovery for the knowledge-base.
+// It replays the event log into a fresh SQLite database (optionally starting
+// from a snapshot), re-embeds the final state into new Qdrant collections,
+// and verifies the resulting counts against the log.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/claims"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// progressEvery is how often replay progress is logged, in events
+const progressEvery = 1000
+
+func main() {
+	// Flags
+	eventsPath := flag.String("events", "", "Event log: a SQLite database or an NDJSON export of GET /events")
+	dbPath := flag.String("db", "", "Path of the SQLite database to rebuild (must not exist)")
+	snapshotPath := flag.String("snapshot", "", "Optional SQLite snapshot to start from; only later events are replayed")
+	withEmbeddings := flag.Bool("embeddings", false, "Recreate the Qdrant collections and re-embed the rebuilt state")
+	flag.Parse()
+
+	if err := run(*eventsPath, *dbPath, *snapshotPath, *withEmbeddings); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// state is the set of live records as of the end of the log
+type state struct {
+	sources  map[string]database.Source
+	articles map[string]database.Article
+	claims   map[string]database.ClaimSet // Keyed by source ID
+}
+
+func (st *state) apply(ev database.Event) error {
+	switch ev.Type {
+	case database.EventSourceUpserted:
+		var src database.Source
+		if err := json.Unmarshal(ev.Data, &src); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		st.sources[src.ID] = src
+	case database.EventSourceDeleted, database.EventSourcePurged:
+		// Deleted sources are replayed into the trash but aren't embedded
+		delete(st.sources, ev.EntityID)
+		delete(st.claims, ev.EntityID)
+	case database.EventArticleUpserted:
+		var art database.Article
+		if err := json.Unmarshal(ev.Data, &art); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		st.articles[art.ID] = art
+	case database.EventArticleDeleted:
+		delete(st.articles, ev.EntityID)
+	case database.EventClaimsExtracted:
+		var set database.ClaimSet
+		if err := json.Unmarshal(ev.Data, &set); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		st.claims[set.SourceID] = set
+	case database.EventAliasAdded, database.EventEntityAliasAdded, database.EventLinkReviewed,
+		database.EventCategoryUpserted, database.EventTopicUpserted, database.EventTopicDeleted:
+		// Aliases, link reviews, categories and the topic taxonomy are
+		// replayed but don't change the live records embedded here
+	default:
+		return fmt.Errorf("event %d: unknown type %s", ev.Seq, ev.Type)
+	}
+	return nil
+}
+
+func run(eventsPath, dbPath, snapshotPath string, withEmbeddings bool) error {
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
+	if eventsPath == "" {
+		return fmt.Errorf("-events is required")
+	}
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("database already exists: %s", dbPath)
+	}
+
+	log.Printf("Event log: %s", eventsPath)
+	log.Printf("Database path: %s", dbPath)
+	if snapshotPath != "" {
+		log.Printf("Snapshot: %s", snapshotPath)
+	}
+	log.Printf("Generate embeddings: %v", withEmbeddings)
+
+	if snapshotPath != "" {
+		if err := copyFile(snapshotPath, dbPath); err != nil {
+			return fmt.Errorf("failed to copy snapshot: %w", err)
+		}
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Events up to the snapshot head are already applied; they are only
+	// folded into the expected state. The snapshot must agree with the log.
+	headSeq, headHash, err := db.EventHead(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot head: %w", err)
+	}
+	if headSeq > 0 {
+		log.Printf("Snapshot is at event %d", headSeq)
+	}
+
+	st := &state{
+		sources:  make(map[string]database.Source),
+		articles: make(map[string]database.Article),
+		claims:   make(map[string]database.ClaimSet),
+	}
+	var total, replayed int64
+	err = database.ReadEventLog(ctx, eventsPath, func(ev database.Event) error {
+		total++
+		if ev.Seq != total {
+			return fmt.Errorf("event log has a gap: expected event %d, got %d", total, ev.Seq)
+		}
+		if err := st.apply(ev); err != nil {
+			return err
+		}
+
+		if ev.Seq < headSeq {
+			return nil
+		}
+		if ev.Seq == headSeq {
+			if ev.Hash != headHash {
+				return fmt.Errorf("snapshot diverges from the event log at event %d", ev.Seq)
+			}
+			return nil
+		}
+		if err := db.ReplayEvent(ctx, ev); err != nil {
+			return err
+		}
+		replayed++
+		if replayed%progressEvery == 0 {
+			log.Printf("Replayed %d events...", replayed)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if total < headSeq {
+		return fmt.Errorf("snapshot is ahead of the event log (%d > %d)", headSeq, total)
+	}
+
+	log.Printf("Replay complete: %d events in log, %d replayed", total, replayed)
+
+	if _, err := db.VerifyEvents(ctx); err != nil {
+		return fmt.Errorf("rebuilt event log failed verification: %w", err)
+	}
+
+	// Verify counts against the state implied by the log
+	var mismatches []string
+	sourceCount, err := db.CountSources(ctx)
+	if err != nil {
+		return err
+	}
+	articleCount, err := db.CountArticles(ctx)
+	if err != nil {
+		return err
+	}
+	if sourceCount != len(st.sources) {
+		mismatches = append(mismatches, fmt.Sprintf("sources: database has %d, log implies %d", sourceCount, len(st.sources)))
+	}
+	if articleCount != len(st.articles) {
+		mismatches = append(mismatches, fmt.Sprintf("articles: database has %d, log implies %d", articleCount, len(st.articles)))
+	}
+	log.Printf("Database stats: %d articles, %d sources", articleCount, sourceCount)
+
+	if withEmbeddings {
+		sourcePoints, articlePoints, err := reembed(ctx, db, st)
+		if err != nil {
+			return err
+		}
+		if sourcePoints != len(st.sources) {
+			mismatches = append(mismatches, fmt.Sprintf("source vectors: stored %d, log implies %d", sourcePoints, len(st.sources)))
+		}
+		if articlePoints != len(st.articles) {
+			mismatches = append(mismatches, fmt.Sprintf("article vectors: stored %d, log implies %d", articlePoints, len(st.articles)))
+		}
+		log.Printf("Vector stats: %d articles, %d sources", articlePoints, sourcePoints)
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("verification failed:\n  %s", strings.Join(mismatches, "\n  "))
+	}
+
+	log.Printf("Rebuild verified against %d events", total)
+	return nil
+}
+
+// reembed recreates the Qdrant collections and stores a point for every live
+// source and article, returning how many points each collection holds
+// afterwards, as counted by Qdrant. The embedding text strategies used are
+// recorded in the rebuilt database.
+func reembed(ctx context.Context, db *database.DB, st *state) (int, int, error) {
+	strategies, err := embedding.StrategiesFromEnv()
+	if err != nil {
+		return 0, 0, err
+	}
+	log.Printf("Embedding text: sources %s, articles %s", strategies.Sources, strategies.Articles)
+
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+
+	embedders := embedding.NewSet(vectorDB.VectorNames()...)
+	log.Printf("Embedding models: %s", strings.Join(embedders.Models(), ", "))
+
+	if err := vectorDB.RecreateCollections(ctx); err != nil {
+		return 0, 0, err
+	}
+	var chunks *chunk.Options
+	if vectorDB.ChunksEnabled() {
+		opts, err := chunk.OptionsFromEnv()
+		if err != nil {
+			return 0, 0, err
+		}
+		chunks = &opts
+	}
+
+	// Points are upserted in batches; a failed batch is logged and its
+	// points are left out of Qdrant, which the counts below catch. Writes
+	// wait for Qdrant, so the counts include them.
+	points := vectorDB.NewBatch(vectordb.WithWait(ctx))
+	for id, src := range st.sources {
+		vectors, err := embedders.EmbedDocument(ctx, strategies.Sources, embedding.Document{
+			Title:   src.Title,
+			Summary: src.EmbeddingText(),
+			Body:    src.Summary,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			continue
+		}
+		if err := points.AddSource(vectordb.SourcePoint{ID: id, Vectors: vectors, Payload: vectordb.NewSourcePayload(src)}); err != nil {
+			log.Printf("Warning: failed to store embeddings: %v", err)
+		}
+		if set, ok := st.claims[id]; ok && vectorDB.ClaimsEnabled() && len(set.Claims) > 0 {
+			claimPoints, err := claims.Points(ctx, embedders, src, set.Claims)
+			if err == nil {
+				err = vectorDB.ReplaceSourceClaims(ctx, id, claimPoints)
+			}
+			if err != nil {
+				log.Printf("Warning: failed to store claim embeddings for %s: %v", id, err)
+			}
+		}
+	}
+
+	for id, art := range st.articles {
+		vectors, err := embedders.EmbedDocument(ctx, strategies.Articles, embedding.Document{
+			Title:   art.Title,
+			Summary: art.Summary,
+			Body:    art.Content,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			continue
+		}
+		category, _ := art.Meta["category"].(string)
+		payload := vectordb.ArticlePayload{
+			ID:        id,
+			Title:     art.Title,
+			Path:      art.Path,
+			Summary:   art.Summary,
+			Tags:      art.Tags,
+			Category:  category,
+			WordCount: database.WordCount(art.Content),
+		}
+		if err := points.AddArticle(vectordb.ArticlePoint{ID: id, Vectors: vectors, Payload: payload}); err != nil {
+			log.Printf("Warning: failed to store embeddings: %v", err)
+		}
+		if chunks != nil {
+			chunkPoints, err := chunk.Points(ctx, embedders, art, *chunks)
+			if err == nil {
+				err = vectorDB.ReplaceArticleChunks(ctx, id, chunkPoints)
+			}
+			if err != nil {
+				log.Printf("Warning: failed to store chunk embeddings for %s: %v", id, err)
+			}
+		}
+	}
+	if err := points.Close(); err != nil {
+		return 0, 0, fmt.Errorf("failed to store embeddings: %w", err)
+	}
+	sources, err := vectorDB.Count(ctx, vectordb.SourcesCollection, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	articles, err := vectorDB.Count(ctx, vectordb.ArticlesCollection, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := embedding.RecordStrategy(ctx, db, "sources", strategies.Sources); err != nil {
+		return 0, 0, err
+	}
+	if err := embedding.RecordStrategy(ctx, db, "articles", strategies.Articles); err != nil {
+		return 0, 0, err
+	}
+	return int(sources), int(articles), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
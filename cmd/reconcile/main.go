@@ -0,0 +1,94 @@
+// Package main provides the Qdrant/SQLite reconciliation job. It compares
+// the IDs of the sources and articles in SQLite with the points in Qdrant,
+// reports records without a point and points without a record, and with
+// -fix re-embeds the missing points and removes the stale ones.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/reconcile"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	fix := flag.Bool("fix", false, "Re-embed missing points and remove stale ones")
+	show := flag.Int("show", 20, "IDs listed per kind of drift and collection")
+	flag.Parse()
+
+	if err := run(*dbPath, *fix, *show); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, fix bool, show int) error {
+	ctx := embedding.WithPriority(context.Background(), embedding.PriorityBackground)
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+	log.Printf("Repair: %v", fix)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+
+	opts := reconcile.Options{Fix: fix}
+	if fix {
+		if opts.Strategies, err = embedding.StrategiesFromEnv(); err != nil {
+			return err
+		}
+		opts.Embedders = embedding.NewSet(vectorDB.VectorNames()...)
+		log.Printf("Embedding models: %s", strings.Join(opts.Embedders.Models(), ", "))
+	}
+
+	reports, err := reconcile.Run(ctx, db, vectorDB, opts)
+	for _, r := range reports {
+		log.Printf("%s: %d records, %d points, %d missing points, %d stale points, %d cold",
+			r.Collection, r.Records, r.Points, len(r.Missing), len(r.Stale), r.Cold)
+		logIDs("missing", r.Missing, show)
+		logIDs("stale", r.Stale, show)
+		if fix {
+			log.Printf("%s: %d points re-embedded, %d removed, %d failed", r.Collection, r.Embedded, r.Removed, r.Failed)
+		}
+	}
+	return err
+}
+
+// logIDs lists up to show IDs of one kind of drift
+func logIDs(kind string, ids []string, show int) {
+	if len(ids) == 0 || show <= 0 {
+		return
+	}
+	more := ""
+	if len(ids) > show {
+		more = fmt.Sprintf(" (and %d more)", len(ids)-show)
+		ids = ids[:show]
+	}
+	log.Printf("  %s: %s%s", kind, strings.Join(ids, ", "), more)
+}
@@ -0,0 +1,95 @@
+// Package main provides the topic report job. For every topic (or the one
+// given) it reports the sources added in the past week, the subjects they
+// share, article links whose source is gone and the subjects the topic's
+// articles miss, stores the reports for GET /reports and POSTs them to a
+// webhook. Run it weekly (e.g. from cron) or set KB_REPORT_INTERVAL on the
+// server instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/report"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	topic := flag.String("topic", "", "Only report on this topic")
+	period := flag.Duration("period", report.DefaultPeriod, "Period each report covers")
+	end := flag.String("end", "", "Day the period ends, YYYY-MM-DD (default: today)")
+	webhook := flag.String("webhook", os.Getenv("KB_REPORT_WEBHOOK_URL"), "URL the reports are POSTed to (default: KB_REPORT_WEBHOOK_URL)")
+	printReports := flag.Bool("print", false, "Print the reports as markdown")
+	flag.Parse()
+
+	opts := report.Options{Period: *period, Webhook: *webhook}
+	if *topic != "" {
+		opts.Topics = []string{slug.Make(*topic)}
+	}
+	if *end != "" {
+		t, err := time.Parse(time.DateOnly, *end)
+		if err != nil {
+			log.Fatalf("Invalid -end: %s", *end)
+		}
+		opts.End = t
+	}
+	if err := run(*dbPath, opts, *printReports); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string, opts report.Options, printReports bool) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	stats, err := report.Run(ctx, db, opts)
+	if err != nil {
+		return err
+	}
+	log.Printf("Topic reports complete: %d stored, %d topics without public sources, %d delivered, %d failed deliveries (%s)",
+		stats.Reports, stats.Skipped, stats.Delivered, stats.Failed, time.Since(start).Round(time.Millisecond))
+
+	if !printReports {
+		return nil
+	}
+	topics, err := report.Topics(ctx, db, opts)
+	if err != nil {
+		return err
+	}
+	periodStart, _ := report.Period(opts)
+	for _, topic := range topics {
+		r, err := db.GetTopicReport(ctx, topic, periodStart.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		if r != nil {
+			fmt.Println(r.Markdown)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package main loads the bundled demo corpus (sources and articles on sleep,
+// Rust and climate, with precomputed embeddings) into the SQLite database
+// and Qdrant, so the API can be evaluated without Ollama or content of
+// one's own. Serve it with KB_DEMO=true, which embeds queries like the
+// corpus and makes the API read-only and rate-limited.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/demo"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "Path to SQLite database")
+	regenerate := flag.String("regenerate", "", "Recompute the embeddings of the corpus file at this path instead of seeding (see go generate)")
+	flag.Parse()
+
+	var err error
+	if *regenerate != "" {
+		err = regenerateCorpus(*regenerate)
+	} else {
+		err = run(*dbPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dbPath string) error {
+	ctx := context.Background()
+
+	if dbPath == "" {
+		dbPath = os.Getenv("KB_DB_PATH")
+		if dbPath == "" {
+			kbRoot, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			dbPath = filepath.Join(kbRoot, "out", "knowledge.sqlite")
+		}
+	}
+	log.Printf("Database path: %s", dbPath)
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Qdrant: %w", err)
+	}
+	defer vectorDB.Close()
+	if err := vectorDB.EnsureCollections(ctx); err != nil {
+		return fmt.Errorf("failed to ensure Qdrant collections: %w", err)
+	}
+
+	start := time.Now()
+	stats, err := demo.Seed(ctx, db, vectorDB)
+	if err != nil {
+		return err
+	}
+	log.Printf("Demo corpus seeded: %d sources, %d articles (%s)", stats.Sources, stats.Articles, time.Since(start).Round(time.Millisecond))
+	log.Printf("Start the server with KB_DEMO=true KB_DB_PATH=%s", dbPath)
+	return nil
+}
+
+// regenerateCorpus recomputes the embeddings of a corpus file after its
+// records were edited
+func regenerateCorpus(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	records, err := demo.ReadRecords(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := demo.Embed(context.Background(), records); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := demo.WriteRecords(&buf, records); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Printf("Embedded %d records into %s", len(records), path)
+	return nil
+}
@@ -3,84 +3,45 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/binary"
-	"encoding/json"
-	"fmt"
 	"log"
-	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/autolink"
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/cold"
 	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/demo"
 	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/geo"
+	"github.com/gitopedia/knowledge-base/internal/guard"
+	"github.com/gitopedia/knowledge-base/internal/ollama"
+	"github.com/gitopedia/knowledge-base/internal/purge"
+	"github.com/gitopedia/knowledge-base/internal/redact"
+	"github.com/gitopedia/knowledge-base/internal/report"
+	"github.com/gitopedia/knowledge-base/internal/rerank"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/scholar"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
 	"github.com/gitopedia/knowledge-base/internal/vectordb"
+	"github.com/gitopedia/knowledge-base/internal/watch"
+	"github.com/gitopedia/knowledge-base/internal/webhook"
+	"google.golang.org/grpc"
 )
 
-// Server holds the dependencies for the HTTP API
-type Server struct {
-	db       *database.DB
-	vectorDB *vectordb.Client
-	embedder *embedding.Client
-}
-
-// SourceRequest is the request body for creating/updating a source
-type SourceRequest struct {
-	ID        string   `json:"id"`
-	URL       string   `json:"url"`
-	Title     string   `json:"title"`
-	Topic     string   `json:"topic"`
-	Summary   string   `json:"summary"`
-	Language  string   `json:"language,omitempty"`
-	Model     string   `json:"model,omitempty"`
-	CreatedAt string   `json:"created_at,omitempty"`
-	Tags      []string `json:"tags,omitempty"`
-}
-
-// SearchRequest is the request body for vector search
-type SearchRequest struct {
-	Query     string  `json:"query,omitempty"`     // Text to embed and search
-	Embedding string  `json:"embedding,omitempty"` // Base64-encoded embedding (alternative to query)
-	Limit     int     `json:"limit,omitempty"`
-	Topic     string  `json:"topic,omitempty"` // Optional topic filter
-}
-
-// SearchResponse is the response for search endpoints
-type SearchResponse struct {
-	Results []SearchResult `json:"results"`
-	Count   int            `json:"count"`
-}
-
-// SearchResult represents a single search result
-type SearchResult struct {
-	ID        string   `json:"id"`
-	URL       string   `json:"url,omitempty"`
-	Title     string   `json:"title"`
-	Topic     string   `json:"topic,omitempty"`
-	Summary   string   `json:"summary"`
-	Score     float32  `json:"score,omitempty"`
-	Tags      []string `json:"tags,omitempty"`
-	Language  string   `json:"language,omitempty"`
-	Model     string   `json:"model,omitempty"`
-	CreatedAt string   `json:"created_at,omitempty"`
-}
-
-// HealthResponse is the response for the health endpoint
-type HealthResponse struct {
-	Status       string `json:"status"`
-	SourceCount  int    `json:"source_count"`
-	ArticleCount int    `json:"article_count"`
-	Version      string `json:"version"`
-}
-
-// ErrorResponse is the response for errors
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+// Rate limit of demo mode, per client IP
+const (
+	demoRatePerMinute = 30
+	demoRateBurst     = 10
+)
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
@@ -96,487 +57,432 @@ func main() {
 		dbPath = "/app/data/knowledge.sqlite"
 	}
 
-	// Initialize database
-	log.Printf("Opening database at %s", dbPath)
-	db, err := database.Open(dbPath)
-	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
+	scanMode, ok := redact.ParseMode(os.Getenv("KB_SCAN_MODE"))
+	if !ok {
+		log.Fatalf("Invalid KB_SCAN_MODE: %s", os.Getenv("KB_SCAN_MODE"))
 	}
-	defer db.Close()
 
-	// Initialize Qdrant client
-	log.Println("Connecting to Qdrant...")
-	vectorDB, err := vectordb.NewClient()
-	if err != nil {
-		log.Fatalf("Failed to connect to Qdrant: %v", err)
+	// Demo mode (see cmd/seed-demo) embeds queries like the demo corpus and
+	// defaults to a read-only, tightly rate-limited API
+	var demoMode bool
+	if v := os.Getenv("KB_DEMO"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid KB_DEMO: %s", v)
+		}
+		demoMode = enabled
 	}
-	defer vectorDB.Close()
-
-	// Ensure collections exist
-	ctx := context.Background()
-	if err := vectorDB.EnsureCollections(ctx); err != nil {
-		log.Fatalf("Failed to ensure Qdrant collections: %v", err)
+	if demoMode {
+		demo.SetEnvDefaults()
+		if p := os.Getenv("EMBEDDING_PROVIDER"); p != embedding.ProviderHash {
+			log.Printf("Warning: demo mode with EMBEDDING_PROVIDER=%s; the demo corpus is embedded with %s", p, embedding.ProviderHash)
+		}
 	}
-	log.Println("Qdrant collections ready")
-
-	// Initialize embedding client
-	embedder := embedding.NewClient()
-	log.Printf("Embedding client ready (model: %s)", embedder.Model())
 
-	// Create server
-	server := &Server{
-		db:       db,
-		vectorDB: vectorDB,
-		embedder: embedder,
+	readOnly := demoMode
+	if v := os.Getenv("KB_READ_ONLY"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid KB_READ_ONLY: %s", v)
+		}
+		readOnly = enabled
 	}
 
-	// Setup routes
-	mux := http.NewServeMux()
-
-	// Health check
-	mux.HandleFunc("GET /health", server.handleHealth)
-
-	// Source endpoints
-	mux.HandleFunc("POST /sources", server.handleCreateSource)
-	mux.HandleFunc("GET /sources/{id}", server.handleGetSource)
-	mux.HandleFunc("DELETE /sources/{id}", server.handleDeleteSource)
-	mux.HandleFunc("GET /sources", server.handleListSources)
-
-	// Search endpoints
-	mux.HandleFunc("POST /sources/search", server.handleSearchSources)
-	mux.HandleFunc("GET /sources/search", server.handleSearchSourcesGET)
-	mux.HandleFunc("GET /sources/topic/{topic}", server.handleGetSourcesByTopic)
-
-	// Article search (uses existing article index)
-	mux.HandleFunc("POST /articles/search", server.handleSearchArticles)
-	mux.HandleFunc("GET /articles/search", server.handleSearchArticlesGET)
-
-	// Wrap with logging middleware
-	handler := loggingMiddleware(corsMiddleware(mux))
-
-	// Start server
-	httpServer := &http.Server{
-		Addr:         ":" + port,
-		Handler:      handler,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 60 * time.Second,
+	// Secret of the operators; API keys would shadow one shaped like them
+	adminToken := os.Getenv("KB_ADMIN_TOKEN")
+	if strings.HasPrefix(adminToken, "kb_") {
+		log.Fatalf("Invalid KB_ADMIN_TOKEN: must not start with kb_, the prefix of API keys")
 	}
 
-	// Graceful shutdown
-	go func() {
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-		<-sigChan
-
-		log.Println("Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		httpServer.Shutdown(ctx)
-	}()
-
-	log.Printf("Knowledge-base API server listening on port %s", port)
-	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("Server error: %v", err)
+	// Source writes only with an API key or the admin token
+	var requireAPIKey bool
+	if v := os.Getenv("KB_REQUIRE_API_KEY"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid KB_REQUIRE_API_KEY: %s", v)
+		}
+		requireAPIKey = enabled
 	}
-	log.Println("Server stopped")
-}
-
-// Middleware
-
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
-	})
-}
-
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	// Requests per minute and burst of each client IP; 0 disables the limit
+	var rateLimit api.RateLimit
+	if demoMode {
+		rateLimit = api.RateLimit{PerMinute: demoRatePerMinute, Burst: demoRateBurst}
+	}
+	if v := os.Getenv("KB_RATE_LIMIT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid KB_RATE_LIMIT: %s", v)
 		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// Handlers
-
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	sourceCount, _ := s.db.CountSources()
-	articleCount, _ := s.db.CountArticles()
-
-	version, _ := s.db.GetInfo("version")
-	if version == "" {
-		version = "unknown"
+		rateLimit = api.RateLimit{PerMinute: n}
+	}
+	if v := os.Getenv("KB_RATE_BURST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid KB_RATE_BURST: %s", v)
+		}
+		rateLimit.Burst = n
 	}
 
-	resp := HealthResponse{
-		Status:       "ok",
-		SourceCount:  sourceCount,
-		ArticleCount: articleCount,
-		Version:      version,
+	strategies, err := embedding.StrategiesFromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	writeJSON(w, http.StatusOK, resp)
-}
+	// Fail at startup rather than on the first embedding
+	if _, err := embedding.ProviderFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+	retryPolicy, err := embedding.RetryPolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-func (s *Server) handleCreateSource(w http.ResponseWriter, r *http.Request) {
-	var req SourceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
-		return
+	// Queries in these languages search their model's vector space
+	languageRoutes, err := embedding.ParseLanguageRoutes(os.Getenv("EMBEDDING_LANGUAGE_MODELS"))
+	if err != nil {
+		log.Fatalf("Invalid EMBEDDING_LANGUAGE_MODELS: %v", err)
 	}
 
-	// Validate required fields
-	if req.URL == "" || req.Summary == "" {
-		writeError(w, http.StatusBadRequest, "url and summary are required")
-		return
+	// Embedding requests are admitted by priority: searches, then writes,
+	// then bulk work
+	scheduler, err := embedding.SchedulerFromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Generate ID if not provided
-	if req.ID == "" {
-		req.ID = fmt.Sprintf("src-%d", time.Now().UnixNano())
+	// Paper metadata is fetched for POST /sources when enabled
+	var scholarClient *scholar.Client
+	if v := os.Getenv("KB_FETCH_METADATA"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid KB_FETCH_METADATA: %s", v)
+		}
+		if enabled {
+			scholarClient = scholar.NewClient()
+		}
 	}
 
-	// Set created_at if not provided
-	if req.CreatedAt == "" {
-		req.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	// Places of new sources are geocoded when enabled
+	var geocoder *geo.Client
+	if v := os.Getenv("KB_GEOCODE"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid KB_GEOCODE: %s", v)
+		}
+		if enabled {
+			geocoder = geo.NewClient()
+		}
 	}
 
-	// Generate embedding
-	ctx := r.Context()
-	emb, err := s.embedder.Embed(ctx, req.Summary)
+	// Search results are reranked on request when a reranker is configured
+	reranker, err := rerank.FromEnv()
 	if err != nil {
-		log.Printf("Failed to generate embedding: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
-		return
-	}
-
-	// Store in SQLite
-	src := database.Source{
-		ID:        req.ID,
-		URL:       req.URL,
-		Title:     req.Title,
-		Topic:     req.Topic,
-		Summary:   req.Summary,
-		Language:  req.Language,
-		Model:     req.Model,
-		CreatedAt: req.CreatedAt,
-		Tags:      req.Tags,
-	}
-	if err := s.db.InsertSource(src); err != nil {
-		log.Printf("Failed to insert source: %v", err)
-		writeError(w, http.StatusInternalServerError, "Failed to store source")
-		return
-	}
-
-	// Store in Qdrant
-	payload := vectordb.SourcePayload{
-		ID:        req.ID,
-		URL:       req.URL,
-		Title:     req.Title,
-		Topic:     req.Topic,
-		Summary:   req.Summary,
-		Language:  req.Language,
-		Model:     req.Model,
-		CreatedAt: req.CreatedAt,
-	}
-	if err := s.vectorDB.UpsertSource(ctx, req.ID, emb, payload); err != nil {
-		log.Printf("Failed to store embedding: %v", err)
-		// Don't fail the request - SQLite has the data
-	}
-
-	writeJSON(w, http.StatusCreated, map[string]string{"id": req.ID})
-}
-
-func (s *Server) handleGetSource(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		writeError(w, http.StatusBadRequest, "id is required")
-		return
+		log.Fatal(err)
 	}
-
-	src, err := s.db.GetSource(id)
+	rerankCandidates, err := rerank.CandidatesFromEnv()
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Database error")
-		return
+		log.Fatal(err)
 	}
-	if src == nil {
-		writeError(w, http.StatusNotFound, "Source not found")
-		return
+	if reranker != nil {
+		log.Printf("Reranking with %s (top %d candidates)", reranker.Model(), rerankCandidates)
 	}
 
-	writeJSON(w, http.StatusOK, src)
-}
-
-func (s *Server) handleDeleteSource(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		writeError(w, http.StatusBadRequest, "id is required")
-		return
+	// Source comparisons need a generation model
+	generator, err := generation.FromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	// Delete from SQLite
-	if err := s.db.DeleteSource(id); err != nil {
-		log.Printf("Failed to delete source from SQLite: %v", err)
+	if generator != nil {
+		log.Printf("Generation model: %s", generator.Model())
 	}
 
-	// Delete from Qdrant
-	ctx := r.Context()
-	if err := s.vectorDB.DeleteSource(ctx, id); err != nil {
-		log.Printf("Failed to delete source from Qdrant: %v", err)
+	// Summary style profiles sources may name, and the profile of each topic
+	summaryStyles, err := summarystyle.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid KB_SUMMARY_STYLES: %v", err)
 	}
 
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func (s *Server) handleListSources(w http.ResponseWriter, r *http.Request) {
-	limitStr := r.URL.Query().Get("limit")
-	limit := 100
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	// Auto-linking runs in the background when an interval (e.g. 24h) is set
+	var autolinkInterval time.Duration
+	if v := os.Getenv("KB_AUTOLINK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_AUTOLINK_INTERVAL: %s", v)
 		}
+		autolinkInterval = d
 	}
 
-	topic := r.URL.Query().Get("topic")
-	var sources []database.Source
-	var err error
-
-	if topic != "" {
-		sources, err = s.db.GetSourcesByTopic(topic, limit)
-	} else {
-		// Get all sources (limited)
-		sources, err = s.db.GetSourcesByTopic("", limit)
+	// Topic reports are generated in the background when an interval (e.g.
+	// 168h) is set, and POSTed to KB_REPORT_WEBHOOK_URL if set
+	var reportInterval time.Duration
+	if v := os.Getenv("KB_REPORT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_REPORT_INTERVAL: %s", v)
+		}
+		reportInterval = d
 	}
 
+	// Sources past their topic's retention period (KB_RETENTION, e.g.
+	// "news=18mo") are pruned in the background when an interval (e.g. 24h)
+	// is set, and archived to KB_RETENTION_ARCHIVE if set
+	retentionPolicies, err := retention.ParsePolicies(os.Getenv("KB_RETENTION"))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Database error")
-		return
+		log.Fatalf("Invalid KB_RETENTION: %v", err)
 	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"sources": sources,
-		"count":   len(sources),
-	})
-}
-
-func (s *Server) handleSearchSources(w http.ResponseWriter, r *http.Request) {
-	var req SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
-		return
+	var retentionInterval time.Duration
+	if v := os.Getenv("KB_RETENTION_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_RETENTION_INTERVAL: %s", v)
+		}
+		if len(retentionPolicies) == 0 {
+			log.Fatal("KB_RETENTION_INTERVAL is set without KB_RETENTION")
+		}
+		retentionInterval = d
 	}
 
-	s.searchSources(w, r, req)
-}
-
-func (s *Server) handleSearchSourcesGET(w http.ResponseWriter, r *http.Request) {
-	req := SearchRequest{
-		Query: r.URL.Query().Get("q"),
-		Topic: r.URL.Query().Get("topic"),
+	// Sources not hit for KB_COLD_AFTER (e.g. "5y") have their vectors
+	// dropped in the background when an interval (e.g. 24h) is set
+	var coldAfter retention.Period
+	if v := os.Getenv("KB_COLD_AFTER"); v != "" {
+		if coldAfter, err = retention.ParsePeriod(v); err != nil || coldAfter.Forever() {
+			log.Fatalf("Invalid KB_COLD_AFTER: %s", v)
+		}
 	}
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			req.Limit = l
+	var coldInterval time.Duration
+	if v := os.Getenv("KB_COLD_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_COLD_INTERVAL: %s", v)
+		}
+		if coldAfter.Forever() {
+			log.Fatal("KB_COLD_INTERVAL is set without KB_COLD_AFTER")
 		}
+		coldInterval = d
 	}
 
-	s.searchSources(w, r, req)
-}
-
-func (s *Server) searchSources(w http.ResponseWriter, r *http.Request, req SearchRequest) {
-	if req.Query == "" && req.Embedding == "" {
-		writeError(w, http.StatusBadRequest, "query or embedding is required")
-		return
+	// Deleted sources are kept for KB_PURGE_AFTER (default 30d), then
+	// purged in the background when an interval (e.g. 24h) is set
+	purgeAfter, err := retention.ParsePeriod(purge.DefaultAfter)
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	if req.Limit <= 0 {
-		req.Limit = 10
+	if v := os.Getenv("KB_PURGE_AFTER"); v != "" {
+		if purgeAfter, err = retention.ParsePeriod(v); err != nil || purgeAfter.Forever() {
+			log.Fatalf("Invalid KB_PURGE_AFTER: %s", v)
+		}
 	}
-
-	ctx := r.Context()
-	var emb []float32
-	var err error
-
-	if req.Embedding != "" {
-		// Decode base64 embedding
-		emb, err = decodeEmbedding(req.Embedding)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "Invalid embedding format")
-			return
+	var purgeInterval time.Duration
+	if v := os.Getenv("KB_PURGE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_PURGE_INTERVAL: %s", v)
 		}
-	} else {
-		// Generate embedding from query
-		emb, err = s.embedder.Embed(ctx, req.Query)
-		if err != nil {
-			log.Printf("Failed to generate embedding: %v", err)
-			writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
-			return
+		purgeInterval = d
+	}
+
+	// The stores are compared every KB_GUARD_INTERVAL (e.g. 5m) when set;
+	// source writes are queued while more than KB_GUARD_THRESHOLD (default
+	// 0.05) of the sources lack their vectors
+	var storeGuard *guard.Guard
+	var guardInterval time.Duration
+	if v := os.Getenv("KB_GUARD_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_GUARD_INTERVAL: %s", v)
 		}
-	}
-
-	// Search Qdrant
-	results, err := s.vectorDB.SearchSources(ctx, emb, req.Limit, req.Topic)
-	if err != nil {
-		log.Printf("Vector search failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Search failed")
-		return
-	}
-
-	// Convert to response format
-	searchResults := make([]SearchResult, len(results))
-	for i, r := range results {
-		searchResults[i] = SearchResult{
-			ID:        r.ID,
-			Score:     r.Score,
-			URL:       getString(r.Payload, "url"),
-			Title:     getString(r.Payload, "title"),
-			Topic:     getString(r.Payload, "topic"),
-			Summary:   getString(r.Payload, "summary"),
-			Language:  getString(r.Payload, "language"),
-			Model:     getString(r.Payload, "model"),
-			CreatedAt: getString(r.Payload, "created_at"),
+		guardInterval = d
+		threshold := guard.DefaultThreshold
+		if v := os.Getenv("KB_GUARD_THRESHOLD"); v != "" {
+			if threshold, err = strconv.ParseFloat(v, 64); err != nil || threshold <= 0 || threshold >= 1 {
+				log.Fatalf("Invalid KB_GUARD_THRESHOLD: %s", v)
+			}
 		}
+		storeGuard = guard.New(guard.Options{Threshold: threshold})
 	}
 
-	writeJSON(w, http.StatusOK, SearchResponse{
-		Results: searchResults,
-		Count:   len(searchResults),
-	})
-}
-
-func (s *Server) handleGetSourcesByTopic(w http.ResponseWriter, r *http.Request) {
-	topic := r.PathValue("topic")
-	if topic == "" {
-		writeError(w, http.StatusBadRequest, "topic is required")
-		return
+	// Webhooks receive pending events every KB_WEBHOOK_INTERVAL (default 5s)
+	webhookInterval := 5 * time.Second
+	if v := os.Getenv("KB_WEBHOOK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_WEBHOOK_INTERVAL: %s", v)
+		}
+		webhookInterval = d
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 100
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	// New sources are compared with the watches of users every
+	// KB_WATCH_INTERVAL (default 10s)
+	watchInterval := 10 * time.Second
+	if v := os.Getenv("KB_WATCH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid KB_WATCH_INTERVAL: %s", v)
 		}
+		watchInterval = d
 	}
 
-	sources, err := s.db.GetSourcesByTopic(topic, limit)
+	// Initialize database
+	log.Printf("Opening database at %s", dbPath)
+	db, err := database.Open(dbPath)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Database error")
-		return
+		log.Fatalf("Failed to open database: %v", err)
 	}
+	defer db.Close()
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"sources": sources,
-		"count":   len(sources),
-	})
-}
-
-func (s *Server) handleSearchArticles(w http.ResponseWriter, r *http.Request) {
-	var req SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
-		return
+	if err := db.CheckQueryPlans(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
 	}
 
-	s.searchArticles(w, r, req)
-}
+	// Initialize Qdrant client
+	log.Println("Connecting to Qdrant...")
+	vectorDB, err := vectordb.NewClient()
+	if err != nil {
+		log.Fatalf("Failed to connect to Qdrant: %v", err)
+	}
+	defer vectorDB.Close()
 
-func (s *Server) handleSearchArticlesGET(w http.ResponseWriter, r *http.Request) {
-	req := SearchRequest{
-		Query: r.URL.Query().Get("q"),
+	// Ensure collections exist
+	ctx := context.Background()
+	if err := vectorDB.EnsureCollections(ctx); err != nil {
+		log.Fatalf("Failed to ensure Qdrant collections: %v", err)
 	}
+	log.Println("Qdrant collections ready")
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			req.Limit = l
+	// Initialize embedding clients (one per vector space)
+	embedders := embedding.NewSet(vectorDB.VectorNames()...)
+	log.Printf("Embedding clients ready (provider: %s, models: %s)", embedders.Default().Provider().Name(), strings.Join(embedders.Models(), ", "))
+	if err := embedders.Route(languageRoutes); err != nil {
+		log.Fatalf("Invalid EMBEDDING_LANGUAGE_MODELS: %v", err)
+	}
+	for language, model := range embedders.Routes() {
+		log.Printf("Queries in %s use %s", language, model)
+	}
+	embedders.Schedule(scheduler)
+	log.Printf("Embedding retries: %d after %s, breaker after %d consecutive failures for %s",
+		retryPolicy.Retries, retryPolicy.Backoff, retryPolicy.Threshold, retryPolicy.Cooldown)
+	limits := scheduler.Limits()
+	log.Printf("Embedding concurrency: %d interactive, %d ingest, %d background",
+		limits[embedding.PriorityInteractive], limits[embedding.PriorityIngest], limits[embedding.PriorityBackground])
+	if err := embedding.CheckStrategy(ctx, db, "sources", strategies.Sources); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	var chunkOpts chunk.Options
+	if vectorDB.ChunksEnabled() {
+		if chunkOpts, err = chunk.OptionsFromEnv(); err != nil {
+			log.Fatalf("Invalid chunking options: %v", err)
 		}
 	}
 
-	s.searchArticles(w, r, req)
-}
-
-func (s *Server) searchArticles(w http.ResponseWriter, r *http.Request, req SearchRequest) {
-	if req.Query == "" {
-		writeError(w, http.StatusBadRequest, "query is required")
-		return
+	jobCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	if autolinkInterval > 0 {
+		log.Printf("Auto-linking every %s", autolinkInterval)
+		go autolink.Schedule(jobCtx, autolinkInterval, db, vectorDB, autolink.Options{})
+	}
+	if reportInterval > 0 {
+		log.Printf("Topic reports every %s", reportInterval)
+		go report.Schedule(jobCtx, reportInterval, db, report.Options{Webhook: os.Getenv("KB_REPORT_WEBHOOK_URL")})
+	}
+	if retentionInterval > 0 {
+		log.Printf("Retention pruning every %s", retentionInterval)
+		go retention.Schedule(jobCtx, retentionInterval, db, vectorDB, retention.Options{
+			Policies:   retentionPolicies,
+			ArchiveDir: os.Getenv("KB_RETENTION_ARCHIVE"),
+		})
+	}
+	if coldInterval > 0 {
+		log.Printf("Cold storage of sources idle for %s every %s", coldAfter, coldInterval)
+		go cold.Schedule(jobCtx, coldInterval, db, vectorDB, cold.Options{Idle: coldAfter})
+	}
+	if purgeInterval > 0 {
+		log.Printf("Purging sources deleted for %s every %s", purgeAfter, purgeInterval)
+		go purge.Schedule(jobCtx, purgeInterval, db, vectorDB, purge.Options{After: purgeAfter})
+	}
+	go webhook.Schedule(jobCtx, webhookInterval, db)
+	go watch.Schedule(jobCtx, watchInterval, db, vectorDB)
+
+	handler := api.NewServer(api.Deps{
+		DB:              db,
+		VectorDB:        vectorDB,
+		Embedders:       embedders,
+		SourceStrategy:  strategies.Sources,
+		ArticleStrategy: strategies.Articles,
+		ChunkOptions:    chunkOpts,
+		ScanMode:        scanMode,
+		Scholar:         scholarClient,
+		Geocoder:        geocoder,
+		SummaryStyles:   summaryStyles,
+
+		Reranker:         reranker,
+		RerankCandidates: rerankCandidates,
+		Generator:        generator,
+		Ollama:           ollama.FromEnv(),
+		Retention:        retentionPolicies,
+		ReadOnly:         readOnly,
+		RateLimit:        rateLimit,
+		Guard:            storeGuard,
+		AdminToken:       adminToken,
+		RequireAPIKey:    requireAPIKey,
+	})
+	// Started after NewServer, which sets how the guard replays queued writes
+	if storeGuard != nil {
+		log.Printf("Store guard every %s, queueing writes above %.1f%% divergence", guardInterval, 100*storeGuard.Status().Threshold)
+		go storeGuard.Schedule(jobCtx, guardInterval, db, vectorDB)
 	}
 
-	if req.Limit <= 0 {
-		req.Limit = 10
+	// Start server
+	httpServer := &http.Server{
+		Addr:         ":" + port,
+		Handler:      handler,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: api.WriteTimeout,
 	}
 
-	// Use FTS search for articles
-	articles, err := s.db.SearchArticles(req.Query, req.Limit)
-	if err != nil {
-		log.Printf("Article search failed: %v", err)
-		writeError(w, http.StatusInternalServerError, "Search failed")
-		return
-	}
-
-	// Convert to response format
-	results := make([]SearchResult, len(articles))
-	for i, a := range articles {
-		results[i] = SearchResult{
-			ID:      a.ID,
-			Title:   a.Title,
-			Summary: a.Summary,
-			Tags:    a.Tags,
+	// gRPC API alongside the HTTP one, served by the same handlers
+	var grpcServer *grpc.Server
+	if grpcPort := os.Getenv("KB_GRPC_PORT"); grpcPort != "" {
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port %s: %v", grpcPort, err)
 		}
+		grpcServer = api.NewGRPCServer(handler)
+		log.Printf("Knowledge-base gRPC server listening on port %s", grpcPort)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("gRPC server error: %v", err)
+			}
+		}()
 	}
 
-	writeJSON(w, http.StatusOK, SearchResponse{
-		Results: results,
-		Count:   len(results),
-	})
-}
-
-// Helper functions
-
-func writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
-}
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, ErrorResponse{Error: message})
-}
+	// Graceful shutdown
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		<-sigChan
 
-func getString(m map[string]interface{}, key string) string {
-	if v, ok := m[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
+		log.Println("Shutting down server...")
+		stopJobs()
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
 		}
-	}
-	return ""
-}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
 
-func decodeEmbedding(encoded string) ([]float32, error) {
-	data, err := base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return nil, err
+	if readOnly {
+		log.Println("Read-only mode: writes are refused")
 	}
-
-	if len(data)%4 != 0 {
-		return nil, fmt.Errorf("invalid embedding length")
+	if rateLimit.PerMinute > 0 {
+		log.Printf("Rate limit: %d requests per minute per client", rateLimit.PerMinute)
 	}
-
-	embedding := make([]float32, len(data)/4)
-	for i := range embedding {
-		bits := binary.LittleEndian.Uint32(data[i*4 : (i+1)*4])
-		embedding[i] = math.Float32frombits(bits)
+	log.Printf("Knowledge-base API server listening on port %s", port)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalf("Server error: %v", err)
 	}
-
-	return embedding, nil
+	log.Println("Server stopped")
 }
-
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// operatorOnly reports whether a request path exposes the records of every
+// user, whatever their visibility, so it is only served with the admin
+// token: webhooks receive every event, and vector points carry every
+// source's payload
+func operatorOnly(path string) bool {
+	return path == "/webhooks" || strings.HasPrefix(path, "/webhooks/") || scrollPath(path)
+}
+
+// adminContextKey marks the requests of operators in their context
+type adminContextKey struct{}
+
+// isAdmin reports whether a request was sent with the admin token
+func isAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminContextKey{}).(bool)
+	return admin
+}
+
+// adminMiddleware admits operators, who send the admin token
+// (Authorization: Bearer <token>). With a token set, /admin requests
+// without it answer 401. Operator-only requests (see operatorOnly) need the
+// token too, and answer 403 when none is set; other /admin requests are
+// then served as before, for a proxy in front to restrict.
+func adminMiddleware(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(secret)), []byte(token)) == 1 {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminContextKey{}, true)))
+			return
+		}
+		restricted := operatorOnly(r.URL.Path)
+		if token == "" {
+			if restricted {
+				writeError(w, http.StatusForbidden, "Disabled until an admin token is set (KB_ADMIN_TOKEN)")
+				return
+			}
+		} else if restricted || strings.HasPrefix(r.URL.Path, "/admin/") {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "Requires the admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
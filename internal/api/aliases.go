@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+func (s *Server) handleCreateAlias(w http.ResponseWriter, r *http.Request) {
+	var req database.Alias
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	var errs fieldErrors
+	if !database.ValidAliasKind(req.Kind) {
+		errs.add("kind", CodeInvalid, "kind must be source or article")
+	}
+	if req.OldID == "" {
+		errs.add("old_id", CodeRequired, "old_id is required")
+	}
+	if req.NewID == "" {
+		errs.add("new_id", CodeRequired, "new_id is required")
+	} else if req.NewID == req.OldID {
+		errs.add("new_id", CodeConflict, "old_id and new_id must differ")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if err := s.db.AddAlias(r.Context(), req); err != nil {
+		log.Printf("Failed to add alias: %v", err)
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// redirectAlias answers a lookup of a retired ID with a 301 to the current
+// record under prefix (e.g. "/sources/"), keeping the query string. It
+// reports whether it wrote a response.
+func (s *Server) redirectAlias(w http.ResponseWriter, r *http.Request, kind, id, prefix string) bool {
+	newID, err := s.db.ResolveAlias(r.Context(), kind, id)
+	if err != nil {
+		log.Printf("Failed to resolve alias: %v", err)
+		return false
+	}
+	if newID == "" {
+		return false
+	}
+
+	target := prefix + url.PathEscape(newID)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+)
+
+const (
+	// apiKeySecretPrefix starts every API key secret, so leaked keys are
+	// recognizable
+	apiKeySecretPrefix = "kb_"
+	// apiKeyPrefixLen is the length of the secret prefix kept in the clear
+	apiKeyPrefixLen = len(apiKeySecretPrefix) + 8
+	// maxAPIKeyGrace bounds how long a rotated secret keeps working
+	maxAPIKeyGrace = 7 * 24 * time.Hour
+	// apiKeyTouchInterval is how stale last_used_at may get, so keys in
+	// use don't cost a write per request
+	apiKeyTouchInterval = time.Minute
+)
+
+// apiKeyRoutes are the requests API keys may make: creating sources, in
+// the key's topics (see checkKeyScope)
+var apiKeyRoutes = map[string]bool{
+	"POST /sources":       true,
+	"POST /sources/batch": true,
+}
+
+// sourceWrite reports whether a request creates, changes or deletes
+// sources, which KB_REQUIRE_API_KEY reserves to API keys and operators
+func sourceWrite(r *http.Request) bool {
+	if apiKeyRoutes[r.Method+" "+r.URL.Path] || (r.Method == http.MethodPost && r.URL.Path == "/import") {
+		return true
+	}
+	id, ok := strings.CutPrefix(r.URL.Path, "/sources/")
+	if !ok || id == "" {
+		return false
+	}
+	switch r.Method {
+	case http.MethodPatch, http.MethodDelete:
+		return !strings.Contains(id, "/")
+	case http.MethodPost:
+		id, ok = strings.CutSuffix(id, "/restore")
+		return ok && id != "" && !strings.Contains(id, "/")
+	}
+	return false
+}
+
+// apiKeyContextKey holds the API key of a request in its context
+type apiKeyContextKey struct{}
+
+// requestAPIKey returns the API key a request was made with, or nil
+func requestAPIKey(ctx context.Context) *database.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*database.APIKey)
+	return key
+}
+
+// apiKeyMiddleware authenticates requests sent with an API key
+// (Authorization: Bearer kb_...), answering 401 to unknown keys and 403 to
+// anything but creating sources. Requests without a key, including those
+// with other credentials (e.g. for a proxy in front), are served as before,
+// except source writes when keys are required (see sourceWrite): those
+// answer 401 unless they carry the admin token.
+func (s *Server) apiKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(secret, apiKeySecretPrefix) {
+			if s.requireAPIKey && sourceWrite(r) && !isAdmin(r.Context()) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "Source writes require an API key or the admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		now := time.Now().UTC()
+		key, err := s.db.APIKeyBySecret(ctx, database.HashAPISecret(strings.TrimSpace(secret)), now.Format(time.RFC3339))
+		if err != nil {
+			log.Printf("Failed to look up API key: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to check API key")
+			return
+		}
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		if !apiKeyRoutes[r.Method+" "+r.URL.Path] {
+			writeError(w, http.StatusForbidden, "API keys may only create sources in their topics")
+			return
+		}
+		if last, err := time.Parse(time.RFC3339, key.LastUsedAt); err != nil || now.Sub(last) >= apiKeyTouchInterval {
+			if err := s.db.TouchAPIKey(ctx, key.ID, now.Format(time.RFC3339)); err != nil {
+				log.Printf("Failed to record use of API key %s: %v", key.ID, err)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, apiKeyContextKey{}, key)))
+	})
+}
+
+// checkKeyScope returns why the request's API key may not store the
+// sources, or "" when it may or there is no key: every source must be in
+// one of the key's topics, and so must the sources it would replace
+func (s *Server) checkKeyScope(ctx context.Context, srcs ...database.Source) (string, error) {
+	key := requestAPIKey(ctx)
+	if key == nil {
+		return "", nil
+	}
+	for _, src := range srcs {
+		if !key.AllowsTopic(src.Topic) {
+			return fmt.Sprintf("API key %s may not create sources in topic %q", key.ID, src.Topic), nil
+		}
+		existing, err := s.db.GetSource(ctx, src.ID)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil && !key.AllowsTopic(existing.Topic) {
+			return fmt.Sprintf("API key %s may not replace source %s of topic %q", key.ID, src.ID, existing.Topic), nil
+		}
+	}
+	return "", nil
+}
+
+// writeScopeError answers a request outside its API key's scope with 403,
+// or with 500 if the scope couldn't be checked
+func writeScopeError(w http.ResponseWriter, denied string, err error) {
+	if err != nil {
+		log.Printf("Failed to check API key scope: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to check API key scope")
+		return
+	}
+	writeError(w, http.StatusForbidden, denied)
+}
+
+// newAPIKeySecret returns a random secret and its prefix kept in the clear
+func newAPIKeySecret() (string, string) {
+	b := make([]byte, 24)
+	rand.Read(b)
+	secret := apiKeySecretPrefix + hex.EncodeToString(b)
+	return secret, secret[:apiKeyPrefixLen]
+}
+
+// handleListAPIKeys serves GET /admin/api-keys: every key, without secrets
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.db.ListAPIKeys(r.Context(), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		log.Printf("Failed to list API keys: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+	writeJSON(w, http.StatusOK, APIKeysResponse{Keys: keys})
+}
+
+// handleCreateAPIKey serves POST /admin/api-keys: it mints a key for the
+// given topics and returns its secret, which is not shown again
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	var errs fieldErrors
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errs.add("name", CodeRequired, "name is required")
+	}
+	var topics []string
+	for i, topic := range req.Topics {
+		topic = slug.Make(topic)
+		if topic == "" {
+			errs.add(fmt.Sprintf("topics[%d]", i), CodeInvalid, "topics must not be empty")
+		} else if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	if len(req.Topics) == 0 {
+		errs.add("topics", CodeRequired, "at least one topic is required")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	secret, prefix := newAPIKeySecret()
+	key := database.APIKey{
+		ID:        "key-" + hex.EncodeToString(b),
+		Name:      req.Name,
+		Topics:    topics,
+		Prefix:    prefix,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.db.CreateAPIKey(r.Context(), key, database.HashAPISecret(secret)); err != nil {
+		log.Printf("Failed to create API key: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+	log.Printf("Created API key %s (%s) for topics %s", key.ID, key.Name, strings.Join(key.Topics, ", "))
+	writeJSON(w, http.StatusCreated, APIKeyResponse{APIKey: key, Secret: secret})
+}
+
+// handleRotateAPIKey serves POST /admin/api-keys/{id}/rotate: it gives the
+// key a new secret. The old secret stops working at once, or after
+// grace_seconds so clients can switch over.
+func (s *Server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeBodyError(w)
+		return
+	}
+	if req.GraceSeconds < 0 || req.GraceSeconds > int(maxAPIKeyGrace/time.Second) {
+		writeFieldError(w, "grace_seconds", CodeOutOfRange, fmt.Sprintf("grace_seconds must be between 0 and %d", int(maxAPIKeyGrace/time.Second)))
+		return
+	}
+	grace := time.Duration(req.GraceSeconds) * time.Second
+
+	now := time.Now().UTC()
+	var previousExpiresAt string
+	if grace > 0 {
+		previousExpiresAt = now.Add(grace).Format(time.RFC3339)
+	}
+	secret, prefix := newAPIKeySecret()
+	id := r.PathValue("id")
+	key, err := s.db.RotateAPIKey(r.Context(), id, database.HashAPISecret(secret), prefix, now.Format(time.RFC3339), previousExpiresAt)
+	if err != nil {
+		log.Printf("Failed to rotate API key %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+	if key == nil {
+		writeError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	log.Printf("Rotated API key %s", key.ID)
+	writeJSON(w, http.StatusOK, APIKeyResponse{APIKey: *key, Secret: secret})
+}
+
+// handleDeleteAPIKey serves DELETE /admin/api-keys/{id}: it revokes the key
+// and all its secrets
+func (s *Server) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	found, err := s.db.DeleteAPIKey(r.Context(), id)
+	if err != nil {
+		log.Printf("Failed to delete API key %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete API key")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	log.Printf("Revoked API key %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,55 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/testsupport"
+)
+
+// TestRequireAPIKey checks that with RequireAPIKey, a source write without
+// an API key is refused, while one with a key or the admin token is stored
+func TestRequireAPIKey(t *testing.T) {
+	const adminToken = "operator-secret"
+	srv := testsupport.NewServer(t, testsupport.Options{SQLite: true, Configure: func(deps *api.Deps) {
+		deps.AdminToken = adminToken
+		deps.RequireAPIKey = true
+	}})
+
+	createSource := func(secret, url string) int {
+		t.Helper()
+		body, _ := json.Marshal(api.SourceRequest{URL: url, Title: "Sleep", Topic: "sleep", Summary: "How sleep works."})
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/sources", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		return srv.DoRequest(t, req, nil)
+	}
+
+	if status := createSource("", "https://example.com/keyless"); status != http.StatusUnauthorized {
+		t.Errorf("POST /sources without a key: status %d, want 401", status)
+	}
+	if status := createSource(adminToken, "https://example.com/operator"); status != http.StatusCreated {
+		t.Errorf("POST /sources with the admin token: status %d, want 201", status)
+	}
+
+	body, _ := json.Marshal(api.CreateAPIKeyRequest{Name: "sleep-scraper", Topics: []string{"sleep"}})
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/admin/api-keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	var key api.APIKeyResponse
+	if status := srv.DoRequest(t, req, &key); status != http.StatusCreated {
+		t.Fatalf("POST /admin/api-keys: status %d", status)
+	}
+	if status := createSource(key.Secret, "https://example.com/scraped"); status != http.StatusCreated {
+		t.Errorf("POST /sources with an API key: status %d, want 201", status)
+	}
+
+	if status := srv.Do(t, http.MethodGet, "/sources", nil, nil); status != http.StatusOK {
+		t.Errorf("GET /sources without a key: status %d, want 200", status)
+	}
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+func (s *Server) handleGetArticle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeFieldError(w, "id", CodeRequired, "id is required")
+		return
+	}
+
+	art, err := s.db.GetArticle(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if art == nil && s.redirectAlias(w, r, database.AliasArticle, id, "/articles/") {
+		return
+	}
+	if art == nil {
+		writeError(w, http.StatusNotFound, "Article not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, art)
+}
+
+func (s *Server) handleSearchArticles(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	s.searchArticles(w, r, req)
+}
+
+func (s *Server) handleSearchArticlesGET(w http.ResponseWriter, r *http.Request) {
+	req := SearchRequest{
+		Query: r.URL.Query().Get("q"),
+	}
+	req.Autocorrect, _ = strconv.ParseBool(r.URL.Query().Get("autocorrect"))
+	req.Semantic, _ = strconv.ParseBool(r.URL.Query().Get("semantic"))
+	req.LateInteraction, _ = strconv.ParseBool(r.URL.Query().Get("late_interaction"))
+	req.Model = r.URL.Query().Get("model")
+	req.Category = r.URL.Query().Get("category")
+	req.QueryToken = r.URL.Query().Get("query_token")
+	req.ReturnEmbedding, _ = strconv.ParseBool(r.URL.Query().Get("return_embedding"))
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = l
+		}
+	}
+
+	s.searchArticles(w, r, req)
+}
+
+func (s *Server) searchArticles(w http.ResponseWriter, r *http.Request, req SearchRequest) {
+	var errs fieldErrors
+	if s.resolveQueryToken(&req.Query, req.QueryToken, &errs); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if req.Query == "" {
+		writeFieldError(w, "query", CodeRequired, "query is required")
+		return
+	}
+	if req.Rerank {
+		writeFieldError(w, "rerank", CodeUnsupported, "rerank is only supported by source search")
+		return
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = parseFields(r.URL.Query().Get("fields"))
+	}
+	if req.SummaryMaxChars == 0 {
+		req.SummaryMaxChars = summaryMaxChars(r)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Semantic {
+		s.searchArticlesSemantic(w, r, req)
+		return
+	}
+
+	// Use FTS search for articles
+	ctx := r.Context()
+	articles, err := s.db.SearchArticles(ctx, req.Query, req.Limit)
+	if err != nil {
+		log.Printf("Article search failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	// Suggest a spelling correction when nothing matched
+	var didYouMean string
+	var corrected bool
+	if len(articles) == 0 {
+		didYouMean = s.suggestQuery(ctx, req.Query)
+	}
+	if didYouMean != "" && req.Autocorrect {
+		articles, err = s.db.SearchArticles(ctx, didYouMean, req.Limit)
+		if err != nil {
+			log.Printf("Article search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		corrected = true
+	}
+
+	results := keywordArticleResults(articles, req.SummaryMaxChars)
+	writeJSONFields(w, http.StatusOK, SearchResponse{
+		Results:    results,
+		Count:      len(results),
+		DidYouMean: didYouMean,
+		Corrected:  corrected,
+	}, "results", req.Fields)
+}
+
+// keywordArticleResults converts the articles of a keyword search to the
+// response format
+func keywordArticleResults(articles []database.Article, summaryMaxChars int) []SearchResult {
+	results := make([]SearchResult, len(articles))
+	for i, a := range articles {
+		results[i] = SearchResult{
+			ID:             a.ID,
+			Title:          a.Title,
+			Topic:          a.Topic(),
+			Summary:        truncateSummary(a.Summary, summaryMaxChars),
+			Tags:           a.Tags,
+			Snippet:        a.Snippet,
+			WordCount:      a.WordCount,
+			ReadingMinutes: a.ReadingMinutes,
+		}
+	}
+	return results
+}
+
+// searchArticlesSemantic searches articles by embedding. With chunks enabled
+// the query is matched against every chunk and each article is ranked by
+// its best chunk, with the chunks that matched; otherwise, and with late
+// interaction, against the whole-article points.
+func (s *Server) searchArticlesSemantic(w http.ResponseWriter, r *http.Request, req SearchRequest) {
+	var errs fieldErrors
+	if req.LateInteraction && !s.vectorDB.LateInteractionEnabled(vectordb.ArticlesCollection) {
+		errs.add("late_interaction", CodeUnsupported, "late_interaction is not enabled for articles")
+	}
+	embedder := s.embedders.Get(req.Model)
+	if req.Model == "" && req.Language != "" {
+		embedder = s.embedders.ForLanguage(req.Language)
+	}
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	emb, token, err := s.queryEmbedding(ctx, embedder, req.Query, req.QueryToken)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+
+	opts := vectordb.SearchOptions{Category: req.Category}
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = embedder.Model()
+	}
+	var matches []vectordb.ArticleMatch
+	scores := s.vectorDB.Distance(vectordb.ArticlesCollection).Scores()
+	if req.LateInteraction {
+		opts.LateInteraction = req.Query
+		scores = vectordb.LateInteractionScores()
+	}
+	if s.vectorDB.ChunksEnabled() && !req.LateInteraction {
+		scores = s.vectorDB.Distance(vectordb.ArticleChunksCollection).Scores()
+		matches, err = vectordb.SearchArticlesByChunk(ctx, s.vectorDB, emb, req.Limit, opts)
+	} else {
+		var hits []vectordb.SearchResult
+		hits, err = s.vectorDB.SearchArticles(ctx, emb, req.Limit, opts)
+		for _, h := range hits {
+			id, _ := h.Payload["id"].(string)
+			matches = append(matches, vectordb.ArticleMatch{ArticleID: id, Score: h.Score})
+		}
+	}
+	if err != nil {
+		log.Printf("Vector search failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	results, err := s.articleResults(ctx, matches, req.SummaryMaxChars)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	resp := SearchResponse{
+		Results:        results,
+		Count:          len(results),
+		EmbeddingModel: embedder.Model(),
+		Scores:         &scores,
+		QueryToken:     token,
+	}
+	if req.ReturnEmbedding {
+		resp.QueryEmbedding = encodeEmbedding(emb)
+	}
+	writeJSONFields(w, http.StatusOK, resp, "results", req.Fields)
+}
+
+// articleResults converts article matches to search results, reading the
+// articles from the database. Articles deleted since they were embedded are
+// left out.
+func (s *Server) articleResults(ctx context.Context, matches []vectordb.ArticleMatch, summaryMaxChars int) ([]SearchResult, error) {
+	results := make([]SearchResult, 0, len(matches))
+	for _, m := range matches {
+		a, err := s.db.GetArticle(ctx, m.ArticleID)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			continue
+		}
+		results = append(results, SearchResult{
+			ID:             a.ID,
+			Title:          a.Title,
+			Topic:          a.Topic(),
+			Summary:        truncateSummary(a.Summary, summaryMaxChars),
+			Score:          m.Score,
+			Tags:           a.Tags,
+			Chunks:         m.Chunks,
+			WordCount:      a.WordCount,
+			ReadingMinutes: a.ReadingMinutes,
+		})
+	}
+	return results, nil
+}
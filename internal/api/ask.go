@@ -0,0 +1,399 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+const (
+	// maxAskLimit bounds the sources, and articles, retrieved for a question
+	maxAskLimit = 20
+	// maxAskChars cuts the text of each passage given to the model
+	maxAskChars = 3000
+	// maxAskChunks is how many matched chunks of an article are passages
+	maxAskChunks = 2
+	// defaultSupportThreshold is the similarity from which a chunk of a
+	// cited passage supports a statement, unless a question sets its own
+	defaultSupportThreshold = 0.6
+)
+
+// supportChunks splits cited passages into the chunks statements are
+// compared with: short enough that a supporting sentence isn't drowned out
+// by the rest of a summary
+var supportChunks = chunk.Options{Size: 300, Overlap: 100}
+
+// askSystem is the system prompt of answers
+const askSystem = `You answer questions from a knowledge base. Work only from the numbered passages given; never add outside knowledge. Cite the passages each statement comes from by their numbers in brackets, as [1] or [2][3]. If the passages don't answer the question, say so briefly.`
+
+// askPrompt asks for the answer to a question from passages
+const askPrompt = `Question: %s
+
+Passages:
+
+%s
+
+Answer the question in a few sentences, citing the passages.`
+
+// citationMarker matches the citations of an answer: [1], [1, 3]
+var citationMarker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// citationStrip matches citations with the spaces before them, to remove
+// from statements
+var citationStrip = regexp.MustCompile(`\s*` + citationMarker.String())
+
+// askPassage is a retrieved text given to the model, cited by its number
+type askPassage struct {
+	citation Citation
+	label    string // Title of the passage: the record's, or its section's
+	text     string
+}
+
+// handleAsk serves POST /ask: it embeds the question, retrieves the closest
+// sources (and article chunks with QDRANT_ARTICLE_CHUNKS), and has the
+// generation model answer from them, citing the passages it used
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	if s.generator == nil {
+		writeError(w, http.StatusNotImplemented, "Generation is not configured (GENERATION_MODEL)")
+		return
+	}
+	var req AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	req.Topic = slug.Make(req.Topic)
+
+	var errs fieldErrors
+	if req.Question == "" {
+		errs.add("question", CodeRequired, "question is required")
+	}
+	if req.Limit == 0 {
+		req.Limit = 5
+	}
+	if req.Limit < 1 || req.Limit > maxAskLimit {
+		errs.add("limit", CodeOutOfRange, fmt.Sprintf("limit must be between 1 and %d", maxAskLimit))
+	}
+	threshold := defaultSupportThreshold
+	if req.SupportThreshold != nil {
+		threshold = *req.SupportThreshold
+		if threshold <= 0 || threshold > 1 {
+			errs.add("support_threshold", CodeOutOfRange, "support_threshold must be greater than 0 and at most 1")
+		}
+	}
+	embedder := s.embedders.Get(req.Model)
+	if req.Model == "" && req.Language != "" {
+		embedder = s.embedders.ForLanguage(req.Language)
+	}
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	emb, err := embedder.Embed(ctx, req.Question)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+	opts := vectordb.SearchOptions{Topic: req.Topic, User: userID(r)}
+	s.scopeTopic(ctx, &opts)
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = embedder.Model()
+	}
+	passages, err := s.askPassages(ctx, emb, req, opts)
+	if err != nil {
+		log.Printf("Retrieval for question failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	resp := AskResponse{Citations: []Citation{}, Retrieved: len(passages), EmbeddingModel: embedder.Model(), Verified: true}
+	if len(passages) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	var texts strings.Builder
+	for _, p := range passages {
+		fmt.Fprintf(&texts, "[%d] %s\n%s\n\n", p.citation.N, p.label, p.text)
+	}
+	answer, err := s.generator.Generate(ctx, fmt.Sprintf(askPrompt, req.Question, strings.TrimSpace(texts.String())),
+		generation.Options{System: askSystem})
+	if err != nil {
+		log.Printf("Failed to answer question: %v", err)
+		writeError(w, http.StatusBadGateway, "Generation failed")
+		return
+	}
+	resp.Answer, resp.Citations = citeAnswer(strings.TrimSpace(answer), passages)
+	resp.Model = s.generator.Model()
+
+	// A failed check leaves the answer unverified rather than failing it
+	resp.Statements, err = verifyAnswer(ctx, embedder, resp.Answer, passages, threshold)
+	if err != nil {
+		log.Printf("Failed to verify answer citations: %v", err)
+		resp.Verified = false
+	}
+	for _, st := range resp.Statements {
+		if !st.Supported {
+			resp.Unsupported++
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// askPassages retrieves the passages of a question: the summaries of the
+// closest sources the caller can see, then the matched chunks of the
+// closest articles when articles are chunked, numbered from 1
+func (s *Server) askPassages(ctx context.Context, emb []float32, req AskRequest, opts vectordb.SearchOptions) ([]askPassage, error) {
+	var passages []askPassage
+	add := func(c Citation, label, text string) {
+		if runes := []rune(text); len(runes) > maxAskChars {
+			text = string(runes[:maxAskChars]) + "…"
+		}
+		c.N = len(passages) + 1
+		passages = append(passages, askPassage{citation: c, label: label, text: text})
+	}
+
+	hits, err := s.vectorDB.SearchSources(ctx, emb, req.Limit, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range hits {
+		src, err := s.db.GetSource(ctx, hit.ID)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil || !src.VisibleTo(opts.User) {
+			continue
+		}
+		text := src.Summary
+		if src.Publication != nil && src.Publication.Abstract != "" {
+			text += "\n\nAbstract: " + src.Publication.Abstract
+		}
+		add(Citation{Kind: KindSource, ID: src.ID, Title: src.Title, URL: src.URL}, src.Title, text)
+	}
+
+	if !s.vectorDB.ChunksEnabled() {
+		return passages, nil
+	}
+	// Articles have no topic in their payload: topic questions fetch extra
+	// candidates to filter
+	fetch := req.Limit
+	if req.Topic != "" {
+		fetch = req.Limit * linkedCandidateFactor
+	}
+	articleOpts := opts
+	articleOpts.Topic, articleOpts.Subtopics, articleOpts.User = "", nil, ""
+	matches, err := vectordb.SearchArticlesByChunk(ctx, s.vectorDB, emb, fetch, articleOpts)
+	if err != nil {
+		return nil, err
+	}
+	articles := 0
+	for _, m := range matches {
+		if articles == req.Limit {
+			break
+		}
+		art, err := s.db.GetArticle(ctx, m.ArticleID)
+		if err != nil {
+			return nil, err
+		}
+		if art == nil || !opts.MatchesTopic(art.Topic()) {
+			continue
+		}
+		articles++
+		for i, chunk := range m.Chunks {
+			if i == maxAskChunks {
+				break
+			}
+			// Headings are paths from the article's top heading, usually
+			// its title
+			label := art.Title
+			if strings.HasPrefix(chunk.Heading, art.Title) {
+				label = chunk.Heading
+			} else if chunk.Heading != "" {
+				label += " > " + chunk.Heading
+			}
+			add(Citation{Kind: KindArticle, ID: art.ID, Title: art.Title}, label, chunk.Text)
+		}
+	}
+	return passages, nil
+}
+
+// citeAnswer returns the passages an answer cites, in passage order, and
+// the answer without citations of passages it wasn't given
+func citeAnswer(answer string, passages []askPassage) (string, []Citation) {
+	cited := make([]bool, len(passages)+1)
+	answer = citationMarker.ReplaceAllStringFunc(answer, func(marker string) string {
+		var kept []string
+		for _, part := range strings.Split(strings.Trim(marker, "[]"), ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n < 1 || n > len(passages) {
+				continue
+			}
+			cited[n] = true
+			kept = append(kept, strconv.Itoa(n))
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return "[" + strings.Join(kept, ", ") + "]"
+	})
+
+	citations := []Citation{}
+	for _, p := range passages {
+		if cited[p.citation.N] {
+			citations = append(citations, p.citation)
+		}
+	}
+	return answer, citations
+}
+
+// citedStatement is a sentence of an answer with the passage numbers it
+// cites
+type citedStatement struct {
+	text  string
+	cited []int
+}
+
+// citedStatements splits an answer into sentences and returns those that
+// cite passages. Citations opening a sentence belong to the previous one,
+// as in "... sleep. [2] Naps ...".
+func citedStatements(answer string) []citedStatement {
+	var all []citedStatement
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.TrimLeft(strings.Join(strings.Fields(line), " "), "-*• ")
+		first := len(all)
+		for _, sentence := range sentences(line) {
+			for {
+				loc := citationMarker.FindStringIndex(sentence)
+				if loc == nil || loc[0] != 0 || len(all) == first {
+					break
+				}
+				all[len(all)-1].cited = append(all[len(all)-1].cited, citationNumbers(sentence[:loc[1]])...)
+				sentence = strings.TrimSpace(sentence[loc[1]:])
+			}
+			var cited []int
+			for _, marker := range citationMarker.FindAllString(sentence, -1) {
+				cited = append(cited, citationNumbers(marker)...)
+			}
+			text := strings.TrimSpace(citationStrip.ReplaceAllString(sentence, ""))
+			if text != "" {
+				all = append(all, citedStatement{text: text, cited: cited})
+			}
+		}
+	}
+
+	var statements []citedStatement
+	for _, st := range all {
+		if len(st.cited) == 0 {
+			continue
+		}
+		seen := make(map[int]bool)
+		cited := st.cited[:0]
+		for _, n := range st.cited {
+			if !seen[n] {
+				seen[n] = true
+				cited = append(cited, n)
+			}
+		}
+		st.cited = cited
+		statements = append(statements, st)
+	}
+	return statements
+}
+
+// citationNumbers returns the passage numbers of a citation marker
+func citationNumbers(marker string) []int {
+	var numbers []int
+	for _, part := range strings.Split(strings.Trim(marker, "[]"), ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// sentences splits text after sentence-ending punctuation followed by a space
+func sentences(text string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		if strings.ContainsRune(".!?", rune(text[i])) && text[i+1] == ' ' {
+			out = append(out, text[start:i+1])
+			start = i + 2
+		}
+	}
+	if start < len(text) {
+		out = append(out, text[start:])
+	}
+	return out
+}
+
+// verifyAnswer checks that the passages an answer cites support it: each
+// cited statement is embedded with the chunks of the passages it cites, and
+// a passage supports the statement when one of its chunks is at least
+// threshold similar. The answer's citations must be those of passages.
+func verifyAnswer(ctx context.Context, embedder *embedding.Client, answer string, passages []askPassage, threshold float64) ([]AskStatement, error) {
+	cited := citedStatements(answer)
+	if len(cited) == 0 {
+		return nil, nil
+	}
+
+	// Statements and chunks are embedded in one batch: chunks maps each
+	// cited passage to the positions of its chunks in texts
+	texts := make([]string, 0, len(cited))
+	for _, st := range cited {
+		texts = append(texts, st.text)
+	}
+	chunks := make(map[int][]int)
+	for _, st := range cited {
+		for _, n := range st.cited {
+			if _, ok := chunks[n]; ok {
+				continue
+			}
+			chunks[n] = []int{}
+			for _, c := range chunk.Split(passages[n-1].text, supportChunks) {
+				chunks[n] = append(chunks[n], len(texts))
+				texts = append(texts, c.Text)
+			}
+		}
+	}
+	embeddings, err := embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]AskStatement, 0, len(cited))
+	for i, st := range cited {
+		statement := AskStatement{Text: st.text, Citations: []CitationSupport{}}
+		for _, n := range st.cited {
+			support := CitationSupport{N: n}
+			for _, j := range chunks[n] {
+				if score := embedding.CosineSimilarity(embeddings[i], embeddings[j]); support.Evidence == "" || score > support.Score {
+					support.Score, support.Evidence = score, texts[j]
+				}
+			}
+			support.Supported = support.Evidence != "" && float64(support.Score) >= threshold
+			statement.Supported = statement.Supported || support.Supported
+			statement.Citations = append(statement.Citations, support)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/sourcebatch"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+const (
+	// maxBatchSources bounds the sources of one POST /sources/batch request
+	maxBatchSources = 1000
+	// batchEmbedConcurrency is the number of sources embedded at once
+	batchEmbedConcurrency = 4
+)
+
+// handleCreateSources serves POST /sources/batch: it creates many sources at
+// once, embedding them concurrently and storing them in SQLite in a single
+// transaction. The batch is all or nothing: an invalid source or a failed
+// embedding stores none of them.
+func (s *Server) handleCreateSources(w http.ResponseWriter, r *http.Request) {
+	reqs, errs := decodeSourceBatch(r)
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if len(reqs) == 0 {
+		writeFieldError(w, "sources", CodeRequired, "at least one source is required")
+		return
+	}
+	if len(reqs) > maxBatchSources {
+		writeFieldError(w, "sources", CodeOutOfRange, fmt.Sprintf("at most %d sources per batch", maxBatchSources))
+		return
+	}
+
+	// Validate everything before embedding anything, listing the failures
+	// of every invalid source
+	owner := userID(r)
+	srcs := make([]database.Source, len(reqs))
+	results := make([]CreateSourceResponse, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for i, req := range reqs {
+		src, findings, srcErrs := s.newSource(req, owner)
+		if len(srcErrs) > 0 {
+			errs.nest("sources", i, srcErrs)
+			continue
+		}
+		// Generated IDs come from the clock, so keep them unique
+		for req.ID == "" && seen[src.ID] {
+			src.ID = fmt.Sprintf("%s-%d", src.ID, i)
+		}
+		seen[src.ID] = true
+		srcs[i] = src
+		results[i] = CreateSourceResponse{ID: src.ID, Findings: findings}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	// Writes yield the embedding provider to searches
+	ctx := embedding.WithPriority(r.Context(), embedding.PriorityIngest)
+	if denied, err := s.checkKeyScope(ctx, srcs...); err != nil || denied != "" {
+		writeScopeError(w, denied, err)
+		return
+	}
+
+	vectors, err := s.embedSources(ctx, srcs)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+
+	// Store in SQLite
+	if err := s.db.InsertSources(ctx, srcs); err != nil {
+		log.Printf("Failed to insert sources: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to store sources")
+		return
+	}
+
+	// Store in Qdrant, a batch of points per request
+	points := vectordb.NewBatch(vectordb.WithWait(ctx), s.vectorDB, vectordb.DefaultBatchSize, 0)
+	for i, src := range srcs {
+		if err := points.AddSource(vectordb.SourcePoint{ID: src.ID, Vectors: vectors[i], Payload: vectordb.NewSourcePayload(src)}); err != nil {
+			log.Printf("Failed to store embeddings: %v", err)
+			// Don't fail the request - SQLite has the data
+		}
+	}
+	if err := points.Close(); err != nil {
+		log.Printf("Failed to store embeddings: %v", err)
+	}
+
+	writeJSON(w, http.StatusCreated, CreateSourcesResponse{Count: len(results), Sources: results})
+}
+
+// decodeSourceBatch reads the sources of a POST /sources/batch body: a JSON
+// array of source requests, or a JSONL or CSV batch file when sent as
+// application/x-ndjson or text/csv, with columns renamed by ?columns=
+func decodeSourceBatch(r *http.Request) ([]SourceRequest, fieldErrors) {
+	var format string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		format = sourcebatch.FormatJSONL
+	case "text/csv":
+		format = sourcebatch.FormatCSV
+	default:
+		var reqs []SourceRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			return nil, fieldErrors{{Code: CodeMalformed, Message: "Invalid request body"}}
+		}
+		return reqs, nil
+	}
+
+	columns, err := sourcebatch.ParseColumns(r.URL.Query().Get("columns"))
+	if err != nil {
+		return nil, fieldErrors{{Field: "columns", Code: CodeInvalid, Message: fmt.Sprintf("invalid columns: %v", err)}}
+	}
+	records, err := sourcebatch.Decode(r.Body, format, columns)
+	if err != nil {
+		return nil, fieldErrors{{Code: CodeMalformed, Message: fmt.Sprintf("invalid %s body: %v", format, err)}}
+	}
+	reqs := make([]SourceRequest, len(records))
+	for i, rec := range records {
+		reqs[i] = SourceRequest{
+			ID:          rec.ID,
+			URL:         rec.URL,
+			Title:       rec.Title,
+			Topic:       rec.Topic,
+			Summary:     rec.Summary,
+			Language:    rec.Language,
+			Model:       rec.Model,
+			CreatedAt:   rec.Created,
+			PublishedAt: rec.Published,
+			Tags:        rec.Tags,
+			Visibility:  rec.Visibility,
+			Type:        rec.Type,
+			People:      rec.People,
+			Orgs:        rec.Orgs,
+			Places:      rec.Places,
+		}
+	}
+	return reqs, nil
+}
+
+// embedSources embeds sources with up to batchEmbedConcurrency at once (see
+// embedSource), returning their vectors in order. The first failure cancels
+// the rest.
+func (s *Server) embedSources(ctx context.Context, srcs []database.Source) ([]vectordb.Vectors, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	vectors := make([]vectordb.Vectors, len(srcs))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	next := make(chan int)
+	for range min(batchEmbedConcurrency, len(srcs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				v, err := s.embedSource(ctx, &srcs[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("source %s: %w", srcs[i].ID, err)
+						cancel()
+					})
+					continue
+				}
+				vectors[i] = v
+			}
+		}()
+	}
+
+feed:
+	for i := range srcs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
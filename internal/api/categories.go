@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// CategoryResponse is a category landing page
+type CategoryResponse struct {
+	database.Category
+	Subcategories []database.Category `json:"subcategories"`
+	Highlighted   []database.Article  `json:"highlighted"` // The curated highlights, in order
+	Articles      []database.Article  `json:"articles"`
+	Count         int                 `json:"count"` // Number of articles returned
+}
+
+// handleGetCategory serves GET /categories/{path...}: the category's index.md
+// description, its subcategories, highlighted articles and articles. A
+// directory without index.md is still served if it has articles or
+// subcategories.
+func (s *Server) handleGetCategory(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.PathValue("path"), "/")
+	ctx := r.Context()
+
+	cat, err := s.db.GetCategory(ctx, path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	subcategories, err := s.db.Subcategories(ctx, path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	articles, err := s.db.ArticlesByCategory(ctx, path, boundedInt(r, "limit", 100, 1000))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if cat == nil && len(subcategories) == 0 && len(articles) == 0 {
+		writeError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if cat == nil {
+		cat = &database.Category{Path: path, Title: path[strings.LastIndex(path, "/")+1:]}
+	}
+
+	resp := CategoryResponse{
+		Category:      *cat,
+		Subcategories: subcategories,
+		Highlighted:   []database.Article{},
+		Articles:      articles,
+		Count:         len(articles),
+	}
+	if resp.Subcategories == nil {
+		resp.Subcategories = []database.Category{}
+	}
+	if resp.Articles == nil {
+		resp.Articles = []database.Article{}
+	}
+	for _, id := range cat.Highlights {
+		art, err := s.db.GetArticle(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if art != nil { // Removed since the category was indexed
+			resp.Highlighted = append(resp.Highlighted, *art)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+const (
+	// maxClaimResults bounds the results of claim searches
+	maxClaimResults = 100
+	// defaultStanceClaims and maxStanceClaims bound the similar claims
+	// judged for a stance query, which all go into one prompt
+	defaultStanceClaims = 20
+	maxStanceClaims     = 50
+)
+
+// Stances the generation model assigns to a similar claim
+const (
+	stanceSupports    = "supports"
+	stanceContradicts = "contradicts"
+)
+
+// stanceSystem is the system prompt of stance judgements
+const stanceSystem = `You check statements against a claim for a fact index. Judge each statement only by what it says; never add outside knowledge.`
+
+// stancePrompt asks for the stance of every similar claim as JSON
+const stancePrompt = `For each numbered statement below, decide whether it supports the claim (asserts it, or something that implies it), contradicts it (asserts something incompatible with it), or is unrelated (is about something else, or neither implies nor rules it out).
+
+Answer with JSON only: {"judgements": [{"statement": "C1", "stance": "supports" | "contradicts" | "unrelated"}, ...]}
+
+Claim: %s
+
+Statements:
+%s`
+
+// handleSearchClaims serves POST /claims/search
+func (s *Server) handleSearchClaims(w http.ResponseWriter, r *http.Request) {
+	var req ClaimSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	s.searchClaims(w, r, req)
+}
+
+// handleSearchClaimsGET serves GET /claims/search?q=
+func (s *Server) handleSearchClaimsGET(w http.ResponseWriter, r *http.Request) {
+	req := ClaimSearchRequest{
+		Query: r.URL.Query().Get("q"),
+		Topic: r.URL.Query().Get("topic"),
+		Model: r.URL.Query().Get("model"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = l
+		}
+	}
+	s.searchClaims(w, r, req)
+}
+
+// searchClaims searches the claims extracted from source summaries by
+// embedding: the statements closest to the query, each with its source
+func (s *Server) searchClaims(w http.ResponseWriter, r *http.Request, req ClaimSearchRequest) {
+	if !s.vectorDB.ClaimsEnabled() {
+		writeError(w, http.StatusNotImplemented, "Claims are not enabled (QDRANT_CLAIMS)")
+		return
+	}
+	var errs fieldErrors
+	if req.Query == "" {
+		errs.add("query", CodeRequired, "query is required")
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+	if req.Limit < 1 || req.Limit > maxClaimResults {
+		errs.add("limit", CodeOutOfRange, fmt.Sprintf("limit must be between 1 and %d", maxClaimResults))
+	}
+	embedder := s.embedders.Get(req.Model)
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	matches, ok := s.similarClaims(w, r, req.Query, slug.Make(req.Topic), embedder.Model(), req.Limit)
+	if !ok {
+		return
+	}
+	scores := s.vectorDB.Distance(vectordb.ClaimsCollection).Scores()
+	writeJSON(w, http.StatusOK, ClaimSearchResponse{
+		Results:        matches,
+		Count:          len(matches),
+		EmbeddingModel: embedder.Model(),
+		Scores:         &scores,
+	})
+}
+
+// similarClaims embeds text and returns up to limit of the closest claims
+// of sources the caller can see. Claims of sources deleted or hidden since
+// they were stored are left out. On failure it writes the error response
+// and returns false.
+func (s *Server) similarClaims(w http.ResponseWriter, r *http.Request, text, topic, model string, limit int) ([]ClaimMatch, bool) {
+	ctx := r.Context()
+	emb, err := s.embedders.Get(model).Embed(ctx, text)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return nil, false
+	}
+
+	opts := vectordb.SearchOptions{Topic: topic, User: userID(r)}
+	s.scopeTopic(ctx, &opts)
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = model
+	}
+	hits, err := s.vectorDB.SearchClaims(ctx, emb, limit, opts)
+	if err != nil {
+		log.Printf("Claim search failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return nil, false
+	}
+
+	matches := []ClaimMatch{}
+	srcs := make(map[string]*database.Source)
+	for _, h := range hits {
+		payload := vectordb.ParseClaimPayload(h.Payload)
+		src, seen := srcs[payload.SourceID]
+		if !seen {
+			if src, err = s.db.GetSource(ctx, payload.SourceID); err != nil {
+				writeError(w, http.StatusInternalServerError, "Database error")
+				return nil, false
+			}
+			if src != nil && !src.VisibleTo(userID(r)) {
+				src = nil
+			}
+			srcs[payload.SourceID] = src
+		}
+		if src == nil {
+			continue
+		}
+		matches = append(matches, ClaimMatch{
+			SourceID: src.ID,
+			Title:    src.Title,
+			URL:      src.URL,
+			Topic:    src.Topic,
+			Position: payload.Claim,
+			Claim:    payload.Text,
+			Score:    h.Score,
+		})
+	}
+	return matches, true
+}
+
+// handleClaimStance serves POST /claims/stance: it finds the stored claims
+// closest to the given one and has the generation model judge whether each
+// supports or contradicts it, answering which sources back the claim and
+// which dispute it
+func (s *Server) handleClaimStance(w http.ResponseWriter, r *http.Request) {
+	if !s.vectorDB.ClaimsEnabled() {
+		writeError(w, http.StatusNotImplemented, "Claims are not enabled (QDRANT_CLAIMS)")
+		return
+	}
+	if s.generator == nil {
+		writeError(w, http.StatusNotImplemented, "Generation is not configured (GENERATION_MODEL)")
+		return
+	}
+	var req ClaimStanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	req.Claim = strings.TrimSpace(req.Claim)
+	var errs fieldErrors
+	if req.Claim == "" {
+		errs.add("claim", CodeRequired, "claim is required")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultStanceClaims
+	}
+	if req.Limit < 1 || req.Limit > maxStanceClaims {
+		errs.add("limit", CodeOutOfRange, fmt.Sprintf("limit must be between 1 and %d", maxStanceClaims))
+	}
+	embedder := s.embedders.Get(req.Model)
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	matches, ok := s.similarClaims(w, r, req.Claim, slug.Make(req.Topic), embedder.Model(), req.Limit)
+	if !ok {
+		return
+	}
+	resp := ClaimStanceResponse{
+		Claim:          req.Claim,
+		Supporting:     []StanceSource{},
+		Contradicting:  []StanceSource{},
+		Judged:         len(matches),
+		Model:          s.generator.Model(),
+		EmbeddingModel: embedder.Model(),
+	}
+	if len(matches) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	var statements strings.Builder
+	for i, m := range matches {
+		fmt.Fprintf(&statements, "[C%d] %s\n", i+1, m.Claim)
+	}
+	var judged struct {
+		Judgements []struct {
+			Statement string `json:"statement"`
+			Stance    string `json:"stance"`
+		} `json:"judgements"`
+	}
+	err := generation.GenerateJSON(r.Context(), s.generator, fmt.Sprintf(stancePrompt, req.Claim, statements.String()),
+		generation.Options{System: stanceSystem}, &judged)
+	if err != nil {
+		log.Printf("Failed to judge claims: %v", err)
+		writeError(w, http.StatusBadGateway, "Generation failed")
+		return
+	}
+
+	stances := make([]string, len(matches))
+	for _, j := range judged.Judgements {
+		var n int
+		if _, err := fmt.Sscanf(strings.Trim(strings.TrimSpace(j.Statement), "[]"), "C%d", &n); err != nil || n < 1 || n > len(matches) {
+			continue
+		}
+		stances[n-1] = strings.ToLower(strings.TrimSpace(j.Stance))
+	}
+	for i, m := range matches {
+		switch stances[i] {
+		case stanceSupports:
+			resp.Supporting = addStanceClaim(resp.Supporting, m)
+		case stanceContradicts:
+			resp.Contradicting = addStanceClaim(resp.Contradicting, m)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// addStanceClaim adds a claim to its source in a stance list, adding the
+// source after the others when it isn't listed yet
+func addStanceClaim(list []StanceSource, m ClaimMatch) []StanceSource {
+	for i := range list {
+		if list[i].ID == m.SourceID {
+			list[i].Claims = append(list[i].Claims, m)
+			return list
+		}
+	}
+	return append(list, StanceSource{ID: m.SourceID, Title: m.Title, URL: m.URL, Claims: []ClaimMatch{m}})
+}
+
+// handleGetSourceClaims serves GET /claims/sources/{id}: the claims
+// extracted from a source's summary
+func (s *Server) handleGetSourceClaims(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	src, err := s.db.GetSource(ctx, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src == nil || !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+	set, err := s.db.SourceClaims(ctx, src.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if set == nil {
+		writeError(w, http.StatusNotFound, "No claims extracted for this source")
+		return
+	}
+	writeJSON(w, http.StatusOK, set)
+}
+
+// syncClaimPayloads copies the title, topic and visibility of an updated
+// source to the payloads of its claims, so claim searches filter it like
+// the source. Failures are logged: SQLite has the data, and stale payloads
+// are checked against it on every search.
+func (s *Server) syncClaimPayloads(r *http.Request, src database.Source) {
+	if !s.vectorDB.ClaimsEnabled() {
+		return
+	}
+	err := s.vectorDB.SetPayloadWhere(vectordb.WithWait(r.Context()), vectordb.ClaimsCollection,
+		vectordb.Filter{"source_id": src.ID}, map[string]any{
+			"title":      src.Title,
+			"topic":      src.Topic,
+			"visibility": src.Visibility,
+			"owner":      src.Owner,
+		})
+	if err != nil {
+		log.Printf("Failed to update claims of source %s: %v", src.ID, err)
+	}
+}
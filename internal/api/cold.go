@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/claims"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// hitResolution is how precisely the last hit of a source is tracked: a
+// source is recorded again once its last recorded hit is older
+const hitResolution = time.Hour
+
+// hitCache holds when this server last recorded the hit of each source, so
+// popular sources aren't written on every request
+type hitCache struct {
+	mu       sync.Mutex
+	recorded map[string]time.Time
+}
+
+// recordHits records that sources were hit, fetched or returned by a
+// search, for the cold storage job (see package cold). Failures are logged:
+// tracking never fails a request.
+func (s *Server) recordHits(ctx context.Context, ids []string) {
+	now := time.Now()
+	var due []string
+	s.hits.mu.Lock()
+	if s.hits.recorded == nil {
+		s.hits.recorded = make(map[string]time.Time)
+	}
+	for _, id := range ids {
+		if now.Sub(s.hits.recorded[id]) >= hitResolution {
+			s.hits.recorded[id] = now
+			due = append(due, id)
+		}
+	}
+	s.hits.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+	if err := s.db.RecordSourceHits(ctx, due, now.UTC().Format(time.RFC3339)); err != nil {
+		log.Printf("Failed to record source hits: %v", err)
+		s.hits.mu.Lock()
+		for _, id := range due {
+			delete(s.hits.recorded, id)
+		}
+		s.hits.mu.Unlock()
+	}
+}
+
+// warmSource re-embeds a cold source, whose vectors the cold storage job
+// dropped, with its claims, so vector search and the endpoints reading its
+// vectors find it again. It reports whether the source was cold.
+func (s *Server) warmSource(ctx context.Context, src database.Source) (bool, error) {
+	access, err := s.db.GetSourceAccess(ctx, src.ID)
+	if err != nil || access.ColdAt == "" {
+		return false, err
+	}
+	vectors, err := s.embedders.EmbedDocument(ctx, s.strategy, sourceDocument(src))
+	if err != nil {
+		return true, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	if err := s.vectorDB.UpsertSourceVectors(vectordb.WithWait(ctx), src.ID, vectors, vectordb.NewSourcePayload(src)); err != nil {
+		return true, fmt.Errorf("failed to store embedding: %w", err)
+	}
+	if s.vectorDB.ClaimsEnabled() {
+		set, err := s.db.SourceClaims(ctx, src.ID)
+		if err != nil {
+			return true, err
+		}
+		if set != nil && len(set.Claims) > 0 {
+			points, err := claims.Points(ctx, s.embedders, src, set.Claims)
+			if err != nil {
+				return true, fmt.Errorf("failed to embed claims: %w", err)
+			}
+			if err := s.vectorDB.ReplaceSourceClaims(ctx, src.ID, points); err != nil {
+				return true, fmt.Errorf("failed to store claims: %w", err)
+			}
+		}
+	}
+	if err := s.db.SetSourceCold(ctx, src.ID, ""); err != nil {
+		return true, err
+	}
+	log.Printf("Re-embedded cold source %s", src.ID)
+	return true, nil
+}
+
+// warmSources re-embeds the cold sources among ids, for the endpoints that
+// read their vectors; unknown IDs are left to those endpoints
+func (s *Server) warmSources(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		src, err := s.db.GetSource(ctx, id)
+		if err != nil {
+			return err
+		}
+		if src == nil {
+			continue
+		}
+		if _, err := s.warmSource(ctx, *src); err != nil {
+			return fmt.Errorf("source %s: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+)
+
+const (
+	// minCompareSources and maxCompareSources bound the sources of one
+	// comparison; more would not fit a model's context with their texts
+	minCompareSources = 2
+	maxCompareSources = 10
+	// maxCompareChars cuts the text of each compared source
+	maxCompareChars = 6000
+)
+
+// compareSystem is the system prompt of source comparisons
+const compareSystem = `You compare sources for a research synthesis. Work only from the texts given; never add outside knowledge. Cite sources by their labels (S1, S2, ...) exactly as given.`
+
+// comparePrompt asks for the comparison as JSON
+const comparePrompt = `Compare the sources below%s.
+
+Answer with a JSON object with three lists:
+- "agreements": statements that two or more sources support, as {"statement": "...", "sources": ["S1", "S2"]}
+- "contradictions": issues on which sources disagree, as {"issue": "...", "positions": [{"source": "S1", "claim": "..."}, {"source": "S2", "claim": "..."}]}
+- "unique_claims": notable claims only one source makes, as {"source": "S1", "claim": "..."}
+
+Keep each statement and claim to one sentence. Leave a list empty rather than stretching.
+
+%s`
+
+// generatedComparison is the comparison as the model writes it, citing
+// sources by label
+type generatedComparison struct {
+	Agreements     []Agreement     `json:"agreements"`
+	Contradictions []Contradiction `json:"contradictions"`
+	UniqueClaims   []SourceClaim   `json:"unique_claims"`
+}
+
+// handleCompareSources serves POST /sources/compare: the generation model
+// compares the summaries (and paper abstracts) of the sources and lists
+// where they agree, where they contradict each other, and what only one of
+// them claims, each citing source IDs
+func (s *Server) handleCompareSources(w http.ResponseWriter, r *http.Request) {
+	if s.generator == nil {
+		writeError(w, http.StatusNotImplemented, "Generation is not configured (GENERATION_MODEL)")
+		return
+	}
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	var errs fieldErrors
+	if len(req.IDs) < minCompareSources || len(req.IDs) > maxCompareSources {
+		errs.add("ids", CodeOutOfRange, fmt.Sprintf("ids must list %d to %d sources", minCompareSources, maxCompareSources))
+	}
+	seen := make(map[string]bool, len(req.IDs))
+	for i, id := range req.IDs {
+		if seen[id] {
+			errs.add(fmt.Sprintf("ids[%d]", i), CodeConflict, fmt.Sprintf("source %s is listed twice", id))
+		}
+		seen[id] = true
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	srcs := make([]database.Source, len(req.IDs))
+	for i, id := range req.IDs {
+		src, err := s.db.GetSource(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if src == nil || !src.VisibleTo(userID(r)) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Source %s not found", id))
+			return
+		}
+		srcs[i] = *src
+	}
+
+	focus := ""
+	if req.Focus != "" {
+		focus = fmt.Sprintf(" with regard to: %s", req.Focus)
+	}
+	var generated generatedComparison
+	err := generation.GenerateJSON(ctx, s.generator, fmt.Sprintf(comparePrompt, focus, compareTexts(srcs)),
+		generation.Options{System: compareSystem}, &generated)
+	if err != nil {
+		log.Printf("Failed to compare sources: %v", err)
+		writeError(w, http.StatusBadGateway, "Generation failed")
+		return
+	}
+
+	resp := citeComparison(generated, srcs)
+	resp.Model = s.generator.Model()
+	for _, src := range srcs {
+		resp.Sources = append(resp.Sources, CompareSource{ID: src.ID, Title: src.Title, URL: src.URL})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// compareTexts lists the sources for the prompt under their labels: S1 for
+// the first, and so on
+func compareTexts(srcs []database.Source) string {
+	var b strings.Builder
+	for i, src := range srcs {
+		text := src.Summary
+		if src.Publication != nil && src.Publication.Abstract != "" {
+			text += "\n\nAbstract: " + src.Publication.Abstract
+		}
+		if runes := []rune(text); len(runes) > maxCompareChars {
+			text = string(runes[:maxCompareChars]) + "…"
+		}
+		fmt.Fprintf(&b, "[S%d] %s\n%s\n\n", i+1, src.Title, text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// citeComparison replaces the labels the model cited with source IDs. Claims
+// citing no compared source are dropped, as are agreements and
+// contradictions left with fewer than two sources.
+func citeComparison(g generatedComparison, srcs []database.Source) CompareResponse {
+	cite := func(label string) (string, bool) {
+		var n int
+		if _, err := fmt.Sscanf(strings.Trim(strings.TrimSpace(label), "[]"), "S%d", &n); err != nil || n < 1 || n > len(srcs) {
+			return "", false
+		}
+		return srcs[n-1].ID, true
+	}
+
+	resp := CompareResponse{Agreements: []Agreement{}, Contradictions: []Contradiction{}, UniqueClaims: []SourceClaim{}}
+	for _, a := range g.Agreements {
+		var ids []string
+		for _, label := range a.Sources {
+			if id, ok := cite(label); ok && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		if a.Statement != "" && len(ids) >= 2 {
+			resp.Agreements = append(resp.Agreements, Agreement{Statement: a.Statement, Sources: ids})
+		}
+	}
+	for _, c := range g.Contradictions {
+		var positions []SourceClaim
+		cited := make(map[string]bool)
+		for _, p := range c.Positions {
+			if id, ok := cite(p.Source); ok && p.Claim != "" {
+				positions = append(positions, SourceClaim{Source: id, Claim: p.Claim})
+				cited[id] = true
+			}
+		}
+		if c.Issue != "" && len(cited) >= 2 {
+			resp.Contradictions = append(resp.Contradictions, Contradiction{Issue: c.Issue, Positions: positions})
+		}
+	}
+	for _, u := range g.UniqueClaims {
+		if id, ok := cite(u.Source); ok && u.Claim != "" {
+			resp.UniqueClaims = append(resp.UniqueClaims, SourceClaim{Source: id, Claim: u.Claim})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/simhash"
+)
+
+// dedupeCandidateFactor is how many more candidates are fetched when
+// duplicates are collapsed, so the collapsed results still fill the page
+const dedupeCandidateFactor = 2
+
+// collapseDuplicates folds results that share a canonical URL or have
+// near-identical summaries (e.g. an article syndicated on several domains)
+// into the best-ranked of them, which lists the others as alternates. The
+// order of the remaining results is kept.
+func collapseDuplicates(results []SearchResult) []SearchResult {
+	type seen struct {
+		index       int // In the collapsed results
+		fingerprint uint64
+		ok          bool // Whether the summary has a fingerprint
+	}
+	var kept []seen
+	byURL := make(map[string]int)
+
+	collapsed := make([]SearchResult, 0, len(results))
+	for _, res := range results {
+		canonical := database.CanonicalURL(res.URL)
+		fp, ok := simhash.Fingerprint(res.Summary)
+
+		dup := -1
+		if i, found := byURL[canonical]; found && res.URL != "" {
+			dup = i
+		} else if ok {
+			for _, k := range kept {
+				if k.ok && simhash.Near(k.fingerprint, fp) {
+					dup = k.index
+					break
+				}
+			}
+		}
+		if dup >= 0 {
+			collapsed[dup].Alternates = append(collapsed[dup].Alternates, Alternate{
+				ID:    res.ID,
+				URL:   res.URL,
+				Title: res.Title,
+				Score: res.Score,
+			})
+			continue
+		}
+
+		if res.URL != "" {
+			byURL[canonical] = len(collapsed)
+		}
+		kept = append(kept, seen{index: len(collapsed), fingerprint: fp, ok: ok})
+		collapsed = append(collapsed, res)
+	}
+	return collapsed
+}
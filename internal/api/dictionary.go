@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// handleGetDictionary serves GET /admin/search/dictionary: the synonyms and
+// stopwords applied to keyword queries
+func (s *Server) handleGetDictionary(w http.ResponseWriter, r *http.Request) {
+	dict, err := s.db.SearchDictionary(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, dict)
+}
+
+// handleSetSynonyms serves PUT /admin/search/synonyms/{term}, replacing the
+// term's synonyms
+func (s *Server) handleSetSynonyms(w http.ResponseWriter, r *http.Request) {
+	var req SynonymsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	var errs fieldErrors
+	term := database.NormalizeTerm(r.PathValue("term"))
+	if term == "" {
+		errs.add("term", CodeInvalid, "term must contain a letter or digit")
+	} else if len(database.NormalizeSynonyms(term, req.Expansions)) == 0 {
+		errs.add("expansions", CodeRequired, "expansions are required")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if err := s.db.SetSynonyms(r.Context(), term, req.Expansions); err != nil {
+		log.Printf("Failed to set synonyms: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to set synonyms")
+		return
+	}
+	s.handleGetDictionary(w, r)
+}
+
+// handleDeleteSynonyms serves DELETE /admin/search/synonyms/{term}
+func (s *Server) handleDeleteSynonyms(w http.ResponseWriter, r *http.Request) {
+	term := database.NormalizeTerm(r.PathValue("term"))
+	if term == "" {
+		writeFieldError(w, "term", CodeInvalid, "term must contain a letter or digit")
+		return
+	}
+	if err := s.db.SetSynonyms(r.Context(), term, nil); err != nil {
+		log.Printf("Failed to delete synonyms: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete synonyms")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetStopword serves PUT and DELETE /admin/search/stopwords/{word}
+func (s *Server) handleSetStopword(w http.ResponseWriter, r *http.Request) {
+	word := database.NormalizeTerm(r.PathValue("word"))
+	if word == "" {
+		writeFieldError(w, "word", CodeInvalid, "word must contain a letter or digit")
+		return
+	}
+	stop := r.Method == http.MethodPut
+	if err := s.db.SetStopword(r.Context(), word, stop); err != nil {
+		log.Printf("Failed to update stopwords: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update stopwords")
+		return
+	}
+	if !stop {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.handleGetDictionary(w, r)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+)
+
+const (
+	// suggestionCandidates caps how many entities are embedded and compared
+	// pairwise when suggesting aliases
+	suggestionCandidates = 200
+	// defaultSuggestionScore is the cosine similarity above which two entity
+	// names are suggested as spellings of the same entity
+	defaultSuggestionScore = 0.9
+)
+
+// EntityAliasSuggestion is a candidate alias found by embedding similarity.
+// Canonical is the more mentioned of the two names.
+type EntityAliasSuggestion struct {
+	Kind      string  `json:"kind"`
+	Alias     string  `json:"alias"`
+	Canonical string  `json:"canonical"`
+	Score     float32 `json:"score"`
+}
+
+func (s *Server) handleListEntities(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if !database.ValidEntityKind(kind) {
+		writeFieldError(w, "kind", CodeInvalid, "kind must be person, org, or place")
+		return
+	}
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	entities, err := s.db.Entities(r.Context(), kind, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entities": entities,
+		"count":    len(entities),
+	})
+}
+
+func (s *Server) handleGetEntitySources(w http.ResponseWriter, r *http.Request) {
+	var errs fieldErrors
+	kind := r.PathValue("kind")
+	if !database.ValidEntityKind(kind) {
+		errs.add("kind", CodeInvalid, "kind must be person, org, or place")
+	}
+	name := r.URL.Query().Get("name")
+	if database.EntityKey(name) == "" {
+		errs.add("name", CodeRequired, "name is required")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	ctx := r.Context()
+	canonical, err := s.db.ResolveEntity(ctx, kind, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	aliases, err := s.db.EntityAliases(ctx, kind, canonical)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	sources, err := s.db.SourcesByEntity(ctx, kind, canonical, limit, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	maxChars := summaryMaxChars(r)
+	for i := range sources {
+		sources[i].Summary = truncateSummary(sources[i].Summary, maxChars)
+	}
+
+	writeJSONFields(w, http.StatusOK, map[string]interface{}{
+		"kind":      kind,
+		"canonical": canonical,
+		"aliases":   aliases,
+		"sources":   sources,
+		"count":     len(sources),
+	}, "sources", parseFields(r.URL.Query().Get("fields")))
+}
+
+func (s *Server) handleCreateEntityAlias(w http.ResponseWriter, r *http.Request) {
+	var req database.EntityAlias
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	var errs fieldErrors
+	if !database.ValidEntityKind(req.Kind) {
+		errs.add("kind", CodeInvalid, "kind must be person, org, or place")
+	}
+	if database.EntityKey(req.Alias) == "" {
+		errs.add("alias", CodeRequired, "alias is required")
+	}
+	if database.EntityKey(req.Canonical) == "" {
+		errs.add("canonical", CodeRequired, "canonical is required")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if err := s.db.AddEntityAlias(r.Context(), req); err != nil {
+		log.Printf("Failed to add entity alias: %v", err)
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSuggestEntityAliases embeds the names of the most mentioned entities
+// of a kind and returns the pairs whose similarity reaches min_score, for a
+// curator to confirm via POST /entities/aliases. Nothing is merged here.
+func (s *Server) handleSuggestEntityAliases(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if !database.ValidEntityKind(kind) {
+		writeFieldError(w, "kind", CodeInvalid, "kind must be person, org, or place")
+		return
+	}
+	minScore := float32(defaultSuggestionScore)
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("min_score"), 32); err == nil && v > 0 {
+		minScore = float32(v)
+	}
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	ctx := r.Context()
+	entities, err := s.db.Entities(ctx, kind, suggestionCandidates)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	names := make([]string, len(entities))
+	for i, e := range entities {
+		names[i] = e.Name
+	}
+	vectors, err := s.embedders.Default().EmbedBatch(ctx, names)
+	if err != nil {
+		log.Printf("Failed to embed entity names: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+
+	// Entities come most mentioned first, so the earlier name of a pair is
+	// suggested as the canonical one
+	suggestions := []EntityAliasSuggestion{}
+	for i := range entities {
+		for j := i + 1; j < len(entities); j++ {
+			score := embedding.CosineSimilarity(vectors[i], vectors[j])
+			if score < minScore {
+				continue
+			}
+			suggestions = append(suggestions, EntityAliasSuggestion{
+				Kind:      kind,
+				Alias:     entities[j].Name,
+				Canonical: entities[i].Name,
+				Score:     score,
+			})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// handleListEvents serves GET /events: a page of the event log. The data of
+// events about sources the caller can't see is withheld.
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		v, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || v < 0 {
+			writeFieldError(w, "since", CodeInvalid, "since must be a non-negative event sequence number")
+			return
+		}
+		since = v
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = min(l, 1000)
+		}
+	}
+
+	events, err := s.db.EventsSince(r.Context(), since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if events == nil {
+		events = []database.Event{}
+	}
+	user := userID(r)
+	for i, ev := range events {
+		visible, err := database.EventVisibleTo(r.Context(), s.db, ev, user)
+		if err != nil {
+			log.Printf("Failed to check visibility of event %d: %v", ev.Seq, err)
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if !visible {
+			events[i].Data, events[i].Redacted = nil, true
+		}
+	}
+
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].Seq
+	}
+
+	writeJSON(w, http.StatusOK, EventsResponse{
+		Events:     events,
+		Count:      len(events),
+		NextCursor: next,
+	})
+}
+
+func (s *Server) handleVerifyEvents(w http.ResponseWriter, r *http.Request) {
+	verified, err := s.db.VerifyEvents(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"valid":    false,
+			"verified": verified,
+			"error":    err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"valid":    true,
+		"verified": verified,
+	})
+}
@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/testsupport"
+)
+
+// TestEventsHidePrivateSources checks that GET /events withholds the record
+// of a private source from everyone but its owner
+func TestEventsHidePrivateSources(t *testing.T) {
+	for _, sqlite := range []bool{false, true} {
+		srv := testsupport.NewServer(t, testsupport.Options{SQLite: sqlite})
+
+		body, _ := json.Marshal(api.SourceRequest{
+			URL:        "https://example.com/secret-plans",
+			Title:      "Secret plans",
+			Topic:      "plans",
+			Summary:    "The summary only alice may read.",
+			Visibility: database.VisibilityPrivate,
+		})
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/sources", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "alice")
+		var created api.CreateSourceResponse
+		if status := srv.DoRequest(t, req, &created); status != http.StatusCreated {
+			t.Fatalf("POST /sources: status %d", status)
+		}
+
+		for user, visible := range map[string]bool{"": false, "bob": false, "alice": true} {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+			if user != "" {
+				req.Header.Set("X-User-ID", user)
+			}
+			var resp api.EventsResponse
+			if status := srv.DoRequest(t, req, &resp); status != http.StatusOK {
+				t.Fatalf("GET /events as %q: status %d", user, status)
+			}
+			found := false
+			for _, ev := range resp.Events {
+				if ev.EntityID != created.ID {
+					continue
+				}
+				found = true
+				leaked := strings.Contains(string(ev.Data), "alice may read")
+				if leaked != visible || ev.Redacted == visible {
+					t.Errorf("sqlite=%v: GET /events as %q: event %d data %s, redacted %v", sqlite, user, ev.Seq, ev.Data, ev.Redacted)
+				}
+			}
+			if !found {
+				t.Errorf("sqlite=%v: GET /events as %q: no event for source %s", sqlite, user, created.ID)
+			}
+		}
+	}
+}
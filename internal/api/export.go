@@ -0,0 +1,299 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// Record types of GET /export lines
+const (
+	ExportSource  = "source"
+	ExportArticle = "article"
+	// ExportTombstone is a source or article deleted since the cursor of
+	// GET /export/delta
+	ExportTombstone = "tombstone"
+	// ExportCursor ends GET /export/delta with the cursor of the next delta
+	ExportCursor = "cursor"
+)
+
+// deltaPageSize is how many events GET /export/delta reads at a time
+const deltaPageSize = 1000
+
+// ExportRecord is a line of GET /export: a source or an article, with its
+// tags, metadata and content (for articles, markdown). GET /export/delta
+// adds tombstones and a final cursor.
+type ExportRecord struct {
+	Type      string            `json:"type"`
+	Source    *database.Source  `json:"source,omitempty"`
+	Article   *database.Article `json:"article,omitempty"`
+	Tombstone *Tombstone        `json:"tombstone,omitempty"`
+	Cursor    int64             `json:"cursor,omitempty"`
+}
+
+// Tombstone is a record deleted since the cursor of a delta export
+type Tombstone struct {
+	Kind string `json:"kind"` // source or article
+	ID   string `json:"id"`
+	// DeletedAt is when the record was deleted; absent for sources that
+	// the caller can no longer see
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// handleExport serves GET /export: every source visible to the caller, then
+// every article, streamed as NDJSON in ID order. With gzip=true the stream
+// is gzip-compressed, ready to be saved as a .ndjson.gz file.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	compress, _ := strconv.ParseBool(r.URL.Query().Get("gzip"))
+	ctx := r.Context()
+
+	out, done := exportWriter(w, compress, "knowledge-base.ndjson.gz")
+	defer done()
+
+	enc := json.NewEncoder(out)
+	var last string
+	err := s.exportRecords(ctx, userID(r), func(rec ExportRecord) error {
+		if rec.Source != nil {
+			last = rec.Source.ID
+		} else {
+			last = rec.Article.ID
+		}
+		return enc.Encode(rec)
+	})
+	if err != nil {
+		// The status is already sent: end the stream with the error
+		log.Printf("Export failed after %q: %v", last, err)
+		enc.Encode(map[string]string{"error": "Export failed", "last_id": last})
+	}
+}
+
+// exportWriter sets the headers of an NDJSON export and returns the writer
+// of its records, gzip-compressed as a download named filename when
+// compress is set. done flushes the compressed stream. Exports outlive the
+// server's write timeout.
+func exportWriter(w http.ResponseWriter, compress bool, filename string) (out io.Writer, done func()) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if !compress {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return w, func() {}
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	zw := gzip.NewWriter(w)
+	return zw, func() { zw.Close() }
+}
+
+// deltaChange is the last change of a record in the event log
+type deltaChange struct {
+	kind      string
+	id        string
+	seq       int64
+	deletedAt string // Set for deletions
+}
+
+// handleExportDelta serves GET /export/delta?since=<cursor>: the sources
+// and articles created, updated or deleted after an event log cursor, or
+// since a time, in the format of GET /export. Each changed record appears
+// once, as it is now or as a tombstone, in the order of its last change; a
+// final cursor record is the since of the next delta. Records changed
+// while the delta is written may be sent again by the next one.
+func (s *Server) handleExportDelta(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sinceStr := r.URL.Query().Get("since")
+	if sinceStr == "" {
+		writeFieldError(w, "since", CodeRequired, "since is required: an event cursor, an RFC 3339 time or a date")
+		return
+	}
+	cursor, err := strconv.ParseInt(sinceStr, 10, 64)
+	if err != nil {
+		t, terr := parseTimeBound(sinceStr)
+		if terr != nil {
+			writeFieldError(w, "since", CodeInvalid, "since must be an event cursor, an RFC 3339 time or a date (2006-01-02)")
+			return
+		}
+		if cursor, err = s.db.EventCursorAt(ctx, t); err != nil {
+			log.Printf("Failed to find the event cursor of %s: %v", sinceStr, err)
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	} else if cursor < 0 {
+		writeFieldError(w, "since", CodeInvalid, "since must be a non-negative event cursor")
+		return
+	}
+
+	changes, next, err := s.deltaChanges(ctx, cursor)
+	if err != nil {
+		log.Printf("Failed to read the event log after %d: %v", cursor, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	compress, _ := strconv.ParseBool(r.URL.Query().Get("gzip"))
+	out, done := exportWriter(w, compress, "knowledge-base-delta.ndjson.gz")
+	defer done()
+
+	enc := json.NewEncoder(out)
+	user := userID(r)
+	for _, change := range changes {
+		rec, err := s.deltaRecord(ctx, change, user)
+		if err == nil && rec != nil {
+			err = enc.Encode(rec)
+		}
+		if err != nil {
+			// The status is already sent: end the stream with the error
+			log.Printf("Delta export failed at %s %q: %v", change.kind, change.id, err)
+			enc.Encode(map[string]string{"error": "Export failed", "last_id": change.id})
+			return
+		}
+	}
+	enc.Encode(ExportRecord{Type: ExportCursor, Cursor: next})
+}
+
+// deltaChanges reads the event log after cursor and returns the sources and
+// articles changed, by their last change, with the cursor of the last event
+func (s *Server) deltaChanges(ctx context.Context, cursor int64) ([]deltaChange, int64, error) {
+	last := make(map[string]*deltaChange)
+	for {
+		events, err := s.db.EventsSince(ctx, cursor, deltaPageSize)
+		if err != nil {
+			return nil, cursor, err
+		}
+		for _, ev := range events {
+			cursor = ev.Seq
+			change := deltaChange{id: ev.EntityID, seq: ev.Seq}
+			switch ev.Type {
+			case database.EventSourceUpserted:
+				change.kind = ExportSource
+			case database.EventSourceDeleted:
+				change.kind, change.deletedAt = ExportSource, ev.CreatedAt
+			case database.EventArticleUpserted:
+				change.kind = ExportArticle
+			case database.EventArticleDeleted:
+				change.kind, change.deletedAt = ExportArticle, ev.CreatedAt
+			default:
+				continue
+			}
+			last[change.kind+"/"+change.id] = &change
+		}
+		if len(events) < deltaPageSize {
+			break
+		}
+	}
+
+	changes := make([]deltaChange, 0, len(last))
+	for _, change := range last {
+		changes = append(changes, *change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].seq < changes[j].seq })
+	return changes, cursor, nil
+}
+
+// deltaRecord returns the export record of a changed source or article: the
+// record as stored, or a tombstone if it is gone or, for sources, no longer
+// visible to user. Sources user couldn't see before they went get no
+// record (nil), so tombstones don't reveal them.
+func (s *Server) deltaRecord(ctx context.Context, change deltaChange, user string) (*ExportRecord, error) {
+	tombstone := &ExportRecord{Type: ExportTombstone, Tombstone: &Tombstone{Kind: change.kind, ID: change.id, DeletedAt: change.deletedAt}}
+	if change.kind == ExportSource {
+		if change.deletedAt != "" {
+			return s.sourceTombstone(ctx, tombstone, change.seq, user)
+		}
+		src, err := s.db.GetSource(ctx, change.id)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil {
+			// Purged since: its last upsert is what user may have seen
+			return s.sourceTombstone(ctx, tombstone, change.seq+1, user)
+		}
+		if !src.VisibleTo(user) {
+			return s.sourceTombstone(ctx, tombstone, change.seq, user)
+		}
+		if src.Content, err = s.db.SourceContent(ctx, change.id); err != nil {
+			return nil, err
+		}
+		return &ExportRecord{Type: ExportSource, Source: src}, nil
+	}
+
+	if change.deletedAt != "" {
+		return tombstone, nil
+	}
+	art, err := s.db.GetArticle(ctx, change.id)
+	if err != nil {
+		return nil, err
+	}
+	if art == nil {
+		return tombstone, nil
+	}
+	if art.Content, err = s.db.ArticleContent(ctx, change.id); err != nil {
+		return nil, err
+	}
+	return &ExportRecord{Type: ExportArticle, Article: art}, nil
+}
+
+// sourceTombstone returns the tombstone of a source if user could see it as
+// of its last upsert before seq, or nil
+func (s *Server) sourceTombstone(ctx context.Context, tombstone *ExportRecord, seq int64, user string) (*ExportRecord, error) {
+	prev, err := s.db.PreviousEvent(ctx, tombstone.Tombstone.ID, seq, database.EventSourceUpserted)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+	visible, err := database.EventVisibleTo(ctx, s.db, *prev, user)
+	if err != nil || !visible {
+		return nil, err
+	}
+	return tombstone, nil
+}
+
+// exportRecords calls fn for every source visible to user, then for every
+// article, in ID order. Records deleted while the export runs are skipped.
+func (s *Server) exportRecords(ctx context.Context, user string, fn func(ExportRecord) error) error {
+	sourceIDs, err := s.db.SourceIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range sourceIDs {
+		src, err := s.db.GetSource(ctx, id)
+		if err != nil {
+			return err
+		}
+		if src == nil || !src.VisibleTo(user) {
+			continue
+		}
+		if src.Content, err = s.db.SourceContent(ctx, id); err != nil {
+			return err
+		}
+		if err := fn(ExportRecord{Type: ExportSource, Source: src}); err != nil {
+			return err
+		}
+	}
+
+	articleIDs, err := s.db.ArticleIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range articleIDs {
+		art, err := s.db.GetArticle(ctx, id)
+		if err != nil {
+			return err
+		}
+		if art == nil {
+			continue
+		}
+		if art.Content, err = s.db.ArticleContent(ctx, id); err != nil {
+			return err
+		}
+		if err := fn(ExportRecord{Type: ExportArticle, Article: art}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
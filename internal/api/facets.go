@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// facetType is the only facet currently supported
+const facetType = "type"
+
+// Facets maps a faceted field to the number of matching sources per value
+type Facets map[string]map[string]int
+
+// validFacets reports whether every requested facet is supported
+func validFacets(facets []string) bool {
+	for _, f := range facets {
+		if f != facetType {
+			return false
+		}
+	}
+	return true
+}
+
+// searchFacets counts the sources matching a vector query's filters by type.
+// The type filter itself is left out, so clients can show the other types
+// alongside the selected one. Failures are logged and leave facets out.
+func (s *Server) searchFacets(ctx context.Context, facets []string, opts vectordb.SearchOptions) Facets {
+	if len(facets) == 0 {
+		return nil
+	}
+	opts.Type = ""
+	counts, err := s.vectorDB.FacetSources(ctx, facetType, opts)
+	if err != nil {
+		log.Printf("Facet counts failed: %v", err)
+		return nil
+	}
+	return Facets{facetType: counts}
+}
+
+// listFacets is searchFacets for SQL listings
+func (s *Server) listFacets(ctx context.Context, facets []string, filter database.SourceFilter, user string) Facets {
+	if len(facets) == 0 {
+		return nil
+	}
+	filter.Type = ""
+	counts, err := s.db.CountSourcesByType(ctx, filter, user)
+	if err != nil {
+		log.Printf("Facet counts failed: %v", err)
+		return nil
+	}
+	return Facets{facetType: counts}
+}
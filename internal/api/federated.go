@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// maxFederatedResults bounds the results of a federated search
+const maxFederatedResults = 100
+
+// rrfK is the rank constant of Reciprocal Rank Fusion: the result at rank
+// r (from 0) of a list scores 1/(rrfK+r+1)
+const rrfK = 60
+
+// keywordScores marks the keyword lists of a federated search, whose
+// results have no scores to compare
+var keywordScores = vectordb.ScoreSemantics{Metric: "keyword"}
+
+// handleFederatedSearch serves POST /search: it embeds the query once,
+// searches the sources and articles collections with it (and with keyword
+// the FTS indexes), and returns one ranking of both, each result tagged
+// with its kind
+func (s *Server) handleFederatedSearch(w http.ResponseWriter, r *http.Request) {
+	var req FederatedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	req.Topic = slug.Make(req.Topic)
+	if len(req.Fields) == 0 {
+		req.Fields = parseFields(r.URL.Query().Get("fields"))
+	}
+	if req.SummaryMaxChars == 0 {
+		req.SummaryMaxChars = summaryMaxChars(r)
+	}
+
+	var errs fieldErrors
+	s.resolveQueryToken(&req.Query, req.QueryToken, &errs)
+	if strings.TrimSpace(req.Query) == "" && req.QueryToken == "" {
+		errs.add("query", CodeRequired, "query is required")
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+	if req.Limit < 1 || req.Limit > maxFederatedResults {
+		errs.add("limit", CodeOutOfRange, fmt.Sprintf("limit must be between 1 and %d", maxFederatedResults))
+	}
+	kinds := map[string]bool{KindSource: len(req.Kinds) == 0, KindArticle: len(req.Kinds) == 0}
+	for _, kind := range req.Kinds {
+		if kind != KindSource && kind != KindArticle {
+			errs.add("kinds", CodeInvalid, "kinds must be source or article")
+			break
+		}
+		kinds[kind] = true
+	}
+	embedder := s.embedders.Get(req.Model)
+	if req.Model == "" && req.Language != "" {
+		embedder = s.embedders.ForLanguage(req.Language)
+	}
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	emb, token, err := s.queryEmbedding(ctx, embedder, req.Query, req.QueryToken)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+	opts := vectordb.SearchOptions{Topic: req.Topic, User: userID(r)}
+	s.scopeTopic(ctx, &opts)
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = embedder.Model()
+	}
+
+	// Articles have no topic in their payload, nor sources in the FTS
+	// index, so topic searches fetch extra candidates to filter
+	fetch := req.Limit
+	if req.Topic != "" {
+		fetch = req.Limit * linkedCandidateFactor
+	}
+
+	var lists [][]SearchResult
+	var semantics []vectordb.ScoreSemantics
+	if kinds[KindSource] {
+		hits, err := s.vectorDB.SearchSources(ctx, emb, req.Limit, opts)
+		if err != nil {
+			log.Printf("Vector search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		lists = append(lists, withKind(sourceResults(hits), KindSource))
+		semantics = append(semantics, s.vectorDB.Distance(vectordb.SourcesCollection).Scores())
+	}
+	if kinds[KindArticle] {
+		results, scores, err := s.federatedArticles(ctx, emb, fetch, opts, req)
+		if err != nil {
+			log.Printf("Vector search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		lists = append(lists, withKind(results, KindArticle))
+		semantics = append(semantics, scores)
+	}
+	if req.Keyword && kinds[KindSource] {
+		srcs, err := s.db.SearchSources(ctx, req.Query, fetch, userID(r))
+		if err != nil {
+			log.Printf("Source search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		var results []SearchResult
+		for _, src := range srcs {
+			if opts.MatchesTopic(src.Topic) {
+				results = append(results, sourceRecordResult(src))
+			}
+		}
+		lists = append(lists, withKind(results, KindSource))
+		semantics = append(semantics, keywordScores)
+	}
+	if req.Keyword && kinds[KindArticle] {
+		articles, err := s.db.SearchArticles(ctx, req.Query, fetch)
+		if err != nil {
+			log.Printf("Article search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		var matched []database.Article
+		for _, a := range articles {
+			if opts.MatchesTopic(a.Topic()) {
+				matched = append(matched, a)
+			}
+		}
+		results := keywordArticleResults(matched, req.SummaryMaxChars)
+		lists = append(lists, withKind(results, KindArticle))
+		semantics = append(semantics, keywordScores)
+	}
+
+	results, scores, fused := mergeResults(lists, semantics)
+	if len(results) > req.Limit {
+		results = results[:req.Limit]
+	}
+	truncateSummaries(results, req.SummaryMaxChars)
+
+	resp := SearchResponse{
+		Results:        results,
+		Count:          len(results),
+		EmbeddingModel: embedder.Model(),
+		Hybrid:         fused,
+		Scores:         &scores,
+		QueryToken:     token,
+	}
+	if req.ReturnEmbedding {
+		resp.QueryEmbedding = encodeEmbedding(emb)
+	}
+	writeJSONFields(w, http.StatusOK, resp, "results", req.Fields)
+}
+
+// withKind tags search results with their kind
+func withKind(results []SearchResult, kind string) []SearchResult {
+	for i := range results {
+		results[i].Kind = kind
+	}
+	return results
+}
+
+// federatedArticles searches the articles closest to an embedding like
+// semantic article search does (by chunk when chunks are enabled), keeping
+// those of the requested topic and its subtopics, and returns them with the
+// semantics of their scores
+func (s *Server) federatedArticles(ctx context.Context, emb []float32, limit int, opts vectordb.SearchOptions, req FederatedSearchRequest) ([]SearchResult, vectordb.ScoreSemantics, error) {
+	articleOpts := opts
+	articleOpts.Topic, articleOpts.Subtopics, articleOpts.User = "", nil, ""
+	var matches []vectordb.ArticleMatch
+	var err error
+	scores := s.vectorDB.Distance(vectordb.ArticlesCollection).Scores()
+	if s.vectorDB.ChunksEnabled() {
+		scores = s.vectorDB.Distance(vectordb.ArticleChunksCollection).Scores()
+		matches, err = vectordb.SearchArticlesByChunk(ctx, s.vectorDB, emb, limit, articleOpts)
+	} else {
+		var hits []vectordb.SearchResult
+		hits, err = s.vectorDB.SearchArticles(ctx, emb, limit, articleOpts)
+		for _, h := range hits {
+			id, _ := h.Payload["id"].(string)
+			matches = append(matches, vectordb.ArticleMatch{ArticleID: id, Score: h.Score})
+		}
+	}
+	if err != nil {
+		return nil, scores, err
+	}
+
+	results, err := s.articleResults(ctx, matches, req.SummaryMaxChars)
+	if err != nil {
+		return nil, scores, err
+	}
+	if opts.Topic == "" {
+		return results, scores, nil
+	}
+	kept := results[:0]
+	for _, res := range results {
+		if opts.MatchesTopic(res.Topic) {
+			kept = append(kept, res)
+		}
+	}
+	return kept, scores, nil
+}
+
+// sourceRecordResult converts a stored source to the response format
+func sourceRecordResult(src database.Source) SearchResult {
+	return SearchResult{
+		ID:             src.ID,
+		URL:            src.URL,
+		Title:          src.Title,
+		Topic:          src.Topic,
+		Summary:        src.Summary,
+		Tags:           src.Tags,
+		Language:       src.Language,
+		Model:          src.Model,
+		CreatedAt:      src.CreatedAt,
+		Type:           src.Type,
+		WordCount:      src.WordCount,
+		ReadingMinutes: src.ReadingMinutes,
+	}
+}
+
+// mergeResults merges the result lists of a federated search, best first.
+// Lists whose scores read the same way (the same metric) are merged by
+// score. Otherwise, and always with keyword lists, the lists are fused by
+// rank with Reciprocal Rank Fusion, a result found by several lists
+// summing its scores; it reports whether they were fused.
+func mergeResults(lists [][]SearchResult, semantics []vectordb.ScoreSemantics) ([]SearchResult, vectordb.ScoreSemantics, bool) {
+	comparable := len(semantics) > 0
+	for _, sem := range semantics {
+		if sem.Metric != semantics[0].Metric || sem.Metric == keywordScores.Metric {
+			comparable = false
+		}
+	}
+
+	if comparable {
+		merged := []SearchResult{}
+		for _, list := range lists {
+			merged = append(merged, list...)
+		}
+		higherIsBetter := semantics[0].HigherIsBetter
+		sort.SliceStable(merged, func(i, j int) bool {
+			if higherIsBetter {
+				return merged[i].Score > merged[j].Score
+			}
+			return merged[i].Score < merged[j].Score
+		})
+		return merged, semantics[0], false
+	}
+
+	merged := []SearchResult{}
+	index := make(map[string]int)
+	for _, list := range lists {
+		for rank, res := range list {
+			score := float32(1.0 / float64(rrfK+rank+1))
+			key := res.Kind + "\x00" + res.ID
+			i, seen := index[key]
+			if !seen {
+				index[key] = len(merged)
+				res.Score = score
+				merged = append(merged, res)
+				continue
+			}
+			merged[i].Score += score
+			if merged[i].Snippet == "" {
+				merged[i].Snippet = res.Snippet
+			}
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	return merged, vectordb.FusedScores(), true
+}
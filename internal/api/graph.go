@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+const (
+	defaultPathDepth = 4
+	maxPathDepth     = 6
+	maxNeighborDepth = 3
+	// defaultNeighborNodes caps the size of a neighborhood response
+	defaultNeighborNodes = 200
+)
+
+// graphNode looks up the node named by a query parameter, writing an error
+// response and returning nil if it is missing, invalid or unknown
+func (s *Server) graphNode(w http.ResponseWriter, r *http.Request, param string) *database.GraphNode {
+	id := r.URL.Query().Get(param)
+	if _, _, ok := database.ParseNodeID(id); !ok {
+		writeFieldError(w, param, CodeInvalid,
+			param+" must be a node ID like source:<id>, article:<id>, topic:<slug>, person:<name>, org:<name> or place:<name>")
+		return nil
+	}
+	node, err := s.db.GraphNode(r.Context(), id, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil
+	}
+	if node == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Node not found: %s", id))
+		return nil
+	}
+	return node
+}
+
+// boundedInt reads a positive integer query parameter, falling back to def
+// and capping it at upper
+func boundedInt(r *http.Request, param string, def, upper int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(param))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return min(v, upper)
+}
+
+func (s *Server) handleGraphPath(w http.ResponseWriter, r *http.Request) {
+	from := s.graphNode(w, r, "from")
+	if from == nil {
+		return
+	}
+	to := s.graphNode(w, r, "to")
+	if to == nil {
+		return
+	}
+	maxDepth := boundedInt(r, "max_depth", defaultPathDepth, maxPathDepth)
+
+	path, err := database.ShortestPath(r.Context(), s.db, *from, *to, maxDepth, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	resp := map[string]interface{}{
+		"from":  from.ID,
+		"to":    to.ID,
+		"found": path != nil,
+	}
+	if path != nil {
+		resp["length"] = len(path.Edges)
+		resp["nodes"] = path.Nodes
+		resp["edges"] = path.Edges
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGraphNeighbors(w http.ResponseWriter, r *http.Request) {
+	node := s.graphNode(w, r, "node")
+	if node == nil {
+		return
+	}
+	depth := boundedInt(r, "depth", 1, maxNeighborDepth)
+	limit := boundedInt(r, "limit", defaultNeighborNodes, database.GraphFanout*maxNeighborDepth)
+
+	nodes, edges, err := database.Neighborhood(r.Context(), s.db, *node, depth, limit, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"node":  node.ID,
+		"nodes": nodes,
+		"edges": edges,
+		"count": len(nodes),
+	})
+}
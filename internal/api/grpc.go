@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	kbv1 "github.com/gitopedia/knowledge-base/proto/kb/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcHeaders are the metadata keys passed on to the HTTP handler as headers
+var grpcHeaders = []string{"authorization", "x-user-id"}
+
+// maxGRPCRedirects bounds the alias redirects a gRPC call follows
+const maxGRPCRedirects = 3
+
+// grpcService serves the KnowledgeBase gRPC service (proto/kb/v1) with the
+// HTTP handler of each operation, so both APIs share validation, API keys,
+// read-only mode and rate limits
+type grpcService struct {
+	kbv1.UnimplementedKnowledgeBaseServer
+	handler http.Handler
+}
+
+// NewGRPCServer returns a gRPC server of the KnowledgeBase service, whose
+// calls are answered by handler, the HTTP API of NewServer
+func NewGRPCServer(handler http.Handler) *grpc.Server {
+	srv := grpc.NewServer()
+	kbv1.RegisterKnowledgeBaseServer(srv, &grpcService{handler: handler})
+	return srv
+}
+
+func (g *grpcService) CreateSource(ctx context.Context, req *kbv1.CreateSourceRequest) (*kbv1.CreateSourceResponse, error) {
+	resp := new(kbv1.CreateSourceResponse)
+	return resp, g.call(ctx, http.MethodPost, "/sources", req, resp)
+}
+
+func (g *grpcService) SearchSources(ctx context.Context, req *kbv1.SearchSourcesRequest) (*kbv1.SearchSourcesResponse, error) {
+	resp := new(kbv1.SearchSourcesResponse)
+	return resp, g.call(ctx, http.MethodPost, "/sources/search", req, resp)
+}
+
+func (g *grpcService) GetArticle(ctx context.Context, req *kbv1.GetArticleRequest) (*kbv1.Article, error) {
+	if req.GetId() == "" {
+		return nil, fieldViolations(ErrorResponse{
+			Error:  "id is required",
+			Errors: []FieldError{{Field: "id", Code: CodeRequired, Message: "id is required"}},
+		})
+	}
+	resp := new(kbv1.Article)
+	return resp, g.call(ctx, http.MethodGet, "/articles/"+url.PathEscape(req.GetId()), nil, resp)
+}
+
+// call serves a request to the HTTP handler: in is the JSON body, in the
+// endpoint's field names, and the response body is decoded into out. GET
+// requests follow redirects, such as those of renamed articles.
+func (g *grpcService) call(ctx context.Context, method, path string, in, out proto.Message) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = (protojson.MarshalOptions{UseProtoNames: true}).Marshal(in); err != nil {
+			return status.Errorf(codes.Internal, "failed to encode request: %v", err)
+		}
+	}
+
+	for redirects := 0; ; redirects++ {
+		r, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to create request: %v", err)
+		}
+		if in != nil {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, key := range grpcHeaders {
+			if values := md.Get(key); len(values) > 0 {
+				r.Header.Set(key, values[0])
+			}
+		}
+		// The client address keys the rate limit
+		if p, ok := peer.FromContext(ctx); ok {
+			r.RemoteAddr = p.Addr.String()
+		}
+
+		w := &responseBuffer{header: make(http.Header), status: http.StatusOK}
+		g.handler.ServeHTTP(w, r)
+
+		if location := w.header.Get("Location"); method == http.MethodGet && w.status/100 == 3 && location != "" && redirects < maxGRPCRedirects {
+			path = location
+			continue
+		}
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if w.status/100 != 2 {
+			return grpcError(w.status, w.body.Bytes())
+		}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(w.body.Bytes(), out); err != nil {
+			return status.Errorf(codes.Internal, "failed to decode response: %v", err)
+		}
+		return nil
+	}
+}
+
+// grpcError converts an HTTP error response to a gRPC status
+func grpcError(httpStatus int, body []byte) error {
+	var resp ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == "" {
+		resp.Error = http.StatusText(httpStatus)
+	}
+	if httpStatus == http.StatusBadRequest {
+		return fieldViolations(resp)
+	}
+	return status.Error(grpcCode(httpStatus), resp.Error)
+}
+
+// fieldViolations returns the INVALID_ARGUMENT status of a validation
+// error, with its field errors as BadRequest details
+func fieldViolations(resp ErrorResponse) error {
+	st := status.New(codes.InvalidArgument, resp.Error)
+	if len(resp.Errors) == 0 {
+		return st.Err()
+	}
+	details := &errdetails.BadRequest{}
+	for _, fe := range resp.Errors {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Message,
+			Reason:      fe.Code,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode maps an HTTP error status to the gRPC code of the same meaning
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// responseBuffer is the http.ResponseWriter of a gRPC call
+type responseBuffer struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
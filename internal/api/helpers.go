@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// apiKeyUserPrefix starts the user ID of requests made with an API key
+const apiKeyUserPrefix = "apikey:"
+
+// userID identifies the caller for per-user features and visibility. A
+// request made with an API key acts as the key ("apikey:<id>"), whatever
+// its X-User-ID, so the private sources it creates are the key's. Other
+// requests are the user of their X-User-ID header, which only a trusted
+// proxy in front should set; headers naming a key are ignored.
+func userID(r *http.Request) string {
+	if key := requestAPIKey(r.Context()); key != nil {
+		return apiKeyUserPrefix + key.ID
+	}
+	user := r.Header.Get("X-User-ID")
+	if strings.HasPrefix(user, apiKeyUserPrefix) {
+		return ""
+	}
+	return user
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
+
+// summaryMaxChars reads the summary_max_chars query parameter (0 if unset or invalid)
+func summaryMaxChars(r *http.Request) int {
+	n, _ := strconv.Atoi(r.URL.Query().Get("summary_max_chars"))
+	return n
+}
+
+// parseTimeBound parses a time filter: an RFC 3339 time, or a date
+// (2006-01-02) meaning its start in UTC
+func parseTimeBound(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// parseTimeRange parses the bounds of a time filter, e.g. created_after and
+// created_before for name "created" (see parseTimeBound), recording invalid
+// bounds in errs. Empty bounds are zero times, i.e. open ends.
+func parseTimeRange(errs *fieldErrors, name, after, before string) (time.Time, time.Time) {
+	var from, to time.Time
+	var err error
+	if after != "" {
+		if from, err = parseTimeBound(after); err != nil {
+			errs.add(name+"_after", CodeInvalid, name+"_after must be an RFC 3339 time or a date (2006-01-02)")
+		}
+	}
+	if before != "" {
+		if to, err = parseTimeBound(before); err != nil {
+			errs.add(name+"_before", CodeInvalid, name+"_before must be an RFC 3339 time or a date (2006-01-02)")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		errs.add(name+"_after", CodeConflict, fmt.Sprintf("%s_after must be before %s_before", name, name))
+	}
+	return from, to
+}
+
+// parseFields splits a fields parameter ("id,title,score") into field names
+func parseFields(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// writeJSONFields writes data like writeJSON, keeping only the given JSON
+// fields of each item in its listKey array (a sparse fieldset). Unknown
+// field names are ignored; with no fields the full items are written.
+func writeJSONFields(w http.ResponseWriter, status int, data interface{}, listKey string, fields []string) {
+	if len(fields) == 0 {
+		writeJSON(w, status, data)
+		return
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	var resp map[string]json.RawMessage
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &resp); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	if err := json.Unmarshal(resp[listKey], &items); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	selected := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		selected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := item[f]; ok {
+				selected[i][f] = v
+			}
+		}
+	}
+	resp[listKey], _ = json.Marshal(selected)
+	writeJSON(w, status, resp)
+}
+
+// sourceResults converts source vector hits to the response format
+func sourceResults(results []vectordb.SearchResult) []SearchResult {
+	searchResults := make([]SearchResult, len(results))
+	for i, r := range results {
+		words := getInt(r.Payload, "word_count")
+		searchResults[i] = SearchResult{
+			ID:             r.ID,
+			Score:          r.Score,
+			URL:            getString(r.Payload, "url"),
+			Title:          getString(r.Payload, "title"),
+			Topic:          getString(r.Payload, "topic"),
+			Summary:        getString(r.Payload, "summary"),
+			Language:       getString(r.Payload, "language"),
+			Model:          getString(r.Payload, "model"),
+			CreatedAt:      getString(r.Payload, "created_at"),
+			Type:           getString(r.Payload, "type"),
+			WordCount:      words,
+			ReadingMinutes: database.ReadingMinutes(words),
+		}
+	}
+	return searchResults
+}
+
+func getString(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// getInt reads a numeric payload value (int64 from Qdrant, float64 from JSON)
+func getInt(m map[string]interface{}, key string) int {
+	switch v := m[key].(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// truncateSummary shortens text to at most maxChars characters, cutting
+// after the last complete sentence that fits. If not even one sentence fits,
+// it cuts at a word boundary and appends an ellipsis. maxChars <= 0 leaves
+// text unchanged.
+func truncateSummary(text string, maxChars int) string {
+	runes := []rune(text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return text
+	}
+
+	// Last sentence end (., !, or ? followed by whitespace) within the limit
+	for i := maxChars - 1; i > 0; i-- {
+		switch runes[i] {
+		case '.', '!', '?':
+			if unicode.IsSpace(runes[i+1]) {
+				return string(runes[:i+1])
+			}
+		}
+	}
+
+	cut := maxChars - 1 // Room for the ellipsis
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
+
+// truncateSummaries applies truncateSummary to every result
+func truncateSummaries(results []SearchResult, maxChars int) {
+	for i := range results {
+		results[i].Summary = truncateSummary(results[i].Summary, maxChars)
+	}
+}
+
+// encodeEmbedding encodes an embedding as base64 little-endian float32s,
+// the format of the embedding search parameter
+func encodeEmbedding(emb []float32) string {
+	data := make([]byte, len(emb)*4)
+	for i, f := range emb {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(f))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func decodeEmbedding(encoded string) ([]float32, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding length")
+	}
+
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		bits := binary.LittleEndian.Uint32(data[i*4 : (i+1)*4])
+		embedding[i] = math.Float32frombits(bits)
+	}
+
+	return embedding, nil
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// Embedding modes of POST /import
+const (
+	// ImportEmbedAuto reuses the point of a record already stored with the
+	// same embedding text, and embeds the others
+	ImportEmbedAuto = "auto"
+	// ImportEmbedAlways embeds every record
+	ImportEmbedAlways = "always"
+	// ImportEmbedNone stores records without points, for cmd/reconcile -fix
+	// or cmd/rebuild -embeddings to embed later
+	ImportEmbedNone = "none"
+)
+
+// Outcomes of an imported record
+const (
+	ImportStatusImported = "imported"
+	ImportStatusFailed   = "failed"
+)
+
+// maxImportBytes bounds the body of POST /import, both as sent and once
+// decompressed
+const maxImportBytes = 1 << 30
+
+// handleImport serves POST /import: it stores the sources and articles of a
+// GET /export dump (NDJSON, or a JSON array of the same records, optionally
+// gzip-compressed) and reports the outcome of every record. Invalid records
+// are skipped; the others are imported. The tombstones of a GET
+// /export/delta delete their records, so deltas apply to a mirror as well.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("embed")
+	if mode == "" {
+		mode = ImportEmbedAuto
+	}
+	if mode != ImportEmbedAuto && mode != ImportEmbedAlways && mode != ImportEmbedNone {
+		writeFieldError(w, "embed", CodeInvalid, "embed must be auto, always, or none")
+		return
+	}
+
+	// Dumps take longer to send and import than the server's timeouts
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" || r.Header.Get("Content-Type") == "application/gzip" {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			writeFieldError(w, "", CodeMalformed, "Invalid gzip body")
+			return
+		}
+		defer zr.Close()
+		body = http.MaxBytesReader(w, zr, maxImportBytes)
+	}
+
+	// Bulk work, so searches and writes go first
+	ctx := embedding.WithPriority(r.Context(), embedding.PriorityBackground)
+	points := vectordb.NewBatch(vectordb.WithWait(ctx), s.vectorDB, vectordb.DefaultBatchSize, 0)
+	var resp ImportResponse
+	err := decodeRecords(body, func(rec ExportRecord) {
+		// The cursor of a delta is for the caller, not a record
+		if rec.Type == ExportCursor {
+			return
+		}
+		result := s.importRecord(ctx, rec, mode, points)
+		result.Record = len(resp.Results) + 1
+		if result.Status == ImportStatusImported {
+			resp.Imported++
+		} else {
+			resp.Failed++
+		}
+		if result.Embedded {
+			resp.Embedded++
+		}
+		if result.Reused {
+			resp.Reused++
+		}
+		resp.Results = append(resp.Results, result)
+	})
+	if err := points.Close(); err != nil {
+		log.Printf("Failed to store embeddings: %v", err)
+	}
+	if err != nil {
+		// Records before the malformed one are imported: report them too
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+			err = fmt.Errorf("imports are limited to %d bytes", maxImportBytes)
+		}
+		resp.Error = fmt.Sprintf("record %d: %v", len(resp.Results)+1, err)
+		writeJSON(w, status, resp)
+		return
+	}
+	if resp.Results == nil {
+		resp.Results = []ImportResult{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// decodeRecords calls fn for every record of an NDJSON stream or a JSON
+// array, stopping at the first malformed one
+func decodeRecords(r io.Reader, fn func(ExportRecord)) error {
+	br := bufio.NewReader(r)
+	array := false
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' {
+			br.ReadByte()
+			continue
+		}
+		array = b[0] == '['
+		break
+	}
+
+	dec := json.NewDecoder(br)
+	if array {
+		dec.Token() // The opening bracket
+	}
+	for array && dec.More() || !array {
+		var rec ExportRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF && !array {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(rec)
+	}
+	return nil
+}
+
+// importRecord validates and stores one record with its point
+func (s *Server) importRecord(ctx context.Context, rec ExportRecord, mode string, points *vectordb.Batch) ImportResult {
+	result := ImportResult{Type: rec.Type, Status: ImportStatusFailed}
+	var err error
+	switch {
+	case rec.Type == ExportSource && rec.Source != nil:
+		result.ID = rec.Source.ID
+		err = s.importSource(ctx, *rec.Source, mode, points, &result)
+	case rec.Type == ExportArticle && rec.Article != nil:
+		result.ID = rec.Article.ID
+		err = s.importArticle(ctx, *rec.Article, mode, points, &result)
+	case rec.Type == ExportTombstone && rec.Tombstone != nil:
+		result.ID = rec.Tombstone.ID
+		err = s.importTombstone(ctx, *rec.Tombstone, points)
+	default:
+		err = errors.New("type must be source, article or tombstone, with the matching record")
+	}
+	if err != nil {
+		result.Error = err.Error()
+		var errs fieldErrors
+		if errors.As(err, &errs) {
+			result.Errors = errs
+		}
+		return result
+	}
+	result.Status = ImportStatusImported
+	return result
+}
+
+// importTombstone deletes the source or article of a delta's tombstone, if
+// it is stored
+func (s *Server) importTombstone(ctx context.Context, t Tombstone, points *vectordb.Batch) error {
+	if t.ID == "" {
+		return fieldErrors{{Field: "tombstone.id", Code: CodeRequired, Message: "id is required"}}
+	}
+	// Points of earlier records may still be queued: store them first, so
+	// none of the deleted record's outlives it
+	if err := points.Flush(); err != nil {
+		log.Printf("Failed to store embeddings: %v", err)
+	}
+	switch t.Kind {
+	case ExportSource:
+		// Deleted as on the exporting side: the purge job removes it later
+		if err := s.deleteSource(ctx, t.ID); err != nil {
+			return fmt.Errorf("failed to delete source: %w", err)
+		}
+	case ExportArticle:
+		if err := s.db.DeleteArticle(ctx, t.ID); err != nil {
+			return fmt.Errorf("failed to delete article: %w", err)
+		}
+		if err := s.vectorDB.DeleteArticle(vectordb.WithWait(ctx), t.ID); err != nil {
+			log.Printf("Failed to delete article %s from Qdrant: %v", t.ID, err)
+		}
+	default:
+		return fieldErrors{{Field: "tombstone.kind", Code: CodeInvalid, Message: "kind must be source or article"}}
+	}
+	return nil
+}
+
+// importSource validates a source like POST /sources does, keeping its ID,
+// owner and the links and publication metadata derived where it came from
+func (s *Server) importSource(ctx context.Context, in database.Source, mode string, points *vectordb.Batch, result *ImportResult) error {
+	src, _, errs := s.newSource(SourceRequest{
+		ID:          in.ID,
+		URL:         in.URL,
+		Title:       in.Title,
+		Topic:       in.Topic,
+		Summary:     in.Summary,
+		Language:    in.Language,
+		Model:       in.Model,
+		CreatedAt:   in.CreatedAt,
+		PublishedAt: in.PublishedAt,
+		Tags:        in.Tags,
+		Visibility:  in.Visibility,
+		Type:        in.Type,
+		People:      in.People,
+		Orgs:        in.Orgs,
+		Places:      in.Places,
+		Locations:   in.Locations,
+		Content:     in.Content,
+	}, in.Owner)
+	if in.ID == "" {
+		errs = append(fieldErrors{{Field: "id", Code: CodeRequired, Message: "id is required"}}, errs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	src.ID = in.ID // Restored as exported, even from before IDs were normalized
+	src.Links = in.Links
+	src.Publication = in.Publication
+	src.SummaryStyle = in.SummaryStyle // As stored, even if the profile changed since
+
+	reuse := false
+	if mode == ImportEmbedAuto {
+		prev, err := s.db.GetSource(ctx, src.ID)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		reuse = prev != nil &&
+			slices.Equal(s.strategy.Texts(sourceDocument(*prev)), s.strategy.Texts(sourceDocument(src))) &&
+			s.pointExists(ctx, vectordb.SourcesCollection, src.ID)
+	}
+	var vectors vectordb.Vectors
+	if mode != ImportEmbedNone && !reuse {
+		var err error
+		if vectors, err = s.embedders.EmbedDocument(ctx, s.strategy, sourceDocument(src)); err != nil {
+			return fmt.Errorf("failed to generate embedding: %w", err)
+		}
+	}
+
+	if err := s.db.InsertSource(ctx, src); err != nil {
+		return fmt.Errorf("failed to store source: %w", err)
+	}
+	switch {
+	case reuse:
+		if err := s.vectorDB.OverwritePayload(vectordb.WithWait(ctx), vectordb.SourcesCollection, []string{src.ID}, vectordb.NewSourcePayload(src).Values()); err != nil {
+			log.Printf("Failed to update vector point: %v", err)
+		}
+		result.Reused = true
+	case vectors != nil:
+		if err := points.AddSource(vectordb.SourcePoint{ID: src.ID, Vectors: vectors, Payload: vectordb.NewSourcePayload(src)}); err != nil {
+			log.Printf("Failed to store embeddings: %v", err)
+		}
+		result.Embedded = true
+	}
+	return nil
+}
+
+// importArticle stores an article with its content, and its chunks when
+// they are enabled
+func (s *Server) importArticle(ctx context.Context, art database.Article, mode string, points *vectordb.Batch, result *ImportResult) error {
+	var errs fieldErrors
+	for _, f := range []struct{ name, value string }{{"id", art.ID}, {"title", art.Title}, {"path", art.Path}} {
+		if f.value == "" {
+			errs.add(f.name, CodeRequired, f.name+" is required")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	doc := embedding.Document{Title: art.Title, Summary: art.Summary, Body: art.Content}
+	reuse := false
+	if mode == ImportEmbedAuto {
+		prev, err := s.db.GetArticle(ctx, art.ID)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		if prev != nil {
+			if prev.Content, err = s.db.ArticleContent(ctx, art.ID); err != nil {
+				return fmt.Errorf("database error: %w", err)
+			}
+			// Chunks are cut from the content, so it must match too
+			reuse = prev.Content == art.Content &&
+				slices.Equal(s.articleStrategy.Texts(embedding.Document{Title: prev.Title, Summary: prev.Summary, Body: prev.Content}), s.articleStrategy.Texts(doc)) &&
+				s.pointExists(ctx, vectordb.ArticlesCollection, art.ID)
+		}
+	}
+	var vectors vectordb.Vectors
+	var chunks []vectordb.ChunkPoint
+	if mode != ImportEmbedNone && !reuse {
+		var err error
+		if vectors, err = s.embedders.EmbedDocument(ctx, s.articleStrategy, doc); err != nil {
+			return fmt.Errorf("failed to generate embedding: %w", err)
+		}
+		if s.vectorDB.ChunksEnabled() {
+			if chunks, err = chunk.Points(ctx, s.embedders, art, s.chunkOptions); err != nil {
+				return fmt.Errorf("failed to generate chunk embeddings: %w", err)
+			}
+		}
+	}
+
+	if err := s.db.InsertArticle(ctx, art); err != nil {
+		return fmt.Errorf("failed to store article: %w", err)
+	}
+	payload := vectordb.ArticlePayload{
+		ID:        art.ID,
+		Title:     art.Title,
+		Path:      art.Path,
+		Summary:   art.Summary,
+		Tags:      art.Tags,
+		Category:  art.Category(),
+		WordCount: database.WordCount(art.Content),
+	}
+	switch {
+	case reuse:
+		if err := s.vectorDB.OverwritePayload(vectordb.WithWait(ctx), vectordb.ArticlesCollection, []string{art.ID}, payload.Values()); err != nil {
+			log.Printf("Failed to update vector point: %v", err)
+		}
+		result.Reused = true
+	case vectors != nil:
+		if err := points.AddArticle(vectordb.ArticlePoint{ID: art.ID, Vectors: vectors, Payload: payload}); err != nil {
+			log.Printf("Failed to store embeddings: %v", err)
+		}
+		if chunks != nil {
+			if err := s.vectorDB.ReplaceArticleChunks(vectordb.WithWait(ctx), art.ID, chunks); err != nil {
+				log.Printf("Failed to store chunk embeddings: %v", err)
+			}
+		}
+		result.Embedded = true
+	}
+	return nil
+}
+
+// pointExists reports whether a collection has the point of a record;
+// lookup failures count as missing, so the record is embedded again
+func (s *Server) pointExists(ctx context.Context, collection, id string) bool {
+	exists, err := s.vectorDB.Exists(ctx, collection, id)
+	if err != nil {
+		log.Printf("Failed to look up stored embedding: %v", err)
+	}
+	return exists
+}
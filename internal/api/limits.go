@@ -0,0 +1,130 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit bounds the requests of each client (by remote IP address) with
+// a token bucket: PerMinute requests a minute on average, in bursts of up
+// to Burst. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int // Zero allows bursts of PerMinute
+}
+
+// maxRateClients is the number of tracked clients above which the buckets
+// of idle clients are dropped
+const maxRateClients = 10000
+
+// readOnlyPosts are the POST endpoints that only read, served in read-only
+// mode
+var readOnlyPosts = map[string]bool{
+	"/search":            true,
+	"/sources/search":    true,
+	"/sources/recommend": true,
+	"/sources/compare":   true,
+	"/articles/search":   true,
+	"/claims/search":     true,
+	"/claims/stance":     true,
+}
+
+// readOnlyMiddleware refuses every request that could write, answering 403
+func readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		case r.Method == http.MethodPost && readOnlyPosts[r.URL.Path]:
+		default:
+			writeError(w, http.StatusForbidden, "The API is read-only")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bucket is the token bucket of a client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter holds the token buckets of the clients
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second
+	burst   float64
+	clients map[string]*bucket
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.PerMinute
+	}
+	return &rateLimiter{
+		rate:    float64(limit.PerMinute) / 60,
+		burst:   float64(burst),
+		clients: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from the client's bucket, or returns how long until
+// one is available
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= maxRateClients {
+			l.dropIdle(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// dropIdle forgets the clients whose bucket has refilled: they start over
+// from a full bucket anyway
+func (l *rateLimiter) dropIdle(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// rateLimitMiddleware answers 429, with Retry-After, to clients over the
+// limit. The probes are not limited.
+func rateLimitMiddleware(limit RateLimit, next http.Handler) http.Handler {
+	limiter := newRateLimiter(limit)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health", "/live", "/ready":
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		if ok, wait := limiter.allow(client, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// ReviewLinkRequest is the request body for reviewing a link suggestion
+type ReviewLinkRequest struct {
+	ArticleID string `json:"article_id"`
+	SourceID  string `json:"source_id"`
+	Status    string `json:"status"` // approved or rejected
+}
+
+func (s *Server) handleListLinkSuggestions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = database.SuggestionPending
+	}
+	if !database.ValidSuggestionStatus(status) {
+		writeFieldError(w, "status", CodeInvalid, "status must be pending, approved, or rejected")
+		return
+	}
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	suggestions, err := s.db.LinkSuggestions(r.Context(), r.URL.Query().Get("article_id"), status, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+	})
+}
+
+func (s *Server) handleReviewLinkSuggestion(w http.ResponseWriter, r *http.Request) {
+	var req ReviewLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	var errs fieldErrors
+	if req.ArticleID == "" {
+		errs.add("article_id", CodeRequired, "article_id is required")
+	}
+	if req.SourceID == "" {
+		errs.add("source_id", CodeRequired, "source_id is required")
+	}
+	if req.Status != database.SuggestionApproved && req.Status != database.SuggestionRejected {
+		errs.add("status", CodeInvalid, "status must be approved or rejected")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	suggestion, err := s.db.ReviewLinkSuggestion(r.Context(), req.ArticleID, req.SourceID, req.Status)
+	if err != nil {
+		log.Printf("Failed to review link suggestion: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if suggestion == nil {
+		writeError(w, http.StatusNotFound, "Suggestion not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestion)
+}
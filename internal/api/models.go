@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/ollama"
+	"github.com/gitopedia/knowledge-base/internal/rerank"
+)
+
+// Roles of the configured models in GET /admin/models/status
+const (
+	RoleEmbedding  = "embedding"
+	RoleGeneration = "generation"
+	RoleRerank     = "rerank"
+)
+
+// configuredModel is a model the server calls on Ollama
+type configuredModel struct {
+	name string
+	role string
+}
+
+// ollamaModels returns the configured models served by Ollama: embedding
+// models with EMBEDDING_PROVIDER=ollama, and the generation and reranking
+// models with an Ollama provider
+func (s *Server) ollamaModels() []configuredModel {
+	var models []configuredModel
+	for _, model := range s.embedders.Models() {
+		if s.embedders.Get(model).Provider().Name() == embedding.ProviderOllama {
+			models = append(models, configuredModel{model, RoleEmbedding})
+		}
+	}
+	if g, ok := s.generator.(*generation.Ollama); ok {
+		models = append(models, configuredModel{g.Model(), RoleGeneration})
+	}
+	if r, ok := s.reranker.(*rerank.OllamaReranker); ok {
+		models = append(models, configuredModel{r.Model(), RoleRerank})
+	}
+	return models
+}
+
+// requireOllama answers 501 unless model management is enabled
+func (s *Server) requireOllama(w http.ResponseWriter) bool {
+	if s.ollama == nil {
+		writeError(w, http.StatusNotImplemented, "Ollama model management is not configured")
+		return false
+	}
+	return true
+}
+
+// handleListModels serves GET /admin/models: the models pulled to Ollama
+func (s *Server) handleListModels(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOllama(w) {
+		return
+	}
+	models, err := s.ollama.Models(r.Context())
+	if err != nil {
+		log.Printf("Failed to list Ollama models: %v", err)
+		writeError(w, http.StatusBadGateway, "Failed to list Ollama models")
+		return
+	}
+	writeJSON(w, http.StatusOK, ModelsResponse{Models: models})
+}
+
+// handleModelStatus serves GET /admin/models/status: whether each model
+// the server calls on Ollama is pulled
+func (s *Server) handleModelStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOllama(w) {
+		return
+	}
+	pulled, err := s.ollama.Models(r.Context())
+	if err != nil {
+		log.Printf("Failed to list Ollama models: %v", err)
+		writeError(w, http.StatusBadGateway, "Failed to list Ollama models")
+		return
+	}
+	resp := ModelStatusResponse{Ready: true, Models: []ModelStatus{}}
+	for _, m := range s.ollamaModels() {
+		status := ModelStatus{Model: m.name, Role: m.role}
+		if model, ok := ollama.Find(pulled, m.name); ok {
+			status.Pulled = true
+			status.Size = model.Size
+		} else {
+			resp.Ready = false
+		}
+		resp.Models = append(resp.Models, status)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handlePullModel serves POST /admin/models/pull: it pulls the requested
+// model, or every configured model that isn't pulled yet, streaming the
+// progress of Ollama as NDJSON. Pulls take minutes, so the request has no
+// deadline (see deadlineMiddleware); a client disconnect cancels it.
+func (s *Server) handlePullModel(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOllama(w) {
+		return
+	}
+	var req PullModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeBodyError(w)
+		return
+	}
+	ctx := r.Context()
+
+	models := []string{req.Model}
+	if req.Model == "" {
+		pulled, err := s.ollama.Models(ctx)
+		if err != nil {
+			log.Printf("Failed to list Ollama models: %v", err)
+			writeError(w, http.StatusBadGateway, "Failed to list Ollama models")
+			return
+		}
+		models = models[:0]
+		seen := make(map[string]bool)
+		for _, m := range s.ollamaModels() {
+			if _, ok := ollama.Find(pulled, m.name); !ok && !seen[m.name] {
+				models = append(models, m.name)
+				seen[m.name] = true
+			}
+		}
+	}
+
+	// The response outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for _, model := range models {
+		log.Printf("Pulling Ollama model %s", model)
+		err := s.ollama.Pull(ctx, model, func(p ollama.Progress) error {
+			if err := enc.Encode(PullProgress{Model: model, Progress: p}); err != nil {
+				return err
+			}
+			return rc.Flush()
+		})
+		if err != nil {
+			// The status is already sent: report the failure in the stream
+			log.Printf("Failed to pull Ollama model %s: %v", model, err)
+			enc.Encode(PullProgress{Model: model, Error: err.Error()})
+			return
+		}
+		log.Printf("Pulled Ollama model %s", model)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// queuedPatterns are the source writes queued in the outbox while the
+// stores are protected (see package guard)
+var queuedPatterns = []string{
+	"POST /sources",
+	"POST /sources/batch",
+	"PATCH /sources/{id}",
+	"DELETE /sources/{id}",
+	"POST /sources/{id}/restore",
+}
+
+const (
+	// maxQueuedBody bounds the body of a queued write
+	maxQueuedBody = 32 << 20
+	// replayBatchSize is how many queued writes are read at a time
+	replayBatchSize = 100
+)
+
+// guardMiddleware queues source writes in the outbox, answering 202, while
+// the guard protects the stores
+func (s *Server) guardMiddleware(next http.Handler) http.Handler {
+	queued := http.NewServeMux()
+	for _, pattern := range queuedPatterns {
+		queued.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := queued.Handler(r); pattern == "" || !s.guard.Protected() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.queueWrite(w, r)
+	})
+}
+
+// queueWrite stores a write in the outbox, to be replayed once the stores
+// agree again
+func (s *Server) queueWrite(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxQueuedBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Writes are queued while the stores are protected, up to %d bytes", maxQueuedBody))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	qw := database.QueuedWrite{
+		Method:      r.Method,
+		Path:        r.URL.RequestURI(),
+		ContentType: r.Header.Get("Content-Type"),
+		UserID:      userID(r),
+		APIKey:      requestAPIKey(r.Context()),
+		Body:        body,
+		QueuedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	id, err := s.db.QueueWrite(r.Context(), qw)
+	if err != nil {
+		log.Printf("Failed to queue %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, "Failed to queue write")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, QueuedWriteResponse{
+		ID:       id,
+		QueuedAt: qw.QueuedAt,
+		Message:  "The stores disagree; the write is queued and will be applied once they are repaired",
+	})
+}
+
+// replayQueuedWrites applies the writes of the outbox in the order they
+// were queued, until it is empty. Writes refused by the API (4xx) are
+// dropped with a log line; a server error stops the replay, leaving the
+// write queued for the next attempt.
+func (s *Server) replayQueuedWrites(ctx context.Context) error {
+	for {
+		writes, err := s.db.QueuedWrites(ctx, replayBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read the outbox: %w", err)
+		}
+		if len(writes) == 0 {
+			return nil
+		}
+		for _, qw := range writes {
+			status, body := s.replayWrite(ctx, qw)
+			if status >= 500 {
+				return fmt.Errorf("%s %s (queued %s) failed with status %d: %s", qw.Method, qw.Path, qw.QueuedAt, status, body)
+			}
+			if status >= 400 {
+				log.Printf("Dropped queued write %d: %s %s (queued %s) was refused with status %d: %s", qw.ID, qw.Method, qw.Path, qw.QueuedAt, status, body)
+			}
+			if err := s.db.DeleteQueuedWrite(ctx, qw.ID); err != nil {
+				return fmt.Errorf("failed to remove replayed write %d: %w", qw.ID, err)
+			}
+		}
+	}
+}
+
+// replayWrite serves a queued write with the caller it was queued for and
+// returns its status and response body. The API key of the write is loaded
+// again, so its scope is checked as it is now: a write whose key was revoked,
+// or rotated since with the replaced secret expired, is refused (401).
+func (s *Server) replayWrite(ctx context.Context, qw database.QueuedWrite) (int, string) {
+	ctx, cancel := context.WithTimeout(ctx, requestDeadline)
+	defer cancel()
+	if qw.APIKey != nil {
+		key, err := s.db.APIKeyByID(ctx, qw.APIKey.ID, time.Now().UTC().Format(time.RFC3339))
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Sprintf("failed to load API key %s: %v", qw.APIKey.ID, err)
+		}
+		if key == nil {
+			return http.StatusUnauthorized, fmt.Sprintf("API key %s was revoked", qw.APIKey.ID)
+		}
+		if key.RotatedAt > qw.QueuedAt && key.PreviousExpiresAt == "" {
+			return http.StatusUnauthorized, fmt.Sprintf("API key %s was rotated and its replaced secret expired", key.ID)
+		}
+		ctx = context.WithValue(ctx, apiKeyContextKey{}, key)
+	}
+	r, err := http.NewRequestWithContext(ctx, qw.Method, qw.Path, bytes.NewReader(qw.Body))
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if qw.ContentType != "" {
+		r.Header.Set("Content-Type", qw.ContentType)
+	}
+	if qw.UserID != "" {
+		r.Header.Set("X-User-ID", qw.UserID)
+	}
+
+	w := &responseBuffer{header: make(http.Header), status: http.StatusOK}
+	s.routes.ServeHTTP(w, r)
+	return w.status, strings.TrimSpace(w.body.String())
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// defaultProfileWeight is the share of the interest profile in a personalized query
+const defaultProfileWeight = 0.3
+
+// interactionWeights scales how much each kind of interaction moves a profile
+var interactionWeights = map[string]float64{
+	"click": 1,
+	"save":  2,
+}
+
+func (s *Server) handleRecordInteraction(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+
+	var req InteractionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	var errs fieldErrors
+	if req.SourceID == "" {
+		errs.add("source_id", CodeRequired, "source_id is required")
+	}
+	weight, ok := interactionWeights[req.Action]
+	if !ok {
+		errs.add("action", CodeInvalid, "action must be click or save")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	// Update the profile of every embedding model, from the source's
+	// vectors, which a cold source gets again
+	ctx := r.Context()
+	if err := s.warmSources(ctx, []string{req.SourceID}); err != nil {
+		log.Printf("Failed to re-embed cold source: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to re-embed cold source")
+		return
+	}
+	named := len(s.vectorDB.VectorSpaces()) > 0
+	for _, model := range s.embedders.Models() {
+		vector := ""
+		if named {
+			vector = model
+		}
+		emb, err := s.vectorDB.GetSourceVector(ctx, req.SourceID, vector)
+		if err != nil {
+			log.Printf("Failed to load source vector: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to load source vector")
+			return
+		}
+		if emb == nil {
+			writeError(w, http.StatusNotFound, "Source not found")
+			return
+		}
+
+		profile, err := s.db.GetProfile(ctx, user, model)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if profile == nil {
+			profile = &database.Profile{UserID: user, Model: model}
+		}
+		profile.Add(emb, weight)
+		profile.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := s.db.SaveProfile(ctx, *profile); err != nil {
+			log.Printf("Failed to save profile: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to save profile")
+			return
+		}
+	}
+
+	s.recordHits(ctx, []string{req.SourceID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+
+	resp := ProfileResponse{UserID: user, Profiles: []database.Profile{}}
+	for _, model := range s.embedders.Models() {
+		profile, err := s.db.GetProfile(r.Context(), user, model)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if profile != nil {
+			resp.Profiles = append(resp.Profiles, *profile)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+
+	if err := s.db.DeleteProfiles(r.Context(), user); err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
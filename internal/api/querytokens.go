@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+)
+
+const (
+	// queryTokenTTL is how long a query token stays valid after it was
+	// issued or last used
+	queryTokenTTL = 10 * time.Minute
+	// maxQueryTokens bounds the embeddings held for tokens; the least
+	// recently used are dropped first
+	maxQueryTokens = 10000
+	// queryTokenPrefix marks query tokens
+	queryTokenPrefix = "qt_"
+)
+
+// queryEntry is a query embedding held for its token
+type queryEntry struct {
+	query     string
+	model     string
+	embedding []float32
+	usedAt    time.Time
+}
+
+// queryCache holds the embeddings of recent search queries by token, so
+// refined or paginated searches of the same query skip the embedding model
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[string]*queryEntry
+}
+
+// lookup returns the live entry of a token, marking it used
+func (c *queryCache) lookup(token string) (queryEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[token]
+	if !ok || time.Since(e.usedAt) >= queryTokenTTL {
+		return queryEntry{}, false
+	}
+	e.usedAt = time.Now()
+	return *e, true
+}
+
+// issue holds an embedding and returns its new token, dropping expired
+// entries, then the least recently used, when the cache is full
+func (c *queryCache) issue(query, model string, emb []float32) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := queryTokenPrefix + hex.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*queryEntry)
+	}
+	if len(c.entries) >= maxQueryTokens {
+		var oldest string
+		for t, e := range c.entries {
+			if time.Since(e.usedAt) >= queryTokenTTL {
+				delete(c.entries, t)
+			} else if oldest == "" || e.usedAt.Before(c.entries[oldest].usedAt) {
+				oldest = t
+			}
+		}
+		if len(c.entries) >= maxQueryTokens {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[token] = &queryEntry{query: query, model: model, embedding: emb, usedAt: time.Now()}
+	return token
+}
+
+// resolveQueryToken fills in the query of a search from its query token
+// when the request has a token but no query, so the filters that need the
+// query text (hybrid, rerank, ...) work with a token alone. An unknown or
+// expired token without a query is a validation failure.
+func (s *Server) resolveQueryToken(query *string, token string, errs *fieldErrors) {
+	if token == "" || *query != "" {
+		return
+	}
+	entry, ok := s.queries.lookup(token)
+	if !ok {
+		errs.add("query_token", CodeInvalid, "query_token is unknown or expired; send the query again")
+		return
+	}
+	*query = entry.query
+}
+
+// queryEmbedding returns the embedding of a search query: the one held for
+// token when it was issued for the same query and model, otherwise a new
+// one. It returns the token of the embedding, the given one or a new one.
+func (s *Server) queryEmbedding(ctx context.Context, embedder *embedding.Client, query, token string) ([]float32, string, error) {
+	if token != "" {
+		if entry, ok := s.queries.lookup(token); ok && entry.query == query && entry.model == embedder.Model() {
+			return entry.embedding, token, nil
+		}
+	}
+	emb, err := embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	return emb, s.queries.issue(query, embedder.Model(), emb), nil
+}
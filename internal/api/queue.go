@@ -0,0 +1,300 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+const (
+	// maxEnqueue bounds the sources added to a queue per request
+	maxEnqueue = 100
+	// defaultQueueStatsDays is the window of queue throughput stats
+	defaultQueueStatsDays = 30
+)
+
+// handleListQueue serves GET /queue: the caller's reading queue with the
+// title, URL and topic of each source, optionally of one status
+func (s *Server) handleListQueue(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !database.ValidQueueStatus(status) {
+		writeFieldError(w, "status", CodeInvalid, "status must be to-read, reading, or done")
+		return
+	}
+
+	ctx := r.Context()
+	items, err := s.db.ReadingQueue(ctx, user, status)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	resp := QueueResponse{Items: []QueueEntry{}}
+	for _, item := range items {
+		src, err := s.db.GetSource(ctx, item.SourceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		// A source made private by its owner leaves the queues of others
+		if src == nil || !src.VisibleTo(user) {
+			continue
+		}
+		resp.Items = append(resp.Items, QueueEntry{
+			QueueItem:      item,
+			Title:          src.Title,
+			URL:            src.URL,
+			Topic:          src.Topic,
+			ReadingMinutes: src.ReadingMinutes,
+		})
+	}
+	resp.Count = len(resp.Items)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleEnqueue serves POST /queue: it adds sources to the caller's queue
+// as to-read
+func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	var req EnqueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	if len(req.SourceIDs) == 0 {
+		writeFieldError(w, "source_ids", CodeRequired, "source_ids is required")
+		return
+	}
+	if len(req.SourceIDs) > maxEnqueue {
+		writeFieldError(w, "source_ids", CodeOutOfRange, fmt.Sprintf("at most %d sources can be queued at once", maxEnqueue))
+		return
+	}
+	for _, id := range req.SourceIDs {
+		src, err := s.db.GetSource(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if src == nil || !src.VisibleTo(user) {
+			writeFieldError(w, "source_ids", CodeInvalid, fmt.Sprintf("source %s not found", id))
+			return
+		}
+	}
+	s.enqueue(w, r, user, req.SourceIDs, req.Note)
+}
+
+// handleEnqueueSearch serves POST /queue/search: it runs a source search,
+// as POST /sources/search does, and adds its results to the caller's queue
+func (s *Server) handleEnqueueSearch(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	var req EnqueueSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	if req.Limit > maxEnqueue {
+		writeFieldError(w, "limit", CodeOutOfRange, fmt.Sprintf("at most %d sources can be queued at once", maxEnqueue))
+		return
+	}
+	req.Fields, req.Facets = []string{"id"}, nil
+
+	// Search errors are answered as the search endpoint answers them
+	buf := &responseBuffer{header: make(http.Header), status: http.StatusOK}
+	s.searchSources(buf, r, req.SearchRequest)
+	if buf.status != http.StatusOK {
+		for key, values := range buf.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+		return
+	}
+	var results SearchResponse
+	if err := json.Unmarshal(buf.body.Bytes(), &results); err != nil {
+		log.Printf("Failed to decode search results: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+	ids := make([]string, len(results.Results))
+	for i, result := range results.Results {
+		ids[i] = result.ID
+	}
+	s.enqueue(w, r, user, ids, req.Note)
+}
+
+// enqueue adds sources to a user's queue and writes the response
+func (s *Server) enqueue(w http.ResponseWriter, r *http.Request, user string, ids []string, note string) {
+	added, err := s.db.EnqueueSources(r.Context(), user, ids, note)
+	if err != nil {
+		log.Printf("Failed to enqueue sources: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update queue")
+		return
+	}
+	writeJSON(w, http.StatusOK, EnqueueResponse{Added: added, Queued: ids})
+}
+
+// handleUpdateQueueItem serves PATCH /queue/{id}: it moves a queued source
+// to another status, stamping when it was started or finished, and/or
+// replaces its note
+func (s *Server) handleUpdateQueueItem(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	var req QueueUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	var errs fieldErrors
+	if req.Status == nil && req.Note == nil {
+		errs.add("status", CodeRequired, "status or note is required")
+	}
+	if req.Status != nil && !database.ValidQueueStatus(*req.Status) {
+		errs.add("status", CodeInvalid, "status must be to-read, reading, or done")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	item, err := s.db.GetQueueItem(ctx, user, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if item == nil {
+		writeError(w, http.StatusNotFound, "Source not in queue")
+		return
+	}
+	now := time.Now()
+	if req.Status != nil {
+		item.Move(*req.Status, now)
+	}
+	if req.Note != nil && *req.Note != item.Note {
+		item.Note = *req.Note
+		item.UpdatedAt = now.UTC().Format(time.RFC3339)
+	}
+	if err := s.db.SaveQueueItem(ctx, *item); err != nil {
+		log.Printf("Failed to update queue item: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update queue")
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// handleDequeue serves DELETE /queue/{id}
+func (s *Server) handleDequeue(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	removed, err := s.db.DequeueSource(r.Context(), user, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !removed {
+		writeError(w, http.StatusNotFound, "Source not in queue")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleQueueStats serves GET /queue/stats?days=30: the throughput of the
+// caller's queue over the last days
+func (s *Server) handleQueueStats(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	days := defaultQueueStatsDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 || days > 366 {
+			writeFieldError(w, "days", CodeOutOfRange, "days must be between 1 and 366")
+			return
+		}
+	}
+
+	items, err := s.db.ReadingQueue(r.Context(), user, "")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, queueStats(items, days, time.Now()))
+}
+
+// queueStats computes the throughput of a queue over the days before now
+func queueStats(items []database.QueueItem, days int, now time.Time) QueueStats {
+	stats := QueueStats{
+		Counts: map[string]int{database.QueueToRead: 0, database.QueueReading: 0, database.QueueDone: 0},
+		Days:   days,
+	}
+	since := now.UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+	var toFinish, reading []float64
+	for _, item := range items {
+		stats.Counts[item.Status]++
+		if item.AddedAt >= since {
+			stats.Added++
+		}
+		if item.StartedAt >= since {
+			stats.Started++
+		}
+		if item.Status == database.QueueToRead && (stats.OldestToRead == "" || item.AddedAt < stats.OldestToRead) {
+			stats.OldestToRead = item.AddedAt
+		}
+		if item.DoneAt < since {
+			continue
+		}
+		stats.Finished++
+		done, _ := time.Parse(time.RFC3339, item.DoneAt)
+		if added, err := time.Parse(time.RFC3339, item.AddedAt); err == nil {
+			toFinish = append(toFinish, done.Sub(added).Hours()/24)
+		}
+		if started, err := time.Parse(time.RFC3339, item.StartedAt); err == nil {
+			reading = append(reading, done.Sub(started).Hours()/24)
+		}
+	}
+	stats.FinishedPerWeek = math.Round(float64(stats.Finished)*7/float64(days)*100) / 100
+	stats.MedianDaysToFinish = median(toFinish)
+	stats.MedianDaysReading = median(reading)
+	return stats
+}
+
+// median returns the median of values to a tenth, or nil without any
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (values[len(values)/2-1] + m) / 2
+	}
+	m = math.Round(m*10) / 10
+	return &m
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// linkedSourceBoost raises the score of sources curated for the searched
+// topic's article by this fraction (or lowers it, for distances). It is
+// multiplicative so it works for similarity, distance and fused-rank scores,
+// and small enough that a clearly better match still ranks first.
+const linkedSourceBoost = 0.1
+
+// linkedCandidateFactor is how many times the requested limit is fetched
+// for topic searches, so boosted sources can move up into the results
+const linkedCandidateFactor = 2
+
+// boostLinkedSources boosts and marks the results curated for topic's
+// article (article_sources), then re-sorts by score, best first: highest
+// first when higherIsBetter, lowest first for distances. Lookup failures
+// leave the ranking unchanged.
+func (s *Server) boostLinkedSources(ctx context.Context, topic string, results []SearchResult, higherIsBetter bool) []SearchResult {
+	ids, err := s.db.LinkedSourceIDs(ctx, topic)
+	if err != nil {
+		log.Printf("Failed to load linked sources: %v", err)
+		return results
+	}
+	if len(ids) == 0 {
+		return results
+	}
+
+	linked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		linked[id] = true
+	}
+	for i := range results {
+		if linked[results[i].ID] {
+			if higherIsBetter {
+				results[i].Score *= 1 + linkedSourceBoost
+			} else {
+				results[i].Score /= 1 + linkedSourceBoost
+			}
+			results[i].Linked = true
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if higherIsBetter {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Score < results[j].Score
+	})
+	return results
+}
+
+// rerankScores returns the semantics of reranked search scores, which are
+// the reranking model's and unbounded in general
+func rerankScores() vectordb.ScoreSemantics {
+	return vectordb.ScoreSemantics{Metric: "rerank", HigherIsBetter: true}
+}
+
+// rerankResults reorders the best max(limit, rerank candidates) results by
+// the reranker's judgement of their relevance to the query, replacing their
+// scores, and drops the rest, whose scores wouldn't compare
+func (s *Server) rerankResults(ctx context.Context, query string, results []SearchResult, limit int) ([]SearchResult, error) {
+	results = results[:min(len(results), max(limit, s.rerankCandidates))]
+	documents := make([]string, len(results))
+	for i, res := range results {
+		documents[i] = strings.TrimSpace(res.Title + "\n\n" + res.Summary)
+	}
+	scores, err := s.reranker.Score(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+	reranked := slices.Clone(results)
+	for i := range reranked {
+		reranked[i].Score = scores[i]
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	return reranked, nil
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readyCheckTimeout bounds each dependency check of GET /ready, so a hung
+// dependency fails the probe instead of the probe timing out
+const readyCheckTimeout = 5 * time.Second
+
+// handleLive serves GET /live: the process is up and serving requests.
+// Dependencies are not checked, so an outage of Qdrant or Ollama doesn't get
+// the server restarted; GET /ready takes it out of rotation instead.
+func handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady serves GET /ready: it checks SQLite, Qdrant and every
+// embedding model concurrently, and answers 503 if any of them fails
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(context.Context) error{
+		"sqlite": s.db.Ping,
+		"qdrant": s.vectorDB.Ping,
+	}
+	for _, model := range s.embedders.Models() {
+		checks["embedding:"+model] = s.embedders.Get(model).Check
+	}
+	if s.generator != nil {
+		checks["generation:"+s.generator.Model()] = s.generator.Check
+	}
+
+	resp := ReadyResponse{Status: "ready", Checks: make(map[string]DependencyCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			result := DependencyCheck{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[name] = result
+			if err != nil {
+				resp.Status = "unavailable"
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if resp.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
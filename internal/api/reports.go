@@ -0,0 +1,99 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/report"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+)
+
+// maxReports bounds report listings
+const maxReports = 100
+
+// handleListReports serves GET /reports: the stored topic reports, most
+// recent period first, optionally of one topic
+func (s *Server) handleListReports(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxReports)
+	}
+	reports, err := s.db.ListTopicReports(r.Context(), slug.Make(r.URL.Query().Get("topic")), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+// handleGetReport serves GET /reports/topics/{topic}: the latest report of
+// a topic, or the one whose period starts on ?start=, as JSON or, with
+// ?format=markdown, as markdown
+func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	var periodStart string
+	if v := r.URL.Query().Get("start"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, v)
+		}
+		if err != nil {
+			writeFieldError(w, "start", CodeInvalid, "start must be a date (YYYY-MM-DD) or an RFC 3339 time")
+			return
+		}
+		periodStart = t.UTC().Format(time.RFC3339)
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "markdown" {
+		writeFieldError(w, "format", CodeInvalid, "format must be json or markdown")
+		return
+	}
+
+	rep, err := s.db.GetTopicReport(r.Context(), slug.Make(r.PathValue("topic")), periodStart)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if rep == nil {
+		writeError(w, http.StatusNotFound, "Report not found")
+		return
+	}
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(rep.Markdown))
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+// handleGenerateReport serves POST /admin/reports/{topic}: it builds and
+// stores the topic's report for the week up to today, replacing the one
+// stored for that period. The report is not sent to the webhook.
+func (s *Server) handleGenerateReport(w http.ResponseWriter, r *http.Request) {
+	topic := slug.Make(r.PathValue("topic"))
+	if topic == "" {
+		writeFieldError(w, "topic", CodeRequired, "topic is required")
+		return
+	}
+	ctx := r.Context()
+	start, end := report.Period(report.Options{})
+	rep, err := report.Build(ctx, s.db, topic, start, end)
+	if err != nil {
+		log.Printf("Failed to build report of %s: %v", topic, err)
+		writeError(w, http.StatusInternalServerError, "Failed to build report")
+		return
+	}
+	if rep.Stats.Sources == 0 && len(rep.BrokenLinks) == 0 {
+		writeError(w, http.StatusNotFound, "Topic has no public sources")
+		return
+	}
+	if err := s.db.SaveTopicReport(ctx, rep); err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusCreated, rep)
+}
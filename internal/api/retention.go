@@ -0,0 +1,24 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gitopedia/knowledge-base/internal/retention"
+)
+
+// handleRetentionReport serves GET /admin/retention: a dry run of the
+// retention job, listing the sources it would prune by topic
+func (s *Server) handleRetentionReport(w http.ResponseWriter, r *http.Request) {
+	if len(s.retention) == 0 {
+		writeError(w, http.StatusNotImplemented, "Retention is not configured (KB_RETENTION)")
+		return
+	}
+	report, err := retention.Run(r.Context(), s.db, s.vectorDB, retention.Options{Policies: s.retention, DryRun: true})
+	if err != nil {
+		log.Printf("Failed to build retention report: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to build retention report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
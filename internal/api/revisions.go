@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/frontmatter"
+)
+
+// handleSourceRevisions serves GET /revisions/sources/{id}: the previous
+// versions of a source, newest first
+func (s *Server) handleSourceRevisions(w http.ResponseWriter, r *http.Request) {
+	src, revisions, ok := s.sourceRevisions(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, RevisionsResponse{ID: src.ID, Current: len(revisions) + 1, Revisions: revisions})
+}
+
+// handleSourceRevisionDiff serves GET /revisions/sources/{id}/diff: the
+// fields changed between two versions of a source
+func (s *Server) handleSourceRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	src, revisions, ok := s.sourceRevisions(w, r)
+	if !ok {
+		return
+	}
+	writeRevisionDiff(w, r, src.ID, "", revisions, database.SourceRevisionFields(*src))
+}
+
+// sourceRevisions reads the source of the request and its revisions,
+// answering 404 when the caller can't see it
+func (s *Server) sourceRevisions(w http.ResponseWriter, r *http.Request) (*database.Source, []database.Revision, bool) {
+	src, err := s.db.GetSource(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, nil, false
+	}
+	if src == nil || !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return nil, nil, false
+	}
+	revisions, err := s.db.SourceRevisions(r.Context(), src.ID)
+	if err != nil {
+		log.Printf("Failed to read revisions of source %s: %v", src.ID, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, nil, false
+	}
+	return src, revisions, true
+}
+
+// handleArticleRevisions serves GET /revisions/articles/{id}: the previous
+// versions of an article, newest first
+func (s *Server) handleArticleRevisions(w http.ResponseWriter, r *http.Request) {
+	art, revisions, ok := s.articleRevisions(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, RevisionsResponse{ID: art.ID, Current: len(revisions) + 1, Revisions: revisions})
+}
+
+// handleArticleRevisionDiff serves GET /revisions/articles/{id}/diff: the
+// fields changed between two versions of an article, with a unified diff of
+// its content
+func (s *Server) handleArticleRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	art, revisions, ok := s.articleRevisions(w, r)
+	if !ok {
+		return
+	}
+	content, err := s.db.ArticleContent(r.Context(), art.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	art.Content = content
+	writeRevisionDiff(w, r, art.ID, art.Path, revisions, database.ArticleRevisionFields(*art))
+}
+
+// articleRevisions reads the article of the request and its revisions,
+// answering 404 when it doesn't exist
+func (s *Server) articleRevisions(w http.ResponseWriter, r *http.Request) (*database.Article, []database.Revision, bool) {
+	art, err := s.db.GetArticle(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, nil, false
+	}
+	if art == nil {
+		writeError(w, http.StatusNotFound, "Article not found")
+		return nil, nil, false
+	}
+	revisions, err := s.db.ArticleRevisions(r.Context(), art.ID)
+	if err != nil {
+		log.Printf("Failed to read revisions of article %s: %v", art.ID, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, nil, false
+	}
+	return art, revisions, true
+}
+
+// writeRevisionDiff answers with the fields changed between the versions
+// from and to (query parameters; by default the current version and the
+// one before it). current has the fields of the current version, whose
+// number follows the last revision. Content is compared as a unified diff
+// of the file at path.
+func writeRevisionDiff(w http.ResponseWriter, r *http.Request, id, path string, revisions []database.Revision, current map[string]any) {
+	latest := len(revisions) + 1
+	var errs fieldErrors
+	to := versionParam(r, "to", latest, latest, &errs)
+	from := versionParam(r, "from", to-1, latest, &errs)
+	if len(errs) == 0 && from < 1 {
+		errs.add("from", CodeOutOfRange, "There is no version before the first one")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	// The current fields are read back from JSON, like those of revisions
+	versions := map[int]map[string]any{}
+	data, _ := json.Marshal(current)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	versions[latest] = fields
+	for _, rev := range revisions {
+		versions[rev.Revision] = rev.Fields
+	}
+	old, updated := versions[from], versions[to]
+
+	names := make(map[string]bool, len(updated))
+	for name := range old {
+		names[name] = true
+	}
+	for name := range updated {
+		names[name] = true
+	}
+	changes := []FieldChange{}
+	for name := range names {
+		a, _ := json.Marshal(old[name])
+		b, _ := json.Marshal(updated[name])
+		if string(a) == string(b) {
+			continue
+		}
+		change := FieldChange{Field: name, From: old[name], To: updated[name]}
+		if name == "content" {
+			oldText, _ := old[name].(string)
+			newText, _ := updated[name].(string)
+			change = FieldChange{Field: name, Diff: frontmatter.Diff(path, []byte(oldText), []byte(newText))}
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	writeJSON(w, http.StatusOK, RevisionDiffResponse{ID: id, From: from, To: to, Changes: changes})
+}
+
+// versionParam reads a version number between 1 and latest from the query,
+// or returns def when it is absent
+func versionParam(r *http.Request, name string, def, latest int, errs *fieldErrors) int {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		errs.add(name, CodeInvalid, name+" must be a version number")
+		return def
+	}
+	if n < 1 || n > latest {
+		errs.add(name, CodeOutOfRange, fmt.Sprintf("%s must be a version from 1 to %d", name, latest))
+		return def
+	}
+	return n
+}
@@ -0,0 +1,418 @@
+// Package api implements the knowledge-base HTTP API on top of the store
+// interfaces, so it can run against SQLite/Qdrant or in-memory fakes.
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/geo"
+	"github.com/gitopedia/knowledge-base/internal/guard"
+	"github.com/gitopedia/knowledge-base/internal/ollama"
+	"github.com/gitopedia/knowledge-base/internal/redact"
+	"github.com/gitopedia/knowledge-base/internal/rerank"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/scholar"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
+)
+
+// Server holds the dependencies for the HTTP API
+type Server struct {
+	db        store.Store
+	vectorDB  store.VectorStore
+	embedders *embedding.Set
+	strategy  embedding.Strategy
+	// Text embedded for imported articles, and how they are chunked
+	articleStrategy embedding.Strategy
+	chunkOptions    chunk.Options
+	scanMode        redact.Mode
+	scholar         *scholar.Client
+	geocoder        *geo.Client
+	spelling        spellingCache
+	hits            hitCache
+	queries         queryCache
+	summaryStyles   *summarystyle.Registry
+	reranker        rerank.Reranker
+	// Vector matches reranked for "rerank": true
+	rerankCandidates int
+	generator        generation.Generator
+	ollama           *ollama.Client
+	retention        retention.Policies
+
+	// Queues source writes while the stores disagree; nil disables it
+	guard *guard.Guard
+	// Refuse source writes without an API key or the admin token
+	requireAPIKey bool
+	// routes serves requests past the middleware, e.g. queued writes
+	routes http.Handler
+}
+
+// Deps are the dependencies of the HTTP API
+type Deps struct {
+	DB        store.Store
+	VectorDB  store.VectorStore
+	Embedders *embedding.Set
+	// Text embedded for POST /sources; empty selects
+	// embedding.DefaultSourceStrategy
+	SourceStrategy embedding.Strategy
+	// Text embedded for articles imported by POST /import; empty selects
+	// embedding.DefaultArticleStrategy
+	ArticleStrategy embedding.Strategy
+	// Chunking of imported articles when chunks are enabled; zero selects
+	// chunk.DefaultOptions
+	ChunkOptions chunk.Options
+	ScanMode     redact.Mode // Sensitive data scanning applied to POST /sources
+	// Fetches paper metadata for POST /sources with DOI or arXiv URLs; nil
+	// disables it
+	Scholar *scholar.Client
+	// Geocoder locates the places of new sources and near_place searches;
+	// nil disables geocoding
+	Geocoder *geo.Client
+	// Summary style profiles sources may name; nil selects the built-in
+	// profiles
+	SummaryStyles *summarystyle.Registry
+	// Reorders search results for "rerank": true; nil disables reranking
+	Reranker rerank.Reranker
+	// Vector matches reranked; zero selects rerank.DefaultCandidates
+	RerankCandidates int
+	// Generation model for source comparisons; nil disables them
+	Generator generation.Generator
+	// Lists and pulls Ollama models for the /admin/models endpoints; nil
+	// disables them
+	Ollama *ollama.Client
+	// Retention periods by topic, reported by GET /admin/retention; none
+	// disables it
+	Retention retention.Policies
+	// Refuse every write (see readOnlyMiddleware), e.g. for a public demo
+	ReadOnly bool
+	// Guard queues source writes while the stores disagree and replays
+	// them once they are repaired (see package guard); nil disables it
+	Guard *guard.Guard
+	// Requests allowed per client; zero disables rate limiting
+	RateLimit RateLimit
+	// Secret operators send (Authorization: Bearer <token>) for /admin and
+	// webhooks (see adminMiddleware); empty leaves /admin to a proxy in
+	// front and disables webhook management
+	AdminToken string
+	// Refuse source writes sent without an API key or the admin token (see
+	// apiKeyMiddleware), so scrapers can't bypass their key's topics
+	RequireAPIKey bool
+}
+
+// NewServer builds the HTTP API handler, with its routes and middleware, on
+// top of the given dependencies
+func NewServer(deps Deps) http.Handler {
+	if deps.SourceStrategy == "" {
+		deps.SourceStrategy = embedding.DefaultSourceStrategy
+	}
+	if deps.ArticleStrategy == "" {
+		deps.ArticleStrategy = embedding.DefaultArticleStrategy
+	}
+	if deps.ChunkOptions.Size <= 0 {
+		deps.ChunkOptions = chunk.DefaultOptions()
+	}
+	if deps.SummaryStyles == nil {
+		deps.SummaryStyles = summarystyle.Defaults()
+	}
+	if deps.RerankCandidates <= 0 {
+		deps.RerankCandidates = rerank.DefaultCandidates
+	}
+	s := &Server{
+		db:        deps.DB,
+		vectorDB:  deps.VectorDB,
+		embedders: deps.Embedders,
+		strategy:  deps.SourceStrategy,
+
+		articleStrategy: deps.ArticleStrategy,
+		chunkOptions:    deps.ChunkOptions,
+		scanMode:        deps.ScanMode,
+		scholar:         deps.Scholar,
+		geocoder:        deps.Geocoder,
+		summaryStyles:   deps.SummaryStyles,
+
+		reranker:         deps.Reranker,
+		rerankCandidates: deps.RerankCandidates,
+		generator:        deps.Generator,
+		ollama:           deps.Ollama,
+		retention:        deps.Retention,
+		guard:            deps.Guard,
+		requireAPIKey:    deps.RequireAPIKey,
+	}
+
+	mux := http.NewServeMux()
+
+	// Health check, and the liveness and readiness probes
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /live", handleLive)
+	mux.HandleFunc("GET /ready", s.handleReady)
+
+	// Source endpoints
+	mux.HandleFunc("POST /sources", s.handleCreateSource)
+	mux.HandleFunc("POST /sources/batch", s.handleCreateSources)
+	mux.HandleFunc("GET /sources/{id}", s.handleGetSource)
+	mux.HandleFunc("PATCH /sources/{id}", s.handleUpdateSource)
+	mux.HandleFunc("DELETE /sources/{id}", s.handleDeleteSource)
+	mux.HandleFunc("POST /sources/{id}/restore", s.handleRestoreSource)
+	mux.HandleFunc("GET /sources", s.handleListSources)
+
+	// Search endpoints
+	mux.HandleFunc("POST /sources/search", s.handleSearchSources)
+	mux.HandleFunc("GET /sources/search", s.handleSearchSourcesGET)
+	mux.HandleFunc("GET /sources/topic/{topic}", s.handleGetSourcesByTopic)
+	mux.HandleFunc("POST /sources/recommend", s.handleRecommendSources)
+	mux.HandleFunc("POST /sources/compare", s.handleCompareSources)
+
+	// Sources and articles in one ranking
+	mux.HandleFunc("POST /search", s.handleFederatedSearch)
+
+	// Questions answered from retrieved sources (retrieval-augmented generation)
+	mux.HandleFunc("POST /ask", s.handleAsk)
+
+	// Summary style profiles for summarizers, and the profile of a source
+	mux.HandleFunc("GET /summary-styles", s.handleListSummaryStyles)
+	mux.HandleFunc("GET /summary-styles/resolve", s.handleResolveSummaryStyle)
+	mux.HandleFunc("GET /summary-styles/sources/{id}", s.handleGetSourceSummaryStyle)
+
+	// Previous versions of sources and articles
+	mux.HandleFunc("GET /revisions/sources/{id}", s.handleSourceRevisions)
+	mux.HandleFunc("GET /revisions/sources/{id}/diff", s.handleSourceRevisionDiff)
+	mux.HandleFunc("GET /revisions/articles/{id}", s.handleArticleRevisions)
+	mux.HandleFunc("GET /revisions/articles/{id}/diff", s.handleArticleRevisionDiff)
+
+	// Claims extracted from source summaries
+	mux.HandleFunc("POST /claims/search", s.handleSearchClaims)
+	mux.HandleFunc("GET /claims/search", s.handleSearchClaimsGET)
+	mux.HandleFunc("POST /claims/stance", s.handleClaimStance)
+	mux.HandleFunc("GET /claims/sources/{id}", s.handleGetSourceClaims)
+
+	// Event log
+	mux.HandleFunc("GET /events", s.handleListEvents)
+	mux.HandleFunc("GET /events/verify", s.handleVerifyEvents)
+
+	// Webhooks receiving the event log as signed deliveries
+	mux.HandleFunc("GET /webhooks", s.handleListWebhooks)
+	mux.HandleFunc("POST /webhooks", s.handleCreateWebhook)
+	mux.HandleFunc("GET /webhooks/{id}", s.handleGetWebhook)
+	mux.HandleFunc("DELETE /webhooks/{id}", s.handleDeleteWebhook)
+	mux.HandleFunc("POST /webhooks/{id}/ping", s.handlePingWebhook)
+
+	// Full export and import of sources and articles as NDJSON
+	mux.HandleFunc("GET /export", s.handleExport)
+	mux.HandleFunc("GET /export/delta", s.handleExportDelta)
+	mux.HandleFunc("POST /import", s.handleImport)
+
+	// Interest profiles (per X-User-ID)
+	mux.HandleFunc("POST /profile/interactions", s.handleRecordInteraction)
+	mux.HandleFunc("GET /profile", s.handleGetProfile)
+	mux.HandleFunc("DELETE /profile", s.handleDeleteProfile)
+
+	// Reading queues (per X-User-ID)
+	mux.HandleFunc("GET /queue", s.handleListQueue)
+	mux.HandleFunc("POST /queue", s.handleEnqueue)
+	mux.HandleFunc("POST /queue/search", s.handleEnqueueSearch)
+	mux.HandleFunc("GET /queue/stats", s.handleQueueStats)
+	mux.HandleFunc("PATCH /queue/{id}", s.handleUpdateQueueItem)
+	mux.HandleFunc("DELETE /queue/{id}", s.handleDequeue)
+
+	// Watches: new sources matching a description (per X-User-ID)
+	mux.HandleFunc("GET /watches", s.handleListWatches)
+	mux.HandleFunc("POST /watches", s.handleCreateWatch)
+	mux.HandleFunc("GET /watches/stream", s.handleWatchStream)
+	mux.HandleFunc("GET /watches/{id}", s.handleGetWatch)
+	mux.HandleFunc("DELETE /watches/{id}", s.handleDeleteWatch)
+	mux.HandleFunc("GET /watches/{id}/matches", s.handleWatchMatches)
+
+	// Article search (uses existing article index)
+	mux.HandleFunc("POST /articles/search", s.handleSearchArticles)
+	mux.HandleFunc("GET /articles/search", s.handleSearchArticlesGET)
+	mux.HandleFunc("GET /articles/{id}", s.handleGetArticle)
+
+	// Aliases for renamed or merged source/article IDs
+	mux.HandleFunc("POST /aliases", s.handleCreateAlias)
+
+	// Entities (people, orgs, places) mentioned by sources
+	mux.HandleFunc("GET /entities/{kind}", s.handleListEntities)
+	mux.HandleFunc("GET /entities/{kind}/sources", s.handleGetEntitySources)
+	mux.HandleFunc("GET /entities/{kind}/suggestions", s.handleSuggestEntityAliases)
+	mux.HandleFunc("POST /entities/aliases", s.handleCreateEntityAlias)
+
+	// Article-source links proposed by the auto-linking job
+	mux.HandleFunc("GET /links/suggestions", s.handleListLinkSuggestions)
+	mux.HandleFunc("POST /links/suggestions/review", s.handleReviewLinkSuggestion)
+
+	// Category landing pages, from the index.md of Compendium directories
+	mux.HandleFunc("GET /categories/{path...}", s.handleGetCategory)
+
+	// Tags of sources and articles
+	mux.HandleFunc("GET /tags", s.handleListTags)
+	mux.HandleFunc("GET /tags/{tag}/sources", s.handleGetTagSources)
+	mux.HandleFunc("GET /tags/{tag}/articles", s.handleGetTagArticles)
+
+	// Topic taxonomy: descriptions, hierarchy and aliases of source topics
+	mux.HandleFunc("GET /topics", s.handleListTopics)
+	mux.HandleFunc("POST /topics", s.handleCreateTopic)
+	mux.HandleFunc("GET /topics/{slug}", s.handleGetTopic)
+	mux.HandleFunc("PATCH /topics/{slug}", s.handleUpdateTopic)
+	mux.HandleFunc("DELETE /topics/{slug}", s.handleDeleteTopic)
+
+	// Synonyms and stopwords applied to keyword queries
+	mux.HandleFunc("GET /admin/search/dictionary", s.handleGetDictionary)
+	mux.HandleFunc("PUT /admin/search/synonyms/{term}", s.handleSetSynonyms)
+	mux.HandleFunc("DELETE /admin/search/synonyms/{term}", s.handleDeleteSynonyms)
+	mux.HandleFunc("PUT /admin/search/stopwords/{word}", s.handleSetStopword)
+	mux.HandleFunc("DELETE /admin/search/stopwords/{word}", s.handleSetStopword)
+	mux.HandleFunc("GET /admin/vectors/{collection}/points", s.handleScrollPoints)
+	mux.HandleFunc("GET /admin/vectors/{collection}/count", s.handleCountPoints)
+	mux.HandleFunc("GET /admin/stats", s.handleStats)
+	mux.HandleFunc("POST /admin/topics/reassign", s.handleReassignTopics)
+	mux.HandleFunc("POST /admin/tags/rename", s.handleRenameTag)
+	mux.HandleFunc("POST /admin/reports/{topic}", s.handleGenerateReport)
+	mux.HandleFunc("GET /admin/retention", s.handleRetentionReport)
+	mux.HandleFunc("GET /admin/api-keys", s.handleListAPIKeys)
+	mux.HandleFunc("POST /admin/api-keys", s.handleCreateAPIKey)
+	mux.HandleFunc("POST /admin/api-keys/{id}/rotate", s.handleRotateAPIKey)
+	mux.HandleFunc("DELETE /admin/api-keys/{id}", s.handleDeleteAPIKey)
+	mux.HandleFunc("GET /admin/models", s.handleListModels)
+	mux.HandleFunc("GET /admin/models/status", s.handleModelStatus)
+	mux.HandleFunc("POST /admin/models/pull", s.handlePullModel)
+
+	// Topic reports (cmd/report)
+	mux.HandleFunc("GET /reports", s.handleListReports)
+	mux.HandleFunc("GET /reports/topics/{topic}", s.handleGetReport)
+
+	// Knowledge graph of articles, sources, topics and entities
+	mux.HandleFunc("GET /graph/path", s.handleGraphPath)
+	mux.HandleFunc("GET /graph/neighbors", s.handleGraphNeighbors)
+
+	// Writes are queued once authenticated
+	s.routes = mux
+	var routes http.Handler = mux
+	if s.guard != nil {
+		s.guard.OnHealthy(s.replayQueuedWrites)
+		routes = s.guardMiddleware(mux)
+	}
+	var handler http.Handler = deadlineMiddleware(adminMiddleware(deps.AdminToken, s.apiKeyMiddleware(routes)))
+	if deps.ReadOnly {
+		handler = readOnlyMiddleware(handler)
+	}
+	if deps.RateLimit.PerMinute > 0 {
+		handler = rateLimitMiddleware(deps.RateLimit, handler)
+	}
+
+	// Wrap with logging middleware
+	return loggingMiddleware(corsMiddleware(handler))
+}
+
+// Middleware
+
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+	})
+}
+
+// WriteTimeout is the write timeout the HTTP server should use
+const WriteTimeout = 60 * time.Second
+
+// requestDeadline bounds the work done for a request. It is shorter than
+// WriteTimeout, so database queries and Qdrant calls are cancelled before the
+// response can no longer be written.
+const requestDeadline = WriteTimeout - 5*time.Second
+
+// noDeadline are the endpoints that stream, or read bodies, for longer than
+// requestDeadline and clear their connection deadlines; only client
+// disconnects cancel them
+var noDeadline = map[string]bool{
+	"/admin/models/pull": true,
+	"/watches/stream":    true,
+	"/export":            true,
+	"/export/delta":      true,
+	"/import":            true,
+}
+
+// exemptFromDeadline reports whether a request path is one of noDeadline,
+// or a scroll of vector points, whose path has a wildcard
+func exemptFromDeadline(path string) bool {
+	return noDeadline[path] || scrollPath(path)
+}
+
+// deadlineMiddleware sets a deadline on the request context; together with
+// client disconnects this cancels the request's queries
+func deadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exemptFromDeadline(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), requestDeadline)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-User-ID, Authorization")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	counts, _ := s.db.CachedCounts(r.Context())
+
+	version, _ := s.db.GetInfo(r.Context(), "version")
+	if version == "" {
+		version = "unknown"
+	}
+
+	resp := HealthResponse{
+		Status:          "ok",
+		SourceCount:     counts["sources"].Count,
+		ArticleCount:    counts["articles"].Count,
+		Version:         version,
+		CountsUpdatedAt: make(map[string]string, len(counts)),
+		EmbeddingText:   make(map[string]string),
+		QdrantWrites:    s.vectorDB.WriteStats(),
+	}
+	if s.guard != nil {
+		st := s.guard.Status()
+		resp.Protection = &st
+		if st.Protected {
+			resp.Status = "degraded"
+		}
+		resp.QueuedWrites, _ = s.db.CountQueuedWrites(r.Context())
+	}
+	if sched := s.embedders.Scheduler(); sched != nil {
+		resp.EmbeddingQueue = sched.Stats()
+	}
+	if breaker := s.embedders.Breaker(); breaker != nil {
+		stats := breaker.Stats()
+		resp.EmbeddingRequests = &stats
+	}
+	for _, collection := range []string{"sources", "articles"} {
+		if st, _ := s.db.GetInfo(r.Context(), embedding.StrategyInfoKey(collection)); st != "" {
+			resp.EmbeddingText[collection] = st
+		}
+	}
+	for table, rc := range counts {
+		resp.CountsUpdatedAt[table] = rc.UpdatedAt
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,997 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/redact"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// maxHNSWEf bounds the hnsw_ef of search requests; larger candidate lists
+// cost more than an exact search
+const maxHNSWEf = 4096
+
+// Geo-radius search defaults and bounds, in kilometers
+const (
+	defaultRadiusKm = 50
+	maxRadiusKm     = 20000 // Half the Earth's circumference
+)
+
+func (s *Server) handleCreateSource(w http.ResponseWriter, r *http.Request) {
+	var req SourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	src, findings, errs := s.newSource(req, userID(r))
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	// Writes yield the embedding provider to searches
+	ctx := embedding.WithPriority(r.Context(), embedding.PriorityIngest)
+	if denied, err := s.checkKeyScope(ctx, src); err != nil || denied != "" {
+		writeScopeError(w, denied, err)
+		return
+	}
+
+	// Generate embeddings, from the official abstract when there is one
+	vectors, err := s.embedSource(ctx, &src)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+
+	// Store in SQLite
+	if err := s.db.InsertSource(ctx, src); err != nil {
+		log.Printf("Failed to insert source: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to store source")
+		return
+	}
+
+	// Store in Qdrant
+	if err := s.vectorDB.UpsertSourceVectors(vectordb.WithWait(ctx), src.ID, vectors, vectordb.NewSourcePayload(src)); err != nil {
+		log.Printf("Failed to store embedding: %v", err)
+		// Don't fail the request - SQLite has the data
+	}
+
+	writeJSON(w, http.StatusCreated, CreateSourceResponse{ID: src.ID, Findings: findings})
+}
+
+// maxContentBytes bounds the full text of a source
+const maxContentBytes = 2 << 20
+
+// newSource validates a source request and builds the source it creates,
+// owned by the given caller. Missing IDs and creation times are generated,
+// and the summary is scanned for sensitive data. Invalid requests return
+// every failing field.
+func (s *Server) newSource(req SourceRequest, owner string) (database.Source, []redact.Finding, fieldErrors) {
+	req.ID = slug.ID(req.ID)
+	req.Topic = slug.Make(req.Topic)
+	var errs fieldErrors
+	if req.URL == "" {
+		errs.add("url", CodeRequired, "url is required")
+	}
+	if req.Summary == "" {
+		errs.add("summary", CodeRequired, "summary is required")
+	}
+	if !database.ValidVisibility(req.Visibility) {
+		errs.add("visibility", CodeInvalid, "visibility must be public, internal, or private")
+	} else if req.Visibility == database.VisibilityPrivate && owner == "" {
+		errs.add("visibility", CodeConflict, "X-User-ID header is required for private sources")
+	}
+	req.Type = database.NormalizeSourceType(req.Type)
+	if !database.ValidSourceType(req.Type) {
+		errs.add("type", CodeInvalid, "type must be paper, blog, video, or dataset")
+	}
+	publishedAt, ok := database.NormalizePublishedAt(req.PublishedAt)
+	if !ok {
+		errs.add("published_at", CodeInvalid, "published_at must be an RFC 3339 time or a date (2006-01-02, 2006-01 or 2006)")
+	}
+	for i, loc := range req.Locations {
+		if err := loc.Validate(); err != nil {
+			errs.add(fmt.Sprintf("locations[%d]", i), CodeInvalid, fmt.Sprintf("invalid location: %v", err))
+		}
+	}
+	if len(req.Content) > maxContentBytes {
+		errs.add("content", CodeInvalid, fmt.Sprintf("content must be at most %d bytes", maxContentBytes))
+	}
+	var style *summarystyle.Profile
+	if req.SummaryStyle != "" {
+		if p, ok := s.summaryStyles.Get(req.SummaryStyle); ok {
+			style = &p
+		} else {
+			errs.add("summary_style", CodeInvalid, fmt.Sprintf("unknown summary style %q", req.SummaryStyle))
+		}
+	}
+	if len(errs) > 0 {
+		return database.Source{}, nil, errs
+	}
+
+	// Generate ID if not provided
+	if req.ID == "" {
+		req.ID = fmt.Sprintf("src-%d", time.Now().UnixNano())
+	}
+
+	// Set created_at if not provided
+	if req.CreatedAt == "" {
+		req.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	// Scan for secrets and personal data before anything is stored or embedded
+	var findings []redact.Finding
+	req.Summary, findings = redact.Apply(s.scanMode, req.Summary)
+	if req.Content != "" {
+		var contentFindings []redact.Finding
+		req.Content, contentFindings = redact.Apply(s.scanMode, req.Content)
+		for _, f := range contentFindings {
+			f.Field = "content"
+			findings = append(findings, f)
+		}
+	}
+	if len(findings) > 0 {
+		log.Printf("Source %s: %d sensitive data finding(s) (%s)", req.ID, len(findings), s.scanMode)
+	}
+
+	return database.Source{
+		ID:          req.ID,
+		URL:         req.URL,
+		Title:       req.Title,
+		Topic:       req.Topic,
+		Summary:     req.Summary,
+		Language:    req.Language,
+		Model:       req.Model,
+		CreatedAt:   req.CreatedAt,
+		PublishedAt: publishedAt,
+		Tags:        req.Tags,
+		Visibility:  req.Visibility,
+		Owner:       owner,
+		Type:        req.Type,
+		People:      req.People,
+		Orgs:        req.Orgs,
+		Places:      req.Places,
+		Locations:   req.Locations,
+
+		SummaryStyle: style,
+		Content:      req.Content,
+	}, findings, nil
+}
+
+// embedSource merges the canonical metadata of papers (DOI or arXiv URLs)
+// into a new source, geocodes its places, and generates its embeddings
+func (s *Server) embedSource(ctx context.Context, src *database.Source) (vectordb.Vectors, error) {
+	if s.scholar != nil {
+		if _, err := s.scholar.Enrich(ctx, src); err != nil {
+			log.Printf("Source %s: failed to fetch paper metadata: %v", src.ID, err)
+		}
+	}
+	if s.geocoder != nil {
+		if _, err := s.geocoder.Locate(ctx, src); err != nil {
+			log.Printf("Source %s: failed to geocode places: %v", src.ID, err)
+		}
+	}
+	return s.embedders.EmbedDocument(ctx, s.strategy, sourceDocument(*src))
+}
+
+// handleUpdateSource serves PATCH /sources/{id}: it updates the given fields
+// of a source in SQLite and Qdrant. The source is only re-embedded when its
+// embedding text changes; otherwise only its payload is sent, and its stored
+// vectors are kept.
+func (s *Server) handleUpdateSource(w http.ResponseWriter, r *http.Request) {
+	var req UpdateSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	if req.Summary != nil && *req.Summary == "" {
+		writeFieldError(w, "summary", CodeRequired, "summary must not be empty")
+		return
+	}
+
+	// Writes yield the embedding provider to searches
+	ctx := embedding.WithPriority(r.Context(), embedding.PriorityIngest)
+	id := r.PathValue("id")
+	src, err := s.db.GetSource(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src == nil || !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+
+	before := s.strategy.Texts(sourceDocument(*src))
+	if req.Title != nil {
+		src.Title = *req.Title
+	}
+	if req.Topic != nil {
+		src.Topic = slug.Make(*req.Topic)
+	}
+	if req.Tags != nil {
+		src.Tags = *req.Tags
+	}
+	if req.SummaryStyle != nil && *req.SummaryStyle != "" {
+		p, ok := s.summaryStyles.Get(*req.SummaryStyle)
+		if !ok {
+			writeFieldError(w, "summary_style", CodeInvalid, fmt.Sprintf("unknown summary style %q", *req.SummaryStyle))
+			return
+		}
+		src.SummaryStyle = &p
+	} else if req.SummaryStyle != nil || (req.Summary != nil && *req.Summary != src.Summary) {
+		src.SummaryStyle = nil
+	}
+	var findings []redact.Finding
+	if req.Summary != nil {
+		// Scan for secrets and personal data before anything is stored or embedded
+		src.Summary, findings = redact.Apply(s.scanMode, *req.Summary)
+		if len(findings) > 0 {
+			log.Printf("Source %s: %d sensitive data finding(s) (%s)", src.ID, len(findings), s.scanMode)
+		}
+	}
+
+	var vectors vectordb.Vectors
+	reembed := !slices.Equal(before, s.strategy.Texts(sourceDocument(*src)))
+	if !reembed {
+		exists, err := s.vectorDB.Exists(ctx, vectordb.SourcesCollection, src.ID)
+		if err != nil {
+			log.Printf("Failed to look up stored embedding: %v", err)
+		}
+		// Sources stored without a point get one now
+		reembed = !exists
+	}
+	if reembed {
+		vectors, err = s.embedders.EmbedDocument(ctx, s.strategy, sourceDocument(*src))
+		if err != nil {
+			log.Printf("Failed to generate embedding: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+			return
+		}
+	}
+
+	if err := s.db.InsertSource(ctx, *src); err != nil {
+		log.Printf("Failed to update source: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to store source")
+		return
+	}
+	if reembed {
+		err = s.vectorDB.UpsertSourceVectors(vectordb.WithWait(ctx), src.ID, vectors, vectordb.NewSourcePayload(*src))
+	} else {
+		err = s.vectorDB.OverwritePayload(vectordb.WithWait(ctx), vectordb.SourcesCollection, []string{src.ID}, vectordb.NewSourcePayload(*src).Values())
+	}
+	if err != nil {
+		log.Printf("Failed to update vector point: %v", err)
+		// Don't fail the request - SQLite has the data
+	} else if reembed {
+		// A cold source has its point again
+		if access, err := s.db.GetSourceAccess(ctx, src.ID); err == nil && access.ColdAt != "" {
+			if err := s.db.SetSourceCold(ctx, src.ID, ""); err != nil {
+				log.Printf("Failed to clear cold state: %v", err)
+			}
+		}
+	}
+	s.syncClaimPayloads(r, *src)
+
+	updated, err := s.db.GetSource(ctx, src.ID)
+	if err != nil || updated == nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, UpdateSourceResponse{Source: *updated, Reembedded: reembed, Findings: findings})
+}
+
+// sourceDocument returns the text of a source that can be embedded: the
+// official abstract when there is one, and the stored summary as body
+func sourceDocument(src database.Source) embedding.Document {
+	return embedding.Document{
+		Title:   src.Title,
+		Summary: src.EmbeddingText(),
+		Body:    src.Summary,
+	}
+}
+
+// nearCircle returns the circle of the near, near_place and radius_km
+// search parameters, or nil without near or near_place. Invalid parameters
+// are recorded in errs; the error is a failed near_place lookup.
+func (s *Server) nearCircle(ctx context.Context, req SearchRequest, errs *fieldErrors) (*vectordb.GeoRadius, error) {
+	if req.Near == "" && req.NearPlace == "" {
+		return nil, nil
+	}
+	if req.Near != "" && req.NearPlace != "" {
+		errs.add("near_place", CodeConflict, "near and near_place are exclusive")
+		return nil, nil
+	}
+	if req.RadiusKm == 0 {
+		req.RadiusKm = defaultRadiusKm
+	}
+	if req.RadiusKm < 0 || req.RadiusKm > maxRadiusKm {
+		errs.add("radius_km", CodeOutOfRange, fmt.Sprintf("radius_km must be between 0 and %d", maxRadiusKm))
+	}
+
+	var loc database.Location
+	if req.Near != "" {
+		lat, lon, ok := strings.Cut(req.Near, ",")
+		var err1, err2 error
+		loc.Lat, err1 = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		loc.Lon, err2 = strconv.ParseFloat(strings.TrimSpace(lon), 64)
+		if !ok || err1 != nil || err2 != nil || loc.Validate() != nil {
+			errs.add("near", CodeInvalid, "near must be lat,lon (e.g. 52.52,13.40)")
+		}
+	} else if s.geocoder == nil {
+		errs.add("near_place", CodeUnsupported, "near_place requires geocoding (KB_GEOCODE)")
+	} else if len(*errs) == 0 {
+		// Places are only looked up for otherwise valid requests
+		found, err := s.geocoder.Geocode(ctx, req.NearPlace)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			errs.add("near_place", CodeInvalid, "Unknown place: "+req.NearPlace)
+		} else {
+			loc = *found
+		}
+	}
+	if len(*errs) > 0 {
+		return nil, nil
+	}
+	return &vectordb.GeoRadius{
+		Center: vectordb.GeoPoint{Lat: loc.Lat, Lon: loc.Lon},
+		Meters: req.RadiusKm * 1000,
+	}, nil
+}
+
+func (s *Server) handleGetSource(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeFieldError(w, "id", CodeRequired, "id is required")
+		return
+	}
+
+	src, err := s.db.GetSource(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src == nil && s.redirectAlias(w, r, database.AliasSource, id, "/sources/") {
+		return
+	}
+	if src == nil || !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+	s.recordHits(r.Context(), []string{src.ID})
+	// A cold source is back in use; the record is served either way
+	if _, err := s.warmSource(r.Context(), *src); err != nil {
+		log.Printf("Failed to re-embed cold source %s: %v", src.ID, err)
+	}
+	if withContent, _ := strconv.ParseBool(r.URL.Query().Get("content")); withContent {
+		if src.Content, err = s.db.SourceContent(r.Context(), src.ID); err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, src)
+}
+
+func (s *Server) handleDeleteSource(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		writeFieldError(w, "id", CodeRequired, "id is required")
+		return
+	}
+
+	// Only callers who can see a source may delete it; unknown and deleted
+	// sources are left alone
+	src, err := s.db.GetSource(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src != nil && !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+
+	if err := s.deleteSource(r.Context(), id); err != nil {
+		log.Printf("Failed to delete source %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteSource soft-deletes a source: its record is hidden in SQLite and
+// its points flagged in Qdrant, both kept until the purge job removes them
+// (see package purge). Unknown and deleted sources are left alone.
+func (s *Server) deleteSource(ctx context.Context, id string) error {
+	src, err := s.db.GetSource(ctx, id)
+	if err != nil || src == nil {
+		return err
+	}
+	if err := s.db.DeleteSource(ctx, id); err != nil {
+		return err
+	}
+	// Cold sources have no points to flag
+	if access, err := s.db.GetSourceAccess(ctx, id); err == nil && access.ColdAt != "" {
+		return nil
+	}
+	if err := s.vectorDB.SetSourceDeleted(vectordb.WithWait(ctx), id, true); err != nil {
+		log.Printf("Failed to flag source %s as deleted in Qdrant: %v", id, err)
+	}
+	return nil
+}
+
+// handleRestoreSource serves POST /sources/{id}/restore: it brings back a
+// deleted source that wasn't purged yet and that the caller can see, with
+// its vectors, entities, links and claims, and returns it
+func (s *Server) handleRestoreSource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	src, err := s.db.RestoreSource(ctx, id, userID(r))
+	if err != nil {
+		log.Printf("Failed to restore source %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src == nil {
+		writeError(w, http.StatusNotFound, "Deleted source not found")
+		return
+	}
+	// A cold source is re-embedded when it is hit again
+	if access, err := s.db.GetSourceAccess(ctx, id); err != nil || access.ColdAt == "" {
+		if err := s.vectorDB.SetSourceDeleted(vectordb.WithWait(ctx), id, false); err != nil {
+			log.Printf("Failed to clear the deleted flag of source %s in Qdrant: %v", id, err)
+		}
+	}
+	writeJSON(w, http.StatusOK, src)
+}
+
+// maxListLimit bounds the page size of GET /sources
+const maxListLimit = 1000
+
+// handleListSources serves GET /sources: a page of the sources matching the
+// filters, and the next_cursor of the following page when there may be one
+func (s *Server) handleListSources(w http.ResponseWriter, r *http.Request) {
+	limit := boundedInt(r, "limit", 100, maxListLimit)
+
+	filter := database.SourceFilter{
+		Topic:  slug.Make(r.URL.Query().Get("topic")),
+		Domain: database.NormalizeDomain(r.URL.Query().Get("domain")),
+		Type:   database.NormalizeSourceType(r.URL.Query().Get("type")),
+	}
+	filter.HasCode, _ = strconv.ParseBool(r.URL.Query().Get("has_code"))
+	filter.HasDataset, _ = strconv.ParseBool(r.URL.Query().Get("has_dataset"))
+	var errs fieldErrors
+	if !database.ValidSourceType(filter.Type) {
+		errs.add("type", CodeInvalid, "type must be paper, blog, video, or dataset")
+	}
+	filter.Order = r.URL.Query().Get("sort")
+	if !database.ValidSourceOrder(filter.Order) {
+		errs.add("sort", CodeInvalid, "sort must be created or published")
+	}
+	publishedAfter, publishedBefore := parseTimeRange(&errs, "published",
+		r.URL.Query().Get("published_after"), r.URL.Query().Get("published_before"))
+	facets := parseFields(r.URL.Query().Get("facets"))
+	if !validFacets(facets) {
+		errs.add("facets", CodeInvalid, "facets must be type")
+	}
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		cursor, err := database.ParseSourceCursor(c)
+		if err != nil {
+			errs.add("cursor", CodeInvalid, "cursor must be the next_cursor of a previous page")
+		} else if (cursor.Order == database.SourceOrderPublished) != (filter.Order == database.SourceOrderPublished) {
+			errs.add("cursor", CodeInvalid, "cursor belongs to a listing with another sort")
+		} else {
+			filter.After = &cursor
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if !publishedAfter.IsZero() {
+		filter.PublishedAfter = publishedAfter.UTC().Format(time.RFC3339)
+	}
+	if !publishedBefore.IsZero() {
+		filter.PublishedBefore = publishedBefore.UTC().Format(time.RFC3339)
+	}
+
+	ctx := r.Context()
+	sources, err := s.db.ListSources(ctx, filter, limit, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	maxChars := summaryMaxChars(r)
+	for i := range sources {
+		sources[i].Summary = truncateSummary(sources[i].Summary, maxChars)
+	}
+
+	resp := map[string]interface{}{
+		"sources": sources,
+		"count":   len(sources),
+	}
+	// A full page may have more after it
+	if len(sources) == limit {
+		resp["next_cursor"] = database.NewSourceCursor(sources[len(sources)-1], filter.Order).Encode()
+	}
+	if counts := s.listFacets(ctx, facets, filter, userID(r)); counts != nil {
+		resp["facets"] = counts
+	}
+	writeJSONFields(w, http.StatusOK, resp, "sources", parseFields(r.URL.Query().Get("fields")))
+}
+
+func (s *Server) handleSearchSources(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	s.searchSources(w, r, req)
+}
+
+func (s *Server) handleSearchSourcesGET(w http.ResponseWriter, r *http.Request) {
+	req := SearchRequest{
+		Query:    r.URL.Query().Get("q"),
+		Topic:    r.URL.Query().Get("topic"),
+		Domain:   r.URL.Query().Get("domain"),
+		Type:     r.URL.Query().Get("type"),
+		Model:    r.URL.Query().Get("model"),
+		Language: r.URL.Query().Get("language"),
+	}
+	req.Hybrid, _ = strconv.ParseBool(r.URL.Query().Get("hybrid"))
+	req.LateInteraction, _ = strconv.ParseBool(r.URL.Query().Get("late_interaction"))
+	req.Rerank, _ = strconv.ParseBool(r.URL.Query().Get("rerank"))
+	req.HasCode, _ = strconv.ParseBool(r.URL.Query().Get("has_code"))
+	req.HasDataset, _ = strconv.ParseBool(r.URL.Query().Get("has_dataset"))
+	req.Tags = parseFields(r.URL.Query().Get("tags"))
+	req.TagsMatch = r.URL.Query().Get("tags_match")
+	req.SourceLanguage = r.URL.Query().Get("source_language")
+	req.CreatedAfter = r.URL.Query().Get("created_after")
+	req.CreatedBefore = r.URL.Query().Get("created_before")
+	req.PublishedAfter = r.URL.Query().Get("published_after")
+	req.PublishedBefore = r.URL.Query().Get("published_before")
+	req.Near = r.URL.Query().Get("near")
+	req.NearPlace = r.URL.Query().Get("near_place")
+	req.RadiusKm, _ = strconv.ParseFloat(r.URL.Query().Get("radius_km"), 64)
+	req.Personalize, _ = strconv.ParseBool(r.URL.Query().Get("personalize"))
+	req.Duplicates, _ = strconv.ParseBool(r.URL.Query().Get("duplicates"))
+	req.Exact, _ = strconv.ParseBool(r.URL.Query().Get("exact"))
+	req.HNSWEf, _ = strconv.Atoi(r.URL.Query().Get("hnsw_ef"))
+	req.QueryToken = r.URL.Query().Get("query_token")
+	req.ReturnEmbedding, _ = strconv.ParseBool(r.URL.Query().Get("return_embedding"))
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = l
+		}
+	}
+
+	s.searchSources(w, r, req)
+}
+
+func (s *Server) searchSources(w http.ResponseWriter, r *http.Request, req SearchRequest) {
+	req.Topic = slug.Make(req.Topic)
+	var errs fieldErrors
+	s.resolveQueryToken(&req.Query, req.QueryToken, &errs)
+	if req.Query == "" && req.Embedding == "" && req.QueryToken == "" {
+		errs.add("query", CodeRequired, "query or embedding is required")
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = parseFields(r.URL.Query().Get("fields"))
+	}
+	if req.SummaryMaxChars == 0 {
+		req.SummaryMaxChars = summaryMaxChars(r)
+	}
+	if len(req.Facets) == 0 {
+		req.Facets = parseFields(r.URL.Query().Get("facets"))
+	}
+	req.Type = database.NormalizeSourceType(req.Type)
+	if !database.ValidSourceType(req.Type) {
+		errs.add("type", CodeInvalid, "type must be paper, blog, video, or dataset")
+	}
+	if !validFacets(req.Facets) {
+		errs.add("facets", CodeInvalid, "facets must be type")
+	}
+
+	if req.HNSWEf < 0 || req.HNSWEf > maxHNSWEf {
+		errs.add("hnsw_ef", CodeOutOfRange, fmt.Sprintf("hnsw_ef must be between 1 and %d", maxHNSWEf))
+	}
+	if req.TagsMatch != "" && req.TagsMatch != "any" && req.TagsMatch != "all" {
+		errs.add("tags_match", CodeInvalid, "tags_match must be any or all")
+	}
+	createdAfter, createdBefore := parseTimeRange(&errs, "created", req.CreatedAfter, req.CreatedBefore)
+	publishedAfter, publishedBefore := parseTimeRange(&errs, "published", req.PublishedAfter, req.PublishedBefore)
+	if req.LateInteraction && req.Query == "" {
+		errs.add("late_interaction", CodeConflict, "late_interaction requires query")
+	} else if req.LateInteraction && !s.vectorDB.LateInteractionEnabled(vectordb.SourcesCollection) {
+		errs.add("late_interaction", CodeUnsupported, "late_interaction is not enabled for sources")
+	}
+	if req.Rerank && req.Query == "" {
+		errs.add("rerank", CodeConflict, "rerank requires query")
+	} else if req.Rerank && s.reranker == nil {
+		errs.add("rerank", CodeUnsupported, "reranking is not enabled (RERANK_PROVIDER)")
+	}
+	if req.Personalize && userID(r) == "" {
+		errs.add("personalize", CodeConflict, "X-User-ID header is required to personalize")
+	}
+
+	embedder := s.embedders.Get(req.Model)
+	if req.Model == "" && req.Language != "" {
+		embedder = s.embedders.ForLanguage(req.Language)
+	}
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	near, err := s.nearCircle(r.Context(), req, &errs)
+	if err != nil {
+		log.Printf("Failed to geocode %q: %v", req.NearPlace, err)
+		writeError(w, http.StatusBadGateway, "Geocoding failed")
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	ctx := r.Context()
+	var emb []float32
+	var token string
+
+	if req.Embedding != "" {
+		// Decode base64 embedding
+		emb, err = decodeEmbedding(req.Embedding)
+		if err != nil {
+			writeFieldError(w, "embedding", CodeInvalid, "Invalid embedding format")
+			return
+		}
+	} else {
+		// Generate embedding from query, unless its token holds it
+		emb, token, err = s.queryEmbedding(ctx, embedder, req.Query, req.QueryToken)
+		if err != nil {
+			log.Printf("Failed to generate embedding: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+			return
+		}
+	}
+
+	queryEmbedding := emb
+
+	// Blend the query with the caller's interest profile
+	personalized := false
+	if req.Personalize {
+		user := userID(r)
+		profile, err := s.db.GetProfile(ctx, user, embedder.Model())
+		if err != nil {
+			log.Printf("Failed to load profile: %v", err)
+		} else if profile != nil {
+			weight := req.ProfileWeight
+			if weight <= 0 || weight >= 1 {
+				weight = defaultProfileWeight
+			}
+			emb = embedding.Blend(emb, profile.Embedding, weight)
+			personalized = true
+		}
+	}
+
+	// Search Qdrant
+	opts := vectordb.SearchOptions{
+		Topic:      req.Topic,
+		Domain:     database.NormalizeDomain(req.Domain),
+		Type:       req.Type,
+		HasCode:    req.HasCode,
+		HasDataset: req.HasDataset,
+		User:       userID(r),
+		HNSWEf:     uint64(req.HNSWEf),
+		Exact:      req.Exact,
+
+		Tags:            req.Tags,
+		AllTags:         req.TagsMatch == "all",
+		Language:        req.SourceLanguage,
+		CreatedAfter:    createdAfter,
+		CreatedBefore:   createdBefore,
+		PublishedAfter:  publishedAfter,
+		PublishedBefore: publishedBefore,
+		Near:            near,
+	}
+	s.scopeTopic(ctx, &opts)
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = embedder.Model()
+	}
+	hybrid := req.Hybrid && req.Query != "" && s.vectorDB.SparseEnabled()
+	if hybrid {
+		opts.Text = req.Query
+	}
+	if req.LateInteraction {
+		opts.LateInteraction = req.Query
+	}
+	// Within a topic, fetch extra candidates so curated sources just below
+	// the cut can be boosted into the results
+	fetch := req.Limit
+	if req.Topic != "" {
+		fetch = req.Limit * linkedCandidateFactor
+	}
+	if !req.Duplicates {
+		fetch = max(fetch, req.Limit*dedupeCandidateFactor)
+	}
+	if req.Rerank {
+		fetch = max(fetch, s.rerankCandidates)
+	}
+	var searchResults []SearchResult
+	scores := s.vectorDB.Distance(vectordb.SourcesCollection).Scores()
+	degraded := false
+	results, err := s.vectorDB.SearchSources(ctx, emb, fetch, opts)
+	switch {
+	case err != nil && vectordb.Unavailable(err) && keywordFallback(req):
+		// Qdrant is down: keyword matches keep search working meanwhile
+		log.Printf("Vector search unavailable, falling back to keyword search: %v", err)
+		searchResults, err = s.keywordSources(ctx, req.Query, fetch, opts)
+		if err != nil {
+			log.Printf("Keyword search failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Search failed")
+			return
+		}
+		scores, hybrid, degraded = keywordScores, false, true
+	case err != nil:
+		log.Printf("Vector search failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Search failed")
+		return
+	default:
+		if hybrid {
+			scores = vectordb.FusedScores()
+		}
+		if req.LateInteraction {
+			scores = vectordb.LateInteractionScores()
+		}
+		searchResults = sourceResults(results)
+	}
+
+	var rerankModel string
+	if req.Rerank {
+		// Cosine ranking surfaces tangential sources; a failed reranker
+		// leaves it in place rather than failing the search
+		if reranked, err := s.rerankResults(ctx, req.Query, searchResults, req.Limit); err != nil {
+			log.Printf("Reranking failed: %v", err)
+		} else {
+			searchResults = reranked
+			scores = rerankScores()
+			rerankModel = s.reranker.Model()
+		}
+	}
+	if req.Topic != "" {
+		searchResults = s.boostLinkedSources(ctx, opts.Topic, searchResults, scores.HigherIsBetter)
+	}
+	if !req.Duplicates {
+		searchResults = collapseDuplicates(searchResults)
+	}
+	if len(searchResults) > req.Limit {
+		searchResults = searchResults[:req.Limit]
+	}
+	truncateSummaries(searchResults, req.SummaryMaxChars)
+	hits := make([]string, len(searchResults))
+	for i, result := range searchResults {
+		hits[i] = result.ID
+	}
+	s.recordHits(ctx, hits)
+
+	resp := SearchResponse{
+		Results:        searchResults,
+		Count:          len(searchResults),
+		EmbeddingModel: embedder.Model(),
+		Hybrid:         hybrid,
+		Scores:         &scores,
+		Personalized:   personalized,
+		RerankModel:    rerankModel,
+		Degraded:       degraded,
+		QueryToken:     token,
+	}
+	if req.ReturnEmbedding {
+		resp.QueryEmbedding = encodeEmbedding(queryEmbedding)
+	}
+	if !degraded {
+		resp.Facets = s.searchFacets(ctx, req.Facets, opts)
+	}
+	writeJSONFields(w, http.StatusOK, resp, "results", req.Fields)
+}
+
+// keywordFallbackFactor is how many keyword matches degraded searches fetch
+// per result, as the filters applied afterwards drop some
+const keywordFallbackFactor = 4
+
+// keywordFallback reports whether a source search can fall back to keyword
+// search while Qdrant is down: it needs the query text, and filters only
+// Qdrant evaluates rule it out rather than being ignored
+func keywordFallback(req SearchRequest) bool {
+	return req.Query != "" && req.Near == "" && req.NearPlace == "" && !req.HasCode && !req.HasDataset
+}
+
+// keywordSources searches the FTS index for the sources of a query the
+// caller can see, applying the filters of opts that sources carry
+func (s *Server) keywordSources(ctx context.Context, query string, limit int, opts vectordb.SearchOptions) ([]SearchResult, error) {
+	srcs, err := s.db.SearchSources(ctx, query, limit*keywordFallbackFactor, opts.User)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	createdAfter, createdBefore := bound(opts.CreatedAfter), bound(opts.CreatedBefore)
+	publishedAfter, publishedBefore := bound(opts.PublishedAfter), bound(opts.PublishedBefore)
+
+	results := []SearchResult{}
+	for _, src := range srcs {
+		switch {
+		case !opts.MatchesTopic(src.Topic),
+			opts.Domain != "" && src.Domain != opts.Domain,
+			opts.Type != "" && src.Type != opts.Type,
+			opts.Language != "" && src.Language != opts.Language,
+			len(opts.Tags) > 0 && !matchTags(src.Tags, opts.Tags, opts.AllTags),
+			createdAfter != "" && src.CreatedAt < createdAfter,
+			createdBefore != "" && src.CreatedAt >= createdBefore,
+			publishedAfter != "" && (src.PublishedAt == "" || src.PublishedAt < publishedAfter),
+			publishedBefore != "" && (src.PublishedAt == "" || src.PublishedAt >= publishedBefore):
+			continue
+		}
+		results = append(results, sourceRecordResult(src))
+		if len(results) == limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// matchTags reports whether tags has any (or, with all, every) wanted tag
+func matchTags(tags, wanted []string, all bool) bool {
+	for _, want := range wanted {
+		if slices.Contains(tags, want) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+func (s *Server) handleRecommendSources(w http.ResponseWriter, r *http.Request) {
+	var req RecommendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+
+	req.Topic = slug.Make(req.Topic)
+	var errs fieldErrors
+	if len(req.Positive) == 0 {
+		errs.add("positive", CodeRequired, "at least one positive source is required")
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = parseFields(r.URL.Query().Get("fields"))
+	}
+	if req.SummaryMaxChars == 0 {
+		req.SummaryMaxChars = summaryMaxChars(r)
+	}
+	if len(req.Facets) == 0 {
+		req.Facets = parseFields(r.URL.Query().Get("facets"))
+	}
+	req.Type = database.NormalizeSourceType(req.Type)
+	if !database.ValidSourceType(req.Type) {
+		errs.add("type", CodeInvalid, "type must be paper, blog, video, or dataset")
+	}
+	if !validFacets(req.Facets) {
+		errs.add("facets", CodeInvalid, "facets must be type")
+	}
+	if !vectordb.ValidRecommendStrategy(req.Strategy) {
+		errs.add("strategy", CodeInvalid, "strategy must be average_vector, best_score, or sum_scores")
+	}
+	embedder := s.embedders.Get(req.Model)
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	opts := vectordb.SearchOptions{Topic: req.Topic, Type: req.Type, User: userID(r)}
+	s.scopeTopic(r.Context(), &opts)
+	if len(s.vectorDB.VectorSpaces()) > 0 {
+		opts.Vector = embedder.Model()
+	}
+	fetch := req.Limit
+	if !req.Duplicates {
+		fetch = req.Limit * dedupeCandidateFactor
+	}
+	// Recommendations start from the vectors of the examples
+	if err := s.warmSources(r.Context(), append(slices.Clone(req.Positive), req.Negative...)); err != nil {
+		log.Printf("Failed to re-embed cold source: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to re-embed cold source")
+		return
+	}
+	results, err := s.vectorDB.RecommendSources(r.Context(), req.Positive, req.Negative, req.Strategy, fetch, opts)
+	if err != nil {
+		log.Printf("Recommend failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Recommend failed")
+		return
+	}
+
+	searchResults := sourceResults(results)
+	if !req.Duplicates {
+		searchResults = collapseDuplicates(searchResults)
+	}
+	if len(searchResults) > req.Limit {
+		searchResults = searchResults[:req.Limit]
+	}
+	truncateSummaries(searchResults, req.SummaryMaxChars)
+
+	// Only average_vector scores are plain similarities to one vector; the
+	// other strategies combine several, so their bounds don't hold
+	scores := s.vectorDB.Distance(vectordb.SourcesCollection).Scores()
+	if req.Strategy != "" && req.Strategy != "average_vector" {
+		scores.Min, scores.Max = nil, nil
+	}
+	writeJSONFields(w, http.StatusOK, SearchResponse{
+		Results:        searchResults,
+		Count:          len(searchResults),
+		EmbeddingModel: embedder.Model(),
+		Scores:         &scores,
+		Facets:         s.searchFacets(r.Context(), req.Facets, opts),
+	}, "results", req.Fields)
+}
+
+func (s *Server) handleGetSourcesByTopic(w http.ResponseWriter, r *http.Request) {
+	topic := slug.Make(r.PathValue("topic"))
+	if topic == "" {
+		writeFieldError(w, "topic", CodeRequired, "topic is required")
+		return
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	limit := 100
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	sources, err := s.db.GetSourcesByTopic(r.Context(), topic, limit, userID(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	maxChars := summaryMaxChars(r)
+	for i := range sources {
+		sources[i].Summary = truncateSummary(sources[i].Summary, maxChars)
+	}
+
+	writeJSONFields(w, http.StatusOK, map[string]interface{}{
+		"sources": sources,
+		"count":   len(sources),
+	}, "sources", parseFields(r.URL.Query().Get("fields")))
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/testsupport"
+)
+
+// TestListSourcesCursor checks that paging GET /sources with next_cursor
+// returns every source once, in order, including sources created at the
+// same time and undated ones in the publication order
+func TestListSourcesCursor(t *testing.T) {
+	for _, sqlite := range []bool{false, true} {
+		srv := testsupport.NewServer(t, testsupport.Options{SQLite: sqlite})
+		for i := range 7 {
+			req := api.SourceRequest{
+				ID:        fmt.Sprintf("src-%d", i),
+				URL:       fmt.Sprintf("https://example.com/%d", i),
+				Title:     fmt.Sprintf("Source %d", i),
+				Topic:     "paging",
+				Summary:   "A source listed a page at a time.",
+				CreatedAt: fmt.Sprintf("2025-01-0%dT00:00:00Z", 1+i/2),
+			}
+			if i%3 != 0 {
+				req.PublishedAt = fmt.Sprintf("2024-0%d-01", 1+i%2)
+			}
+			if status := srv.Do(t, http.MethodPost, "/sources", req, nil); status != http.StatusCreated {
+				t.Fatalf("sqlite=%v: POST /sources %d: status %d", sqlite, i, status)
+			}
+		}
+
+		for _, sort := range []string{"", database.SourceOrderPublished} {
+			var all struct {
+				Sources []database.Source `json:"sources"`
+			}
+			if status := srv.Do(t, http.MethodGet, "/sources?limit=100&sort="+sort, nil, &all); status != http.StatusOK {
+				t.Fatalf("sqlite=%v sort=%q: GET /sources: status %d", sqlite, sort, status)
+			}
+			if len(all.Sources) != 7 {
+				t.Fatalf("sqlite=%v sort=%q: GET /sources: %d sources, want 7", sqlite, sort, len(all.Sources))
+			}
+
+			var paged []string
+			cursor := ""
+			for range 10 {
+				var page struct {
+					Sources    []database.Source `json:"sources"`
+					NextCursor string            `json:"next_cursor"`
+				}
+				path := "/sources?limit=2&sort=" + sort + "&cursor=" + url.QueryEscape(cursor)
+				if status := srv.Do(t, http.MethodGet, path, nil, &page); status != http.StatusOK {
+					t.Fatalf("sqlite=%v sort=%q: GET %s: status %d", sqlite, sort, path, status)
+				}
+				for _, src := range page.Sources {
+					paged = append(paged, src.ID)
+				}
+				if cursor = page.NextCursor; cursor == "" {
+					break
+				}
+			}
+			for i, src := range all.Sources {
+				if i >= len(paged) || paged[i] != src.ID {
+					t.Errorf("sqlite=%v sort=%q: pages list %v, want the order of one page %v", sqlite, sort, paged, all.Sources)
+					break
+				}
+			}
+			if len(paged) != len(all.Sources) {
+				t.Errorf("sqlite=%v sort=%q: pages list %d sources, want %d", sqlite, sort, len(paged), len(all.Sources))
+			}
+		}
+
+		if status := srv.Do(t, http.MethodGet, "/sources?cursor=bogus", nil, nil); status != http.StatusBadRequest {
+			t.Errorf("sqlite=%v: GET /sources with a bogus cursor: status %d, want 400", sqlite, status)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/spell"
+)
+
+const (
+	// spellingTTL is how long a corrector built from the article vocabulary
+	// is reused before it is rebuilt, picking up re-indexed articles
+	spellingTTL = 10 * time.Minute
+	// spellingMinDocs leaves out of the vocabulary the terms of fewer
+	// articles, which are mostly names and typos themselves
+	spellingMinDocs = 2
+)
+
+// spellingCache holds the corrector built from the article vocabulary
+type spellingCache struct {
+	mu        sync.Mutex
+	corrector *spell.Corrector
+	builtAt   time.Time
+}
+
+// corrector returns the spelling corrector, building it if it is missing or
+// older than spellingTTL
+func (s *Server) corrector(ctx context.Context) (*spell.Corrector, error) {
+	s.spelling.mu.Lock()
+	defer s.spelling.mu.Unlock()
+	if s.spelling.corrector != nil && time.Since(s.spelling.builtAt) < spellingTTL {
+		return s.spelling.corrector, nil
+	}
+
+	vocab, err := s.db.ArticleVocabulary(ctx, spellingMinDocs)
+	if err != nil {
+		return nil, err
+	}
+	s.spelling.corrector = spell.New(vocab)
+	s.spelling.builtAt = time.Now()
+	return s.spelling.corrector, nil
+}
+
+// suggestQuery returns the query with its misspelled terms corrected, or ""
+// if there is nothing to correct. Synonyms and stopwords are never
+// corrected.
+func (s *Server) suggestQuery(ctx context.Context, query string) string {
+	corrector, err := s.corrector(ctx)
+	if err != nil {
+		log.Printf("Failed to build spelling corrector: %v", err)
+		return ""
+	}
+	dict, err := s.db.SearchDictionary(ctx)
+	if err != nil {
+		log.Printf("Failed to load search dictionary: %v", err)
+		return ""
+	}
+	stopwords := make(map[string]bool, len(dict.Stopwords))
+	for _, w := range dict.Stopwords {
+		stopwords[w] = true
+	}
+
+	corrected, ok := corrector.CorrectQuery(query, func(word string) bool {
+		_, synonym := dict.Synonyms[word]
+		return synonym || stopwords[word]
+	})
+	if !ok {
+		return ""
+	}
+	return corrected
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
+)
+
+// summaryStyle pairs a profile with its rendered prompt
+func summaryStyle(p summarystyle.Profile) SummaryStyle {
+	return SummaryStyle{Profile: p, Prompt: p.Prompt()}
+}
+
+// handleListSummaryStyles serves GET /summary-styles: the available
+// profiles, the default, and the profile of each topic that has its own
+func (s *Server) handleListSummaryStyles(w http.ResponseWriter, r *http.Request) {
+	resp := SummaryStylesResponse{
+		Default:  s.summaryStyles.Default(),
+		Profiles: []SummaryStyle{},
+		Topics:   s.summaryStyles.Topics(),
+	}
+	for _, p := range s.summaryStyles.Profiles() {
+		resp.Profiles = append(resp.Profiles, summaryStyle(p))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleResolveSummaryStyle serves GET /summary-styles/resolve: the profile
+// a summarizer should write a summary with, for ?style= (a profile name
+// chosen per request) or else ?topic=
+func (s *Server) handleResolveSummaryStyle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p, err := s.summaryStyles.Resolve(q.Get("style"), slug.Make(q.Get("topic")))
+	if err != nil {
+		writeFieldError(w, "style", CodeInvalid, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summaryStyle(p))
+}
+
+// handleGetSourceSummaryStyle serves GET /summary-styles/sources/{id}: the
+// profile stored with the source, to regenerate its summary the way it was
+// written even after the profile file changed
+func (s *Server) handleGetSourceSummaryStyle(w http.ResponseWriter, r *http.Request) {
+	src, err := s.db.GetSource(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if src == nil || !src.VisibleTo(userID(r)) {
+		writeError(w, http.StatusNotFound, "Source not found")
+		return
+	}
+	if src.SummaryStyle == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Source %s has no summary style", src.ID))
+		return
+	}
+	writeJSON(w, http.StatusOK, summaryStyle(*src.SummaryStyle))
+}
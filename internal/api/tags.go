@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// handleListTags serves GET /tags: the tags of the sources visible to the
+// caller and of the articles, with how many of each carry them, most used
+// first
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.db.Tags(r.Context(), boundedInt(r, "limit", 100, 1000), userID(r))
+	if err != nil {
+		log.Printf("Failed to list tags: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, TagsResponse{Tags: tags, Count: len(tags)})
+}
+
+// handleGetTagSources serves GET /tags/{tag}/sources: the sources with a
+// tag, newest first
+func (s *Server) handleGetTagSources(w http.ResponseWriter, r *http.Request) {
+	tag := strings.TrimSpace(r.PathValue("tag"))
+	sources, err := s.db.SourcesByTag(r.Context(), tag, boundedInt(r, "limit", 100, 1000), userID(r))
+	if err != nil {
+		log.Printf("Failed to list sources of tag %s: %v", tag, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if sources == nil {
+		sources = []database.Source{}
+	}
+
+	maxChars := summaryMaxChars(r)
+	for i := range sources {
+		sources[i].Summary = truncateSummary(sources[i].Summary, maxChars)
+	}
+
+	writeJSONFields(w, http.StatusOK, map[string]interface{}{
+		"tag":     tag,
+		"sources": sources,
+		"count":   len(sources),
+	}, "sources", parseFields(r.URL.Query().Get("fields")))
+}
+
+// handleGetTagArticles serves GET /tags/{tag}/articles: the articles with a
+// tag, by title
+func (s *Server) handleGetTagArticles(w http.ResponseWriter, r *http.Request) {
+	tag := strings.TrimSpace(r.PathValue("tag"))
+	articles, err := s.db.ArticlesByTag(r.Context(), tag, boundedInt(r, "limit", 100, 1000))
+	if err != nil {
+		log.Printf("Failed to list articles of tag %s: %v", tag, err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if articles == nil {
+		articles = []database.Article{}
+	}
+	writeJSON(w, http.StatusOK, TagArticlesResponse{Tag: tag, Articles: articles, Count: len(articles)})
+}
+
+// handleRenameTag serves POST /admin/tags/rename: it replaces a tag of
+// every source having it, in SQLite and in the Qdrant payloads. Article
+// tags come from frontmatter and are renamed there.
+func (s *Server) handleRenameTag(w http.ResponseWriter, r *http.Request) {
+	var req RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	req.From, req.To = strings.TrimSpace(req.From), strings.TrimSpace(req.To)
+	var errs fieldErrors
+	if req.From == "" {
+		errs.add("from", CodeRequired, "from is required")
+	}
+	if req.To == "" {
+		errs.add("to", CodeRequired, "to is required")
+	} else if req.To == req.From {
+		errs.add("to", CodeInvalid, "to must differ from from")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	ids, err := s.db.SourceIDsByTag(ctx, req.From)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	resp := RenameTagResponse{From: req.From, To: req.To, Renamed: len(ids), DryRun: req.DryRun, SourceIDs: ids}
+	if resp.SourceIDs == nil {
+		resp.SourceIDs = []string{}
+	}
+	if req.DryRun || len(ids) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	srcs := make([]database.Source, 0, len(ids))
+	for _, id := range ids {
+		src, err := s.db.GetSource(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if src == nil {
+			continue // Deleted meanwhile
+		}
+		src.Tags = renameTag(src.Tags, req.From, req.To)
+		srcs = append(srcs, *src)
+	}
+	if err := s.db.InsertSources(ctx, srcs); err != nil {
+		log.Printf("Failed to rename tag %s: %v", req.From, err)
+		writeError(w, http.StatusInternalServerError, "Failed to store sources")
+		return
+	}
+
+	// Payloads hold the whole tag list, so each source's payload is
+	// rewritten. Failures don't fail the request: SQLite has the data, and
+	// reconcile or rebuild repair the payloads.
+	ctx = vectordb.WithWait(ctx)
+	for _, src := range srcs {
+		if err := s.vectorDB.OverwritePayload(ctx, vectordb.SourcesCollection, []string{src.ID}, vectordb.NewSourcePayload(src).Values()); err != nil {
+			log.Printf("Failed to rename tag %s of source %s in Qdrant: %v", req.From, src.ID, err)
+		}
+	}
+
+	log.Printf("Renamed tag %s to %s on %d sources", req.From, req.To, len(srcs))
+	resp.Renamed = len(srcs)
+	resp.SourceIDs = make([]string, len(srcs))
+	for i, src := range srcs {
+		resp.SourceIDs[i] = src.ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// renameTag returns tags with from replaced by to in place, keeping a
+// single to
+func renameTag(tags []string, from, to string) []string {
+	renamed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == from {
+			tag = to
+		}
+		if !slices.Contains(renamed, tag) {
+			renamed = append(renamed, tag)
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// handleListTopics serves GET /topics: the whole taxonomy, by slug
+func (s *Server) handleListTopics(w http.ResponseWriter, r *http.Request) {
+	topics, err := s.db.ListTopics(r.Context())
+	if err != nil {
+		log.Printf("Failed to list topics: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, TopicsResponse{Topics: topics, Count: len(topics)})
+}
+
+// handleGetTopic serves GET /topics/{slug}: a topic, looked up by its slug
+// or an alias, with the topics above and directly below it
+func (s *Server) handleGetTopic(w http.ResponseWriter, r *http.Request) {
+	t, topics, ok := s.lookupTopic(w, r)
+	if !ok {
+		return
+	}
+	resp := TopicResponse{Topic: *t, Ancestors: database.TopicAncestors(topics, t.Slug), Children: []string{}}
+	for _, child := range topics {
+		if child.Parent == t.Slug {
+			resp.Children = append(resp.Children, child.Slug)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleCreateTopic serves POST /topics: it adds a topic to the taxonomy
+func (s *Server) handleCreateTopic(w http.ResponseWriter, r *http.Request) {
+	var req CreateTopicRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	t := database.Topic{
+		Slug:        slug.Make(req.Slug),
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Parent:      slug.Make(req.Parent),
+		Aliases:     topicAliases(req.Aliases),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if t.Slug == "" {
+		t.Slug = slug.Make(t.Name)
+	}
+
+	ctx := r.Context()
+	topics, err := s.db.ListTopics(ctx)
+	if err != nil {
+		log.Printf("Failed to list topics: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if t.Parent != "" {
+		t.Parent = database.TopicScope(topics, t.Parent)[0] // Parents may be named by an alias
+	}
+	var errs fieldErrors
+	if t.Name == "" {
+		errs.add("name", CodeRequired, "name is required")
+	}
+	if t.Slug == "" && t.Name != "" {
+		errs.add("slug", CodeInvalid, "slug must have letters or digits")
+	}
+	for _, other := range topics {
+		if other.Slug == t.Slug || slices.Contains(other.Aliases, t.Slug) {
+			errs.add("slug", CodeConflict, fmt.Sprintf("topic %s already exists", other.Slug))
+		}
+	}
+	errs = append(errs, validateTopic(topics, t)...)
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if err := s.db.PutTopic(ctx, t); err != nil {
+		log.Printf("Failed to create topic %s: %v", t.Slug, err)
+		writeError(w, http.StatusInternalServerError, "Failed to store topic")
+		return
+	}
+	log.Printf("Created topic %s", t.Slug)
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// handleUpdateTopic serves PATCH /topics/{slug}: it changes the name,
+// description, parent or aliases of a topic
+func (s *Server) handleUpdateTopic(w http.ResponseWriter, r *http.Request) {
+	var req UpdateTopicRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	t, topics, ok := s.lookupTopic(w, r)
+	if !ok {
+		return
+	}
+	var errs fieldErrors
+	if req.Name != nil {
+		if t.Name = strings.TrimSpace(*req.Name); t.Name == "" {
+			errs.add("name", CodeRequired, "name must not be empty")
+		}
+	}
+	if req.Description != nil {
+		t.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Parent != nil {
+		if t.Parent = slug.Make(*req.Parent); t.Parent != "" {
+			t.Parent = database.TopicScope(topics, t.Parent)[0]
+		}
+	}
+	if req.Aliases != nil {
+		t.Aliases = topicAliases(*req.Aliases)
+	}
+	errs = append(errs, validateTopic(topics, *t)...)
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := s.db.PutTopic(r.Context(), *t); err != nil {
+		log.Printf("Failed to update topic %s: %v", t.Slug, err)
+		writeError(w, http.StatusInternalServerError, "Failed to store topic")
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// handleDeleteTopic serves DELETE /topics/{slug}: the topic leaves the
+// taxonomy and the topics below it move up to its parent. Sources keep
+// their topic.
+func (s *Server) handleDeleteTopic(w http.ResponseWriter, r *http.Request) {
+	t, _, ok := s.lookupTopic(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteTopic(r.Context(), t.Slug); err != nil {
+		log.Printf("Failed to delete topic %s: %v", t.Slug, err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete topic")
+		return
+	}
+	log.Printf("Deleted topic %s", t.Slug)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupTopic reads the topic of the request, by slug or alias, along with
+// the whole taxonomy, answering 404 when there is no such topic
+func (s *Server) lookupTopic(w http.ResponseWriter, r *http.Request) (*database.Topic, []database.Topic, bool) {
+	topics, err := s.db.ListTopics(r.Context())
+	if err != nil {
+		log.Printf("Failed to list topics: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, nil, false
+	}
+	name := slug.Make(r.PathValue("slug"))
+	for _, t := range topics {
+		if t.Slug == name {
+			return &t, topics, true
+		}
+	}
+	for _, t := range topics {
+		if slices.Contains(t.Aliases, name) {
+			return &t, topics, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "Topic not found")
+	return nil, nil, false
+}
+
+// topicAliases normalizes aliases to slugs, dropping repeats
+func topicAliases(aliases []string) []string {
+	var normalized []string
+	for _, alias := range aliases {
+		if alias = slug.Make(alias); !slices.Contains(normalized, alias) {
+			normalized = append(normalized, alias)
+		}
+	}
+	return normalized
+}
+
+// validateTopic checks a topic against the rest of the taxonomy: its
+// parent must exist and not be below it, and its aliases must not name
+// another topic
+func validateTopic(topics []database.Topic, t database.Topic) fieldErrors {
+	var errs fieldErrors
+	if t.Parent != "" {
+		known := slices.ContainsFunc(topics, func(other database.Topic) bool { return other.Slug == t.Parent })
+		switch {
+		case t.Parent == t.Slug || slices.Contains(database.TopicAncestors(topics, t.Parent), t.Slug):
+			errs.add("parent", CodeConflict, fmt.Sprintf("topic %s can't be below itself", t.Slug))
+		case !known:
+			errs.add("parent", CodeInvalid, fmt.Sprintf("unknown parent topic %s", t.Parent))
+		}
+	}
+	for i, alias := range t.Aliases {
+		field := fmt.Sprintf("aliases[%d]", i)
+		if alias == "" {
+			errs.add(field, CodeInvalid, "aliases must have letters or digits")
+			continue
+		}
+		if alias == t.Slug {
+			errs.add(field, CodeConflict, fmt.Sprintf("%s is the topic's own slug", alias))
+			continue
+		}
+		for _, other := range topics {
+			if other.Slug != t.Slug && (other.Slug == alias || slices.Contains(other.Aliases, alias)) {
+				errs.add(field, CodeConflict, fmt.Sprintf("%s already names topic %s", alias, other.Slug))
+			}
+		}
+	}
+	return errs
+}
+
+// scopeTopic widens the topic filter of a search to the topics below it in
+// the taxonomy and the aliases of all of them, so a search in physics
+// finds sources on quantum mechanics too. If the taxonomy can't be read,
+// the filter is left as it is.
+func (s *Server) scopeTopic(ctx context.Context, opts *vectordb.SearchOptions) {
+	if opts.Topic == "" {
+		return
+	}
+	topics, err := s.db.ListTopics(ctx)
+	if err != nil {
+		log.Printf("Failed to read the topic taxonomy: %v", err)
+		return
+	}
+	scope := database.TopicScope(topics, opts.Topic)
+	opts.Topic, opts.Subtopics = scope[0], scope[1:]
+}
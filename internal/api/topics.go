@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// handleReassignTopics serves POST /admin/topics/reassign: it moves the
+// sources of renamed topics to their new names and divides those of split
+// topics among the new ones, in SQLite (with the FTS index and the event
+// log) and in the Qdrant payloads
+func (s *Server) handleReassignTopics(w http.ResponseWriter, r *http.Request) {
+	var req ReassignTopicsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	// New topics are slugs; old ones are taken as stored, so topics from
+	// before slugs were normalized can be renamed to theirs
+	for from, to := range req.Renames {
+		req.Renames[from] = slug.Make(to)
+	}
+	for _, split := range req.Splits {
+		for j := range split.Into {
+			split.Into[j].Topic = slug.Make(split.Into[j].Topic)
+		}
+	}
+	if errs := validateReassignment(req); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	// Bulk work, so searches and writes go first
+	ctx := embedding.WithPriority(r.Context(), embedding.PriorityBackground)
+	var moves []TopicReassignment
+	for _, from := range slices.Sorted(maps.Keys(req.Renames)) {
+		to := req.Renames[from]
+		ids, err := s.db.SourceIDsByTopic(ctx, from)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		for _, id := range ids {
+			moves = append(moves, TopicReassignment{SourceID: id, From: from, To: to})
+		}
+	}
+	for _, split := range req.Splits {
+		splitMoves, err := s.splitTopic(ctx, split)
+		if err != nil {
+			log.Printf("Failed to split topic %s: %v", split.From, err)
+			writeError(w, http.StatusInternalServerError, "Failed to split topic "+split.From)
+			return
+		}
+		moves = append(moves, splitMoves...)
+	}
+
+	resp := ReassignTopicsResponse{Reassigned: len(moves), DryRun: req.DryRun, Results: moves}
+	if resp.Results == nil {
+		resp.Results = []TopicReassignment{}
+	}
+	if req.DryRun || len(moves) == 0 {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	srcs := make([]database.Source, 0, len(moves))
+	for _, m := range moves {
+		src, err := s.db.GetSource(ctx, m.SourceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if src == nil {
+			continue // Deleted meanwhile
+		}
+		src.Topic = m.To
+		srcs = append(srcs, *src)
+	}
+	if err := s.db.InsertSources(ctx, srcs); err != nil {
+		log.Printf("Failed to reassign topics: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to store sources")
+		return
+	}
+
+	// Points of renamed topics are matched by their payload, the points of
+	// split topics by ID. Failures don't fail the request: SQLite has the
+	// data, and reconcile or rebuild repair the payloads.
+	ctx = vectordb.WithWait(ctx)
+	for from, to := range req.Renames {
+		if err := s.vectorDB.SetPayloadWhere(ctx, vectordb.SourcesCollection, vectordb.Filter{"topic": from}, map[string]any{"topic": to}); err != nil {
+			log.Printf("Failed to rename topic %s in Qdrant: %v", from, err)
+		}
+		if s.vectorDB.ClaimsEnabled() {
+			if err := s.vectorDB.SetPayloadWhere(ctx, vectordb.ClaimsCollection, vectordb.Filter{"topic": from}, map[string]any{"topic": to}); err != nil {
+				log.Printf("Failed to rename topic %s of claims in Qdrant: %v", from, err)
+			}
+		}
+	}
+	byTopic := make(map[string][]string)
+	for _, m := range moves {
+		if _, renamed := req.Renames[m.From]; !renamed {
+			byTopic[m.To] = append(byTopic[m.To], m.SourceID)
+		}
+	}
+	for to, ids := range byTopic {
+		if err := s.vectorDB.SetPayload(ctx, vectordb.SourcesCollection, ids, map[string]any{"topic": to}); err != nil {
+			log.Printf("Failed to move %d sources to topic %s in Qdrant: %v", len(ids), to, err)
+		}
+		if s.vectorDB.ClaimsEnabled() {
+			for _, id := range ids {
+				if err := s.vectorDB.SetPayloadWhere(ctx, vectordb.ClaimsCollection, vectordb.Filter{"source_id": id}, map[string]any{"topic": to}); err != nil {
+					log.Printf("Failed to move claims of source %s to topic %s in Qdrant: %v", id, to, err)
+				}
+			}
+		}
+	}
+
+	log.Printf("Reassigned %d sources to new topics", len(srcs))
+	resp.Reassigned = len(srcs)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateReassignment checks that every topic is reassigned at most once,
+// and never to itself or to a topic that is reassigned too (the result of a
+// chain would depend on the order of the updates). Renames are reported
+// under renames.<old topic>.
+func validateReassignment(req ReassignTopicsRequest) fieldErrors {
+	var errs fieldErrors
+	if len(req.Renames) == 0 && len(req.Splits) == 0 {
+		errs.add("renames", CodeRequired, "renames or splits are required")
+		return errs
+	}
+	froms := slices.Sorted(maps.Keys(req.Renames))
+	seen := make(map[string]bool)
+	for _, from := range froms {
+		to := req.Renames[from]
+		if from == "" {
+			errs.add("renames", CodeRequired, "renamed topics must not be empty")
+		} else if to == "" {
+			errs.add("renames."+from, CodeRequired, "renamed topics must not be empty")
+		} else if from == to {
+			errs.add("renames."+from, CodeConflict, fmt.Sprintf("topic %s is renamed to itself", from))
+		}
+		seen[from] = true
+	}
+	for i, split := range req.Splits {
+		field := fmt.Sprintf("splits[%d]", i)
+		if split.From == "" {
+			errs.add(field+".from", CodeRequired, "splits need a from topic")
+		} else if seen[split.From] {
+			errs.add(field+".from", CodeConflict, fmt.Sprintf("topic %s is reassigned more than once", split.From))
+		}
+		if len(split.Into) == 0 {
+			errs.add(field+".into", CodeRequired, "splits need at least one topic to split into")
+		}
+		seen[split.From] = true
+		targets := make(map[string]bool)
+		for j, target := range split.Into {
+			if target.Topic == "" || target.Topic == split.From || targets[target.Topic] {
+				errs.add(fmt.Sprintf("%s.into[%d].topic", field, j), CodeInvalid,
+					fmt.Sprintf("split of %s: topics must be non-empty, distinct and differ from %s", split.From, split.From))
+			}
+			targets[target.Topic] = true
+		}
+	}
+	for _, from := range froms {
+		if to := req.Renames[from]; to != "" && to != from && seen[to] {
+			errs.add("renames."+from, CodeConflict, fmt.Sprintf("topic %s is both reassigned and a new topic", to))
+		}
+	}
+	for i, split := range req.Splits {
+		for j, target := range split.Into {
+			if target.Topic != "" && seen[target.Topic] {
+				errs.add(fmt.Sprintf("splits[%d].into[%d].topic", i, j), CodeConflict,
+					fmt.Sprintf("topic %s is both reassigned and a new topic", target.Topic))
+			}
+		}
+	}
+	return errs
+}
+
+// splitTopic decides the new topic of every source of a split topic
+func (s *Server) splitTopic(ctx context.Context, split TopicSplit) ([]TopicReassignment, error) {
+	ids, err := s.db.SourceIDsByTopic(ctx, split.From)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var targets [][]float32
+	embedder := s.embedders.Default()
+	vector := ""
+	if split.Classify {
+		texts := make([]string, len(split.Into))
+		for i, target := range split.Into {
+			texts[i] = target.Description
+			if texts[i] == "" {
+				texts[i] = strings.NewReplacer("-", " ", "_", " ").Replace(target.Topic)
+			}
+		}
+		if targets, err = embedder.EmbedBatch(ctx, texts); err != nil {
+			return nil, fmt.Errorf("failed to embed topics: %w", err)
+		}
+		if len(s.vectorDB.VectorSpaces()) > 0 {
+			vector = embedder.Model()
+		}
+	}
+
+	moves := make([]TopicReassignment, len(ids))
+	for i, id := range ids {
+		moves[i] = TopicReassignment{SourceID: id, From: split.From, To: split.Into[0].Topic}
+		if !split.Classify {
+			continue
+		}
+		emb, err := s.vectorDB.GetSourceVector(ctx, id, vector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load source vector: %w", err)
+		}
+		if emb == nil {
+			continue // Not embedded: the first target
+		}
+		best := -1
+		for j, target := range targets {
+			if sim := embedding.CosineSimilarity(emb, target); best < 0 || sim > moves[i].Similarity {
+				best, moves[i].Similarity = j, sim
+			}
+		}
+		moves[i].To = split.Into[best].Topic
+		moves[i].Classified = true
+	}
+	return moves, nil
+}
@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/testsupport"
+)
+
+// TestHealthCountsSkipTrash checks that GET /health stops counting a source
+// when it is deleted and counts it again when it is restored
+func TestHealthCountsSkipTrash(t *testing.T) {
+	for _, sqlite := range []bool{false, true} {
+		srv := testsupport.NewServer(t, testsupport.Options{SQLite: sqlite})
+		sourceCount := func(step string, want int) {
+			t.Helper()
+			var health api.HealthResponse
+			if status := srv.Do(t, http.MethodGet, "/health", nil, &health); status != http.StatusOK {
+				t.Fatalf("sqlite=%v: GET /health after %s: status %d", sqlite, step, status)
+			}
+			if health.SourceCount != want {
+				t.Errorf("sqlite=%v: GET /health after %s: source_count %d, want %d", sqlite, step, health.SourceCount, want)
+			}
+		}
+
+		var created api.CreateSourceResponse
+		status := srv.Do(t, http.MethodPost, "/sources", api.SourceRequest{
+			URL:     "https://example.com/trash",
+			Title:   "Trash",
+			Topic:   "trash",
+			Summary: "A source that is deleted and restored.",
+		}, &created)
+		if status != http.StatusCreated {
+			t.Fatalf("sqlite=%v: POST /sources: status %d", sqlite, status)
+		}
+		sourceCount("create", 1)
+
+		if status := srv.Do(t, http.MethodDelete, "/sources/"+created.ID, nil, nil); status != http.StatusNoContent {
+			t.Fatalf("sqlite=%v: DELETE /sources/%s: status %d", sqlite, created.ID, status)
+		}
+		sourceCount("delete", 0)
+
+		if status := srv.Do(t, http.MethodPost, "/sources/"+created.ID+"/restore", nil, nil); status != http.StatusOK {
+			t.Fatalf("sqlite=%v: POST /sources/%s/restore: status %d", sqlite, created.ID, status)
+		}
+		sourceCount("restore", 1)
+	}
+}
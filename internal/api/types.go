@@ -0,0 +1,858 @@
+package api
+
+import (
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/guard"
+	"github.com/gitopedia/knowledge-base/internal/ollama"
+	"github.com/gitopedia/knowledge-base/internal/redact"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+	"github.com/gitopedia/knowledge-base/internal/watch"
+)
+
+// CreateSourceResponse is the response for source creation
+type CreateSourceResponse struct {
+	ID       string           `json:"id"`
+	Findings []redact.Finding `json:"findings,omitempty"` // Sensitive data flagged or redacted in the summary or content
+}
+
+// CreateSourcesResponse is the response for POST /sources/batch, with the
+// created sources in request order
+type CreateSourcesResponse struct {
+	Count   int                    `json:"count"`
+	Sources []CreateSourceResponse `json:"sources"`
+}
+
+// ImportResponse is the response for POST /import
+type ImportResponse struct {
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Embedded int            `json:"embedded"` // Records embedded
+	Reused   int            `json:"reused"`   // Records whose stored point was kept
+	Results  []ImportResult `json:"results"`
+	// Error is set when the body became unreadable; records before it are
+	// imported and listed
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResult is the outcome of a record of POST /import
+type ImportResult struct {
+	Record int    `json:"record"` // Position in the dump, from 1
+	Type   string `json:"type,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"` // imported or failed
+	Error  string `json:"error,omitempty"`
+	// Errors lists the failing fields of an invalid source or article
+	Errors   []FieldError `json:"errors,omitempty"`
+	Embedded bool         `json:"embedded,omitempty"`
+	Reused   bool         `json:"reused,omitempty"`
+}
+
+// UpdateSourceRequest is the request body for PATCH /sources/{id}; omitted
+// fields are left unchanged
+type UpdateSourceRequest struct {
+	Title   *string   `json:"title,omitempty"`
+	Topic   *string   `json:"topic,omitempty"`
+	Tags    *[]string `json:"tags,omitempty"`
+	Summary *string   `json:"summary,omitempty"`
+	// Summary style profile of a new summary; "" clears it. A new summary
+	// without one clears the profile of the old summary.
+	SummaryStyle *string `json:"summary_style,omitempty"`
+}
+
+// UpdateSourceResponse is the updated source
+type UpdateSourceResponse struct {
+	database.Source
+	Reembedded bool             `json:"reembedded"`         // Whether the embedding was regenerated
+	Findings   []redact.Finding `json:"findings,omitempty"` // Sensitive data flagged or redacted in the summary
+}
+
+// SourceRequest is the request body for creating/updating a source
+type SourceRequest struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	Topic     string `json:"topic"`
+	Summary   string `json:"summary"`
+	Language  string `json:"language,omitempty"`
+	Model     string `json:"model,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// When the source was published: an RFC 3339 time or a date (2006-01-02,
+	// 2006-01 or 2006). Papers get the date of their publication metadata.
+	PublishedAt string   `json:"published_at,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Visibility is public (default), internal, or private to the caller
+	Visibility string `json:"visibility,omitempty"`
+	Type       string `json:"type,omitempty"` // paper, blog, video, or dataset
+	// Entities mentioned by the source, indexed for GET /entities
+	People []string `json:"people,omitempty"`
+	Orgs   []string `json:"orgs,omitempty"`
+	Places []string `json:"places,omitempty"`
+	// Locations of the places; without them, places are geocoded when
+	// KB_GEOCODE is on
+	Locations []database.Location `json:"locations,omitempty"`
+	// Name of the summary style profile the summary was written with (see
+	// GET /summary-styles); the profile is stored with the source
+	SummaryStyle string `json:"summary_style,omitempty"`
+	// Full text of the page, indexed for keyword search (at most
+	// maxContentBytes)
+	Content string `json:"content,omitempty"`
+}
+
+// SearchRequest is the request body for vector search
+type SearchRequest struct {
+	Query     string `json:"query,omitempty"`     // Text to embed and search
+	Embedding string `json:"embedding,omitempty"` // Base64-encoded embedding (alternative to query)
+	Limit     int    `json:"limit,omitempty"`
+	Topic     string `json:"topic,omitempty"`    // Optional topic filter
+	Category  string `json:"category,omitempty"` // Optional article category filter (semantic article search)
+	Domain    string `json:"domain,omitempty"`   // Optional URL domain filter (e.g. arxiv.org)
+	Type      string `json:"type,omitempty"`     // Optional source type filter (paper, blog, video, dataset)
+	Model     string `json:"model,omitempty"`    // Embedding model (vector space) to query; defaults to the first configured
+	Language  string `json:"language,omitempty"` // Language of the query; without model, selects the model routed to it
+	Hybrid    bool   `json:"hybrid,omitempty"`   // Fuse vector and BM25 keyword matches (requires QDRANT_SPARSE)
+	Semantic  bool   `json:"semantic,omitempty"` // Articles: vector search (by chunk with QDRANT_ARTICLE_CHUNKS) instead of FTS
+	// LateInteraction rescores the vector matches by the token vectors of
+	// the query (requires QDRANT_MULTIVECTOR; experimental)
+	LateInteraction bool `json:"late_interaction,omitempty"`
+	// Rerank reorders the best vector matches with the reranking model
+	// (requires RERANK_PROVIDER); scores become the reranker's
+	Rerank bool `json:"rerank,omitempty"`
+	// QueryToken is the query_token of an earlier search: its query
+	// embedding is reused instead of embedding the same query again
+	QueryToken      string `json:"query_token,omitempty"`
+	ReturnEmbedding bool   `json:"return_embedding,omitempty"` // Echo the query embedding as query_embedding
+
+	HNSWEf int  `json:"hnsw_ef,omitempty"` // HNSW candidate list size: higher trades latency for recall
+	Exact  bool `json:"exact,omitempty"`   // Score every point instead of using the index (ground truth)
+
+	HasCode    bool `json:"has_code,omitempty"`    // Only sources linking to a code repository
+	HasDataset bool `json:"has_dataset,omitempty"` // Only sources linking to a dataset
+
+	Tags      []string `json:"tags,omitempty"`       // Only sources with any of these tags; also ?tags=a,b
+	TagsMatch string   `json:"tags_match,omitempty"` // any (default) or all of tags
+	// SourceLanguage keeps sources in a language (e.g. de); language selects
+	// the vector space of the query instead
+	SourceLanguage string `json:"source_language,omitempty"`
+	// Only sources created at or after, and before, an RFC 3339 time or a
+	// date (2006-01-02, from its start in UTC)
+	CreatedAfter  string `json:"created_after,omitempty"`
+	CreatedBefore string `json:"created_before,omitempty"`
+	// Only sources published in a range, bounded like created_after and
+	// created_before; undated sources never match
+	PublishedAfter  string `json:"published_after,omitempty"`
+	PublishedBefore string `json:"published_before,omitempty"`
+	// Only sources with a location within radius_km (default 50) of near
+	// ("lat,lon") or of near_place, geocoded (requires KB_GEOCODE)
+	Near      string  `json:"near,omitempty"`
+	NearPlace string  `json:"near_place,omitempty"`
+	RadiusKm  float64 `json:"radius_km,omitempty"`
+
+	Personalize   bool    `json:"personalize,omitempty"`    // Blend in the caller's interest profile (X-User-ID)
+	ProfileWeight float32 `json:"profile_weight,omitempty"` // Share of the profile in the blend (default 0.3)
+
+	Fields          []string `json:"fields,omitempty"`            // Result fields to return (default all); also ?fields=id,title
+	SummaryMaxChars int      `json:"summary_max_chars,omitempty"` // Truncate summaries at a sentence boundary
+	Facets          []string `json:"facets,omitempty"`            // Fields to count matches by (type); also ?facets=type
+	Autocorrect     bool     `json:"autocorrect,omitempty"`       // Retry keyword searches without matches with did_you_mean
+	Duplicates      bool     `json:"duplicates,omitempty"`        // Keep duplicates as separate results instead of alternates
+}
+
+// SynonymsRequest sets the synonyms of a term
+type SynonymsRequest struct {
+	Expansions []string `json:"expansions"` // Terms or phrases also searched for the term
+}
+
+// InteractionRequest records a user's interaction with a source
+type InteractionRequest struct {
+	SourceID string `json:"source_id"`
+	Action   string `json:"action"` // click or save
+}
+
+// ProfileResponse describes a user's interest profiles
+type ProfileResponse struct {
+	UserID   string             `json:"user_id"`
+	Profiles []database.Profile `json:"profiles"`
+}
+
+// EnqueueRequest is the request body for adding sources to a reading queue
+type EnqueueRequest struct {
+	SourceIDs []string `json:"source_ids"`
+	Note      string   `json:"note,omitempty"`
+}
+
+// EnqueueSearchRequest is a source search whose results are added to a
+// reading queue
+type EnqueueSearchRequest struct {
+	SearchRequest
+	Note string `json:"note,omitempty"`
+}
+
+// EnqueueResponse reports the sources added to a reading queue
+type EnqueueResponse struct {
+	Added  int      `json:"added"`  // Sources that weren't queued yet
+	Queued []string `json:"queued"` // The sources given, now all queued
+}
+
+// QueueUpdateRequest moves a queued source to another status and/or
+// replaces its note
+type QueueUpdateRequest struct {
+	Status *string `json:"status,omitempty"` // to-read, reading or done
+	Note   *string `json:"note,omitempty"`
+}
+
+// QueueEntry is an item of a reading queue with its source
+type QueueEntry struct {
+	database.QueueItem
+	Title          string `json:"title"`
+	URL            string `json:"url"`
+	Topic          string `json:"topic"`
+	ReadingMinutes int    `json:"reading_minutes,omitempty"`
+}
+
+// QueueResponse lists a user's reading queue
+type QueueResponse struct {
+	Items []QueueEntry `json:"items"`
+	Count int          `json:"count"`
+}
+
+// QueueStats describes the throughput of a user's reading queue over the
+// last Days days
+type QueueStats struct {
+	Counts   map[string]int `json:"counts"` // Items by status, whenever queued
+	Days     int            `json:"days"`
+	Added    int            `json:"added"`
+	Started  int            `json:"started"`
+	Finished int            `json:"finished"`
+	// FinishedPerWeek is the rate of Finished over the window
+	FinishedPerWeek float64 `json:"finished_per_week"`
+	// Median days from queueing to done, and from starting to done, of the
+	// items finished in the window; absent without any
+	MedianDaysToFinish *float64 `json:"median_days_to_finish,omitempty"`
+	MedianDaysReading  *float64 `json:"median_days_reading,omitempty"`
+	// OldestToRead is when the longest waiting to-read item was queued
+	OldestToRead string `json:"oldest_to_read,omitempty"`
+}
+
+// CreateWatchRequest is the request body of POST /watches
+type CreateWatchRequest struct {
+	Text      string   `json:"text"`                // What the user cares about, a sentence or a paragraph
+	Name      string   `json:"name,omitempty"`      // Defaults to the start of text
+	Threshold *float64 `json:"threshold,omitempty"` // Minimum cosine similarity, 0.75 by default
+	Topic     string   `json:"topic,omitempty"`     // Only compare sources of this topic
+	URL       string   `json:"url,omitempty"`       // POSTed each match, signed
+	Model     string   `json:"model,omitempty"`     // Embedding model; the default model if empty
+}
+
+// WatchResponse is a new watch, with the secret signing its deliveries when
+// it has a URL; the secret is only ever shown in this response
+type WatchResponse struct {
+	database.Watch
+	Secret string `json:"secret,omitempty"`
+}
+
+// WatchesResponse lists the watches of a user
+type WatchesResponse struct {
+	Watches []database.Watch `json:"watches"`
+	Count   int              `json:"count"`
+}
+
+// WatchMatchesResponse is a page of the matches of a watch
+type WatchMatchesResponse struct {
+	Matches    []watch.Notification `json:"matches"`
+	Count      int                  `json:"count"`
+	NextCursor int64                `json:"next_cursor"` // Pass as since= to continue
+}
+
+// RecommendRequest is the request body for source recommendations
+type RecommendRequest struct {
+	Positive []string `json:"positive"`           // Source IDs to find more of
+	Negative []string `json:"negative,omitempty"` // Source IDs to steer away from
+	Strategy string   `json:"strategy,omitempty"` // average_vector (default), best_score, or sum_scores
+	Limit    int      `json:"limit,omitempty"`
+	Topic    string   `json:"topic,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Model    string   `json:"model,omitempty"` // Embedding model (vector space) to compare in
+	Fields   []string `json:"fields,omitempty"`
+	Facets   []string `json:"facets,omitempty"`
+
+	SummaryMaxChars int  `json:"summary_max_chars,omitempty"`
+	Duplicates      bool `json:"duplicates,omitempty"` // Keep duplicates as separate results instead of alternates
+}
+
+// SearchResponse is the response for search endpoints
+type SearchResponse struct {
+	Results        []SearchResult `json:"results"`
+	Count          int            `json:"count"`
+	EmbeddingModel string         `json:"embedding_model,omitempty"`
+	Hybrid         bool           `json:"hybrid,omitempty"` // Scores are fused ranks rather than similarities
+	// Scores tells how to read the result scores: metric, order and bounds
+	Scores       *vectordb.ScoreSemantics `json:"scores,omitempty"`
+	Personalized bool                     `json:"personalized,omitempty"`
+	// RerankModel is the model that reordered the results; absent when
+	// they weren't reranked, including when the reranker failed
+	RerankModel string `json:"rerank_model,omitempty"`
+	Facets      Facets `json:"facets,omitempty"` // Match counts per value of the requested facets
+	// DidYouMean is a spelling correction of a query without keyword matches
+	DidYouMean string `json:"did_you_mean,omitempty"`
+	Corrected  bool   `json:"corrected,omitempty"` // Results are for did_you_mean (autocorrect)
+	// Degraded is set when Qdrant was unreachable and the results are
+	// keyword matches instead (without facets)
+	Degraded bool `json:"degraded,omitempty"`
+	// QueryToken lets later searches of the same query reuse its embedding
+	// (query_token), for 10 minutes after its last use; QueryEmbedding is
+	// the embedding itself, base64-encoded, with return_embedding
+	QueryToken     string `json:"query_token,omitempty"`
+	QueryEmbedding string `json:"query_embedding,omitempty"`
+}
+
+// FederatedSearchRequest is the request body for POST /search
+type FederatedSearchRequest struct {
+	Query    string   `json:"query"`
+	Kinds    []string `json:"kinds,omitempty"` // source and/or article; both by default
+	Limit    int      `json:"limit,omitempty"`
+	Topic    string   `json:"topic,omitempty"`    // Sources of the topic, and its articles
+	Model    string   `json:"model,omitempty"`    // Embedding model (vector space) to query
+	Language string   `json:"language,omitempty"` // Language of the query; without model, selects the model routed to it
+	// Keyword also matches the query against the FTS indexes, fusing the
+	// keyword and vector rankings
+	Keyword bool     `json:"keyword,omitempty"`
+	Fields  []string `json:"fields,omitempty"`
+
+	QueryToken      string `json:"query_token,omitempty"`      // Reuse the query embedding of an earlier search
+	ReturnEmbedding bool   `json:"return_embedding,omitempty"` // Echo the query embedding as query_embedding
+
+	SummaryMaxChars int `json:"summary_max_chars,omitempty"`
+}
+
+// Kinds of federated search results
+const (
+	KindSource  = "source"
+	KindArticle = "article"
+)
+
+// SearchResult represents a single search result
+type SearchResult struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind,omitempty"` // source or article, in federated results
+	URL       string   `json:"url,omitempty"`
+	Title     string   `json:"title"`
+	Topic     string   `json:"topic,omitempty"`
+	Summary   string   `json:"summary"`
+	Score     float32  `json:"score,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Language  string   `json:"language,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	CreatedAt string   `json:"created_at,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	Linked    bool     `json:"linked,omitempty"` // Curated for the searched topic's article (score boosted)
+
+	// Alternates are lower-ranked results with the same canonical URL or a
+	// near-identical summary, collapsed into this one
+	Alternates []Alternate `json:"alternates,omitempty"`
+
+	// Chunks are the passages of an article that matched a semantic
+	// search, best first
+	Chunks []vectordb.ChunkMatch `json:"chunks,omitempty"`
+
+	// Snippet is an excerpt around the match of a keyword article search,
+	// with the query terms marked <mark>like this</mark>
+	Snippet string `json:"snippet,omitempty"`
+
+	WordCount      int `json:"word_count,omitempty"`      // Words in the full summary (sources) or content (articles)
+	ReadingMinutes int `json:"reading_minutes,omitempty"` // Estimated at 200 words per minute
+}
+
+// Alternate is a duplicate of a search result
+type Alternate struct {
+	ID    string  `json:"id"`
+	URL   string  `json:"url,omitempty"`
+	Title string  `json:"title,omitempty"`
+	Score float32 `json:"score,omitempty"`
+}
+
+// ScrollResponse is a page of GET /admin/vectors/{collection}/points
+type ScrollResponse struct {
+	Points     []vectordb.Point `json:"points"`
+	NextOffset string           `json:"next_offset,omitempty"` // Offset of the next page; omitted after the last
+}
+
+// CountResponse is the response of GET /admin/vectors/{collection}/count
+type CountResponse struct {
+	Collection string `json:"collection"`
+	Count      uint64 `json:"count"`
+}
+
+// StatsResponse is the response of GET /admin/stats
+type StatsResponse struct {
+	SourceCount  int `json:"source_count"`
+	ArticleCount int `json:"article_count"`
+	// VectorCounts is the number of points of each Qdrant collection, to
+	// compare with the counts above; omitted when Qdrant can't be reached
+	VectorCounts map[string]uint64 `json:"vector_counts,omitempty"`
+}
+
+// CreateAPIKeyRequest is the request body of POST /admin/api-keys
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`   // What the key is for, e.g. the scraper's name
+	Topics []string `json:"topics"` // Topics the key may create sources in
+}
+
+// RotateAPIKeyRequest is the request body of POST
+// /admin/api-keys/{id}/rotate
+type RotateAPIKeyRequest struct {
+	// How long the old secret keeps working; zero revokes it at once
+	GraceSeconds int `json:"grace_seconds,omitempty"`
+}
+
+// APIKeyResponse is a new or rotated API key with its secret, which is only
+// ever shown in this response
+type APIKeyResponse struct {
+	database.APIKey
+	Secret string `json:"secret"`
+}
+
+// APIKeysResponse is the response of GET /admin/api-keys
+type APIKeysResponse struct {
+	Keys []database.APIKey `json:"keys"`
+}
+
+// CreateWebhookRequest is the request body of POST /webhooks
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"` // Event types to receive; empty means all
+}
+
+// WebhookResponse is a new webhook with its signing secret, which is only
+// ever shown in this response
+type WebhookResponse struct {
+	database.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhooksResponse is the response of GET /webhooks
+type WebhooksResponse struct {
+	Webhooks   []database.Webhook `json:"webhooks"`
+	EventTypes []string           `json:"event_types"` // The event types webhooks may subscribe to
+}
+
+// PingWebhookResponse is the outcome of POST /webhooks/{id}/ping
+type PingWebhookResponse struct {
+	Delivered bool   `json:"delivered"`
+	Status    int    `json:"status,omitempty"` // HTTP status of the endpoint's response
+	Error     string `json:"error,omitempty"`
+}
+
+// ModelsResponse is the response of GET /admin/models
+type ModelsResponse struct {
+	Models []ollama.Model `json:"models"`
+}
+
+// ModelStatus is whether a model the server calls on Ollama is pulled
+type ModelStatus struct {
+	Model  string `json:"model"`
+	Role   string `json:"role"` // embedding, generation or rerank
+	Pulled bool   `json:"pulled"`
+	Size   int64  `json:"size,omitempty"` // Bytes on disk, when pulled
+}
+
+// ModelStatusResponse is the response of GET /admin/models/status
+type ModelStatusResponse struct {
+	Ready  bool          `json:"ready"` // Every model is pulled
+	Models []ModelStatus `json:"models"`
+}
+
+// PullModelRequest is the request body of POST /admin/models/pull
+type PullModelRequest struct {
+	// Model to pull; empty pulls every configured model that isn't pulled
+	Model string `json:"model,omitempty"`
+}
+
+// PullProgress is a line of the POST /admin/models/pull stream: a status
+// update of Ollama, or the error that ended the pull
+type PullProgress struct {
+	Model string `json:"model"`
+	ollama.Progress
+	Error string `json:"error,omitempty"`
+}
+
+// HealthResponse is the response for the health endpoint
+type HealthResponse struct {
+	Status       string `json:"status"`
+	SourceCount  int    `json:"source_count"`
+	ArticleCount int    `json:"article_count"`
+	Version      string `json:"version"`
+	// CountsUpdatedAt is when each cached count last changed, by table
+	CountsUpdatedAt map[string]string `json:"counts_updated_at,omitempty"`
+	// EmbeddingText is the text strategy each collection was embedded with
+	EmbeddingText map[string]string `json:"embedding_text,omitempty"`
+	// QdrantWrites counts the upserts and deletes sent to Qdrant since the
+	// server started, with their retries and failures
+	QdrantWrites vectordb.WriteStats `json:"qdrant_writes"`
+	// EmbeddingQueue is the embedding requests in flight and waiting, by
+	// priority; omitted when they aren't scheduled
+	EmbeddingQueue map[string]embedding.QueueStats `json:"embedding_queue,omitempty"`
+	// EmbeddingRequests counts the embedding requests since the server
+	// started, with their retries and failures and the circuit breaker's
+	// state; omitted when they aren't retried
+	EmbeddingRequests *embedding.RequestStats `json:"embedding_requests,omitempty"`
+	// Protection is the last check of the store guard; while it protects
+	// the stores, Status is degraded and source writes are queued.
+	// Omitted when there is no guard.
+	Protection   *guard.Status `json:"protection,omitempty"`
+	QueuedWrites int           `json:"queued_writes,omitempty"` // Writes in the outbox
+}
+
+// RevisionsResponse is the response for GET /revisions/sources/{id} and
+// GET /revisions/articles/{id}
+type RevisionsResponse struct {
+	ID        string              `json:"id"`
+	Current   int                 `json:"current"`   // Number of the current version
+	Revisions []database.Revision `json:"revisions"` // Previous versions, newest first
+}
+
+// RevisionDiffResponse is the response for GET /revisions/sources/{id}/diff
+// and GET /revisions/articles/{id}/diff
+type RevisionDiffResponse struct {
+	ID      string        `json:"id"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"` // By field name
+}
+
+// FieldChange is a field that differs between two versions. Article
+// content is compared as a unified diff instead of its two values.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from,omitempty"`
+	To    any    `json:"to,omitempty"`
+	Diff  string `json:"diff,omitempty"`
+}
+
+// QueuedWriteResponse is the 202 response to a source write queued while
+// the stores are protected
+type QueuedWriteResponse struct {
+	ID       int64  `json:"id"`
+	QueuedAt string `json:"queued_at"`
+	Message  string `json:"message"`
+}
+
+// ReadyResponse is the response for GET /ready
+type ReadyResponse struct {
+	Status string `json:"status"` // ready, or unavailable when a check failed
+	// Checks has the outcome of each dependency: sqlite, qdrant,
+	// embedding:<model> for every embedding model, and generation:<model>
+	// when a generation model is configured
+	Checks map[string]DependencyCheck `json:"checks"`
+}
+
+// DependencyCheck is the outcome of checking one dependency
+type DependencyCheck struct {
+	Status    string  `json:"status"` // ok or error
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// EventsResponse is a page of the event log
+type EventsResponse struct {
+	Events     []database.Event `json:"events"`
+	Count      int              `json:"count"`
+	NextCursor int64            `json:"next_cursor"` // Pass as since= to continue
+}
+
+// ReassignTopicsRequest is the request body of POST /admin/topics/reassign,
+// moving sources to new topics after Compendium articles are renamed or split
+type ReassignTopicsRequest struct {
+	Renames map[string]string `json:"renames,omitempty"` // Old topic → new topic
+	Splits  []TopicSplit      `json:"splits,omitempty"`
+	DryRun  bool              `json:"dry_run,omitempty"` // Report the reassignments without making them
+}
+
+// TopicSplit divides the sources of a topic among several new topics
+type TopicSplit struct {
+	From string        `json:"from"`
+	Into []TopicTarget `json:"into"`
+	// Classify assigns each source to the target its embedding is most
+	// similar to; otherwise, and for sources without an embedding, sources
+	// go to the first target
+	Classify bool `json:"classify,omitempty"`
+}
+
+// TopicTarget is a topic a split sends sources to
+type TopicTarget struct {
+	Topic string `json:"topic"`
+	// Description is embedded to classify sources (default: the topic slug
+	// as words)
+	Description string `json:"description,omitempty"`
+}
+
+// ReassignTopicsResponse is the response for POST /admin/topics/reassign
+type ReassignTopicsResponse struct {
+	Reassigned int                 `json:"reassigned"`
+	DryRun     bool                `json:"dry_run,omitempty"`
+	Results    []TopicReassignment `json:"results"`
+}
+
+// TopicReassignment is the move of one source to a new topic
+type TopicReassignment struct {
+	SourceID string `json:"source_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	// Similarity of the source to its new topic, for classified splits
+	Similarity float32 `json:"similarity,omitempty"`
+	Classified bool    `json:"classified,omitempty"`
+}
+
+// TagsResponse is the response for GET /tags
+type TagsResponse struct {
+	Tags  []database.Tag `json:"tags"`
+	Count int            `json:"count"`
+}
+
+// TagArticlesResponse is the response for GET /tags/{tag}/articles
+type TagArticlesResponse struct {
+	Tag      string             `json:"tag"`
+	Articles []database.Article `json:"articles"`
+	Count    int                `json:"count"`
+}
+
+// RenameTagRequest is the request body of POST /admin/tags/rename
+type RenameTagRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`                // Sources having both keep one
+	DryRun bool   `json:"dry_run,omitempty"` // Report the sources without renaming
+}
+
+// RenameTagResponse is the response for POST /admin/tags/rename
+type RenameTagResponse struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Renamed   int      `json:"renamed"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+	SourceIDs []string `json:"source_ids"`
+}
+
+// CreateTopicRequest is the request body for POST /topics
+type CreateTopicRequest struct {
+	Slug        string   `json:"slug,omitempty"` // Default: the slug of the name
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Parent      string   `json:"parent,omitempty"` // Slug of the parent topic
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// UpdateTopicRequest is the request body for PATCH /topics/{slug}; absent
+// fields are left as they are
+type UpdateTopicRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Parent      *string   `json:"parent,omitempty"` // "" makes the topic a root
+	Aliases     *[]string `json:"aliases,omitempty"`
+}
+
+// TopicResponse is a topic of the taxonomy with its place in the hierarchy
+type TopicResponse struct {
+	database.Topic
+	Ancestors []string `json:"ancestors"` // From the parent up to the root
+	Children  []string `json:"children"`
+}
+
+// TopicsResponse is the response for GET /topics
+type TopicsResponse struct {
+	Topics []database.Topic `json:"topics"`
+	Count  int              `json:"count"`
+}
+
+// ErrorResponse is the response for errors. Validation failures also list
+// every failing field in Errors; Error then joins their messages.
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a validation failure of one request field
+type FieldError struct {
+	// Field is the JSON field or query parameter, with the index of list
+	// items (sources[2].url); empty for the request as a whole
+	Field   string `json:"field"`
+	Code    string `json:"code"` // See the Code* constants
+	Message string `json:"message"`
+}
+
+// Codes of field errors
+const (
+	CodeRequired    = "required"     // Missing or empty
+	CodeInvalid     = "invalid"      // Malformed, or not one of the allowed values
+	CodeOutOfRange  = "out_of_range" // A number or time outside its bounds
+	CodeConflict    = "conflict"     // Contradicts another field of the request
+	CodeUnsupported = "unsupported"  // Valid, but not enabled on this server
+	CodeMalformed   = "malformed"    // The body couldn't be decoded
+)
+
+// SummaryStyle is a summary style profile with the prompt it renders
+type SummaryStyle struct {
+	summarystyle.Profile
+	Prompt string `json:"prompt"`
+}
+
+// SummaryStylesResponse is the response for GET /summary-styles
+type SummaryStylesResponse struct {
+	Default  string         `json:"default"`
+	Profiles []SummaryStyle `json:"profiles"`
+	// Profile name of each topic that has its own
+	Topics map[string]string `json:"topics"`
+}
+
+// CompareRequest is the request body for POST /sources/compare
+type CompareRequest struct {
+	IDs []string `json:"ids"` // 2 to 10 sources
+	// Focus narrows the comparison to an aspect or question, e.g. "effect
+	// on sleep quality"
+	Focus string `json:"focus,omitempty"`
+}
+
+// CompareResponse is the response for POST /sources/compare. Every claim
+// cites the IDs of the compared sources it comes from.
+type CompareResponse struct {
+	Sources        []CompareSource `json:"sources"`
+	Agreements     []Agreement     `json:"agreements"`
+	Contradictions []Contradiction `json:"contradictions"`
+	UniqueClaims   []SourceClaim   `json:"unique_claims"` // Claims only one source makes
+	Model          string          `json:"model"`         // Generation model that wrote the comparison
+}
+
+// CompareSource is a compared source
+type CompareSource struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// Agreement is a statement two or more sources support
+type Agreement struct {
+	Statement string   `json:"statement"`
+	Sources   []string `json:"sources"`
+}
+
+// Contradiction is an issue on which sources take incompatible positions
+type Contradiction struct {
+	Issue     string        `json:"issue"`
+	Positions []SourceClaim `json:"positions"`
+}
+
+// SourceClaim is a claim of one source
+type SourceClaim struct {
+	Source string `json:"source"`
+	Claim  string `json:"claim"`
+}
+
+// AskRequest is the request body for POST /ask
+type AskRequest struct {
+	Question string `json:"question"`
+	Limit    int    `json:"limit,omitempty"`    // Sources (and articles) to retrieve, 1 to 20 (default 5)
+	Topic    string `json:"topic,omitempty"`    // Only sources of the topic
+	Model    string `json:"model,omitempty"`    // Embedding model (vector space) to search
+	Language string `json:"language,omitempty"` // Language of the question; without model, selects the model routed to it
+	// SupportThreshold is the similarity from which a chunk of a cited
+	// passage supports a statement of the answer, 0.6 by default
+	SupportThreshold *float64 `json:"support_threshold,omitempty"`
+}
+
+// AskResponse is the response for POST /ask. The answer cites its passages
+// by their number in citations, as [1].
+type AskResponse struct {
+	Answer         string     `json:"answer"` // Empty when nothing was retrieved
+	Citations      []Citation `json:"citations"`
+	Retrieved      int        `json:"retrieved"`       // Passages given to the model
+	Model          string     `json:"model,omitempty"` // Generation model that wrote the answer
+	EmbeddingModel string     `json:"embedding_model"`
+	// Statements are the sentences of the answer that cite passages, checked
+	// against them; Unsupported counts those no cited passage supports.
+	// Verified is false when the check failed and they are left out.
+	Statements  []AskStatement `json:"statements,omitempty"`
+	Unsupported int            `json:"unsupported"`
+	Verified    bool           `json:"verified"`
+}
+
+// AskStatement is a sentence of an answer with the passages it cites
+type AskStatement struct {
+	Text      string            `json:"text"` // Without its citations
+	Citations []CitationSupport `json:"citations"`
+	Supported bool              `json:"supported"` // Some cited passage supports it
+}
+
+// CitationSupport is how well a cited passage supports a statement: the
+// similarity of the statement and the closest chunk of the passage
+type CitationSupport struct {
+	N         int     `json:"n"`
+	Score     float32 `json:"score"`
+	Supported bool    `json:"supported"`          // Score reaches the support threshold
+	Evidence  string  `json:"evidence,omitempty"` // The closest chunk
+}
+
+// Citation is a source or article an answer cites
+type Citation struct {
+	N     int    `json:"n"`    // The number the answer cites it by
+	Kind  string `json:"kind"` // source or article
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"` // Sources only
+}
+
+// ClaimSearchRequest is the request body for POST /claims/search
+type ClaimSearchRequest struct {
+	Query string `json:"query"`
+	Topic string `json:"topic,omitempty"`
+	Model string `json:"model,omitempty"` // Embedding model (vector space) to search
+	Limit int    `json:"limit,omitempty"`
+}
+
+// ClaimSearchResponse is the response for claim searches
+type ClaimSearchResponse struct {
+	Results        []ClaimMatch             `json:"results"`
+	Count          int                      `json:"count"`
+	EmbeddingModel string                   `json:"embedding_model,omitempty"`
+	Scores         *vectordb.ScoreSemantics `json:"scores,omitempty"`
+}
+
+// ClaimMatch is a claim extracted from a source that matched a search
+type ClaimMatch struct {
+	SourceID string  `json:"source_id"`
+	Title    string  `json:"title,omitempty"`
+	URL      string  `json:"url"`
+	Topic    string  `json:"topic,omitempty"`
+	Position int     `json:"position"` // Position of the claim in the source's claim list
+	Claim    string  `json:"claim"`
+	Score    float32 `json:"score"`
+}
+
+// ClaimStanceRequest is the request body for POST /claims/stance
+type ClaimStanceRequest struct {
+	Claim string `json:"claim"`
+	Topic string `json:"topic,omitempty"`
+	Model string `json:"model,omitempty"` // Embedding model (vector space) to search
+	// Limit is the number of similar claims judged (default 20, at most 50)
+	Limit int `json:"limit,omitempty"`
+}
+
+// ClaimStanceResponse is the response for POST /claims/stance: the sources
+// whose claims support the claim and those whose claims contradict it
+type ClaimStanceResponse struct {
+	Claim          string         `json:"claim"`
+	Supporting     []StanceSource `json:"supporting"`
+	Contradicting  []StanceSource `json:"contradicting"`
+	Judged         int            `json:"judged"` // Similar claims the model judged
+	Model          string         `json:"model"`  // Generation model that judged the claims
+	EmbeddingModel string         `json:"embedding_model,omitempty"`
+}
+
+// StanceSource is a source taking a stance on a claim, with the claims of
+// it that do, most similar first
+type StanceSource struct {
+	ID     string       `json:"id"`
+	Title  string       `json:"title,omitempty"`
+	URL    string       `json:"url"`
+	Claims []ClaimMatch `json:"claims"`
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// fieldErrors collects the validation failures of a request, so that one
+// response lists every failing field instead of the first
+type fieldErrors []FieldError
+
+// add records a failure of field
+func (e *fieldErrors) add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// nest records the failures of a list item, prefixing their fields with the
+// item's path (sources[2].url)
+func (e *fieldErrors) nest(list string, i int, item fieldErrors) {
+	for _, f := range item {
+		path := fmt.Sprintf("%s[%d]", list, i)
+		if f.Field != "" {
+			path += "." + f.Field
+		}
+		*e = append(*e, FieldError{Field: path, Code: f.Code, Message: f.Message})
+	}
+}
+
+// Error joins the messages of the failures
+func (e fieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// err returns the failures as an error, or nil if there are none
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// writeValidationError writes a 400 response listing the failures
+func writeValidationError(w http.ResponseWriter, errs fieldErrors) {
+	writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: errs.Error(), Errors: errs})
+}
+
+// writeFieldError writes a 400 response for a single failing field
+func writeFieldError(w http.ResponseWriter, field, code, message string) {
+	writeValidationError(w, fieldErrors{{Field: field, Code: code, Message: message}})
+}
+
+// writeBodyError writes the 400 response for a body that couldn't be decoded
+func writeBodyError(w http.ResponseWriter) {
+	writeFieldError(w, "", CodeMalformed, "Invalid request body")
+}
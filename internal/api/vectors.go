@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// maxScrollLimit bounds the page size of GET /admin/vectors/{collection}/points
+const maxScrollLimit = 1000
+
+// scrollPath reports whether a request path is that of GET
+// /admin/vectors/{collection}/points, whose points carry every source's
+// payload and whose stream outlasts requestDeadline
+func scrollPath(path string) bool {
+	collection, ok := strings.CutPrefix(path, "/admin/vectors/")
+	if !ok {
+		return false
+	}
+	collection, ok = strings.CutSuffix(collection, "/points")
+	return ok && collection != "" && !strings.Contains(collection, "/")
+}
+
+// handleScrollPoints serves GET /admin/vectors/{collection}/points: the ID
+// and payload of the collection's points from offset on. With limit, it
+// returns one page and the offset of the next; without, it streams every
+// point as NDJSON, a page at a time.
+func (s *Server) handleScrollPoints(w http.ResponseWriter, r *http.Request) {
+	collection := r.PathValue("collection")
+	if !knownCollection(collection) {
+		writeError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	ctx := r.Context()
+	offset := r.URL.Query().Get("offset")
+
+	if r.URL.Query().Has("limit") {
+		limit := boundedInt(r, "limit", 100, maxScrollLimit)
+		page, err := s.vectorDB.Scroll(ctx, collection, offset, limit)
+		if err != nil {
+			log.Printf("Scroll failed: %v", err)
+			writeError(w, http.StatusInternalServerError, "Scroll failed")
+			return
+		}
+		if page.Points == nil {
+			page.Points = []vectordb.Point{}
+		}
+		writeJSON(w, http.StatusOK, ScrollResponse{Points: page.Points, NextOffset: page.Next})
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	var last string
+	err := vectordb.ScrollAll(ctx, s.vectorDB, collection, offset, func(p vectordb.Point) error {
+		last = p.ID
+		return enc.Encode(p)
+	})
+	if err != nil {
+		// The status is already sent: end the stream with the error, and
+		// where to resume from
+		log.Printf("Scroll of %s failed after %q: %v", collection, last, err)
+		enc.Encode(map[string]string{"error": "Scroll failed", "last_id": last})
+	}
+	rc.Flush()
+}
+
+// knownCollection reports whether the admin endpoints serve a collection
+func knownCollection(collection string) bool {
+	return collection == vectordb.SourcesCollection || collection == vectordb.ArticlesCollection
+}
+
+// handleCountPoints serves GET /admin/vectors/{collection}/count: the exact
+// number of points of the collection, or of those whose payload matches
+// every query parameter (e.g. ?topic=physics&has_code=true)
+func (s *Server) handleCountPoints(w http.ResponseWriter, r *http.Request) {
+	collection := r.PathValue("collection")
+	if !knownCollection(collection) {
+		writeError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+
+	filter := make(vectordb.Filter)
+	for key, values := range r.URL.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "has_code", "has_dataset":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				writeFieldError(w, key, CodeInvalid, key+" must be true or false")
+				return
+			}
+			filter[key] = b
+		default:
+			filter[key] = value
+		}
+	}
+
+	count, err := s.vectorDB.Count(r.Context(), collection, filter)
+	if err != nil {
+		log.Printf("Count failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Count failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, CountResponse{Collection: collection, Count: count})
+}
+
+// handleStats serves GET /admin/stats: the cached source and article counts
+// next to the exact point count of each Qdrant collection, so points missing
+// from Qdrant show up as a difference. Unlike GET /health, it counts the
+// points, which takes as long as Qdrant needs.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.db.CachedCounts(r.Context())
+	if err != nil {
+		log.Printf("Failed to read row counts: %v", err)
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	resp := StatsResponse{SourceCount: counts["sources"].Count, ArticleCount: counts["articles"].Count}
+	for _, collection := range []string{vectordb.SourcesCollection, vectordb.ArticlesCollection} {
+		count, err := s.vectorDB.Count(r.Context(), collection, nil)
+		if err != nil {
+			log.Printf("Count of %s failed: %v", collection, err)
+			resp.VectorCounts = nil
+			break
+		}
+		if resp.VectorCounts == nil {
+			resp.VectorCounts = make(map[string]uint64, 2)
+		}
+		resp.VectorCounts[collection] = count
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/watch"
+)
+
+const (
+	// defaultWatchThreshold is the similarity of a match unless a watch sets
+	// its own
+	defaultWatchThreshold = 0.75
+	// maxWatches bounds the watches of a user
+	maxWatches = 50
+	// maxWatchChars bounds the text of a watch
+	maxWatchChars = 2000
+	// watchNameChars is the length of names taken from the text
+	watchNameChars = 60
+	// watchStreamPoll is how often GET /watches/stream looks for matches,
+	// and watchStreamKeepAlive how often it writes when there are none
+	watchStreamPoll      = 2 * time.Second
+	watchStreamKeepAlive = 30 * time.Second
+)
+
+// handleListWatches serves GET /watches: the caller's watches with their
+// match counts
+func (s *Server) handleListWatches(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	watches, err := s.db.ListWatches(r.Context(), user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, WatchesResponse{Watches: watches, Count: len(watches)})
+}
+
+// handleCreateWatch serves POST /watches: it embeds the text of a new watch,
+// which is compared with the sources created from now on. A watch with a
+// URL gets a signing secret, which is not shown again.
+func (s *Server) handleCreateWatch(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	var req CreateWatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	req.Name = strings.TrimSpace(req.Name)
+	req.URL = strings.TrimSpace(req.URL)
+	req.Topic = slug.Make(req.Topic)
+
+	var errs fieldErrors
+	if req.Text == "" {
+		errs.add("text", CodeRequired, "text is required")
+	} else if len([]rune(req.Text)) > maxWatchChars {
+		errs.add("text", CodeOutOfRange, fmt.Sprintf("text must be at most %d characters", maxWatchChars))
+	}
+	threshold := defaultWatchThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+		if threshold <= 0 || threshold > 1 {
+			errs.add("threshold", CodeOutOfRange, "threshold must be greater than 0 and at most 1")
+		}
+	}
+	if req.URL != "" {
+		if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("url", CodeInvalid, "url must be an absolute http or https URL")
+		}
+	}
+	embedder := s.embedders.Get(req.Model)
+	if embedder == nil {
+		errs.add("model", CodeInvalid, "Unknown embedding model")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	existing, err := s.db.ListWatches(ctx, user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if len(existing) >= maxWatches {
+		writeFieldError(w, "X-User-ID", CodeOutOfRange, fmt.Sprintf("at most %d watches per user", maxWatches))
+		return
+	}
+	emb, err := embedder.Embed(ctx, req.Text)
+	if err != nil {
+		log.Printf("Failed to generate embedding: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to generate embedding")
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = req.Text
+		if runes := []rune(req.Text); len(runes) > watchNameChars {
+			req.Name = strings.TrimSpace(string(runes[:watchNameChars])) + "…"
+		}
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	secret := ""
+	if req.URL != "" {
+		b := make([]byte, 24)
+		rand.Read(b)
+		secret = webhookSecretPrefix + hex.EncodeToString(b)
+	}
+	created, err := s.db.CreateWatch(ctx, database.Watch{
+		ID:        "watch-" + hex.EncodeToString(id),
+		UserID:    user,
+		Name:      req.Name,
+		Text:      req.Text,
+		Model:     embedder.Model(),
+		Embedding: emb,
+		Threshold: threshold,
+		Topic:     req.Topic,
+		URL:       req.URL,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Failed to create watch: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create watch")
+		return
+	}
+	writeJSON(w, http.StatusCreated, WatchResponse{Watch: *created, Secret: created.Secret})
+}
+
+// handleGetWatch serves GET /watches/{id}
+func (s *Server) handleGetWatch(w http.ResponseWriter, r *http.Request) {
+	found, ok := s.lookupWatch(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// handleDeleteWatch serves DELETE /watches/{id}, with its matches
+func (s *Server) handleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	found, ok := s.lookupWatch(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.db.DeleteWatch(r.Context(), found.ID); err != nil {
+		log.Printf("Failed to delete watch %s: %v", found.ID, err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete watch")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWatchMatches serves GET /watches/{id}/matches?since=0&limit=100: the
+// matches of a watch after a cursor, oldest first
+func (s *Server) handleWatchMatches(w http.ResponseWriter, r *http.Request) {
+	found, ok := s.lookupWatch(w, r)
+	if !ok {
+		return
+	}
+	var since int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = strconv.ParseInt(v, 10, 64); err != nil || since < 0 {
+			writeFieldError(w, "since", CodeInvalid, "since must be a non-negative match sequence number")
+			return
+		}
+	}
+	limit := boundedInt(r, "limit", 100, 1000)
+
+	ctx := r.Context()
+	matches, err := s.db.WatchMatches(ctx, found.UserID, found.ID, since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	notifications, err := s.watchNotifications(ctx, found.UserID, matches, map[string]database.Watch{found.ID: *found})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	next := since
+	if len(matches) > 0 {
+		next = matches[len(matches)-1].Seq
+	}
+	writeJSON(w, http.StatusOK, WatchMatchesResponse{Matches: notifications, Count: len(notifications), NextCursor: next})
+}
+
+// handleWatchStream serves GET /watches/stream: the matches of the caller's
+// watches as server-sent events of type watch.matched, from the ones after
+// the Last-Event-ID header (or since= parameter) if set, or from now on
+func (s *Server) handleWatchStream(w http.ResponseWriter, r *http.Request) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return
+	}
+	ctx := r.Context()
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("since")
+	}
+	var since int64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseInt(cursor, 10, 64); err != nil || since < 0 {
+			writeFieldError(w, "since", CodeInvalid, "since must be a non-negative match sequence number")
+			return
+		}
+	} else {
+		var err error
+		if since, err = s.db.LastWatchMatch(ctx, user); err != nil {
+			writeError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": watching\n\n")
+	if rc.Flush() != nil {
+		return
+	}
+
+	watches := make(map[string]database.Watch)
+	ticker := time.NewTicker(watchStreamPoll)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		matches, err := s.db.WatchMatches(ctx, user, "", since, 100)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Watch stream of %s failed: %v", user, err)
+			}
+			return
+		}
+		notifications, err := s.watchNotifications(ctx, user, matches, watches)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Watch stream of %s failed: %v", user, err)
+			}
+			return
+		}
+		if len(matches) > 0 {
+			since = matches[len(matches)-1].Seq
+		}
+		for _, n := range notifications {
+			data, _ := json.Marshal(n)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", n.Seq, watch.EventMatched, data)
+		}
+		if len(notifications) == 0 {
+			if time.Since(lastWrite) < watchStreamKeepAlive {
+				continue
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if rc.Flush() != nil {
+			return
+		}
+		lastWrite = time.Now()
+	}
+}
+
+// watchNotifications describes matches of a user's watches, leaving out
+// those of sources since deleted or made private. Watches is a cache of the
+// user's watches by ID, filled as needed.
+func (s *Server) watchNotifications(ctx context.Context, user string, matches []database.WatchMatch, watches map[string]database.Watch) ([]watch.Notification, error) {
+	notifications := []watch.Notification{}
+	for _, m := range matches {
+		wt, ok := watches[m.WatchID]
+		if !ok {
+			found, err := s.db.GetWatch(ctx, m.WatchID)
+			if err != nil {
+				return nil, err
+			}
+			if found == nil {
+				continue
+			}
+			wt = *found
+			watches[wt.ID] = wt
+		}
+		src, err := s.db.GetSource(ctx, m.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil || !src.VisibleTo(user) {
+			continue
+		}
+		notifications = append(notifications, watch.NewNotification(m, wt, *src))
+	}
+	return notifications, nil
+}
+
+// lookupWatch returns the caller's watch of the request path, answering
+// 404 when the caller has none by that ID
+func (s *Server) lookupWatch(w http.ResponseWriter, r *http.Request) (*database.Watch, bool) {
+	user := userID(r)
+	if user == "" {
+		writeFieldError(w, "X-User-ID", CodeRequired, "X-User-ID header is required")
+		return nil, false
+	}
+	found, err := s.db.GetWatch(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Database error")
+		return nil, false
+	}
+	if found == nil || found.UserID != user {
+		writeError(w, http.StatusNotFound, "Watch not found")
+		return nil, false
+	}
+	return found, true
+}
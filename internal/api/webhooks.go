@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/webhook"
+)
+
+// webhookSecretPrefix starts every webhook signing secret
+const webhookSecretPrefix = "whsec_"
+
+// handleListWebhooks serves GET /webhooks: every webhook with its delivery
+// status, without secrets
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.db.ListWebhooks(r.Context())
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list webhooks")
+		return
+	}
+	writeJSON(w, http.StatusOK, WebhooksResponse{Webhooks: hooks, EventTypes: webhook.EventTypes})
+}
+
+// handleCreateWebhook serves POST /webhooks: it registers an endpoint for
+// the events from now on and returns its signing secret, which is not
+// shown again
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w)
+		return
+	}
+	var errs fieldErrors
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		errs.add("url", CodeRequired, "url is required")
+	} else if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add("url", CodeInvalid, "url must be an absolute http or https URL")
+	}
+	events := []string{}
+	for i, eventType := range req.Events {
+		if !slices.Contains(webhook.EventTypes, eventType) {
+			errs.add(fmt.Sprintf("events[%d]", i), CodeUnsupported,
+				fmt.Sprintf("unknown event type %q (one of %s)", eventType, strings.Join(webhook.EventTypes, ", ")))
+		} else if !slices.Contains(events, eventType) {
+			events = append(events, eventType)
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	secret := make([]byte, 24)
+	rand.Read(secret)
+	hook, err := s.db.CreateWebhook(r.Context(), database.Webhook{
+		ID:          "hook-" + hex.EncodeToString(id),
+		URL:         req.URL,
+		Description: strings.TrimSpace(req.Description),
+		Events:      events,
+		Secret:      webhookSecretPrefix + hex.EncodeToString(secret),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Failed to create webhook: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+	log.Printf("Created webhook %s for %s", hook.ID, hook.URL)
+	writeJSON(w, http.StatusCreated, WebhookResponse{Webhook: *hook, Secret: hook.Secret})
+}
+
+// handleGetWebhook serves GET /webhooks/{id}
+func (s *Server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, hook)
+}
+
+// handleDeleteWebhook serves DELETE /webhooks/{id}: deliveries stop,
+// including retries of a failing one
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	found, err := s.db.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		log.Printf("Failed to delete webhook %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	log.Printf("Deleted webhook %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePingWebhook serves POST /webhooks/{id}/ping: it sends a signed ping
+// event right away, to check the endpoint and its signature verification.
+// The outcome is reported in the response, not recorded.
+func (s *Server) handlePingWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.lookupWebhook(w, r)
+	if !ok {
+		return
+	}
+	status, err := webhook.Send(r.Context(), *hook, webhook.Payload{
+		Type:       webhook.EventPing,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	resp := PingWebhookResponse{Delivered: err == nil, Status: status}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lookupWebhook returns the webhook of the request path, answering 404 or
+// 500 when there is none
+func (s *Server) lookupWebhook(w http.ResponseWriter, r *http.Request) (*database.Webhook, bool) {
+	id := r.PathValue("id")
+	hook, err := s.db.GetWebhook(r.Context(), id)
+	if err != nil {
+		log.Printf("Failed to get webhook %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to get webhook")
+		return nil, false
+	}
+	if hook == nil {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return nil, false
+	}
+	return hook, true
+}
@@ -0,0 +1,119 @@
+// Package autolink proposes links between articles and sources: each
+// article's embedding is searched against the sources collection, and close
+// matches are stored as link suggestions for curators to review.
+package autolink
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// Defaults for Options
+const (
+	DefaultThreshold = 0.8
+	DefaultLimit     = 5
+)
+
+// Options tune a run
+type Options struct {
+	// Threshold is the minimum similarity for a suggestion, or the maximum
+	// distance when the sources collection uses the euclid metric
+	Threshold float32
+	Limit     int    // Sources considered per article
+	Vector    string // Vector space to search; empty selects the default
+}
+
+// Stats summarize a run
+type Stats struct {
+	Articles  int // Articles searched
+	Skipped   int // Articles without a stored embedding
+	Suggested int // New suggestions
+}
+
+// Run searches the sources closest to every article and records those
+// reaching the threshold as pending suggestions. Only public sources are
+// proposed, since articles are public.
+func Run(ctx context.Context, db store.Store, vectorDB store.VectorStore, opts Options) (Stats, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	distance := vectorDB.Distance(vectordb.SourcesCollection)
+
+	var stats Stats
+	ids, err := db.ArticleIDs(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list articles: %w", err)
+	}
+
+	for _, id := range ids {
+		vec, err := vectorDB.GetArticleVector(ctx, id, opts.Vector)
+		if err != nil {
+			return stats, fmt.Errorf("failed to get embedding of article %s: %w", id, err)
+		}
+		if vec == nil {
+			stats.Skipped++
+			continue
+		}
+		stats.Articles++
+
+		results, err := vectorDB.SearchSources(ctx, vec, opts.Limit, vectordb.SearchOptions{Vector: opts.Vector})
+		if err != nil {
+			return stats, fmt.Errorf("failed to search sources for article %s: %w", id, err)
+		}
+
+		var suggestions []database.LinkSuggestion
+		for _, r := range results {
+			if !distance.Reaches(r.Score, opts.Threshold) {
+				continue
+			}
+			sourceID, _ := r.Payload["id"].(string)
+			if sourceID == "" {
+				continue
+			}
+			suggestions = append(suggestions, database.LinkSuggestion{ArticleID: id, SourceID: sourceID, Score: r.Score})
+		}
+		if len(suggestions) == 0 {
+			continue
+		}
+
+		added, err := db.SuggestLinks(ctx, suggestions)
+		if err != nil {
+			return stats, fmt.Errorf("failed to store suggestions for article %s: %w", id, err)
+		}
+		stats.Suggested += added
+	}
+	return stats, nil
+}
+
+// Schedule runs the job every interval until ctx is done, logging the
+// outcome of each run
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, vectorDB store.VectorStore, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			stats, err := Run(ctx, db, vectorDB, opts)
+			if err != nil {
+				log.Printf("Auto-linking failed: %v", err)
+				continue
+			}
+			log.Printf("Auto-linking: %d articles searched, %d without embeddings, %d new suggestions (%s)",
+				stats.Articles, stats.Skipped, stats.Suggested, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
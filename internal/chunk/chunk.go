@@ -0,0 +1,245 @@
+// Package chunk splits article bodies into chunks that are embedded one by
+// one, so every part of a long article can be found by semantic search.
+// Splitting follows the markdown structure: chunks never span two sections,
+// and each carries the path of headings it falls under.
+package chunk
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defaults for Options
+const (
+	DefaultSize    = 1000
+	DefaultOverlap = 150
+)
+
+// Options tune the splitting
+type Options struct {
+	// Size is the target length of a chunk in bytes, before the overlap
+	Size int
+	// Overlap is how much of the end of a chunk is repeated at the start of
+	// the next chunk of the same section, so a passage cut in two is still
+	// found whole in one of them
+	Overlap int
+}
+
+// DefaultOptions returns the default chunk size and overlap
+func DefaultOptions() Options {
+	return Options{Size: DefaultSize, Overlap: DefaultOverlap}
+}
+
+// OptionsFromEnv reads the options from KB_CHUNK_SIZE and KB_CHUNK_OVERLAP,
+// defaulting to DefaultSize and DefaultOverlap. The overlap must be smaller
+// than the size.
+func OptionsFromEnv() (Options, error) {
+	opts := DefaultOptions()
+	if s := os.Getenv("KB_CHUNK_SIZE"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 {
+			return opts, fmt.Errorf("invalid KB_CHUNK_SIZE: %s", s)
+		}
+		opts.Size = size
+	}
+	if s := os.Getenv("KB_CHUNK_OVERLAP"); s != "" {
+		overlap, err := strconv.Atoi(s)
+		if err != nil || overlap < 0 {
+			return opts, fmt.Errorf("invalid KB_CHUNK_OVERLAP: %s", s)
+		}
+		opts.Overlap = overlap
+	}
+	if opts.Overlap >= opts.Size {
+		return opts, fmt.Errorf("KB_CHUNK_OVERLAP (%d) must be smaller than KB_CHUNK_SIZE (%d)", opts.Overlap, opts.Size)
+	}
+	return opts, nil
+}
+
+// Chunk is a part of an article body
+type Chunk struct {
+	Index   int    // Position in the article, from 0
+	Heading string // Headings the chunk falls under, e.g. "Setup > Linux"
+	Text    string
+}
+
+// EmbeddingText returns the text embedded for the chunk: the article title
+// and the chunk's headings give the passage its context
+func (c Chunk) EmbeddingText(title string) string {
+	var b strings.Builder
+	b.WriteString(title)
+	if c.Heading != "" {
+		b.WriteString("\n")
+		b.WriteString(c.Heading)
+	}
+	b.WriteString("\n\n")
+	b.WriteString(c.Text)
+	return strings.TrimSpace(b.String())
+}
+
+// section is the text under a heading, up to the next heading
+type section struct {
+	heading    string
+	paragraphs []string
+}
+
+// Split splits a markdown body into chunks of about opts.Size bytes, plus
+// the overlap carried over from the previous chunk of the same section.
+// Paragraphs are kept whole when they fit; longer ones are split between
+// words. Code blocks are never split at blank lines.
+func Split(body string, opts Options) []Chunk {
+	if opts.Size <= 0 {
+		opts = DefaultOptions()
+	}
+	var chunks []Chunk
+	for _, sec := range sections(body) {
+		var prev string
+		for _, text := range pack(sec.paragraphs, opts.Size) {
+			if prev != "" && opts.Overlap > 0 {
+				text = tail(prev, opts.Overlap) + " " + text
+			}
+			chunks = append(chunks, Chunk{Index: len(chunks), Heading: sec.heading, Text: text})
+			prev = text
+		}
+	}
+	return chunks
+}
+
+// sections splits a markdown body at its headings into sections of
+// paragraphs. Lines inside fenced code blocks are never headings.
+func sections(body string) []section {
+	var out []section
+	var path []string // Heading texts by level
+	cur := section{}
+	var para []string
+	fence := ""
+
+	endParagraph := func() {
+		if p := strings.TrimSpace(strings.Join(para, "\n")); p != "" {
+			cur.paragraphs = append(cur.paragraphs, p)
+		}
+		para = para[:0]
+	}
+	endSection := func() {
+		endParagraph()
+		if len(cur.paragraphs) > 0 {
+			out = append(out, cur)
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			para = append(para, line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			para = append(para, line)
+			continue
+		}
+		if level, text, ok := heading(trimmed); ok {
+			endSection()
+			if len(path) >= level {
+				path = path[:level-1]
+			}
+			for len(path) < level-1 {
+				path = append(path, "")
+			}
+			path = append(path, text)
+			cur = section{heading: joinHeadings(path)}
+			continue
+		}
+		if trimmed == "" {
+			endParagraph()
+			continue
+		}
+		para = append(para, line)
+	}
+	endSection()
+	return out
+}
+
+// heading parses an ATX heading line ("## Title") into its level and text,
+// which may be empty
+func heading(line string) (int, string, bool) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, text, true
+}
+
+// joinHeadings joins the non-empty headings of a path
+func joinHeadings(path []string) string {
+	var parts []string
+	for _, h := range path {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// pack groups paragraphs into texts of at most size bytes, splitting longer
+// paragraphs between words
+func pack(paragraphs []string, size int) []string {
+	var texts []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			texts = append(texts, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, para := range paragraphs {
+		if cur.Len() > 0 && cur.Len()+len(para)+2 > size {
+			flush()
+		}
+		if len(para) <= size {
+			if cur.Len() > 0 {
+				cur.WriteString("\n\n")
+			}
+			cur.WriteString(para)
+			continue
+		}
+		for _, word := range strings.Fields(para) {
+			if cur.Len() > 0 && cur.Len()+len(word)+1 > size {
+				flush()
+			}
+			if cur.Len() > 0 {
+				cur.WriteByte(' ')
+			}
+			cur.WriteString(word)
+		}
+	}
+	flush()
+	return texts
+}
+
+// tail returns about the last n bytes of s, starting at a word boundary
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	t := s[len(s)-n:]
+	if i := strings.IndexFunc(t, unicode.IsSpace); i >= 0 {
+		return strings.TrimSpace(t[i:])
+	}
+	// A single long word: start at a rune boundary
+	for len(t) > 0 && !utf8.RuneStart(t[0]) {
+		t = t[1:]
+	}
+	return t
+}
@@ -0,0 +1,36 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// Points splits the body of an article and embeds every chunk with every
+// model, returning the points to store with ReplaceArticleChunks
+func Points(ctx context.Context, embedders *embedding.Set, art database.Article, opts Options) ([]vectordb.ChunkPoint, error) {
+	chunks := Split(art.Content, opts)
+	points := make([]vectordb.ChunkPoint, 0, len(chunks))
+	for _, c := range chunks {
+		vectors, err := embedders.EmbedAll(ctx, c.EmbeddingText(art.Title))
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", c.Index, err)
+		}
+		points = append(points, vectordb.ChunkPoint{
+			Vectors: vectors,
+			Payload: vectordb.ChunkPayload{
+				ArticleID: art.ID,
+				Title:     art.Title,
+				Path:      art.Path,
+				Category:  art.Category(),
+				Chunk:     c.Index,
+				Heading:   c.Heading,
+				Text:      c.Text,
+			},
+		})
+	}
+	return points, nil
+}
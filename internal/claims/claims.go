@@ -0,0 +1,99 @@
+// Package claims extracts discrete claims from source summaries with a
+// generation model and embeds them for the claims collection, so sources
+// can be searched, and compared, statement by statement.
+package claims
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+const (
+	// MaxClaims is the most claims kept per source
+	MaxClaims = 12
+	// maxSummaryChars cuts the summary sent to the model
+	maxSummaryChars = 8000
+	// maxClaimChars drops "claims" that are really paragraphs
+	maxClaimChars = 400
+)
+
+// extractSystem is the system prompt of claim extraction
+const extractSystem = `You extract claims from source summaries for a fact index. Work only from the text given; never add outside knowledge.`
+
+// extractPrompt asks for the claims of a summary as JSON
+const extractPrompt = `List the discrete, checkable claims the source below makes: findings, measurements, causal statements, definitions it argues for. Write each claim as one self-contained sentence that can be understood without the source (name the subject rather than writing "it" or "the study"). Skip background, opinions about the source itself, and statements of what the source is about. List at most %d claims, most important first.
+
+Answer with JSON only: {"claims": ["...", "..."]}
+
+Title: %s
+
+%s`
+
+// Extract asks the generator for the claims of a source's summary (and paper
+// abstract). Duplicates, empty claims and overlong ones are dropped; a
+// summary that states nothing checkable yields no claims.
+func Extract(ctx context.Context, g generation.Generator, src database.Source) ([]string, error) {
+	text := src.Summary
+	if src.Publication != nil && src.Publication.Abstract != "" {
+		text += "\n\nAbstract: " + src.Publication.Abstract
+	}
+	if runes := []rune(text); len(runes) > maxSummaryChars {
+		text = string(runes[:maxSummaryChars]) + "…"
+	}
+
+	var out struct {
+		Claims []string `json:"claims"`
+	}
+	err := generation.GenerateJSON(ctx, g, fmt.Sprintf(extractPrompt, MaxClaims, src.Title, text),
+		generation.Options{System: extractSystem}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []string{}
+	seen := make(map[string]bool)
+	for _, c := range out.Claims {
+		c = strings.Join(strings.Fields(c), " ")
+		key := strings.ToLower(c)
+		if c == "" || len(c) > maxClaimChars || seen[key] {
+			continue
+		}
+		seen[key] = true
+		claims = append(claims, c)
+		if len(claims) == MaxClaims {
+			break
+		}
+	}
+	return claims, nil
+}
+
+// Points embeds every claim of a source with every model, returning the
+// points to store with ReplaceSourceClaims
+func Points(ctx context.Context, embedders *embedding.Set, src database.Source, claims []string) ([]vectordb.ClaimPoint, error) {
+	points := make([]vectordb.ClaimPoint, 0, len(claims))
+	for i, text := range claims {
+		vectors, err := embedders.EmbedAll(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("claim %d: %w", i, err)
+		}
+		points = append(points, vectordb.ClaimPoint{
+			Vectors: vectors,
+			Payload: vectordb.ClaimPayload{
+				SourceID:   src.ID,
+				Title:      src.Title,
+				Topic:      src.Topic,
+				Claim:      i,
+				Text:       text,
+				Visibility: src.Visibility,
+				Owner:      src.Owner,
+			},
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,130 @@
+// Package cold moves sources nobody has hit in a long time to cold storage:
+// their vectors are dropped from Qdrant, shrinking the in-memory index,
+// while their records stay in SQLite, where keyword search, listings and
+// GET /sources/{id} still find them. A cold source is re-embedded when it is
+// hit again (see the API's warmSource), so decade-old material costs memory
+// only while someone uses it. A dry run reports what would be moved.
+package cold
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// DefaultLimit bounds the sources moved by a run
+const DefaultLimit = 10000
+
+// Options configure a run
+type Options struct {
+	// Idle is how long a source must go without a hit to be moved; sources
+	// never hit are idle since they were ingested
+	Idle retention.Period
+	// Now is the time idleness is measured from; zero means the current
+	// time
+	Now time.Time
+	// Limit bounds the sources moved, longest idle first; zero selects
+	// DefaultLimit
+	Limit int
+	// DryRun reports the idle sources without moving them
+	DryRun bool
+}
+
+// IdleSource is a source moved, or to move, to cold storage
+type IdleSource struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// LastHitAt is the source's last hit; empty if it was never hit since
+	// hits are tracked
+	LastHitAt string `json:"last_hit_at,omitempty"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	DryRun  bool         `json:"dry_run"`
+	Idle    string       `json:"idle"`
+	Cutoff  string       `json:"cutoff"` // Sources not hit since are idle
+	Sources []IdleSource `json:"sources"`
+	Moved   int          `json:"moved"` // Or to move, in a dry run
+	Failed  int          `json:"failed"`
+	Cold    int          `json:"cold"` // Cold sources after the run
+}
+
+// Run drops the vectors of the sources idle for opts.Idle, longest idle
+// first, and marks them cold; or only reports them in a dry run
+func Run(ctx context.Context, db store.Store, vectorDB store.VectorStore, opts Options) (Report, error) {
+	if opts.Idle.Forever() {
+		return Report{}, fmt.Errorf("an idle period is required")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	cutoff := opts.Idle.Cutoff(now).Format(time.RFC3339)
+	report := Report{DryRun: opts.DryRun, Idle: opts.Idle.String(), Cutoff: cutoff, Sources: []IdleSource{}}
+
+	idle, err := db.IdleSources(ctx, cutoff, limit)
+	if err != nil {
+		return report, fmt.Errorf("failed to list idle sources: %w", err)
+	}
+	for _, a := range idle {
+		src, err := db.GetSource(ctx, a.SourceID)
+		if err != nil {
+			return report, err
+		}
+		if src == nil {
+			continue // Deleted since it was listed
+		}
+		if !opts.DryRun {
+			if err := vectorDB.DeleteSource(ctx, src.ID); err != nil {
+				log.Printf("Failed to drop vectors of source %s: %v", src.ID, err)
+				report.Failed++
+				continue
+			}
+			if err := db.SetSourceCold(ctx, src.ID, now.Format(time.RFC3339)); err != nil {
+				return report, err
+			}
+		}
+		report.Sources = append(report.Sources, IdleSource{ID: src.ID, Title: src.Title, LastHitAt: a.LastHitAt})
+		report.Moved++
+	}
+
+	ids, err := db.ColdSourceIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to count cold sources: %w", err)
+	}
+	report.Cold = len(ids)
+	return report, nil
+}
+
+// Schedule moves idle sources to cold storage every interval until ctx is
+// cancelled
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, vectorDB store.VectorStore, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			report, err := Run(ctx, db, vectorDB, opts)
+			if err != nil {
+				log.Printf("Cold storage failed: %v", err)
+				continue
+			}
+			log.Printf("Cold storage: %d sources moved, %d failed, %d cold (%s)",
+				report.Moved, report.Failed, report.Cold, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
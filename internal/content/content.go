@@ -0,0 +1,81 @@
+// Package content keeps the full texts of sources and articles outside the
+// SQLite database. By default the database holds them itself, in its FTS
+// indexes; for very large corpora they can live in a directory or an
+// S3-compatible bucket instead, leaving the database with only the search
+// index of the texts. The layout is recorded in the database, so every
+// command opening it finds the texts (see database.DB.MoveContent).
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SQLite names the default layout, in which the database holds the texts
+const SQLite = "sqlite"
+
+// Store holds texts by key (see SourceKey and ArticleKey)
+type Store interface {
+	// Get returns the text stored under key, or "" if there is none
+	Get(ctx context.Context, key string) (string, error)
+	Put(ctx context.Context, key, text string) error
+	// Delete removes the text under key; missing keys are not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// SourceKey is the key of the full text of a source
+func SourceKey(id string) string {
+	return "sources/" + escapeID(id)
+}
+
+// ArticleKey is the key of the markdown body of an article
+func ArticleKey(id string) string {
+	return "articles/" + escapeID(id)
+}
+
+// escapeID makes an ID a single path segment; dots are escaped too, so no
+// ID is "." or ".."
+func escapeID(id string) string {
+	return strings.ReplaceAll(url.PathEscape(id), ".", "%2E")
+}
+
+// shard returns a two-character directory spreading keys evenly, so no
+// directory of a large corpus holds every text
+func shard(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:1])
+}
+
+// Open returns the store at location:
+//
+//	file:///var/lib/kb/content
+//	s3://bucket/prefix?endpoint=https://minio.example.com:9000&region=eu-west-1
+//
+// Files go in subdirectories of the directory. Objects go in the bucket,
+// with path-style requests signed with AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY (and AWS_SESSION_TOKEN, if set); the endpoint
+// defaults to AWS's for the region, and the region to AWS_REGION or
+// us-east-1. Open returns nil for "" and "sqlite", the SQLite layout.
+func Open(location string) (Store, error) {
+	if location == "" || location == SQLite {
+		return nil, nil
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content store %q: %w", location, err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" || u.Host != "" {
+			return nil, fmt.Errorf("invalid content store %q: file stores need an absolute path (file:///dir)", location)
+		}
+		return NewDir(u.Path), nil
+	case "s3":
+		return newS3(u)
+	}
+	return nil, fmt.Errorf("invalid content store %q: unknown scheme (file, s3 or sqlite)", location)
+}
@@ -0,0 +1,73 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dir stores texts as files below a directory, sharded by the hash of
+// their key
+type Dir struct {
+	root string
+}
+
+// NewDir returns a store writing below root, which is created on the first
+// write
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// path returns the file holding the text of key
+func (d *Dir) path(key string) string {
+	dir, name := filepath.Split(filepath.FromSlash(key))
+	return filepath.Join(d.root, dir, shard(key), name)
+}
+
+// Get reads the text stored under key, or "" if there is none
+func (d *Dir) Get(ctx context.Context, key string) (string, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read content %s: %w", key, err)
+	}
+	return string(data), nil
+}
+
+// Put writes the text of key, replacing it atomically
+func (d *Dir) Put(ctx context.Context, key, text string) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create content directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write content %s: %w", key, err)
+	}
+	_, err = tmp.WriteString(text)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write content %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the text of key
+func (d *Dir) Delete(ctx context.Context, key string) error {
+	err := os.Remove(d.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete content %s: %w", key, err)
+	}
+	return nil
+}
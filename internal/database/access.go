@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SourceAccess is when a source was last hit, fetched by ID or returned by
+// a search, and whether it is cold: its vectors dropped from Qdrant by the
+// cold storage job (see package cold) until it is hit again
+type SourceAccess struct {
+	SourceID  string `json:"source_id"`
+	LastHitAt string `json:"last_hit_at,omitempty"`
+	ColdAt    string `json:"cold_at,omitempty"`
+}
+
+// RecordSourceHits sets the last hit of sources to at
+func (db *DB) RecordSourceHits(ctx context.Context, ids []string, at string) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO source_access (source_id, last_hit_at) VALUES (?, ?)
+				ON CONFLICT(source_id) DO UPDATE SET last_hit_at = excluded.last_hit_at
+			`, id, at)
+			if err != nil {
+				return fmt.Errorf("failed to record hit of source %s: %w", id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetSourceAccess returns the access record of a source; a source never hit
+// nor made cold has an empty one
+func (db *DB) GetSourceAccess(ctx context.Context, id string) (SourceAccess, error) {
+	a := SourceAccess{SourceID: id}
+	var lastHit, coldAt sql.NullString
+	err := db.queryRow(ctx, "SELECT last_hit_at, cold_at FROM source_access WHERE source_id = ?", id).Scan(&lastHit, &coldAt)
+	if err == sql.ErrNoRows {
+		return a, nil
+	}
+	a.LastHitAt, a.ColdAt = lastHit.String, coldAt.String
+	return a, err
+}
+
+// SetSourceCold records that a source's vectors were dropped at coldAt, or
+// restored when coldAt is empty
+func (db *DB) SetSourceCold(ctx context.Context, id, coldAt string) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO source_access (source_id, cold_at) VALUES (?, NULLIF(?, ''))
+		ON CONFLICT(source_id) DO UPDATE SET cold_at = excluded.cold_at
+	`, id, coldAt)
+	if err != nil {
+		return fmt.Errorf("failed to set cold state of source %s: %w", id, err)
+	}
+	return nil
+}
+
+// IdleSources returns up to limit sources that aren't cold (nor deleted) and weren't hit
+// since before (RFC 3339), longest idle first. Sources never hit are idle
+// since they were ingested.
+func (db *DB) IdleSources(ctx context.Context, before string, limit int) ([]SourceAccess, error) {
+	rows, err := db.query(ctx, `
+		SELECT s.id, COALESCE(a.last_hit_at, '') FROM sources s
+		LEFT JOIN source_access a ON a.source_id = s.id
+		WHERE s.deleted_at IS NULL AND a.cold_at IS NULL AND COALESCE(a.last_hit_at, s.created_at) < ?
+		ORDER BY COALESCE(a.last_hit_at, s.created_at), s.id
+		LIMIT ?
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	idle := []SourceAccess{}
+	for rows.Next() {
+		var a SourceAccess
+		if err := rows.Scan(&a.SourceID, &a.LastHitAt); err != nil {
+			return nil, err
+		}
+		idle = append(idle, a)
+	}
+	return idle, rows.Err()
+}
+
+// ColdSourceIDs returns the IDs of the cold sources
+func (db *DB) ColdSourceIDs(ctx context.Context) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT source_id FROM source_access WHERE cold_at IS NOT NULL ORDER BY source_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
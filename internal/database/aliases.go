@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Alias kinds
+const (
+	AliasSource  = "source"
+	AliasArticle = "article"
+)
+
+// Alias maps a retired ID (after a merge or slug rename) to the ID of the
+// record that replaced it
+type Alias struct {
+	Kind      string `json:"kind"` // source or article
+	OldID     string `json:"old_id"`
+	NewID     string `json:"new_id"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// ValidAliasKind reports whether kind is a known alias kind
+func ValidAliasKind(kind string) bool {
+	return kind == AliasSource || kind == AliasArticle
+}
+
+// AddAlias records that OldID now refers to NewID. Aliases that pointed at
+// OldID are repointed to NewID, and NewID is resolved through any existing
+// alias first, so every alias resolves in one step and cycles are rejected.
+func (db *DB) AddAlias(ctx context.Context, alias Alias) error {
+	if !ValidAliasKind(alias.Kind) {
+		return fmt.Errorf("unknown alias kind: %s", alias.Kind)
+	}
+	if alias.OldID == "" || alias.NewID == "" || alias.OldID == alias.NewID {
+		return fmt.Errorf("alias must map an ID to a different ID")
+	}
+	if alias.CreatedAt == "" {
+		alias.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		target, err := resolveAlias(ctx, tx, alias.Kind, alias.NewID)
+		if err != nil {
+			return err
+		}
+		if target == alias.OldID {
+			return fmt.Errorf("alias %s -> %s would create a cycle", alias.OldID, alias.NewID)
+		}
+		if target != "" {
+			alias.NewID = target
+		}
+
+		if err := writeAlias(ctx, tx, alias); err != nil {
+			return err
+		}
+		return appendEvent(ctx, tx, EventAliasAdded, alias.OldID, alias)
+	})
+}
+
+// writeAlias stores an alias and repoints the aliases that targeted its old ID
+func writeAlias(ctx context.Context, tx *sql.Tx, alias Alias) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO aliases (kind, old_id, new_id, created_at)
+		VALUES (?, ?, ?, ?)
+	`, alias.Kind, alias.OldID, alias.NewID, alias.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert alias: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE aliases SET new_id = ? WHERE kind = ? AND new_id = ?",
+		alias.NewID, alias.Kind, alias.OldID)
+	if err != nil {
+		return fmt.Errorf("failed to repoint aliases: %w", err)
+	}
+	return nil
+}
+
+func resolveAlias(ctx context.Context, tx *sql.Tx, kind, id string) (string, error) {
+	var newID string
+	err := tx.QueryRowContext(ctx, "SELECT new_id FROM aliases WHERE kind = ? AND old_id = ?", kind, id).Scan(&newID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return newID, err
+}
+
+// ResolveAlias returns the current ID for a retired ID, or "" if id is not
+// an alias
+func (db *DB) ResolveAlias(ctx context.Context, kind, id string) (string, error) {
+	var newID string
+	err := db.queryRow(ctx, "SELECT new_id FROM aliases WHERE kind = ? AND old_id = ?", kind, id).Scan(&newID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return newID, err
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// APIKey is a key for automated clients such as scrapers, restricted to
+// creating sources in its topics. Only the hash of its secret is stored;
+// the secret itself is shown once, when the key is created or rotated.
+// Keys are credentials, not knowledge, so they are not recorded in the
+// event log.
+type APIKey struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Topics []string `json:"topics"` // Topic slugs the key may create sources in
+	// Prefix is the start of the secret, to tell keys apart in logs and
+	// configuration
+	Prefix     string `json:"prefix"`
+	CreatedAt  string `json:"created_at"`
+	RotatedAt  string `json:"rotated_at,omitempty"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	// PreviousExpiresAt is when the secret replaced by the last rotation
+	// stops working; omitted once it has
+	PreviousExpiresAt string `json:"previous_expires_at,omitempty"`
+}
+
+// AllowsTopic reports whether the key may write sources of a topic
+func (k APIKey) AllowsTopic(topic string) bool {
+	return topic != "" && slices.Contains(k.Topics, topic)
+}
+
+// HashAPISecret returns the stored form of an API key secret. Secrets are
+// long random strings, so a plain SHA-256 can't be reversed.
+func HashAPISecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+const apiKeyColumns = "id, name, topics, prefix, created_at, rotated_at, last_used_at, previous_expires_at"
+
+// scanAPIKey reads a row of apiKeyColumns
+func scanAPIKey(row interface{ Scan(...any) error }) (APIKey, error) {
+	var k APIKey
+	var topics string
+	var rotatedAt, lastUsedAt, previousExpiresAt sql.NullString
+	if err := row.Scan(&k.ID, &k.Name, &topics, &k.Prefix, &k.CreatedAt, &rotatedAt, &lastUsedAt, &previousExpiresAt); err != nil {
+		return k, err
+	}
+	if err := json.Unmarshal([]byte(topics), &k.Topics); err != nil {
+		return k, fmt.Errorf("invalid stored topics of API key %s: %w", k.ID, err)
+	}
+	k.RotatedAt, k.LastUsedAt, k.PreviousExpiresAt = rotatedAt.String, lastUsedAt.String, previousExpiresAt.String
+	return k, nil
+}
+
+// CreateAPIKey stores a new key with the hash of its secret
+func (db *DB) CreateAPIKey(ctx context.Context, key APIKey, secretHash string) error {
+	topics, err := json.Marshal(key.Topics)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, topics, prefix, secret_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, key.ID, key.Name, string(topics), key.Prefix, secretHash, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store API key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns every key, oldest first, with the expiry of
+// previous secrets that expired before now (RFC 3339) left out
+func (db *DB) ListAPIKeys(ctx context.Context, now string) ([]APIKey, error) {
+	rows, err := db.query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		if k.PreviousExpiresAt <= now {
+			k.PreviousExpiresAt = ""
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// APIKeyBySecret returns the key of a secret hash: its current secret, or
+// the previous one until it expires (compared with now, RFC 3339); nil if
+// no key matches
+func (db *DB) APIKeyBySecret(ctx context.Context, secretHash, now string) (*APIKey, error) {
+	k, err := scanAPIKey(db.queryRow(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE secret_hash = ? OR (previous_hash = ? AND previous_expires_at > ?)
+	`, secretHash, secretHash, now))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.PreviousExpiresAt <= now {
+		k.PreviousExpiresAt = ""
+	}
+	return &k, nil
+}
+
+// APIKeyByID returns a key, with the expiry of a previous secret that
+// expired before now (RFC 3339) left out; nil if there is no key of that ID
+func (db *DB) APIKeyByID(ctx context.Context, id, now string) (*APIKey, error) {
+	k, err := scanAPIKey(db.queryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.PreviousExpiresAt <= now {
+		k.PreviousExpiresAt = ""
+	}
+	return &k, nil
+}
+
+// RotateAPIKey replaces the secret of a key. The replaced secret keeps
+// working until previousExpiresAt (RFC 3339); empty revokes it at once.
+// It returns the updated key, or nil if there is no key of that ID.
+func (db *DB) RotateAPIKey(ctx context.Context, id, secretHash, prefix, rotatedAt, previousExpiresAt string) (*APIKey, error) {
+	var expires any
+	if previousExpiresAt != "" {
+		expires = previousExpiresAt
+	}
+	res, err := db.conn.ExecContext(ctx, `
+		UPDATE api_keys SET previous_hash = secret_hash, previous_expires_at = ?,
+			secret_hash = ?, prefix = ?, rotated_at = ?
+		WHERE id = ?
+	`, expires, secretHash, prefix, rotatedAt, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	k, err := scanAPIKey(db.queryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// DeleteAPIKey revokes a key and its secrets, reporting whether it existed
+func (db *DB) DeleteAPIKey(ctx context.Context, id string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, "DELETE FROM api_keys WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete API key: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TouchAPIKey records when a key was last used
+func (db *DB) TouchAPIKey(ctx context.Context, id, usedAt string) error {
+	_, err := db.conn.ExecContext(ctx, "UPDATE api_keys SET last_used_at = ? WHERE id = ?", usedAt, id)
+	return err
+}
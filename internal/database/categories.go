@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Category is a Compendium directory described by its index.md: a landing
+// page with a description, an ordering among its siblings and highlighted
+// articles
+type Category struct {
+	Path        string   `json:"path"` // Directory relative to the Compendium root; "" is the root
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Order       int      `json:"order"`                // Position among sibling categories, then by title
+	Highlights  []string `json:"highlights,omitempty"` // IDs of curated articles, in order
+	Content     string   `json:"content,omitempty"`    // Markdown body of index.md
+}
+
+// CategoryParent returns the path of a category's parent, and false for the
+// root
+func CategoryParent(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i], true
+	}
+	return "", true
+}
+
+// Category returns the category (directory) of the article: the "category"
+// frontmatter field if set, otherwise the directory part of its path
+func (a *Article) Category() string {
+	if category, ok := a.Meta["category"].(string); ok && category != "" {
+		return category
+	}
+	if i := strings.LastIndex(a.Path, "/"); i >= 0 {
+		return a.Path[:i]
+	}
+	return ""
+}
+
+// InsertCategories inserts or updates categories in a single transaction
+func (db *DB) InsertCategories(ctx context.Context, cats []Category) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, cat := range cats {
+			if err := writeCategory(ctx, tx, cat); err != nil {
+				return fmt.Errorf("category %q: %w", cat.Path, err)
+			}
+			if err := appendEvent(ctx, tx, EventCategoryUpserted, cat.Path, cat); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeCategory writes a category row
+func writeCategory(ctx context.Context, tx *sql.Tx, cat Category) error {
+	highlightsJSON, _ := json.Marshal(cat.Highlights)
+	var parent any
+	if p, ok := CategoryParent(cat.Path); ok {
+		parent = p
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO categories (path, parent, title, description, sort_order, highlights, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, cat.Path, parent, cat.Title, cat.Description, cat.Order, string(highlightsJSON), cat.Content)
+	if err != nil {
+		return fmt.Errorf("failed to insert category: %w", err)
+	}
+	return nil
+}
+
+// GetCategory retrieves a category by path, or nil if it has no index.md
+func (db *DB) GetCategory(ctx context.Context, path string) (*Category, error) {
+	var cat Category
+	var highlightsJSON string
+	err := db.queryRow(ctx, `
+		SELECT path, title, description, sort_order, highlights, content
+		FROM categories WHERE path = ?
+	`, path).Scan(&cat.Path, &cat.Title, &cat.Description, &cat.Order, &highlightsJSON, &cat.Content)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if highlightsJSON != "" {
+		json.Unmarshal([]byte(highlightsJSON), &cat.Highlights)
+	}
+	return &cat, nil
+}
+
+// Subcategories returns the categories directly below path, in their order.
+// Content is not returned.
+func (db *DB) Subcategories(ctx context.Context, path string) ([]Category, error) {
+	rows, err := db.query(ctx, `
+		SELECT path, title, description, sort_order, highlights
+		FROM categories WHERE parent = ?
+		ORDER BY sort_order, title
+	`, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cats []Category
+	for rows.Next() {
+		var cat Category
+		var highlightsJSON string
+		if err := rows.Scan(&cat.Path, &cat.Title, &cat.Description, &cat.Order, &highlightsJSON); err != nil {
+			return nil, err
+		}
+		if highlightsJSON != "" {
+			json.Unmarshal([]byte(highlightsJSON), &cat.Highlights)
+		}
+		cats = append(cats, cat)
+	}
+	return cats, rows.Err()
+}
+
+// ArticlesByCategory returns up to limit articles of a category, by title.
+// Content and curated sources are not returned.
+func (db *DB) ArticlesByCategory(ctx context.Context, path string, limit int) ([]Article, error) {
+	rows, err := db.query(ctx, `
+		SELECT id, title, path, author, summary, tags, meta_json,
+			COALESCE(word_count, 0), COALESCE(reading_minutes, 0)
+		FROM articles WHERE category = ?
+		ORDER BY title LIMIT ?
+	`, path, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var art Article
+		var tagsJSON, metaJSON string
+		if err := rows.Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON,
+			&art.WordCount, &art.ReadingMinutes); err != nil {
+			return nil, err
+		}
+		if tagsJSON != "" {
+			json.Unmarshal([]byte(tagsJSON), &art.Tags)
+		}
+		if metaJSON != "" {
+			json.Unmarshal([]byte(metaJSON), &art.Meta)
+		}
+		articles = append(articles, art)
+	}
+	return articles, rows.Err()
+}
+
+// backfillArticleCategories sets the category of articles written before
+// the column existed
+func (db *DB) backfillArticleCategories() error {
+	return db.backfill("SELECT id, path, COALESCE(meta_json, '') FROM articles WHERE category IS NULL",
+		"UPDATE articles SET category = ? WHERE id = ?",
+		func(id string, cols []string) [][]any {
+			art := Article{ID: id, Path: cols[0]}
+			if cols[1] != "" {
+				json.Unmarshal([]byte(cols[1]), &art.Meta)
+			}
+			return [][]any{{art.Category(), id}}
+		})
+}
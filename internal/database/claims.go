@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ClaimSet holds the claims extracted from a source summary by a generation
+// model. SummaryHash identifies the summary they were extracted from, so
+// claims of a summary edited since are found stale.
+type ClaimSet struct {
+	SourceID    string   `json:"source_id"`
+	Model       string   `json:"model"`
+	SummaryHash string   `json:"summary_hash"`
+	ExtractedAt string   `json:"extracted_at,omitempty"`
+	Claims      []string `json:"claims"` // In the order of the summary
+}
+
+// SummaryHash returns the hash of a summary recorded with its claims
+func SummaryHash(summary string) string {
+	sum := sha256.Sum256([]byte(summary))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ReplaceClaims stores the claims of a source, replacing those extracted
+// before. A set without claims is kept, so a summary that states nothing
+// checkable is not sent to the model again.
+func (db *DB) ReplaceClaims(ctx context.Context, set ClaimSet) error {
+	if set.ExtractedAt == "" {
+		set.ExtractedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := writeClaims(ctx, tx, set); err != nil {
+			return err
+		}
+		return appendEvent(ctx, tx, EventClaimsExtracted, set.SourceID, set)
+	})
+}
+
+// writeClaims replaces the claims of a source within a transaction
+func writeClaims(ctx context.Context, tx *sql.Tx, set ClaimSet) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_claims WHERE source_id = ?", set.SourceID); err != nil {
+		return fmt.Errorf("failed to delete claims: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO claim_extractions (source_id, model, summary_hash, claims, extracted_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, set.SourceID, set.Model, set.SummaryHash, len(set.Claims), set.ExtractedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record claim extraction: %w", err)
+	}
+	for i, text := range set.Claims {
+		_, err := tx.ExecContext(ctx, "INSERT INTO source_claims (source_id, position, text) VALUES (?, ?, ?)",
+			set.SourceID, i, text)
+		if err != nil {
+			return fmt.Errorf("failed to insert claim: %w", err)
+		}
+	}
+	return nil
+}
+
+// SourceClaims returns the claims of a source, or nil if none were
+// extracted
+func (db *DB) SourceClaims(ctx context.Context, sourceID string) (*ClaimSet, error) {
+	set := ClaimSet{SourceID: sourceID, Claims: []string{}}
+	err := db.queryRow(ctx, `
+		SELECT model, summary_hash, extracted_at FROM claim_extractions WHERE source_id = ?
+	`, sourceID).Scan(&set.Model, &set.SummaryHash, &set.ExtractedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.query(ctx, "SELECT text FROM source_claims WHERE source_id = ? ORDER BY position", sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, err
+		}
+		set.Claims = append(set.Claims, text)
+	}
+	return &set, rows.Err()
+}
+
+// ClaimSummaryHashes returns the summary hash recorded with the claims of
+// every source that has them, keyed by source ID
+func (db *DB) ClaimSummaryHashes(ctx context.Context) (map[string]string, error) {
+	rows, err := db.query(ctx, "SELECT source_id, summary_hash FROM claim_extractions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
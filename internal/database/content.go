@@ -0,0 +1,305 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/content"
+)
+
+// infoContentStore is the db_info key of the location of the full texts
+// (see content.Open); absent or "sqlite" when the FTS indexes hold them
+const infoContentStore = "content_store"
+
+// ftsColumns are the columns of the FTS indexes holding the full texts
+var ftsColumns = map[string]string{
+	"source_fts":  "summary, title, topic, content, id UNINDEXED",
+	"article_fts": "content, title, summary, tags, id UNINDEXED",
+}
+
+// contentlessFTS are the options of the FTS indexes when the texts live in
+// a content store: the index keeps its terms but not the texts, while rows
+// can still be deleted and their IDs read
+const contentlessFTS = "content='', contentless_delete=1, contentless_unindexed=1"
+
+// loadContentStore opens the content store recorded in the database
+func (db *DB) loadContentStore() error {
+	var location string
+	err := db.conn.QueryRow("SELECT value FROM db_info WHERE key = ?", infoContentStore).Scan(&location)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	store, err := content.Open(location)
+	if err != nil {
+		return err
+	}
+	db.content, db.contentLocation = store, location
+	return nil
+}
+
+// ContentLocation returns where the full texts of sources and articles
+// live: "sqlite" or the location of a content store
+func (db *DB) ContentLocation() string {
+	if db.content == nil {
+		return content.SQLite
+	}
+	return db.contentLocation
+}
+
+// sourceText reads the full text of a source, or "" if it has none
+func (db *DB) sourceText(ctx context.Context, q queryRower, id string) (string, error) {
+	if db.content != nil {
+		return db.content.Get(ctx, content.SourceKey(id))
+	}
+	var text sql.NullString
+	err := q.QueryRowContext(ctx, "SELECT content FROM source_fts WHERE id = ?", id).Scan(&text)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return text.String, nil
+}
+
+// articleText reads the markdown body of an article, or "" if it has none
+func (db *DB) articleText(ctx context.Context, q queryRower, id string) (string, error) {
+	if db.content != nil {
+		return db.content.Get(ctx, content.ArticleKey(id))
+	}
+	var text sql.NullString
+	err := q.QueryRowContext(ctx, "SELECT content FROM article_fts WHERE id = ?", id).Scan(&text)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return text.String, nil
+}
+
+// storeText writes a text to the content store, deleting it when empty.
+// With the SQLite layout the FTS index holds the text and nothing is done.
+// The store isn't transactional: a text written by a transaction that
+// rolls back stays until the entity is written again.
+func (db *DB) storeText(ctx context.Context, key, text string) error {
+	if db.content == nil {
+		return nil
+	}
+	if text == "" {
+		return db.content.Delete(ctx, key)
+	}
+	return db.content.Put(ctx, key, text)
+}
+
+// fillSnippets builds the snippets of articles found by a search in Go,
+// from their texts, when the FTS index doesn't hold the texts to build
+// them from
+func (db *DB) fillSnippets(ctx context.Context, articles []Article, groups [][]string) error {
+	if db.content == nil {
+		return nil
+	}
+	for i, a := range articles {
+		text, err := db.articleText(ctx, db.conn, a.ID)
+		if err != nil {
+			return err
+		}
+		articles[i].Snippet = Snippet(groups, text, a.Title, a.Summary, strings.Join(a.Tags, " "))
+	}
+	return nil
+}
+
+// ContentMove reports a move of the full texts to another layout
+type ContentMove struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Sources  int    `json:"sources"`
+	Articles int    `json:"articles"`
+	Bytes    int64  `json:"bytes"` // Total size of the texts moved
+}
+
+// contentMoveBatch is how many rows a content move reads at a time
+const contentMoveBatch = 500
+
+// MoveContent moves the full texts of every source and article to the
+// layout at location (see content.Open; "sqlite" for the FTS indexes). The
+// FTS indexes are rebuilt in one transaction, with the texts when they move
+// into SQLite and without them otherwise, and the new layout is recorded in
+// the database. Texts are copied, not moved: a store left behind keeps its
+// copies, which can be deleted once the move is done. Nothing else may
+// write to the database during the move.
+func (db *DB) MoveContent(ctx context.Context, location string) (*ContentMove, error) {
+	if location == "" {
+		location = content.SQLite
+	}
+	move := &ContentMove{From: db.ContentLocation(), To: location}
+	if move.From == move.To {
+		return nil, fmt.Errorf("the texts are already in %s", location)
+	}
+	target, err := content.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	options := ""
+	if target != nil {
+		options = ", " + contentlessFTS
+	}
+
+	err = db.withTx(ctx, func(tx *sql.Tx) error {
+		// FTS5 tables can't be altered, so the indexes are rebuilt. Indexes
+		// holding the texts are set aside to read them from; contentless
+		// ones are dropped, along with the shadow table of their IDs, which
+		// SQLite neither renames nor drops with them. The vocabulary reads
+		// the article index by name and is recreated.
+		cmds := []string{"DROP TABLE article_vocab"}
+		for _, table := range []string{"source_fts", "article_fts"} {
+			if db.content == nil {
+				cmds = append(cmds, fmt.Sprintf("ALTER TABLE %s RENAME TO %s_old", table, table))
+			} else {
+				cmds = append(cmds, "DROP TABLE "+table, "DROP TABLE IF EXISTS "+table+"_content")
+			}
+			cmds = append(cmds, fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(%s%s)", table, ftsColumns[table], options))
+		}
+		for _, cmd := range cmds {
+			if _, err := tx.ExecContext(ctx, cmd); err != nil {
+				return fmt.Errorf("failed to replace the FTS indexes: %w", err)
+			}
+		}
+
+		if err := db.moveSourceTexts(ctx, tx, target, move); err != nil {
+			return err
+		}
+		if err := db.moveArticleTexts(ctx, tx, target, move); err != nil {
+			return err
+		}
+
+		cmds = []string{"CREATE VIRTUAL TABLE article_vocab USING fts5vocab(article_fts, 'row')"}
+		if db.content == nil {
+			cmds = append(cmds, "DROP TABLE source_fts_old", "DROP TABLE article_fts_old")
+		}
+		for _, cmd := range cmds {
+			if _, err := tx.ExecContext(ctx, cmd); err != nil {
+				return fmt.Errorf("failed to replace the FTS indexes: %w", err)
+			}
+		}
+		_, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO db_info (key, value) VALUES (?, ?)", infoContentStore, location)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.content, db.contentLocation = target, location
+	db.resetDictionary()
+	return move, nil
+}
+
+// movedText reads a text from the layout being left: the content store, or
+// the FTS index set aside as table
+func (db *DB) movedText(ctx context.Context, tx *sql.Tx, table, key, id string) (string, error) {
+	if db.content != nil {
+		return db.content.Get(ctx, key)
+	}
+	var text sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT content FROM "+table+" WHERE id = ?", id).Scan(&text)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return text.String, nil
+}
+
+// moveSourceTexts copies the texts of every source, deleted ones included,
+// to target and indexes them in the new source_fts
+func (db *DB) moveSourceTexts(ctx context.Context, tx *sql.Tx, target content.Store, move *ContentMove) error {
+	for after := ""; ; {
+		var batch []Source
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, COALESCE(summary, ''), COALESCE(title, ''), COALESCE(topic, '') FROM sources
+			WHERE id > ? ORDER BY id LIMIT ?
+		`, after, contentMoveBatch)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var src Source
+			if err := rows.Scan(&src.ID, &src.Summary, &src.Title, &src.Topic); err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, src)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil || len(batch) == 0 {
+			return err
+		}
+
+		for _, src := range batch {
+			text, err := db.movedText(ctx, tx, "source_fts_old", content.SourceKey(src.ID), src.ID)
+			if err != nil {
+				return fmt.Errorf("source %s: %w", src.ID, err)
+			}
+			if target != nil && text != "" {
+				if err := target.Put(ctx, content.SourceKey(src.ID), text); err != nil {
+					return err
+				}
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO source_fts (id, summary, title, topic, content)
+				VALUES (?, ?, ?, ?, ?)
+			`, src.ID, src.Summary, src.Title, src.Topic, text)
+			if err != nil {
+				return fmt.Errorf("failed to index source %s: %w", src.ID, err)
+			}
+			move.Sources++
+			move.Bytes += int64(len(text))
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+// moveArticleTexts copies the texts of every article to target and
+// indexes them in the new article_fts
+func (db *DB) moveArticleTexts(ctx context.Context, tx *sql.Tx, target content.Store, move *ContentMove) error {
+	for after := ""; ; {
+		var batch []Article
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, COALESCE(title, ''), COALESCE(summary, ''), COALESCE(tags, '') FROM articles
+			WHERE id > ? ORDER BY id LIMIT ?
+		`, after, contentMoveBatch)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var art Article
+			var tagsJSON string
+			if err := rows.Scan(&art.ID, &art.Title, &art.Summary, &tagsJSON); err != nil {
+				rows.Close()
+				return err
+			}
+			json.Unmarshal([]byte(tagsJSON), &art.Tags)
+			batch = append(batch, art)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil || len(batch) == 0 {
+			return err
+		}
+
+		for _, art := range batch {
+			text, err := db.movedText(ctx, tx, "article_fts_old", content.ArticleKey(art.ID), art.ID)
+			if err != nil {
+				return fmt.Errorf("article %s: %w", art.ID, err)
+			}
+			if target != nil && text != "" {
+				if err := target.Put(ctx, content.ArticleKey(art.ID), text); err != nil {
+					return err
+				}
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO article_fts (id, content, title, summary, tags)
+				VALUES (?, ?, ?, ?, ?)
+			`, art.ID, text, art.Title, art.Summary, strings.Join(art.Tags, " "))
+			if err != nil {
+				return fmt.Errorf("failed to index article %s: %w", art.ID, err)
+			}
+			move.Articles++
+			move.Bytes += int64(len(text))
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// SourceCursor is the position of the last source of a listing page. The
+// next page lists the sources after it in the listing's order (keyset
+// pagination), so sources written in between don't shift the pages.
+type SourceCursor struct {
+	Order       string `json:"o,omitempty"` // SourceOrderPublished, or empty for SourceOrderCreated
+	PublishedAt string `json:"p,omitempty"`
+	CreatedAt   string `json:"c"`
+	ID          string `json:"i"`
+}
+
+// NewSourceCursor returns the position of src in a listing of the given order
+func NewSourceCursor(src Source, order string) SourceCursor {
+	c := SourceCursor{CreatedAt: src.CreatedAt, ID: src.ID}
+	if order == SourceOrderPublished {
+		c.Order, c.PublishedAt = order, src.PublishedAt
+	}
+	return c
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c SourceCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseSourceCursor decodes a cursor returned by Encode
+func ParseSourceCursor(s string) (SourceCursor, error) {
+	var c SourceCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.CreatedAt == "" || c.ID == "" || !ValidSourceOrder(c.Order) {
+		return SourceCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// Follows reports whether src comes after the cursor in its order: newest
+// first, then by descending ID; by publication, undated sources last
+func (c SourceCursor) Follows(src Source) bool {
+	if c.Order == SourceOrderPublished && src.PublishedAt != c.PublishedAt {
+		if c.PublishedAt == "" {
+			return false
+		}
+		return src.PublishedAt == "" || src.PublishedAt < c.PublishedAt
+	}
+	if src.CreatedAt != c.CreatedAt {
+		return src.CreatedAt < c.CreatedAt
+	}
+	return src.ID < c.ID
+}
+
+// after returns the SQL predicate and arguments selecting the sources that
+// come after the cursor, as Follows does
+func (c SourceCursor) after() (string, []any) {
+	const created = "(created_at, id) < (?, ?)"
+	if c.Order != SourceOrderPublished {
+		return created, []any{c.CreatedAt, c.ID}
+	}
+	if c.PublishedAt == "" {
+		return "(published_at IS NULL AND " + created + ")", []any{c.CreatedAt, c.ID}
+	}
+	return "(published_at < ? OR published_at IS NULL OR (published_at = ? AND " + created + "))",
+		[]any{c.PublishedAt, c.PublishedAt, c.CreatedAt, c.ID}
+}
@@ -2,12 +2,20 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gitopedia/knowledge-base/internal/content"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
 	_ "modernc.org/sqlite"
 )
 
@@ -15,31 +23,339 @@ import (
 type DB struct {
 	conn *sql.DB
 	path string
+
+	// writeMu serializes write transactions, keeping the event log's hash
+	// chain linear
+	writeMu sync.Mutex
+
+	// stmts caches prepared statements by query text
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+
+	// dict caches the search dictionary; nil until loaded and after changes
+	dictMu sync.Mutex
+	dict   *Dictionary
+
+	// content holds the full texts when they live outside the FTS indexes
+	// (see MoveContent); nil for the SQLite layout
+	content         content.Store
+	contentLocation string
+}
+
+// Source visibility levels
+const (
+	VisibilityPublic   = "public"   // Visible to everyone
+	VisibilityInternal = "internal" // Visible to identified callers
+	VisibilityPrivate  = "private"  // Visible to the owner only
+)
+
+// Source content types
+const (
+	SourceTypePaper   = "paper"
+	SourceTypeBlog    = "blog"
+	SourceTypeVideo   = "video"
+	SourceTypeDataset = "dataset"
+)
+
+// SourceTypes lists the known source content types
+var SourceTypes = []string{SourceTypePaper, SourceTypeBlog, SourceTypeVideo, SourceTypeDataset}
+
+// NormalizeSourceType lowercases and trims a source type
+func NormalizeSourceType(t string) string {
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+// ValidSourceType reports whether t is a known (normalized) source type;
+// empty means untyped
+func ValidSourceType(t string) bool {
+	if t == "" {
+		return true
+	}
+	for _, known := range SourceTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
 }
 
 // Source represents a source document in the database
 type Source struct {
-	ID        string   `json:"id"`
-	URL       string   `json:"url"`
-	Title     string   `json:"title"`
-	Topic     string   `json:"topic"`
-	Summary   string   `json:"summary"`
-	Language  string   `json:"language,omitempty"`
-	Model     string   `json:"model,omitempty"`
-	CreatedAt string   `json:"created_at"`
-	Tags      []string `json:"tags,omitempty"`
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	Topic     string `json:"topic"`
+	Summary   string `json:"summary"`
+	Language  string `json:"language,omitempty"`
+	Model     string `json:"model,omitempty"`
+	CreatedAt string `json:"created_at"` // When the source was ingested
+	// When the source itself was published (RFC 3339, see
+	// NormalizePublishedAt), from its frontmatter or publication metadata
+	PublishedAt string   `json:"published_at,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Visibility  string   `json:"visibility,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Domain      string   `json:"domain,omitempty"` // Derived from URL when the source is written
+	Type        string   `json:"type,omitempty"`   // paper, blog, video, or dataset
+	People      []string `json:"people,omitempty"`
+	Orgs        []string `json:"orgs,omitempty"`
+	Places      []string `json:"places,omitempty"`
+	// Positions of the places, for geo-radius search
+	Locations []Location `json:"locations,omitempty"`
+	// Outbound links of the body; links in the summary are added when the
+	// source is written
+	Links []SourceLink `json:"links,omitempty"`
+	// Canonical metadata for sources whose URL has a DOI or arXiv ID
+	Publication *Publication `json:"publication,omitempty"`
+	// Style profile the summary was written with, as resolved when the
+	// source was summarized, so the summary can be regenerated the same way
+	SummaryStyle *summarystyle.Profile `json:"summary_style,omitempty"`
+	// Full text of the page, for keyword search. It is kept when the source
+	// is written without one; GetSource leaves it out (see SourceContent).
+	Content string `json:"content,omitempty"`
+
+	// Derived from the summary when the source is written
+	WordCount      int `json:"word_count,omitempty"`
+	ReadingMinutes int `json:"reading_minutes,omitempty"`
+}
+
+// SourceFilter narrows a source listing; empty fields match everything
+type SourceFilter struct {
+	Topic  string
+	Domain string // Normalized with NormalizeDomain
+	Type   string // Normalized with NormalizeSourceType
+	// Only sources linking to code repositories or datasets
+	HasCode    bool
+	HasDataset bool
+	// Publication time bounds (RFC 3339): at or after PublishedAfter and
+	// before PublishedBefore. Sources without a publication date never match.
+	PublishedAfter  string
+	PublishedBefore string
+	// Ingestion time bounds (RFC 3339): at or after CreatedAfter and before
+	// CreatedBefore
+	CreatedAfter  string
+	CreatedBefore string
+	// Order of listings: SourceOrderCreated (newest first, the default) or
+	// SourceOrderPublished (most recently published first)
+	Order string
+	// Listings only: the sources after the cursor, in the same Order
+	After *SourceCursor
+}
+
+// Orders of source listings
+const (
+	SourceOrderCreated   = "created"
+	SourceOrderPublished = "published"
+)
+
+// ValidSourceOrder reports whether order is a listing order; empty selects
+// the default
+func ValidSourceOrder(order string) bool {
+	return order == "" || order == SourceOrderCreated || order == SourceOrderPublished
+}
+
+// orderBy returns the ORDER BY clause of the filter's listing order
+func (f SourceFilter) orderBy() string {
+	if f.Order == SourceOrderPublished {
+		// NULLs sort last in descending order, so undated sources come last
+		return "published_at DESC, created_at DESC, id DESC"
+	}
+	return "created_at DESC, id DESC"
+}
+
+// where returns the SQL predicate and arguments selecting the sources that
+// match the filter and are visible to user
+func (f SourceFilter) where(user string) (string, []any) {
+	var where []string
+	var args []any
+	if f.Topic != "" {
+		where = append(where, "topic = ?")
+		args = append(args, f.Topic)
+	}
+	if f.Domain != "" {
+		where = append(where, "domain = ?")
+		args = append(args, f.Domain)
+	}
+	if f.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, f.Type)
+	}
+	if f.HasCode {
+		where = append(where, "id IN (SELECT source_id FROM source_links WHERE kind = ?)")
+		args = append(args, LinkCode)
+	}
+	if f.HasDataset {
+		where = append(where, "id IN (SELECT source_id FROM source_links WHERE kind = ?)")
+		args = append(args, LinkDataset)
+	}
+	if f.PublishedAfter != "" {
+		where = append(where, "published_at >= ?")
+		args = append(args, f.PublishedAfter)
+	}
+	if f.PublishedBefore != "" {
+		where = append(where, "published_at < ?")
+		args = append(args, f.PublishedBefore)
+	}
+	if f.CreatedAfter != "" {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.CreatedAfter)
+	}
+	if f.CreatedBefore != "" {
+		where = append(where, "created_at < ?")
+		args = append(args, f.CreatedBefore)
+	}
+	where = append(where, visibleTo)
+	args = append(args, user, user)
+	return strings.Join(where, " AND "), args
+}
+
+// URLDomain returns the normalized host of a URL, or "" if it has none
+func URLDomain(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return NormalizeDomain(u.Hostname())
+}
+
+// NormalizeDomain lowercases a host name and strips a trailing dot and a
+// leading "www.", so www.arxiv.org and arxiv.org are the same domain
+func NormalizeDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	return strings.TrimPrefix(host, "www.")
+}
+
+// trackingParams are query parameters that identify a campaign or click
+// rather than a page
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true,
+	"ref": true, "ref_src": true, "igshid": true, "_hsenc": true, "_hsmi": true,
+}
+
+// CanonicalURL normalizes a URL so that links to the same page compare
+// equal: the scheme is dropped, the domain normalized (see NormalizeDomain),
+// the fragment, tracking parameters (utm_*, fbclid, ...) and a trailing
+// slash removed, and the remaining parameters sorted. URLs that can't be
+// parsed are returned trimmed.
+func CanonicalURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	canonical := NormalizeDomain(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		canonical += ":" + port
+	}
+	canonical += strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		canonical += "?" + query.Encode() // Encode sorts by key
+	}
+	return canonical
+}
+
+// VisibleTo reports whether the source may be shown to the given caller
+// (empty for anonymous callers)
+func (s *Source) VisibleTo(user string) bool {
+	switch s.Visibility {
+	case VisibilityInternal:
+		return user != ""
+	case VisibilityPrivate:
+		return user != "" && s.Owner == user
+	default:
+		return true
+	}
+}
+
+// ValidVisibility reports whether v is a known visibility level (empty means public)
+func ValidVisibility(v string) bool {
+	switch v {
+	case "", VisibilityPublic, VisibilityInternal, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// sourceColumns is the column list scanned by scanSource
+const sourceColumns = `id, url, title, topic, summary, language, model, created_at, tags,
+	visibility, COALESCE(owner, ''), COALESCE(domain, ''),
+	COALESCE(word_count, 0), COALESCE(reading_minutes, 0), COALESCE(type, ''), COALESCE(publication, ''),
+	COALESCE(locations, ''), COALESCE(published_at, ''), COALESCE(summary_style, '')`
+
+// visibleTo is the SQL predicate restricting sources to those visible to the
+// caller bound to its two parameters; deleted sources are visible to nobody
+const visibleTo = `(deleted_at IS NULL AND (visibility = 'public'
+	OR (visibility = 'internal' AND ? != '')
+	OR (visibility = 'private' AND owner = ?)))`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSource scans a row selected with sourceColumns
+func scanSource(row rowScanner) (Source, error) {
+	var src Source
+	var tagsJSON, publicationJSON, locationsJSON, styleJSON string
+	if err := row.Scan(&src.ID, &src.URL, &src.Title, &src.Topic, &src.Summary,
+		&src.Language, &src.Model, &src.CreatedAt, &tagsJSON,
+		&src.Visibility, &src.Owner, &src.Domain,
+		&src.WordCount, &src.ReadingMinutes, &src.Type, &publicationJSON,
+		&locationsJSON, &src.PublishedAt, &styleJSON); err != nil {
+		return src, err
+	}
+	if tagsJSON != "" {
+		json.Unmarshal([]byte(tagsJSON), &src.Tags)
+	}
+	if publicationJSON != "" {
+		json.Unmarshal([]byte(publicationJSON), &src.Publication)
+	}
+	if locationsJSON != "" {
+		json.Unmarshal([]byte(locationsJSON), &src.Locations)
+	}
+	if styleJSON != "" {
+		json.Unmarshal([]byte(styleJSON), &src.SummaryStyle)
+	}
+	return src, nil
 }
 
 // Article represents an article in the database
 type Article struct {
-	ID       string                 `json:"id"`
-	Title    string                 `json:"title"`
-	Path     string                 `json:"path"`
-	Author   string                 `json:"author,omitempty"`
-	Summary  string                 `json:"summary"`
-	Tags     []string               `json:"tags"`
-	Meta     map[string]interface{} `json:"meta,omitempty"`
-	Content  string                 `json:"content,omitempty"` // Full body text for FTS
+	ID      string                 `json:"id"`
+	Title   string                 `json:"title"`
+	Path    string                 `json:"path"`
+	Author  string                 `json:"author,omitempty"`
+	Summary string                 `json:"summary"`
+	Tags    []string               `json:"tags"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Content string                 `json:"content,omitempty"` // Full body text for FTS
+	Sources []string               `json:"sources,omitempty"` // IDs of sources curated for the article
+
+	// Derived from the content when the article is written
+	WordCount      int `json:"word_count,omitempty"`
+	ReadingMinutes int `json:"reading_minutes,omitempty"`
+
+	// Snippet is an excerpt around the match of a keyword search, with the
+	// query terms between SnippetOpen and SnippetClose
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Topic returns the topic slug that sources use to refer to the article
+// (their related_article): the slug of the "topic" frontmatter field if
+// set, otherwise of the file name without its extension (see slug.Make)
+func (a *Article) Topic() string {
+	if topic, ok := a.Meta["topic"].(string); ok && slug.Make(topic) != "" {
+		return slug.Make(topic)
+	}
+	base := a.Path[strings.LastIndex(a.Path, "/")+1:]
+	return slug.Make(strings.TrimSuffix(base, filepath.Ext(base)))
 }
 
 // Open opens or creates a SQLite database at the given path
@@ -50,16 +366,22 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	conn, err := sql.Open("sqlite", path)
+	// Recursive triggers make INSERT OR REPLACE fire the delete triggers for
+	// the rows it replaces, which keeps the row_counts counters exact
+	conn, err := sql.Open("sqlite", path+"?_pragma=recursive_triggers(1)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db := &DB{conn: conn, path: path}
+	db := &DB{conn: conn, path: path, stmts: make(map[string]*sql.Stmt)}
 	if err := db.init(); err != nil {
 		conn.Close()
 		return nil, err
 	}
+	if err := db.loadContentStore(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open content store: %w", err)
+	}
 
 	return db, nil
 }
@@ -90,6 +412,10 @@ func (db *DB) init() error {
 			id UNINDEXED
 		);`,
 
+		// Terms of the article index and how many articles contain them,
+		// for spelling suggestions
+		`CREATE VIRTUAL TABLE IF NOT EXISTS article_vocab USING fts5vocab(article_fts, 'row');`,
+
 		// Sources table (new)
 		`CREATE TABLE IF NOT EXISTS sources (
 			id TEXT PRIMARY KEY,
@@ -108,11 +434,11 @@ func (db *DB) init() error {
 			summary,
 			title,
 			topic,
+			content,
 			id UNINDEXED
 		);`,
 
 		// Indexes
-		`CREATE INDEX IF NOT EXISTS idx_sources_topic ON sources(topic);`,
 		`CREATE INDEX IF NOT EXISTS idx_sources_url ON sources(url);`,
 		`CREATE INDEX IF NOT EXISTS idx_articles_path ON articles(path);`,
 
@@ -121,6 +447,315 @@ func (db *DB) init() error {
 			key TEXT PRIMARY KEY,
 			value TEXT
 		);`,
+
+		// Append-only, hash-chained log of every knowledge-base mutation
+		`CREATE TABLE IF NOT EXISTS events (
+			seq INTEGER PRIMARY KEY,
+			type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			data TEXT,
+			created_at TEXT NOT NULL,
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS events_no_update BEFORE UPDATE ON events
+		BEGIN SELECT RAISE(ABORT, 'events are append-only'); END;`,
+		`CREATE TRIGGER IF NOT EXISTS events_no_delete BEFORE DELETE ON events
+		BEGIN SELECT RAISE(ABORT, 'events are append-only'); END;`,
+		`CREATE INDEX IF NOT EXISTS idx_events_entity ON events(entity_id, seq);`,
+
+		// Row counters maintained by triggers, so /health doesn't scan tables
+		`CREATE TABLE IF NOT EXISTS row_counts (
+			tbl TEXT PRIMARY KEY,
+			count INTEGER NOT NULL,
+			updated_at TEXT NOT NULL
+		);`,
+
+		// Sources curated for an article, keyed by the article's topic slug
+		`CREATE TABLE IF NOT EXISTS article_sources (
+			article_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			PRIMARY KEY (article_id, source_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_article_sources_topic ON article_sources(topic, source_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_article_sources_source ON article_sources(source_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_article_sources_topic_article ON article_sources(topic, article_id);`,
+
+		// Sources proposed for articles by the auto-linking job
+		`CREATE TABLE IF NOT EXISTS link_suggestions (
+			article_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			score REAL NOT NULL,
+			status TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			reviewed_at TEXT,
+			PRIMARY KEY (article_id, source_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_link_suggestions_status ON link_suggestions(status, score DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_link_suggestions_source ON link_suggestions(source_id);`,
+
+		// Entity index: people, organizations and places mentioned by sources
+		`CREATE TABLE IF NOT EXISTS source_entities (
+			source_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL,
+			canonical TEXT NOT NULL,
+			canonical_key TEXT NOT NULL,
+			PRIMARY KEY (source_id, kind, name)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_source_entities_key ON source_entities(kind, canonical_key);`,
+
+		// Tag index: one row per tag of a source or article, mirroring their
+		// tags columns
+		`CREATE TABLE IF NOT EXISTS source_tags (
+			source_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (source_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_source_tags_tag ON source_tags(tag);`,
+		`CREATE TABLE IF NOT EXISTS article_tags (
+			article_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (article_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_article_tags_tag ON article_tags(tag);`,
+
+		// Claims extracted from source summaries by cmd/claims, and the
+		// model and summary they were extracted from
+		`CREATE TABLE IF NOT EXISTS claim_extractions (
+			source_id TEXT PRIMARY KEY,
+			model TEXT NOT NULL,
+			summary_hash TEXT NOT NULL,
+			claims INTEGER NOT NULL,
+			extracted_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS source_claims (
+			source_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			PRIMARY KEY (source_id, position)
+		);`,
+
+		// Topic reports generated by cmd/report, by topic and period
+		`CREATE TABLE IF NOT EXISTS topic_reports (
+			topic TEXT NOT NULL,
+			period_start TEXT NOT NULL,
+			period_end TEXT NOT NULL,
+			generated_at TEXT NOT NULL,
+			report_json TEXT NOT NULL,
+			PRIMARY KEY (topic, period_start)
+		);`,
+
+		// Outbound links of sources, typed (code, dataset, doi, other)
+		`CREATE TABLE IF NOT EXISTS source_links (
+			source_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			PRIMARY KEY (source_id, url)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_source_links_kind ON source_links(kind, source_id);`,
+
+		// Curated spelling variants of entities and their canonical names
+		`CREATE TABLE IF NOT EXISTS entity_aliases (
+			kind TEXT NOT NULL,
+			alias_key TEXT NOT NULL,
+			alias TEXT NOT NULL,
+			canonical TEXT NOT NULL,
+			canonical_key TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (kind, alias_key)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_entity_aliases_canonical ON entity_aliases(kind, canonical_key);`,
+
+		// Retired source/article IDs and the IDs that replaced them
+		`CREATE TABLE IF NOT EXISTS aliases (
+			kind TEXT NOT NULL,
+			old_id TEXT NOT NULL,
+			new_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (kind, old_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_aliases_new ON aliases(kind, new_id);`,
+
+		// Category landing pages, from the index.md of Compendium directories
+		`CREATE TABLE IF NOT EXISTS categories (
+			path TEXT PRIMARY KEY,
+			parent TEXT,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			highlights TEXT,
+			content TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent, sort_order, title);`,
+
+		// Topic taxonomy: descriptions, hierarchy and aliases of source
+		// topics
+		`CREATE TABLE IF NOT EXISTS topics (
+			slug TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			parent TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_topics_parent ON topics(parent);`,
+		`CREATE TABLE IF NOT EXISTS topic_aliases (
+			alias TEXT PRIMARY KEY,
+			slug TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_topic_aliases_slug ON topic_aliases(slug);`,
+
+		// Per-user interest profiles (one embedding per user and model)
+		`CREATE TABLE IF NOT EXISTS user_profiles (
+			user_id TEXT NOT NULL,
+			model TEXT NOT NULL,
+			embedding BLOB,
+			interactions INTEGER NOT NULL DEFAULT 0,
+			updated_at TEXT,
+			PRIMARY KEY (user_id, model)
+		);`,
+
+		// Per-user reading queues of sources, with their status
+		// (to-read, reading, done) and when it last changed
+		`CREATE TABLE IF NOT EXISTS reading_queue (
+			user_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			added_at TEXT NOT NULL,
+			started_at TEXT,
+			done_at TEXT,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, source_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_reading_queue_source ON reading_queue(source_id);`,
+
+		// Per-user watches: an embedded description of an interest that new
+		// sources are compared with, and the sources that matched it
+		`CREATE TABLE IF NOT EXISTS watches (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			text TEXT NOT NULL,
+			model TEXT NOT NULL,
+			embedding BLOB NOT NULL,
+			threshold REAL NOT NULL,
+			topic TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL DEFAULT '',
+			secret TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			cursor INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_watches_user ON watches(user_id);`,
+		`CREATE TABLE IF NOT EXISTS watch_matches (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			watch_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			score REAL NOT NULL,
+			matched_at TEXT NOT NULL,
+			delivered_at TEXT,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_attempt_at TEXT,
+			last_error TEXT,
+			UNIQUE (watch_id, source_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_watch_matches_user ON watch_matches(user_id, seq);`,
+		`CREATE INDEX IF NOT EXISTS idx_watch_matches_source ON watch_matches(source_id);`,
+
+		// When sources were last hit, and which are cold: their vectors
+		// dropped from Qdrant until they are hit again
+		`CREATE TABLE IF NOT EXISTS source_access (
+			source_id TEXT PRIMARY KEY,
+			last_hit_at TEXT,
+			cold_at TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_source_access_cold ON source_access(cold_at) WHERE cold_at IS NOT NULL;`,
+
+		// Synonyms and stopwords applied to keyword queries
+		`CREATE TABLE IF NOT EXISTS search_synonyms (
+			term TEXT PRIMARY KEY,
+			expansions TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS search_stopwords (
+			word TEXT PRIMARY KEY
+		);`,
+
+		// Topic-scoped API keys of automated clients, by the SHA-256 of
+		// their secret (and of the secret replaced by the last rotation)
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			topics TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			secret_hash TEXT NOT NULL UNIQUE,
+			previous_hash TEXT,
+			previous_expires_at TEXT,
+			created_at TEXT NOT NULL,
+			rotated_at TEXT,
+			last_used_at TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_previous ON api_keys(previous_hash);`,
+
+		// Endpoints receiving signed event log deliveries, with the
+		// sequence number of the last event each has handled
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			events TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			cursor INTEGER NOT NULL DEFAULT 0,
+			last_delivery_at TEXT,
+			last_status INTEGER,
+			last_error TEXT,
+			failures INTEGER NOT NULL DEFAULT 0
+		);`,
+
+		// State of the markdown files indexed by cmd/indexer, so unchanged
+		// files are skipped on the next run
+		`CREATE TABLE IF NOT EXISTS indexed_files (
+			path TEXT PRIMARY KEY,
+			article_id TEXT NOT NULL,
+			mod_time INTEGER NOT NULL,
+			size INTEGER NOT NULL,
+			hash TEXT NOT NULL,
+			embedding_text TEXT NOT NULL DEFAULT ''
+		);`,
+
+		// Previous versions of sources and articles, numbered from 1 for
+		// the oldest, as JSON objects of their tracked fields (see
+		// SourceRevisionFields and ArticleRevisionFields)
+		`CREATE TABLE IF NOT EXISTS source_revisions (
+			source_id TEXT NOT NULL,
+			revision INTEGER NOT NULL,
+			fields TEXT NOT NULL,
+			replaced_at TEXT NOT NULL,
+			PRIMARY KEY (source_id, revision)
+		);`,
+		`CREATE TABLE IF NOT EXISTS article_revisions (
+			article_id TEXT NOT NULL,
+			revision INTEGER NOT NULL,
+			fields TEXT NOT NULL,
+			replaced_at TEXT NOT NULL,
+			PRIMARY KEY (article_id, revision)
+		);`,
+
+		// Source writes held while the server protects its stores (see
+		// QueuedWrite), replayed in ID order
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			content_type TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT '',
+			api_key TEXT,
+			body BLOB,
+			queued_at TEXT NOT NULL
+		);`,
 	}
 
 	for _, cmd := range cmds {
@@ -129,72 +764,568 @@ func (db *DB) init() error {
 		}
 	}
 
-	return nil
+	return db.migrate()
+}
+
+// migrate adds columns introduced after the initial schema, so databases
+// built by older versions keep working, then creates indexes on them
+func (db *DB) migrate() error {
+	columns := []struct {
+		table, column, decl string
+	}{
+		{"sources", "visibility", "TEXT NOT NULL DEFAULT 'public'"},
+		{"sources", "owner", "TEXT"},
+		{"sources", "domain", "TEXT"},
+		{"sources", "word_count", "INTEGER"},
+		{"sources", "reading_minutes", "INTEGER"},
+		{"sources", "type", "TEXT"},
+		{"sources", "publication", "TEXT"}, // JSON, see Publication
+		{"sources", "locations", "TEXT"},   // JSON, see Location
+		{"sources", "published_at", "TEXT"},
+		{"sources", "canonical_url", "TEXT"}, // see CanonicalURL
+		{"sources", "summary_style", "TEXT"}, // JSON, see summarystyle.Profile
+		{"sources", "deleted_at", "TEXT"},    // Soft deletion, see DeleteSource
+		{"articles", "word_count", "INTEGER"},
+		{"articles", "reading_minutes", "INTEGER"},
+		{"articles", "category", "TEXT"},
+		// frontmatter or suggestion (an approved link suggestion)
+		{"article_sources", "origin", "TEXT NOT NULL DEFAULT 'frontmatter'"},
+	}
+
+	for _, c := range columns {
+		if err := db.addColumn(c.table, c.column, c.decl); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_sources_visibility ON sources(visibility, owner);`,
+		// Topic listings filter by topic and return the newest first, paged
+		// by (created_at, id) (see SourceCursor)
+		`CREATE INDEX IF NOT EXISTS idx_sources_topic_created_id ON sources(topic, created_at DESC, id DESC);`,
+		`DROP INDEX IF EXISTS idx_sources_topic;`,
+		`DROP INDEX IF EXISTS idx_sources_topic_created;`,
+		// Domain listings ("everything from arxiv.org") also return the newest first
+		`CREATE INDEX IF NOT EXISTS idx_sources_domain_created_id ON sources(domain, created_at DESC, id DESC);`,
+		`DROP INDEX IF EXISTS idx_sources_domain_created;`,
+		`CREATE INDEX IF NOT EXISTS idx_sources_type_created_id ON sources(type, created_at DESC, id DESC);`,
+		`DROP INDEX IF EXISTS idx_sources_type_created;`,
+		// "Recently published" listings and publication date filters
+		`CREATE INDEX IF NOT EXISTS idx_sources_published ON sources(published_at DESC);`,
+		// Ingestion matches new sources against existing ones by canonical URL
+		`CREATE INDEX IF NOT EXISTS idx_sources_canonical_url ON sources(canonical_url);`,
+		// The purge job finds the sources deleted before its cutoff
+		`CREATE INDEX IF NOT EXISTS idx_sources_deleted ON sources(deleted_at) WHERE deleted_at IS NOT NULL;`,
+		// Category pages list their articles by title
+		`CREATE INDEX IF NOT EXISTS idx_articles_category_title ON articles(category, title);`,
+	}
+
+	for _, cmd := range indexes {
+		if _, err := db.conn.Exec(cmd); err != nil {
+			return fmt.Errorf("failed to execute '%s': %w", cmd[:min(50, len(cmd))], err)
+		}
+	}
+
+	if err := db.migrateSourceFTS(); err != nil {
+		return fmt.Errorf("failed to add content to the source index: %w", err)
+	}
+	if err := db.backfillDomains(); err != nil {
+		return fmt.Errorf("failed to backfill source domains: %w", err)
+	}
+	if err := db.backfillCanonicalURLs(); err != nil {
+		return fmt.Errorf("failed to backfill canonical URLs: %w", err)
+	}
+	if err := db.backfillWordCounts(); err != nil {
+		return fmt.Errorf("failed to backfill word counts: %w", err)
+	}
+	if err := db.backfillArticleCategories(); err != nil {
+		return fmt.Errorf("failed to backfill article categories: %w", err)
+	}
+	if err := db.backfillSourceLinks(); err != nil {
+		return fmt.Errorf("failed to backfill source links: %w", err)
+	}
+	if err := db.backfillPublishedAt(); err != nil {
+		return fmt.Errorf("failed to backfill publication dates: %w", err)
+	}
+	if err := db.backfillTags(); err != nil {
+		return fmt.Errorf("failed to backfill tag index: %w", err)
+	}
+
+	return db.initRowCounts()
+}
+
+// countedTables are the tables whose row counts are kept in row_counts,
+// with the column set on soft-deleted rows, which aren't counted
+var countedTables = []struct{ table, trashed string }{
+	{"sources", "deleted_at"},
+	{"articles", ""},
+}
+
+// initRowCounts installs the counter triggers and seeds the counters of
+// tables that have none yet (new tables, or databases from older versions).
+// Counters of versions that counted trashed sources are seeded again.
+func (db *DB) initRowCounts() error {
+	const now = `strftime('%Y-%m-%dT%H:%M:%fZ', 'now')`
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range countedTables {
+		insertWhen, deleteWhen, where := "", "", ""
+		var cmds []string
+		if c.trashed != "" {
+			var triggers int
+			err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?", c.table+"_count_trash").Scan(&triggers)
+			if err != nil {
+				return err
+			}
+			if triggers == 0 {
+				cmds = append(cmds,
+					fmt.Sprintf(`DROP TRIGGER IF EXISTS %s_count_insert;`, c.table),
+					fmt.Sprintf(`DROP TRIGGER IF EXISTS %s_count_delete;`, c.table),
+					fmt.Sprintf(`DELETE FROM row_counts WHERE tbl = '%s';`, c.table))
+			}
+			insertWhen = fmt.Sprintf(" WHEN NEW.%s IS NULL", c.trashed)
+			deleteWhen = fmt.Sprintf(" WHEN OLD.%s IS NULL", c.trashed)
+			where = fmt.Sprintf(" WHERE %s IS NULL", c.trashed)
+			cmds = append(cmds,
+				fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_count_trash AFTER UPDATE OF %[3]s ON %[1]s
+				WHEN OLD.%[3]s IS NULL AND NEW.%[3]s IS NOT NULL
+				BEGIN UPDATE row_counts SET count = count - 1, updated_at = %[2]s WHERE tbl = '%[1]s'; END;`, c.table, now, c.trashed),
+				fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_count_restore AFTER UPDATE OF %[3]s ON %[1]s
+				WHEN OLD.%[3]s IS NOT NULL AND NEW.%[3]s IS NULL
+				BEGIN UPDATE row_counts SET count = count + 1, updated_at = %[2]s WHERE tbl = '%[1]s'; END;`, c.table, now, c.trashed))
+		}
+		cmds = append(cmds,
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_count_insert AFTER INSERT ON %[1]s%[3]s
+			BEGIN UPDATE row_counts SET count = count + 1, updated_at = %[2]s WHERE tbl = '%[1]s'; END;`, c.table, now, insertWhen),
+			fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_count_delete AFTER DELETE ON %[1]s%[3]s
+			BEGIN UPDATE row_counts SET count = count - 1, updated_at = %[2]s WHERE tbl = '%[1]s'; END;`, c.table, now, deleteWhen),
+			fmt.Sprintf(`INSERT OR IGNORE INTO row_counts (tbl, count, updated_at)
+			SELECT '%[1]s', COUNT(*), %[2]s FROM %[1]s%[3]s;`, c.table, now, where))
+		for _, cmd := range cmds {
+			if _, err := tx.Exec(cmd); err != nil {
+				return fmt.Errorf("failed to set up row count for %s: %w", c.table, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateSourceFTS recreates the source FTS index of databases built before
+// it had a content column, re-indexing every source without content. FTS5
+// tables can't be altered.
+func (db *DB) migrateSourceFTS() error {
+	rows, err := db.conn.Query("PRAGMA table_info(source_fts)")
+	if err != nil {
+		return err
+	}
+	hasContent := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		hasContent = hasContent || name == "content"
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || hasContent {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	cmds := []string{
+		`DROP TABLE source_fts;`,
+		`CREATE VIRTUAL TABLE source_fts USING fts5(
+			summary,
+			title,
+			topic,
+			content,
+			id UNINDEXED
+		);`,
+		`INSERT INTO source_fts (id, summary, title, topic, content)
+		SELECT id, COALESCE(summary, ''), COALESCE(title, ''), COALESCE(topic, ''), '' FROM sources;`,
+	}
+	for _, cmd := range cmds {
+		if _, err := tx.Exec(cmd); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// backfill fills derived columns of rows written before the columns
+// existed. query selects the ID of each row to fill and the columns its
+// values are computed from; values returns the arguments of stmt for the
+// row, one list per execution, or none to leave it as is. All rows are
+// written in one transaction, so an interrupted backfill leaves the table
+// as it was and is run again at the next startup.
+func (db *DB) backfill(query, stmt string, values func(id string, cols []string) [][]any) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return err
+	}
+	var args [][]any
+	for rows.Next() {
+		cols := make([]sql.NullString, len(columns))
+		dest := make([]any, len(cols))
+		for i := range cols {
+			dest[i] = &cols[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		strs := make([]string, len(cols)-1)
+		for i, col := range cols[1:] {
+			strs[i] = col.String
+		}
+		args = append(args, values(cols[0].String, strs)...)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	prepared, err := tx.Prepare(stmt)
+	if err != nil {
+		return err
+	}
+	defer prepared.Close()
+	for _, a := range args {
+		if _, err := prepared.Exec(a...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// backfillDomains sets the domain of sources written before the column
+// existed. Domains are derived data, so no events are recorded.
+func (db *DB) backfillDomains() error {
+	return db.backfill("SELECT id, url FROM sources WHERE domain IS NULL",
+		"UPDATE sources SET domain = ? WHERE id = ?",
+		func(id string, cols []string) [][]any {
+			return [][]any{{URLDomain(cols[0]), id}}
+		})
+}
+
+// backfillCanonicalURLs sets the canonical URL of sources written before
+// the column existed. Like domains, they are derived from the URL, so no
+// events are recorded.
+func (db *DB) backfillCanonicalURLs() error {
+	return db.backfill("SELECT id, url FROM sources WHERE canonical_url IS NULL",
+		"UPDATE sources SET canonical_url = ? WHERE id = ?",
+		func(id string, cols []string) [][]any {
+			return [][]any{{CanonicalURL(cols[0]), id}}
+		})
+}
+
+// backfillPublishedAt sets the publication date of sources written before
+// the column existed from their publication metadata. The date is derived
+// from the recorded publication, so no events are recorded.
+func (db *DB) backfillPublishedAt() error {
+	return db.backfill("SELECT id, publication FROM sources WHERE published_at IS NULL AND publication IS NOT NULL",
+		"UPDATE sources SET published_at = ? WHERE id = ?",
+		func(id string, cols []string) [][]any {
+			var pub Publication
+			if json.Unmarshal([]byte(cols[0]), &pub) != nil {
+				return nil
+			}
+			published, ok := NormalizePublishedAt(pub.Published)
+			if !ok || published == "" {
+				return nil
+			}
+			return [][]any{{published, id}}
+		})
+}
+
+// backfillWordCounts sets the word counts and reading times of sources and
+// articles written before the columns existed. Article text is read from the
+// FTS index: databases that old have the SQLite layout (see MoveContent).
+func (db *DB) backfillWordCounts() error {
+	queries := map[string]string{
+		"sources":  "SELECT id, COALESCE(summary, '') FROM sources WHERE word_count IS NULL",
+		"articles": "SELECT a.id, COALESCE(f.content, '') FROM articles a LEFT JOIN article_fts f ON f.id = a.id WHERE a.word_count IS NULL",
+	}
+	for table, query := range queries {
+		err := db.backfill(query, fmt.Sprintf("UPDATE %s SET word_count = ?, reading_minutes = ? WHERE id = ?", table),
+			func(id string, cols []string) [][]any {
+				words := WordCount(cols[0])
+				return [][]any{{words, ReadingMinutes(words), id}}
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to a table unless it already exists
+func (db *DB) addColumn(table, column, decl string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
+	return err
+}
+
+// Ping checks that the database answers queries
+func (db *DB) Ping(ctx context.Context) error {
+	var one int
+	return db.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Vacuum rebuilds the database file, returning the space of deleted data
+// to the file system
+func (db *DB) Vacuum(ctx context.Context) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+	_, err := db.conn.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// Close closes the cached statements and the database connection
+func (db *DB) Close() error {
+	db.stmtMu.Lock()
+	for _, stmt := range db.stmts {
+		stmt.Close()
+	}
+	db.stmts = nil
+	db.stmtMu.Unlock()
+
+	return db.conn.Close()
+}
+
+// prepared returns a cached prepared statement for query, preparing it on
+// first use. Statements are prepared on the pool and reused across calls.
+func (db *DB) prepared(query string) (*sql.Stmt, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+	if db.stmts == nil {
+		return nil, fmt.Errorf("database is closed")
+	}
+	stmt, err := db.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
+
+// queryRow runs a single-row query through the statement cache
+func (db *DB) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	stmt, err := db.prepared(query)
+	if err != nil {
+		// Surface the prepare error through Row.Scan
+		return db.conn.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// query runs a query through the statement cache
+func (db *DB) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := db.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// withTx runs fn in a write transaction, committing if it returns nil
+func (db *DB) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InsertSource inserts a new source into the database
+func (db *DB) InsertSource(ctx context.Context, src Source) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		return db.insertSource(ctx, tx, src)
+	})
 }
 
-// Close closes the database connection
-func (db *DB) Close() error {
-	return db.conn.Close()
+// InsertSources inserts or updates sources in a single transaction. Either
+// all of them are stored or none are.
+func (db *DB) InsertSources(ctx context.Context, srcs []Source) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, src := range srcs {
+			if err := db.insertSource(ctx, tx, src); err != nil {
+				return fmt.Errorf("source %s: %w", src.ID, err)
+			}
+		}
+		return nil
+	})
 }
 
-// InsertSource inserts a new source into the database
-func (db *DB) InsertSource(src Source) error {
+func (db *DB) insertSource(ctx context.Context, tx *sql.Tx, src Source) error {
+	if err := reviseSource(ctx, tx, src, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if err := db.writeSource(ctx, tx, src); err != nil {
+		return err
+	}
+	return appendEvent(ctx, tx, EventSourceUpserted, src.ID, src)
+}
+
+// writeSource writes a source row, its FTS entry and its full text
+func (db *DB) writeSource(ctx context.Context, tx *sql.Tx, src Source) error {
 	tagsJSON, _ := json.Marshal(src.Tags)
+	words := WordCount(src.Summary)
+	if src.Visibility == "" {
+		src.Visibility = VisibilityPublic
+	}
+	var publicationJSON any
+	if src.Publication != nil {
+		data, _ := json.Marshal(src.Publication)
+		publicationJSON = string(data)
+	}
+	var locationsJSON any
+	if len(src.Locations) > 0 {
+		data, _ := json.Marshal(src.Locations)
+		locationsJSON = string(data)
+	}
+	var styleJSON any
+	if src.SummaryStyle != nil {
+		data, _ := json.Marshal(src.SummaryStyle)
+		styleJSON = string(data)
+	}
 
-	_, err := db.conn.Exec(`
-		INSERT OR REPLACE INTO sources (id, url, title, topic, summary, language, model, created_at, tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, src.ID, src.URL, src.Title, src.Topic, src.Summary, src.Language, src.Model, src.CreatedAt, string(tagsJSON))
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO sources (id, url, title, topic, summary, language, model, created_at, tags, visibility, owner,
+			domain, word_count, reading_minutes, type, publication, locations, published_at, canonical_url,
+			summary_style)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, src.ID, src.URL, src.Title, src.Topic, src.Summary, src.Language, src.Model, src.CreatedAt, string(tagsJSON),
+		src.Visibility, src.Owner, URLDomain(src.URL), words, ReadingMinutes(words), nullIfEmpty(NormalizeSourceType(src.Type)),
+		publicationJSON, locationsJSON, nullIfEmpty(src.PublishedAt), CanonicalURL(src.URL),
+		styleJSON)
 	if err != nil {
 		return fmt.Errorf("failed to insert source: %w", err)
 	}
 
-	// Update FTS index
-	_, err = db.conn.Exec(`
-		INSERT OR REPLACE INTO source_fts (id, summary, title, topic)
-		VALUES (?, ?, ?, ?)
-	`, src.ID, src.Summary, src.Title, src.Topic)
+	// Keep the stored content when the source is written without one (e.g.
+	// by an update), re-indexing it
+	text := src.Content
+	if text == "" {
+		if text, err = db.sourceText(ctx, tx, src.ID); err != nil {
+			return fmt.Errorf("failed to read source content: %w", err)
+		}
+	} else if err := db.storeText(ctx, content.SourceKey(src.ID), text); err != nil {
+		return err
+	}
+
+	// Update FTS index (FTS5 has no unique key, so replace by hand)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_fts WHERE id = ?", src.ID); err != nil {
+		return fmt.Errorf("failed to update source FTS: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO source_fts (id, summary, title, topic, content)
+		VALUES (?, ?, ?, ?, ?)
+	`, src.ID, src.Summary, src.Title, src.Topic, text)
 	if err != nil {
 		return fmt.Errorf("failed to update source FTS: %w", err)
 	}
 
-	return nil
+	if err := writeSourceLinks(ctx, tx, src); err != nil {
+		return err
+	}
+	if err := writeSourceTags(ctx, tx, src); err != nil {
+		return err
+	}
+	return writeSourceEntities(ctx, tx, src)
 }
 
-// GetSource retrieves a source by ID
-func (db *DB) GetSource(id string) (*Source, error) {
-	var src Source
-	var tagsJSON string
+// GetSource retrieves a source by ID; deleted sources are left out
+func (db *DB) GetSource(ctx context.Context, id string) (*Source, error) {
+	return db.getSource(ctx, "deleted_at IS NULL", id)
+}
 
-	err := db.conn.QueryRow(`
-		SELECT id, url, title, topic, summary, language, model, created_at, tags
-		FROM sources WHERE id = ?
-	`, id).Scan(&src.ID, &src.URL, &src.Title, &src.Topic, &src.Summary,
-		&src.Language, &src.Model, &src.CreatedAt, &tagsJSON)
+// getSource retrieves a source by ID if it matches the condition
+func (db *DB) getSource(ctx context.Context, cond, id string) (*Source, error) {
+	src, err := scanSource(db.queryRow(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE id = ? AND `+cond+`
+	`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	if tagsJSON != "" {
-		json.Unmarshal([]byte(tagsJSON), &src.Tags)
+	if err := db.loadSourceEntities(ctx, &src); err != nil {
+		return nil, err
+	}
+	if err := db.loadSourceLinks(ctx, &src); err != nil {
+		return nil, err
 	}
 
 	return &src, nil
 }
 
 // GetSourceByURL retrieves a source by URL
-func (db *DB) GetSourceByURL(url string) (*Source, error) {
-	var src Source
-	var tagsJSON string
-
-	err := db.conn.QueryRow(`
-		SELECT id, url, title, topic, summary, language, model, created_at, tags
-		FROM sources WHERE url = ?
-	`, url).Scan(&src.ID, &src.URL, &src.Title, &src.Topic, &src.Summary,
-		&src.Language, &src.Model, &src.CreatedAt, &tagsJSON)
+func (db *DB) GetSourceByURL(ctx context.Context, url string) (*Source, error) {
+	src, err := scanSource(db.queryRow(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE url = ? AND deleted_at IS NULL
+	`, url))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -202,103 +1333,274 @@ func (db *DB) GetSourceByURL(url string) (*Source, error) {
 		return nil, err
 	}
 
-	if tagsJSON != "" {
-		json.Unmarshal([]byte(tagsJSON), &src.Tags)
+	return &src, nil
+}
+
+// GetSourceByCanonicalURL retrieves a source whose URL has the same
+// canonical form (see CanonicalURL) as rawURL, or nil if there is none
+func (db *DB) GetSourceByCanonicalURL(ctx context.Context, rawURL string) (*Source, error) {
+	src, err := scanSource(db.queryRow(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE canonical_url = ? AND deleted_at IS NULL LIMIT 1
+	`, CanonicalURL(rawURL)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	return &src, nil
 }
 
-// GetSourcesByTopic retrieves all sources for a given topic
-func (db *DB) GetSourcesByTopic(topic string, limit int) ([]Source, error) {
-	rows, err := db.conn.Query(`
-		SELECT id, url, title, topic, summary, language, model, created_at, tags
-		FROM sources WHERE topic = ? LIMIT ?
-	`, topic, limit)
+// GetSourcesByTopic retrieves the sources for a given topic that are visible
+// to the caller (empty for anonymous callers), newest first
+func (db *DB) GetSourcesByTopic(ctx context.Context, topic string, limit int, user string) ([]Source, error) {
+	rows, err := db.query(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE topic = ? AND `+visibleTo+`
+		ORDER BY created_at DESC LIMIT ?
+	`, topic, user, user, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var sources []Source
+	return scanSources(rows)
+}
+
+// ListSources retrieves the sources matching filter that are visible to the
+// caller (empty for anonymous callers) in the filter's order, newest first
+// by default
+func (db *DB) ListSources(ctx context.Context, filter SourceFilter, limit int, user string) ([]Source, error) {
+	where, args := filter.where(user)
+	if filter.After != nil {
+		after, afterArgs := filter.After.after()
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+	rows, err := db.query(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE `+where+`
+		ORDER BY `+filter.orderBy()+` LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSources(rows)
+}
+
+// CountSourcesByType counts the sources matching filter that are visible to
+// the caller, by type. Untyped sources are not counted.
+func (db *DB) CountSourcesByType(ctx context.Context, filter SourceFilter, user string) (map[string]int, error) {
+	where, args := filter.where(user)
+	rows, err := db.query(ctx, `
+		SELECT type, COUNT(*) FROM sources
+		WHERE type IS NOT NULL AND `+where+`
+		GROUP BY type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
 	for rows.Next() {
-		var src Source
-		var tagsJSON string
-		if err := rows.Scan(&src.ID, &src.URL, &src.Title, &src.Topic, &src.Summary,
-			&src.Language, &src.Model, &src.CreatedAt, &tagsJSON); err != nil {
+		var t string
+		var n int
+		if err := rows.Scan(&t, &n); err != nil {
 			return nil, err
 		}
-		if tagsJSON != "" {
-			json.Unmarshal([]byte(tagsJSON), &src.Tags)
-		}
-		sources = append(sources, src)
+		counts[t] = n
 	}
-
-	return sources, rows.Err()
+	return counts, rows.Err()
 }
 
-// SearchSources performs a full-text search on sources
-func (db *DB) SearchSources(query string, limit int) ([]Source, error) {
-	rows, err := db.conn.Query(`
-		SELECT s.id, s.url, s.title, s.topic, s.summary, s.language, s.model, s.created_at, s.tags
-		FROM sources s
-		JOIN source_fts f ON s.id = f.id
-		WHERE source_fts MATCH ?
-		ORDER BY rank
+// SearchSources performs a full-text search on the sources visible to the
+// caller (empty for anonymous callers). The query is rewritten with the
+// search dictionary (see Dictionary.FTSQuery).
+func (db *DB) SearchSources(ctx context.Context, query string, limit int, user string) ([]Source, error) {
+	match, err := db.matchExpression(ctx, query)
+	if err != nil || match == "" {
+		return nil, err
+	}
+	rows, err := db.query(ctx, `
+		SELECT `+sourceColumns+`
+		FROM (SELECT id AS fts_id, rank FROM source_fts WHERE source_fts MATCH ?) f
+		JOIN sources ON sources.id = f.fts_id
+		WHERE `+visibleTo+`
+		ORDER BY f.rank
 		LIMIT ?
-	`, query, limit)
+	`, match, user, user, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanSources(rows)
+}
+
+// scanSources scans all rows selected with sourceColumns
+func scanSources(rows *sql.Rows) ([]Source, error) {
 	var sources []Source
 	for rows.Next() {
-		var src Source
-		var tagsJSON string
-		if err := rows.Scan(&src.ID, &src.URL, &src.Title, &src.Topic, &src.Summary,
-			&src.Language, &src.Model, &src.CreatedAt, &tagsJSON); err != nil {
+		src, err := scanSource(rows)
+		if err != nil {
 			return nil, err
 		}
-		if tagsJSON != "" {
-			json.Unmarshal([]byte(tagsJSON), &src.Tags)
-		}
 		sources = append(sources, src)
 	}
 
 	return sources, rows.Err()
 }
 
-// DeleteSource removes a source from the database
-func (db *DB) DeleteSource(id string) error {
-	_, err := db.conn.Exec("DELETE FROM sources WHERE id = ?", id)
+// removeSource deletes a source row, its FTS entry and its full text
+func (db *DB) removeSource(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM sources WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
-	_, err = db.conn.Exec("DELETE FROM source_fts WHERE id = ?", id)
-	return err
+	_, err = tx.ExecContext(ctx, "DELETE FROM source_fts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM source_entities WHERE source_id = ?", id)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM source_tags WHERE source_id = ?", id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM source_links WHERE source_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM link_suggestions WHERE source_id = ? AND status = 'pending'", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM source_claims WHERE source_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM claim_extractions WHERE source_id = ?", id)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM reading_queue WHERE source_id = ?", id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM watch_matches WHERE source_id = ?", id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM source_access WHERE source_id = ?", id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM source_revisions WHERE source_id = ?", id); err != nil {
+		return err
+	}
+	return db.storeText(ctx, content.SourceKey(id), "")
+}
+
+// SourceIDs returns the IDs of all sources, including the deleted ones not
+// purged yet, whose vectors are kept
+func (db *DB) SourceIDs(ctx context.Context) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT id FROM sources ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SourceIDsByTopic returns the IDs of every source of a topic, whatever its
+// visibility, in ID order
+func (db *DB) SourceIDsByTopic(ctx context.Context, topic string) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT id FROM sources WHERE topic = ? ORDER BY id", topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
 
-// CountSources returns the total number of sources
-func (db *DB) CountSources() (int, error) {
+// CountSources returns the total number of sources, deleted ones excepted
+func (db *DB) CountSources(ctx context.Context) (int, error) {
 	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM sources").Scan(&count)
+	err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sources WHERE deleted_at IS NULL").Scan(&count)
 	return count, err
 }
 
 // InsertArticle inserts or updates an article
-func (db *DB) InsertArticle(art Article) error {
+func (db *DB) InsertArticle(ctx context.Context, art Article) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		return db.insertArticle(ctx, tx, art)
+	})
+}
+
+// InsertArticles inserts or updates articles in a single transaction. Either
+// all of them are stored or none are.
+func (db *DB) InsertArticles(ctx context.Context, arts []Article) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, art := range arts {
+			if err := db.insertArticle(ctx, tx, art); err != nil {
+				return fmt.Errorf("article %s: %w", art.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (db *DB) insertArticle(ctx context.Context, tx *sql.Tx, art Article) error {
+	if err := db.reviseArticle(ctx, tx, art, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if err := db.writeArticle(ctx, tx, art); err != nil {
+		return err
+	}
+	return appendEvent(ctx, tx, EventArticleUpserted, art.ID, art)
+}
+
+// writeArticle writes an article row, its FTS entry and its body
+func (db *DB) writeArticle(ctx context.Context, tx *sql.Tx, art Article) error {
 	tagsJSON, _ := json.Marshal(art.Tags)
 	metaJSON, _ := json.Marshal(art.Meta)
 
-	_, err := db.conn.Exec(`
-		INSERT OR REPLACE INTO articles (id, title, path, author, summary, tags, meta_json)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, art.ID, art.Title, art.Path, art.Author, art.Summary, string(tagsJSON), string(metaJSON))
+	words := WordCount(art.Content)
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO articles (id, title, path, author, summary, tags, meta_json, word_count, reading_minutes, category)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, art.ID, art.Title, art.Path, art.Author, art.Summary, string(tagsJSON), string(metaJSON), words, ReadingMinutes(words),
+		art.Category())
 	if err != nil {
 		return fmt.Errorf("failed to insert article: %w", err)
 	}
 
+	if err := db.storeText(ctx, content.ArticleKey(art.ID), art.Content); err != nil {
+		return err
+	}
+
 	// Update FTS index
 	tagsStr := ""
 	for i, tag := range art.Tags {
@@ -308,26 +1610,98 @@ func (db *DB) InsertArticle(art Article) error {
 		tagsStr += tag
 	}
 
-	_, err = db.conn.Exec(`
-		INSERT OR REPLACE INTO article_fts (id, content, title, summary, tags)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_fts WHERE id = ?", art.ID); err != nil {
+		return fmt.Errorf("failed to update article FTS: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO article_fts (id, content, title, summary, tags)
 		VALUES (?, ?, ?, ?, ?)
 	`, art.ID, art.Content, art.Title, art.Summary, tagsStr)
 	if err != nil {
 		return fmt.Errorf("failed to update article FTS: %w", err)
 	}
 
+	if err := writeArticleTags(ctx, tx, art); err != nil {
+		return err
+	}
+
+	// Replace the article's curated source links, keeping approved link
+	// suggestions
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_sources WHERE article_id = ?", art.ID); err != nil {
+		return fmt.Errorf("failed to update article sources: %w", err)
+	}
+	topic := art.Topic()
+	for _, sourceID := range art.Sources {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO article_sources (article_id, source_id, topic)
+			VALUES (?, ?, ?)
+		`, art.ID, sourceID, topic)
+		if err != nil {
+			return fmt.Errorf("failed to update article sources: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO article_sources (article_id, source_id, topic, origin)
+		SELECT article_id, source_id, ?, 'suggestion' FROM link_suggestions
+		WHERE article_id = ? AND status = 'approved'
+	`, topic, art.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update article sources: %w", err)
+	}
+
 	return nil
 }
 
+// DeleteArticle removes an article, e.g. when its file was deleted
+func (db *DB) DeleteArticle(ctx context.Context, id string) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := db.removeArticle(ctx, tx, id); err != nil {
+			return err
+		}
+		return appendEvent(ctx, tx, EventArticleDeleted, id, nil)
+	})
+}
+
+// removeArticle deletes an article row, its FTS entry, its body and its
+// source links
+func (db *DB) removeArticle(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM articles WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_fts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_sources WHERE article_id = ?", id)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = ?", id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM link_suggestions WHERE article_id = ? AND status = 'pending'", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_revisions WHERE article_id = ?", id)
+	if err != nil {
+		return err
+	}
+	return db.storeText(ctx, content.ArticleKey(id), "")
+}
+
 // GetArticle retrieves an article by ID
-func (db *DB) GetArticle(id string) (*Article, error) {
+func (db *DB) GetArticle(ctx context.Context, id string) (*Article, error) {
 	var art Article
 	var tagsJSON, metaJSON string
 
-	err := db.conn.QueryRow(`
-		SELECT id, title, path, author, summary, tags, meta_json
+	err := db.queryRow(ctx, `
+		SELECT id, title, path, author, summary, tags, meta_json,
+			COALESCE(word_count, 0), COALESCE(reading_minutes, 0)
 		FROM articles WHERE id = ?
-	`, id).Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON)
+	`, id).Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON,
+		&art.WordCount, &art.ReadingMinutes)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -342,19 +1716,166 @@ func (db *DB) GetArticle(id string) (*Article, error) {
 		json.Unmarshal([]byte(metaJSON), &art.Meta)
 	}
 
+	rows, err := db.query(ctx, "SELECT source_id FROM article_sources WHERE article_id = ? ORDER BY source_id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sourceID string
+		if err := rows.Scan(&sourceID); err != nil {
+			return nil, err
+		}
+		art.Sources = append(art.Sources, sourceID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return &art, nil
 }
 
-// SearchArticles performs a full-text search on articles
-func (db *DB) SearchArticles(query string, limit int) ([]Article, error) {
-	rows, err := db.conn.Query(`
-		SELECT a.id, a.title, a.path, a.author, a.summary, a.tags, a.meta_json
+// ArticleContent returns the markdown body of an article, which GetArticle
+// leaves out, or "" if the article doesn't exist
+func (db *DB) ArticleContent(ctx context.Context, id string) (string, error) {
+	return db.articleText(ctx, db.conn, id)
+}
+
+// SourceContent returns the full text of a source, which GetSource leaves
+// out, or "" if the source has none or doesn't exist
+func (db *DB) SourceContent(ctx context.Context, id string) (string, error) {
+	return db.sourceText(ctx, db.conn, id)
+}
+
+// LinkedSourceIDs returns the IDs of the sources curated for the articles
+// with the given topic slug
+func (db *DB) LinkedSourceIDs(ctx context.Context, topic string) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT DISTINCT source_id FROM article_sources WHERE topic = ?", topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CitedSourceIDs returns the IDs of the sources curated for any article
+func (db *DB) CitedSourceIDs(ctx context.Context) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT DISTINCT source_id FROM article_sources ORDER BY source_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SearchArticles performs a full-text search on articles, tolerant of
+// inflections and typos. The query is rewritten with the search dictionary
+// and matches words by their stem (see Dictionary.TolerantFTSQuery). When
+// nothing matches, terms are widened to the vocabulary words sharing most of
+// their trigrams (see FuzzyTerms), and failing that, every term is looked
+// for as a substring of titles, summaries and tags. Queries with AND, OR or
+// NOT get no fallback, which would ignore the operators.
+func (db *DB) SearchArticles(ctx context.Context, query string, limit int) ([]Article, error) {
+	dict, err := db.SearchDictionary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search dictionary: %w", err)
+	}
+	match := dict.TolerantFTSQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	articles, err := db.searchArticles(ctx, `
+		SELECT `+articleColumns+`, `+snippetSQL+`
 		FROM articles a
 		JOIN article_fts f ON a.id = f.id
 		WHERE article_fts MATCH ?
 		ORDER BY rank
 		LIMIT ?
-	`, query, limit)
+	`, match, limit)
+	groups := dict.Groups(query)
+	if err == nil {
+		err = db.fillSnippets(ctx, articles, groups)
+	}
+	if err != nil || len(articles) > 0 || hasOperators(query) {
+		return articles, err
+	}
+
+	// Misspelled terms: search the words of the index resembling them
+	vocab, err := db.ArticleVocabulary(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if fuzzy, ok := FuzzyGroups(vocab, groups); ok {
+		articles, err = db.searchArticles(ctx, `
+			SELECT `+articleColumns+`, `+snippetSQL+`
+			FROM articles a
+			JOIN article_fts f ON a.id = f.id
+			WHERE article_fts MATCH ?
+			ORDER BY rank
+			LIMIT ?
+		`, groupsFTSQuery(fuzzy), limit)
+		if err == nil {
+			err = db.fillSnippets(ctx, articles, fuzzy)
+		}
+		if err != nil || len(articles) > 0 {
+			return articles, err
+		}
+	}
+
+	// Terms inside words (or across the tokenizer's word boundaries)
+	var where []string
+	var args []any
+	for _, group := range groups {
+		var alternatives []string
+		for _, term := range group {
+			pattern := "%" + likeEscaper.Replace(term) + "%"
+			alternatives = append(alternatives, `a.title LIKE ? ESCAPE '\' OR a.summary LIKE ? ESCAPE '\' OR a.tags LIKE ? ESCAPE '\'`)
+			args = append(args, pattern, pattern, pattern)
+		}
+		where = append(where, "("+strings.Join(alternatives, " OR ")+")")
+	}
+	articles, err = db.searchArticles(ctx, `
+		SELECT `+articleColumns+`, ''
+		FROM articles a
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY a.title
+		LIMIT ?
+	`, append(args, limit)...)
+	for i, a := range articles {
+		articles[i].Snippet = Snippet(groups, a.Title, a.Summary, strings.Join(a.Tags, " "))
+	}
+	return articles, err
+}
+
+// articleColumns are the columns of article search results, from articles a
+const articleColumns = `a.id, a.title, a.path, a.author, a.summary, a.tags, a.meta_json,
+			COALESCE(a.word_count, 0), COALESCE(a.reading_minutes, 0)`
+
+// likeEscaper escapes the wildcards of LIKE patterns (with ESCAPE '\')
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchArticles runs an article search selecting articleColumns and a
+// snippet
+func (db *DB) searchArticles(ctx context.Context, query string, args ...any) ([]Article, error) {
+	rows, err := db.query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +1885,8 @@ func (db *DB) SearchArticles(query string, limit int) ([]Article, error) {
 	for rows.Next() {
 		var art Article
 		var tagsJSON, metaJSON string
-		if err := rows.Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON); err != nil {
+		if err := rows.Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON,
+			&art.WordCount, &art.ReadingMinutes, &art.Snippet); err != nil {
 			return nil, err
 		}
 		if tagsJSON != "" {
@@ -379,33 +1901,68 @@ func (db *DB) SearchArticles(query string, limit int) ([]Article, error) {
 	return articles, rows.Err()
 }
 
+// RowCount is a cached table row count and when it last changed
+type RowCount struct {
+	Count     int    `json:"count"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// CachedCounts returns the trigger-maintained row counts of the sources and
+// articles tables, keyed by table name. Unlike CountSources and
+// CountArticles it doesn't scan the tables.
+func (db *DB) CachedCounts(ctx context.Context) (map[string]RowCount, error) {
+	rows, err := db.query(ctx, "SELECT tbl, count, updated_at FROM row_counts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]RowCount)
+	for rows.Next() {
+		var table string
+		var rc RowCount
+		if err := rows.Scan(&table, &rc.Count, &rc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		counts[table] = rc
+	}
+	return counts, rows.Err()
+}
+
 // CountArticles returns the total number of articles
-func (db *DB) CountArticles() (int, error) {
+func (db *DB) CountArticles(ctx context.Context) (int, error) {
 	var count int
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM articles").Scan(&count)
+	err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM articles").Scan(&count)
 	return count, err
 }
 
 // SetInfo stores a key-value pair in the db_info table
-func (db *DB) SetInfo(key, value string) error {
-	_, err := db.conn.Exec("INSERT OR REPLACE INTO db_info (key, value) VALUES (?, ?)", key, value)
+func (db *DB) SetInfo(ctx context.Context, key, value string) error {
+	_, err := db.conn.ExecContext(ctx, "INSERT OR REPLACE INTO db_info (key, value) VALUES (?, ?)", key, value)
 	return err
 }
 
 // GetInfo retrieves a value from the db_info table
-func (db *DB) GetInfo(key string) (string, error) {
+func (db *DB) GetInfo(ctx context.Context, key string) (string, error) {
 	var value string
-	err := db.conn.QueryRow("SELECT value FROM db_info WHERE key = ?", key).Scan(&value)
+	err := db.queryRow(ctx, "SELECT value FROM db_info WHERE key = ?", key).Scan(&value)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
 	return value, err
 }
 
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
 }
-
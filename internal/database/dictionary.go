@@ -0,0 +1,389 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dictionary holds the synonyms and stopwords applied to keyword queries
+type Dictionary struct {
+	Synonyms  map[string][]string `json:"synonyms"` // Term → terms also searched for it, e.g. "k8s" → ["kubernetes"]
+	Stopwords []string            `json:"stopwords"`
+}
+
+// queryToken is a term, quoted phrase or operator of a keyword query
+type queryToken struct {
+	text     string
+	phrase   bool // "quoted phrase"
+	prefix   bool // term*
+	operator bool // AND, OR, NOT
+}
+
+// key is the dictionary key of a token: the lowercased term or phrase
+func (t queryToken) key() string {
+	return NormalizeTerm(t.text)
+}
+
+// NormalizeTerm lowercases a term and strips surrounding punctuation, as
+// dictionary entries are stored
+func NormalizeTerm(term string) string {
+	return strings.ToLower(strings.TrimFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
+
+// tokenizeQuery splits a keyword query into terms, quoted phrases and the
+// FTS5 operators AND, OR and NOT. Terms without letters or digits are
+// dropped.
+func tokenizeQuery(query string) []queryToken {
+	var tokens []queryToken
+	for query != "" {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if query == "" {
+			break
+		}
+
+		var tok queryToken
+		if query[0] == '"' {
+			end := strings.IndexByte(query[1:], '"')
+			if end < 0 {
+				end = len(query) - 1
+			}
+			tok = queryToken{text: query[1 : end+1], phrase: true}
+			query = query[min(end+2, len(query)):]
+		} else {
+			end := strings.IndexFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(query)
+			}
+			tok = queryToken{text: query[:end]}
+			query = query[end:]
+			switch tok.text {
+			case "AND", "OR", "NOT":
+				tok.operator = true
+			default:
+				if strings.HasSuffix(tok.text, "*") {
+					tok.text = strings.TrimRight(tok.text, "*")
+					tok.prefix = true
+				}
+			}
+		}
+		if tok.operator || tok.key() != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// stopwords returns the stopword set, or nil if d is nil
+func (d *Dictionary) stopwords() map[string]bool {
+	if d == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(d.Stopwords))
+	for _, w := range d.Stopwords {
+		set[w] = true
+	}
+	return set
+}
+
+// expansions returns the synonyms of a token, or nil if d is nil
+func (d *Dictionary) expansions(tok queryToken) []string {
+	if d == nil || tok.prefix {
+		return nil
+	}
+	return d.Synonyms[tok.key()]
+}
+
+// terms returns the term and phrase tokens of a query, without stopwords
+// unless the query consists of nothing else
+func (d *Dictionary) terms(tokens []queryToken) []queryToken {
+	stop := d.stopwords()
+	var terms, all []queryToken
+	for _, tok := range tokens {
+		if tok.operator {
+			continue
+		}
+		all = append(all, tok)
+		if !stop[tok.key()] {
+			terms = append(terms, tok)
+		}
+	}
+	if len(terms) == 0 {
+		return all
+	}
+	return terms
+}
+
+// minPrefixRunes is the length of the shortest stem that tolerant queries
+// match by prefix; shorter prefixes match too many unrelated words
+const minPrefixRunes = 4
+
+// inflections are the English suffixes stripped from terms before prefix
+// matching, longest first
+var inflections = []string{"ations", "ation", "ings", "ing", "ies", "ied", "ers", "est", "er", "es", "ed", "ly", "s", "y", "e"}
+
+// Stem returns the prefix by which tolerant queries match a normalized
+// term: the term without an inflection suffix, so that "computing" also
+// finds "computer" and "computers". A suffix is only stripped if
+// minPrefixRunes runes remain, and terms shorter than that have no stem
+// ("").
+func Stem(term string) string {
+	if utf8.RuneCountInString(term) < minPrefixRunes {
+		return ""
+	}
+	for _, suffix := range inflections {
+		if stem, ok := strings.CutSuffix(term, suffix); ok && utf8.RuneCountInString(stem) >= minPrefixRunes {
+			return stem
+		}
+	}
+	return term
+}
+
+// FTSQuery builds the FTS5 MATCH expression of a keyword query. Terms are
+// quoted, so punctuation can't cause syntax errors; stopwords are dropped
+// (unless the query has nothing else) and terms with synonyms match any of
+// them. Quoted phrases, prefix terms (term*) and the AND, OR and NOT
+// operators keep their FTS5 meaning. d may be nil.
+func (d *Dictionary) FTSQuery(query string) string {
+	return d.ftsQuery(query, false)
+}
+
+// TolerantFTSQuery is FTSQuery with prefix matching: terms match every word
+// starting with their stem (see Stem), so inflections and words typed
+// halfway are found. Quoted phrases stay exact.
+func (d *Dictionary) TolerantFTSQuery(query string) string {
+	return d.ftsQuery(query, true)
+}
+
+func (d *Dictionary) ftsQuery(query string, tolerant bool) string {
+	tokens := tokenizeQuery(query)
+	keep := make(map[queryToken]bool)
+	for _, tok := range d.terms(tokens) {
+		keep[tok] = true
+	}
+
+	var parts []string
+	afterTerm := false // Operators must sit between two terms
+	for _, tok := range tokens {
+		if tok.operator {
+			if afterTerm {
+				parts = append(parts, tok.text)
+				afterTerm = false
+			}
+			continue
+		}
+		if !keep[tok] {
+			continue
+		}
+
+		expr := quoteFTS(tok.text)
+		if tok.prefix {
+			expr += "*"
+		} else if stem := Stem(tok.key()); tolerant && !tok.phrase && stem != "" {
+			expr = quoteFTS(stem) + "*"
+		}
+		if syns := d.expansions(tok); len(syns) > 0 {
+			alternatives := []string{expr}
+			for _, syn := range syns {
+				alternatives = append(alternatives, quoteFTS(syn))
+			}
+			expr = "(" + strings.Join(alternatives, " OR ") + ")"
+		}
+		if afterTerm {
+			// FTS5 only joins bare phrases implicitly, not parenthesized
+			// synonym groups
+			parts = append(parts, "AND")
+		}
+		parts = append(parts, expr)
+		afterTerm = true
+	}
+	if len(parts) > 0 && !afterTerm {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, " ")
+}
+
+// Groups returns the terms of a keyword query for stores without FTS: one
+// group per term, holding the lowercased term and its synonyms. Stopwords
+// are dropped as in FTSQuery; operators are ignored. d may be nil.
+func (d *Dictionary) Groups(query string) [][]string {
+	var groups [][]string
+	for _, tok := range d.terms(tokenizeQuery(query)) {
+		groups = append(groups, append([]string{tok.key()}, d.expansions(tok)...))
+	}
+	return groups
+}
+
+// TolerantGroups is Groups with every term but phrases replaced by its stem,
+// for stores matching terms as substrings: the stem matches whatever
+// TolerantFTSQuery finds by prefix
+func (d *Dictionary) TolerantGroups(query string) [][]string {
+	groups := d.Groups(query)
+	for _, group := range groups {
+		if stem := Stem(group[0]); stem != "" && !strings.Contains(group[0], " ") {
+			group[0] = stem
+		}
+	}
+	return groups
+}
+
+// hasOperators reports whether a keyword query uses AND, OR or NOT
+func hasOperators(query string) bool {
+	return slices.ContainsFunc(tokenizeQuery(query), func(tok queryToken) bool { return tok.operator })
+}
+
+// quoteFTS quotes a string as an FTS5 phrase
+func quoteFTS(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// NormalizeSynonyms normalizes the expansions of a term, dropping empty
+// ones, duplicates and the term itself
+func NormalizeSynonyms(term string, expansions []string) []string {
+	seen := map[string]bool{term: true}
+	var out []string
+	for _, e := range expansions {
+		e = strings.Join(strings.Fields(strings.ToLower(e)), " ")
+		if e != "" && !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SearchDictionary returns the synonyms and stopwords applied to keyword
+// queries
+func (db *DB) SearchDictionary(ctx context.Context) (*Dictionary, error) {
+	db.dictMu.Lock()
+	defer db.dictMu.Unlock()
+	if db.dict != nil {
+		return db.dict, nil
+	}
+
+	dict := &Dictionary{Synonyms: make(map[string][]string), Stopwords: []string{}}
+	rows, err := db.conn.QueryContext(ctx, "SELECT term, expansions FROM search_synonyms")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var term, expansionsJSON string
+		if err := rows.Scan(&term, &expansionsJSON); err != nil {
+			return nil, err
+		}
+		var expansions []string
+		json.Unmarshal([]byte(expansionsJSON), &expansions)
+		dict.Synonyms[term] = expansions
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	words, err := db.conn.QueryContext(ctx, "SELECT word FROM search_stopwords ORDER BY word")
+	if err != nil {
+		return nil, err
+	}
+	defer words.Close()
+	for words.Next() {
+		var word string
+		if err := words.Scan(&word); err != nil {
+			return nil, err
+		}
+		dict.Stopwords = append(dict.Stopwords, word)
+	}
+	if err := words.Err(); err != nil {
+		return nil, err
+	}
+
+	db.dict = dict
+	return dict, nil
+}
+
+// ArticleVocabulary returns the terms of the article index contained in at
+// least minDocs articles, with the number of articles containing them
+func (db *DB) ArticleVocabulary(ctx context.Context, minDocs int) (map[string]int, error) {
+	rows, err := db.conn.QueryContext(ctx, "SELECT term, doc FROM article_vocab WHERE doc >= ?", minDocs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vocab := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var docs int
+		if err := rows.Scan(&term, &docs); err != nil {
+			return nil, err
+		}
+		vocab[term] = docs
+	}
+	return vocab, rows.Err()
+}
+
+// matchExpression builds the FTS5 MATCH expression of a keyword query with
+// the search dictionary. It is "" for queries without terms.
+func (db *DB) matchExpression(ctx context.Context, query string) (string, error) {
+	dict, err := db.SearchDictionary(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load search dictionary: %w", err)
+	}
+	return dict.FTSQuery(query), nil
+}
+
+// SetSynonyms sets the terms also searched for a term; no expansions
+// removes the term's synonyms
+func (db *DB) SetSynonyms(ctx context.Context, term string, expansions []string) error {
+	term = NormalizeTerm(term)
+	if term == "" {
+		return fmt.Errorf("empty term")
+	}
+	expansions = NormalizeSynonyms(term, expansions)
+
+	var err error
+	if len(expansions) == 0 {
+		_, err = db.conn.ExecContext(ctx, "DELETE FROM search_synonyms WHERE term = ?", term)
+	} else {
+		expansionsJSON, _ := json.Marshal(expansions)
+		_, err = db.conn.ExecContext(ctx, "INSERT OR REPLACE INTO search_synonyms (term, expansions) VALUES (?, ?)",
+			term, string(expansionsJSON))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update synonyms: %w", err)
+	}
+	db.resetDictionary()
+	return nil
+}
+
+// SetStopword adds a word to the stopwords, or removes it
+func (db *DB) SetStopword(ctx context.Context, word string, stop bool) error {
+	word = NormalizeTerm(word)
+	if word == "" {
+		return fmt.Errorf("empty word")
+	}
+
+	var err error
+	if stop {
+		_, err = db.conn.ExecContext(ctx, "INSERT OR IGNORE INTO search_stopwords (word) VALUES (?)", word)
+	} else {
+		_, err = db.conn.ExecContext(ctx, "DELETE FROM search_stopwords WHERE word = ?", word)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update stopwords: %w", err)
+	}
+	db.resetDictionary()
+	return nil
+}
+
+// resetDictionary drops the cached search dictionary
+func (db *DB) resetDictionary() {
+	db.dictMu.Lock()
+	db.dict = nil
+	db.dictMu.Unlock()
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Entity kinds, from the people/orgs/places frontmatter of sources
+const (
+	EntityPerson = "person"
+	EntityOrg    = "org"
+	EntityPlace  = "place"
+)
+
+// ValidEntityKind reports whether kind is a known entity kind
+func ValidEntityKind(kind string) bool {
+	return kind == EntityPerson || kind == EntityOrg || kind == EntityPlace
+}
+
+// EntityKey normalizes an entity name for matching: case, periods and
+// spacing are ignored, so "R. Feynman" and "r feynman" are the same key
+func EntityKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(name, ".", " "))), " ")
+}
+
+// EntityAlias maps a spelling variant of an entity to its canonical name
+type EntityAlias struct {
+	Kind      string `json:"kind"`  // person, org, or place
+	Alias     string `json:"alias"` // e.g. "R. Feynman"
+	Canonical string `json:"canonical"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Entity is a canonical entity with the number of sources mentioning it
+type Entity struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Sources int    `json:"sources"`
+}
+
+// entities returns the entity names of a source by kind
+func (src *Source) entities() map[string][]string {
+	return map[string][]string{
+		EntityPerson: src.People,
+		EntityOrg:    src.Orgs,
+		EntityPlace:  src.Places,
+	}
+}
+
+// writeSourceEntities replaces a source's entity index rows, resolving each
+// name through the curated aliases
+func writeSourceEntities(ctx context.Context, tx *sql.Tx, src Source) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_entities WHERE source_id = ?", src.ID); err != nil {
+		return fmt.Errorf("failed to update entity index: %w", err)
+	}
+	for kind, names := range src.entities() {
+		for _, name := range names {
+			if EntityKey(name) == "" {
+				continue
+			}
+			canonical, err := resolveEntity(ctx, tx, kind, name)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO source_entities (source_id, kind, name, canonical, canonical_key)
+				VALUES (?, ?, ?, ?, ?)
+			`, src.ID, kind, name, canonical, EntityKey(canonical))
+			if err != nil {
+				return fmt.Errorf("failed to update entity index: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// queryRower is implemented by *sql.Tx and *sql.DB
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// resolveEntity returns the canonical name of an entity: the target of its
+// curated alias, or the name itself
+func resolveEntity(ctx context.Context, q queryRower, kind, name string) (string, error) {
+	var canonical string
+	err := q.QueryRowContext(ctx, "SELECT canonical FROM entity_aliases WHERE kind = ? AND alias_key = ?",
+		kind, EntityKey(name)).Scan(&canonical)
+	if err == sql.ErrNoRows {
+		return name, nil
+	}
+	return canonical, err
+}
+
+// ResolveEntity returns the canonical name of an entity
+func (db *DB) ResolveEntity(ctx context.Context, kind, name string) (string, error) {
+	return resolveEntity(ctx, db.conn, kind, name)
+}
+
+// AddEntityAlias records that Alias is a spelling of Canonical and merges
+// the indexed mentions of Alias into it. Canonical is resolved through any
+// existing alias, and aliases that targeted Alias are repointed, so every
+// alias resolves in one step; aliases that would form a cycle are rejected.
+func (db *DB) AddEntityAlias(ctx context.Context, alias EntityAlias) error {
+	if !ValidEntityKind(alias.Kind) {
+		return fmt.Errorf("unknown entity kind: %s", alias.Kind)
+	}
+	if EntityKey(alias.Alias) == "" || EntityKey(alias.Canonical) == "" {
+		return fmt.Errorf("alias and canonical are required")
+	}
+	if alias.CreatedAt == "" {
+		alias.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		canonical, err := resolveEntity(ctx, tx, alias.Kind, alias.Canonical)
+		if err != nil {
+			return err
+		}
+		if EntityKey(canonical) == EntityKey(alias.Alias) {
+			return fmt.Errorf("%q already resolves to %q", alias.Canonical, alias.Alias)
+		}
+		alias.Canonical = canonical
+
+		if err := writeEntityAlias(ctx, tx, alias); err != nil {
+			return err
+		}
+		return appendEvent(ctx, tx, EventEntityAliasAdded, alias.Alias, alias)
+	})
+}
+
+// writeEntityAlias stores an alias and moves everything that resolved to
+// the alias (other aliases and indexed mentions) to its canonical name
+func writeEntityAlias(ctx context.Context, tx *sql.Tx, alias EntityAlias) error {
+	aliasKey, canonicalKey := EntityKey(alias.Alias), EntityKey(alias.Canonical)
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO entity_aliases (kind, alias_key, alias, canonical, canonical_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, alias.Kind, aliasKey, alias.Alias, alias.Canonical, canonicalKey, alias.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert entity alias: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE entity_aliases SET canonical = ?, canonical_key = ? WHERE kind = ? AND canonical_key = ?",
+		alias.Canonical, canonicalKey, alias.Kind, aliasKey)
+	if err != nil {
+		return fmt.Errorf("failed to repoint entity aliases: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE source_entities SET canonical = ?, canonical_key = ? WHERE kind = ? AND canonical_key = ?",
+		alias.Canonical, canonicalKey, alias.Kind, aliasKey)
+	if err != nil {
+		return fmt.Errorf("failed to merge entity mentions: %w", err)
+	}
+	return nil
+}
+
+// EntityAliases returns the aliases of an entity's canonical name
+func (db *DB) EntityAliases(ctx context.Context, kind, canonical string) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT alias FROM entity_aliases WHERE kind = ? AND canonical_key = ? ORDER BY alias_key",
+		kind, EntityKey(canonical))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// SourcesByEntity returns the sources mentioning an entity under any of its
+// spellings that are visible to the caller, newest first
+func (db *DB) SourcesByEntity(ctx context.Context, kind, name string, limit int, user string) ([]Source, error) {
+	canonical, err := db.ResolveEntity(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.query(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE id IN (
+			SELECT source_id FROM source_entities WHERE kind = ? AND canonical_key = ?
+		) AND `+visibleTo+`
+		ORDER BY created_at DESC LIMIT ?
+	`, kind, EntityKey(canonical), user, user, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSources(rows)
+}
+
+// loadSourceEntities fills in the entity names of a source as written
+func (db *DB) loadSourceEntities(ctx context.Context, src *Source) error {
+	rows, err := db.query(ctx, "SELECT kind, name FROM source_entities WHERE source_id = ? ORDER BY kind, rowid", src.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			return err
+		}
+		switch kind {
+		case EntityPerson:
+			src.People = append(src.People, name)
+		case EntityOrg:
+			src.Orgs = append(src.Orgs, name)
+		case EntityPlace:
+			src.Places = append(src.Places, name)
+		}
+	}
+	return rows.Err()
+}
+
+// Entities returns the canonical entities of a kind, most mentioned first
+func (db *DB) Entities(ctx context.Context, kind string, limit int) ([]Entity, error) {
+	rows, err := db.query(ctx, `
+		SELECT MIN(canonical), COUNT(DISTINCT source_id) AS n
+		FROM source_entities WHERE kind = ?
+		GROUP BY canonical_key
+		ORDER BY n DESC, canonical_key
+		LIMIT ?
+	`, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entities []Entity
+	for rows.Next() {
+		e := Entity{Kind: kind}
+		if err := rows.Scan(&e.Name, &e.Sources); err != nil {
+			return nil, err
+		}
+		entities = append(entities, e)
+	}
+	return entities, rows.Err()
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// eventPage is how many events are read from a SQLite event log at a time
+const eventPage = 1000
+
+// ReadEventLog calls fn for every event of the log at path in order. NDJSON
+// files (.ndjson, .jsonl), such as exports of GET /events, hold one event
+// per line; anything else is opened as a knowledge-base SQLite database.
+func ReadEventLog(ctx context.Context, path string, fn func(Event) error) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return readEventLogFile(path, fn)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	src, err := Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer src.Close()
+
+	var cursor int64
+	for {
+		events, err := src.EventsSince(ctx, cursor, eventPage)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if err := fn(ev); err != nil {
+				return err
+			}
+			cursor = ev.Seq
+		}
+		if len(events) < eventPage {
+			return nil
+		}
+	}
+}
+
+func readEventLogFile(path string, fn func(Event) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(text), &ev); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
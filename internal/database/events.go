@@ -0,0 +1,299 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Event types recorded in the event log
+const (
+	EventSourceUpserted   = "source.upserted"
+	EventSourceDeleted    = "source.deleted" // Soft deletion; restores are upserts
+	EventSourcePurged     = "source.purged"
+	EventArticleUpserted  = "article.upserted"
+	EventArticleDeleted   = "article.deleted"
+	EventAliasAdded       = "alias.added"
+	EventEntityAliasAdded = "entity_alias.added"
+	EventLinkReviewed     = "link.reviewed"
+	EventCategoryUpserted = "category.upserted"
+	EventClaimsExtracted  = "claims.extracted"
+	EventTopicUpserted    = "topic.upserted"
+	EventTopicDeleted     = "topic.deleted"
+)
+
+// Event is an entry of the append-only event log. Each event's hash covers
+// its content and the previous event's hash, so tampering with or removing
+// an event breaks the chain from that point on.
+type Event struct {
+	Seq       int64           `json:"seq"`
+	Type      string          `json:"type"`
+	EntityID  string          `json:"entity_id"`
+	Data      json.RawMessage `json:"data,omitempty"` // The record after the change
+	CreatedAt string          `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+	// Redacted is set when Data was withheld from the reader (see
+	// EventVisibleTo); the hash still covers it
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// appendEvent records a mutation in the event log within the caller's
+// transaction. Callers hold writeMu (via withTx).
+func appendEvent(ctx context.Context, tx *sql.Tx, eventType, entityID string, data any) error {
+	var prevSeq int64
+	var prevHash string
+	err := tx.QueryRowContext(ctx, "SELECT seq, hash FROM events ORDER BY seq DESC LIMIT 1").Scan(&prevSeq, &prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read event log head: %w", err)
+	}
+
+	ev := Event{
+		Seq:       prevSeq + 1,
+		Type:      eventType,
+		EntityID:  entityID,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		PrevHash:  prevHash,
+	}
+	if data != nil {
+		if ev.Data, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+	ev.Hash = ev.computeHash()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO events (seq, type, entity_id, data, created_at, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, ev.Seq, ev.Type, ev.EntityID, string(ev.Data), ev.CreatedAt, ev.PrevHash, ev.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// EventLookup finds the last earlier event about an entity (see
+// DB.PreviousEvent)
+type EventLookup interface {
+	PreviousEvent(ctx context.Context, entityID string, seq int64, types ...string) (*Event, error)
+}
+
+// EventVisibleTo reports whether user may read the data of an event. Source
+// upserts carry the source, and extracted claims what it says, so they
+// follow the visibility of the source as of the event; other events are
+// visible to everyone.
+func EventVisibleTo(ctx context.Context, lookup EventLookup, ev Event, user string) (bool, error) {
+	data := ev.Data
+	switch ev.Type {
+	case EventSourceUpserted:
+	case EventClaimsExtracted:
+		prev, err := lookup.PreviousEvent(ctx, ev.EntityID, ev.Seq, EventSourceUpserted)
+		if err != nil || prev == nil {
+			return false, err
+		}
+		data = prev.Data
+	default:
+		return true, nil
+	}
+	var src Source
+	if err := json.Unmarshal(data, &src); err != nil {
+		return false, nil
+	}
+	return src.VisibleTo(user), nil
+}
+
+// computeHash hashes the event's content chained to the previous hash
+func (ev *Event) computeHash() string {
+	h := sha256.New()
+	for _, field := range []string{ev.PrevHash, strconv.FormatInt(ev.Seq, 10), ev.Type, ev.EntityID, ev.CreatedAt, string(ev.Data)} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EventsSince returns up to limit events with a sequence number greater than
+// cursor, in order. Pass the last returned Seq as the next cursor.
+func (db *DB) EventsSince(ctx context.Context, cursor int64, limit int) ([]Event, error) {
+	rows, err := db.query(ctx, `
+		SELECT seq, type, entity_id, COALESCE(data, ''), created_at, prev_hash, hash
+		FROM events WHERE seq > ? ORDER BY seq LIMIT ?
+	`, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var ev Event
+		var data string
+		if err := rows.Scan(&ev.Seq, &ev.Type, &ev.EntityID, &data, &ev.CreatedAt, &ev.PrevHash, &ev.Hash); err != nil {
+			return nil, err
+		}
+		if data != "" {
+			ev.Data = json.RawMessage(data)
+		}
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// EventCursorAt returns the sequence number of the last event recorded
+// before t, to read the log from t on (0 if there is none). Events are
+// compared to the second: those of the second of t count as after it.
+func (db *DB) EventCursorAt(ctx context.Context, t time.Time) (int64, error) {
+	// Events are logged in time order, so the scan from the head stops at
+	// the first older event
+	var seq int64
+	err := db.queryRow(ctx, `
+		SELECT seq FROM events WHERE substr(created_at, 1, 19) < ? ORDER BY seq DESC LIMIT 1
+	`, t.UTC().Format("2006-01-02T15:04:05")).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// VerifyEvents walks the whole event log and checks the hash chain. It
+// returns the number of events verified, and an error naming the first
+// event whose hash or link doesn't match.
+func (db *DB) VerifyEvents(ctx context.Context) (int64, error) {
+	const page = 1000
+	var cursor, verified int64
+	prevHash := ""
+
+	for {
+		events, err := db.EventsSince(ctx, cursor, page)
+		if err != nil {
+			return verified, err
+		}
+		for _, ev := range events {
+			if ev.PrevHash != prevHash {
+				return verified, fmt.Errorf("event %d: broken link to previous event", ev.Seq)
+			}
+			if ev.computeHash() != ev.Hash {
+				return verified, fmt.Errorf("event %d: hash mismatch", ev.Seq)
+			}
+			prevHash = ev.Hash
+			cursor = ev.Seq
+			verified++
+		}
+		if len(events) < page {
+			return verified, nil
+		}
+	}
+}
+
+// EventHead returns the sequence number and hash of the last event (zero
+// values for an empty log)
+func (db *DB) EventHead(ctx context.Context) (int64, string, error) {
+	var seq int64
+	var hash string
+	err := db.conn.QueryRowContext(ctx, "SELECT seq, hash FROM events ORDER BY seq DESC LIMIT 1").Scan(&seq, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return seq, hash, err
+}
+
+// ReplayEvent applies a logged mutation to the tables and appends the event
+// verbatim, preserving its sequence number and hash. The event must extend
+// the current head of the log and its hash must verify.
+func (db *DB) ReplayEvent(ctx context.Context, ev Event) error {
+	if ev.computeHash() != ev.Hash {
+		return fmt.Errorf("event %d: hash mismatch", ev.Seq)
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		var headSeq int64
+		var headHash string
+		err := tx.QueryRowContext(ctx, "SELECT seq, hash FROM events ORDER BY seq DESC LIMIT 1").Scan(&headSeq, &headHash)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read event log head: %w", err)
+		}
+		if ev.Seq != headSeq+1 || ev.PrevHash != headHash {
+			return fmt.Errorf("event %d does not extend the log (head is %d)", ev.Seq, headSeq)
+		}
+
+		switch ev.Type {
+		case EventSourceUpserted:
+			var src Source
+			if err := json.Unmarshal(ev.Data, &src); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			if err = reviseSource(ctx, tx, src, ev.CreatedAt); err == nil {
+				err = db.writeSource(ctx, tx, src)
+			}
+		case EventSourceDeleted:
+			_, err = trashSource(ctx, tx, ev.EntityID, ev.CreatedAt)
+		case EventSourcePurged:
+			err = db.removeSource(ctx, tx, ev.EntityID)
+		case EventArticleUpserted:
+			var art Article
+			if err := json.Unmarshal(ev.Data, &art); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			if err = db.reviseArticle(ctx, tx, art, ev.CreatedAt); err == nil {
+				err = db.writeArticle(ctx, tx, art)
+			}
+		case EventArticleDeleted:
+			err = db.removeArticle(ctx, tx, ev.EntityID)
+		case EventAliasAdded:
+			var alias Alias
+			if err := json.Unmarshal(ev.Data, &alias); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeAlias(ctx, tx, alias)
+		case EventEntityAliasAdded:
+			var alias EntityAlias
+			if err := json.Unmarshal(ev.Data, &alias); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeEntityAlias(ctx, tx, alias)
+		case EventLinkReviewed:
+			var s LinkSuggestion
+			if err := json.Unmarshal(ev.Data, &s); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeLinkReview(ctx, tx, s)
+		case EventCategoryUpserted:
+			var cat Category
+			if err := json.Unmarshal(ev.Data, &cat); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeCategory(ctx, tx, cat)
+		case EventClaimsExtracted:
+			var set ClaimSet
+			if err := json.Unmarshal(ev.Data, &set); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeClaims(ctx, tx, set)
+		case EventTopicUpserted:
+			var t Topic
+			if err := json.Unmarshal(ev.Data, &t); err != nil {
+				return fmt.Errorf("event %d: %w", ev.Seq, err)
+			}
+			err = writeTopic(ctx, tx, t)
+		case EventTopicDeleted:
+			_, err = removeTopic(ctx, tx, ev.EntityID)
+		default:
+			return fmt.Errorf("event %d: unknown type %s", ev.Seq, ev.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO events (seq, type, entity_id, data, created_at, prev_hash, hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, ev.Seq, ev.Type, ev.EntityID, string(ev.Data), ev.CreatedAt, ev.PrevHash, ev.Hash)
+		return err
+	})
+}
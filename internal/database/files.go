@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// IndexedFile is the state of a markdown file when it was last indexed
+type IndexedFile struct {
+	Path      string // Relative to the Compendium root, with slashes
+	ArticleID string // Empty for category index.md files
+	ModTime   int64  // Unix nanoseconds
+	Size      int64
+	Hash      string // See ContentHash
+	// EmbeddingText is the strategy the article was embedded with, or empty
+	// if it wasn't embedded
+	EmbeddingText string
+}
+
+// ContentHash returns the hex SHA-256 of a file's content
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IndexedFiles returns the state of every indexed file, by path
+func (db *DB) IndexedFiles(ctx context.Context) (map[string]IndexedFile, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT path, article_id, mod_time, size, hash, embedding_text FROM indexed_files
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make(map[string]IndexedFile)
+	for rows.Next() {
+		var f IndexedFile
+		if err := rows.Scan(&f.Path, &f.ArticleID, &f.ModTime, &f.Size, &f.Hash, &f.EmbeddingText); err != nil {
+			return nil, err
+		}
+		files[f.Path] = f
+	}
+	return files, rows.Err()
+}
+
+// SetIndexedFiles records the state of indexed files in a single
+// transaction. This is indexer bookkeeping, not knowledge-base content, so
+// it isn't recorded in the event log.
+func (db *DB) SetIndexedFiles(ctx context.Context, files []IndexedFile) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, f := range files {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO indexed_files (path, article_id, mod_time, size, hash, embedding_text)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, f.Path, f.ArticleID, f.ModTime, f.Size, f.Hash, f.EmbeddingText)
+		if err != nil {
+			return fmt.Errorf("failed to record indexed file %s: %w", f.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ClearIndexedFiles forgets the state of every indexed file, so the next
+// run of the indexer processes them all
+func (db *DB) ClearIndexedFiles(ctx context.Context) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM indexed_files")
+	return err
+}
+
+// DeleteIndexedFile forgets the state of a file that no longer exists
+func (db *DB) DeleteIndexedFile(ctx context.Context, path string) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM indexed_files WHERE path = ?", path)
+	return err
+}
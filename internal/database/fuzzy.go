@@ -0,0 +1,116 @@
+package database
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// minTrigramSimilarity is the share of trigrams a vocabulary word must
+	// have in common with a query term to stand in for it (pg_trgm's
+	// default is 0.3, which lets in too many unrelated words)
+	minTrigramSimilarity = 0.4
+	// maxFuzzyTerms bounds the vocabulary words searched in place of one
+	// query term
+	maxFuzzyTerms = 5
+)
+
+// trigrams returns the set of three-rune sequences of a word padded like
+// pg_trgm does (two spaces before, one after), so short words and word
+// starts count
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// TrigramSimilarity returns the share of the trigrams of two words they
+// have in common, from 0 (none) to 1 (the same trigrams)
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// FuzzyTerms returns up to maxFuzzyTerms words of a vocabulary (words and
+// their document counts) resembling a normalized query term by their
+// trigrams, most similar first and then most frequent. Terms shorter than
+// minPrefixRunes have too few trigrams to compare and get none, as do
+// phrases.
+func FuzzyTerms(vocab map[string]int, term string) []string {
+	n := utf8.RuneCountInString(term)
+	if n < minPrefixRunes || strings.Contains(term, " ") {
+		return nil
+	}
+	type candidate struct {
+		word       string
+		similarity float64
+	}
+	var candidates []candidate
+	for word := range vocab {
+		// Words differing in length by more than a few runes can't share
+		// enough trigrams
+		if m := utf8.RuneCountInString(word); word == term || m < n-3 || m > n+3 {
+			continue
+		}
+		if sim := TrigramSimilarity(term, word); sim >= minTrigramSimilarity {
+			candidates = append(candidates, candidate{word, sim})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		if vocab[candidates[i].word] != vocab[candidates[j].word] {
+			return vocab[candidates[i].word] > vocab[candidates[j].word]
+		}
+		return candidates[i].word < candidates[j].word
+	})
+
+	var words []string
+	for _, c := range candidates[:min(len(candidates), maxFuzzyTerms)] {
+		words = append(words, c.word)
+	}
+	return words
+}
+
+// FuzzyGroups adds to each term group (see Dictionary.Groups) the
+// vocabulary words resembling its term, and reports whether any were added
+func FuzzyGroups(vocab map[string]int, groups [][]string) ([][]string, bool) {
+	added := false
+	fuzzy := make([][]string, len(groups))
+	for i, group := range groups {
+		fuzzy[i] = append([]string(nil), group...)
+		for _, word := range FuzzyTerms(vocab, group[0]) {
+			if !slices.Contains(fuzzy[i], word) {
+				fuzzy[i] = append(fuzzy[i], word)
+				added = true
+			}
+		}
+	}
+	return fuzzy, added
+}
+
+// groupsFTSQuery builds the FTS5 MATCH expression requiring one term of
+// every group
+func groupsFTSQuery(groups [][]string) string {
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		alternatives := make([]string, len(group))
+		for j, term := range group {
+			alternatives[j] = quoteFTS(term)
+		}
+		parts[i] = "(" + strings.Join(alternatives, " OR ") + ")"
+	}
+	return strings.Join(parts, " AND ")
+}
@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Graph node kinds besides the entity kinds (person, org, place)
+const (
+	NodeArticle = "article"
+	NodeSource  = "source"
+	NodeTopic   = "topic"
+)
+
+// Graph edge relations, named in the direction of the edge
+const (
+	RelCites    = "cites"    // article -> source, from article_sources
+	RelTopic    = "topic"    // source or article -> topic
+	RelMentions = "mentions" // source -> entity, from source_entities
+)
+
+// GraphFanout caps how many neighbors of one node are followed
+const GraphFanout = 200
+
+// graphMaxVisited caps how many nodes a single traversal may reach
+const graphMaxVisited = 10000
+
+// GraphNode is a node of the knowledge graph. IDs are "<kind>:<key>", e.g.
+// "source:src-1", "topic:quantum-computing" or "person:richard feynman".
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// GraphEdge is a directed, labeled edge between two graph nodes
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rel  string `json:"rel"`
+}
+
+// GraphNeighbor is a node adjacent to another and the edge joining them
+type GraphNeighbor struct {
+	Node GraphNode
+	Edge GraphEdge
+}
+
+// GraphReader reads nodes and adjacency of the knowledge graph as seen by a
+// user; sources the user cannot see, and edges through them, are left out
+type GraphReader interface {
+	GraphNode(ctx context.Context, id string, user string) (*GraphNode, error)
+	GraphNeighbors(ctx context.Context, node GraphNode, limit int, user string) ([]GraphNeighbor, error)
+}
+
+// NodeID builds a graph node ID
+func NodeID(kind, key string) string {
+	return kind + ":" + key
+}
+
+// ParseNodeID splits a graph node ID into its kind and key
+func ParseNodeID(id string) (kind, key string, ok bool) {
+	kind, key, ok = strings.Cut(id, ":")
+	if !ok || key == "" {
+		return "", "", false
+	}
+	switch kind {
+	case NodeArticle, NodeSource, NodeTopic:
+		return kind, key, true
+	}
+	return kind, key, ValidEntityKind(kind)
+}
+
+// GraphNode looks up a node by ID, or returns nil if it does not exist or
+// is not visible to user. Entity names are resolved through their aliases,
+// so the returned ID may differ from the one asked for.
+func (db *DB) GraphNode(ctx context.Context, id string, user string) (*GraphNode, error) {
+	kind, key, ok := ParseNodeID(id)
+	if !ok {
+		return nil, fmt.Errorf("invalid node id: %s", id)
+	}
+
+	node := &GraphNode{ID: id, Kind: kind, Label: key}
+	var err error
+	switch kind {
+	case NodeSource:
+		err = db.queryRow(ctx, "SELECT COALESCE(NULLIF(title, ''), id) FROM sources WHERE id = ? AND "+visibleTo,
+			key, user, user).Scan(&node.Label)
+	case NodeArticle:
+		err = db.queryRow(ctx, "SELECT COALESCE(NULLIF(title, ''), id) FROM articles WHERE id = ?", key).Scan(&node.Label)
+	case NodeTopic:
+		err = db.queryRow(ctx, `
+			SELECT topic FROM sources WHERE topic = ? AND `+visibleTo+`
+			UNION ALL SELECT topic FROM article_sources WHERE topic = ?
+			LIMIT 1
+		`, key, user, user, key).Scan(&node.Label)
+	default:
+		canonical, rerr := db.ResolveEntity(ctx, kind, key)
+		if rerr != nil {
+			return nil, rerr
+		}
+		node.ID = NodeID(kind, EntityKey(canonical))
+		err = db.queryRow(ctx, `
+			SELECT e.canonical FROM source_entities e JOIN sources ON sources.id = e.source_id
+			WHERE e.kind = ? AND e.canonical_key = ? AND `+visibleTo+`
+			LIMIT 1
+		`, kind, EntityKey(canonical), user, user).Scan(&node.Label)
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// GraphNeighbors returns up to limit neighbors of each relation of a node
+func (db *DB) GraphNeighbors(ctx context.Context, node GraphNode, limit int, user string) ([]GraphNeighbor, error) {
+	_, key, ok := ParseNodeID(node.ID)
+	if !ok {
+		return nil, fmt.Errorf("invalid node id: %s", node.ID)
+	}
+
+	var neighbors []GraphNeighbor
+	add := func(kind, rel string, outgoing bool) func(*sql.Rows) error {
+		return func(rows *sql.Rows) error {
+			var n GraphNode
+			if err := rows.Scan(&n.ID, &n.Label); err != nil {
+				return err
+			}
+			n.Kind = kind
+			n.ID = NodeID(kind, n.ID)
+			edge := GraphEdge{From: node.ID, To: n.ID, Rel: rel}
+			if !outgoing {
+				edge.From, edge.To = n.ID, node.ID
+			}
+			neighbors = append(neighbors, GraphNeighbor{Node: n, Edge: edge})
+			return nil
+		}
+	}
+
+	var err error
+	switch node.Kind {
+	case NodeSource:
+		err = db.eachRow(ctx, add(NodeTopic, RelTopic, true),
+			"SELECT topic, topic FROM sources WHERE id = ? AND topic != '' AND "+visibleTo, key, user, user)
+		if err == nil {
+			err = db.eachRow(ctx, add(NodeArticle, RelCites, false), `
+				SELECT a.id, COALESCE(NULLIF(a.title, ''), a.id)
+				FROM article_sources l JOIN articles a ON a.id = l.article_id
+				WHERE l.source_id = ? LIMIT ?
+			`, key, limit)
+		}
+		if err == nil {
+			err = db.eachSourceEntity(ctx, key, limit, node.ID, &neighbors)
+		}
+	case NodeArticle:
+		err = db.eachRow(ctx, add(NodeTopic, RelTopic, true),
+			"SELECT DISTINCT topic, topic FROM article_sources WHERE article_id = ?", key)
+		if err == nil {
+			err = db.eachRow(ctx, add(NodeSource, RelCites, true), `
+				SELECT sources.id, COALESCE(NULLIF(sources.title, ''), sources.id)
+				FROM article_sources l JOIN sources ON sources.id = l.source_id
+				WHERE l.article_id = ? AND `+visibleTo+` LIMIT ?
+			`, key, user, user, limit)
+		}
+	case NodeTopic:
+		err = db.eachRow(ctx, add(NodeSource, RelTopic, false), `
+			SELECT id, COALESCE(NULLIF(title, ''), id) FROM sources
+			WHERE topic = ? AND `+visibleTo+`
+			ORDER BY created_at DESC LIMIT ?
+		`, key, user, user, limit)
+		if err == nil {
+			err = db.eachRow(ctx, add(NodeArticle, RelTopic, false), `
+				SELECT a.id, COALESCE(NULLIF(a.title, ''), a.id)
+				FROM article_sources l JOIN articles a ON a.id = l.article_id
+				WHERE l.topic = ? GROUP BY l.article_id LIMIT ?
+			`, key, limit)
+		}
+	default:
+		err = db.eachRow(ctx, add(NodeSource, RelMentions, false), `
+			SELECT sources.id, COALESCE(NULLIF(sources.title, ''), sources.id)
+			FROM source_entities e JOIN sources ON sources.id = e.source_id
+			WHERE e.kind = ? AND e.canonical_key = ? AND `+visibleTo+` LIMIT ?
+		`, node.Kind, key, user, user, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+// eachSourceEntity adds the canonical entities mentioned by a source. Two
+// spellings of one entity are a single neighbor.
+func (db *DB) eachSourceEntity(ctx context.Context, sourceID string, limit int, from string, neighbors *[]GraphNeighbor) error {
+	seen := make(map[string]bool)
+	return db.eachRow(ctx, func(rows *sql.Rows) error {
+		var kind, canonical, key string
+		if err := rows.Scan(&kind, &canonical, &key); err != nil {
+			return err
+		}
+		id := NodeID(kind, key)
+		if seen[id] || len(seen) >= limit {
+			return nil
+		}
+		seen[id] = true
+		n := GraphNode{ID: id, Kind: kind, Label: canonical}
+		*neighbors = append(*neighbors, GraphNeighbor{Node: n, Edge: GraphEdge{From: from, To: id, Rel: RelMentions}})
+		return nil
+	}, "SELECT kind, canonical, canonical_key FROM source_entities WHERE source_id = ?", sourceID)
+}
+
+// eachRow runs a cached query and calls fn for each row
+func (db *DB) eachRow(ctx context.Context, fn func(*sql.Rows) error, query string, args ...any) error {
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GraphPath is a path through the knowledge graph: Edges[i] joins Nodes[i]
+// and Nodes[i+1], in either direction
+type GraphPath struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// ShortestPath finds a shortest path of at most maxDepth edges between two
+// nodes with a breadth-first search, or returns nil if there is none. Each
+// node contributes at most GraphFanout neighbors per relation, so on very
+// dense nodes a longer path may be returned, or none.
+func ShortestPath(ctx context.Context, g GraphReader, from, to GraphNode, maxDepth int, user string) (*GraphPath, error) {
+	nodes := map[string]GraphNode{from.ID: from}
+	prev := map[string]graphStep{}
+	frontier := []GraphNode{from}
+
+	for depth := 0; depth < maxDepth && len(frontier) > 0 && from.ID != to.ID; depth++ {
+		var next []GraphNode
+		for _, node := range frontier {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			neighbors, err := g.GraphNeighbors(ctx, node, GraphFanout, user)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range neighbors {
+				if _, seen := nodes[n.Node.ID]; seen {
+					continue
+				}
+				nodes[n.Node.ID] = n.Node
+				prev[n.Node.ID] = graphStep{prev: node.ID, edge: n.Edge}
+				if n.Node.ID == to.ID {
+					return buildPath(nodes, prev, from.ID, to.ID), nil
+				}
+				next = append(next, n.Node)
+			}
+			if len(nodes) >= graphMaxVisited {
+				return nil, nil
+			}
+		}
+		frontier = next
+	}
+
+	if from.ID == to.ID {
+		return &GraphPath{Nodes: []GraphNode{from}, Edges: []GraphEdge{}}, nil
+	}
+	return nil, nil
+}
+
+// graphStep records how a breadth-first search reached a node
+type graphStep struct {
+	prev string
+	edge GraphEdge
+}
+
+// buildPath walks back from to along the search's steps
+func buildPath(nodes map[string]GraphNode, prev map[string]graphStep, from, to string) *GraphPath {
+	path := &GraphPath{}
+	for id := to; id != from; id = prev[id].prev {
+		path.Nodes = append(path.Nodes, nodes[id])
+		path.Edges = append(path.Edges, prev[id].edge)
+	}
+	path.Nodes = append(path.Nodes, nodes[from])
+
+	for i, j := 0, len(path.Nodes)-1; i < j; i, j = i+1, j-1 {
+		path.Nodes[i], path.Nodes[j] = path.Nodes[j], path.Nodes[i]
+	}
+	for i, j := 0, len(path.Edges)-1; i < j; i, j = i+1, j-1 {
+		path.Edges[i], path.Edges[j] = path.Edges[j], path.Edges[i]
+	}
+	return path
+}
+
+// Neighborhood returns the nodes within depth edges of start, and the edges
+// between them, stopping once maxNodes nodes are found
+func Neighborhood(ctx context.Context, g GraphReader, start GraphNode, depth, maxNodes int, user string) ([]GraphNode, []GraphEdge, error) {
+	nodes := []GraphNode{start}
+	seen := map[string]bool{start.ID: true}
+	edgeSeen := map[GraphEdge]bool{}
+	edges := []GraphEdge{}
+	frontier := []GraphNode{start}
+
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		var next []GraphNode
+		for _, node := range frontier {
+			neighbors, err := g.GraphNeighbors(ctx, node, GraphFanout, user)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, n := range neighbors {
+				if !seen[n.Node.ID] {
+					if len(nodes) >= maxNodes {
+						continue // Leave out the edges to nodes past the cap
+					}
+					seen[n.Node.ID] = true
+					nodes = append(nodes, n.Node)
+					next = append(next, n.Node)
+				}
+				if !edgeSeen[n.Edge] {
+					edgeSeen[n.Edge] = true
+					edges = append(edges, n.Edge)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nodes, edges, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Link suggestion statuses
+const (
+	SuggestionPending  = "pending"
+	SuggestionApproved = "approved"
+	SuggestionRejected = "rejected"
+)
+
+// LinkSuggestion is a source proposed for an article by the auto-linking
+// job, awaiting or after curator review. Approved suggestions are curated
+// links like the article's own `sources:`.
+type LinkSuggestion struct {
+	ArticleID  string  `json:"article_id"`
+	SourceID   string  `json:"source_id"`
+	Score      float32 `json:"score"` // Similarity of the article and source embeddings
+	Status     string  `json:"status"`
+	CreatedAt  string  `json:"created_at,omitempty"`
+	ReviewedAt string  `json:"reviewed_at,omitempty"`
+}
+
+// ValidSuggestionStatus reports whether status is a known suggestion status
+func ValidSuggestionStatus(status string) bool {
+	return status == SuggestionPending || status == SuggestionApproved || status == SuggestionRejected
+}
+
+// ArticleIDs returns the IDs of all articles
+func (db *DB) ArticleIDs(ctx context.Context) ([]string, error) {
+	rows, err := db.query(ctx, "SELECT id FROM articles ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SuggestLinks records pending link suggestions. Sources already curated
+// for the article are skipped, pending suggestions get their score
+// refreshed, and reviewed ones are left alone, so a rejected link is not
+// proposed again. It returns how many new suggestions were added.
+func (db *DB) SuggestLinks(ctx context.Context, suggestions []LinkSuggestion) (int, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	added := 0
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, s := range suggestions {
+			var exists int
+			err := tx.QueryRowContext(ctx, `
+				SELECT 1 FROM article_sources WHERE article_id = ? AND source_id = ?
+				UNION ALL SELECT 1 FROM link_suggestions WHERE article_id = ? AND source_id = ?
+			`, s.ArticleID, s.SourceID, s.ArticleID, s.SourceID).Scan(&exists)
+			if err == nil {
+				_, err = tx.ExecContext(ctx, `
+					UPDATE link_suggestions SET score = ?
+					WHERE article_id = ? AND source_id = ? AND status = 'pending'
+				`, s.Score, s.ArticleID, s.SourceID)
+				if err != nil {
+					return fmt.Errorf("failed to update link suggestion: %w", err)
+				}
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO link_suggestions (article_id, source_id, score, status, created_at)
+				VALUES (?, ?, ?, 'pending', ?)
+			`, s.ArticleID, s.SourceID, s.Score, now)
+			if err != nil {
+				return fmt.Errorf("failed to insert link suggestion: %w", err)
+			}
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+// LinkSuggestions returns suggestions with the given status, best first.
+// An empty articleID returns the suggestions of all articles.
+func (db *DB) LinkSuggestions(ctx context.Context, articleID, status string, limit int) ([]LinkSuggestion, error) {
+	query := `
+		SELECT article_id, source_id, score, status, created_at, COALESCE(reviewed_at, '')
+		FROM link_suggestions WHERE status = ?
+		ORDER BY score DESC LIMIT ?`
+	args := []any{status, limit}
+	if articleID != "" {
+		query = `
+			SELECT article_id, source_id, score, status, created_at, COALESCE(reviewed_at, '')
+			FROM link_suggestions WHERE article_id = ? AND status = ?
+			ORDER BY score DESC LIMIT ?`
+		args = []any{articleID, status, limit}
+	}
+
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []LinkSuggestion
+	for rows.Next() {
+		var s LinkSuggestion
+		if err := rows.Scan(&s.ArticleID, &s.SourceID, &s.Score, &s.Status, &s.CreatedAt, &s.ReviewedAt); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// ReviewLinkSuggestion approves or rejects a suggestion and returns it, or
+// nil if there is no such suggestion. Approving links the source to the
+// article; rejecting an approved suggestion unlinks it again.
+func (db *DB) ReviewLinkSuggestion(ctx context.Context, articleID, sourceID, status string) (*LinkSuggestion, error) {
+	if status != SuggestionApproved && status != SuggestionRejected {
+		return nil, fmt.Errorf("status must be approved or rejected")
+	}
+
+	var reviewed *LinkSuggestion
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		var s LinkSuggestion
+		err := tx.QueryRowContext(ctx, `
+			SELECT article_id, source_id, score, status, created_at
+			FROM link_suggestions WHERE article_id = ? AND source_id = ?
+		`, articleID, sourceID).Scan(&s.ArticleID, &s.SourceID, &s.Score, &s.Status, &s.CreatedAt)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.Status = status
+		s.ReviewedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := writeLinkReview(ctx, tx, s); err != nil {
+			return err
+		}
+		reviewed = &s
+		return appendEvent(ctx, tx, EventLinkReviewed, s.ArticleID, s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewed, nil
+}
+
+// writeLinkReview stores a reviewed suggestion and links or unlinks its
+// source. Links from the article's own frontmatter are not removed.
+func writeLinkReview(ctx context.Context, tx *sql.Tx, s LinkSuggestion) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO link_suggestions (article_id, source_id, score, status, created_at, reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, s.ArticleID, s.SourceID, s.Score, s.Status, s.CreatedAt, s.ReviewedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store link review: %w", err)
+	}
+
+	if s.Status != SuggestionApproved {
+		_, err = tx.ExecContext(ctx, "DELETE FROM article_sources WHERE article_id = ? AND source_id = ? AND origin = 'suggestion'",
+			s.ArticleID, s.SourceID)
+		return err
+	}
+
+	topic, ok, err := articleTopic(ctx, tx, s.ArticleID)
+	if err != nil || !ok {
+		return err // The link is added when the article is written
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO article_sources (article_id, source_id, topic, origin)
+		VALUES (?, ?, ?, 'suggestion')
+	`, s.ArticleID, s.SourceID, topic)
+	if err != nil {
+		return fmt.Errorf("failed to link source: %w", err)
+	}
+	return nil
+}
+
+// articleTopic returns the topic slug of a stored article
+func articleTopic(ctx context.Context, tx *sql.Tx, id string) (string, bool, error) {
+	var art Article
+	var metaJSON string
+	err := tx.QueryRowContext(ctx, "SELECT path, meta_json FROM articles WHERE id = ?", id).Scan(&art.Path, &metaJSON)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if metaJSON != "" {
+		json.Unmarshal([]byte(metaJSON), &art.Meta)
+	}
+	return art.Topic(), true, nil
+}
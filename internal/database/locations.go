@@ -0,0 +1,45 @@
+package database
+
+import (
+	"fmt"
+	"math"
+)
+
+// Location is the position of a place a source is about, given with the
+// source or geocoded from its places
+type Location struct {
+	Place string  `json:"place,omitempty"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+}
+
+// Validate checks that the coordinates are on the globe
+func (l Location) Validate() error {
+	if math.IsNaN(l.Lat) || l.Lat < -90 || l.Lat > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90, got %v", l.Lat)
+	}
+	if math.IsNaN(l.Lon) || l.Lon < -180 || l.Lon > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180, got %v", l.Lon)
+	}
+	return nil
+}
+
+// Coordinates returns the latitude and longitude of every location of the
+// source
+func (s *Source) Coordinates() [][2]float64 {
+	coords := make([][2]float64, len(s.Locations))
+	for i, l := range s.Locations {
+		coords[i] = [2]float64{l.Lat, l.Lon}
+	}
+	return coords
+}
+
+// Located reports whether the source has a location for a place
+func (s *Source) Located(place string) bool {
+	for _, l := range s.Locations {
+		if EntityKey(l.Place) == EntityKey(place) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// QueuedWrite is a source write held in the outbox while the server
+// protects its stores (see package guard), to be replayed in order once
+// they agree again. Writes are authorized when they are queued: the API key
+// they were sent with, if any, is kept without its secret.
+type QueuedWrite struct {
+	ID          int64   `json:"id"`
+	Method      string  `json:"method"`
+	Path        string  `json:"path"`
+	ContentType string  `json:"content_type,omitempty"`
+	UserID      string  `json:"user_id,omitempty"`
+	APIKey      *APIKey `json:"api_key,omitempty"`
+	Body        []byte  `json:"-"`
+	QueuedAt    string  `json:"queued_at"`
+}
+
+// QueueWrite appends a write to the outbox and returns its ID
+func (db *DB) QueueWrite(ctx context.Context, w QueuedWrite) (int64, error) {
+	var key sql.NullString
+	if w.APIKey != nil {
+		b, err := json.Marshal(w.APIKey)
+		if err != nil {
+			return 0, err
+		}
+		key = sql.NullString{String: string(b), Valid: true}
+	}
+	res, err := db.conn.ExecContext(ctx, `
+		INSERT INTO outbox (method, path, content_type, user_id, api_key, body, queued_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, w.Method, w.Path, w.ContentType, w.UserID, key, w.Body, w.QueuedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue write: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// QueuedWrites returns up to limit writes of the outbox, oldest first
+func (db *DB) QueuedWrites(ctx context.Context, limit int) ([]QueuedWrite, error) {
+	rows, err := db.query(ctx, `
+		SELECT id, method, path, content_type, user_id, api_key, body, queued_at
+		FROM outbox ORDER BY id LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	writes := []QueuedWrite{}
+	for rows.Next() {
+		var w QueuedWrite
+		var key sql.NullString
+		if err := rows.Scan(&w.ID, &w.Method, &w.Path, &w.ContentType, &w.UserID, &key, &w.Body, &w.QueuedAt); err != nil {
+			return nil, err
+		}
+		if key.Valid {
+			w.APIKey = &APIKey{}
+			if err := json.Unmarshal([]byte(key.String), w.APIKey); err != nil {
+				return nil, fmt.Errorf("invalid stored API key of queued write %d: %w", w.ID, err)
+			}
+		}
+		writes = append(writes, w)
+	}
+	return writes, rows.Err()
+}
+
+// DeleteQueuedWrite removes a replayed write from the outbox
+func (db *DB) DeleteQueuedWrite(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM outbox WHERE id = ?", id)
+	return err
+}
+
+// CountQueuedWrites returns the number of writes in the outbox
+func (db *DB) CountQueuedWrites(ctx context.Context) (int, error) {
+	var n int
+	err := db.queryRow(ctx, "SELECT COUNT(*) FROM outbox").Scan(&n)
+	return n, err
+}
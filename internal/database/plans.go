@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// planCheck is a hot query whose plan must use an index
+type planCheck struct {
+	name  string
+	query string
+	args  []any
+}
+
+// planChecks lists the hot queries guarded by CheckQueryPlans. Add an entry
+// when a query becomes part of a request path.
+var planChecks = []planCheck{
+	{"source by id", `SELECT ` + sourceColumns + ` FROM sources WHERE id = ?`, []any{""}},
+	{"source by url", `SELECT ` + sourceColumns + ` FROM sources WHERE url = ?`, []any{""}},
+	{"source by canonical url", `SELECT ` + sourceColumns + ` FROM sources WHERE canonical_url = ? LIMIT 1`, []any{""}},
+	{"sources by topic", `SELECT ` + sourceColumns + ` FROM sources WHERE topic = ? AND ` + visibleTo + `
+		ORDER BY created_at DESC, id DESC LIMIT ?`, []any{"", "", "", 1}},
+	{"sources by topic after cursor", `SELECT ` + sourceColumns + ` FROM sources WHERE topic = ? AND ` + visibleTo + `
+		AND (created_at, id) < (?, ?) ORDER BY created_at DESC, id DESC LIMIT ?`, []any{"", "", "", "", "", 1}},
+	{"sources by domain", `SELECT ` + sourceColumns + ` FROM sources WHERE domain = ? AND ` + visibleTo + `
+		ORDER BY created_at DESC, id DESC LIMIT ?`, []any{"", "", "", 1}},
+	{"article by id", `SELECT id, title, path FROM articles WHERE id = ?`, []any{""}},
+	{"events since", `SELECT seq FROM events WHERE seq > ? ORDER BY seq LIMIT ?`, []any{0, 1}},
+	{"sources by type", `SELECT ` + sourceColumns + ` FROM sources WHERE type = ? AND ` + visibleTo + `
+		ORDER BY created_at DESC, id DESC LIMIT ?`, []any{"", "", "", 1}},
+	{"linked sources", `SELECT DISTINCT source_id FROM article_sources WHERE topic = ?`, []any{""}},
+	{"article sources", `SELECT source_id FROM article_sources WHERE article_id = ? ORDER BY source_id`, []any{""}},
+	// The newest-first sort runs over one entity's mentions, so only the
+	// lookup is checked
+	{"sources by entity", `SELECT source_id FROM source_entities WHERE kind = ? AND canonical_key = ?`, []any{"", ""}},
+	{"graph articles citing source", `SELECT a.id FROM article_sources l JOIN articles a ON a.id = l.article_id
+		WHERE l.source_id = ? LIMIT ?`, []any{"", 1}},
+	{"category by path", `SELECT path, title FROM categories WHERE path = ?`, []any{""}},
+	{"subcategories", `SELECT path, title FROM categories WHERE parent = ? ORDER BY sort_order, title`, []any{""}},
+	{"articles by category", `SELECT id, title FROM articles WHERE category = ? ORDER BY title LIMIT ?`, []any{"", 1}},
+	{"source links", `SELECT url, kind FROM source_links WHERE source_id = ? ORDER BY url`, []any{""}},
+	{"graph entities of source", `SELECT kind, canonical, canonical_key FROM source_entities WHERE source_id = ?`, []any{""}},
+	{"graph articles of topic", `SELECT a.id FROM article_sources l JOIN articles a ON a.id = l.article_id
+		WHERE l.topic = ? GROUP BY l.article_id LIMIT ?`, []any{"", 1}},
+	{"graph sources of entity", `SELECT sources.id FROM source_entities e JOIN sources ON sources.id = e.source_id
+		WHERE e.kind = ? AND e.canonical_key = ? AND ` + visibleTo + ` LIMIT ?`, []any{"", "", "", "", 1}},
+	{"pending link suggestions", `SELECT article_id, source_id FROM link_suggestions WHERE status = ?
+		ORDER BY score DESC LIMIT ?`, []any{"", 1}},
+	{"entity alias", `SELECT canonical FROM entity_aliases WHERE kind = ? AND alias_key = ?`, []any{"", ""}},
+	{"alias", `SELECT new_id FROM aliases WHERE kind = ? AND old_id = ?`, []any{"", ""}},
+	{"latest topic report", `SELECT report_json FROM topic_reports WHERE topic = ? ORDER BY period_start DESC LIMIT 1`, []any{""}},
+	{"api key by secret", `SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE secret_hash = ? OR (previous_hash = ? AND previous_expires_at > ?)`, []any{"", "", ""}},
+	{"previous event of entity", `SELECT seq, type FROM events WHERE entity_id = ? AND seq < ? AND type IN (?, ?)
+		ORDER BY seq DESC LIMIT 1`, []any{"", 1, "", ""}},
+	{"profile", `SELECT embedding FROM user_profiles WHERE user_id = ? AND model = ?`, []any{"", ""}},
+	// As for entities, the sorts run over one tag's records
+	{"sources by tag", `SELECT source_id FROM source_tags WHERE tag = ?`, []any{""}},
+	{"articles by tag", `SELECT article_id FROM article_tags WHERE tag = ?`, []any{""}},
+}
+
+// CheckQueryPlans runs EXPLAIN QUERY PLAN on the hot queries and returns an
+// error listing any that scan a whole table or sort without an index, so
+// schema changes that drop or bypass an index are caught at startup
+func (db *DB) CheckQueryPlans(ctx context.Context) error {
+	var problems []string
+	for _, check := range planChecks {
+		details, err := db.explain(ctx, check.query, check.args...)
+		if err != nil {
+			return fmt.Errorf("failed to explain %s: %w", check.name, err)
+		}
+		for _, detail := range details {
+			if isTableScan(detail) || strings.Contains(detail, "USE TEMP B-TREE") {
+				problems = append(problems, fmt.Sprintf("%s: %s", check.name, detail))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unindexed query plans:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// explain returns the detail lines of a query's plan
+func (db *DB) explain(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := db.conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var details []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	return details, rows.Err()
+}
+
+// isTableScan reports whether a plan step reads a whole table. Scans of FTS
+// virtual tables and covering-index scans are expected and allowed.
+func isTableScan(detail string) bool {
+	if !strings.HasPrefix(detail, "SCAN ") {
+		return false
+	}
+	return !strings.Contains(detail, "VIRTUAL TABLE") && !strings.Contains(detail, "COVERING INDEX")
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestQueryPlans fails when a hot query of planChecks scans a table or
+// sorts without an index, e.g. after a schema change drops an index
+func TestQueryPlans(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "knowledge.sqlite"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.CheckQueryPlans(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestQueryPlansCatchMissingIndex checks that CheckQueryPlans notices a
+// dropped index, so TestQueryPlans guards the schema
+func TestQueryPlansCatchMissingIndex(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "knowledge.sqlite"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.conn.Exec("DROP INDEX idx_sources_topic_created_id"); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	if err := db.CheckQueryPlans(context.Background()); err == nil {
+		t.Fatal("CheckQueryPlans passed without the (topic, created_at, id) index")
+	}
+}
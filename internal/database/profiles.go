@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ProfileWindow bounds the rolling average of a profile, so older
+// interactions fade out once a user has more than this many
+const ProfileWindow = 50
+
+// Profile is a user's interest embedding for one embedding model: a rolling
+// average of the vectors of sources they clicked or saved
+type Profile struct {
+	UserID       string    `json:"user_id"`
+	Model        string    `json:"model"`
+	Embedding    []float32 `json:"-"`
+	Interactions int       `json:"interactions"`
+	UpdatedAt    string    `json:"updated_at"`
+}
+
+// Add folds a source vector into the profile. Weight scales the influence of
+// the interaction (e.g. a save counts more than a click).
+func (p *Profile) Add(vector []float32, weight float64) {
+	if len(p.Embedding) != len(vector) {
+		// First interaction, or the model's dimension changed
+		p.Embedding = append([]float32(nil), vector...)
+		p.Interactions = 1
+		return
+	}
+
+	n := float64(min(p.Interactions, ProfileWindow))
+	alpha := weight / (n + weight)
+	for i := range p.Embedding {
+		p.Embedding[i] += float32(alpha) * (vector[i] - p.Embedding[i])
+	}
+	p.Interactions++
+}
+
+// GetProfile retrieves a user's profile for a model, or nil if none exists
+func (db *DB) GetProfile(ctx context.Context, userID, model string) (*Profile, error) {
+	var p Profile
+	var blob []byte
+	var updatedAt sql.NullString
+
+	err := db.queryRow(ctx, `
+		SELECT user_id, model, embedding, interactions, updated_at
+		FROM user_profiles WHERE user_id = ? AND model = ?
+	`, userID, model).Scan(&p.UserID, &p.Model, &blob, &p.Interactions, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.Embedding = decodeVector(blob)
+	p.UpdatedAt = updatedAt.String
+	return &p, nil
+}
+
+// SaveProfile inserts or replaces a user's profile
+func (db *DB) SaveProfile(ctx context.Context, p Profile) error {
+	if p.UpdatedAt == "" {
+		p.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT OR REPLACE INTO user_profiles (user_id, model, embedding, interactions, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, p.UserID, p.Model, encodeVector(p.Embedding), p.Interactions, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+// DeleteProfiles removes all profiles of a user
+func (db *DB) DeleteProfiles(ctx context.Context, userID string) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM user_profiles WHERE user_id = ?", userID)
+	return err
+}
+
+// encodeVector serializes a vector as little-endian float32s
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, len(v)*4)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(f))
+	}
+	return buf
+}
+
+// decodeVector deserializes a vector written by encodeVector
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return v
+}
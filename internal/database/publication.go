@@ -0,0 +1,38 @@
+package database
+
+// Publication is the canonical metadata of a paper, fetched from Crossref
+// or arXiv when a source URL contains a DOI or arXiv ID
+type Publication struct {
+	DOI       string   `json:"doi,omitempty"`
+	ArXivID   string   `json:"arxiv_id,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Authors   []string `json:"authors,omitempty"`
+	Abstract  string   `json:"abstract,omitempty"`
+	Published string   `json:"published,omitempty"` // Publication date, 2006-01-02 (or 2006-01, 2006)
+	Venue     string   `json:"venue,omitempty"`     // Journal, proceedings, or "arXiv"
+}
+
+// ApplyPublication merges publication metadata into the source: it is
+// attached as Publication, fills in a missing title and publication date,
+// and marks an untyped source as a paper
+func (s *Source) ApplyPublication(pub *Publication) {
+	s.Publication = pub
+	if s.Title == "" {
+		s.Title = pub.Title
+	}
+	if s.PublishedAt == "" {
+		s.PublishedAt, _ = NormalizePublishedAt(pub.Published)
+	}
+	if s.Type == "" {
+		s.Type = SourceTypePaper
+	}
+}
+
+// EmbeddingText returns the text embedded for the source: the official
+// abstract of its publication if there is one, otherwise its summary
+func (s *Source) EmbeddingText() string {
+	if s.Publication != nil && s.Publication.Abstract != "" {
+		return s.Publication.Abstract
+	}
+	return s.Summary
+}
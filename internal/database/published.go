@@ -0,0 +1,27 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// publishedLayouts are the accepted forms of a publication date, most
+// precise first
+var publishedLayouts = []string{time.RFC3339, time.DateOnly, "2006-01", "2006"}
+
+// NormalizePublishedAt parses a publication date given as an RFC 3339 time,
+// a date (2006-01-02), a month (2006-01) or a year (2006), and returns it as
+// an RFC 3339 time in UTC; partial dates mean their start. Empty input is
+// valid and stays empty.
+func NormalizePublishedAt(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", true
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
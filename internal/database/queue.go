@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reading queue statuses
+const (
+	QueueToRead  = "to-read"
+	QueueReading = "reading"
+	QueueDone    = "done"
+)
+
+// ValidQueueStatus reports whether status is a known reading queue status
+func ValidQueueStatus(status string) bool {
+	return status == QueueToRead || status == QueueReading || status == QueueDone
+}
+
+// QueueItem is a source in a user's reading queue
+type QueueItem struct {
+	UserID    string `json:"user_id"`
+	SourceID  string `json:"source_id"`
+	Status    string `json:"status"`
+	Note      string `json:"note"`
+	AddedAt   string `json:"added_at"`
+	StartedAt string `json:"started_at,omitempty"` // When it was last moved to reading
+	DoneAt    string `json:"done_at,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Move sets the status of the item at now and keeps its timestamps in step:
+// reading starts the item (again) and done finishes it, while to-read puts
+// it back unstarted. Setting the current status changes nothing.
+func (q *QueueItem) Move(status string, now time.Time) {
+	if status == q.Status {
+		return
+	}
+	ts := now.UTC().Format(time.RFC3339)
+	switch status {
+	case QueueToRead:
+		q.StartedAt, q.DoneAt = "", ""
+	case QueueReading:
+		q.StartedAt, q.DoneAt = ts, ""
+	case QueueDone:
+		q.DoneAt = ts
+	}
+	q.Status = status
+	q.UpdatedAt = ts
+}
+
+const queueColumns = `user_id, source_id, status, note, added_at,
+	COALESCE(started_at, ''), COALESCE(done_at, ''), updated_at`
+
+func scanQueueItem(row interface{ Scan(...any) error }) (QueueItem, error) {
+	var q QueueItem
+	err := row.Scan(&q.UserID, &q.SourceID, &q.Status, &q.Note, &q.AddedAt, &q.StartedAt, &q.DoneAt, &q.UpdatedAt)
+	return q, err
+}
+
+// EnqueueSources adds sources to a user's queue as to-read, with a note.
+// Sources already queued keep their status and note. It returns how many
+// were added.
+func (db *DB) EnqueueSources(ctx context.Context, userID string, sourceIDs []string, note string) (int, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	added := 0
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range sourceIDs {
+			res, err := tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO reading_queue (user_id, source_id, status, note, added_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, userID, id, QueueToRead, note, now, now)
+			if err != nil {
+				return fmt.Errorf("failed to enqueue source %s: %w", id, err)
+			}
+			n, _ := res.RowsAffected()
+			added += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// GetQueueItem returns a source of a user's queue, or nil if it isn't queued
+func (db *DB) GetQueueItem(ctx context.Context, userID, sourceID string) (*QueueItem, error) {
+	q, err := scanQueueItem(db.queryRow(ctx, `
+		SELECT `+queueColumns+` FROM reading_queue WHERE user_id = ? AND source_id = ?
+	`, userID, sourceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SaveQueueItem replaces a queued item, e.g. after Move
+func (db *DB) SaveQueueItem(ctx context.Context, q QueueItem) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE reading_queue SET status = ?, note = ?, started_at = NULLIF(?, ''), done_at = NULLIF(?, ''), updated_at = ?
+		WHERE user_id = ? AND source_id = ?
+	`, q.Status, q.Note, q.StartedAt, q.DoneAt, q.UpdatedAt, q.UserID, q.SourceID)
+	if err != nil {
+		return fmt.Errorf("failed to save queue item: %w", err)
+	}
+	return nil
+}
+
+// ReadingQueue returns a user's queue, or the items with a status when
+// status isn't empty, in the order they were added
+func (db *DB) ReadingQueue(ctx context.Context, userID, status string) ([]QueueItem, error) {
+	query := `SELECT ` + queueColumns + ` FROM reading_queue WHERE user_id = ? ORDER BY added_at, source_id`
+	args := []any{userID}
+	if status != "" {
+		query = `SELECT ` + queueColumns + ` FROM reading_queue WHERE user_id = ? AND status = ? ORDER BY added_at, source_id`
+		args = append(args, status)
+	}
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []QueueItem{}
+	for rows.Next() {
+		q, err := scanQueueItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, q)
+	}
+	return items, rows.Err()
+}
+
+// DequeueSource removes a source from a user's queue, reporting whether it
+// was queued
+func (db *DB) DequeueSource(ctx context.Context, userID, sourceID string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, "DELETE FROM reading_queue WHERE user_id = ? AND source_id = ?", userID, sourceID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// TopicReport is the periodic briefing of a topic's curators: what was
+// added in the period, which new sources share a subject, and what the
+// topic's articles are missing. Reports are derived from the knowledge
+// base, so they are stored for the API but not recorded in the event log.
+type TopicReport struct {
+	Topic       string `json:"topic"`
+	PeriodStart string `json:"period_start"` // RFC 3339, inclusive
+	PeriodEnd   string `json:"period_end"`   // RFC 3339, exclusive
+	GeneratedAt string `json:"generated_at"`
+
+	Stats       ReportStats     `json:"stats"`
+	NewSources  []ReportSource  `json:"new_sources"`
+	Clusters    []ReportCluster `json:"clusters"`
+	BrokenLinks []ArticleLink   `json:"broken_links"`
+	Gaps        []CoverageGap   `json:"gaps"`
+
+	Markdown string `json:"markdown"` // The report rendered for reading
+}
+
+// ReportStats count a topic's records at the end of a report period
+type ReportStats struct {
+	Sources    int            `json:"sources"`     // Public sources of the topic
+	NewSources int            `json:"new_sources"` // Of which added in the period
+	NewByType  map[string]int `json:"new_by_type,omitempty"`
+	Articles   int            `json:"articles"`
+	// Link suggestions for the topic's articles awaiting review
+	PendingSuggestions int `json:"pending_suggestions"`
+}
+
+// ReportSource is a source added in a report period
+type ReportSource struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Type      string `json:"type,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ReportCluster is a tag, domain or entity shared by several sources added
+// in a report period
+type ReportCluster struct {
+	Kind    string   `json:"kind"` // tag, domain, person, org, or place
+	Name    string   `json:"name"`
+	Sources []string `json:"sources"` // IDs of the new sources sharing it
+}
+
+// ArticleLink is a source curated for an article
+type ArticleLink struct {
+	ArticleID string `json:"article_id"`
+	SourceID  string `json:"source_id"`
+}
+
+// Kinds of coverage gaps
+const (
+	GapNoArticle = "no_article" // The topic has sources but no article
+	GapUncovered = "uncovered"  // A subject of many sources the articles never mention
+)
+
+// CoverageGap is something a topic's sources cover and its articles don't
+type CoverageGap struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject,omitempty"` // Tag or entity name of uncovered gaps
+	Sources int    `json:"sources"`           // Sources of the topic about it
+}
+
+// SourceTopics returns the number of sources of every topic, whatever
+// their visibility
+func (db *DB) SourceTopics(ctx context.Context) (map[string]int, error) {
+	rows, err := db.query(ctx, "SELECT topic, COUNT(*) FROM sources WHERE topic != '' AND deleted_at IS NULL GROUP BY topic")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := make(map[string]int)
+	for rows.Next() {
+		var topic string
+		var n int
+		if err := rows.Scan(&topic, &n); err != nil {
+			return nil, err
+		}
+		topics[topic] = n
+	}
+	return topics, rows.Err()
+}
+
+// BrokenArticleLinks returns the sources curated for the articles of a
+// topic that no longer exist or were deleted, by article and source ID
+func (db *DB) BrokenArticleLinks(ctx context.Context, topic string) ([]ArticleLink, error) {
+	rows, err := db.query(ctx, `
+		SELECT l.article_id, l.source_id FROM article_sources l
+		LEFT JOIN sources s ON s.id = l.source_id
+		WHERE l.topic = ? AND (s.id IS NULL OR s.deleted_at IS NOT NULL)
+		ORDER BY l.article_id, l.source_id
+	`, topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []ArticleLink
+	for rows.Next() {
+		var l ArticleLink
+		if err := rows.Scan(&l.ArticleID, &l.SourceID); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// SaveTopicReport stores a report, replacing the topic's report of the same
+// period
+func (db *DB) SaveTopicReport(ctx context.Context, report TopicReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.ExecContext(ctx, `
+		INSERT OR REPLACE INTO topic_reports (topic, period_start, period_end, generated_at, report_json)
+		VALUES (?, ?, ?, ?, ?)
+	`, report.Topic, report.PeriodStart, report.PeriodEnd, report.GeneratedAt, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store topic report: %w", err)
+	}
+	return nil
+}
+
+// GetTopicReport returns the report of a topic starting at periodStart, or
+// its latest report when periodStart is empty; nil if there is none
+func (db *DB) GetTopicReport(ctx context.Context, topic, periodStart string) (*TopicReport, error) {
+	query := "SELECT report_json FROM topic_reports WHERE topic = ? AND period_start = ?"
+	args := []any{topic, periodStart}
+	if periodStart == "" {
+		query = "SELECT report_json FROM topic_reports WHERE topic = ? ORDER BY period_start DESC LIMIT 1"
+		args = []any{topic}
+	}
+	var data string
+	err := db.queryRow(ctx, query, args...).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report TopicReport
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		return nil, fmt.Errorf("invalid stored report: %w", err)
+	}
+	return &report, nil
+}
+
+// ListTopicReports returns up to limit reports, most recent period first.
+// An empty topic lists the reports of all topics.
+func (db *DB) ListTopicReports(ctx context.Context, topic string, limit int) ([]TopicReport, error) {
+	query := "SELECT report_json FROM topic_reports ORDER BY period_start DESC, topic LIMIT ?"
+	args := []any{limit}
+	if topic != "" {
+		query = "SELECT report_json FROM topic_reports WHERE topic = ? ORDER BY period_start DESC LIMIT ?"
+		args = []any{topic, limit}
+	}
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []TopicReport{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var report TopicReport
+		if err := json.Unmarshal([]byte(data), &report); err != nil {
+			return nil, fmt.Errorf("invalid stored report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
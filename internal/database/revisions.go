@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Revision is a previous version of a source or article: the fields it had
+// until an update replaced them. Revisions are numbered from 1 for the
+// oldest; the current version is the one after the last revision.
+type Revision struct {
+	Revision   int            `json:"revision"`
+	ReplacedAt string         `json:"replaced_at"`
+	Fields     map[string]any `json:"fields"`
+}
+
+// SourceRevisionFields returns the fields of a source kept by its
+// revisions: those set by editors and by summarization, not the derived
+// ones (domain, word count, ...) or the content
+func SourceRevisionFields(src Source) map[string]any {
+	tags := src.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	visibility := src.Visibility
+	if visibility == "" {
+		visibility = VisibilityPublic
+	}
+	return map[string]any{
+		"url":          src.URL,
+		"title":        src.Title,
+		"topic":        src.Topic,
+		"summary":      src.Summary,
+		"language":     src.Language,
+		"model":        src.Model,
+		"tags":         tags,
+		"type":         NormalizeSourceType(src.Type),
+		"published_at": src.PublishedAt,
+		"visibility":   visibility,
+		"owner":        src.Owner,
+	}
+}
+
+// ArticleRevisionFields returns the fields of an article kept by its
+// revisions, its content included
+func ArticleRevisionFields(art Article) map[string]any {
+	tags := art.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	meta := art.Meta
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	return map[string]any{
+		"title":   art.Title,
+		"path":    art.Path,
+		"author":  art.Author,
+		"summary": art.Summary,
+		"tags":    tags,
+		"meta":    meta,
+		"content": art.Content,
+	}
+}
+
+// reviseSource records the stored version of a source as a revision before
+// src replaces it at at (RFC 3339), unless nothing it tracks changes
+func reviseSource(ctx context.Context, tx *sql.Tx, src Source, at string) error {
+	prev, err := scanSource(tx.QueryRowContext(ctx, "SELECT "+sourceColumns+" FROM sources WHERE id = ?", src.ID))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read source revision: %w", err)
+	}
+	return recordRevision(ctx, tx, "source_revisions", "source_id", src.ID,
+		SourceRevisionFields(prev), SourceRevisionFields(src), at)
+}
+
+// reviseArticle records the stored version of an article as a revision
+// before art replaces it at at (RFC 3339), unless nothing it tracks changes
+func (db *DB) reviseArticle(ctx context.Context, tx *sql.Tx, art Article, at string) error {
+	var prev Article
+	var tagsJSON, metaJSON string
+	err := tx.QueryRowContext(ctx, `
+		SELECT title, path, author, summary, tags, meta_json FROM articles WHERE id = ?
+	`, art.ID).Scan(&prev.Title, &prev.Path, &prev.Author, &prev.Summary, &tagsJSON, &metaJSON)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read article revision: %w", err)
+	}
+	json.Unmarshal([]byte(tagsJSON), &prev.Tags)
+	json.Unmarshal([]byte(metaJSON), &prev.Meta)
+	if prev.Content, err = db.articleText(ctx, tx, art.ID); err != nil {
+		return fmt.Errorf("failed to read article revision: %w", err)
+	}
+	return recordRevision(ctx, tx, "article_revisions", "article_id", art.ID,
+		ArticleRevisionFields(prev), ArticleRevisionFields(art), at)
+}
+
+// recordRevision appends prev as the next revision of an entity when it
+// differs from next
+func recordRevision(ctx context.Context, tx *sql.Tx, table, idColumn, id string, prev, next map[string]any, at string) error {
+	old, err := json.Marshal(prev)
+	if err != nil {
+		return err
+	}
+	if updated, err := json.Marshal(next); err != nil || string(updated) == string(old) {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO `+table+` (`+idColumn+`, revision, fields, replaced_at)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ? FROM `+table+` WHERE `+idColumn+` = ?
+	`, id, string(old), at, id)
+	if err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+	return nil
+}
+
+// SourceRevisions returns the previous versions of a source, newest first
+func (db *DB) SourceRevisions(ctx context.Context, id string) ([]Revision, error) {
+	return db.revisions(ctx, "source_revisions", "source_id", id)
+}
+
+// ArticleRevisions returns the previous versions of an article, newest
+// first
+func (db *DB) ArticleRevisions(ctx context.Context, id string) ([]Revision, error) {
+	return db.revisions(ctx, "article_revisions", "article_id", id)
+}
+
+func (db *DB) revisions(ctx context.Context, table, idColumn, id string) ([]Revision, error) {
+	rows, err := db.query(ctx, `
+		SELECT revision, replaced_at, fields FROM `+table+`
+		WHERE `+idColumn+` = ? ORDER BY revision DESC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var r Revision
+		var fields string
+		if err := rows.Scan(&r.Revision, &r.ReplacedAt, &fields); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &r.Fields); err != nil {
+			return nil, fmt.Errorf("invalid stored revision %d of %s: %w", r.Revision, id, err)
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Marks around the query terms in search snippets, and the ellipsis where
+// a snippet cuts its text
+const (
+	SnippetOpen     = "<mark>"
+	SnippetClose    = "</mark>"
+	SnippetEllipsis = "…"
+)
+
+// snippetWords is the most words of a snippet
+const snippetWords = 16
+
+// snippetSQL selects the FTS5 snippet of the best-matching column of an
+// article_fts match, or "" when the index doesn't hold the texts (see
+// DB.fillSnippets)
+var snippetSQL = fmt.Sprintf("COALESCE(snippet(article_fts, -1, '%s', '%s', '%s', %d), '')",
+	SnippetOpen, SnippetClose, SnippetEllipsis, snippetWords)
+
+// Snippet builds a search snippet like FTS5's snippet() for matches found
+// outside the index: up to snippetWords words of the text with the most
+// words containing a term of the groups (see Dictionary.Groups), starting
+// just before the first of them, with those words marked. It returns "" if
+// no text contains a term.
+func Snippet(groups [][]string, texts ...string) string {
+	var terms []string
+	for _, group := range groups {
+		for _, term := range group {
+			terms = append(terms, strings.Fields(strings.ToLower(term))...)
+		}
+	}
+
+	best, bestWords, bestMatches := "", []span(nil), 0
+	for _, text := range texts {
+		words := wordSpans(text)
+		matches := 0
+		for i := range words {
+			lower := strings.ToLower(text[words[i].start:words[i].end])
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					words[i].match = true
+					matches++
+					break
+				}
+			}
+		}
+		if matches > bestMatches {
+			best, bestWords, bestMatches = text, words, matches
+		}
+	}
+	if bestMatches == 0 {
+		return ""
+	}
+
+	first := 0
+	for !bestWords[first].match {
+		first++
+	}
+	from := max(0, min(first-2, len(bestWords)-snippetWords))
+	to := min(len(bestWords), from+snippetWords)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString(SnippetEllipsis)
+	}
+	pos := bestWords[from].start
+	for _, w := range bestWords[from:to] {
+		b.WriteString(best[pos:w.start])
+		if w.match {
+			b.WriteString(SnippetOpen + best[w.start:w.end] + SnippetClose)
+		} else {
+			b.WriteString(best[w.start:w.end])
+		}
+		pos = w.end
+	}
+	if to < len(bestWords) {
+		b.WriteString(SnippetEllipsis)
+	} else {
+		b.WriteString(best[pos:])
+	}
+	return b.String()
+}
+
+// span is a word of a text, by byte offsets
+type span struct {
+	start, end int
+	match      bool
+}
+
+// wordSpans returns the runs of letters and digits of a text
+func wordSpans(text string) []span {
+	var words []span
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			words = append(words, span{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, span{start: start, end: len(text)})
+	}
+	return words
+}
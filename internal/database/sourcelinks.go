@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Source link kinds
+const (
+	LinkCode    = "code"    // Source repositories (GitHub, GitLab, ...)
+	LinkDataset = "dataset" // Dataset hosts and dataset DOIs (Zenodo, Kaggle, ...)
+	LinkDOI     = "doi"     // Other DOIs
+	LinkOther   = "other"
+)
+
+// SourceLink is an outbound link found in a source's body
+type SourceLink struct {
+	URL  string `json:"url"`
+	Kind string `json:"kind"` // code, dataset, doi, or other
+}
+
+var (
+	linkURLPattern = regexp.MustCompile("https?://[^\\s<>()\\[\\]{}\"'`]+")
+	linkDOIPattern = regexp.MustCompile(`(?i)\b10\.\d{4,9}/[^\s<>()\[\]{}"'` + "`" + `]+`)
+)
+
+// codeHosts host repositories at /<owner>/<repo>
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+	"codeberg.org":  true,
+}
+
+// githubPages are GitHub paths that look like repositories but aren't
+var githubPages = map[string]bool{
+	"about": true, "features": true, "pricing": true, "topics": true,
+	"marketplace": true, "sponsors": true, "settings": true, "login": true, "site": true,
+}
+
+// datasetHosts are dataset repositories, with the path prefix of their
+// dataset pages ("" for any page)
+var datasetHosts = map[string]string{
+	"zenodo.org":            "/record",
+	"kaggle.com":            "/datasets/",
+	"huggingface.co":        "/datasets/",
+	"figshare.com":          "",
+	"datadryad.org":         "",
+	"data.world":            "",
+	"dataverse.harvard.edu": "",
+	"catalog.data.gov":      "",
+	"archive.ics.uci.edu":   "",
+	"openml.org":            "/d/",
+}
+
+// datasetDOIPrefixes are the DOI prefixes of dataset repositories
+var datasetDOIPrefixes = []string{
+	"10.5281/zenodo.",
+	"10.5061/dryad.",
+	"10.6084/m9.figshare.",
+	"10.7910/dvn/",
+}
+
+// ExtractLinks returns the outbound links of a markdown or plain text body
+// without duplicates: URLs in order of appearance, then bare DOIs
+// ("doi:10.1000/x") as https://doi.org/ links.
+func ExtractLinks(text string) []SourceLink {
+	seen := make(map[string]bool)
+	var links []SourceLink
+	add := func(rawURL string) {
+		link := classifyLink(rawURL)
+		if link.URL != "" && !seen[link.URL] {
+			seen[link.URL] = true
+			links = append(links, link)
+		}
+	}
+
+	for _, u := range linkURLPattern.FindAllString(text, -1) {
+		add(trimLinkPunctuation(u))
+	}
+	// DOIs inside the URLs found above are not bare DOIs
+	rest := linkURLPattern.ReplaceAllString(text, " ")
+	for _, doi := range linkDOIPattern.FindAllString(rest, -1) {
+		add("https://doi.org/" + trimLinkPunctuation(doi))
+	}
+	return links
+}
+
+// ExtractSourceLinks returns the typed outbound links of a source: those in
+// its summary plus those given in Links (e.g. found by ingest in the body)
+func ExtractSourceLinks(src *Source) []SourceLink {
+	var given strings.Builder
+	for _, link := range src.Links {
+		given.WriteString(link.URL)
+		given.WriteByte('\n')
+	}
+	return ExtractLinks(given.String() + src.Summary)
+}
+
+// HasLink reports whether the source links to anything of the given kind
+func (s *Source) HasLink(kind string) bool {
+	for _, link := range ExtractSourceLinks(s) {
+		if link.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// trimLinkPunctuation strips the sentence and markdown punctuation that
+// follows links in prose
+func trimLinkPunctuation(s string) string {
+	return strings.TrimRight(s, ".,;:!?*_~>")
+}
+
+// classifyLink normalizes a link and determines its kind. It returns an
+// empty link for URLs that can't be parsed.
+func classifyLink(rawURL string) SourceLink {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return SourceLink{}
+	}
+	host := NormalizeDomain(u.Hostname())
+
+	if host == "doi.org" || host == "dx.doi.org" {
+		doi := strings.TrimPrefix(u.Path, "/")
+		if doi == "" {
+			return SourceLink{}
+		}
+		link := SourceLink{URL: "https://doi.org/" + doi, Kind: LinkDOI}
+		lower := strings.ToLower(doi)
+		for _, prefix := range datasetDOIPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				link.Kind = LinkDataset
+			}
+		}
+		return link
+	}
+
+	link := SourceLink{URL: rawURL, Kind: LinkOther}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case host == "gist.github.com":
+		link.Kind = LinkCode
+	case codeHosts[host] && len(segments) >= 2 && !(host == "github.com" && githubPages[segments[0]]):
+		link.Kind = LinkCode
+	case host == "sourceforge.net" && strings.HasPrefix(u.Path, "/projects/"):
+		link.Kind = LinkCode
+	default:
+		if prefix, ok := datasetHosts[host]; ok && strings.HasPrefix(u.Path, prefix) {
+			link.Kind = LinkDataset
+		}
+	}
+	return link
+}
+
+// writeSourceLinks replaces the link index rows of a source
+func writeSourceLinks(ctx context.Context, tx *sql.Tx, src Source) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_links WHERE source_id = ?", src.ID); err != nil {
+		return fmt.Errorf("failed to update source links: %w", err)
+	}
+	for _, link := range ExtractSourceLinks(&src) {
+		_, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO source_links (source_id, url, kind) VALUES (?, ?, ?)",
+			src.ID, link.URL, link.Kind)
+		if err != nil {
+			return fmt.Errorf("failed to update source links: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadSourceLinks sets the links of a source from the link index
+func (db *DB) loadSourceLinks(ctx context.Context, src *Source) error {
+	rows, err := db.query(ctx, "SELECT url, kind FROM source_links WHERE source_id = ? ORDER BY url", src.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link SourceLink
+		if err := rows.Scan(&link.URL, &link.Kind); err != nil {
+			return err
+		}
+		src.Links = append(src.Links, link)
+	}
+	return rows.Err()
+}
+
+// backfillSourceLinks indexes the links of sources written before the link
+// index existed. Sources whose summary has no links are checked again at
+// every startup, which costs one pass over their summaries.
+func (db *DB) backfillSourceLinks() error {
+	return db.backfill(`
+		SELECT id, summary FROM sources
+		WHERE (summary LIKE '%http%' OR summary LIKE '%10.%')
+			AND id NOT IN (SELECT source_id FROM source_links)
+	`, "INSERT OR IGNORE INTO source_links (source_id, url, kind) VALUES (?, ?, ?)",
+		func(id string, cols []string) [][]any {
+			var args [][]any
+			for _, link := range ExtractLinks(cols[0]) {
+				args = append(args, []any{id, link.URL, link.Kind})
+			}
+			return args
+		})
+}
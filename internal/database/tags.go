@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tag is a tag with the number of sources and articles carrying it
+type Tag struct {
+	Name     string `json:"name"`
+	Sources  int    `json:"sources"`
+	Articles int    `json:"articles"`
+}
+
+// TagSet returns the distinct tags of a record as the tag index holds
+// them: trimmed, without empty ones
+func TagSet(tags []string) []string {
+	var set []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			set = append(set, tag)
+		}
+	}
+	return set
+}
+
+// writeSourceTags replaces a source's tag index rows
+func writeSourceTags(ctx context.Context, tx *sql.Tx, src Source) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_tags WHERE source_id = ?", src.ID); err != nil {
+		return fmt.Errorf("failed to update tag index: %w", err)
+	}
+	for _, tag := range TagSet(src.Tags) {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO source_tags (source_id, tag) VALUES (?, ?)", src.ID, tag); err != nil {
+			return fmt.Errorf("failed to update tag index: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeArticleTags replaces an article's tag index rows
+func writeArticleTags(ctx context.Context, tx *sql.Tx, art Article) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = ?", art.ID); err != nil {
+		return fmt.Errorf("failed to update tag index: %w", err)
+	}
+	for _, tag := range TagSet(art.Tags) {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO article_tags (article_id, tag) VALUES (?, ?)", art.ID, tag); err != nil {
+			return fmt.Errorf("failed to update tag index: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillTags indexes the tags of sources and articles written before the
+// tag index existed. Records without tags are checked again at every
+// startup, which costs one pass over their tags columns.
+func (db *DB) backfillTags() error {
+	queries := map[string]string{
+		"source_tags": `SELECT id, tags FROM sources
+			WHERE tags NOT IN ('', 'null', '[]') AND id NOT IN (SELECT source_id FROM source_tags)`,
+		"article_tags": `SELECT id, tags FROM articles
+			WHERE tags NOT IN ('', 'null', '[]') AND id NOT IN (SELECT article_id FROM article_tags)`,
+	}
+	for table, query := range queries {
+		idColumn := strings.TrimSuffix(table, "_tags") + "_id"
+		err := db.backfill(query, "INSERT OR IGNORE INTO "+table+" ("+idColumn+", tag) VALUES (?, ?)",
+			func(id string, cols []string) [][]any {
+				var list []string
+				json.Unmarshal([]byte(cols[0]), &list)
+				var args [][]any
+				for _, tag := range TagSet(list) {
+					args = append(args, []any{id, tag})
+				}
+				return args
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tags returns up to limit tags of the sources visible to the caller and of
+// the articles, most used first
+func (db *DB) Tags(ctx context.Context, limit int, user string) ([]Tag, error) {
+	rows, err := db.query(ctx, `
+		SELECT tag, SUM(sources) AS s, SUM(articles) AS a FROM (
+			SELECT source_tags.tag, 1 AS sources, 0 AS articles
+			FROM source_tags JOIN sources ON sources.id = source_tags.source_id
+			WHERE `+visibleTo+`
+			UNION ALL
+			SELECT tag, 0, 1 FROM article_tags
+		)
+		GROUP BY tag
+		ORDER BY s + a DESC, tag
+		LIMIT ?
+	`, user, user, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.Name, &t.Sources, &t.Articles); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// SourcesByTag returns the sources with a tag that are visible to the
+// caller, newest first
+func (db *DB) SourcesByTag(ctx context.Context, tag string, limit int, user string) ([]Source, error) {
+	rows, err := db.query(ctx, `
+		SELECT `+sourceColumns+`
+		FROM sources WHERE id IN (SELECT source_id FROM source_tags WHERE tag = ?)
+			AND `+visibleTo+`
+		ORDER BY created_at DESC LIMIT ?
+	`, tag, user, user, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSources(rows)
+}
+
+// SourceIDsByTag returns the IDs of every live source with a tag in ID
+// order, whatever its visibility
+func (db *DB) SourceIDsByTag(ctx context.Context, tag string) ([]string, error) {
+	rows, err := db.query(ctx, `
+		SELECT source_id FROM source_tags JOIN sources ON sources.id = source_tags.source_id
+		WHERE tag = ? AND deleted_at IS NULL
+		ORDER BY source_id
+	`, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ArticlesByTag returns up to limit articles with a tag, by title
+func (db *DB) ArticlesByTag(ctx context.Context, tag string, limit int) ([]Article, error) {
+	rows, err := db.query(ctx, `
+		SELECT id, title, path, author, summary, tags, meta_json,
+			COALESCE(word_count, 0), COALESCE(reading_minutes, 0)
+		FROM articles WHERE id IN (SELECT article_id FROM article_tags WHERE tag = ?)
+		ORDER BY title LIMIT ?
+	`, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var art Article
+		var tagsJSON, metaJSON string
+		if err := rows.Scan(&art.ID, &art.Title, &art.Path, &art.Author, &art.Summary, &tagsJSON, &metaJSON,
+			&art.WordCount, &art.ReadingMinutes); err != nil {
+			return nil, err
+		}
+		if tagsJSON != "" {
+			json.Unmarshal([]byte(tagsJSON), &art.Tags)
+		}
+		if metaJSON != "" {
+			json.Unmarshal([]byte(metaJSON), &art.Meta)
+		}
+		articles = append(articles, art)
+	}
+	return articles, rows.Err()
+}
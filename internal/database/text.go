@@ -0,0 +1,28 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wordsPerMinute is the reading speed used for reading time estimates
+const wordsPerMinute = 200
+
+// WordCount returns the number of whitespace-separated words in text that
+// contain at least one letter or digit, so Markdown markup like "#" or "---"
+// isn't counted
+func WordCount(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ReadingMinutes estimates the reading time of a text with the given word
+// count, rounded up to whole minutes (0 for empty text)
+func ReadingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
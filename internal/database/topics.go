@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+)
+
+// Topic is an entry of the topic taxonomy. Sources keep their topic as a
+// free-form slug; the taxonomy describes those slugs and arranges them in a
+// hierarchy, so filtering by a topic can take in the topics below it.
+type Topic struct {
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Parent      string   `json:"parent,omitempty"`  // Slug of the parent topic; "" for a root
+	Aliases     []string `json:"aliases,omitempty"` // Other slugs sources may use for the topic
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// TopicScope returns the topic slugs a filter on topic matches: the topic
+// itself (the one it names when it is an alias), then the topics below it
+// in the taxonomy, and the aliases of all of them. A topic outside the
+// taxonomy matches itself only.
+func TopicScope(topics []Topic, topic string) []string {
+	bySlug := make(map[string]Topic, len(topics))
+	children := make(map[string][]string)
+	for _, t := range topics {
+		bySlug[t.Slug] = t
+		if t.Parent != "" {
+			children[t.Parent] = append(children[t.Parent], t.Slug)
+		}
+	}
+	root, ok := bySlug[topic]
+	if !ok {
+		for _, t := range topics {
+			if slices.Contains(t.Aliases, topic) {
+				root, ok = t, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return []string{topic}
+	}
+
+	scope := []string{root.Slug}
+	seen := map[string]bool{root.Slug: true}
+	for i := 0; i < len(scope); i++ {
+		for _, child := range children[scope[i]] {
+			if !seen[child] {
+				seen[child] = true
+				scope = append(scope, child)
+			}
+		}
+	}
+	for _, slug := range scope[:len(scope):len(scope)] {
+		scope = append(scope, bySlug[slug].Aliases...)
+	}
+	return scope
+}
+
+// TopicAncestors returns the slugs of the topics above slug, from its
+// parent up to the root
+func TopicAncestors(topics []Topic, slug string) []string {
+	parents := make(map[string]string, len(topics))
+	for _, t := range topics {
+		parents[t.Slug] = t.Parent
+	}
+	ancestors := []string{}
+	for p := parents[slug]; p != "" && !slices.Contains(ancestors, p); p = parents[p] {
+		ancestors = append(ancestors, p)
+	}
+	return ancestors
+}
+
+// PutTopic creates or replaces a topic of the taxonomy, with its aliases
+func (db *DB) PutTopic(ctx context.Context, t Topic) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		if err := writeTopic(ctx, tx, t); err != nil {
+			return fmt.Errorf("topic %q: %w", t.Slug, err)
+		}
+		return appendEvent(ctx, tx, EventTopicUpserted, t.Slug, t)
+	})
+}
+
+// writeTopic writes a topic row and replaces its aliases
+func writeTopic(ctx context.Context, tx *sql.Tx, t Topic) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO topics (slug, name, description, parent, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, t.Slug, t.Name, t.Description, t.Parent, t.CreatedAt, t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert topic: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM topic_aliases WHERE slug = ?", t.Slug); err != nil {
+		return fmt.Errorf("failed to replace topic aliases: %w", err)
+	}
+	for _, alias := range t.Aliases {
+		if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO topic_aliases (alias, slug) VALUES (?, ?)", alias, t.Slug); err != nil {
+			return fmt.Errorf("failed to insert topic alias: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteTopic removes a topic and its aliases from the taxonomy; the
+// topics below it move up to its parent. Sources keep their topic. It
+// reports whether the topic existed.
+func (db *DB) DeleteTopic(ctx context.Context, slug string) (bool, error) {
+	var found bool
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if found, err = removeTopic(ctx, tx, slug); err != nil || !found {
+			return err
+		}
+		return appendEvent(ctx, tx, EventTopicDeleted, slug, nil)
+	})
+	return found, err
+}
+
+// removeTopic deletes a topic row and its aliases, moving its children to
+// its parent
+func removeTopic(ctx context.Context, tx *sql.Tx, slug string) (bool, error) {
+	var parent string
+	err := tx.QueryRowContext(ctx, "SELECT parent FROM topics WHERE slug = ?", slug).Scan(&parent)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE topics SET parent = ? WHERE parent = ?", parent, slug); err != nil {
+		return false, fmt.Errorf("failed to move subtopics: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM topic_aliases WHERE slug = ?", slug); err != nil {
+		return false, fmt.Errorf("failed to delete topic aliases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM topics WHERE slug = ?", slug); err != nil {
+		return false, fmt.Errorf("failed to delete topic: %w", err)
+	}
+	return true, nil
+}
+
+// GetTopic retrieves a topic by its slug or one of its aliases, or nil if
+// the taxonomy has neither
+func (db *DB) GetTopic(ctx context.Context, slug string) (*Topic, error) {
+	var t Topic
+	err := db.queryRow(ctx, `
+		SELECT slug, name, description, parent, created_at, updated_at FROM topics
+		WHERE slug = ? OR slug = (SELECT slug FROM topic_aliases WHERE alias = ?)
+		ORDER BY slug = ? DESC LIMIT 1
+	`, slug, slug, slug).Scan(&t.Slug, &t.Name, &t.Description, &t.Parent, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.query(ctx, "SELECT alias FROM topic_aliases WHERE slug = ? ORDER BY alias", t.Slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		t.Aliases = append(t.Aliases, alias)
+	}
+	return &t, rows.Err()
+}
+
+// ListTopics returns the whole taxonomy, by slug
+func (db *DB) ListTopics(ctx context.Context) ([]Topic, error) {
+	aliases := make(map[string][]string)
+	rows, err := db.query(ctx, "SELECT alias, slug FROM topic_aliases ORDER BY alias")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var alias, slug string
+		if err := rows.Scan(&alias, &slug); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		aliases[slug] = append(aliases[slug], alias)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.query(ctx, `
+		SELECT slug, name, description, parent, created_at, updated_at
+		FROM topics ORDER BY slug
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []Topic{}
+	for rows.Next() {
+		var t Topic
+		if err := rows.Scan(&t.Slug, &t.Name, &t.Description, &t.Parent, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		t.Aliases = aliases[t.Slug]
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
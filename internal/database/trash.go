@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DeletedSource is a soft-deleted source awaiting its restore or purge
+type DeletedSource struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+// DeleteSource soft-deletes a source: it is left out of reads, searches and
+// listings until it is restored (see RestoreSource) or purged (see
+// PurgeSource), and keeps its entities, links and claims meanwhile. Deleting
+// an unknown or deleted source does nothing.
+func (db *DB) DeleteSource(ctx context.Context, id string) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		deleted, err := trashSource(ctx, tx, id, time.Now().UTC().Format(time.RFC3339))
+		if err != nil || !deleted {
+			return err
+		}
+		return appendEvent(ctx, tx, EventSourceDeleted, id, nil)
+	})
+}
+
+// trashSource marks a live source deleted at at, reporting whether it was
+// live
+func trashSource(ctx context.Context, tx *sql.Tx, id, at string) (bool, error) {
+	res, err := tx.ExecContext(ctx, "UPDATE sources SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", at, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete source %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RestoreSource undoes the deletion of a source that wasn't purged yet and
+// returns it, or nil if no deleted source visible to user has the ID. The
+// restore is logged as an upsert of the source with its content, so
+// consumers of the log see it again as they saw its deletion.
+func (db *DB) RestoreSource(ctx context.Context, id, user string) (*Source, error) {
+	src, err := db.getSource(ctx, "deleted_at IS NOT NULL", id)
+	if err != nil || src == nil || !src.VisibleTo(user) {
+		return nil, err
+	}
+	restored := false
+	err = db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE sources SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return fmt.Errorf("failed to restore source %s: %w", id, err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err // Restored, purged or replaced meanwhile
+		}
+		restored = true
+
+		logged := *src
+		if logged.Content, err = db.sourceText(ctx, tx, id); err != nil {
+			return err
+		}
+		return appendEvent(ctx, tx, EventSourceUpserted, id, logged)
+	})
+	if err != nil || !restored {
+		return nil, err
+	}
+	return src, nil
+}
+
+// DeletedSources returns up to limit sources deleted before before (RFC
+// 3339), longest deleted first
+func (db *DB) DeletedSources(ctx context.Context, before string, limit int) ([]DeletedSource, error) {
+	rows, err := db.query(ctx, `
+		SELECT id, COALESCE(title, ''), deleted_at FROM sources
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		ORDER BY deleted_at, id
+		LIMIT ?
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := []DeletedSource{}
+	for rows.Next() {
+		var d DeletedSource
+		if err := rows.Scan(&d.ID, &d.Title, &d.DeletedAt); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, d)
+	}
+	return deleted, rows.Err()
+}
+
+// PurgeSource permanently removes a deleted source with its entities,
+// links, claims and queue entries, reporting whether it was deleted. Live
+// sources are left alone.
+func (db *DB) PurgeSource(ctx context.Context, id string) (bool, error) {
+	purged := false
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		var one int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM sources WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&one)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := db.removeSource(ctx, tx, id); err != nil {
+			return err
+		}
+		purged = true
+		return appendEvent(ctx, tx, EventSourcePurged, id, nil)
+	})
+	return purged, err
+}
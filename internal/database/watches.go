@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Watch is a user's standing interest: a paragraph describing what they
+// care about, embedded with one model. Sources created after the watch whose
+// vectors are at least Threshold similar to it are recorded as matches (see
+// package watch), and POSTed to URL if set. Watches follow the event log
+// from Cursor like webhooks, and are not recorded in it.
+type Watch struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Text      string    `json:"text"`
+	Model     string    `json:"model"` // Embedding model of Embedding
+	Embedding []float32 `json:"-"`
+	Threshold float64   `json:"threshold"` // Minimum cosine similarity of a match
+	Topic     string    `json:"topic,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	// Secret signs the deliveries to URL; it is shown once, when the watch
+	// is created
+	Secret    string `json:"-"`
+	CreatedAt string `json:"created_at"`
+	// Cursor is the sequence number of the last event compared
+	Cursor      int64  `json:"cursor"`
+	Matches     int    `json:"matches"`
+	LastMatchAt string `json:"last_match_at,omitempty"`
+}
+
+// WatchMatch is a source matching a watch, numbered in the order matches
+// were recorded
+type WatchMatch struct {
+	Seq       int64   `json:"seq"`
+	WatchID   string  `json:"watch_id"`
+	UserID    string  `json:"user_id"`
+	SourceID  string  `json:"source_id"`
+	Score     float64 `json:"score"`
+	MatchedAt string  `json:"matched_at"`
+	// Delivery to the watch's URL: when it succeeded, the failed attempts
+	// before, and the last error
+	DeliveredAt   string `json:"delivered_at,omitempty"`
+	Attempts      int    `json:"attempts"`
+	LastAttemptAt string `json:"last_attempt_at,omitempty"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+const watchColumns = `w.id, w.user_id, w.name, w.text, w.model, w.embedding, w.threshold, w.topic, w.url, w.secret,
+	w.created_at, w.cursor,
+	(SELECT COUNT(*) FROM watch_matches m WHERE m.watch_id = w.id),
+	(SELECT COALESCE(MAX(matched_at), '') FROM watch_matches m WHERE m.watch_id = w.id)`
+
+// scanWatch reads a row of watchColumns
+func scanWatch(row interface{ Scan(...any) error }) (Watch, error) {
+	var w Watch
+	var blob []byte
+	err := row.Scan(&w.ID, &w.UserID, &w.Name, &w.Text, &w.Model, &blob, &w.Threshold, &w.Topic, &w.URL, &w.Secret,
+		&w.CreatedAt, &w.Cursor, &w.Matches, &w.LastMatchAt)
+	w.Embedding = decodeVector(blob)
+	return w, err
+}
+
+const watchMatchColumns = `seq, watch_id, user_id, source_id, score, matched_at,
+	COALESCE(delivered_at, ''), attempts, COALESCE(last_attempt_at, ''), COALESCE(last_error, '')`
+
+// scanWatchMatch reads a row of watchMatchColumns
+func scanWatchMatch(row interface{ Scan(...any) error }) (WatchMatch, error) {
+	var m WatchMatch
+	err := row.Scan(&m.Seq, &m.WatchID, &m.UserID, &m.SourceID, &m.Score, &m.MatchedAt,
+		&m.DeliveredAt, &m.Attempts, &m.LastAttemptAt, &m.LastError)
+	return m, err
+}
+
+// CreateWatch stores a new watch. Its cursor starts at the head of the event
+// log, so it is compared with the sources created from now on. It returns
+// the stored watch.
+func (db *DB) CreateWatch(ctx context.Context, w Watch) (*Watch, error) {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO watches (id, user_id, name, text, model, embedding, threshold, topic, url, secret, created_at, cursor)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(seq), 0) FROM events))
+	`, w.ID, w.UserID, w.Name, w.Text, w.Model, encodeVector(w.Embedding), w.Threshold, w.Topic, w.URL, w.Secret, w.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store watch: %w", err)
+	}
+	return db.GetWatch(ctx, w.ID)
+}
+
+// ListWatches returns the watches of a user, or of every user when userID
+// is empty, oldest first
+func (db *DB) ListWatches(ctx context.Context, userID string) ([]Watch, error) {
+	query := `SELECT ` + watchColumns + ` FROM watches w ORDER BY w.created_at, w.id`
+	var args []any
+	if userID != "" {
+		query = `SELECT ` + watchColumns + ` FROM watches w WHERE w.user_id = ? ORDER BY w.created_at, w.id`
+		args = append(args, userID)
+	}
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watches := []Watch{}
+	for rows.Next() {
+		w, err := scanWatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		watches = append(watches, w)
+	}
+	return watches, rows.Err()
+}
+
+// GetWatch returns a watch by ID, or nil if there is none
+func (db *DB) GetWatch(ctx context.Context, id string) (*Watch, error) {
+	w, err := scanWatch(db.queryRow(ctx, `SELECT `+watchColumns+` FROM watches w WHERE w.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// DeleteWatch removes a watch and its matches, reporting whether it existed
+func (db *DB) DeleteWatch(ctx context.Context, id string) (bool, error) {
+	var found bool
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM watches WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		found = n > 0
+		_, err = tx.ExecContext(ctx, "DELETE FROM watch_matches WHERE watch_id = ?", id)
+		return err
+	})
+	return found, err
+}
+
+// AdvanceWatch moves a watch's cursor and records the matches found before
+// it, in one transaction. Sources matched already are not recorded again.
+func (db *DB) AdvanceWatch(ctx context.Context, id string, cursor int64, matches []WatchMatch) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, m := range matches {
+			_, err := tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO watch_matches (watch_id, user_id, source_id, score, matched_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, m.WatchID, m.UserID, m.SourceID, m.Score, m.MatchedAt)
+			if err != nil {
+				return fmt.Errorf("failed to record match of watch %s: %w", id, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE watches SET cursor = ? WHERE id = ?", cursor, id); err != nil {
+			return fmt.Errorf("failed to advance watch %s: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// WatchMatches returns up to limit matches after the since sequence number,
+// in order: those of a watch, or of every watch of a user when watchID is
+// empty
+func (db *DB) WatchMatches(ctx context.Context, userID, watchID string, since int64, limit int) ([]WatchMatch, error) {
+	query := `SELECT ` + watchMatchColumns + ` FROM watch_matches WHERE user_id = ? AND seq > ? ORDER BY seq LIMIT ?`
+	args := []any{userID, since, limit}
+	if watchID != "" {
+		query = `SELECT ` + watchMatchColumns + ` FROM watch_matches WHERE user_id = ? AND watch_id = ? AND seq > ? ORDER BY seq LIMIT ?`
+		args = []any{userID, watchID, since, limit}
+	}
+	return db.watchMatches(ctx, query, args...)
+}
+
+// LastWatchMatch returns the sequence number of a user's last match, 0 if
+// there is none
+func (db *DB) LastWatchMatch(ctx context.Context, userID string) (int64, error) {
+	var seq int64
+	err := db.queryRow(ctx, "SELECT COALESCE(MAX(seq), 0) FROM watch_matches WHERE user_id = ?", userID).Scan(&seq)
+	return seq, err
+}
+
+// UndeliveredWatchMatches returns the matches of watches with a URL that
+// weren't delivered yet and have failed fewer than maxAttempts times, in
+// order
+func (db *DB) UndeliveredWatchMatches(ctx context.Context, maxAttempts int) ([]WatchMatch, error) {
+	return db.watchMatches(ctx, `
+		SELECT `+watchMatchColumns+` FROM watch_matches
+		WHERE delivered_at IS NULL AND attempts < ?
+			AND watch_id IN (SELECT id FROM watches WHERE url != '')
+		ORDER BY seq
+	`, maxAttempts)
+}
+
+func (db *DB) watchMatches(ctx context.Context, query string, args ...any) ([]WatchMatch, error) {
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []WatchMatch{}
+	for rows.Next() {
+		m, err := scanWatchMatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// RecordWatchDelivery records an attempt to deliver a match at a time: a
+// success when errMsg is empty, a failure otherwise
+func (db *DB) RecordWatchDelivery(ctx context.Context, seq int64, at, errMsg string) error {
+	query := `UPDATE watch_matches SET delivered_at = ?, last_attempt_at = ?, last_error = NULL WHERE seq = ?`
+	args := []any{at, at, seq}
+	if errMsg != "" {
+		query = `UPDATE watch_matches SET attempts = attempts + 1, last_attempt_at = ?, last_error = ? WHERE seq = ?`
+		args = []any{at, errMsg, seq}
+	}
+	if _, err := db.conn.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record delivery of match %d: %w", seq, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Webhook is an endpoint that receives the events of the event log as
+// signed JSON (see package webhook). Deliveries follow the log in order
+// from Cursor, so a failing endpoint catches up once it recovers. Like API
+// keys, webhooks are configuration and not recorded in the event log.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events"` // Webhook event types; empty means all
+	// Secret signs the deliveries; it is shown once, when the webhook is
+	// created
+	Secret    string `json:"-"`
+	CreatedAt string `json:"created_at"`
+	// Cursor is the sequence number of the last event handled: delivered,
+	// or skipped as not subscribed to
+	Cursor         int64  `json:"cursor"`
+	LastDeliveryAt string `json:"last_delivery_at,omitempty"`
+	LastStatus     int    `json:"last_status,omitempty"` // HTTP status of the last attempt
+	LastError      string `json:"last_error,omitempty"`
+	Failures       int    `json:"failures"` // Consecutive failed attempts
+}
+
+// Subscribes reports whether the webhook receives events of a type
+func (h Webhook) Subscribes(eventType string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, eventType)
+}
+
+// WebhookAttempt is the outcome of a delivery attempt
+type WebhookAttempt struct {
+	Cursor    int64 // The cursor after the attempt
+	At        string
+	Status    int
+	Error     string // Empty for a successful delivery
+	Delivered bool   // Whether an event was sent, not only skipped
+}
+
+const webhookColumns = "id, url, description, events, secret, created_at, cursor, last_delivery_at, last_status, last_error, failures"
+
+// scanWebhook reads a row of webhookColumns
+func scanWebhook(row interface{ Scan(...any) error }) (Webhook, error) {
+	var h Webhook
+	var events string
+	var lastDeliveryAt, lastError sql.NullString
+	var lastStatus sql.NullInt64
+	if err := row.Scan(&h.ID, &h.URL, &h.Description, &events, &h.Secret, &h.CreatedAt, &h.Cursor,
+		&lastDeliveryAt, &lastStatus, &lastError, &h.Failures); err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal([]byte(events), &h.Events); err != nil {
+		return h, fmt.Errorf("invalid stored events of webhook %s: %w", h.ID, err)
+	}
+	h.LastDeliveryAt, h.LastStatus, h.LastError = lastDeliveryAt.String, int(lastStatus.Int64), lastError.String
+	return h, nil
+}
+
+// CreateWebhook stores a new webhook. Its cursor starts at the head of the
+// event log, so it receives the events from now on. It returns the stored
+// webhook.
+func (db *DB) CreateWebhook(ctx context.Context, hook Webhook) (*Webhook, error) {
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	events, err := json.Marshal(hook.Events)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.conn.ExecContext(ctx, `
+		INSERT INTO webhooks (id, url, description, events, secret, created_at, cursor)
+		VALUES (?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(seq), 0) FROM events))
+	`, hook.ID, hook.URL, hook.Description, string(events), hook.Secret, hook.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store webhook: %w", err)
+	}
+	return db.GetWebhook(ctx, hook.ID)
+}
+
+// ListWebhooks returns every webhook, oldest first
+func (db *DB) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := db.query(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		h, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, rows.Err()
+}
+
+// GetWebhook returns a webhook by ID, or nil if there is none
+func (db *DB) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	h, err := scanWebhook(db.queryRow(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// DeleteWebhook removes a webhook, reporting whether it existed
+func (db *DB) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordWebhookAttempt moves a webhook's cursor and, when an event was
+// sent, records the outcome: a failure counts towards Failures, a success
+// resets it
+func (db *DB) RecordWebhookAttempt(ctx context.Context, id string, a WebhookAttempt) error {
+	var err error
+	switch {
+	case !a.Delivered:
+		_, err = db.conn.ExecContext(ctx, "UPDATE webhooks SET cursor = ? WHERE id = ?", a.Cursor, id)
+	case a.Error == "":
+		_, err = db.conn.ExecContext(ctx, `
+			UPDATE webhooks SET cursor = ?, last_delivery_at = ?, last_status = ?, last_error = NULL, failures = 0
+			WHERE id = ?
+		`, a.Cursor, a.At, a.Status, id)
+	default:
+		_, err = db.conn.ExecContext(ctx, `
+			UPDATE webhooks SET cursor = ?, last_delivery_at = ?, last_status = ?, last_error = ?, failures = failures + 1
+			WHERE id = ?
+		`, a.Cursor, a.At, a.Status, a.Error, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// PreviousEvent returns the last event before seq about an entity, among
+// events of the given types; nil if there is none. Webhooks use it to tell
+// a source's creation from an update.
+func (db *DB) PreviousEvent(ctx context.Context, entityID string, seq int64, types ...string) (*Event, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+	args := []any{entityID, seq}
+	for _, t := range types {
+		args = append(args, t)
+	}
+	var ev Event
+	var data string
+	err := db.queryRow(ctx, `
+		SELECT seq, type, entity_id, COALESCE(data, ''), created_at, prev_hash, hash
+		FROM events WHERE entity_id = ? AND seq < ? AND type IN (?`+strings.Repeat(", ?", len(types)-1)+`)
+		ORDER BY seq DESC LIMIT 1
+	`, args...).Scan(&ev.Seq, &ev.Type, &ev.EntityID, &data, &ev.CreatedAt, &ev.PrevHash, &ev.Hash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data != "" {
+		ev.Data = json.RawMessage(data)
+	}
+	return &ev, nil
+}
@@ -0,0 +1,266 @@
+// Package demo bundles a small sample corpus (sources and articles on a few
+// topics, with precomputed hash embeddings) that cmd/seed-demo loads, so
+// the API can be evaluated without Ollama or content of one's own.
+package demo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+
+	"github.com/gitopedia/knowledge-base/internal/chunk"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+//go:generate go run ../../cmd/seed-demo -regenerate corpus.ndjson
+
+// corpus is the sample corpus, one Record per line
+//
+//go:embed corpus.ndjson
+var corpus []byte
+
+// Dimensions is the size of the corpus vectors
+const Dimensions = embedding.DefaultHashDimensions
+
+// Record types, those of GET /export lines
+const (
+	RecordSource  = "source"
+	RecordArticle = "article"
+)
+
+// Record is a line of the corpus: a GET /export record with the embedding
+// of the source or article. The corpus can be imported with POST /import
+// too, which ignores the embeddings.
+type Record struct {
+	Type    string            `json:"type"`
+	Source  *database.Source  `json:"source,omitempty"`
+	Article *database.Article `json:"article,omitempty"`
+	Vector  *SparseVector     `json:"vector,omitempty"`
+}
+
+// SparseVector is an embedding of Dimensions values stored as its nonzero
+// values, which keeps the hash embeddings of the corpus small
+type SparseVector struct {
+	Indices []int     `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+// dense returns the full vector
+func (v SparseVector) dense() []float32 {
+	out := make([]float32, Dimensions)
+	for i, idx := range v.Indices {
+		if idx >= 0 && idx < Dimensions && i < len(v.Values) {
+			out[idx] = v.Values[i]
+		}
+	}
+	return out
+}
+
+// sparse returns the nonzero values of a vector, rounded to 5 decimals
+func sparse(v []float32) *SparseVector {
+	out := &SparseVector{Indices: []int{}, Values: []float32{}}
+	for i, x := range v {
+		if x = float32(math.Round(float64(x)*1e5) / 1e5); x != 0 {
+			out.Indices = append(out.Indices, i)
+			out.Values = append(out.Values, x)
+		}
+	}
+	return out
+}
+
+// SetEnvDefaults selects the hash embedding provider (EMBEDDING_PROVIDER)
+// unless another one is set, so queries are embedded like the corpus
+func SetEnvDefaults() {
+	if os.Getenv("EMBEDDING_PROVIDER") == "" {
+		os.Setenv("EMBEDDING_PROVIDER", embedding.ProviderHash)
+	}
+}
+
+// Records returns the bundled corpus
+func Records() ([]Record, error) {
+	return ReadRecords(bytes.NewReader(corpus))
+}
+
+// ReadRecords reads a corpus, one record per line
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if (rec.Type == RecordSource) != (rec.Source != nil) || (rec.Type == RecordArticle) != (rec.Article != nil) ||
+			(rec.Source == nil && rec.Article == nil) {
+			return nil, fmt.Errorf("line %d: invalid %q record", line, rec.Type)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// WriteRecords writes a corpus, one record per line
+func WriteRecords(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Embed computes the vectors of the records with the hash provider and the
+// default embedding strategies, as the server embeds them with
+// EMBEDDING_PROVIDER=hash
+func Embed(ctx context.Context, records []Record) error {
+	embedders := embedding.NewSetWithProvider(embedding.NewHashProvider(Dimensions), "")
+	for i, rec := range records {
+		var doc embedding.Document
+		strategy := embedding.DefaultSourceStrategy
+		if rec.Source != nil {
+			doc = embedding.Document{Title: rec.Source.Title, Summary: rec.Source.EmbeddingText(), Body: rec.Source.Summary}
+		} else {
+			doc = embedding.Document{Title: rec.Article.Title, Summary: rec.Article.Summary, Body: rec.Article.Content}
+			strategy = embedding.DefaultArticleStrategy
+		}
+		vectors, err := embedders.EmbedDocument(ctx, strategy, doc)
+		if err != nil {
+			return err
+		}
+		for _, v := range vectors {
+			records[i].Vector = sparse(v)
+		}
+	}
+	return nil
+}
+
+// Stats reports what a seeding stored
+type Stats struct {
+	Sources  int
+	Articles int
+}
+
+// Seed stores the bundled corpus in db and its vectors in vectorDB,
+// replacing the records of the same IDs. Every vector space must have
+// Dimensions dimensions. Article chunks, when enabled, are embedded with the
+// hash provider.
+func Seed(ctx context.Context, db store.Store, vectorDB store.VectorStore) (Stats, error) {
+	var stats Stats
+	records, err := Records()
+	if err != nil {
+		return stats, fmt.Errorf("invalid demo corpus: %w", err)
+	}
+	for _, space := range vectorDB.VectorSpaces() {
+		if space.Size != Dimensions {
+			return stats, fmt.Errorf("vector space %s has %d dimensions; the demo corpus needs %d", space.Name, space.Size, Dimensions)
+		}
+	}
+	for _, c := range []struct {
+		collection string
+		strategy   embedding.Strategy
+	}{{"sources", embedding.DefaultSourceStrategy}, {"articles", embedding.DefaultArticleStrategy}} {
+		if err := embedding.CheckStrategy(ctx, db, c.collection, c.strategy); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	var sources []database.Source
+	var articles []database.Article
+	var sourcePoints []vectordb.SourcePoint
+	var articlePoints []vectordb.ArticlePoint
+	for _, rec := range records {
+		if rec.Vector == nil {
+			return stats, fmt.Errorf("demo record %s has no vector", recordID(rec))
+		}
+		vectors := spaceVectors(vectorDB, rec.Vector.dense())
+		if src := rec.Source; src != nil {
+			sources = append(sources, *src)
+			sourcePoints = append(sourcePoints, vectordb.SourcePoint{ID: src.ID, Vectors: vectors, Payload: vectordb.NewSourcePayload(*src)})
+			continue
+		}
+		art := *rec.Article
+		articles = append(articles, art)
+		articlePoints = append(articlePoints, vectordb.ArticlePoint{ID: art.ID, Vectors: vectors, Payload: vectordb.ArticlePayload{
+			ID:        art.ID,
+			Title:     art.Title,
+			Path:      art.Path,
+			Summary:   art.Summary,
+			Tags:      art.Tags,
+			Category:  art.Category(),
+			WordCount: database.WordCount(art.Content),
+		}})
+	}
+
+	if err := db.InsertSources(ctx, sources); err != nil {
+		return stats, fmt.Errorf("failed to store sources: %w", err)
+	}
+	if err := db.InsertArticles(ctx, articles); err != nil {
+		return stats, fmt.Errorf("failed to store articles: %w", err)
+	}
+	if err := vectorDB.UpsertSources(vectordb.WithWait(ctx), sourcePoints); err != nil {
+		return stats, fmt.Errorf("failed to store source vectors: %w", err)
+	}
+	if err := vectorDB.UpsertArticles(vectordb.WithWait(ctx), articlePoints); err != nil {
+		return stats, fmt.Errorf("failed to store article vectors: %w", err)
+	}
+	stats.Sources, stats.Articles = len(sources), len(articles)
+
+	if vectorDB.ChunksEnabled() {
+		opts, err := chunk.OptionsFromEnv()
+		if err != nil {
+			return stats, err
+		}
+		models := vectorDB.VectorNames()
+		if len(models) == 0 {
+			models = []string{""}
+		}
+		embedders := embedding.NewSetWithProvider(embedding.NewHashProvider(Dimensions), models...)
+		for _, art := range articles {
+			points, err := chunk.Points(ctx, embedders, art, opts)
+			if err != nil {
+				return stats, fmt.Errorf("failed to embed chunks of %s: %w", art.ID, err)
+			}
+			if err := vectorDB.ReplaceArticleChunks(vectordb.WithWait(ctx), art.ID, points); err != nil {
+				return stats, fmt.Errorf("failed to store chunks of %s: %w", art.ID, err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// spaceVectors returns the vectors of a point: the corpus vector in every
+// vector space, or as the unnamed vector
+func spaceVectors(vectorDB store.VectorStore, v []float32) vectordb.Vectors {
+	names := vectorDB.VectorNames()
+	if len(names) == 0 {
+		return vectordb.Vectors{"": v}
+	}
+	vectors := make(vectordb.Vectors, len(names))
+	for _, name := range names {
+		vectors[name] = v
+	}
+	return vectors
+}
+
+func recordID(rec Record) string {
+	if rec.Source != nil {
+		return rec.Source.ID
+	}
+	return rec.Article.ID
+}
@@ -1,15 +1,11 @@
-// Package embedding provides a client for generating text embeddings via Ollama.
+// Package embedding provides a client for generating text embeddings via
+// Ollama or an OpenAI-compatible API.
 package embedding
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"time"
 )
 
 const (
@@ -19,113 +15,93 @@ const (
 	DefaultDimension = 768
 )
 
-// Client provides embedding generation via Ollama
+// Client generates embeddings with one model of a Provider
 type Client struct {
-	baseURL    string
-	model      string
-	httpClient *http.Client
+	model     string
+	provider  Provider
+	scheduler *Scheduler // Admits requests by priority; nil admits all
+	breaker   *Breaker   // Retries requests; nil sends each once
 }
 
-// embeddingRequest is the request body for Ollama's /api/embeddings endpoint
-type embeddingRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+// NewClient creates a new embedding client for EMBEDDING_MODEL with the
+// provider selected by EMBEDDING_PROVIDER (see ProviderFromEnv), retrying
+// under the policy of RetryPolicyFromEnv
+func NewClient() *Client {
+	provider := providerFromEnv()
+	c := NewClientWithProvider(provider, os.Getenv("EMBEDDING_MODEL"))
+	c.breaker = breakerFromEnv()
+	return c
 }
 
-// embeddingResponse is the response from Ollama's /api/embeddings endpoint
-type embeddingResponse struct {
-	Embedding []float32 `json:"embedding"`
+// NewClientWithConfig creates a new Ollama embedding client with explicit configuration
+func NewClientWithConfig(baseURL, model string) *Client {
+	return NewClientWithProvider(NewOllamaProvider(baseURL), model)
 }
 
-// NewClient creates a new embedding client
-func NewClient() *Client {
-	baseURL := os.Getenv("OLLAMA_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:11434"
-	}
-
-	model := os.Getenv("EMBEDDING_MODEL")
+// NewClientWithProvider creates a client for a model of the provider. An
+// empty model selects the provider's default model.
+func NewClientWithProvider(provider Provider, model string) *Client {
 	if model == "" {
-		model = DefaultModel
-	}
-
-	return &Client{
-		baseURL: baseURL,
-		model:   model,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		model = defaultModel(provider)
 	}
+	return &Client{model: model, provider: provider}
 }
 
-// NewClientWithConfig creates a new embedding client with explicit configuration
-func NewClientWithConfig(baseURL, model string) *Client {
-	if model == "" {
-		model = DefaultModel
-	}
-	return &Client{
-		baseURL: baseURL,
-		model:   model,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+// defaultModel returns the model used when none is configured
+func defaultModel(provider Provider) string {
+	if provider.Name() == ProviderOpenAI {
+		return DefaultOpenAIModel
 	}
+	return DefaultModel
 }
 
 // Embed generates an embedding vector for the given text
 func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
-	reqBody := embeddingRequest{
-		Model:  c.model,
-		Prompt: text,
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
+	embeddings, err := c.embed(ctx, []string{text})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
+	return embeddings[0], nil
+}
 
-	url := c.baseURL + "/api/embeddings"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// EmbedBatch generates embeddings for multiple texts, in one request if the
+// provider supports batches
+func (c *Client) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	embeddings, err := c.embed(ctx, texts)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	var embResp embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to embed %d texts: %w", len(texts), err)
 	}
+	return embeddings, nil
+}
 
-	if len(embResp.Embedding) == 0 {
-		return nil, fmt.Errorf("empty embedding returned")
+// embed calls the provider through the breaker, once the scheduler admits
+// each attempt
+func (c *Client) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if c.breaker == nil {
+		return c.attempt(ctx, texts)
 	}
-
-	return embResp.Embedding, nil
+	var embeddings [][]float32
+	err := c.breaker.do(ctx, func() error {
+		var err error
+		embeddings, err = c.attempt(ctx, texts)
+		return err
+	})
+	return embeddings, err
 }
 
-// EmbedBatch generates embeddings for multiple texts
-// Note: Ollama doesn't have native batch support, so this calls Embed sequentially
-func (c *Client) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
-	for i, text := range texts {
-		emb, err := c.Embed(ctx, text)
+// attempt calls the provider once the scheduler admits the request
+func (c *Client) attempt(ctx context.Context, texts []string) ([][]float32, error) {
+	if c.scheduler != nil {
+		release, err := c.scheduler.acquire(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+			return nil, err
 		}
-		embeddings[i] = emb
+		defer release()
 	}
-	return embeddings, nil
+	return c.provider.Embed(ctx, c.model, texts)
 }
 
 // Model returns the embedding model being used
@@ -133,8 +109,22 @@ func (c *Client) Model() string {
 	return c.model
 }
 
-// Dimension returns the expected embedding dimension
+// Provider returns the provider generating the embeddings
+func (c *Client) Provider() Provider {
+	return c.provider
+}
+
+// Check returns an error if the client's model can't be used
+func (c *Client) Check(ctx context.Context) error {
+	return c.provider.Check(ctx, c.model)
+}
+
+// Dimension returns the expected embedding dimension: the requested
+// dimension of an OpenAI provider, DefaultDimension otherwise
 func (c *Client) Dimension() int {
+	if p, ok := c.provider.(*OpenAIProvider); ok && p.Dimensions() > 0 {
+		return p.Dimensions()
+	}
 	return DefaultDimension
 }
 
@@ -171,3 +161,128 @@ func sqrt(x float32) float32 {
 	return z
 }
 
+// Set groups embedding clients for several models, so a point can carry one
+// named vector per model. The first model is the default.
+type Set struct {
+	clients []*Client
+	routes  map[string]string // Language → model, see Route
+}
+
+// NewSet creates a Set with one client per model, all sharing the provider
+// selected by EMBEDDING_PROVIDER and a breaker with the policy of
+// RetryPolicyFromEnv. Without models it falls back to a single client
+// configured from the environment (see NewClient).
+func NewSet(models ...string) *Set {
+	if len(models) == 0 {
+		return &Set{clients: []*Client{NewClient()}}
+	}
+
+	s := NewSetWithProvider(providerFromEnv(), models...)
+	s.Protect(breakerFromEnv())
+	return s
+}
+
+// NewSetWithProvider creates a Set with one client per model, all sharing
+// the given provider
+func NewSetWithProvider(provider Provider, models ...string) *Set {
+	clients := make([]*Client, len(models))
+	for i, model := range models {
+		clients[i] = NewClientWithProvider(provider, model)
+	}
+	return &Set{clients: clients}
+}
+
+// Default returns the client for the default model
+func (s *Set) Default() *Client {
+	return s.clients[0]
+}
+
+// Get returns the client for the given model, or nil if it isn't configured.
+// An empty model selects the default.
+func (s *Set) Get(model string) *Client {
+	if model == "" {
+		return s.Default()
+	}
+	for _, c := range s.clients {
+		if c.model == model {
+			return c
+		}
+	}
+	return nil
+}
+
+// Models returns the configured model names
+func (s *Set) Models() []string {
+	models := make([]string, len(s.clients))
+	for i, c := range s.clients {
+		models[i] = c.model
+	}
+	return models
+}
+
+// Schedule sends the requests of every client through a scheduler, so
+// they share its limits
+func (s *Set) Schedule(scheduler *Scheduler) {
+	for _, c := range s.clients {
+		c.scheduler = scheduler
+	}
+}
+
+// Scheduler returns the scheduler of the set's requests, or nil
+func (s *Set) Scheduler() *Scheduler {
+	return s.clients[0].scheduler
+}
+
+// Protect sends the requests of every client through a breaker, so they
+// share its retries and state
+func (s *Set) Protect(breaker *Breaker) {
+	for _, c := range s.clients {
+		c.breaker = breaker
+	}
+}
+
+// Breaker returns the breaker of the set's requests, or nil
+func (s *Set) Breaker() *Breaker {
+	return s.clients[0].breaker
+}
+
+// EmbedAll generates an embedding of the text with every model, keyed by model
+func (s *Set) EmbedAll(ctx context.Context, text string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(s.clients))
+	for _, c := range s.clients {
+		emb, err := c.Embed(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.model, err)
+		}
+		vectors[c.model] = emb
+	}
+	return vectors, nil
+}
+
+// Blend returns (1-weight)·â + weight·b̂, where â and b̂ are the unit-length
+// versions of a and b. Searching with the blend under cosine similarity ranks
+// results by the same weighted mix of similarity to a and to b.
+func Blend(a, b []float32, weight float32) []float32 {
+	if len(a) != len(b) {
+		return a
+	}
+
+	na, nb := norm(a), norm(b)
+	if na == 0 || nb == 0 {
+		return a
+	}
+
+	out := make([]float32, len(a))
+	for i := range a {
+		out[i] = (1-weight)*a[i]/na + weight*b[i]/nb
+	}
+	return out
+}
+
+func norm(v []float32) float32 {
+	var sum float32
+	for _, x := range v {
+		sum += x * x
+	}
+	return sqrt(sum)
+}
@@ -0,0 +1,94 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultHashDimensions is the size of hash embeddings, that of the unnamed
+// vector
+const DefaultHashDimensions = 768
+
+// hashPrefixRunes is the length of the word prefixes hashed alongside the
+// words, so inflections of a word share part of their features
+const hashPrefixRunes = 5
+
+// HashProvider embeds text locally by feature hashing: every word (and word
+// prefix) of the text adds a signed count to one dimension. The vectors
+// capture word overlap, not meaning, but need no model server, which makes
+// them fit for demos (see cmd/seed-demo). The model name is ignored.
+type HashProvider struct {
+	dimensions int
+}
+
+// NewHashProvider creates a hash provider of the given dimension, 0 for
+// DefaultHashDimensions
+func NewHashProvider(dimensions int) *HashProvider {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+	return &HashProvider{dimensions: dimensions}
+}
+
+// Name returns ProviderHash
+func (p *HashProvider) Name() string { return ProviderHash }
+
+// Dimensions returns the size of the embeddings
+func (p *HashProvider) Dimensions() int { return p.dimensions }
+
+// Check always succeeds: there is no model to load
+func (p *HashProvider) Check(context.Context, string) error { return nil }
+
+// Embed returns the unit-length hash embedding of every text; texts
+// without words get a zero vector
+func (p *HashProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = p.embed(text)
+	}
+	return embeddings, nil
+}
+
+func (p *HashProvider) embed(text string) []float32 {
+	v := make([]float32, p.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) < 2 {
+			continue
+		}
+		p.add(v, "w:"+word, 1)
+		if len(runes) > hashPrefixRunes {
+			p.add(v, "p:"+string(runes[:hashPrefixRunes]), 0.5)
+		}
+	}
+
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+	return v
+}
+
+// add adds weight to the dimension of a feature, with the sign of its hash
+func (p *HashProvider) add(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(p.dimensions)] += weight
+}
@@ -0,0 +1,62 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeLanguage reduces a language tag to its lowercase primary
+// subtag ("de-AT" → "de")
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// ParseLanguageRoutes parses a language → model mapping such as
+// "de=multilingual-e5,fr=multilingual-e5". Empty input means no routes.
+func ParseLanguageRoutes(s string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		language, model, ok := strings.Cut(part, "=")
+		language, model = NormalizeLanguage(language), strings.TrimSpace(model)
+		if !ok || language == "" || model == "" {
+			return nil, fmt.Errorf("expected language=model, got %q", part)
+		}
+		routes[language] = model
+	}
+	return routes, nil
+}
+
+// Route sends texts in the given languages to the vector spaces of their
+// models, e.g. a multilingual model for non-English queries. Every routed
+// model must be one of the set's models.
+func (s *Set) Route(routes map[string]string) error {
+	for language, model := range routes {
+		if s.Get(model) == nil {
+			return fmt.Errorf("language %s is routed to %s, which is not a configured embedding model", language, model)
+		}
+	}
+	s.routes = routes
+	return nil
+}
+
+// ForLanguage returns the client for texts in the given language: the model
+// routed to it, or the default
+func (s *Set) ForLanguage(language string) *Client {
+	if model, ok := s.routes[NormalizeLanguage(language)]; ok {
+		return s.Get(model)
+	}
+	return s.Default()
+}
+
+// Routes returns the language → model routes
+func (s *Set) Routes() map[string]string {
+	return s.routes
+}
@@ -0,0 +1,176 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultOpenAIURL is the default address of the OpenAI-compatible API
+	DefaultOpenAIURL = "https://api.openai.com"
+	// DefaultOpenAIModel is the default model of the OpenAI provider
+	DefaultOpenAIModel = "text-embedding-3-small"
+	// maxOpenAIInputs is the most texts OpenAI accepts in one request
+	maxOpenAIInputs = 2048
+)
+
+// OpenAIProvider generates embeddings with an OpenAI-compatible
+// POST /v1/embeddings endpoint (OpenAI, Azure-style gateways, vLLM, LocalAI,
+// text-embeddings-inference, ...)
+type OpenAIProvider struct {
+	baseURL    string
+	apiKey     string
+	dimensions int // 0 leaves the dimension to the model
+	httpClient *http.Client
+}
+
+// openAIRequest is the request body of POST /v1/embeddings
+type openAIRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// openAIResponse is the response of POST /v1/embeddings
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL (without the
+// /v1 suffix). dimensions asks the model for shortened embeddings; 0 uses
+// the model's native size.
+func NewOpenAIProvider(baseURL, apiKey string, dimensions int) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		apiKey:     apiKey,
+		dimensions: dimensions,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// OpenAIProviderFromEnv creates an OpenAI provider from EMBEDDING_API_URL
+// (DefaultOpenAIURL when unset), EMBEDDING_API_KEY (falling back to
+// OPENAI_API_KEY) and EMBEDDING_DIMENSIONS
+func OpenAIProviderFromEnv() (*OpenAIProvider, error) {
+	baseURL := os.Getenv("EMBEDDING_API_URL")
+	if baseURL == "" {
+		baseURL = DefaultOpenAIURL
+	}
+	apiKey := os.Getenv("EMBEDDING_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	dimensions, err := parseDimensions(os.Getenv("EMBEDDING_DIMENSIONS"))
+	if err != nil {
+		return nil, err
+	}
+	return NewOpenAIProvider(baseURL, apiKey, dimensions), nil
+}
+
+// Name returns ProviderOpenAI
+func (p *OpenAIProvider) Name() string { return ProviderOpenAI }
+
+// Dimensions returns the requested embedding dimension, 0 for the model's
+// native size
+func (p *OpenAIProvider) Dimensions() int { return p.dimensions }
+
+// Check returns an error unless the API answers GET /v1/models/{model}
+// for the model
+func (p *OpenAIProvider) Check(ctx context.Context, model string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/v1/models/"+url.PathEscape(model), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("model %s not found", model)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("openai API error (status %d): %s", resp.StatusCode, string(body))
+	}
+}
+
+// Embed generates the embeddings of the texts in as few requests as the API
+// allows
+func (p *OpenAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxOpenAIInputs {
+		batch, err := p.embed(ctx, model, texts[start:min(start+maxOpenAIInputs, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (p *OpenAIProvider) embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(openAIRequest{Model: model, Input: texts, Dimensions: p.dimensions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/embeddings", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Provider: ProviderOpenAI, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var embResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// The data entries carry the index of their input and aren't
+	// guaranteed to be in input order
+	embeddings := make([][]float32, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	for i, emb := range embeddings {
+		if len(emb) == 0 {
+			return nil, fmt.Errorf("empty embedding returned for text %d", i)
+		}
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,227 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider names accepted by EMBEDDING_PROVIDER
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	// ProviderHash embeds locally without a model, see HashProvider
+	ProviderHash = "hash"
+)
+
+// DefaultOllamaURL is the default address of the Ollama API
+const DefaultOllamaURL = "http://localhost:11434"
+
+// Provider is an embedding backend. Clients of every model share one.
+type Provider interface {
+	// Embed generates one embedding per text with the given model
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+	// Name returns the provider name, e.g. ProviderOllama
+	Name() string
+	// Check returns an error if the model can't be used, without
+	// generating embeddings
+	Check(ctx context.Context, model string) error
+}
+
+// ProviderFromEnv returns the provider selected by EMBEDDING_PROVIDER
+// (ProviderOllama when unset). Ollama is reached at OLLAMA_URL; see
+// OpenAIProviderFromEnv for the OpenAI-compatible settings. Hash embeddings
+// are EMBEDDING_DIMENSIONS long (default DefaultHashDimensions).
+func ProviderFromEnv() (Provider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("EMBEDDING_PROVIDER"))); name {
+	case "", ProviderOllama:
+		baseURL := os.Getenv("OLLAMA_URL")
+		if baseURL == "" {
+			baseURL = DefaultOllamaURL
+		}
+		return NewOllamaProvider(baseURL), nil
+	case ProviderOpenAI:
+		return OpenAIProviderFromEnv()
+	case ProviderHash:
+		dimensions, err := parseDimensions(os.Getenv("EMBEDDING_DIMENSIONS"))
+		if err != nil {
+			return nil, err
+		}
+		return NewHashProvider(dimensions), nil
+	default:
+		return nil, fmt.Errorf("invalid EMBEDDING_PROVIDER: %s (expected %s, %s or %s)", name, ProviderOllama, ProviderOpenAI, ProviderHash)
+	}
+}
+
+// providerFromEnv is ProviderFromEnv for constructors that can't return an
+// error: a misconfigured provider, or retry policy (see RetryPolicyFromEnv),
+// fails every embedding with the error
+func providerFromEnv() Provider {
+	p, err := ProviderFromEnv()
+	if err == nil {
+		_, err = RetryPolicyFromEnv()
+	}
+	if err != nil {
+		return brokenProvider{err}
+	}
+	return p
+}
+
+// brokenProvider is a provider whose configuration is invalid
+type brokenProvider struct{ err error }
+
+func (p brokenProvider) Embed(context.Context, string, []string) ([][]float32, error) {
+	return nil, p.err
+}
+
+func (p brokenProvider) Name() string { return "invalid" }
+
+func (p brokenProvider) Check(context.Context, string) error { return p.err }
+
+// OllamaProvider generates embeddings with Ollama's /api/embeddings
+// endpoint
+type OllamaProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// embeddingRequest is the request body for Ollama's /api/embeddings endpoint
+type embeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// embeddingResponse is the response from Ollama's /api/embeddings endpoint
+type embeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// NewOllamaProvider creates an Ollama provider for the API at baseURL
+func NewOllamaProvider(baseURL string) *OllamaProvider {
+	return &OllamaProvider{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Name returns ProviderOllama
+func (p *OllamaProvider) Name() string { return ProviderOllama }
+
+// Embed generates the embeddings one text at a time, as Ollama's
+// /api/embeddings has no batch input
+func (p *OllamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		emb, err := p.embed(ctx, model, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = emb
+	}
+	return embeddings, nil
+}
+
+func (p *OllamaProvider) embed(ctx context.Context, model, text string) ([]float32, error) {
+	jsonBody, err := json.Marshal(embeddingRequest{Model: model, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/embeddings", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Provider: ProviderOllama, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var embResp embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(embResp.Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+
+	return embResp.Embedding, nil
+}
+
+// ollamaTagsResponse is the response of Ollama's /api/tags endpoint
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// Check returns an error unless Ollama answers and has pulled the model
+// (with or without its :latest tag)
+func (p *OllamaProvider) Check(ctx context.Context, model string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, m := range tags.Models {
+		if m.Name == model || m.Name == model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %s is not pulled", model)
+}
+
+// APIError is an embedding request the provider answered with an error
+// status. Rate limits and server errors, such as Ollama's while it loads a
+// model, are transient (see RetryPolicy).
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// parseDimensions reads an optional positive dimension count
+func parseDimensions(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid EMBEDDING_DIMENSIONS: %s", s)
+	}
+	return n, nil
+}
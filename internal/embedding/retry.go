@@ -0,0 +1,292 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRetryBackoff caps the doubled delay between retries
+const maxRetryBackoff = 10 * time.Second
+
+// probeWait is how often requests waiting on an open breaker look again
+// while another request probes the provider
+const probeWait = time.Second
+
+// ErrCircuitOpen is returned for requests the breaker stops while the
+// provider is failing
+var ErrCircuitOpen = errors.New("embedding provider unavailable (circuit breaker open)")
+
+// RetryPolicy configures how embedding requests that fail with a transient
+// error are retried, and when the circuit breaker stops sending them. An
+// error is transient when the provider can't be reached or answers a rate
+// limit or server error status, as Ollama does while it loads a model.
+type RetryPolicy struct {
+	Retries int           // Retries after the first attempt; 0 disables them
+	Backoff time.Duration // Delay before the first retry, doubled for each next one (jittered)
+	// Threshold is the number of consecutive transient failures that trips
+	// the breaker; 0 disables it
+	Threshold int
+	// Cooldown is how long a tripped breaker stops requests before letting
+	// one through to probe the provider
+	Cooldown time.Duration
+}
+
+// DefaultRetryPolicy retries four times, after about 0.5s, 1s, 2s and 4s,
+// and trips the breaker after five consecutive failures for 30s
+var DefaultRetryPolicy = RetryPolicy{Retries: 4, Backoff: 500 * time.Millisecond, Threshold: 5, Cooldown: 30 * time.Second}
+
+// RetryPolicyFromEnv reads EMBEDDING_RETRIES, EMBEDDING_RETRY_BACKOFF,
+// EMBEDDING_BREAKER_THRESHOLD and EMBEDDING_BREAKER_COOLDOWN, defaulting to
+// DefaultRetryPolicy
+func RetryPolicyFromEnv() (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	if s := os.Getenv("EMBEDDING_RETRIES"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid EMBEDDING_RETRIES: %s", s)
+		}
+		policy.Retries = n
+	}
+	if s := os.Getenv("EMBEDDING_RETRY_BACKOFF"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return policy, fmt.Errorf("invalid EMBEDDING_RETRY_BACKOFF: %s", s)
+		}
+		policy.Backoff = d
+	}
+	if s := os.Getenv("EMBEDDING_BREAKER_THRESHOLD"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid EMBEDDING_BREAKER_THRESHOLD: %s", s)
+		}
+		policy.Threshold = n
+	}
+	if s := os.Getenv("EMBEDDING_BREAKER_COOLDOWN"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return policy, fmt.Errorf("invalid EMBEDDING_BREAKER_COOLDOWN: %s", s)
+		}
+		policy.Cooldown = d
+	}
+	return policy, nil
+}
+
+// breakerFromEnv is a breaker with the RetryPolicyFromEnv policy for
+// constructors that can't return an error; providerFromEnv reports an
+// invalid policy
+func breakerFromEnv() *Breaker {
+	policy, err := RetryPolicyFromEnv()
+	if err != nil {
+		policy = DefaultRetryPolicy
+	}
+	return NewBreaker(policy)
+}
+
+// Breaker retries the embedding requests of a provider under a RetryPolicy
+// and trips after consecutive transient failures. While it is open,
+// interactive requests fail at once with ErrCircuitOpen, so searches don't
+// hang on a provider that is down, while ingest and background requests
+// wait (within their context) for it to recover, so bulk work survives a
+// provider restart. After the cooldown one request probes the provider: its
+// success closes the breaker, its failure opens it again. One breaker is
+// shared by the clients of a provider (see Set.Protect).
+type Breaker struct {
+	policy RetryPolicy
+
+	mu       sync.Mutex
+	open     bool
+	openedAt time.Time
+	probing  bool // A request is probing the provider
+	failures int  // Consecutive transient failures
+
+	requests, retries, failed, rejected, trips atomic.Int64
+}
+
+// NewBreaker creates a breaker with the given policy
+func NewBreaker(policy RetryPolicy) *Breaker {
+	return &Breaker{policy: policy}
+}
+
+// Policy returns the breaker's policy
+func (b *Breaker) Policy() RetryPolicy {
+	return b.policy
+}
+
+// do runs a request, retrying transient failures
+func (b *Breaker) do(ctx context.Context, op func() error) error {
+	b.requests.Add(1)
+	wait := PriorityOf(ctx) != PriorityInteractive
+	backoff := b.policy.Backoff
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		probe, err := b.admit(ctx, wait)
+		if err != nil {
+			b.failed.Add(1)
+			if errors.Is(err, ErrCircuitOpen) {
+				b.rejected.Add(1)
+				if lastErr != nil {
+					err = fmt.Errorf("%w: %v", err, lastErr)
+				}
+			}
+			return err
+		}
+		err = op()
+		if err != nil && ctx.Err() != nil {
+			// Cancelled: says nothing of the provider
+			b.release(probe)
+			b.failed.Add(1)
+			return err
+		}
+		retry := err != nil && transient(err)
+		b.record(probe, retry)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= b.policy.Retries {
+			b.failed.Add(1)
+			return err
+		}
+
+		lastErr = err
+		b.retries.Add(1)
+		select {
+		case <-time.After(jitter(backoff)):
+		case <-ctx.Done():
+			b.failed.Add(1)
+			return err
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// admit waits, if wait is set, until the breaker lets a request through,
+// and reports whether the request is the probe of an open breaker
+func (b *Breaker) admit(ctx context.Context, wait bool) (bool, error) {
+	for {
+		b.mu.Lock()
+		delay := time.Duration(0)
+		probe := false
+		switch {
+		case !b.open:
+		case time.Since(b.openedAt) < b.policy.Cooldown:
+			delay = b.policy.Cooldown - time.Since(b.openedAt)
+		case b.probing:
+			delay = probeWait
+		default:
+			b.probing, probe = true, true
+		}
+		b.mu.Unlock()
+		if delay == 0 {
+			return probe, nil
+		}
+		if !wait {
+			return false, ErrCircuitOpen
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// record counts the outcome of an attempt: a transient failure, or an
+// answer from the provider, which closes the breaker
+func (b *Breaker) record(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !failed {
+		b.open, b.failures = false, 0
+		return
+	}
+	b.failures++
+	if probe || (!b.open && b.policy.Threshold > 0 && b.failures >= b.policy.Threshold) {
+		if !b.open {
+			b.trips.Add(1)
+		}
+		b.open, b.openedAt = true, time.Now()
+	}
+}
+
+// release gives up the probe of an attempt that was cancelled
+func (b *Breaker) release(probe bool) {
+	if probe {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+	}
+}
+
+// transient reports whether a failed request may succeed when retried
+func transient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// The provider couldn't be reached: refused, reset or timed out
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// jitter returns a delay between half of d and d, so clients retrying
+// together don't hit a recovering provider at once
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// RequestStats counts the embedding requests of a breaker since it was
+// created, with the breaker's state
+type RequestStats struct {
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+	Failures int64 `json:"failures"` // Requests that failed after every attempt
+	Rejected int64 `json:"rejected"` // Failures stopped by the open breaker
+	Trips    int64 `json:"trips"`    // Times the breaker opened
+	// Breaker is closed, open or half-open (letting a probe through)
+	Breaker             string `json:"breaker"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	OpenedAt            string `json:"opened_at,omitempty"` // While open
+}
+
+// Stats returns the breaker's request counts and state
+func (b *Breaker) Stats() RequestStats {
+	stats := RequestStats{
+		Requests: b.requests.Load(),
+		Retries:  b.retries.Load(),
+		Failures: b.failed.Load(),
+		Rejected: b.rejected.Load(),
+		Trips:    b.trips.Load(),
+		Breaker:  "closed",
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats.ConsecutiveFailures = b.failures
+	if b.open {
+		stats.Breaker = "open"
+		if time.Since(b.openedAt) >= b.policy.Cooldown {
+			stats.Breaker = "half-open"
+		}
+		stats.OpenedAt = b.openedAt.UTC().Format(time.RFC3339)
+	}
+	return stats
+}
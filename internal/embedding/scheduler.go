@@ -0,0 +1,217 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Priority is the class of an embedding request. Interactive requests are
+// served first, then ingest, then background work.
+type Priority int
+
+// Priorities, highest first
+const (
+	// PriorityInteractive is for queries a user is waiting on, such as
+	// searches; it is the priority of requests without one
+	PriorityInteractive Priority = iota
+	// PriorityIngest is for sources written through the API
+	PriorityIngest
+	// PriorityBackground is for bulk work: imports, topic reassignment and
+	// other re-embeds
+	PriorityBackground
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"interactive", "ingest", "background"}
+
+// String returns the priority's name, as used in EMBEDDING_CONCURRENCY
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return "priority(" + strconv.Itoa(int(p)) + ")"
+	}
+	return priorityNames[p]
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose embedding requests have the given
+// priority
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityOf returns the priority of a context's embedding requests
+// (PriorityInteractive when unset)
+func PriorityOf(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// Limits is the number of embedding requests each priority may have in
+// flight. The largest limit is the provider's capacity: a request waits
+// while the capacity is used up, and waiting requests of higher priority
+// get the next free slot, so bulk work never crowds out searches.
+type Limits [numPriorities]int
+
+// DefaultLimits leaves interactive requests at least two slots
+var DefaultLimits = Limits{PriorityInteractive: 8, PriorityIngest: 4, PriorityBackground: 2}
+
+// ParseLimits parses per-priority limits such as
+// "interactive=8,ingest=4,background=2"; priorities left out keep their
+// DefaultLimits
+func ParseLimits(s string) (Limits, error) {
+	limits := DefaultLimits
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n < 1 {
+			return limits, fmt.Errorf("expected priority=limit with a positive limit, got %q", part)
+		}
+		p := Priority(-1)
+		for i, pn := range priorityNames {
+			if strings.EqualFold(strings.TrimSpace(name), pn) {
+				p = Priority(i)
+			}
+		}
+		if p < 0 {
+			return limits, fmt.Errorf("unknown priority %q (expected %s)", name, strings.Join(priorityNames[:], ", "))
+		}
+		limits[p] = n
+	}
+	return limits, nil
+}
+
+// Scheduler admits embedding requests by priority, see Limits. One
+// scheduler is shared by the clients of a provider (see Set.Schedule).
+type Scheduler struct {
+	limits   Limits
+	capacity int
+
+	mu      sync.Mutex
+	running [numPriorities]int
+	total   int
+	waiting [numPriorities]*list.List // Of chan struct{}, oldest first
+}
+
+// NewScheduler creates a scheduler with the given limits
+func NewScheduler(limits Limits) *Scheduler {
+	s := &Scheduler{limits: limits}
+	for p := range numPriorities {
+		s.capacity = max(s.capacity, limits[p])
+		s.waiting[p] = list.New()
+	}
+	return s
+}
+
+// SchedulerFromEnv creates a scheduler with the limits of
+// EMBEDDING_CONCURRENCY (see ParseLimits)
+func SchedulerFromEnv() (*Scheduler, error) {
+	limits, err := ParseLimits(os.Getenv("EMBEDDING_CONCURRENCY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMBEDDING_CONCURRENCY: %w", err)
+	}
+	return NewScheduler(limits), nil
+}
+
+// Limits returns the scheduler's limits
+func (s *Scheduler) Limits() Limits {
+	return s.limits
+}
+
+// acquire waits for a slot for a request of the context's priority. The
+// returned function releases it.
+func (s *Scheduler) acquire(ctx context.Context) (func(), error) {
+	p := PriorityOf(ctx)
+	if p < 0 || p >= numPriorities {
+		p = PriorityBackground
+	}
+	release := func() { s.release(p) }
+
+	s.mu.Lock()
+	if s.admits(p) {
+		s.running[p]++
+		s.total++
+		s.mu.Unlock()
+		return release, nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiting[p].PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Admitted while giving up: pass the slot on
+			s.mu.Unlock()
+			release()
+		default:
+			s.waiting[p].Remove(elem)
+			s.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// admits reports whether a request of priority p may start now: there is
+// capacity, p is below its limit, and no request of higher or equal
+// priority is waiting for the slot. Callers hold mu.
+func (s *Scheduler) admits(p Priority) bool {
+	if s.total >= s.capacity || s.running[p] >= s.limits[p] {
+		return false
+	}
+	for q := PriorityInteractive; q <= p; q++ {
+		if s.waiting[q].Len() > 0 && s.running[q] < s.limits[q] {
+			return false
+		}
+	}
+	return true
+}
+
+// release frees a slot of priority p and admits waiting requests, highest
+// priority first
+func (s *Scheduler) release(p Priority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[p]--
+	s.total--
+	for q := PriorityInteractive; q < numPriorities && s.total < s.capacity; q++ {
+		for s.waiting[q].Len() > 0 && s.total < s.capacity && s.running[q] < s.limits[q] {
+			front := s.waiting[q].Front()
+			s.waiting[q].Remove(front)
+			s.running[q]++
+			s.total++
+			close(front.Value.(chan struct{}))
+		}
+	}
+}
+
+// QueueStats are the requests of a priority in flight and waiting
+type QueueStats struct {
+	Limit   int `json:"limit"`
+	Running int `json:"running"`
+	Waiting int `json:"waiting"`
+}
+
+// Stats returns the requests in flight and waiting, by priority name
+func (s *Scheduler) Stats() map[string]QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[string]QueueStats, numPriorities)
+	for p := range numPriorities {
+		stats[p.String()] = QueueStats{Limit: s.limits[p], Running: s.running[p], Waiting: s.waiting[p].Len()}
+	}
+	return stats
+}
@@ -0,0 +1,215 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Strategy selects the text embedded for a document
+type Strategy string
+
+const (
+	// StrategySummary embeds the summary alone
+	StrategySummary Strategy = "summary"
+	// StrategyTitleSummary embeds the title and the summary
+	StrategyTitleSummary Strategy = "title-summary"
+	// StrategyPreview embeds the title, the summary and the first part of
+	// the body (see ArticleText)
+	StrategyPreview Strategy = "preview"
+	// StrategyChunkedBody embeds every chunk of the body and averages the
+	// chunk vectors into the document's vector
+	StrategyChunkedBody Strategy = "chunked-body"
+)
+
+// Default strategies, which match how points were embedded before the
+// strategy was configurable
+const (
+	DefaultSourceStrategy  = StrategySummary
+	DefaultArticleStrategy = StrategyPreview
+)
+
+const (
+	// chunkChars is the target length of body chunks
+	chunkChars = 1000
+	// maxChunks bounds the embedding calls made for one document; the rest
+	// of a longer body is not embedded
+	maxChunks = 32
+)
+
+// ParseStrategy parses a strategy name; empty selects def
+func ParseStrategy(s string, def Strategy) (Strategy, bool) {
+	switch st := Strategy(strings.ToLower(strings.TrimSpace(s))); st {
+	case "":
+		return def, true
+	case StrategySummary, StrategyTitleSummary, StrategyPreview, StrategyChunkedBody:
+		return st, true
+	}
+	return def, false
+}
+
+// Strategies are the embedding text strategies of the collections
+type Strategies struct {
+	Sources  Strategy
+	Articles Strategy
+}
+
+// StrategiesFromEnv reads the strategies from KB_SOURCE_EMBEDDING_TEXT and
+// KB_ARTICLE_EMBEDDING_TEXT, defaulting to DefaultSourceStrategy and
+// DefaultArticleStrategy
+func StrategiesFromEnv() (Strategies, error) {
+	var strategies Strategies
+	var ok bool
+	if strategies.Sources, ok = ParseStrategy(os.Getenv("KB_SOURCE_EMBEDDING_TEXT"), DefaultSourceStrategy); !ok {
+		return strategies, fmt.Errorf("invalid KB_SOURCE_EMBEDDING_TEXT: %s", os.Getenv("KB_SOURCE_EMBEDDING_TEXT"))
+	}
+	if strategies.Articles, ok = ParseStrategy(os.Getenv("KB_ARTICLE_EMBEDDING_TEXT"), DefaultArticleStrategy); !ok {
+		return strategies, fmt.Errorf("invalid KB_ARTICLE_EMBEDDING_TEXT: %s", os.Getenv("KB_ARTICLE_EMBEDDING_TEXT"))
+	}
+	return strategies, nil
+}
+
+// Document is the text of a source or article that can be embedded. For
+// sources, Summary is the official abstract when there is one and Body is
+// the stored summary.
+type Document struct {
+	Title   string
+	Summary string
+	Body    string
+}
+
+// Texts returns the texts embedded for the document: one, or one per chunk
+// of the body for StrategyChunkedBody
+func (st Strategy) Texts(doc Document) []string {
+	switch st {
+	case StrategyTitleSummary:
+		return []string{strings.TrimSpace(doc.Title + " " + doc.Summary)}
+	case StrategyPreview:
+		return []string{ArticleText(doc.Title, doc.Summary, doc.Body)}
+	case StrategyChunkedBody:
+		if chunks := Chunk(doc.Body, chunkChars); len(chunks) > 0 {
+			return chunks[:min(len(chunks), maxChunks)]
+		}
+		// Nothing to chunk: fall back to what is known about the document
+		return []string{strings.TrimSpace(doc.Title + " " + doc.Summary)}
+	}
+	return []string{doc.Summary}
+}
+
+// Chunk splits text into chunks of about size characters, at paragraph
+// breaks where possible and otherwise between words
+func Chunk(text string, size int) []string {
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+	}
+
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if cur.Len() > 0 && cur.Len()+len(para)+2 > size {
+			flush()
+		}
+		if len(para) <= size {
+			if cur.Len() > 0 {
+				cur.WriteString("\n\n")
+			}
+			cur.WriteString(para)
+			continue
+		}
+		// Paragraph longer than a chunk: split between words
+		for _, word := range strings.Fields(para) {
+			if cur.Len() > 0 && cur.Len()+len(word)+1 > size {
+				flush()
+			}
+			if cur.Len() > 0 {
+				cur.WriteByte(' ')
+			}
+			cur.WriteString(word)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// EmbedDocument generates the embeddings of a document under a strategy
+// with every model, keyed by model. Chunk vectors are normalized and
+// averaged, so every chunk weighs the same under cosine similarity.
+func (s *Set) EmbedDocument(ctx context.Context, st Strategy, doc Document) (map[string][]float32, error) {
+	texts := st.Texts(doc)
+	if len(texts) == 1 {
+		return s.EmbedAll(ctx, texts[0])
+	}
+
+	sums := make(map[string][]float32, len(s.clients))
+	for i, text := range texts {
+		vectors, err := s.EmbedAll(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+		for model, v := range vectors {
+			sum := sums[model]
+			if sum == nil {
+				sum = make([]float32, len(v))
+				sums[model] = sum
+			}
+			n := norm(v)
+			if n == 0 || len(v) != len(sum) {
+				continue
+			}
+			for j := range v {
+				sum[j] += v[j] / n
+			}
+		}
+	}
+	for _, sum := range sums {
+		for j := range sum {
+			sum[j] /= float32(len(texts))
+		}
+	}
+	return sums, nil
+}
+
+// Info reads and writes database metadata (see store.InfoStore)
+type Info interface {
+	GetInfo(ctx context.Context, key string) (string, error)
+	SetInfo(ctx context.Context, key, value string) error
+}
+
+// StrategyInfoKey is the db_info key recording the strategy a collection
+// ("sources" or "articles") was embedded with
+func StrategyInfoKey(collection string) string {
+	return "embedding_text." + collection
+}
+
+// RecordStrategy records that every point of the collection was embedded
+// with st, e.g. after re-embedding it
+func RecordStrategy(ctx context.Context, info Info, collection string, st Strategy) error {
+	return info.SetInfo(ctx, StrategyInfoKey(collection), string(st))
+}
+
+// CheckStrategy verifies that new points of the collection are embedded
+// with the strategy recorded for it, recording st if none is. A mismatch
+// means the collection would mix vectors of different texts until it is
+// re-embedded.
+func CheckStrategy(ctx context.Context, info Info, collection string, st Strategy) error {
+	recorded, err := info.GetInfo(ctx, StrategyInfoKey(collection))
+	if err != nil {
+		return err
+	}
+	if recorded == "" {
+		return RecordStrategy(ctx, info, collection, st)
+	}
+	if Strategy(recorded) != st {
+		return fmt.Errorf("%s were embedded with %q text but %q is configured; re-embed them (rebuild -embeddings) to switch",
+			collection, recorded, st)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package embedding
+
+// articlePreviewChars is how much of an article body goes into its embedding
+const articlePreviewChars = 1000
+
+// ArticleText builds the text embedded for an article: title, summary and
+// the first part of the body
+func ArticleText(title, summary, body string) string {
+	text := title
+	if summary != "" {
+		text += " " + summary
+	}
+	if len(body) > 0 {
+		preview := body
+		if len(preview) > articlePreviewChars {
+			preview = preview[:articlePreviewChars]
+		}
+		text += " " + preview
+	}
+	return text
+}
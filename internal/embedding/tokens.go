@@ -0,0 +1,98 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultTokenURL is the default address of the token embedding service
+const DefaultTokenURL = "http://localhost:8090"
+
+// TokenClient generates token-level embeddings (one vector per token, as
+// ColBERT-style late-interaction models produce) from a token embedding
+// service. Ollama only returns one vector per text, so these come from a
+// separate service serving POST /embed/tokens:
+//
+//	{"model": "colbertv2.0", "input": "...", "is_query": true}
+//	→ {"embeddings": [[0.1, ...], [0.3, ...]]}
+type TokenClient struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// tokenRequest is the request body of POST /embed/tokens
+type tokenRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+	// IsQuery selects query encoding: late-interaction models encode
+	// queries and documents differently (e.g. query augmentation)
+	IsQuery bool `json:"is_query"`
+}
+
+// tokenResponse is the response of POST /embed/tokens
+type tokenResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewTokenClient creates a token embedding client for a model, at the
+// address in TOKEN_EMBEDDING_URL (DefaultTokenURL when unset)
+func NewTokenClient(model string) *TokenClient {
+	baseURL := os.Getenv("TOKEN_EMBEDDING_URL")
+	if baseURL == "" {
+		baseURL = DefaultTokenURL
+	}
+	return &TokenClient{
+		baseURL: baseURL,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// EmbedTokens generates the token vectors of a document, or of a query when
+// query is set
+func (c *TokenClient) EmbedTokens(ctx context.Context, text string, query bool) ([][]float32, error) {
+	jsonBody, err := json.Marshal(tokenRequest{Model: c.model, Input: text, IsQuery: query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embed/tokens", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("token embedding API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tokResp tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(tokResp.Embeddings) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+	return tokResp.Embeddings, nil
+}
+
+// Model returns the token embedding model being used
+func (c *TokenClient) Model() string {
+	return c.model
+}
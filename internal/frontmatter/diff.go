@@ -0,0 +1,130 @@
+package frontmatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 3
+
+// Diff returns a unified diff turning old into new, in the format of
+// `git diff`, or "" if they are equal. path is the file's path relative to
+// the repository root. Changes are reported as a single hunk, which suits
+// frontmatter edits: they are all near the top of the file.
+func Diff(path string, old, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	a, aNewline := splitLines(string(old))
+	b, bNewline := splitLines(string(new))
+
+	// Trim the common prefix and suffix, then align the middle
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	// A change of the final newline changes the last line
+	if aNewline != bNewline && suffix > 0 {
+		suffix--
+	}
+
+	start := max(prefix-diffContext, 0)
+	aEnd := min(len(a)-suffix+diffContext, len(a))
+	bEnd := min(len(b)-suffix+diffContext, len(b))
+
+	var hunk strings.Builder
+	line := func(op byte, text string, last, newline bool) {
+		hunk.WriteByte(op)
+		hunk.WriteString(text)
+		hunk.WriteByte('\n')
+		if last && !newline {
+			hunk.WriteString("\\ No newline at end of file\n")
+		}
+	}
+
+	for i := start; i < prefix; i++ {
+		line(' ', a[i], false, true)
+	}
+	for _, op := range align(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		switch op.kind {
+		case ' ':
+			line(' ', op.text, false, true)
+		case '-':
+			line('-', op.text, prefix+op.a == len(a)-1, aNewline)
+		case '+':
+			line('+', op.text, prefix+op.b == len(b)-1, bNewline)
+		}
+	}
+	for i := len(a) - suffix; i < aEnd; i++ {
+		line(' ', a[i], i == len(a)-1, aNewline)
+	}
+
+	return fmt.Sprintf("diff --git a/%[1]s b/%[1]s\n--- a/%[1]s\n+++ b/%[1]s\n@@ -%s +%s @@\n%s",
+		path, hunkRange(start, aEnd-start), hunkRange(start, bEnd-start), hunk.String())
+}
+
+// splitLines splits text into lines and reports whether it ends with a
+// newline
+func splitLines(s string) ([]string, bool) {
+	if s == "" {
+		return nil, true
+	}
+	newline := strings.HasSuffix(s, "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n"), newline
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffOp is a line kept (' '), removed ('-') or added ('+'). a and b are
+// its indexes in the old and new lines.
+type diffOp struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// align returns the edit script between two short runs of lines, from
+// their longest common subsequence
+func align(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,214 @@
+// Package frontmatter edits the YAML frontmatter of Compendium markdown
+// files in place. Edits are made on the text, line by line, so everything
+// that isn't changed keeps its formatting and diffs stay minimal.
+package frontmatter
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a markdown file with YAML frontmatter between "---" lines
+type Document struct {
+	lines []string // Frontmatter lines, without the delimiters
+	rest  string   // Everything after the closing delimiter line
+	root  *yaml.Node
+}
+
+// Parse splits a markdown file into its frontmatter and body. A file without
+// frontmatter gets an empty one.
+func Parse(content []byte) (*Document, error) {
+	s := strings.ReplaceAll(string(content), "\r\n", "\n")
+	d := &Document{rest: s}
+
+	if strings.HasPrefix(s, "---\n") {
+		lines := strings.Split(s, "\n")
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimRight(lines[i], " ") == "---" {
+				d.lines = lines[1:i]
+				d.rest = strings.Join(lines[i+1:], "\n")
+				break
+			}
+		}
+	}
+	if err := d.reparse(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// reparse refreshes the YAML tree after the lines changed
+func (d *Document) reparse() error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(d.lines, "\n")), &doc); err != nil {
+		return fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	d.root = nil
+	if len(doc.Content) > 0 {
+		if doc.Content[0].Kind != yaml.MappingNode {
+			return fmt.Errorf("frontmatter is not a mapping")
+		}
+		d.root = doc.Content[0]
+	}
+	return nil
+}
+
+// Body returns the markdown after the frontmatter
+func (d *Document) Body() string {
+	return d.rest
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	if len(d.lines) == 0 {
+		return []byte(d.rest)
+	}
+	return []byte("---\n" + strings.Join(d.lines, "\n") + "\n---\n" + d.rest)
+}
+
+// lookup returns the key and value nodes of a top-level key
+func (d *Document) lookup(key string) (*yaml.Node, *yaml.Node) {
+	if d.root == nil {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(d.root.Content); i += 2 {
+		if d.root.Content[i].Value == key {
+			return d.root.Content[i], d.root.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// String returns a top-level string value, or "" if it is missing
+func (d *Document) String(key string) string {
+	_, v := d.lookup(key)
+	if v == nil || v.Kind != yaml.ScalarNode || v.Tag == "!!null" {
+		return ""
+	}
+	return v.Value
+}
+
+// List returns a top-level list of strings; a single string counts as a
+// list of one
+func (d *Document) List(key string) []string {
+	_, v := d.lookup(key)
+	if v == nil || v.Tag == "!!null" {
+		return nil
+	}
+	if v.Kind == yaml.ScalarNode {
+		return []string{v.Value}
+	}
+	var values []string
+	for _, item := range v.Content {
+		if item.Kind == yaml.ScalarNode {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
+
+// SetString sets a top-level key to a single-line string if it is missing
+// or empty. It reports whether the document changed.
+func (d *Document) SetString(key, value string) (bool, error) {
+	if d.String(key) != "" {
+		return false, nil
+	}
+	k, v := d.lookup(key)
+	if k != nil && (v.Kind != yaml.ScalarNode || v.Line != k.Line) {
+		return false, fmt.Errorf("%s: unsupported value layout", key)
+	}
+
+	line := key + ": " + scalar(value)
+	if k == nil {
+		d.lines = append(d.lines, line)
+	} else {
+		d.lines[k.Line-1] = strings.Repeat(" ", k.Column-1) + line
+	}
+	return true, d.reparse()
+}
+
+// AddToList adds values missing from a top-level list, creating it if
+// needed. Block lists get new "- item" lines; flow lists and single values
+// are rewritten as a flow list on their line. It reports whether the
+// document changed.
+func (d *Document) AddToList(key string, values []string) (bool, error) {
+	existing := make(map[string]bool)
+	for _, v := range d.List(key) {
+		existing[v] = true
+	}
+	var add []string
+	for _, v := range values {
+		if !existing[v] {
+			existing[v] = true
+			add = append(add, v)
+		}
+	}
+	if len(add) == 0 {
+		return false, nil
+	}
+
+	k, v := d.lookup(key)
+	switch {
+	case k == nil:
+		d.lines = append(d.lines, key+":")
+		d.lines = append(d.lines, items("  ", add)...)
+	case v.Kind == yaml.SequenceNode && v.Style&yaml.FlowStyle == 0 && len(v.Content) > 0:
+		last := v.Content[len(v.Content)-1]
+		if last.Kind != yaml.ScalarNode {
+			return false, fmt.Errorf("%s: unsupported list layout", key)
+		}
+		indent := strings.Repeat(" ", max(last.Column-3, 0))
+		d.insert(last.Line, items(indent, add)...)
+	case v.Tag == "!!null":
+		if v.Line != k.Line {
+			return false, fmt.Errorf("%s: unsupported value layout", key)
+		}
+		d.lines[k.Line-1] = strings.Repeat(" ", k.Column-1) + key + ":"
+		d.insert(k.Line, items(strings.Repeat(" ", k.Column+1), add)...)
+	default:
+		if v.Line != k.Line || (v.Kind == yaml.SequenceNode && lastLine(v) != k.Line) {
+			return false, fmt.Errorf("%s: unsupported list layout", key)
+		}
+		all := append(d.List(key), add...)
+		quoted := make([]string, len(all))
+		for i, item := range all {
+			quoted[i] = scalar(item)
+		}
+		d.lines[k.Line-1] = strings.Repeat(" ", k.Column-1) + key + ": [" + strings.Join(quoted, ", ") + "]"
+	}
+	return true, d.reparse()
+}
+
+// insert inserts lines after the given 1-based frontmatter line
+func (d *Document) insert(after int, lines ...string) {
+	rest := append(lines, d.lines[after:]...)
+	d.lines = append(d.lines[:after:after], rest...)
+}
+
+// lastLine returns the last line a flow sequence's items are on
+func lastLine(v *yaml.Node) int {
+	line := v.Line
+	for _, item := range v.Content {
+		line = max(line, item.Line)
+	}
+	return line
+}
+
+func items(indent string, values []string) []string {
+	lines := make([]string, len(values))
+	for i, v := range values {
+		lines[i] = indent + "- " + scalar(v)
+	}
+	return lines
+}
+
+// scalar renders a string as a YAML scalar, quoting it only when needed
+func scalar(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
@@ -0,0 +1,38 @@
+package frontmatter
+
+import "strings"
+
+// Summary returns the leading sentences of the first prose paragraph
+// of a markdown body that fit in maxChars, or "" if there are none
+func Summary(body string, maxChars int) string {
+	for _, para := range strings.Split(body, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" || strings.ContainsAny(para[:1], "#-*>|![`<") || strings.HasPrefix(para, "1.") {
+			continue
+		}
+		text := strings.Join(strings.Fields(para), " ")
+
+		summary := ""
+		for _, sentence := range sentences(text) {
+			if len(summary)+len(sentence) > maxChars {
+				break
+			}
+			summary += sentence
+		}
+		return strings.TrimSpace(summary)
+	}
+	return ""
+}
+
+// sentences splits text after sentence-ending punctuation followed by a space
+func sentences(text string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		if strings.ContainsRune(".!?", rune(text[i])) && text[i+1] == ' ' {
+			out = append(out, text[start:i+2])
+			start = i + 2
+		}
+	}
+	return append(out, text[start:])
+}
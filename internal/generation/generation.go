@@ -0,0 +1,99 @@
+// Package generation calls text generation models (LLMs) for the features
+// that write text from the knowledge base rather than retrieve it, such as
+// source comparisons. Models are served by Ollama or an OpenAI-compatible
+// chat completions API.
+package generation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Provider names accepted by GENERATION_PROVIDER
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+const (
+	// DefaultOllamaURL is the default address of the Ollama API
+	DefaultOllamaURL = "http://localhost:11434"
+	// DefaultOpenAIURL is the default address of the OpenAI-compatible API
+	DefaultOpenAIURL = "https://api.openai.com"
+)
+
+// Options adjust a generation
+type Options struct {
+	// System is the system prompt
+	System string
+	// JSON asks the model for a JSON object
+	JSON bool
+	// Temperature is the sampling temperature; zero is deterministic as far
+	// as the backend allows
+	Temperature float64
+}
+
+// Generator generates text with one model
+type Generator interface {
+	// Generate returns the model's completion of the prompt
+	Generate(ctx context.Context, prompt string, opts Options) (string, error)
+	// Model returns the name of the model
+	Model() string
+	// Check returns an error if the model can't be used, without
+	// generating text
+	Check(ctx context.Context) error
+}
+
+// FromEnv returns the generator of GENERATION_MODEL with the provider in
+// GENERATION_PROVIDER (ProviderOllama when unset), or nil when
+// GENERATION_MODEL is unset. Ollama is reached at OLLAMA_URL; the
+// OpenAI-compatible API at GENERATION_API_URL with GENERATION_API_KEY
+// (falling back to OPENAI_API_KEY).
+func FromEnv() (Generator, error) {
+	model := os.Getenv("GENERATION_MODEL")
+	if model == "" {
+		return nil, nil
+	}
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("GENERATION_PROVIDER"))); name {
+	case "", ProviderOllama:
+		baseURL := os.Getenv("OLLAMA_URL")
+		if baseURL == "" {
+			baseURL = DefaultOllamaURL
+		}
+		return NewOllama(baseURL, model), nil
+	case ProviderOpenAI:
+		baseURL := os.Getenv("GENERATION_API_URL")
+		if baseURL == "" {
+			baseURL = DefaultOpenAIURL
+		}
+		apiKey := os.Getenv("GENERATION_API_KEY")
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		return NewOpenAI(baseURL, apiKey, model), nil
+	default:
+		return nil, fmt.Errorf("invalid GENERATION_PROVIDER: %s (expected %s or %s)", name, ProviderOllama, ProviderOpenAI)
+	}
+}
+
+// GenerateJSON generates with JSON output and decodes the completion into v.
+// Models sometimes wrap JSON in a markdown code fence, which is removed.
+func GenerateJSON(ctx context.Context, g Generator, prompt string, opts Options, v any) error {
+	opts.JSON = true
+	out, err := g.Generate(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSpace(out)
+	if strings.HasPrefix(out, "```") {
+		out = strings.TrimPrefix(strings.TrimPrefix(out, "```json"), "```")
+		out = strings.TrimSpace(strings.TrimSuffix(out, "```"))
+	}
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		return fmt.Errorf("model returned invalid JSON: %w", err)
+	}
+	return nil
+}
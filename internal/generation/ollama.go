@@ -0,0 +1,125 @@
+package generation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ollama generates text with Ollama's /api/generate endpoint
+type Ollama struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// ollamaRequest is the request body of Ollama's /api/generate endpoint
+type ollamaRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	System  string         `json:"system,omitempty"`
+	Stream  bool           `json:"stream"`
+	Format  string         `json:"format,omitempty"`
+	Options map[string]any `json:"options"`
+}
+
+// ollamaResponse is the response of Ollama's /api/generate endpoint
+type ollamaResponse struct {
+	Response string `json:"response"`
+}
+
+// NewOllama creates a generator for a model of the Ollama API at baseURL
+func NewOllama(baseURL, model string) *Ollama {
+	return &Ollama{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		httpClient: &http.Client{
+			// Generations of long prompts on CPU take minutes
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// Model returns the model name
+func (g *Ollama) Model() string { return g.model }
+
+// Generate returns the completion of the prompt, without streaming
+func (g *Ollama) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	body := ollamaRequest{
+		Model:   g.model,
+		Prompt:  prompt,
+		System:  opts.System,
+		Options: map[string]any{"temperature": opts.Temperature},
+	}
+	if opts.JSON {
+		body.Format = "json"
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", g.baseURL+"/api/generate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var genResp ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if strings.TrimSpace(genResp.Response) == "" {
+		return "", fmt.Errorf("empty completion returned")
+	}
+	return genResp.Response, nil
+}
+
+// Check returns an error unless Ollama answers and has pulled the model
+// (with or without its :latest tag)
+func (g *Ollama) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, m := range tags.Models {
+		if m.Name == g.model || m.Name == g.model+":latest" {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %s is not pulled", g.model)
+}
@@ -0,0 +1,134 @@
+package generation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenAI generates text with an OpenAI-compatible POST /v1/chat/completions
+// endpoint (OpenAI, vLLM, LocalAI, llama.cpp server, ...)
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// chatMessage is a message of a chat completion request
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatRequest is the request body of POST /v1/chat/completions
+type chatRequest struct {
+	Model          string        `json:"model"`
+	Messages       []chatMessage `json:"messages"`
+	Temperature    float64       `json:"temperature"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format,omitempty"`
+}
+
+// chatResponse is the response of POST /v1/chat/completions
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAI creates a generator for a model of the API at baseURL (without
+// the /v1 suffix)
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	return &OpenAI{
+		baseURL: strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// Model returns the model name
+func (g *OpenAI) Model() string { return g.model }
+
+// Generate returns the completion of the prompt as the user message
+func (g *OpenAI) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	body := chatRequest{Model: g.model, Temperature: opts.Temperature}
+	if opts.System != "" {
+		body.Messages = append(body.Messages, chatMessage{Role: "system", Content: opts.System})
+	}
+	body.Messages = append(body.Messages, chatMessage{Role: "user", Content: prompt})
+	if opts.JSON {
+		body.ResponseFormat = &struct {
+			Type string `json:"type"`
+		}{Type: "json_object"}
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", g.baseURL+"/v1/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("openai API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 || strings.TrimSpace(chatResp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty completion returned")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// Check returns an error unless the API answers GET /v1/models/{model} for
+// the model
+func (g *OpenAI) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/v1/models/"+url.PathEscape(g.model), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("model %s not found", g.model)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("openai API error (status %d): %s", resp.StatusCode, string(body))
+	}
+}
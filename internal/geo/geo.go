@@ -0,0 +1,164 @@
+// Package geo geocodes the places sources are about with a Nominatim
+// (OpenStreetMap) compatible search API, so sources can be searched by
+// distance from a point.
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+const (
+	// DefaultURL is the public Nominatim API
+	DefaultURL = "https://nominatim.openstreetmap.org"
+	// minInterval spaces requests out, as the public API allows one per
+	// second
+	minInterval = time.Second
+)
+
+// Client geocodes place names. Results, including places that weren't
+// found, are cached for the lifetime of the client.
+type Client struct {
+	baseURL    string
+	email      string // Contact address sent with requests, as Nominatim asks
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	last  time.Time // Time of the last request
+	cache map[string]*database.Location
+}
+
+// searchResult is an entry of the search API response
+type searchResult struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+}
+
+// NewClient creates a client for the API at GEOCODER_URL (DefaultURL when
+// unset); GEOCODER_EMAIL identifies the caller
+func NewClient() *Client {
+	baseURL := os.Getenv("GEOCODER_URL")
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return NewClientWithConfig(baseURL, os.Getenv("GEOCODER_EMAIL"))
+}
+
+// NewClientWithConfig creates a client with an explicit API endpoint
+func NewClientWithConfig(baseURL, email string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		email:   email,
+		httpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+		cache: make(map[string]*database.Location),
+	}
+}
+
+// Geocode returns the location of a place, or nil if the API doesn't know
+// it
+func (c *Client) Geocode(ctx context.Context, place string) (*database.Location, error) {
+	key := database.EntityKey(place)
+	if key == "" {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if loc, ok := c.cache[key]; ok {
+		return loc, nil
+	}
+	if wait := minInterval - time.Since(c.last); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c.last = time.Now()
+
+	loc, err := c.search(ctx, place)
+	if err != nil {
+		return nil, err
+	}
+	c.cache[key] = loc
+	return loc, nil
+}
+
+// search queries the API for the best match of a place
+func (c *Client) search(ctx context.Context, place string) (*database.Location, error) {
+	params := url.Values{"q": {place}, "format": {"jsonv2"}, "limit": {"1"}}
+	if c.email != "" {
+		params.Set("email", c.email)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "gitopedia-knowledge-base")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("geocoder API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var results []searchResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	lat, err1 := strconv.ParseFloat(results[0].Lat, 64)
+	lon, err2 := strconv.ParseFloat(results[0].Lon, 64)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid coordinates for %q: %s, %s", place, results[0].Lat, results[0].Lon)
+	}
+	loc := &database.Location{Place: place, Lat: lat, Lon: lon}
+	if err := loc.Validate(); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+// Locate geocodes the places of a source that have no location yet and
+// adds their locations. It reports how many were added; places that can't
+// be found are skipped, and an API error stops at the place it occurred.
+func (c *Client) Locate(ctx context.Context, src *database.Source) (int, error) {
+	added := 0
+	for _, place := range src.Places {
+		if src.Located(place) {
+			continue
+		}
+		loc, err := c.Geocode(ctx, place)
+		if err != nil {
+			return added, fmt.Errorf("%s: %w", place, err)
+		}
+		if loc != nil {
+			l := *loc
+			l.Place = place
+			src.Locations = append(src.Locations, l)
+			added++
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,156 @@
+// Package guard keeps the SQLite and vector stores from drifting further
+// apart unnoticed. Writes tolerate Qdrant failures, so sources can pile up
+// without their vectors (see package reconcile). A guard checks the gap
+// periodically; once more than a threshold of the sources lack vectors it
+// protects the stores: the API queues source writes in the outbox instead
+// of applying them and reports degraded health. When a later check finds the
+// gap closed again, e.g. after cmd/reconcile -fix, the queued writes are
+// replayed in order and protection is lifted.
+package guard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/reconcile"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// DefaultThreshold is the fraction of sources missing their vectors above
+// which the stores are protected by default
+const DefaultThreshold = 0.05
+
+// Options configure a guard
+type Options struct {
+	// Threshold is the fraction of sources (leaving cold ones out) missing
+	// their vectors above which writes are queued; zero selects
+	// DefaultThreshold
+	Threshold float64
+}
+
+// Status is the outcome of the last check
+type Status struct {
+	Protected bool `json:"protected"`
+	// Missing of Sources lack their vectors, cold sources left out
+	Missing    int     `json:"missing"`
+	Sources    int     `json:"sources"`
+	Divergence float64 `json:"divergence"` // Missing / Sources
+	Threshold  float64 `json:"threshold"`
+	CheckedAt  string  `json:"checked_at,omitempty"`
+	// Error is why the last check failed; protection is left as it was
+	Error string `json:"error,omitempty"`
+}
+
+// Guard holds the protection state of the stores. The zero value is not
+// usable; see New.
+type Guard struct {
+	mu      sync.RWMutex
+	status  Status
+	healthy func(context.Context) error
+}
+
+// New returns a guard that protects nothing until its first check
+func New(opts Options) *Guard {
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Guard{status: Status{Threshold: threshold}}
+}
+
+// Protected reports whether writes must be queued
+func (g *Guard) Protected() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status.Protected
+}
+
+// Status returns the outcome of the last check
+func (g *Guard) Status() Status {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status
+}
+
+// OnHealthy sets the function replaying the queued writes, called after
+// every check that finds the stores in agreement. Protection is lifted
+// only once it succeeds, so writes stay queued behind the ones replayed.
+func (g *Guard) OnHealthy(fn func(context.Context) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.healthy = fn
+}
+
+// Check measures the gap between the sources and their vectors and
+// protects the stores, or lifts protection, accordingly
+func (g *Guard) Check(ctx context.Context, db store.Store, vectorDB store.VectorStore) (Status, error) {
+	reports, err := reconcile.Run(ctx, db, vectorDB, reconcile.Options{Collections: []string{vectordb.SourcesCollection}})
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err != nil {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.status.CheckedAt, g.status.Error = now, err.Error()
+		return g.status, fmt.Errorf("failed to compare the stores: %w", err)
+	}
+
+	report := reports[0]
+	g.mu.Lock()
+	st := g.status
+	st.Missing, st.Sources = len(report.Missing), report.Records-report.Cold
+	st.Divergence = 0
+	if st.Sources > 0 {
+		st.Divergence = float64(st.Missing) / float64(st.Sources)
+	}
+	st.CheckedAt, st.Error = now, ""
+	diverged := st.Divergence > st.Threshold
+	if diverged {
+		st.Protected = true
+	}
+	g.status = st
+	healthy := g.healthy
+	g.mu.Unlock()
+	if diverged {
+		return st, nil
+	}
+
+	if healthy != nil {
+		if err := healthy(ctx); err != nil {
+			return st, fmt.Errorf("failed to replay queued writes: %w", err)
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status.Protected = false
+	return g.status, nil
+}
+
+// Schedule checks the stores now and then every interval until ctx is
+// cancelled
+func (g *Guard) Schedule(ctx context.Context, interval time.Duration, db store.Store, vectorDB store.VectorStore) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		was := g.Protected()
+		st, err := g.Check(ctx, db, vectorDB)
+		switch {
+		case err != nil:
+			log.Printf("Store guard: %v", err)
+		case st.Protected && !was:
+			log.Printf("Store guard: %d of %d sources lack their vectors (%.1f%%, threshold %.1f%%); queueing source writes",
+				st.Missing, st.Sources, 100*st.Divergence, 100*st.Threshold)
+		case !st.Protected && was:
+			log.Printf("Store guard: %d of %d sources lack their vectors; queued writes replayed, protection lifted", st.Missing, st.Sources)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
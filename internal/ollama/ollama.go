@@ -0,0 +1,173 @@
+// Package ollama manages the models of an Ollama server: it lists the
+// pulled models and pulls new ones, so a deployment can provision its
+// embedding and generation models through the knowledge-base API. Embedding
+// and generation themselves are in the embedding and generation packages.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the default address of the Ollama API
+const DefaultURL = "http://localhost:11434"
+
+// Client calls the model management endpoints of the Ollama API
+type Client struct {
+	baseURL string
+	// Lists models; pulls use pullClient, as they take minutes
+	httpClient *http.Client
+	pullClient *http.Client
+}
+
+// NewClient creates a client for the Ollama API at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		// Pulls are bounded by their context only
+		pullClient: &http.Client{},
+	}
+}
+
+// FromEnv creates a client for the Ollama API at OLLAMA_URL (DefaultURL when
+// unset)
+func FromEnv() *Client {
+	baseURL := os.Getenv("OLLAMA_URL")
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return NewClient(baseURL)
+}
+
+// Model is a model pulled to the Ollama server
+type Model struct {
+	Name       string       `json:"name"`
+	Size       int64        `json:"size"` // Bytes on disk
+	Digest     string       `json:"digest"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelDetails describe the weights of a model
+type ModelDetails struct {
+	Format            string `json:"format,omitempty"`
+	Family            string `json:"family,omitempty"`
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+}
+
+// Models returns the models pulled to the server, from /api/tags
+func (c *Client) Models(ctx context.Context) ([]Model, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tags struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if tags.Models == nil {
+		tags.Models = []Model{}
+	}
+	return tags.Models, nil
+}
+
+// Find returns the model of the given name among models, with or without
+// its :latest tag
+func Find(models []Model, name string) (Model, bool) {
+	for _, m := range models {
+		if m.Name == name || m.Name == name+":latest" {
+			return m, true
+		}
+	}
+	return Model{}, false
+}
+
+// Progress is a status update of a pull, e.g. "pulling manifest", or the
+// download of a layer with its Total and Completed bytes
+type Progress struct {
+	Status    string `json:"status,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// StatusSuccess is the status of the last Progress of a successful pull
+const StatusSuccess = "success"
+
+// Pull downloads a model, or the layers of it that changed, calling
+// progress with each status update of /api/pull. An error returned by
+// progress stops the pull.
+func (c *Client) Pull(ctx context.Context, model string, progress func(Progress) error) error {
+	jsonBody, err := json.Marshal(map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/pull", bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.pullClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	// One JSON object per line; failures arrive as {"error": ...}
+	scanner := bufio.NewScanner(resp.Body)
+	success := false
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var update struct {
+			Progress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			return fmt.Errorf("failed to decode progress: %w", err)
+		}
+		if update.Error != "" {
+			return errors.New(update.Error)
+		}
+		success = update.Status == StatusSuccess
+		if err := progress(update.Progress); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read progress: %w", err)
+	}
+	if !success {
+		return fmt.Errorf("pull of %s ended without success", model)
+	}
+	return nil
+}
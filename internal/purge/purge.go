@@ -0,0 +1,117 @@
+// Package purge permanently removes the sources deleted longer ago than a
+// retention window. DELETE /sources/{id} only soft-deletes a source: its
+// record stays in SQLite and its points in Qdrant, hidden from reads and
+// searches, so POST /sources/{id}/restore can bring it back. The purge job
+// removes both once the window has passed. A dry run reports what would be
+// purged.
+package purge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/retention"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// DefaultLimit bounds the sources purged by a run
+const DefaultLimit = 10000
+
+// DefaultAfter is how long deleted sources are kept by default
+const DefaultAfter = "30d"
+
+// Options configure a run
+type Options struct {
+	// After is how long a deleted source is kept before it is purged
+	After retention.Period
+	// Now is the time the window is measured from; zero means the current
+	// time
+	Now time.Time
+	// Limit bounds the sources purged, longest deleted first; zero selects
+	// DefaultLimit
+	Limit int
+	// DryRun reports the sources due without purging them
+	DryRun bool
+}
+
+// Report is the outcome of a run
+type Report struct {
+	DryRun  bool                     `json:"dry_run"`
+	After   string                   `json:"after"`
+	Cutoff  string                   `json:"cutoff"` // Sources deleted before are purged
+	Sources []database.DeletedSource `json:"sources"`
+	Purged  int                      `json:"purged"` // Or to purge, in a dry run
+	// Failed counts purged sources whose points couldn't be deleted; the
+	// reconcile job removes them as orphans
+	Failed int `json:"failed"`
+}
+
+// Run purges the sources deleted longer ago than opts.After, longest
+// deleted first: their records from SQLite, then their points from Qdrant.
+// In a dry run it only reports them.
+func Run(ctx context.Context, db store.Store, vectorDB store.VectorStore, opts Options) (Report, error) {
+	if opts.After.Forever() {
+		return Report{}, fmt.Errorf("a retention window is required")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	cutoff := opts.After.Cutoff(now).Format(time.RFC3339)
+	report := Report{DryRun: opts.DryRun, After: opts.After.String(), Cutoff: cutoff, Sources: []database.DeletedSource{}}
+
+	deleted, err := db.DeletedSources(ctx, cutoff, limit)
+	if err != nil {
+		return report, fmt.Errorf("failed to list deleted sources: %w", err)
+	}
+	for _, src := range deleted {
+		if !opts.DryRun {
+			// The record goes first, so a source restored meanwhile keeps
+			// its points
+			purged, err := db.PurgeSource(ctx, src.ID)
+			if err != nil {
+				return report, fmt.Errorf("failed to purge source %s: %w", src.ID, err)
+			}
+			if !purged {
+				continue // Restored since it was listed
+			}
+			if err := vectorDB.DeleteSource(ctx, src.ID); err != nil {
+				log.Printf("Failed to delete source %s from Qdrant: %v", src.ID, err)
+				report.Failed++
+			}
+		}
+		report.Sources = append(report.Sources, src)
+		report.Purged++
+	}
+	return report, nil
+}
+
+// Schedule purges the sources due every interval until ctx is cancelled
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, vectorDB store.VectorStore, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			report, err := Run(ctx, db, vectorDB, opts)
+			if err != nil {
+				log.Printf("Purge failed: %v", err)
+				continue
+			}
+			log.Printf("Purge: %d deleted sources purged, %d failed (%s)",
+				report.Purged, report.Failed, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package reconcile compares the records of the SQLite store with the points
+// of the vector store. Writes tolerate Qdrant failures so that SQLite stays
+// the source of truth, which lets the two drift apart: a record can lack its
+// point (missing) and a point can outlive its record (stale). A run reports
+// both and, when asked to, repairs them.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// upsertBatchSize is how many re-embedded points are upserted at a time
+const upsertBatchSize = 64
+
+// Options tune a run
+type Options struct {
+	// Fix removes stale points and re-embeds missing ones; otherwise the
+	// run only reports
+	Fix bool
+	// Embedders and Strategies re-embed missing points; required with Fix
+	Embedders  *embedding.Set
+	Strategies embedding.Strategies
+	// Collections are the collections reconciled; none selects sources
+	// and articles
+	Collections []string
+}
+
+// Report is the outcome of a run for one collection
+type Report struct {
+	Collection string
+	Records    int      // Records in SQLite
+	Points     int      // Points in Qdrant
+	Missing    []string // IDs of records without a point
+	Cold       int      // Records without a point as they are cold (see package cold)
+	Stale      []string // IDs of points without a record
+	Embedded   int      // Missing points re-embedded (with Fix)
+	Removed    int      // Stale points removed (with Fix)
+	Failed     int      // Repairs that failed (with Fix)
+}
+
+// Run reconciles the sources and articles collections (or those of
+// opts.Collections) with their tables
+func Run(ctx context.Context, db store.Store, vectorDB store.VectorStore, opts Options) ([]Report, error) {
+	if opts.Fix && opts.Embedders == nil {
+		return nil, fmt.Errorf("repairing requires embedders")
+	}
+	collections := opts.Collections
+	if len(collections) == 0 {
+		collections = []string{vectordb.SourcesCollection, vectordb.ArticlesCollection}
+	}
+
+	var reports []Report
+	for _, collection := range collections {
+		report, err := reconcile(ctx, db, vectorDB, collection, opts)
+		if err != nil {
+			return reports, fmt.Errorf("%s: %w", collection, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func reconcile(ctx context.Context, db store.Store, vectorDB store.VectorStore, collection string, opts Options) (Report, error) {
+	report := Report{Collection: collection}
+
+	var ids []string
+	var err error
+	if collection == vectordb.SourcesCollection {
+		ids, err = db.SourceIDs(ctx)
+	} else {
+		ids, err = db.ArticleIDs(ctx)
+	}
+	if err != nil {
+		return report, fmt.Errorf("failed to list records: %w", err)
+	}
+	report.Records = len(ids)
+	records := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		records[id] = true
+	}
+
+	// Points are matched to records by the ID in their payload; points
+	// without one can only be named by their UUID
+	points := make(map[string]bool, len(ids))
+	err = vectordb.ScrollAll(ctx, vectorDB, collection, "", func(p vectordb.Point) error {
+		report.Points++
+		id, _ := p.Payload["id"].(string)
+		if id == "" {
+			id = p.ID
+		}
+		points[id] = true
+		if !records[id] {
+			report.Stale = append(report.Stale, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	// Cold sources lack their points on purpose
+	cold := make(map[string]bool)
+	if collection == vectordb.SourcesCollection {
+		coldIDs, err := db.ColdSourceIDs(ctx)
+		if err != nil {
+			return report, fmt.Errorf("failed to list cold sources: %w", err)
+		}
+		for _, id := range coldIDs {
+			cold[id] = true
+		}
+	}
+	for _, id := range ids {
+		switch {
+		case points[id]:
+		case cold[id]:
+			report.Cold++
+		default:
+			report.Missing = append(report.Missing, id)
+		}
+	}
+
+	if !opts.Fix {
+		return report, nil
+	}
+	for _, id := range report.Stale {
+		if collection == vectordb.SourcesCollection {
+			err = vectorDB.DeleteSource(ctx, id)
+		} else {
+			err = vectorDB.DeleteArticle(ctx, id)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to remove stale point %s: %v", id, err)
+			report.Failed++
+			continue
+		}
+		report.Removed++
+	}
+	if len(report.Missing) == 0 {
+		return report, nil
+	}
+
+	// Re-embedded points must match the strategy the collection was
+	// embedded with
+	strategy := opts.Strategies.Sources
+	if collection == vectordb.ArticlesCollection {
+		strategy = opts.Strategies.Articles
+	}
+	if err := embedding.CheckStrategy(ctx, db, collection, strategy); err != nil {
+		return report, err
+	}
+	if collection == vectordb.SourcesCollection {
+		err = embedSources(ctx, db, vectorDB, opts.Embedders, strategy, &report)
+	} else {
+		err = embedArticles(ctx, db, vectorDB, opts.Embedders, strategy, &report)
+	}
+	return report, err
+}
+
+// embedSources re-embeds the missing points of the sources collection
+func embedSources(ctx context.Context, db store.Store, vectorDB store.VectorStore, embedders *embedding.Set, strategy embedding.Strategy, report *Report) error {
+	var batch []vectordb.SourcePoint
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := vectorDB.UpsertSources(ctx, batch); err != nil {
+			return fmt.Errorf("failed to upsert %d points: %w", len(batch), err)
+		}
+		report.Embedded += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for _, id := range report.Missing {
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to read source %s: %w", id, err)
+		}
+		if src == nil {
+			continue // Deleted since it was listed
+		}
+		vectors, err := embedders.EmbedDocument(ctx, strategy, embedding.Document{
+			Title:   src.Title,
+			Summary: src.EmbeddingText(),
+			Body:    src.Summary,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			report.Failed++
+			continue
+		}
+		batch = append(batch, vectordb.SourcePoint{ID: id, Vectors: vectors, Payload: vectordb.NewSourcePayload(*src)})
+		if len(batch) >= upsertBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// embedArticles re-embeds the missing points of the articles collection
+func embedArticles(ctx context.Context, db store.Store, vectorDB store.VectorStore, embedders *embedding.Set, strategy embedding.Strategy, report *Report) error {
+	var batch []vectordb.ArticlePoint
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := vectorDB.UpsertArticles(ctx, batch); err != nil {
+			return fmt.Errorf("failed to upsert %d points: %w", len(batch), err)
+		}
+		report.Embedded += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for _, id := range report.Missing {
+		art, err := db.GetArticle(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to read article %s: %w", id, err)
+		}
+		if art == nil {
+			continue // Deleted since it was listed
+		}
+		if art.Content, err = db.ArticleContent(ctx, id); err != nil {
+			return fmt.Errorf("failed to read article %s: %w", id, err)
+		}
+		vectors, err := embedders.EmbedDocument(ctx, strategy, embedding.Document{
+			Title:   art.Title,
+			Summary: art.Summary,
+			Body:    art.Content,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to generate embedding for %s: %v", id, err)
+			report.Failed++
+			continue
+		}
+		payload := vectordb.ArticlePayload{
+			ID:        art.ID,
+			Title:     art.Title,
+			Path:      art.Path,
+			Summary:   art.Summary,
+			Tags:      art.Tags,
+			Category:  art.Category(),
+			WordCount: database.WordCount(art.Content),
+		}
+		batch = append(batch, vectordb.ArticlePoint{ID: id, Vectors: vectors, Payload: payload})
+		if len(batch) >= upsertBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
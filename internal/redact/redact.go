@@ -0,0 +1,185 @@
+// Package redact detects and redacts secrets and personal data (API keys,
+// tokens, private keys, email addresses) in text before it is stored or embedded.
+package redact
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mode controls what happens to sensitive strings
+type Mode string
+
+const (
+	// ModeOff disables scanning
+	ModeOff Mode = "off"
+	// ModeFlag reports findings but stores the text unchanged
+	ModeFlag Mode = "flag"
+	// ModeRedact replaces findings with a placeholder
+	ModeRedact Mode = "redact"
+)
+
+// ParseMode parses a mode name; empty means off
+func ParseMode(s string) (Mode, bool) {
+	switch Mode(strings.ToLower(s)) {
+	case "", ModeOff:
+		return ModeOff, true
+	case ModeFlag:
+		return ModeFlag, true
+	case ModeRedact:
+		return ModeRedact, true
+	}
+	return ModeOff, false
+}
+
+// Finding is a sensitive string found in a text
+type Finding struct {
+	Rule   string `json:"rule"`
+	Offset int    `json:"offset"` // Byte offset in the scanned text
+	Hint   string `json:"hint"`   // Masked excerpt, safe to log
+	// Field is the scanned field when it isn't the summary, e.g. content
+	Field  string `json:"field,omitempty"`
+	length int
+}
+
+// rule is a named pattern for a kind of sensitive string
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var rules = []rule{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"aws_access_key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"openai_key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{"credential_assignment", regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|passw(or)?d)\b\s*[:=]\s*["']?[^\s"']{8,}`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// tokenPattern matches candidate opaque tokens for the entropy check
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+// minTokenEntropy is the Shannon entropy (bits per character) above which a
+// long opaque token is treated as a likely secret. English words and slugs
+// stay well below it; random base64/hex keys sit above it.
+const minTokenEntropy = 4.0
+
+// Scan returns the sensitive strings found in text, ordered by offset.
+// Overlapping matches are reported once, for the earliest rule.
+func Scan(text string) []Finding {
+	var findings []Finding
+	taken := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.Offset+f.length && f.Offset < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range rules {
+		for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+			if !taken(loc[0], loc[1]) {
+				findings = append(findings, newFinding(r.name, text, loc))
+			}
+		}
+	}
+
+	for _, loc := range tokenPattern.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		if !taken(loc[0], loc[1]) && looksRandom(token) {
+			findings = append(findings, newFinding("high_entropy", text, loc))
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Offset < findings[j].Offset })
+	return findings
+}
+
+// Redact replaces every finding in text with a [REDACTED:<rule>] placeholder
+func Redact(text string) (string, []Finding) {
+	findings := Scan(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Offset])
+		b.WriteString("[REDACTED:" + f.Rule + "]")
+		last = f.Offset + f.length
+	}
+	b.WriteString(text[last:])
+	return b.String(), findings
+}
+
+// Apply scans or redacts text according to mode
+func Apply(mode Mode, text string) (string, []Finding) {
+	switch mode {
+	case ModeFlag:
+		return text, Scan(text)
+	case ModeRedact:
+		return Redact(text)
+	}
+	return text, nil
+}
+
+func newFinding(name, text string, loc []int) Finding {
+	return Finding{
+		Rule:   name,
+		Offset: loc[0],
+		Hint:   mask(text[loc[0]:loc[1]]),
+		length: loc[1] - loc[0],
+	}
+}
+
+// mask keeps only the first few characters of a match
+func mask(s string) string {
+	const keep = 4
+	if len(s) <= keep {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:keep] + strings.Repeat("*", min(len(s)-keep, 8))
+}
+
+// looksRandom reports whether a token mixes character classes and has high
+// entropy, which distinguishes keys from long words or paths
+func looksRandom(token string) bool {
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(upper || lower) {
+		return false
+	}
+	return entropy(token) >= minTokenEntropy
+}
+
+// entropy computes the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
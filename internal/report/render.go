@@ -0,0 +1,97 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+// Render renders a report to markdown
+func Render(r database.TopicReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Topic report: %s\n\n", r.Topic)
+	fmt.Fprintf(&b, "%s to %s\n\n", day(r.PeriodStart), day(r.PeriodEnd))
+
+	b.WriteString("## Summary\n\n")
+	fmt.Fprintf(&b, "- %d new sources (%d in total)", r.Stats.NewSources, r.Stats.Sources)
+	if len(r.Stats.NewByType) > 0 {
+		types := make([]string, 0, len(r.Stats.NewByType))
+		for t, n := range r.Stats.NewByType {
+			types = append(types, fmt.Sprintf("%d %s", n, t))
+		}
+		sort.Strings(types)
+		fmt.Fprintf(&b, ": %s", strings.Join(types, ", "))
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "- %d articles, %d link suggestions awaiting review\n", r.Stats.Articles, r.Stats.PendingSuggestions)
+	fmt.Fprintf(&b, "- %d broken article links, %d coverage gaps\n\n", len(r.BrokenLinks), len(r.Gaps))
+
+	b.WriteString("## New sources\n\n")
+	if len(r.NewSources) == 0 {
+		b.WriteString("No sources were added.\n\n")
+	}
+	for _, src := range r.NewSources {
+		fmt.Fprintf(&b, "- [%s](%s)", escape(src.Title), src.URL)
+		var about []string
+		if src.Type != "" {
+			about = append(about, src.Type)
+		}
+		if src.Domain != "" {
+			about = append(about, src.Domain)
+		}
+		if len(about) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(about, ", "))
+		}
+		fmt.Fprintf(&b, ", added %s\n", day(src.CreatedAt))
+	}
+	if len(r.NewSources) > 0 {
+		b.WriteString("\n")
+	}
+
+	if len(r.Clusters) > 0 {
+		b.WriteString("## Clusters\n\n")
+		for _, c := range r.Clusters {
+			fmt.Fprintf(&b, "- %s **%s**: %d sources (%s)\n", c.Kind, escape(c.Name), len(c.Sources), strings.Join(c.Sources, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.BrokenLinks) > 0 {
+		b.WriteString("## Broken links\n\n")
+		for _, l := range r.BrokenLinks {
+			fmt.Fprintf(&b, "- Article `%s` cites source `%s`, which no longer exists\n", l.ArticleID, l.SourceID)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Gaps) > 0 {
+		b.WriteString("## Coverage gaps\n\n")
+		for _, g := range r.Gaps {
+			switch g.Kind {
+			case database.GapNoArticle:
+				fmt.Fprintf(&b, "- The topic has %d sources but no article\n", g.Sources)
+			default:
+				fmt.Fprintf(&b, "- **%s**: %d sources, not mentioned by the topic's articles\n", escape(g.Subject), g.Sources)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// day returns the date of an RFC 3339 timestamp
+func day(ts string) string {
+	if len(ts) >= len("2006-01-02") {
+		return ts[:len("2006-01-02")]
+	}
+	return ts
+}
+
+// markdownEscaper escapes the characters that would format or link titles
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`")
+
+func escape(s string) string {
+	return markdownEscaper.Replace(s)
+}
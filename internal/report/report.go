@@ -0,0 +1,404 @@
+// Package report builds the periodic topic reports for curators: the
+// sources a topic gained in the period, the subjects several of them share,
+// curated article links whose source is gone, and the subjects its sources
+// cover but its articles never mention. Reports are rendered to markdown,
+// stored, and optionally POSTed to a webhook.
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// DefaultPeriod is the period a report covers, ending at the start of the
+// current day (UTC)
+const DefaultPeriod = 7 * 24 * time.Hour
+
+const (
+	// minClusterSources is the fewest new sources sharing a subject for it
+	// to be reported as a cluster
+	minClusterSources = 2
+	maxClusters       = 10
+	// minGapSources is the fewest sources of the topic about a subject for
+	// the articles' silence on it to be a gap
+	minGapSources = 3
+	maxGaps       = 10
+	// maxTopicSources bounds the sources read per topic
+	maxTopicSources = 10000
+)
+
+// Options select the reports of a run
+type Options struct {
+	Topics []string      // Topics to report on; empty for every topic with sources
+	Period time.Duration // Zero selects DefaultPeriod
+	// End of the period; zero selects the start of the current day (UTC)
+	End time.Time
+	// Webhook receives every report as a JSON POST; empty disables delivery
+	Webhook string
+}
+
+// Stats summarize a run
+type Stats struct {
+	Reports   int // Reports stored
+	Skipped   int // Topics without public sources
+	Delivered int // Reports POSTed to the webhook
+	Failed    int // Deliveries that failed
+}
+
+// webhookClient posts reports to the webhook
+var webhookClient = &http.Client{Timeout: 20 * time.Second}
+
+// Run builds and stores the report of every selected topic for the period
+// ending at opts.End, replacing reports of the same period, and delivers
+// them to the webhook. Topics without public sources get no report, since
+// reports leave private sources out.
+func Run(ctx context.Context, db store.Store, opts Options) (Stats, error) {
+	start, end := Period(opts)
+
+	topics, err := Topics(ctx, db, opts)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	b, err := newBuilder(ctx, db)
+	if err != nil {
+		return Stats{}, err
+	}
+	var stats Stats
+	for _, topic := range topics {
+		r, err := b.build(ctx, topic, start, end)
+		if err != nil {
+			return stats, fmt.Errorf("failed to build report of %s: %w", topic, err)
+		}
+		if r.Stats.Sources == 0 && len(r.BrokenLinks) == 0 {
+			stats.Skipped++
+			continue
+		}
+		if err := db.SaveTopicReport(ctx, r); err != nil {
+			return stats, err
+		}
+		stats.Reports++
+
+		if opts.Webhook == "" {
+			continue
+		}
+		if err := Deliver(ctx, opts.Webhook, r); err != nil {
+			// The report is stored; the API still serves it
+			log.Printf("Failed to deliver report of %s: %v", topic, err)
+			stats.Failed++
+			continue
+		}
+		stats.Delivered++
+	}
+	return stats, nil
+}
+
+// Topics returns the topics of a run's options: those given, or every
+// topic with sources
+func Topics(ctx context.Context, db store.Store, opts Options) ([]string, error) {
+	if len(opts.Topics) > 0 {
+		return opts.Topics, nil
+	}
+	counts, err := db.SourceTopics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	topics := make([]string, 0, len(counts))
+	for topic := range counts {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// Period returns the start and end of the period of a run's options
+func Period(opts Options) (time.Time, time.Time) {
+	end := opts.End
+	if end.IsZero() {
+		end = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	period := opts.Period
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+	return end.Add(-period).UTC(), end.UTC()
+}
+
+// Build builds the report of one topic for the period from start to end
+func Build(ctx context.Context, db store.Store, topic string, start, end time.Time) (database.TopicReport, error) {
+	b, err := newBuilder(ctx, db)
+	if err != nil {
+		return database.TopicReport{}, err
+	}
+	return b.build(ctx, topic, start, end)
+}
+
+// Schedule runs the job every interval until ctx is done, logging the
+// outcome of each run
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			stats, err := Run(ctx, db, opts)
+			if err != nil {
+				log.Printf("Topic reports failed: %v", err)
+				continue
+			}
+			log.Printf("Topic reports: %d stored, %d topics without public sources, %d delivered, %d failed deliveries (%s)",
+				stats.Reports, stats.Skipped, stats.Delivered, stats.Failed, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
+
+// Deliver POSTs a report to a webhook as JSON
+func Deliver(ctx context.Context, url string, r database.TopicReport) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gitopedia-knowledge-base")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// topicArticle is the searchable text of an article, lowercased
+type topicArticle struct {
+	id   string
+	text string
+}
+
+// builder builds reports, reading the articles once for all topics
+type builder struct {
+	db       store.Store
+	articles map[string][]topicArticle // By topic
+}
+
+func newBuilder(ctx context.Context, db store.Store) (*builder, error) {
+	ids, err := db.ArticleIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list articles: %w", err)
+	}
+	b := &builder{db: db, articles: make(map[string][]topicArticle)}
+	for _, id := range ids {
+		art, err := db.GetArticle(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if art == nil {
+			continue
+		}
+		content, err := db.ArticleContent(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.Join([]string{art.Title, art.Summary, strings.Join(art.Tags, " "), content}, "\n")
+		b.articles[art.Topic()] = append(b.articles[art.Topic()], topicArticle{id: id, text: strings.ToLower(text)})
+	}
+	return b, nil
+}
+
+func (b *builder) build(ctx context.Context, topic string, start, end time.Time) (database.TopicReport, error) {
+	r := database.TopicReport{
+		Topic:       topic,
+		PeriodStart: start.Format(time.RFC3339),
+		PeriodEnd:   end.Format(time.RFC3339),
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		NewSources:  []database.ReportSource{},
+		Clusters:    []database.ReportCluster{},
+		BrokenLinks: []database.ArticleLink{},
+		Gaps:        []database.CoverageGap{},
+	}
+
+	// Public sources as of the end of the period, newest first
+	sources, err := b.db.ListSources(ctx, database.SourceFilter{Topic: topic, CreatedBefore: r.PeriodEnd}, maxTopicSources, "")
+	if err != nil {
+		return r, err
+	}
+	r.Stats.Sources = len(sources)
+	for _, src := range sources {
+		if src.CreatedAt < r.PeriodStart {
+			break
+		}
+		r.NewSources = append(r.NewSources, database.ReportSource{
+			ID:        src.ID,
+			Title:     src.Title,
+			URL:       src.URL,
+			Type:      src.Type,
+			Domain:    src.Domain,
+			CreatedAt: src.CreatedAt,
+		})
+		if src.Type != "" {
+			if r.Stats.NewByType == nil {
+				r.Stats.NewByType = make(map[string]int)
+			}
+			r.Stats.NewByType[src.Type]++
+		}
+	}
+	r.Stats.NewSources = len(r.NewSources)
+
+	if r.Clusters, err = b.clusters(ctx, r.NewSources); err != nil {
+		return r, err
+	}
+
+	articles := b.articles[topic]
+	r.Stats.Articles = len(articles)
+	for _, art := range articles {
+		pending, err := b.db.LinkSuggestions(ctx, art.id, database.SuggestionPending, maxTopicSources)
+		if err != nil {
+			return r, err
+		}
+		r.Stats.PendingSuggestions += len(pending)
+	}
+
+	broken, err := b.db.BrokenArticleLinks(ctx, topic)
+	if err != nil {
+		return r, err
+	}
+	r.BrokenLinks = append(r.BrokenLinks, broken...)
+
+	r.Gaps = gaps(sources, articles)
+	r.Markdown = Render(r)
+	return r, nil
+}
+
+// clusters groups the new sources by the tags, domains and entities they
+// share, largest groups first
+func (b *builder) clusters(ctx context.Context, sources []database.ReportSource) ([]database.ReportCluster, error) {
+	groups := make(map[string]*database.ReportCluster)
+	add := func(kind, name, id string) {
+		key := kind + "\x00" + strings.ToLower(name)
+		c, ok := groups[key]
+		if !ok {
+			c = &database.ReportCluster{Kind: kind, Name: name}
+			groups[key] = c
+		}
+		if len(c.Sources) == 0 || c.Sources[len(c.Sources)-1] != id {
+			c.Sources = append(c.Sources, id)
+		}
+	}
+	for _, rs := range sources {
+		src, err := b.db.GetSource(ctx, rs.ID)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil {
+			continue
+		}
+		for _, tag := range src.Tags {
+			add("tag", tag, src.ID)
+		}
+		if src.Domain != "" {
+			add("domain", src.Domain, src.ID)
+		}
+		for _, name := range src.People {
+			add("person", name, src.ID)
+		}
+		for _, name := range src.Orgs {
+			add("org", name, src.ID)
+		}
+		for _, name := range src.Places {
+			add("place", name, src.ID)
+		}
+	}
+
+	clusters := []database.ReportCluster{}
+	for _, c := range groups {
+		if len(c.Sources) >= minClusterSources {
+			clusters = append(clusters, *c)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Sources) != len(clusters[j].Sources) {
+			return len(clusters[i].Sources) > len(clusters[j].Sources)
+		}
+		if clusters[i].Kind != clusters[j].Kind {
+			return clusters[i].Kind < clusters[j].Kind
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters[:min(len(clusters), maxClusters)], nil
+}
+
+// gaps lists what the topic's sources cover and its articles don't: the
+// article itself, or the tags of at least minGapSources sources that no
+// article of the topic mentions
+func gaps(sources []database.Source, articles []topicArticle) []database.CoverageGap {
+	gaps := []database.CoverageGap{}
+	if len(sources) == 0 {
+		return gaps
+	}
+	if len(articles) == 0 {
+		return append(gaps, database.CoverageGap{Kind: database.GapNoArticle, Sources: len(sources)})
+	}
+
+	counts := make(map[string]int)
+	names := make(map[string]string)
+	for _, src := range sources {
+		seen := make(map[string]bool)
+		for _, tag := range src.Tags {
+			key := strings.ToLower(strings.TrimSpace(tag))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			counts[key]++
+			if _, ok := names[key]; !ok {
+				names[key] = tag
+			}
+		}
+	}
+	for key, n := range counts {
+		if n < minGapSources || mentioned(articles, key) {
+			continue
+		}
+		gaps = append(gaps, database.CoverageGap{Kind: database.GapUncovered, Subject: names[key], Sources: n})
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Sources != gaps[j].Sources {
+			return gaps[i].Sources > gaps[j].Sources
+		}
+		return gaps[i].Subject < gaps[j].Subject
+	})
+	return gaps[:min(len(gaps), maxGaps)]
+}
+
+// mentioned reports whether any article mentions a lowercased subject, with
+// hyphens and underscores read as spaces
+func mentioned(articles []topicArticle, subject string) bool {
+	spaced := strings.NewReplacer("-", " ", "_", " ").Replace(subject)
+	for _, art := range articles {
+		if strings.Contains(art.text, subject) || strings.Contains(art.text, spaced) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIReranker scores documents with a Cohere-style POST /v1/rerank
+// endpoint, as served by Cohere, Jina, vLLM, Infinity and LocalAI for
+// cross-encoder models such as bge-reranker
+type APIReranker struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// apiRequest is the request body of POST /v1/rerank
+type apiRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// apiResponse is the response of POST /v1/rerank
+type apiResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float32 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewAPIReranker creates a reranker for the API at baseURL (without the /v1
+// suffix)
+func NewAPIReranker(baseURL, apiKey, model string) *APIReranker {
+	return &APIReranker{
+		baseURL: strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Model returns the reranking model
+func (r *APIReranker) Model() string { return r.model }
+
+// Score scores every document in one request
+func (r *APIReranker) Score(ctx context.Context, query string, documents []string) ([]float32, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	jsonBody, err := json.Marshal(apiRequest{Model: r.model, Query: query, Documents: documents, TopN: len(documents)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+"/v1/rerank", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rerank API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var rerankResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Results come sorted by relevance and carry the index of their document
+	scores := make([]float32, len(documents))
+	scored := make([]bool, len(documents))
+	for _, res := range rerankResp.Results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("rerank index %d out of range", res.Index)
+		}
+		scores[res.Index] = res.RelevanceScore
+		scored[res.Index] = true
+	}
+	for i, ok := range scored {
+		if !ok {
+			return nil, fmt.Errorf("no score returned for document %d", i)
+		}
+	}
+	return scores, nil
+}
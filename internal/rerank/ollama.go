@@ -0,0 +1,165 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOllamaURL is the default address of the Ollama API
+	DefaultOllamaURL = "http://localhost:11434"
+	// ollamaConcurrency is the number of documents scored at once
+	ollamaConcurrency = 4
+	// maxDocumentRunes cuts long documents, which the model would spend
+	// most of its time reading for little change in the judgement
+	maxDocumentRunes = 2000
+)
+
+// ollamaPrompt asks a generation model for a pointwise relevance judgement.
+// Ollama has no rerank endpoint, so an instruction-following model stands in
+// for the cross-encoder; the query and the document are read together all
+// the same.
+const ollamaPrompt = `Rate how relevant the document is to the search query, from 0 (unrelated or only tangentially related) to 10 (directly answers the query). Answer with JSON only: {"score": <0-10>}.
+
+Query: %s
+
+Document:
+%s`
+
+// OllamaReranker scores documents with a generation model served by
+// Ollama's /api/generate endpoint, one document at a time
+type OllamaReranker struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// generateRequest is the request body of Ollama's /api/generate endpoint
+type generateRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Format  string         `json:"format"`
+	Options map[string]any `json:"options"`
+}
+
+// generateResponse is the response of Ollama's /api/generate endpoint
+type generateResponse struct {
+	Response string `json:"response"`
+}
+
+// NewOllamaReranker creates a reranker for the Ollama API at baseURL
+func NewOllamaReranker(baseURL, model string) *OllamaReranker {
+	return &OllamaReranker{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Model returns the reranking model
+func (r *OllamaReranker) Model() string { return r.model }
+
+// Score scores up to ollamaConcurrency documents at once, scaled to 0..1.
+// The first failure cancels the rest.
+func (r *OllamaReranker) Score(ctx context.Context, query string, documents []string) ([]float32, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scores := make([]float32, len(documents))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	next := make(chan int)
+	for range min(ollamaConcurrency, len(documents)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				score, err := r.score(ctx, query, documents[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("document %d: %w", i, err)
+						cancel()
+					})
+					continue
+				}
+				scores[i] = score
+			}
+		}()
+	}
+
+feed:
+	for i := range documents {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+func (r *OllamaReranker) score(ctx context.Context, query, document string) (float32, error) {
+	if runes := []rune(document); len(runes) > maxDocumentRunes {
+		document = string(runes[:maxDocumentRunes])
+	}
+	jsonBody, err := json.Marshal(generateRequest{
+		Model:   r.model,
+		Prompt:  fmt.Sprintf(ollamaPrompt, query, document),
+		Format:  "json",
+		Options: map[string]any{"temperature": 0},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+"/api/generate", bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("ollama API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var genResp generateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	var judgement struct {
+		Score *float32 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(genResp.Response), &judgement); err != nil || judgement.Score == nil {
+		return 0, fmt.Errorf("unexpected judgement: %q", genResp.Response)
+	}
+	return min(max(*judgement.Score, 0), 10) / 10, nil
+}
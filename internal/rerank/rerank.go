@@ -0,0 +1,80 @@
+// Package rerank reorders search candidates with a reranker: a model that
+// reads the query and each candidate together, as a cross-encoder does, and
+// so judges relevance better than the similarity of their embeddings. Vector
+// search finds the candidates; the reranker only orders them.
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Provider names accepted by RERANK_PROVIDER
+const (
+	ProviderOllama = "ollama"
+	ProviderAPI    = "api"
+)
+
+// DefaultCandidates is how many vector search results are reranked when
+// RERANK_CANDIDATES is unset
+const DefaultCandidates = 50
+
+// maxCandidates bounds RERANK_CANDIDATES, since every candidate costs the
+// reranker a pass over the query and its text
+const maxCandidates = 500
+
+// Reranker scores documents by their relevance to a query
+type Reranker interface {
+	// Score returns the relevance of each document to the query, in the
+	// order of the documents; higher is more relevant
+	Score(ctx context.Context, query string, documents []string) ([]float32, error)
+	// Model returns the name of the reranking model
+	Model() string
+}
+
+// FromEnv returns the reranker selected by RERANK_PROVIDER, with the model
+// in RERANK_MODEL, or nil when RERANK_PROVIDER is unset. Ollama is reached
+// at OLLAMA_URL; the rerank API at RERANK_URL with RERANK_API_KEY.
+func FromEnv() (Reranker, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("RERANK_PROVIDER")))
+	if name == "" {
+		return nil, nil
+	}
+	model := os.Getenv("RERANK_MODEL")
+	if model == "" {
+		return nil, fmt.Errorf("RERANK_MODEL is required with RERANK_PROVIDER")
+	}
+	switch name {
+	case ProviderOllama:
+		baseURL := os.Getenv("OLLAMA_URL")
+		if baseURL == "" {
+			baseURL = DefaultOllamaURL
+		}
+		return NewOllamaReranker(baseURL, model), nil
+	case ProviderAPI:
+		baseURL := os.Getenv("RERANK_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("RERANK_URL is required with RERANK_PROVIDER=%s", ProviderAPI)
+		}
+		return NewAPIReranker(baseURL, os.Getenv("RERANK_API_KEY"), model), nil
+	default:
+		return nil, fmt.Errorf("invalid RERANK_PROVIDER: %s (expected %s or %s)", name, ProviderOllama, ProviderAPI)
+	}
+}
+
+// CandidatesFromEnv returns the number of vector search results to rerank
+// from RERANK_CANDIDATES, DefaultCandidates when unset
+func CandidatesFromEnv() (int, error) {
+	s := os.Getenv("RERANK_CANDIDATES")
+	if s == "" {
+		return DefaultCandidates, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxCandidates {
+		return 0, fmt.Errorf("invalid RERANK_CANDIDATES: %s (must be between 1 and %d)", s, maxCandidates)
+	}
+	return n, nil
+}
@@ -0,0 +1,325 @@
+// Package retention prunes sources past the retention period of their
+// topic, e.g. news topics after 18 months while evergreen topics keep
+// theirs forever. Expired sources are removed from SQLite (records and
+// keyword index) and Qdrant, optionally archived as NDJSON first; sources
+// an article cites are kept. A dry run reports what would be pruned.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/slug"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// DefaultTopic is the topic key of the policy for topics without their own
+const DefaultTopic = "*"
+
+// Period is how long sources are kept; the zero Period keeps them forever
+type Period struct {
+	Years, Months, Days int
+}
+
+// Forever reports whether the period keeps sources forever
+func (p Period) Forever() bool {
+	return p == Period{}
+}
+
+// Cutoff returns the time before which sources are expired at now
+func (p Period) Cutoff(now time.Time) time.Time {
+	return now.AddDate(-p.Years, -p.Months, -p.Days)
+}
+
+// String formats the period the way ParsePeriod reads it
+func (p Period) String() string {
+	switch {
+	case p.Forever():
+		return "forever"
+	case p.Years > 0:
+		return strconv.Itoa(p.Years) + "y"
+	case p.Months > 0:
+		return strconv.Itoa(p.Months) + "mo"
+	default:
+		return strconv.Itoa(p.Days) + "d"
+	}
+}
+
+// ParsePeriod parses a retention period: a number of days (30d), weeks
+// (8w), months (18mo) or years (2y), or "forever"
+func ParsePeriod(s string) (Period, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "forever" {
+		return Period{}, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return Period{}, fmt.Errorf("invalid retention period %q", s)
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n <= 0 {
+		return Period{}, fmt.Errorf("invalid retention period %q", s)
+	}
+	switch s[i:] {
+	case "d":
+		return Period{Days: n}, nil
+	case "w":
+		return Period{Days: 7 * n}, nil
+	case "mo":
+		return Period{Months: n}, nil
+	case "y":
+		return Period{Years: n}, nil
+	}
+	return Period{}, fmt.Errorf("invalid retention period %q (expected a number of d, w, mo or y, or forever)", s)
+}
+
+// Policies are the retention periods by topic slug. DefaultTopic applies to
+// topics without their own; without it, they keep their sources forever.
+type Policies map[string]Period
+
+// ParsePolicies parses policies such as "news=18mo,tech-news=2y,*=forever".
+// Empty input means no policies.
+func ParsePolicies(s string) (Policies, error) {
+	policies := make(Policies)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		topic, value, ok := strings.Cut(part, "=")
+		if topic = strings.TrimSpace(topic); topic != DefaultTopic {
+			topic = slug.Make(topic)
+		}
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("expected topic=period, got %q", part)
+		}
+		period, err := ParsePeriod(value)
+		if err != nil {
+			return nil, err
+		}
+		policies[topic] = period
+	}
+	return policies, nil
+}
+
+// For returns the retention period of a topic
+func (p Policies) For(topic string) Period {
+	if period, ok := p[topic]; ok {
+		return period
+	}
+	return p[DefaultTopic]
+}
+
+// Options configure a pruning run
+type Options struct {
+	Policies Policies
+	// Now is the time retention is measured from; zero means the current
+	// time
+	Now time.Time
+	// DryRun reports the expired sources without pruning them
+	DryRun bool
+	// ArchiveDir receives the pruned sources, content included, as an
+	// NDJSON file per run in the format of GET /export, so they can be
+	// restored with POST /import; empty deletes them without a copy
+	ArchiveDir string
+}
+
+// ExpiredSource is a source past its topic's retention period
+type ExpiredSource struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+	// Date is the publication date, or the ingestion date of sources
+	// without one, which retention is measured from
+	Date string `json:"date"`
+}
+
+// TopicReport is the outcome of a run for one topic
+type TopicReport struct {
+	Topic  string          `json:"topic"`
+	Period string          `json:"period"`
+	Cutoff string          `json:"cutoff"` // Sources dated before are expired
+	Pruned []ExpiredSource `json:"pruned"` // Or to prune, in a dry run
+	// Cited counts expired sources kept because an article cites them
+	Cited int `json:"cited"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	DryRun  bool          `json:"dry_run"`
+	Topics  []TopicReport `json:"topics"` // Topics with expired sources, by slug
+	Pruned  int           `json:"pruned"`
+	Cited   int           `json:"cited"`
+	Archive string        `json:"archive,omitempty"` // The archive file written
+}
+
+// archiveRecord is a line of an archive: the record format of GET /export
+type archiveRecord struct {
+	Type   string           `json:"type"`
+	Source *database.Source `json:"source"`
+}
+
+// Run prunes the sources past their topic's retention period, or only
+// reports them in a dry run. A source is dated by its publication date,
+// falling back to when it was ingested. Sources cited by an article are
+// kept, as the article's evidence.
+func Run(ctx context.Context, db store.Store, vectorDB store.VectorStore, opts Options) (Report, error) {
+	report := Report{DryRun: opts.DryRun, Topics: []TopicReport{}}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	cited := make(map[string]bool)
+	citedIDs, err := db.CitedSourceIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list cited sources: %w", err)
+	}
+	for _, id := range citedIDs {
+		cited[id] = true
+	}
+
+	ids, err := db.SourceIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list sources: %w", err)
+	}
+	topics := make(map[string]*TopicReport)
+	var expired []string
+	for _, id := range ids {
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			return report, err
+		}
+		if src == nil {
+			continue
+		}
+		period := opts.Policies.For(src.Topic)
+		if period.Forever() {
+			continue
+		}
+		cutoff := period.Cutoff(now).Format(time.RFC3339)
+		date := src.PublishedAt
+		if date == "" {
+			date = src.CreatedAt
+		}
+		if date >= cutoff {
+			continue
+		}
+
+		tr := topics[src.Topic]
+		if tr == nil {
+			tr = &TopicReport{Topic: src.Topic, Period: period.String(), Cutoff: cutoff, Pruned: []ExpiredSource{}}
+			topics[src.Topic] = tr
+		}
+		if cited[id] {
+			tr.Cited++
+			report.Cited++
+			continue
+		}
+		tr.Pruned = append(tr.Pruned, ExpiredSource{ID: src.ID, Title: src.Title, URL: src.URL, Date: date})
+		expired = append(expired, id)
+	}
+	for _, tr := range topics {
+		report.Topics = append(report.Topics, *tr)
+	}
+	sort.Slice(report.Topics, func(i, j int) bool { return report.Topics[i].Topic < report.Topics[j].Topic })
+
+	if opts.DryRun {
+		report.Pruned = len(expired)
+		return report, nil
+	}
+	if len(expired) > 0 && opts.ArchiveDir != "" {
+		path := filepath.Join(opts.ArchiveDir, "pruned-"+now.Format("20060102T150405Z")+".ndjson")
+		if err := archive(ctx, db, path, expired); err != nil {
+			return report, err
+		}
+		report.Archive = path
+	}
+	for _, id := range expired {
+		// Pruned sources skip the trash
+		if err := db.DeleteSource(ctx, id); err != nil {
+			return report, fmt.Errorf("failed to delete source %s: %w", id, err)
+		}
+		if _, err := db.PurgeSource(ctx, id); err != nil {
+			return report, fmt.Errorf("failed to purge source %s: %w", id, err)
+		}
+		// The record is gone: a stale point is reported but doesn't stop
+		// the run
+		if err := vectorDB.DeleteSource(ctx, id); err != nil {
+			log.Printf("Failed to delete source %s from Qdrant: %v", id, err)
+		}
+		report.Pruned++
+	}
+	return report, nil
+}
+
+// archive writes the sources, with their content, to a new NDJSON file
+func archive(ctx context.Context, db store.Store, path string, ids []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, id := range ids {
+		src, err := db.GetSource(ctx, id)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if src == nil {
+			continue
+		}
+		if src.Content, err = db.SourceContent(ctx, id); err != nil {
+			f.Close()
+			return err
+		}
+		if err := enc.Encode(archiveRecord{Type: "source", Source: src}); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	// Nothing is deleted unless the archive is complete on disk
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Schedule prunes expired sources every interval until ctx is cancelled
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, vectorDB store.VectorStore, opts Options) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			report, err := Run(ctx, db, vectorDB, opts)
+			if err != nil {
+				log.Printf("Retention pruning failed: %v", err)
+				continue
+			}
+			log.Printf("Retention: %d sources pruned, %d expired but cited kept (%s)",
+				report.Pruned, report.Cited, time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
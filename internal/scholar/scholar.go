@@ -0,0 +1,301 @@
+// Package scholar fetches the canonical metadata of papers (authors,
+// abstract, publication date, venue) from Crossref and arXiv for sources
+// whose URL contains a DOI or an arXiv ID.
+package scholar
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+)
+
+const (
+	// DefaultCrossrefURL is the Crossref REST API
+	DefaultCrossrefURL = "https://api.crossref.org"
+	// DefaultArxivURL is the arXiv query API
+	DefaultArxivURL = "https://export.arxiv.org/api/query"
+)
+
+var (
+	doiPattern     = regexp.MustCompile(`10\.\d{4,9}/\S+`)
+	arxivIDPattern = regexp.MustCompile(`^(\d{4}\.\d{4,5}|[a-z][a-z.-]*/\d{7})(v\d+)?$`)
+	arxivDOIPrefix = "10.48550/arxiv."
+	tagPattern     = regexp.MustCompile(`<[^>]+>`)
+)
+
+// doiSuffixes are path suffixes publishers add after the DOI in their URLs
+var doiSuffixes = []string{".pdf", "/full", "/abstract", "/pdf", "/epdf", "/html"}
+
+// Client fetches paper metadata
+type Client struct {
+	crossrefURL string
+	arxivURL    string
+	mailto      string // Contact address for Crossref's polite pool
+	httpClient  *http.Client
+}
+
+// NewClient creates a client for the public Crossref and arXiv APIs.
+// CROSSREF_MAILTO identifies the caller to Crossref, which serves
+// identified callers from a faster pool.
+func NewClient() *Client {
+	return NewClientWithConfig(DefaultCrossrefURL, DefaultArxivURL, os.Getenv("CROSSREF_MAILTO"))
+}
+
+// NewClientWithConfig creates a client with explicit API endpoints
+func NewClientWithConfig(crossrefURL, arxivURL, mailto string) *Client {
+	return &Client{
+		crossrefURL: strings.TrimSuffix(crossrefURL, "/"),
+		arxivURL:    arxivURL,
+		mailto:      mailto,
+		httpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+// Identify extracts the DOI or arXiv ID of a paper from its URL. arXiv IDs
+// are returned without their version; DOIs minted by arXiv are returned as
+// arXiv IDs.
+func Identify(rawURL string) (doi, arxivID string) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", ""
+	}
+	host := database.NormalizeDomain(u.Hostname())
+
+	if host == "arxiv.org" || host == "export.arxiv.org" {
+		for _, prefix := range []string{"/abs/", "/pdf/", "/html/"} {
+			if id, ok := strings.CutPrefix(u.Path, prefix); ok {
+				return "", normalizeArxivID(strings.TrimSuffix(strings.Trim(id, "/"), ".pdf"))
+			}
+		}
+		return "", ""
+	}
+
+	doi = doiPattern.FindString(u.Path)
+	if host == "doi.org" || host == "dx.doi.org" {
+		doi = strings.TrimPrefix(u.Path, "/")
+	}
+	for _, suffix := range doiSuffixes {
+		doi = strings.TrimSuffix(doi, suffix)
+	}
+	if id, ok := strings.CutPrefix(strings.ToLower(doi), arxivDOIPrefix); ok {
+		return "", normalizeArxivID(id)
+	}
+	return doi, ""
+}
+
+// normalizeArxivID validates an arXiv ID and strips its version, returning
+// "" for invalid IDs
+func normalizeArxivID(id string) string {
+	m := arxivIDPattern.FindStringSubmatch(id)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// Lookup returns the metadata of the paper at rawURL, or nil if the URL has
+// no DOI or arXiv ID or the registry doesn't know it
+func (c *Client) Lookup(ctx context.Context, rawURL string) (*database.Publication, error) {
+	doi, arxivID := Identify(rawURL)
+	switch {
+	case arxivID != "":
+		return c.arxiv(ctx, arxivID)
+	case doi != "":
+		return c.crossref(ctx, doi)
+	}
+	return nil, nil
+}
+
+// Enrich looks up the source's paper and merges its metadata into the
+// source. It reports whether metadata was found.
+func (c *Client) Enrich(ctx context.Context, src *database.Source) (bool, error) {
+	pub, err := c.Lookup(ctx, src.URL)
+	if err != nil || pub == nil {
+		return false, err
+	}
+	src.ApplyPublication(pub)
+	return true, nil
+}
+
+// get fetches a URL, returning nil for 404s
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "gitopedia-knowledge-base")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// crossrefWork is the part of a Crossref /works/{doi} response we use
+type crossrefWork struct {
+	Message struct {
+		DOI    string   `json:"DOI"`
+		Title  []string `json:"title"`
+		Author []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+			Name   string `json:"name"` // Organizations
+		} `json:"author"`
+		Abstract       string    `json:"abstract"` // JATS XML
+		ContainerTitle []string  `json:"container-title"`
+		Published      dateParts `json:"published"`
+		Issued         dateParts `json:"issued"`
+	} `json:"message"`
+}
+
+type dateParts struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// String formats the date as 2006-01-02, 2006-01 or 2006, or "" if unknown
+func (d dateParts) String() string {
+	if len(d.DateParts) == 0 || len(d.DateParts[0]) == 0 || d.DateParts[0][0] == 0 {
+		return ""
+	}
+	parts := d.DateParts[0]
+	s := fmt.Sprintf("%04d", parts[0])
+	for _, p := range parts[1:min(len(parts), 3)] {
+		s += fmt.Sprintf("-%02d", p)
+	}
+	return s
+}
+
+func (c *Client) crossref(ctx context.Context, doi string) (*database.Publication, error) {
+	segments := strings.Split(doi, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	endpoint := c.crossrefURL + "/works/" + strings.Join(segments, "/")
+	if c.mailto != "" {
+		endpoint += "?mailto=" + url.QueryEscape(c.mailto)
+	}
+
+	body, err := c.get(ctx, endpoint)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	var work crossrefWork
+	if err := json.Unmarshal(body, &work); err != nil {
+		return nil, fmt.Errorf("failed to decode Crossref response: %w", err)
+	}
+
+	m := work.Message
+	pub := &database.Publication{
+		DOI:       doi,
+		Abstract:  stripJATS(m.Abstract),
+		Published: m.Published.String(),
+	}
+	if m.DOI != "" {
+		pub.DOI = m.DOI
+	}
+	if len(m.Title) > 0 {
+		pub.Title = clean(m.Title[0])
+	}
+	if len(m.ContainerTitle) > 0 {
+		pub.Venue = clean(m.ContainerTitle[0])
+	}
+	if pub.Published == "" {
+		pub.Published = m.Issued.String()
+	}
+	for _, a := range m.Author {
+		name := clean(strings.TrimSpace(a.Given + " " + a.Family))
+		if name == "" {
+			name = clean(a.Name)
+		}
+		if name != "" {
+			pub.Authors = append(pub.Authors, name)
+		}
+	}
+	return pub, nil
+}
+
+// arxivFeed is the part of an arXiv API Atom response we use
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"http://www.w3.org/2005/Atom id"`
+		Title     string `xml:"http://www.w3.org/2005/Atom title"`
+		Summary   string `xml:"http://www.w3.org/2005/Atom summary"`
+		Published string `xml:"http://www.w3.org/2005/Atom published"`
+		Authors   []struct {
+			Name string `xml:"http://www.w3.org/2005/Atom name"`
+		} `xml:"http://www.w3.org/2005/Atom author"`
+		JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+		DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+func (c *Client) arxiv(ctx context.Context, id string) (*database.Publication, error) {
+	body, err := c.get(ctx, c.arxivURL+"?id_list="+url.QueryEscape(id))
+	if err != nil || body == nil {
+		return nil, err
+	}
+	var feed arxivFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to decode arXiv response: %w", err)
+	}
+	// Unknown IDs come back as an error entry
+	if len(feed.Entries) == 0 || strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return nil, nil
+	}
+
+	e := feed.Entries[0]
+	pub := &database.Publication{
+		ArXivID:  id,
+		DOI:      strings.TrimSpace(e.DOI),
+		Title:    clean(e.Title),
+		Abstract: clean(e.Summary),
+		Venue:    clean(e.JournalRef),
+	}
+	if len(e.Published) >= len(time.DateOnly) {
+		pub.Published = e.Published[:len(time.DateOnly)]
+	}
+	if pub.Venue == "" {
+		pub.Venue = "arXiv"
+	}
+	for _, a := range e.Authors {
+		if name := clean(a.Name); name != "" {
+			pub.Authors = append(pub.Authors, name)
+		}
+	}
+	return pub, nil
+}
+
+// stripJATS turns a JATS XML abstract into plain text, dropping a leading
+// "Abstract" heading
+func stripJATS(s string) string {
+	s = strings.ReplaceAll(s, "<jats:title>Abstract</jats:title>", "")
+	return clean(html.UnescapeString(tagPattern.ReplaceAllString(s, " ")))
+}
+
+// clean collapses whitespace, including the line breaks of Atom fields
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,65 @@
+// Package simhash computes 64-bit SimHash fingerprints of text. Texts that
+// share most of their word shingles get fingerprints that differ in few
+// bits, so near-duplicates are found by comparing fingerprints.
+package simhash
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+const (
+	// shingleWords is the number of words per feature
+	shingleWords = 3
+	// MinWords is the length below which texts are not fingerprinted; short
+	// texts share too many shingles by chance
+	MinWords = 8
+	// NearDistance is the largest number of differing bits between the
+	// fingerprints of near-identical texts
+	NearDistance = 3
+)
+
+// Fingerprint returns the SimHash of a text over its lowercased word
+// shingles, and false for texts shorter than MinWords words
+func Fingerprint(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < MinWords {
+		return 0, false
+	}
+
+	var weights [64]int
+	for i := 0; i+shingleWords <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleWords], " ")))
+		sum := h.Sum64()
+		for b := range weights {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var fp uint64
+	for b, w := range weights {
+		if w > 0 {
+			fp |= 1 << b
+		}
+	}
+	return fp, true
+}
+
+// Distance returns the number of bits in which two fingerprints differ
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Near reports whether two fingerprints belong to near-identical texts
+func Near(a, b uint64) bool {
+	return Distance(a, b) <= NearDistance
+}
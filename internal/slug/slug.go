@@ -0,0 +1,105 @@
+// Package slug normalizes topic slugs and IDs, so that the topics and IDs
+// derived from titles, filenames and requests come out the same whichever
+// entry point (ingest, indexer or server) derives them.
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxLen is the length limit of slugs, in runes
+	MaxLen = 80
+	// MaxIDLen is the length limit of IDs, in runes
+	MaxIDLen = 128
+)
+
+// transliterations spells out the Latin letters that don't decompose into
+// a base letter and accents
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d",
+	'þ': "th", 'ł': "l", 'ı': "i", 'ħ': "h", 'ŋ': "ng", 'ĸ': "k",
+}
+
+// Make returns the slug of a title or file name: lowercased, with accents
+// removed from Latin letters (Ä → a, ß → ss), and every run of other
+// characters than letters and digits replaced by a hyphen. Other scripts
+// are kept as they are (NFC normalized, with their combining marks), so
+// non-Latin titles keep a readable slug. Slugs longer than MaxLen are cut
+// at a hyphen where possible.
+func Make(s string) string {
+	var b strings.Builder
+	hyphen := false
+	write := func(r rune) {
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteRune(r)
+	}
+	for _, r := range norm.NFC.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			if t, ok := transliterations[r]; ok {
+				for _, c := range t {
+					write(c)
+				}
+				continue
+			}
+			// Ligatures and fullwidth forms decompose too (ﬁ → fi)
+			for _, d := range norm.NFKD.String(string(r)) {
+				if unicode.IsLetter(d) || unicode.IsDigit(d) {
+					write(d)
+				}
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc):
+			write(r)
+		default:
+			hyphen = true
+		}
+	}
+	return truncate(b.String(), MaxLen)
+}
+
+// ID returns an ID made safe for URLs, file names and payloads: NFC
+// normalized, with every run of whitespace and other characters than
+// letters, digits, '-', '_', '.' and ':' replaced by a hyphen, and at most
+// MaxIDLen runes long. Case is kept, so ULIDs and existing IDs are
+// unchanged.
+func ID(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFC.String(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:", r) {
+			if hyphen {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = b.Len() > 0
+	}
+	id := b.String()
+	if runes := []rune(id); len(runes) > MaxIDLen {
+		id = string(runes[:MaxIDLen])
+	}
+	return id
+}
+
+// truncate cuts a slug to at most n runes, at the last hyphen when it
+// keeps at least half of them
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, '-'); i >= len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSuffix(cut, "-")
+}
@@ -0,0 +1,273 @@
+// Package sourcebatch reads sources from JSONL and CSV files, such as the
+// exports of bookmarking and reference tools, into records with the fields
+// of source frontmatter, so they can be ingested without converting them to
+// markdown first.
+package sourcebatch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Batch file formats
+const (
+	FormatJSONL = "jsonl" // One JSON object per line
+	FormatCSV   = "csv"   // A header row of field names, then one source per row
+)
+
+// ListSeparator separates the items of list fields (tags, people, orgs,
+// places) in CSV cells and in JSON strings
+const ListSeparator = ";"
+
+// Record is one source of a batch file
+type Record struct {
+	Line       int // Line of the record in the file, for error messages
+	ID         string
+	Title      string
+	URL        string
+	Type       string
+	Topic      string
+	Created    string
+	Published  string
+	Tags       []string
+	People     []string
+	Orgs       []string
+	Places     []string
+	Summary    string
+	Model      string
+	Language   string
+	Visibility string
+	Owner      string
+	// Name of the summary style profile the summary was written with
+	SummaryStyle string
+}
+
+// fieldAliases maps the names a field may have in a batch file to its
+// canonical name. Fields are named as in source frontmatter; the names of
+// the API (topic, created_at, published_at) work too.
+var fieldAliases = map[string]string{
+	"id": "id", "title": "title", "url": "url", "type": "type",
+	"topic": "topic", "related_article": "topic",
+	"created": "created", "created_at": "created",
+	"published": "published", "published_at": "published",
+	"tags": "tags", "people": "people", "orgs": "orgs", "places": "places",
+	"summary": "summary", "model": "model", "language": "language",
+	"visibility": "visibility", "owner": "owner",
+	"summary_style": "summary_style",
+}
+
+// FormatOf returns the batch format of a file by its extension (.jsonl,
+// .ndjson or .csv), or "" for other files
+func FormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".csv":
+		return FormatCSV
+	}
+	return ""
+}
+
+// ParseColumns parses a column mapping such as "Link=url,Name=title", which
+// renames the columns (or JSON keys) of files from other tools to source
+// fields. Empty input means no mapping.
+func ParseColumns(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		column, field, ok := strings.Cut(part, "=")
+		column, field = strings.TrimSpace(column), strings.ToLower(strings.TrimSpace(field))
+		if !ok || column == "" {
+			return nil, fmt.Errorf("expected column=field, got %q", part)
+		}
+		if _, known := fieldAliases[field]; !known {
+			return nil, fmt.Errorf("unknown source field %q", field)
+		}
+		columns[column] = field
+	}
+	return columns, nil
+}
+
+// Decode reads the records of a batch file. columns renames columns to
+// source fields (see ParseColumns); other columns are matched to fields by
+// name, case-insensitively, and unknown ones are ignored. A malformed line
+// fails the whole file.
+func Decode(r io.Reader, format string, columns map[string]string) ([]Record, error) {
+	switch format {
+	case FormatJSONL:
+		return decodeJSONL(r, columns)
+	case FormatCSV:
+		return decodeCSV(r, columns)
+	}
+	return nil, fmt.Errorf("unknown batch format %q", format)
+}
+
+func decodeJSONL(r io.Reader, columns map[string]string) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var values map[string]any
+		if err := json.Unmarshal(text, &values); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", line, err)
+		}
+		rec := Record{Line: line}
+		for key, value := range values {
+			if err := rec.set(fieldName(key, columns), value); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, key, err)
+			}
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func decodeCSV(r io.Reader, columns map[string]string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	fields := make([]string, len(header))
+	for i, column := range header {
+		// Spreadsheet exports often start with a byte order mark
+		fields[i] = fieldName(strings.TrimPrefix(column, "\ufeff"), columns)
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err)
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rec := Record{Line: line}
+		for i, value := range row {
+			if err := rec.set(fields[i], value); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, header[i], err)
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+// fieldName returns the source field a column or JSON key maps to, or ""
+func fieldName(column string, columns map[string]string) string {
+	if field, ok := columns[column]; ok {
+		return fieldAliases[field]
+	}
+	return fieldAliases[strings.ToLower(strings.TrimSpace(column))]
+}
+
+// set assigns a value to a field of the record. Lists are given as JSON
+// arrays or as strings separated by ListSeparator; null and empty values
+// leave the field unset.
+func (rec *Record) set(field string, value any) error {
+	if value == nil {
+		return nil
+	}
+	switch field {
+	case "":
+		return nil // Not a source field
+	case "tags":
+		return setList(&rec.Tags, value)
+	case "people":
+		return setList(&rec.People, value)
+	case "orgs":
+		return setList(&rec.Orgs, value)
+	case "places":
+		return setList(&rec.Places, value)
+	}
+
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case float64, bool:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("expected a string, got %T", value)
+	}
+	switch field {
+	case "id":
+		rec.ID = s
+	case "title":
+		rec.Title = s
+	case "url":
+		rec.URL = s
+	case "type":
+		rec.Type = s
+	case "topic":
+		rec.Topic = s
+	case "created":
+		rec.Created = s
+	case "published":
+		rec.Published = s
+	case "summary":
+		rec.Summary = s
+	case "model":
+		rec.Model = s
+	case "language":
+		rec.Language = s
+	case "visibility":
+		rec.Visibility = s
+	case "owner":
+		rec.Owner = s
+	case "summary_style":
+		rec.SummaryStyle = s
+	}
+	return nil
+}
+
+// setList assigns a list field from a JSON array of strings or from a
+// string separated by ListSeparator
+func setList(list *[]string, value any) error {
+	var items []string
+	switch v := value.(type) {
+	case string:
+		items = strings.Split(v, ListSeparator)
+	case []any:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a list of strings, got %v", item)
+			}
+			items = append(items, s)
+		}
+	default:
+		return fmt.Errorf("expected a list of strings, got %T", value)
+	}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			*list = append(*list, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package spell suggests corrections for misspelled query terms from a
+// corpus vocabulary, using the symmetric delete algorithm (SymSpell):
+// vocabulary words are indexed by the strings left after deleting up to
+// MaxDistance characters, so candidates for a term are found by looking up
+// its own deletes instead of comparing it to every word.
+package spell
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxDistance is the largest edit distance of a suggestion
+	MaxDistance = 2
+	// prefixLength is how much of a word is indexed; longer words are
+	// matched on their prefix and then verified in full, which bounds the
+	// number of deletes per word
+	prefixLength = 7
+	// minWordLength is the length below which terms are not corrected;
+	// short words have too many neighbours for a suggestion to be useful
+	minWordLength = 4
+)
+
+// Corrector suggests vocabulary words for misspelled terms
+type Corrector struct {
+	words   []string
+	counts  map[string]int     // Word → number of documents containing it
+	deletes map[string][]int32 // Delete → indexes of the words producing it
+}
+
+// New builds a corrector from a vocabulary of words and their document
+// counts
+func New(vocabulary map[string]int) *Corrector {
+	c := &Corrector{
+		counts:  make(map[string]int, len(vocabulary)),
+		deletes: make(map[string][]int32),
+	}
+	for word, count := range vocabulary {
+		word = strings.ToLower(word)
+		if utf8.RuneCountInString(word) < minWordLength-MaxDistance || !isWord(word) {
+			continue
+		}
+		if _, ok := c.counts[word]; ok {
+			c.counts[word] += count
+			continue
+		}
+		c.counts[word] = count
+		i := int32(len(c.words))
+		c.words = append(c.words, word)
+		for d := range deletes(prefix(word)) {
+			c.deletes[d] = append(c.deletes[d], i)
+		}
+	}
+	return c
+}
+
+// Len returns the number of words in the vocabulary
+func (c *Corrector) Len() int {
+	return len(c.words)
+}
+
+// Known reports whether a word is in the vocabulary
+func (c *Corrector) Known(word string) bool {
+	_, ok := c.counts[strings.ToLower(word)]
+	return ok
+}
+
+// Suggest returns the vocabulary word closest to term, preferring the most
+// frequent among equally close words, and false if none is within
+// MaxDistance. Known words are their own suggestion.
+func (c *Corrector) Suggest(term string) (string, bool) {
+	term = strings.ToLower(term)
+	if c.Known(term) {
+		return term, true
+	}
+	if utf8.RuneCountInString(term) < minWordLength {
+		return "", false
+	}
+
+	best, bestDistance, bestCount := "", MaxDistance+1, 0
+	seen := make(map[int32]bool)
+	for d := range deletes(prefix(term)) {
+		for _, i := range c.deletes[d] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			word := c.words[i]
+			// Exact up to bestDistance, so ties are real ties
+			dist := distance(term, word, min(bestDistance, MaxDistance)+1)
+			if dist > MaxDistance {
+				continue
+			}
+			if dist < bestDistance || (dist == bestDistance && c.counts[word] > bestCount) {
+				best, bestDistance, bestCount = word, dist, c.counts[word]
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// CorrectQuery replaces the unknown words of a query with suggestions,
+// keeping its punctuation, quotes and FTS operators. Words for which keep
+// returns true (e.g. synonyms and stopwords) are left alone; keep may be
+// nil. It reports whether anything was replaced.
+func (c *Corrector) CorrectQuery(query string, keep func(word string) bool) (string, bool) {
+	fields := strings.Fields(query)
+	changed := false
+	for i, field := range fields {
+		switch field {
+		case "AND", "OR", "NOT":
+			continue
+		}
+		start := strings.IndexFunc(field, isWordRune)
+		if start < 0 {
+			continue
+		}
+		end := strings.LastIndexFunc(field, isWordRune)
+		end += utf8.RuneLen([]rune(field[end:])[0])
+		word := field[start:end]
+		if !isWord(word) || c.Known(word) || (keep != nil && keep(strings.ToLower(word))) {
+			continue
+		}
+		if suggestion, ok := c.Suggest(word); ok {
+			fields[i] = field[:start] + suggestion + field[end:]
+			changed = true
+		}
+	}
+	if !changed {
+		return query, false
+	}
+	return strings.Join(fields, " "), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isWord reports whether s consists of letters only; terms with digits or
+// punctuation (versions, identifiers) are not corrected
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// prefix truncates a word to prefixLength runes
+func prefix(word string) string {
+	runes := []rune(word)
+	if len(runes) > prefixLength {
+		return string(runes[:prefixLength])
+	}
+	return word
+}
+
+// deletes returns the strings left after deleting up to MaxDistance runes
+// from s, including s itself
+func deletes(s string) map[string]bool {
+	out := map[string]bool{s: true}
+	frontier := []string{s}
+	for range MaxDistance {
+		var next []string
+		for _, w := range frontier {
+			runes := []rune(w)
+			if len(runes) <= 1 {
+				continue
+			}
+			for i := range runes {
+				d := string(runes[:i]) + string(runes[i+1:])
+				if !out[d] {
+					out[d] = true
+					next = append(next, d)
+				}
+			}
+		}
+		frontier = next
+	}
+	return out
+}
+
+// distance returns the optimal string alignment distance (Levenshtein with
+// adjacent transpositions) between a and b, or limit if it is at least
+// limit
+func distance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff >= limit || -diff >= limit {
+		return limit
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(rb)], limit)
+}
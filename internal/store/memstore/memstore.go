@@ -0,0 +1,2325 @@
+// Package memstore provides in-memory implementations of the store
+// interfaces. They keep the observable behavior of the SQLite and Qdrant
+// backends (visibility rules, ordering, upsert semantics) without their
+// infrastructure, for handler tests and local experiments.
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// Store is an in-memory store.Store
+type Store struct {
+	mu        sync.RWMutex
+	sources   map[string]database.Source
+	contents  map[string]string // Source content, kept apart as GetSource leaves it out
+	articles  map[string]database.Article
+	profiles  map[string]database.Profile        // Keyed by user ID and model
+	queue     map[string]database.QueueItem      // Keyed by user and source ID
+	watches   map[string]database.Watch          // Keyed by ID
+	matches   []database.WatchMatch              // In the order they were recorded
+	matchSeq  int64                              // Sequence number of the last match
+	access    map[string]database.SourceAccess   // Keyed by source ID
+	aliases   map[string]database.Alias          // Keyed by kind and old ID
+	entities  map[string]database.EntityAlias    // Keyed by kind and alias key
+	links     map[string]database.LinkSuggestion // Keyed by article and source ID
+	claims    map[string]database.ClaimSet       // Keyed by source ID
+	reports   map[string]database.TopicReport    // Keyed by topic and period start
+	cats      map[string]database.Category       // Keyed by path
+	synonyms  map[string][]string
+	stopwords map[string]bool
+	apiKeys   map[string]apiKey // Keyed by ID
+	webhooks  map[string]database.Webhook
+	events    []database.Event
+	info      map[string]string
+	counts    map[string]database.RowCount
+
+	// Deleted sources, with when they were deleted, until they are restored
+	// or purged
+	trash     map[string]database.Source
+	deletedAt map[string]string
+
+	// Source writes queued while the server protects its stores
+	outbox    []database.QueuedWrite
+	outboxSeq int64
+
+	// Previous versions of sources and articles by ID, oldest first
+	sourceRevisions  map[string][]database.Revision
+	articleRevisions map[string][]database.Revision
+
+	// Topic taxonomy, keyed by slug
+	topics map[string]database.Topic
+}
+
+var _ store.Store = (*Store)(nil)
+
+// New returns an empty in-memory store
+func New() *Store {
+	s := &Store{
+		sources:   make(map[string]database.Source),
+		contents:  make(map[string]string),
+		trash:     make(map[string]database.Source),
+		deletedAt: make(map[string]string),
+		articles:  make(map[string]database.Article),
+		profiles:  make(map[string]database.Profile),
+		queue:     make(map[string]database.QueueItem),
+		watches:   make(map[string]database.Watch),
+		access:    make(map[string]database.SourceAccess),
+		aliases:   make(map[string]database.Alias),
+		entities:  make(map[string]database.EntityAlias),
+		links:     make(map[string]database.LinkSuggestion),
+		claims:    make(map[string]database.ClaimSet),
+		reports:   make(map[string]database.TopicReport),
+		cats:      make(map[string]database.Category),
+		synonyms:  make(map[string][]string),
+		stopwords: make(map[string]bool),
+		apiKeys:   make(map[string]apiKey),
+		webhooks:  make(map[string]database.Webhook),
+		info:      make(map[string]string),
+		counts:    make(map[string]database.RowCount),
+
+		sourceRevisions:  make(map[string][]database.Revision),
+		articleRevisions: make(map[string][]database.Revision),
+
+		topics: make(map[string]database.Topic),
+	}
+	s.recount()
+	return s
+}
+
+// Close implements store.Store; it does nothing
+func (s *Store) Close() error {
+	return nil
+}
+
+// Ping implements store.Store; the in-memory store is always available
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// InsertSource inserts or replaces a source. Like the SQLite store, a source
+// with the same URL but another ID is replaced.
+func (s *Store) InsertSource(ctx context.Context, src database.Source) error {
+	return s.InsertSources(ctx, []database.Source{src})
+}
+
+// InsertSources inserts or replaces sources
+func (s *Store) InsertSources(ctx context.Context, srcs []database.Source) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, src := range srcs {
+		if src.Visibility == "" {
+			src.Visibility = database.VisibilityPublic
+		}
+		src.Domain = database.URLDomain(src.URL)
+		src.Type = database.NormalizeSourceType(src.Type)
+		src.WordCount = database.WordCount(src.Summary)
+		src.ReadingMinutes = database.ReadingMinutes(src.WordCount)
+		src.Links = database.ExtractSourceLinks(&src)
+		sort.Slice(src.Links, func(i, j int) bool { return src.Links[i].URL < src.Links[j].URL })
+		for id, existing := range s.sources {
+			if existing.URL == src.URL && id != src.ID {
+				delete(s.sources, id)
+				delete(s.contents, id)
+				delete(s.claims, id)
+			}
+		}
+		// A deleted source is replaced like a live one
+		for id, existing := range s.trash {
+			if existing.URL == src.URL || id == src.ID {
+				delete(s.trash, id)
+				delete(s.deletedAt, id)
+				if id != src.ID {
+					delete(s.contents, id)
+					delete(s.claims, id)
+				}
+			}
+		}
+		if prev, ok := s.sources[src.ID]; ok {
+			revise(s.sourceRevisions, src.ID, database.SourceRevisionFields(prev), database.SourceRevisionFields(src))
+		} else if prev, ok := s.trash[src.ID]; ok {
+			revise(s.sourceRevisions, src.ID, database.SourceRevisionFields(prev), database.SourceRevisionFields(src))
+		}
+		s.appendEvent(database.EventSourceUpserted, src.ID, src)
+		// Like the SQLite store, the content is kept when none is given
+		if src.Content != "" {
+			s.contents[src.ID] = src.Content
+		}
+		src.Content = ""
+		s.sources[src.ID] = src
+	}
+	s.recount()
+	return nil
+}
+
+// GetSource retrieves a source by ID, or nil if it doesn't exist
+func (s *Store) GetSource(ctx context.Context, id string) (*database.Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	src, ok := s.sources[id]
+	if !ok {
+		return nil, nil
+	}
+	return &src, nil
+}
+
+// SourceContent returns the full text of a source, or "" if it has none or
+// doesn't exist
+func (s *Store) SourceContent(ctx context.Context, id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.contents[id], nil
+}
+
+// GetSourceByURL retrieves a source by URL, or nil if it doesn't exist
+func (s *Store) GetSourceByURL(ctx context.Context, url string) (*database.Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, src := range s.sources {
+		if src.URL == url {
+			return &src, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetSourceByCanonicalURL retrieves a source whose URL has the same
+// canonical form as rawURL, or nil if there is none
+func (s *Store) GetSourceByCanonicalURL(ctx context.Context, rawURL string) (*database.Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	canonical := database.CanonicalURL(rawURL)
+	for _, src := range s.sources {
+		if database.CanonicalURL(src.URL) == canonical {
+			return &src, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetSourcesByTopic returns the sources of a topic visible to user, newest first
+func (s *Store) GetSourcesByTopic(ctx context.Context, topic string, limit int, user string) ([]database.Source, error) {
+	return s.filterSources(limit, func(src *database.Source) bool {
+		return src.Topic == topic && src.VisibleTo(user)
+	}), nil
+}
+
+// ListSources returns the sources matching filter visible to user in the
+// filter's order, newest first by default
+func (s *Store) ListSources(ctx context.Context, filter database.SourceFilter, limit int, user string) ([]database.Source, error) {
+	keep := func(src *database.Source) bool {
+		return matchesFilter(src, filter, user) && (filter.After == nil || filter.After.Follows(*src))
+	}
+	if filter.Order != database.SourceOrderPublished {
+		return s.filterSources(limit, keep), nil
+	}
+
+	// Most recently published first, undated sources last
+	sources := s.filterSources(len(s.sources), keep)
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].PublishedAt > sources[j].PublishedAt
+	})
+	if len(sources) > limit {
+		sources = sources[:limit]
+	}
+	return sources, nil
+}
+
+// CountSourcesByType counts the typed sources matching filter visible to
+// user, by type
+func (s *Store) CountSourcesByType(ctx context.Context, filter database.SourceFilter, user string) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, src := range s.sources {
+		if src.Type != "" && matchesFilter(&src, filter, user) {
+			counts[src.Type]++
+		}
+	}
+	return counts, nil
+}
+
+func matchesFilter(src *database.Source, filter database.SourceFilter, user string) bool {
+	return (filter.Topic == "" || src.Topic == filter.Topic) &&
+		(filter.Domain == "" || src.Domain == filter.Domain) &&
+		(filter.Type == "" || src.Type == filter.Type) &&
+		(!filter.HasCode || hasLinkKind(src.Links, database.LinkCode)) &&
+		(!filter.HasDataset || hasLinkKind(src.Links, database.LinkDataset)) &&
+		(filter.PublishedAfter == "" || src.PublishedAt != "" && src.PublishedAt >= filter.PublishedAfter) &&
+		(filter.PublishedBefore == "" || src.PublishedAt != "" && src.PublishedAt < filter.PublishedBefore) &&
+		(filter.CreatedAfter == "" || src.CreatedAt >= filter.CreatedAfter) &&
+		(filter.CreatedBefore == "" || src.CreatedAt < filter.CreatedBefore) &&
+		src.VisibleTo(user)
+}
+
+func hasLinkKind(links []database.SourceLink, kind string) bool {
+	for _, link := range links {
+		if link.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchSources returns the sources visible to user whose title, summary or
+// topic contain every term of query (case-insensitive) or one of its
+// synonyms, newest first
+func (s *Store) SearchSources(ctx context.Context, query string, limit int, user string) ([]database.Source, error) {
+	dict, _ := s.SearchDictionary(ctx)
+	terms := dict.Groups(query)
+	return s.filterSources(limit, func(src *database.Source) bool {
+		return src.VisibleTo(user) && matchesAll(terms, src.Title, src.Summary, src.Topic, s.contents[src.ID])
+	}), nil
+}
+
+func (s *Store) filterSources(limit int, keep func(*database.Source) bool) []database.Source {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sources []database.Source
+	for _, src := range s.sources {
+		if keep(&src) {
+			sources = append(sources, src)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].CreatedAt != sources[j].CreatedAt {
+			return sources[i].CreatedAt > sources[j].CreatedAt
+		}
+		return sources[i].ID > sources[j].ID
+	})
+	if len(sources) > limit {
+		sources = sources[:limit]
+	}
+	return sources
+}
+
+// DeleteSource soft-deletes a source: it moves to the trash until it is
+// restored or purged
+func (s *Store) DeleteSource(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trashSource(id, time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// trashSource moves a live source to the trash
+func (s *Store) trashSource(id, at string) {
+	src, ok := s.sources[id]
+	if !ok {
+		return
+	}
+	delete(s.sources, id)
+	s.trash[id], s.deletedAt[id] = src, at
+	s.appendEvent(database.EventSourceDeleted, id, nil)
+	s.recount()
+}
+
+// RestoreSource moves a deleted source back from the trash and returns it,
+// or nil if no deleted source visible to user has the ID
+func (s *Store) RestoreSource(ctx context.Context, id, user string) (*database.Source, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, ok := s.trash[id]
+	if !ok || !src.VisibleTo(user) {
+		return nil, nil
+	}
+	delete(s.trash, id)
+	delete(s.deletedAt, id)
+	s.sources[id] = src
+	logged := src
+	logged.Content = s.contents[id]
+	s.appendEvent(database.EventSourceUpserted, id, logged)
+	s.recount()
+	return &src, nil
+}
+
+// DeletedSources returns up to limit sources deleted before before,
+// longest deleted first
+func (s *Store) DeletedSources(ctx context.Context, before string, limit int) ([]database.DeletedSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deleted := []database.DeletedSource{}
+	for id, src := range s.trash {
+		if s.deletedAt[id] < before {
+			deleted = append(deleted, database.DeletedSource{ID: id, Title: src.Title, DeletedAt: s.deletedAt[id]})
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		if deleted[i].DeletedAt != deleted[j].DeletedAt {
+			return deleted[i].DeletedAt < deleted[j].DeletedAt
+		}
+		return deleted[i].ID < deleted[j].ID
+	})
+	if len(deleted) > limit {
+		deleted = deleted[:limit]
+	}
+	return deleted, nil
+}
+
+// PurgeSource permanently removes a deleted source, reporting whether it
+// was deleted
+func (s *Store) PurgeSource(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.trash[id]; !ok {
+		return false, nil
+	}
+	s.forget(id)
+	s.appendEvent(database.EventSourcePurged, id, nil)
+	s.recount()
+	return true, nil
+}
+
+// forget removes a deleted source and the records about it
+func (s *Store) forget(id string) {
+	delete(s.trash, id)
+	delete(s.deletedAt, id)
+	delete(s.contents, id)
+	delete(s.claims, id)
+	for key, sug := range s.links {
+		if sug.SourceID == id && sug.Status == database.SuggestionPending {
+			delete(s.links, key)
+		}
+	}
+	for key, q := range s.queue {
+		if q.SourceID == id {
+			delete(s.queue, key)
+		}
+	}
+	s.matches = slices.DeleteFunc(s.matches, func(m database.WatchMatch) bool { return m.SourceID == id })
+	delete(s.access, id)
+	delete(s.sourceRevisions, id)
+}
+
+// revise appends prev to the revisions of an entity when it differs from
+// next, with its fields as they read back from JSON like in the SQLite store
+func revise(revisions map[string][]database.Revision, id string, prev, next map[string]any) {
+	old, _ := json.Marshal(prev)
+	if updated, _ := json.Marshal(next); string(updated) == string(old) {
+		return
+	}
+	r := database.Revision{Revision: len(revisions[id]) + 1, ReplacedAt: time.Now().UTC().Format(time.RFC3339)}
+	json.Unmarshal(old, &r.Fields)
+	revisions[id] = append(revisions[id], r)
+}
+
+// SourceRevisions returns the previous versions of a source, newest first
+func (s *Store) SourceRevisions(ctx context.Context, id string) ([]database.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newestFirst(s.sourceRevisions[id]), nil
+}
+
+// ArticleRevisions returns the previous versions of an article, newest
+// first
+func (s *Store) ArticleRevisions(ctx context.Context, id string) ([]database.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newestFirst(s.articleRevisions[id]), nil
+}
+
+func newestFirst(revisions []database.Revision) []database.Revision {
+	out := make([]database.Revision, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		out = append(out, revisions[i])
+	}
+	return out
+}
+
+// SourceIDs returns the IDs of all sources, including the deleted ones not
+// purged yet
+func (s *Store) SourceIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.sources)+len(s.trash))
+	for id := range s.sources {
+		ids = append(ids, id)
+	}
+	for id := range s.trash {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// SourceIDsByTopic returns the IDs of every source of a topic in ID order
+func (s *Store) SourceIDsByTopic(ctx context.Context, topic string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for id, src := range s.sources {
+		if src.Topic == topic {
+			ids = append(ids, id)
+		}
+	}
+	for id, src := range s.trash {
+		if src.Topic == topic {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// CountSources returns the number of sources
+func (s *Store) CountSources(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sources), nil
+}
+
+// InsertArticle inserts or replaces an article
+func (s *Store) InsertArticle(ctx context.Context, art database.Article) error {
+	return s.InsertArticles(ctx, []database.Article{art})
+}
+
+// InsertArticles inserts or replaces articles
+func (s *Store) InsertArticles(ctx context.Context, arts []database.Article) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, art := range arts {
+		art.WordCount = database.WordCount(art.Content)
+		art.ReadingMinutes = database.ReadingMinutes(art.WordCount)
+		for id, existing := range s.articles {
+			if existing.Path == art.Path && id != art.ID {
+				delete(s.articles, id)
+			}
+		}
+		if prev, ok := s.articles[art.ID]; ok {
+			revise(s.articleRevisions, art.ID, database.ArticleRevisionFields(prev), database.ArticleRevisionFields(art))
+		}
+		s.articles[art.ID] = art
+		s.appendEvent(database.EventArticleUpserted, art.ID, art)
+	}
+	s.recount()
+	return nil
+}
+
+// DeleteArticle removes an article
+func (s *Store) DeleteArticle(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.articles, id)
+	delete(s.articleRevisions, id)
+	for key, sug := range s.links {
+		if sug.ArticleID == id && sug.Status == database.SuggestionPending {
+			delete(s.links, key)
+		}
+	}
+	s.appendEvent(database.EventArticleDeleted, id, nil)
+	s.recount()
+	return nil
+}
+
+// ArticleContent returns the body of an article, or "" if it doesn't exist
+func (s *Store) ArticleContent(ctx context.Context, id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.articles[id].Content, nil
+}
+
+// GetArticle retrieves an article by ID, or nil if it doesn't exist. Like the
+// SQLite store, the content is not returned.
+func (s *Store) GetArticle(ctx context.Context, id string) (*database.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	art, ok := s.articles[id]
+	if !ok {
+		return nil, nil
+	}
+	art.Content = ""
+	art.Sources = s.linkedSources(art)
+	return &art, nil
+}
+
+// SearchArticles returns the articles whose title, summary, tags or content
+// contain the stem of every term of query (case-insensitive) or one of its
+// synonyms, ordered by ID. When none do, terms are widened to the words
+// resembling them, like the SQLite store does.
+func (s *Store) SearchArticles(ctx context.Context, query string, limit int) ([]database.Article, error) {
+	dict, _ := s.SearchDictionary(ctx)
+	articles := s.searchArticles(dict.TolerantGroups(query), limit)
+	if len(articles) == 0 {
+		// Misspelled terms: search the words resembling them
+		vocab, _ := s.ArticleVocabulary(ctx, 1)
+		if fuzzy, ok := database.FuzzyGroups(vocab, dict.Groups(query)); ok {
+			articles = s.searchArticles(fuzzy, limit)
+		}
+	}
+	return articles, nil
+}
+
+// searchArticles returns up to limit articles matching one term of every
+// group, ordered by ID, with snippets
+func (s *Store) searchArticles(terms [][]string, limit int) []database.Article {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var articles []database.Article
+	for _, art := range s.articles {
+		if matchesAll(terms, art.Title, art.Summary, strings.Join(art.Tags, " "), art.Content) {
+			art.Snippet = database.Snippet(terms, art.Content, art.Title, art.Summary, strings.Join(art.Tags, " "))
+			art.Content = ""
+			art.Sources = nil
+			articles = append(articles, art)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].ID < articles[j].ID })
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles
+}
+
+// ArticleVocabulary returns the words of the articles' title, summary, tags
+// and content contained in at least minDocs articles, with the number of
+// articles containing them
+func (s *Store) ArticleVocabulary(ctx context.Context, minDocs int) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	docs := make(map[string]int)
+	for _, art := range s.articles {
+		text := strings.ToLower(strings.Join([]string{art.Title, art.Summary, strings.Join(art.Tags, " "), art.Content}, " "))
+		words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		seen := make(map[string]bool)
+		for _, w := range words {
+			if !seen[w] {
+				seen[w] = true
+				docs[w]++
+			}
+		}
+	}
+	for w, n := range docs {
+		if n < minDocs {
+			delete(docs, w)
+		}
+	}
+	return docs, nil
+}
+
+// LinkedSourceIDs returns the IDs of the sources curated for the articles
+// with the given topic slug
+func (s *Store) LinkedSourceIDs(ctx context.Context, topic string) ([]string, error) {
+	return s.linkedSourceIDs(func(art database.Article) bool { return art.Topic() == topic }), nil
+}
+
+// CitedSourceIDs returns the IDs of the sources curated for any article
+func (s *Store) CitedSourceIDs(ctx context.Context) ([]string, error) {
+	return s.linkedSourceIDs(func(database.Article) bool { return true }), nil
+}
+
+// linkedSourceIDs returns the sources curated for the articles matching
+// keep, sorted
+func (s *Store) linkedSourceIDs(keep func(database.Article) bool) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, art := range s.articles {
+		if !keep(art) {
+			continue
+		}
+		for _, id := range s.linkedSources(art) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// CountArticles returns the number of articles
+func (s *Store) CountArticles(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.articles), nil
+}
+
+// GetProfile retrieves a user's profile for a model, or nil if none exists
+func (s *Store) GetProfile(ctx context.Context, userID, model string) (*database.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.profiles[profileKey(userID, model)]
+	if !ok {
+		return nil, nil
+	}
+	p.Embedding = append([]float32(nil), p.Embedding...)
+	return &p, nil
+}
+
+// SaveProfile inserts or replaces a profile
+func (s *Store) SaveProfile(ctx context.Context, p database.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.Embedding = append([]float32(nil), p.Embedding...)
+	s.profiles[profileKey(p.UserID, p.Model)] = p
+	return nil
+}
+
+// DeleteProfiles removes all of a user's profiles
+func (s *Store) DeleteProfiles(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, p := range s.profiles {
+		if p.UserID == userID {
+			delete(s.profiles, key)
+		}
+	}
+	return nil
+}
+
+func profileKey(userID, model string) string {
+	return userID + "\x00" + model
+}
+
+func queueKey(userID, sourceID string) string {
+	return userID + "\x00" + sourceID
+}
+
+// EnqueueSources adds sources to a user's queue as to-read; queued sources
+// are left as they are. It returns how many were added.
+func (s *Store) EnqueueSources(ctx context.Context, userID string, sourceIDs []string, note string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	added := 0
+	for _, id := range sourceIDs {
+		key := queueKey(userID, id)
+		if _, ok := s.queue[key]; ok {
+			continue
+		}
+		s.queue[key] = database.QueueItem{UserID: userID, SourceID: id, Status: database.QueueToRead,
+			Note: note, AddedAt: now, UpdatedAt: now}
+		added++
+	}
+	return added, nil
+}
+
+// GetQueueItem returns a source of a user's queue, or nil if it isn't queued
+func (s *Store) GetQueueItem(ctx context.Context, userID, sourceID string) (*database.QueueItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q, ok := s.queue[queueKey(userID, sourceID)]
+	if !ok {
+		return nil, nil
+	}
+	return &q, nil
+}
+
+// SaveQueueItem replaces a queued item; items not in the queue are ignored
+func (s *Store) SaveQueueItem(ctx context.Context, q database.QueueItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := queueKey(q.UserID, q.SourceID)
+	if old, ok := s.queue[key]; ok {
+		q.AddedAt = old.AddedAt
+		s.queue[key] = q
+	}
+	return nil
+}
+
+// ReadingQueue returns a user's queue, or the items with a status, in the
+// order they were added
+func (s *Store) ReadingQueue(ctx context.Context, userID, status string) ([]database.QueueItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := []database.QueueItem{}
+	for _, q := range s.queue {
+		if q.UserID == userID && (status == "" || q.Status == status) {
+			items = append(items, q)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].AddedAt != items[j].AddedAt {
+			return items[i].AddedAt < items[j].AddedAt
+		}
+		return items[i].SourceID < items[j].SourceID
+	})
+	return items, nil
+}
+
+// DequeueSource removes a source from a user's queue, reporting whether it
+// was queued
+func (s *Store) DequeueSource(ctx context.Context, userID, sourceID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := queueKey(userID, sourceID)
+	_, ok := s.queue[key]
+	delete(s.queue, key)
+	return ok, nil
+}
+
+// RecordSourceHits sets the last hit of sources to at
+func (s *Store) RecordSourceHits(ctx context.Context, ids []string, at string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		a := s.access[id]
+		a.SourceID, a.LastHitAt = id, at
+		s.access[id] = a
+	}
+	return nil
+}
+
+// GetSourceAccess returns the access record of a source
+func (s *Store) GetSourceAccess(ctx context.Context, id string) (database.SourceAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a := s.access[id]
+	a.SourceID = id
+	return a, nil
+}
+
+// SetSourceCold records that a source's vectors were dropped at coldAt, or
+// restored when coldAt is empty
+func (s *Store) SetSourceCold(ctx context.Context, id, coldAt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.access[id]
+	a.SourceID, a.ColdAt = id, coldAt
+	s.access[id] = a
+	return nil
+}
+
+// IdleSources returns up to limit sources that aren't cold and weren't hit
+// since before, longest idle first
+func (s *Store) IdleSources(ctx context.Context, before string, limit int) ([]database.SourceAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type idleSource struct {
+		database.SourceAccess
+		since string
+	}
+	var idle []idleSource
+	for id, src := range s.sources {
+		a := s.access[id]
+		since := a.LastHitAt
+		if since == "" {
+			since = src.CreatedAt
+		}
+		if a.ColdAt == "" && since < before {
+			idle = append(idle, idleSource{database.SourceAccess{SourceID: id, LastHitAt: a.LastHitAt}, since})
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		if idle[i].since != idle[j].since {
+			return idle[i].since < idle[j].since
+		}
+		return idle[i].SourceID < idle[j].SourceID
+	})
+	result := []database.SourceAccess{}
+	for _, a := range idle {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, a.SourceAccess)
+	}
+	return result, nil
+}
+
+// ColdSourceIDs returns the IDs of the cold sources
+func (s *Store) ColdSourceIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := []string{}
+	for id, a := range s.access {
+		if a.ColdAt != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// CreateWatch stores a new watch with its cursor at the head of the event
+// log
+func (s *Store) CreateWatch(ctx context.Context, w database.Watch) (*database.Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.watches[w.ID]; ok {
+		return nil, fmt.Errorf("failed to store watch: duplicate ID")
+	}
+	w.Embedding = slices.Clone(w.Embedding)
+	w.Cursor = 0
+	if n := len(s.events); n > 0 {
+		w.Cursor = s.events[n-1].Seq
+	}
+	s.watches[w.ID] = w
+	w = s.watchStats(w)
+	return &w, nil
+}
+
+// watchStats fills in the match count and last match of a watch
+func (s *Store) watchStats(w database.Watch) database.Watch {
+	w.Matches, w.LastMatchAt = 0, ""
+	for _, m := range s.matches {
+		if m.WatchID == w.ID {
+			w.Matches++
+			w.LastMatchAt = max(w.LastMatchAt, m.MatchedAt)
+		}
+	}
+	w.Embedding = slices.Clone(w.Embedding)
+	return w
+}
+
+// ListWatches returns the watches of a user, or of every user when userID
+// is empty, oldest first
+func (s *Store) ListWatches(ctx context.Context, userID string) ([]database.Watch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	watches := []database.Watch{}
+	for _, w := range s.watches {
+		if userID == "" || w.UserID == userID {
+			watches = append(watches, s.watchStats(w))
+		}
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		if watches[i].CreatedAt != watches[j].CreatedAt {
+			return watches[i].CreatedAt < watches[j].CreatedAt
+		}
+		return watches[i].ID < watches[j].ID
+	})
+	return watches, nil
+}
+
+// GetWatch returns a watch by ID, or nil if there is none
+func (s *Store) GetWatch(ctx context.Context, id string) (*database.Watch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.watches[id]
+	if !ok {
+		return nil, nil
+	}
+	w = s.watchStats(w)
+	return &w, nil
+}
+
+// DeleteWatch removes a watch and its matches, reporting whether it existed
+func (s *Store) DeleteWatch(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.watches[id]
+	delete(s.watches, id)
+	s.matches = slices.DeleteFunc(s.matches, func(m database.WatchMatch) bool { return m.WatchID == id })
+	return ok, nil
+}
+
+// AdvanceWatch moves a watch's cursor and records the matches found before
+// it; sources matched already are not recorded again
+func (s *Store) AdvanceWatch(ctx context.Context, id string, cursor int64, matches []database.WatchMatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range matches {
+		if slices.ContainsFunc(s.matches, func(o database.WatchMatch) bool {
+			return o.WatchID == m.WatchID && o.SourceID == m.SourceID
+		}) {
+			continue
+		}
+		s.matchSeq++
+		m.Seq = s.matchSeq
+		m.DeliveredAt, m.Attempts, m.LastAttemptAt, m.LastError = "", 0, "", ""
+		s.matches = append(s.matches, m)
+	}
+	if w, ok := s.watches[id]; ok {
+		w.Cursor = cursor
+		s.watches[id] = w
+	}
+	return nil
+}
+
+// WatchMatches returns up to limit matches after since, in order: those of
+// a watch, or of every watch of a user when watchID is empty
+func (s *Store) WatchMatches(ctx context.Context, userID, watchID string, since int64, limit int) ([]database.WatchMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matches := []database.WatchMatch{}
+	for _, m := range s.matches {
+		if len(matches) == limit {
+			break
+		}
+		if m.UserID == userID && (watchID == "" || m.WatchID == watchID) && m.Seq > since {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// LastWatchMatch returns the sequence number of a user's last match, 0 if
+// there is none
+func (s *Store) LastWatchMatch(ctx context.Context, userID string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var seq int64
+	for _, m := range s.matches {
+		if m.UserID == userID {
+			seq = m.Seq
+		}
+	}
+	return seq, nil
+}
+
+// UndeliveredWatchMatches returns the matches of watches with a URL that
+// weren't delivered yet and have failed fewer than maxAttempts times
+func (s *Store) UndeliveredWatchMatches(ctx context.Context, maxAttempts int) ([]database.WatchMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matches := []database.WatchMatch{}
+	for _, m := range s.matches {
+		if m.DeliveredAt == "" && m.Attempts < maxAttempts && s.watches[m.WatchID].URL != "" {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// RecordWatchDelivery records an attempt to deliver a match: a success when
+// errMsg is empty, a failure otherwise
+func (s *Store) RecordWatchDelivery(ctx context.Context, seq int64, at, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.matches {
+		if m.Seq != seq {
+			continue
+		}
+		m.LastAttemptAt = at
+		if errMsg == "" {
+			m.DeliveredAt, m.LastError = at, ""
+		} else {
+			m.Attempts++
+			m.LastError = errMsg
+		}
+		s.matches[i] = m
+	}
+	return nil
+}
+
+// AddAlias records that OldID now refers to NewID, keeping every alias one
+// step from its target like the SQLite store
+func (s *Store) AddAlias(ctx context.Context, alias database.Alias) error {
+	if !database.ValidAliasKind(alias.Kind) {
+		return fmt.Errorf("unknown alias kind: %s", alias.Kind)
+	}
+	if alias.OldID == "" || alias.NewID == "" || alias.OldID == alias.NewID {
+		return fmt.Errorf("alias must map an ID to a different ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if target, ok := s.aliases[aliasKey(alias.Kind, alias.NewID)]; ok {
+		if target.NewID == alias.OldID {
+			return fmt.Errorf("alias %s -> %s would create a cycle", alias.OldID, alias.NewID)
+		}
+		alias.NewID = target.NewID
+	}
+	if alias.CreatedAt == "" {
+		alias.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.aliases[aliasKey(alias.Kind, alias.OldID)] = alias
+	for key, a := range s.aliases {
+		if a.Kind == alias.Kind && a.NewID == alias.OldID {
+			a.NewID = alias.NewID
+			s.aliases[key] = a
+		}
+	}
+	s.appendEvent(database.EventAliasAdded, alias.OldID, alias)
+	return nil
+}
+
+// ResolveAlias returns the current ID for a retired ID, or "" if id is not
+// an alias
+func (s *Store) ResolveAlias(ctx context.Context, kind, id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.aliases[aliasKey(kind, id)].NewID, nil
+}
+
+func aliasKey(kind, id string) string {
+	return kind + "\x00" + id
+}
+
+// AddEntityAlias records that Alias is a spelling of Canonical. Mentions are
+// resolved when read, so nothing else needs updating.
+func (s *Store) AddEntityAlias(ctx context.Context, alias database.EntityAlias) error {
+	if !database.ValidEntityKind(alias.Kind) {
+		return fmt.Errorf("unknown entity kind: %s", alias.Kind)
+	}
+	if database.EntityKey(alias.Alias) == "" || database.EntityKey(alias.Canonical) == "" {
+		return fmt.Errorf("alias and canonical are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	canonical := s.resolveEntity(alias.Kind, alias.Canonical)
+	if database.EntityKey(canonical) == database.EntityKey(alias.Alias) {
+		return fmt.Errorf("%q already resolves to %q", alias.Canonical, alias.Alias)
+	}
+	alias.Canonical = canonical
+	if alias.CreatedAt == "" {
+		alias.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	aliasKey := database.EntityKey(alias.Alias)
+	s.entities[entityAliasKey(alias.Kind, aliasKey)] = alias
+	for key, a := range s.entities {
+		if a.Kind == alias.Kind && database.EntityKey(a.Canonical) == aliasKey {
+			a.Canonical = canonical
+			s.entities[key] = a
+		}
+	}
+	s.appendEvent(database.EventEntityAliasAdded, alias.Alias, alias)
+	return nil
+}
+
+// ResolveEntity returns the canonical name of an entity
+func (s *Store) ResolveEntity(ctx context.Context, kind, name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resolveEntity(kind, name), nil
+}
+
+// resolveEntity resolves a name through the aliases. Callers hold mu.
+func (s *Store) resolveEntity(kind, name string) string {
+	if alias, ok := s.entities[entityAliasKey(kind, database.EntityKey(name))]; ok {
+		return alias.Canonical
+	}
+	return name
+}
+
+// EntityAliases returns the aliases of an entity's canonical name
+func (s *Store) EntityAliases(ctx context.Context, kind, canonical string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var aliases []string
+	for _, a := range s.entities {
+		if a.Kind == kind && database.EntityKey(a.Canonical) == database.EntityKey(canonical) {
+			aliases = append(aliases, a.Alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// SourcesByEntity returns the sources mentioning an entity under any of its
+// spellings visible to user, newest first
+func (s *Store) SourcesByEntity(ctx context.Context, kind, name string, limit int, user string) ([]database.Source, error) {
+	s.mu.RLock()
+	key := database.EntityKey(s.resolveEntity(kind, name))
+	s.mu.RUnlock()
+
+	return s.filterSources(limit, func(src *database.Source) bool {
+		if !src.VisibleTo(user) {
+			return false
+		}
+		for _, n := range sourceEntityNames(src, kind) {
+			if database.EntityKey(s.resolveEntity(kind, n)) == key {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// Entities returns the canonical entities of a kind, most mentioned first
+func (s *Store) Entities(ctx context.Context, kind string, limit int) ([]database.Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[string]*database.Entity)
+	for _, src := range s.sources {
+		seen := make(map[string]bool)
+		for _, n := range sourceEntityNames(&src, kind) {
+			canonical := s.resolveEntity(kind, n)
+			key := database.EntityKey(canonical)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if e, ok := byKey[key]; ok {
+				e.Sources++
+			} else {
+				byKey[key] = &database.Entity{Kind: kind, Name: canonical, Sources: 1}
+			}
+		}
+	}
+
+	entities := make([]database.Entity, 0, len(byKey))
+	for _, e := range byKey {
+		entities = append(entities, *e)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Sources != entities[j].Sources {
+			return entities[i].Sources > entities[j].Sources
+		}
+		return database.EntityKey(entities[i].Name) < database.EntityKey(entities[j].Name)
+	})
+	if len(entities) > limit {
+		entities = entities[:limit]
+	}
+	return entities, nil
+}
+
+func sourceEntityNames(src *database.Source, kind string) []string {
+	switch kind {
+	case database.EntityPerson:
+		return src.People
+	case database.EntityOrg:
+		return src.Orgs
+	case database.EntityPlace:
+		return src.Places
+	}
+	return nil
+}
+
+func entityAliasKey(kind, key string) string {
+	return kind + "\x00" + key
+}
+
+// InsertCategories inserts or replaces categories
+func (s *Store) InsertCategories(ctx context.Context, cats []database.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cat := range cats {
+		s.cats[cat.Path] = cat
+		s.appendEvent(database.EventCategoryUpserted, cat.Path, cat)
+	}
+	return nil
+}
+
+// GetCategory retrieves a category by path, or nil if it doesn't exist
+func (s *Store) GetCategory(ctx context.Context, path string) (*database.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cat, ok := s.cats[path]
+	if !ok {
+		return nil, nil
+	}
+	return &cat, nil
+}
+
+// Subcategories returns the categories directly below path, by order and
+// title. Content is not returned.
+func (s *Store) Subcategories(ctx context.Context, path string) ([]database.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var cats []database.Category
+	for _, cat := range s.cats {
+		if parent, ok := database.CategoryParent(cat.Path); ok && parent == path {
+			cat.Content = ""
+			cats = append(cats, cat)
+		}
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		if cats[i].Order != cats[j].Order {
+			return cats[i].Order < cats[j].Order
+		}
+		return cats[i].Title < cats[j].Title
+	})
+	return cats, nil
+}
+
+// Tags returns up to limit tags of the sources visible to the caller and of
+// the articles, most used first
+func (s *Store) Tags(ctx context.Context, limit int, user string) ([]database.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byName := make(map[string]*database.Tag)
+	tag := func(name string) *database.Tag {
+		if byName[name] == nil {
+			byName[name] = &database.Tag{Name: name}
+		}
+		return byName[name]
+	}
+	for _, src := range s.sources {
+		if src.VisibleTo(user) {
+			for _, name := range database.TagSet(src.Tags) {
+				tag(name).Sources++
+			}
+		}
+	}
+	for _, art := range s.articles {
+		for _, name := range database.TagSet(art.Tags) {
+			tag(name).Articles++
+		}
+	}
+
+	tags := make([]database.Tag, 0, len(byName))
+	for _, t := range byName {
+		tags = append(tags, *t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if n, m := tags[i].Sources+tags[i].Articles, tags[j].Sources+tags[j].Articles; n != m {
+			return n > m
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// SourcesByTag returns the sources with a tag that are visible to the
+// caller, newest first
+func (s *Store) SourcesByTag(ctx context.Context, tag string, limit int, user string) ([]database.Source, error) {
+	return s.filterSources(limit, func(src *database.Source) bool {
+		return src.VisibleTo(user) && slices.Contains(database.TagSet(src.Tags), tag)
+	}), nil
+}
+
+// SourceIDsByTag returns the IDs of every live source with a tag in ID
+// order, whatever its visibility
+func (s *Store) SourceIDsByTag(ctx context.Context, tag string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for id, src := range s.sources {
+		if slices.Contains(database.TagSet(src.Tags), tag) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ArticlesByTag returns up to limit articles with a tag, by title
+func (s *Store) ArticlesByTag(ctx context.Context, tag string, limit int) ([]database.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var articles []database.Article
+	for _, art := range s.articles {
+		if slices.Contains(database.TagSet(art.Tags), tag) {
+			art.Content = ""
+			art.Sources = nil
+			articles = append(articles, art)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].Title < articles[j].Title })
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, nil
+}
+
+// ArticlesByCategory returns up to limit articles of a category, by title
+func (s *Store) ArticlesByCategory(ctx context.Context, path string, limit int) ([]database.Article, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var articles []database.Article
+	for _, art := range s.articles {
+		if art.Category() == path {
+			art.Content = ""
+			art.Sources = nil
+			articles = append(articles, art)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].Title < articles[j].Title })
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, nil
+}
+
+// PutTopic creates or replaces a topic of the taxonomy
+func (s *Store) PutTopic(ctx context.Context, t database.Topic) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.Aliases = slices.Clone(t.Aliases)
+	s.topics[t.Slug] = t
+	s.appendEvent(database.EventTopicUpserted, t.Slug, t)
+	return nil
+}
+
+// GetTopic retrieves a topic by its slug or one of its aliases, or nil if
+// the taxonomy has neither
+func (s *Store) GetTopic(ctx context.Context, slug string) (*database.Topic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if t, ok := s.topics[slug]; ok {
+		return &t, nil
+	}
+	for _, t := range s.topics {
+		if slices.Contains(t.Aliases, slug) {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListTopics returns the whole taxonomy, by slug
+func (s *Store) ListTopics(ctx context.Context) ([]database.Topic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics := make([]database.Topic, 0, len(s.topics))
+	for _, t := range s.topics {
+		topics = append(topics, t)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Slug < topics[j].Slug })
+	return topics, nil
+}
+
+// DeleteTopic removes a topic from the taxonomy, moving the topics below it
+// up to its parent
+func (s *Store) DeleteTopic(ctx context.Context, slug string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.topics[slug]
+	if !ok {
+		return false, nil
+	}
+	for child, c := range s.topics {
+		if c.Parent == slug {
+			c.Parent = t.Parent
+			s.topics[child] = c
+		}
+	}
+	delete(s.topics, slug)
+	s.appendEvent(database.EventTopicDeleted, slug, nil)
+	return true, nil
+}
+
+// GraphNode looks up a graph node by ID, or returns nil if it does not exist
+// or is not visible to user
+func (s *Store) GraphNode(ctx context.Context, id string, user string) (*database.GraphNode, error) {
+	kind, key, ok := database.ParseNodeID(id)
+	if !ok {
+		return nil, fmt.Errorf("invalid node id: %s", id)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch kind {
+	case database.NodeSource:
+		if src, ok := s.sources[key]; ok && src.VisibleTo(user) {
+			return &database.GraphNode{ID: id, Kind: kind, Label: nodeLabel(src.Title, src.ID)}, nil
+		}
+	case database.NodeArticle:
+		if art, ok := s.articles[key]; ok {
+			return &database.GraphNode{ID: id, Kind: kind, Label: nodeLabel(art.Title, art.ID)}, nil
+		}
+	case database.NodeTopic:
+		for _, src := range s.sources {
+			if src.Topic == key && src.VisibleTo(user) {
+				return &database.GraphNode{ID: id, Kind: kind, Label: key}, nil
+			}
+		}
+		for _, art := range s.articles {
+			if art.Topic() == key && len(s.linkedSources(art)) > 0 {
+				return &database.GraphNode{ID: id, Kind: kind, Label: key}, nil
+			}
+		}
+	default:
+		canonicalKey := database.EntityKey(s.resolveEntity(kind, key))
+		for _, src := range s.sources {
+			if !src.VisibleTo(user) {
+				continue
+			}
+			for _, n := range sourceEntityNames(&src, kind) {
+				if canonical := s.resolveEntity(kind, n); database.EntityKey(canonical) == canonicalKey {
+					return &database.GraphNode{ID: database.NodeID(kind, canonicalKey), Kind: kind, Label: canonical}, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GraphNeighbors returns up to limit neighbors of each relation of a node
+func (s *Store) GraphNeighbors(ctx context.Context, node database.GraphNode, limit int, user string) ([]database.GraphNeighbor, error) {
+	_, key, ok := database.ParseNodeID(node.ID)
+	if !ok {
+		return nil, fmt.Errorf("invalid node id: %s", node.ID)
+	}
+
+	var neighbors []database.GraphNeighbor
+	add := func(n database.GraphNode, rel string, outgoing bool) {
+		edge := database.GraphEdge{From: node.ID, To: n.ID, Rel: rel}
+		if !outgoing {
+			edge.From, edge.To = n.ID, node.ID
+		}
+		neighbors = append(neighbors, database.GraphNeighbor{Node: n, Edge: edge})
+	}
+	sourceNode := func(src database.Source) database.GraphNode {
+		return database.GraphNode{ID: database.NodeID(database.NodeSource, src.ID), Kind: database.NodeSource, Label: nodeLabel(src.Title, src.ID)}
+	}
+	articleNode := func(art database.Article) database.GraphNode {
+		return database.GraphNode{ID: database.NodeID(database.NodeArticle, art.ID), Kind: database.NodeArticle, Label: nodeLabel(art.Title, art.ID)}
+	}
+	topicNode := func(topic string) database.GraphNode {
+		return database.GraphNode{ID: database.NodeID(database.NodeTopic, topic), Kind: database.NodeTopic, Label: topic}
+	}
+
+	switch node.Kind {
+	case database.NodeSource:
+		s.mu.RLock()
+		src, ok := s.sources[key]
+		if ok && src.VisibleTo(user) && src.Topic != "" {
+			add(topicNode(src.Topic), database.RelTopic, true)
+		}
+		for _, art := range s.sortedArticles(limit, func(art *database.Article) bool {
+			return contains(s.linkedSources(*art), key)
+		}) {
+			add(articleNode(art), database.RelCites, false)
+		}
+		seen := make(map[string]bool)
+		for _, kind := range []string{database.EntityOrg, database.EntityPerson, database.EntityPlace} {
+			for _, name := range sourceEntityNames(&src, kind) {
+				canonical := s.resolveEntity(kind, name)
+				id := database.NodeID(kind, database.EntityKey(canonical))
+				if seen[id] || len(seen) >= limit {
+					continue
+				}
+				seen[id] = true
+				add(database.GraphNode{ID: id, Kind: kind, Label: canonical}, database.RelMentions, true)
+			}
+		}
+		s.mu.RUnlock()
+	case database.NodeArticle:
+		s.mu.RLock()
+		art, ok := s.articles[key]
+		linked := s.linkedSources(art)
+		s.mu.RUnlock()
+		if ok && len(linked) > 0 {
+			add(topicNode(art.Topic()), database.RelTopic, true)
+		}
+		for _, src := range s.filterSources(limit, func(src *database.Source) bool {
+			return src.VisibleTo(user) && contains(linked, src.ID)
+		}) {
+			add(sourceNode(src), database.RelCites, true)
+		}
+	case database.NodeTopic:
+		for _, src := range s.filterSources(limit, func(src *database.Source) bool {
+			return src.VisibleTo(user) && src.Topic == key
+		}) {
+			add(sourceNode(src), database.RelTopic, false)
+		}
+		s.mu.RLock()
+		for _, art := range s.sortedArticles(limit, func(art *database.Article) bool {
+			return art.Topic() == key && len(s.linkedSources(*art)) > 0
+		}) {
+			add(articleNode(art), database.RelTopic, false)
+		}
+		s.mu.RUnlock()
+	default:
+		sources, err := s.SourcesByEntity(ctx, node.Kind, node.Label, limit, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range sources {
+			add(sourceNode(src), database.RelMentions, false)
+		}
+	}
+	return neighbors, nil
+}
+
+// sortedArticles returns up to limit articles kept by keep, ordered by ID.
+// Callers hold mu.
+func (s *Store) sortedArticles(limit int, keep func(*database.Article) bool) []database.Article {
+	var articles []database.Article
+	for _, art := range s.articles {
+		if keep(&art) {
+			articles = append(articles, art)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].ID < articles[j].ID })
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles
+}
+
+func nodeLabel(title, id string) string {
+	if title != "" {
+		return title
+	}
+	return id
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ArticleIDs returns the IDs of all articles
+func (s *Store) ArticleIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.articles))
+	for id := range s.articles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// SuggestLinks records pending link suggestions, skipping curated links and
+// leaving reviewed suggestions alone
+func (s *Store) SuggestLinks(ctx context.Context, suggestions []database.LinkSuggestion) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, sug := range suggestions {
+		key := linkKey(sug.ArticleID, sug.SourceID)
+		if existing, ok := s.links[key]; ok {
+			if existing.Status == database.SuggestionPending {
+				existing.Score = sug.Score
+				s.links[key] = existing
+			}
+			continue
+		}
+		if contains(s.articles[sug.ArticleID].Sources, sug.SourceID) {
+			continue
+		}
+		sug.Status = database.SuggestionPending
+		sug.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+		sug.ReviewedAt = ""
+		s.links[key] = sug
+		added++
+	}
+	return added, nil
+}
+
+// LinkSuggestions returns suggestions with the given status, best first
+func (s *Store) LinkSuggestions(ctx context.Context, articleID, status string, limit int) ([]database.LinkSuggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var suggestions []database.LinkSuggestion
+	for _, sug := range s.links {
+		if sug.Status == status && (articleID == "" || sug.ArticleID == articleID) {
+			suggestions = append(suggestions, sug)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// ReviewLinkSuggestion approves or rejects a suggestion and returns it, or
+// nil if there is no such suggestion
+func (s *Store) ReviewLinkSuggestion(ctx context.Context, articleID, sourceID, status string) (*database.LinkSuggestion, error) {
+	if status != database.SuggestionApproved && status != database.SuggestionRejected {
+		return nil, fmt.Errorf("status must be approved or rejected")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := linkKey(articleID, sourceID)
+	sug, ok := s.links[key]
+	if !ok {
+		return nil, nil
+	}
+	sug.Status = status
+	sug.ReviewedAt = time.Now().UTC().Format(time.RFC3339)
+	s.links[key] = sug
+	s.appendEvent(database.EventLinkReviewed, articleID, sug)
+	return &sug, nil
+}
+
+// ReplaceClaims stores the claims of a source, replacing those extracted
+// before
+func (s *Store) ReplaceClaims(ctx context.Context, set database.ClaimSet) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if set.ExtractedAt == "" {
+		set.ExtractedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	set.Claims = append([]string{}, set.Claims...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims[set.SourceID] = set
+	s.appendEvent(database.EventClaimsExtracted, set.SourceID, set)
+	return nil
+}
+
+// SourceClaims returns the claims of a source, or nil if none were
+// extracted
+func (s *Store) SourceClaims(ctx context.Context, sourceID string) (*database.ClaimSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set, ok := s.claims[sourceID]
+	if !ok {
+		return nil, nil
+	}
+	set.Claims = append([]string{}, set.Claims...)
+	return &set, nil
+}
+
+// ClaimSummaryHashes returns the summary hash recorded with the claims of
+// every source that has them
+func (s *Store) ClaimSummaryHashes(ctx context.Context) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hashes := make(map[string]string, len(s.claims))
+	for id, set := range s.claims {
+		hashes[id] = set.SummaryHash
+	}
+	return hashes, nil
+}
+
+// SourceTopics returns the number of sources of every topic, whatever
+// their visibility
+func (s *Store) SourceTopics(ctx context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	topics := make(map[string]int)
+	for _, src := range s.sources {
+		if src.Topic != "" {
+			topics[src.Topic]++
+		}
+	}
+	return topics, nil
+}
+
+// BrokenArticleLinks returns the sources curated for the articles of a
+// topic that no longer exist
+func (s *Store) BrokenArticleLinks(ctx context.Context, topic string) ([]database.ArticleLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var links []database.ArticleLink
+	for _, art := range s.articles {
+		if art.Topic() != topic {
+			continue
+		}
+		for _, id := range s.linkedSources(art) {
+			if _, ok := s.sources[id]; !ok {
+				links = append(links, database.ArticleLink{ArticleID: art.ID, SourceID: id})
+			}
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].ArticleID != links[j].ArticleID {
+			return links[i].ArticleID < links[j].ArticleID
+		}
+		return links[i].SourceID < links[j].SourceID
+	})
+	return links, nil
+}
+
+// SaveTopicReport stores a report, replacing the topic's report of the same
+// period
+func (s *Store) SaveTopicReport(ctx context.Context, report database.TopicReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[reportKey(report.Topic, report.PeriodStart)] = report
+	return nil
+}
+
+// GetTopicReport returns the report of a topic starting at periodStart, or
+// its latest report when periodStart is empty; nil if there is none
+func (s *Store) GetTopicReport(ctx context.Context, topic, periodStart string) (*database.TopicReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if periodStart != "" {
+		if report, ok := s.reports[reportKey(topic, periodStart)]; ok {
+			return &report, nil
+		}
+		return nil, nil
+	}
+	var latest *database.TopicReport
+	for _, report := range s.reports {
+		if report.Topic == topic && (latest == nil || report.PeriodStart > latest.PeriodStart) {
+			latest = &report
+		}
+	}
+	return latest, nil
+}
+
+// ListTopicReports returns up to limit reports, most recent period first,
+// of one topic or, when topic is empty, of all
+func (s *Store) ListTopicReports(ctx context.Context, topic string, limit int) ([]database.TopicReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []database.TopicReport{}
+	for _, report := range s.reports {
+		if topic == "" || report.Topic == topic {
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].PeriodStart != reports[j].PeriodStart {
+			return reports[i].PeriodStart > reports[j].PeriodStart
+		}
+		return reports[i].Topic < reports[j].Topic
+	})
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports, nil
+}
+
+// linkedSources returns the sources curated for an article: its own and
+// those of approved suggestions. Callers hold mu.
+func (s *Store) linkedSources(art database.Article) []string {
+	linked := append([]string(nil), art.Sources...)
+	for _, sug := range s.links {
+		if sug.ArticleID == art.ID && sug.Status == database.SuggestionApproved && !contains(linked, sug.SourceID) {
+			linked = append(linked, sug.SourceID)
+		}
+	}
+	return linked
+}
+
+func linkKey(articleID, sourceID string) string {
+	return articleID + "\x00" + sourceID
+}
+
+func reportKey(topic, periodStart string) string {
+	return topic + "\x00" + periodStart
+}
+
+// apiKey is a stored API key with the hashes of its secrets
+type apiKey struct {
+	database.APIKey
+	secretHash, previousHash string
+}
+
+// CreateAPIKey stores a new key with the hash of its secret
+func (s *Store) CreateAPIKey(ctx context.Context, key database.APIKey, secretHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.ID == key.ID || k.secretHash == secretHash {
+			return fmt.Errorf("failed to store API key: duplicate ID or secret")
+		}
+	}
+	key.Topics = slices.Clone(key.Topics)
+	s.apiKeys[key.ID] = apiKey{APIKey: key, secretHash: secretHash}
+	return nil
+}
+
+// ListAPIKeys returns every key, oldest first
+func (s *Store) ListAPIKeys(ctx context.Context, now string) ([]database.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := []database.APIKey{}
+	for _, k := range s.apiKeys {
+		keys = append(keys, k.public(now))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt != keys[j].CreatedAt {
+			return keys[i].CreatedAt < keys[j].CreatedAt
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// APIKeyBySecret returns the key of a current secret hash, or of a previous
+// one until it expires; nil if no key matches
+func (s *Store) APIKeyBySecret(ctx context.Context, secretHash, now string) (*database.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.apiKeys {
+		if k.secretHash == secretHash || (k.previousHash == secretHash && k.PreviousExpiresAt > now) {
+			key := k.public(now)
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+// APIKeyByID returns a key; nil if there is none of that ID
+func (s *Store) APIKeyByID(ctx context.Context, id, now string) (*database.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return nil, nil
+	}
+	key := k.public(now)
+	return &key, nil
+}
+
+// RotateAPIKey replaces the secret of a key; nil if there is none of that
+// ID
+func (s *Store) RotateAPIKey(ctx context.Context, id, secretHash, prefix, rotatedAt, previousExpiresAt string) (*database.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return nil, nil
+	}
+	k.previousHash, k.PreviousExpiresAt = k.secretHash, previousExpiresAt
+	k.secretHash, k.Prefix, k.RotatedAt = secretHash, prefix, rotatedAt
+	s.apiKeys[id] = k
+	key := k.APIKey
+	return &key, nil
+}
+
+// DeleteAPIKey revokes a key, reporting whether it existed
+func (s *Store) DeleteAPIKey(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.apiKeys[id]
+	delete(s.apiKeys, id)
+	return ok, nil
+}
+
+// TouchAPIKey records when a key was last used
+func (s *Store) TouchAPIKey(ctx context.Context, id, usedAt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.apiKeys[id]; ok {
+		k.LastUsedAt = usedAt
+		s.apiKeys[id] = k
+	}
+	return nil
+}
+
+// public returns the key without its secrets, leaving out the expiry of a
+// previous secret that expired before now
+func (k apiKey) public(now string) database.APIKey {
+	key := k.APIKey
+	key.Topics = slices.Clone(key.Topics)
+	if key.PreviousExpiresAt <= now {
+		key.PreviousExpiresAt = ""
+	}
+	return key
+}
+
+// CreateWebhook stores a new webhook with its cursor at the head of the
+// event log
+func (s *Store) CreateWebhook(ctx context.Context, hook database.Webhook) (*database.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[hook.ID]; ok {
+		return nil, fmt.Errorf("failed to store webhook: duplicate ID")
+	}
+	hook.Events = slices.Clone(hook.Events)
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	hook.Cursor = 0
+	if n := len(s.events); n > 0 {
+		hook.Cursor = s.events[n-1].Seq
+	}
+	s.webhooks[hook.ID] = hook
+	return &hook, nil
+}
+
+// ListWebhooks returns every webhook, oldest first
+func (s *Store) ListWebhooks(ctx context.Context) ([]database.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hooks := []database.Webhook{}
+	for _, h := range s.webhooks {
+		h.Events = slices.Clone(h.Events)
+		hooks = append(hooks, h)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].CreatedAt != hooks[j].CreatedAt {
+			return hooks[i].CreatedAt < hooks[j].CreatedAt
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks, nil
+}
+
+// GetWebhook returns a webhook by ID, or nil if there is none
+func (s *Store) GetWebhook(ctx context.Context, id string) (*database.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, ok := s.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	h.Events = slices.Clone(h.Events)
+	return &h, nil
+}
+
+// DeleteWebhook removes a webhook, reporting whether it existed
+func (s *Store) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.webhooks[id]
+	delete(s.webhooks, id)
+	return ok, nil
+}
+
+// RecordWebhookAttempt moves a webhook's cursor and records the outcome of
+// a delivery
+func (s *Store) RecordWebhookAttempt(ctx context.Context, id string, a database.WebhookAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.webhooks[id]
+	if !ok {
+		return nil
+	}
+	h.Cursor = a.Cursor
+	if a.Delivered {
+		h.LastDeliveryAt, h.LastStatus, h.LastError = a.At, a.Status, a.Error
+		if a.Error == "" {
+			h.Failures = 0
+		} else {
+			h.Failures++
+		}
+	}
+	s.webhooks[id] = h
+	return nil
+}
+
+// QueueWrite appends a write to the outbox and returns its ID
+func (s *Store) QueueWrite(ctx context.Context, w database.QueuedWrite) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outboxSeq++
+	w.ID = s.outboxSeq
+	w.Body = slices.Clone(w.Body)
+	s.outbox = append(s.outbox, w)
+	return w.ID, nil
+}
+
+// QueuedWrites returns up to limit writes of the outbox, oldest first
+func (s *Store) QueuedWrites(ctx context.Context, limit int) ([]database.QueuedWrite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writes := slices.Clone(s.outbox[:min(limit, len(s.outbox))])
+	if writes == nil {
+		writes = []database.QueuedWrite{}
+	}
+	return writes, nil
+}
+
+// DeleteQueuedWrite removes a replayed write from the outbox
+func (s *Store) DeleteQueuedWrite(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = slices.DeleteFunc(s.outbox, func(w database.QueuedWrite) bool { return w.ID == id })
+	return nil
+}
+
+// CountQueuedWrites returns the number of writes in the outbox
+func (s *Store) CountQueuedWrites(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.outbox), nil
+}
+
+// ApplyEvent applies an event from another store's log, e.g. to load the
+// state of a backup as of some point in time. The event is recorded again
+// in this store's own log.
+func (s *Store) ApplyEvent(ctx context.Context, ev database.Event) error {
+	switch ev.Type {
+	case database.EventSourceUpserted:
+		var src database.Source
+		if err := json.Unmarshal(ev.Data, &src); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.InsertSource(ctx, src)
+	case database.EventSourceDeleted:
+		return s.DeleteSource(ctx, ev.EntityID)
+	case database.EventSourcePurged:
+		_, err := s.PurgeSource(ctx, ev.EntityID)
+		return err
+	case database.EventArticleUpserted:
+		var art database.Article
+		if err := json.Unmarshal(ev.Data, &art); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.InsertArticle(ctx, art)
+	case database.EventArticleDeleted:
+		return s.DeleteArticle(ctx, ev.EntityID)
+	case database.EventAliasAdded:
+		var alias database.Alias
+		if err := json.Unmarshal(ev.Data, &alias); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.AddAlias(ctx, alias)
+	case database.EventEntityAliasAdded:
+		var alias database.EntityAlias
+		if err := json.Unmarshal(ev.Data, &alias); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.AddEntityAlias(ctx, alias)
+	case database.EventLinkReviewed:
+		var sug database.LinkSuggestion
+		if err := json.Unmarshal(ev.Data, &sug); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.links[linkKey(sug.ArticleID, sug.SourceID)] = sug
+		s.appendEvent(ev.Type, ev.EntityID, sug)
+		return nil
+	case database.EventCategoryUpserted:
+		var cat database.Category
+		if err := json.Unmarshal(ev.Data, &cat); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.InsertCategories(ctx, []database.Category{cat})
+	case database.EventClaimsExtracted:
+		var set database.ClaimSet
+		if err := json.Unmarshal(ev.Data, &set); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.ReplaceClaims(ctx, set)
+	case database.EventTopicUpserted:
+		var t database.Topic
+		if err := json.Unmarshal(ev.Data, &t); err != nil {
+			return fmt.Errorf("event %d: %w", ev.Seq, err)
+		}
+		return s.PutTopic(ctx, t)
+	case database.EventTopicDeleted:
+		_, err := s.DeleteTopic(ctx, ev.EntityID)
+		return err
+	}
+	return fmt.Errorf("event %d: unknown type %s", ev.Seq, ev.Type)
+}
+
+// EventsSince returns up to limit events after cursor. Events are recorded
+// with their data but without hashes.
+func (s *Store) EventsSince(ctx context.Context, cursor int64, limit int) ([]database.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []database.Event
+	for _, ev := range s.events {
+		if ev.Seq > cursor && len(events) < limit {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// EventCursorAt returns the sequence number of the last event recorded
+// before the second of t (0 if there is none)
+func (s *Store) EventCursorAt(ctx context.Context, t time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	second := t.UTC().Truncate(time.Second)
+	var seq int64
+	for _, ev := range s.events {
+		if at, err := time.Parse(time.RFC3339Nano, ev.CreatedAt); err == nil && at.Before(second) {
+			seq = ev.Seq
+		}
+	}
+	return seq, nil
+}
+
+// VerifyEvents returns the number of events; the in-memory log is not
+// hash-chained
+func (s *Store) VerifyEvents(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.events)), nil
+}
+
+// PreviousEvent returns the last event before seq about an entity, among
+// events of the given types; nil if there is none
+func (s *Store) PreviousEvent(ctx context.Context, entityID string, seq int64, types ...string) (*database.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.events) - 1; i >= 0; i-- {
+		ev := s.events[i]
+		if ev.Seq < seq && ev.EntityID == entityID && slices.Contains(types, ev.Type) {
+			return &ev, nil
+		}
+	}
+	return nil, nil
+}
+
+// appendEvent records a mutation. Callers hold mu.
+func (s *Store) appendEvent(eventType, entityID string, data any) {
+	ev := database.Event{
+		Seq:       int64(len(s.events)) + 1,
+		Type:      eventType,
+		EntityID:  entityID,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if data != nil {
+		ev.Data, _ = json.Marshal(data)
+	}
+	s.events = append(s.events, ev)
+}
+
+// GetInfo retrieves a metadata value, or "" if unset
+func (s *Store) GetInfo(ctx context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.info[key], nil
+}
+
+// SetInfo stores a metadata value
+func (s *Store) SetInfo(ctx context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info[key] = value
+	return nil
+}
+
+// CachedCounts returns the source and article counts
+func (s *Store) CachedCounts(ctx context.Context) (map[string]database.RowCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]database.RowCount, len(s.counts))
+	for table, rc := range s.counts {
+		counts[table] = rc
+	}
+	return counts, nil
+}
+
+// recount refreshes the cached counts after a write. Callers hold mu.
+func (s *Store) recount() {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for table, n := range map[string]int{"sources": len(s.sources), "articles": len(s.articles)} {
+		if rc, ok := s.counts[table]; !ok || rc.Count != n {
+			s.counts[table] = database.RowCount{Count: n, UpdatedAt: now}
+		}
+	}
+}
+
+// matchesAll reports whether one term of every group (a term and its
+// synonyms) occurs in one of the fields
+func matchesAll(groups [][]string, fields ...string) bool {
+	if len(groups) == 0 {
+		return false
+	}
+	text := strings.ToLower(strings.Join(fields, "\n"))
+	for _, group := range groups {
+		if !slices.ContainsFunc(group, func(term string) bool { return strings.Contains(text, term) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchDictionary returns the synonyms and stopwords applied to keyword
+// queries
+func (s *Store) SearchDictionary(ctx context.Context) (*database.Dictionary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dict := &database.Dictionary{Synonyms: make(map[string][]string, len(s.synonyms)), Stopwords: []string{}}
+	for term, expansions := range s.synonyms {
+		dict.Synonyms[term] = expansions
+	}
+	for word := range s.stopwords {
+		dict.Stopwords = append(dict.Stopwords, word)
+	}
+	sort.Strings(dict.Stopwords)
+	return dict, nil
+}
+
+// SetSynonyms sets the terms also searched for a term; no expansions
+// removes the term's synonyms
+func (s *Store) SetSynonyms(ctx context.Context, term string, expansions []string) error {
+	term = database.NormalizeTerm(term)
+	if term == "" {
+		return fmt.Errorf("empty term")
+	}
+	expansions = database.NormalizeSynonyms(term, expansions)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(expansions) == 0 {
+		delete(s.synonyms, term)
+	} else {
+		s.synonyms[term] = expansions
+	}
+	return nil
+}
+
+// SetStopword adds a word to the stopwords, or removes it
+func (s *Store) SetStopword(ctx context.Context, word string, stop bool) error {
+	word = database.NormalizeTerm(word)
+	if word == "" {
+		return fmt.Errorf("empty word")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stop {
+		s.stopwords[word] = true
+	} else {
+		delete(s.stopwords, word)
+	}
+	return nil
+}
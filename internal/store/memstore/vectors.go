@@ -0,0 +1,700 @@
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// point is a stored vector point
+type point struct {
+	vectors vectordb.Vectors
+	payload map[string]interface{}
+}
+
+// Vectors is an in-memory store.VectorStore that scores points by exact
+// cosine similarity. Sparse vectors are not supported, and result IDs are the
+// record IDs rather than Qdrant's UUIDs. Article chunks are always stored.
+type Vectors struct {
+	mu          sync.RWMutex
+	spaces      []vectordb.VectorSpace
+	collections map[string]map[string]point
+}
+
+var _ store.VectorStore = (*Vectors)(nil)
+
+// NewVectors returns an empty in-memory vector store with the given named
+// vector spaces (none for a single unnamed vector per point)
+func NewVectors(spaces ...vectordb.VectorSpace) *Vectors {
+	return &Vectors{
+		spaces: spaces,
+		collections: map[string]map[string]point{
+			vectordb.SourcesCollection:       {},
+			vectordb.ArticlesCollection:      {},
+			vectordb.ArticleChunksCollection: {},
+			vectordb.ClaimsCollection:        {},
+		},
+	}
+}
+
+// VectorSpaces returns the configured named vector spaces
+func (v *Vectors) VectorSpaces() []vectordb.VectorSpace {
+	return v.spaces
+}
+
+// VectorNames returns the names of the configured vector spaces
+func (v *Vectors) VectorNames() []string {
+	names := make([]string, len(v.spaces))
+	for i, space := range v.spaces {
+		names[i] = space.Name
+	}
+	return names
+}
+
+// SparseEnabled always reports false
+func (v *Vectors) SparseEnabled() bool {
+	return false
+}
+
+// Distance always reports cosine, the only metric scored
+func (v *Vectors) Distance(collection string) vectordb.Distance {
+	return vectordb.DistanceCosine
+}
+
+// ChunksEnabled always reports true
+func (v *Vectors) ChunksEnabled() bool {
+	return true
+}
+
+// ClaimsEnabled always reports true
+func (v *Vectors) ClaimsEnabled() bool {
+	return true
+}
+
+// LateInteractionEnabled always reports false: points carry no token
+// vectors
+func (v *Vectors) LateInteractionEnabled(collection string) bool {
+	return false
+}
+
+// EnsureCollections implements store.VectorStore; collections always exist
+func (v *Vectors) EnsureCollections(ctx context.Context) error {
+	return nil
+}
+
+// Ping implements store.VectorStore; collections always exist
+func (v *Vectors) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close implements store.VectorStore; it does nothing
+func (v *Vectors) Close() error {
+	return nil
+}
+
+// WriteStats always returns zero counts: writes are not counted
+func (v *Vectors) WriteStats() vectordb.WriteStats {
+	return vectordb.WriteStats{}
+}
+
+// UpsertSourceVectors stores the vectors and payload of a source
+func (v *Vectors) UpsertSourceVectors(ctx context.Context, id string, vectors vectordb.Vectors, payload vectordb.SourcePayload) error {
+	if payload.Visibility == "" {
+		payload.Visibility = database.VisibilityPublic
+	}
+	return v.upsert(ctx, vectordb.SourcesCollection, id, vectors, payload)
+}
+
+// UpsertArticleVectors stores the vectors and payload of an article
+func (v *Vectors) UpsertArticleVectors(ctx context.Context, id string, vectors vectordb.Vectors, payload vectordb.ArticlePayload) error {
+	return v.upsert(ctx, vectordb.ArticlesCollection, id, vectors, payload)
+}
+
+// UpsertSources stores the vectors and payloads of sources
+func (v *Vectors) UpsertSources(ctx context.Context, points []vectordb.SourcePoint) error {
+	for _, p := range points {
+		if err := v.UpsertSourceVectors(ctx, p.ID, p.Vectors, p.Payload); err != nil {
+			return fmt.Errorf("source %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// UpsertArticles stores the vectors and payloads of articles
+func (v *Vectors) UpsertArticles(ctx context.Context, points []vectordb.ArticlePoint) error {
+	for _, p := range points {
+		if err := v.UpsertArticleVectors(ctx, p.ID, p.Vectors, p.Payload); err != nil {
+			return fmt.Errorf("article %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+func (v *Vectors) upsert(ctx context.Context, collection, id string, vectors vectordb.Vectors, payload any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(v.spaces) == 0 && len(vectors) != 1 {
+		return fmt.Errorf("expected one embedding, got %d", len(vectors))
+	}
+	for name := range vectors {
+		if len(v.spaces) > 0 && !v.hasSpace(name) {
+			return fmt.Errorf("unknown vector space: %s", name)
+		}
+	}
+
+	// Round-trip through JSON so payloads look like the ones read back from Qdrant
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	stored := make(vectordb.Vectors, len(vectors))
+	for name, vec := range vectors {
+		if len(v.spaces) == 0 {
+			name = "" // The single embedding is the unnamed vector
+		}
+		stored[name] = append([]float32(nil), vec...)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.collections[collection][id] = point{vectors: stored, payload: fields}
+	return nil
+}
+
+// space resolves a vector space name the way the Qdrant client does: the
+// unnamed vector when no spaces are configured, otherwise the named space,
+// with empty selecting the first one
+func (v *Vectors) space(name string) (string, error) {
+	if len(v.spaces) == 0 {
+		return "", nil
+	}
+	if name == "" {
+		return v.spaces[0].Name, nil
+	}
+	if !v.hasSpace(name) {
+		return "", fmt.Errorf("unknown vector space: %s", name)
+	}
+	return name, nil
+}
+
+func (v *Vectors) hasSpace(name string) bool {
+	for _, space := range v.spaces {
+		if space.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchSources returns the sources most similar to the embedding, filtered
+// by topic, domain, type and visibility
+func (v *Vectors) SearchSources(ctx context.Context, emb []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error) {
+	return v.search(vectordb.SourcesCollection, emb, limit, opts.Vector, sourceMatcher(opts))
+}
+
+// SearchArticles returns the articles most similar to the embedding,
+// filtered by category
+func (v *Vectors) SearchArticles(ctx context.Context, emb []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error) {
+	return v.search(vectordb.ArticlesCollection, emb, limit, opts.Vector, func(payload map[string]interface{}) bool {
+		return opts.Category == "" || payload["category"] == opts.Category
+	})
+}
+
+// ReplaceArticleChunks stores the chunks of an article, removing those of
+// its previous version
+func (v *Vectors) ReplaceArticleChunks(ctx context.Context, articleID string, points []vectordb.ChunkPoint) error {
+	v.deleteChunks(articleID)
+	for _, p := range points {
+		p.Payload.ArticleID = articleID
+		id := fmt.Sprintf("%s#%d", articleID, p.Payload.Chunk)
+		if err := v.upsert(ctx, vectordb.ArticleChunksCollection, id, p.Vectors, p.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteChunks removes every chunk of an article
+func (v *Vectors) deleteChunks(articleID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for id, p := range v.collections[vectordb.ArticleChunksCollection] {
+		if p.payload["article_id"] == articleID {
+			delete(v.collections[vectordb.ArticleChunksCollection], id)
+		}
+	}
+}
+
+// SearchArticleChunks returns the article chunks most similar to the
+// embedding, filtered by category
+func (v *Vectors) SearchArticleChunks(ctx context.Context, emb []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error) {
+	return v.search(vectordb.ArticleChunksCollection, emb, limit, opts.Vector, func(payload map[string]interface{}) bool {
+		return opts.Category == "" || payload["category"] == opts.Category
+	})
+}
+
+// ReplaceSourceClaims stores the claims of a source, removing those
+// extracted before
+func (v *Vectors) ReplaceSourceClaims(ctx context.Context, sourceID string, points []vectordb.ClaimPoint) error {
+	v.deleteClaims(sourceID)
+	for _, p := range points {
+		p.Payload.SourceID = sourceID
+		id := fmt.Sprintf("%s#%d", sourceID, p.Payload.Claim)
+		if err := v.upsert(ctx, vectordb.ClaimsCollection, id, p.Vectors, p.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteClaims removes every claim of a source
+func (v *Vectors) deleteClaims(sourceID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for id, p := range v.collections[vectordb.ClaimsCollection] {
+		if p.payload["source_id"] == sourceID {
+			delete(v.collections[vectordb.ClaimsCollection], id)
+		}
+	}
+}
+
+// SearchClaims returns the claims most similar to the embedding among
+// those of sources visible to opts.User, filtered by topic
+func (v *Vectors) SearchClaims(ctx context.Context, emb []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error) {
+	return v.search(vectordb.ClaimsCollection, emb, limit, opts.Vector, func(payload map[string]interface{}) bool {
+		topic, _ := payload["topic"].(string)
+		if payload["deleted"] == true || !opts.MatchesTopic(topic) {
+			return false
+		}
+		visibility, _ := payload["visibility"].(string)
+		owner, _ := payload["owner"].(string)
+		src := database.Source{Visibility: visibility, Owner: owner}
+		return src.VisibleTo(opts.User)
+	})
+}
+
+// RecommendSources scores sources against the average of the positive
+// examples pushed away from the average of the negative ones (Qdrant's
+// average_vector strategy, used for every strategy). The examples are
+// excluded from the results.
+func (v *Vectors) RecommendSources(ctx context.Context, positive, negative []string, strategy string, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error) {
+	if len(positive) == 0 {
+		return nil, fmt.Errorf("at least one positive example is required")
+	}
+	name, err := v.space(opts.Vector)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	points := v.collections[vectordb.SourcesCollection]
+	pos, err := averageVector(points, positive, name)
+	if err != nil {
+		v.mu.RUnlock()
+		return nil, err
+	}
+	target := pos
+	if len(negative) > 0 {
+		neg, err := averageVector(points, negative, name)
+		if err != nil {
+			v.mu.RUnlock()
+			return nil, err
+		}
+		target = make([]float32, len(pos))
+		for i := range pos {
+			target[i] = 2*pos[i] - neg[i]
+		}
+	}
+	v.mu.RUnlock()
+
+	examples := make(map[string]bool)
+	for _, ids := range [][]string{positive, negative} {
+		for _, id := range ids {
+			examples[id] = true
+		}
+	}
+	match := sourceMatcher(opts)
+	return v.search(vectordb.SourcesCollection, target, limit, opts.Vector, func(payload map[string]interface{}) bool {
+		id, _ := payload["id"].(string)
+		return !examples[id] && match(payload)
+	})
+}
+
+func averageVector(points map[string]point, ids []string, name string) ([]float32, error) {
+	var sum []float32
+	for _, id := range ids {
+		p, ok := points[id]
+		if !ok {
+			return nil, fmt.Errorf("no point with id %s", id)
+		}
+		vec := p.vectors[name]
+		if sum == nil {
+			sum = make([]float32, len(vec))
+		}
+		for i := range vec {
+			sum[i] += vec[i]
+		}
+	}
+	for i := range sum {
+		sum[i] /= float32(len(ids))
+	}
+	return sum, nil
+}
+
+// FacetSources counts the sources matching opts by the string values of a
+// payload key
+func (v *Vectors) FacetSources(ctx context.Context, key string, opts vectordb.SearchOptions) (map[string]int, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	match := sourceMatcher(opts)
+	counts := make(map[string]int)
+	for _, p := range v.collections[vectordb.SourcesCollection] {
+		if value, _ := p.payload[key].(string); value != "" && match(p.payload) {
+			counts[value]++
+		}
+	}
+	return counts, nil
+}
+
+// GetSourcesByTopic returns up to limit sources of a topic with score 1
+func (v *Vectors) GetSourcesByTopic(ctx context.Context, topic string, limit int) ([]vectordb.SearchResult, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var results []vectordb.SearchResult
+	for id, p := range v.collections[vectordb.SourcesCollection] {
+		if p.payload["topic"] == topic && p.payload["deleted"] != true {
+			results = append(results, vectordb.SearchResult{ID: id, Score: 1.0, Payload: p.payload})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Scroll returns up to limit points of a collection in ID order, starting at
+// the point with ID offset (empty for the first)
+func (v *Vectors) Scroll(ctx context.Context, collection, offset string, limit int) (vectordb.ScrollPage, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	points, ok := v.collections[collection]
+	if !ok {
+		return vectordb.ScrollPage{}, fmt.Errorf("unknown collection: %s", collection)
+	}
+	ids := make([]string, 0, len(points))
+	for id := range points {
+		if id >= offset {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var page vectordb.ScrollPage
+	if len(ids) > limit {
+		page.Next = ids[limit]
+		ids = ids[:limit]
+	}
+	for _, id := range ids {
+		page.Points = append(page.Points, vectordb.Point{ID: id, Payload: points[id].payload})
+	}
+	return page, nil
+}
+
+// Count returns the number of points of a collection matching filter
+func (v *Vectors) Count(ctx context.Context, collection string, filter vectordb.Filter) (uint64, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	points, ok := v.collections[collection]
+	if !ok {
+		return 0, fmt.Errorf("unknown collection: %s", collection)
+	}
+	var count uint64
+	for _, p := range points {
+		if matches(p.payload, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matches reports whether a payload matches a filter
+func matches(payload map[string]interface{}, filter vectordb.Filter) bool {
+	for key, value := range filter {
+		// Payloads went through JSON, so numbers are float64: compare their
+		// printed forms
+		if got, ok := payload[key]; !ok || fmt.Sprint(got) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Exists reports whether a collection has a point with the given ID
+func (v *Vectors) Exists(ctx context.Context, collection, id string) (bool, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	points, ok := v.collections[collection]
+	if !ok {
+		return false, fmt.Errorf("unknown collection: %s", collection)
+	}
+	_, ok = points[id]
+	return ok, nil
+}
+
+// SetPayload sets the given payload keys of points, keeping their other keys
+func (v *Vectors) SetPayload(ctx context.Context, collection string, ids []string, payload map[string]any) error {
+	return v.setPayload(collection, ids, nil, payload, false)
+}
+
+// SetPayloadWhere sets the given payload keys of every point matching filter
+func (v *Vectors) SetPayloadWhere(ctx context.Context, collection string, filter vectordb.Filter, payload map[string]any) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("an empty filter would update every point")
+	}
+	return v.setPayload(collection, nil, filter, payload, false)
+}
+
+// OverwritePayload replaces the whole payload of points
+func (v *Vectors) OverwritePayload(ctx context.Context, collection string, ids []string, payload map[string]any) error {
+	return v.setPayload(collection, ids, nil, payload, true)
+}
+
+// setPayload updates the payload of the points with the given IDs, or of
+// those matching filter. Like Qdrant, it fails if an ID has no point.
+func (v *Vectors) setPayload(collection string, ids []string, filter vectordb.Filter, payload map[string]any, overwrite bool) error {
+	// Round-trip through JSON so payloads look like the ones read back from Qdrant
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	points, ok := v.collections[collection]
+	if !ok {
+		return fmt.Errorf("unknown collection: %s", collection)
+	}
+	if filter != nil {
+		ids = nil
+		for id, p := range points {
+			if matches(p.payload, filter) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := points[id]; !ok {
+			return fmt.Errorf("no point with id %s", id)
+		}
+	}
+	for _, id := range ids {
+		// Payloads handed out by searches are never modified in place
+		p := points[id]
+		updated := make(map[string]interface{})
+		if !overwrite {
+			maps.Copy(updated, p.payload)
+		}
+		if err := json.Unmarshal(data, &updated); err != nil {
+			return err
+		}
+		p.payload = updated
+		points[id] = p
+	}
+	return nil
+}
+
+// GetSourceVector returns a source's stored vector, or nil if it has none
+func (v *Vectors) GetSourceVector(ctx context.Context, id, vector string) ([]float32, error) {
+	return v.getVector(vectordb.SourcesCollection, id, vector)
+}
+
+// GetArticleVector returns an article's stored vector, or nil if it has none
+func (v *Vectors) GetArticleVector(ctx context.Context, id, vector string) ([]float32, error) {
+	return v.getVector(vectordb.ArticlesCollection, id, vector)
+}
+
+func (v *Vectors) getVector(collection, id, vector string) ([]float32, error) {
+	name, err := v.space(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	p, ok := v.collections[collection][id]
+	if !ok {
+		return nil, nil
+	}
+	return append([]float32(nil), p.vectors[name]...), nil
+}
+
+// DeleteSource removes a source's point and claims
+func (v *Vectors) DeleteSource(ctx context.Context, id string) error {
+	v.deleteClaims(id)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.collections[vectordb.SourcesCollection], id)
+	return nil
+}
+
+// SetSourceDeleted flags the points of a source and its claims as deleted,
+// or clears the flag
+func (v *Vectors) SetSourceDeleted(ctx context.Context, id string, deleted bool) error {
+	payload := map[string]any{"deleted": deleted}
+	if err := v.SetPayloadWhere(ctx, vectordb.ClaimsCollection, vectordb.Filter{"source_id": id}, payload); err != nil {
+		return err
+	}
+	return v.SetPayload(ctx, vectordb.SourcesCollection, []string{id}, payload)
+}
+
+// DeleteArticle removes an article's point and chunks
+func (v *Vectors) DeleteArticle(ctx context.Context, id string) error {
+	v.deleteChunks(id)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.collections[vectordb.ArticlesCollection], id)
+	return nil
+}
+
+func (v *Vectors) search(collection string, emb []float32, limit int, vector string, keep func(map[string]interface{}) bool) ([]vectordb.SearchResult, error) {
+	name, err := v.space(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var results []vectordb.SearchResult
+	for id, p := range v.collections[collection] {
+		vec, ok := p.vectors[name]
+		if !ok || !keep(p.payload) {
+			continue
+		}
+		results = append(results, vectordb.SearchResult{
+			ID:      id,
+			Score:   embedding.CosineSimilarity(emb, vec),
+			Payload: p.payload,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// sourceMatcher applies the topic, domain, type, link, language, tag,
+// creation time, location and visibility filters of a source query, and
+// leaves out deleted sources
+func sourceMatcher(opts vectordb.SearchOptions) func(map[string]interface{}) bool {
+	return func(payload map[string]interface{}) bool {
+		if payload["deleted"] == true {
+			return false
+		}
+		if topic, _ := payload["topic"].(string); !opts.MatchesTopic(topic) {
+			return false
+		}
+		if opts.Domain != "" && payload["domain"] != opts.Domain {
+			return false
+		}
+		if opts.Type != "" && payload["type"] != opts.Type {
+			return false
+		}
+		if opts.HasCode && payload["has_code"] != true {
+			return false
+		}
+		if opts.HasDataset && payload["has_dataset"] != true {
+			return false
+		}
+		if opts.Language != "" && payload["language"] != opts.Language {
+			return false
+		}
+		if len(opts.Tags) > 0 && !matchTags(payload["tags"], opts.Tags, opts.AllTags) {
+			return false
+		}
+		if !matchTimeRange(payload["created_at"], opts.CreatedAfter, opts.CreatedBefore) ||
+			!matchTimeRange(payload["published_at"], opts.PublishedAfter, opts.PublishedBefore) {
+			return false
+		}
+		if opts.Near != nil && !matchNear(payload["location"], *opts.Near) {
+			return false
+		}
+		visibility, _ := payload["visibility"].(string)
+		owner, _ := payload["owner"].(string)
+		src := database.Source{Visibility: visibility, Owner: owner}
+		return src.VisibleTo(opts.User)
+	}
+}
+
+// matchTimeRange reports whether an RFC 3339 payload value lies at or after
+// after and before before; zero times are open ends
+func matchTimeRange(value any, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	s, _ := value.(string)
+	t, err := time.Parse(time.RFC3339, s)
+	return err == nil && !t.Before(after) && (before.IsZero() || t.Before(before))
+}
+
+// matchNear reports whether any point of a payload location list lies
+// within the circle
+func matchNear(value any, near vectordb.GeoRadius) bool {
+	list, _ := value.([]any)
+	for _, v := range list {
+		m, _ := v.(map[string]any)
+		lat, ok1 := m["lat"].(float64)
+		lon, ok2 := m["lon"].(float64)
+		if ok1 && ok2 && near.Contains(vectordb.GeoPoint{Lat: lat, Lon: lon}) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTags reports whether a payload tag list holds any of the tags, or
+// all of them
+func matchTags(value any, tags []string, all bool) bool {
+	list, _ := value.([]any)
+	has := make(map[string]bool, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			has[s] = true
+		}
+	}
+	for _, tag := range tags {
+		if has[tag] != all {
+			return !all
+		}
+	}
+	return all
+}
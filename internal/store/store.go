@@ -0,0 +1,286 @@
+// Package store defines the storage interfaces consumed by the server and
+// CLIs. database.DB and vectordb.Client implement them; package memstore
+// provides in-memory implementations for tests and local experiments.
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// SourceStore stores source records and searches them by keyword
+type SourceStore interface {
+	InsertSource(ctx context.Context, src database.Source) error
+	InsertSources(ctx context.Context, srcs []database.Source) error
+	GetSource(ctx context.Context, id string) (*database.Source, error)
+	SourceContent(ctx context.Context, id string) (string, error)
+	GetSourceByURL(ctx context.Context, url string) (*database.Source, error)
+	GetSourceByCanonicalURL(ctx context.Context, rawURL string) (*database.Source, error)
+	GetSourcesByTopic(ctx context.Context, topic string, limit int, user string) ([]database.Source, error)
+	ListSources(ctx context.Context, filter database.SourceFilter, limit int, user string) ([]database.Source, error)
+	CountSourcesByType(ctx context.Context, filter database.SourceFilter, user string) (map[string]int, error)
+	SearchSources(ctx context.Context, query string, limit int, user string) ([]database.Source, error)
+	DeleteSource(ctx context.Context, id string) error
+	RestoreSource(ctx context.Context, id, user string) (*database.Source, error)
+	DeletedSources(ctx context.Context, before string, limit int) ([]database.DeletedSource, error)
+	PurgeSource(ctx context.Context, id string) (bool, error)
+	CountSources(ctx context.Context) (int, error)
+	SourceIDs(ctx context.Context) ([]string, error)
+	SourceIDsByTopic(ctx context.Context, topic string) ([]string, error)
+	SourceRevisions(ctx context.Context, id string) ([]database.Revision, error)
+}
+
+// ArticleStore stores article records and searches them by keyword
+type ArticleStore interface {
+	InsertArticle(ctx context.Context, art database.Article) error
+	InsertArticles(ctx context.Context, arts []database.Article) error
+	DeleteArticle(ctx context.Context, id string) error
+	GetArticle(ctx context.Context, id string) (*database.Article, error)
+	ArticleContent(ctx context.Context, id string) (string, error)
+	SearchArticles(ctx context.Context, query string, limit int) ([]database.Article, error)
+	ArticleVocabulary(ctx context.Context, minDocs int) (map[string]int, error)
+	LinkedSourceIDs(ctx context.Context, topic string) ([]string, error)
+	CitedSourceIDs(ctx context.Context) ([]string, error)
+	CountArticles(ctx context.Context) (int, error)
+	ArticleRevisions(ctx context.Context, id string) ([]database.Revision, error)
+}
+
+// ProfileStore stores per-user interest profiles
+type ProfileStore interface {
+	GetProfile(ctx context.Context, userID, model string) (*database.Profile, error)
+	SaveProfile(ctx context.Context, p database.Profile) error
+	DeleteProfiles(ctx context.Context, userID string) error
+}
+
+// QueueStore stores per-user reading queues of sources
+type QueueStore interface {
+	EnqueueSources(ctx context.Context, userID string, sourceIDs []string, note string) (int, error)
+	GetQueueItem(ctx context.Context, userID, sourceID string) (*database.QueueItem, error)
+	SaveQueueItem(ctx context.Context, q database.QueueItem) error
+	ReadingQueue(ctx context.Context, userID, status string) ([]database.QueueItem, error)
+	DequeueSource(ctx context.Context, userID, sourceID string) (bool, error)
+}
+
+// WatchStore stores per-user watches and the new sources matching them
+type WatchStore interface {
+	CreateWatch(ctx context.Context, w database.Watch) (*database.Watch, error)
+	ListWatches(ctx context.Context, userID string) ([]database.Watch, error)
+	GetWatch(ctx context.Context, id string) (*database.Watch, error)
+	DeleteWatch(ctx context.Context, id string) (bool, error)
+	AdvanceWatch(ctx context.Context, id string, cursor int64, matches []database.WatchMatch) error
+	WatchMatches(ctx context.Context, userID, watchID string, since int64, limit int) ([]database.WatchMatch, error)
+	LastWatchMatch(ctx context.Context, userID string) (int64, error)
+	UndeliveredWatchMatches(ctx context.Context, maxAttempts int) ([]database.WatchMatch, error)
+	RecordWatchDelivery(ctx context.Context, seq int64, at, errMsg string) error
+}
+
+// AccessStore tracks when sources were last hit and which are cold
+type AccessStore interface {
+	RecordSourceHits(ctx context.Context, ids []string, at string) error
+	GetSourceAccess(ctx context.Context, id string) (database.SourceAccess, error)
+	SetSourceCold(ctx context.Context, id, coldAt string) error
+	IdleSources(ctx context.Context, before string, limit int) ([]database.SourceAccess, error)
+	ColdSourceIDs(ctx context.Context) ([]string, error)
+}
+
+// AliasStore maps retired source and article IDs to their replacements
+type AliasStore interface {
+	AddAlias(ctx context.Context, alias database.Alias) error
+	ResolveAlias(ctx context.Context, kind, id string) (string, error)
+}
+
+// EntityStore indexes the people, organizations and places mentioned by
+// sources and resolves their spelling variants
+type EntityStore interface {
+	AddEntityAlias(ctx context.Context, alias database.EntityAlias) error
+	ResolveEntity(ctx context.Context, kind, name string) (string, error)
+	EntityAliases(ctx context.Context, kind, canonical string) ([]string, error)
+	SourcesByEntity(ctx context.Context, kind, name string, limit int, user string) ([]database.Source, error)
+	Entities(ctx context.Context, kind string, limit int) ([]database.Entity, error)
+}
+
+// TagStore indexes the tags of sources and articles
+type TagStore interface {
+	Tags(ctx context.Context, limit int, user string) ([]database.Tag, error)
+	SourcesByTag(ctx context.Context, tag string, limit int, user string) ([]database.Source, error)
+	SourceIDsByTag(ctx context.Context, tag string) ([]string, error)
+	ArticlesByTag(ctx context.Context, tag string, limit int) ([]database.Article, error)
+}
+
+// LinkSuggestionStore holds the article-source links proposed by the
+// auto-linking job and their review by curators
+type LinkSuggestionStore interface {
+	ArticleIDs(ctx context.Context) ([]string, error)
+	SuggestLinks(ctx context.Context, suggestions []database.LinkSuggestion) (int, error)
+	LinkSuggestions(ctx context.Context, articleID, status string, limit int) ([]database.LinkSuggestion, error)
+	ReviewLinkSuggestion(ctx context.Context, articleID, sourceID, status string) (*database.LinkSuggestion, error)
+}
+
+// ClaimStore holds the claims extracted from source summaries
+type ClaimStore interface {
+	ReplaceClaims(ctx context.Context, set database.ClaimSet) error
+	SourceClaims(ctx context.Context, sourceID string) (*database.ClaimSet, error)
+	ClaimSummaryHashes(ctx context.Context) (map[string]string, error)
+}
+
+// ReportStore holds the periodic topic reports and the data only they read
+type ReportStore interface {
+	SourceTopics(ctx context.Context) (map[string]int, error)
+	BrokenArticleLinks(ctx context.Context, topic string) ([]database.ArticleLink, error)
+	SaveTopicReport(ctx context.Context, report database.TopicReport) error
+	GetTopicReport(ctx context.Context, topic, periodStart string) (*database.TopicReport, error)
+	ListTopicReports(ctx context.Context, topic string, limit int) ([]database.TopicReport, error)
+}
+
+// CategoryStore holds the category landing pages of Compendium directories
+type CategoryStore interface {
+	InsertCategories(ctx context.Context, cats []database.Category) error
+	GetCategory(ctx context.Context, path string) (*database.Category, error)
+	Subcategories(ctx context.Context, path string) ([]database.Category, error)
+	ArticlesByCategory(ctx context.Context, path string, limit int) ([]database.Article, error)
+}
+
+// TopicStore holds the topic taxonomy
+type TopicStore interface {
+	PutTopic(ctx context.Context, t database.Topic) error
+	// GetTopic looks a topic up by its slug or one of its aliases
+	GetTopic(ctx context.Context, slug string) (*database.Topic, error)
+	ListTopics(ctx context.Context) ([]database.Topic, error)
+	DeleteTopic(ctx context.Context, slug string) (bool, error)
+}
+
+// SearchDictionaryStore holds the synonyms and stopwords applied to keyword
+// queries
+type SearchDictionaryStore interface {
+	SearchDictionary(ctx context.Context) (*database.Dictionary, error)
+	SetSynonyms(ctx context.Context, term string, expansions []string) error
+	SetStopword(ctx context.Context, word string, stop bool) error
+}
+
+// APIKeyStore holds the topic-scoped API keys of automated clients
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key database.APIKey, secretHash string) error
+	ListAPIKeys(ctx context.Context, now string) ([]database.APIKey, error)
+	APIKeyBySecret(ctx context.Context, secretHash, now string) (*database.APIKey, error)
+	APIKeyByID(ctx context.Context, id, now string) (*database.APIKey, error)
+	RotateAPIKey(ctx context.Context, id, secretHash, prefix, rotatedAt, previousExpiresAt string) (*database.APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) (bool, error)
+	TouchAPIKey(ctx context.Context, id, usedAt string) error
+}
+
+// OutboxStore holds the source writes queued while the server protects its
+// stores
+type OutboxStore interface {
+	QueueWrite(ctx context.Context, w database.QueuedWrite) (int64, error)
+	QueuedWrites(ctx context.Context, limit int) ([]database.QueuedWrite, error)
+	DeleteQueuedWrite(ctx context.Context, id int64) error
+	CountQueuedWrites(ctx context.Context) (int, error)
+}
+
+// GraphStore reads the knowledge graph of articles, sources, topics and
+// entities
+type GraphStore interface {
+	database.GraphReader
+}
+
+// EventLog reads the log of knowledge-base mutations
+type EventLog interface {
+	EventsSince(ctx context.Context, cursor int64, limit int) ([]database.Event, error)
+	EventCursorAt(ctx context.Context, t time.Time) (int64, error)
+	VerifyEvents(ctx context.Context) (int64, error)
+	PreviousEvent(ctx context.Context, entityID string, seq int64, types ...string) (*database.Event, error)
+}
+
+// WebhookStore stores the endpoints receiving event log deliveries and
+// their progress through the log
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, hook database.Webhook) (*database.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]database.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*database.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error)
+	RecordWebhookAttempt(ctx context.Context, id string, a database.WebhookAttempt) error
+}
+
+// InfoStore holds database metadata and cached statistics
+type InfoStore interface {
+	GetInfo(ctx context.Context, key string) (string, error)
+	SetInfo(ctx context.Context, key, value string) error
+	CachedCounts(ctx context.Context) (map[string]database.RowCount, error)
+}
+
+// Store is the full record store behind the knowledge-base
+type Store interface {
+	SourceStore
+	ArticleStore
+	ProfileStore
+	QueueStore
+	WatchStore
+	AccessStore
+	AliasStore
+	EntityStore
+	TagStore
+	CategoryStore
+	TopicStore
+	SearchDictionaryStore
+	GraphStore
+	LinkSuggestionStore
+	ClaimStore
+	ReportStore
+	APIKeyStore
+	WebhookStore
+	OutboxStore
+	EventLog
+	InfoStore
+	// Ping checks that the store can answer queries
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// VectorStore stores embeddings and runs similarity searches
+type VectorStore interface {
+	VectorSpaces() []vectordb.VectorSpace
+	VectorNames() []string
+	SparseEnabled() bool
+	Distance(collection string) vectordb.Distance
+	ChunksEnabled() bool
+	ClaimsEnabled() bool
+	LateInteractionEnabled(collection string) bool
+	EnsureCollections(ctx context.Context) error
+
+	UpsertSourceVectors(ctx context.Context, id string, vectors vectordb.Vectors, payload vectordb.SourcePayload) error
+	UpsertArticleVectors(ctx context.Context, id string, vectors vectordb.Vectors, payload vectordb.ArticlePayload) error
+	UpsertSources(ctx context.Context, points []vectordb.SourcePoint) error
+	UpsertArticles(ctx context.Context, points []vectordb.ArticlePoint) error
+	ReplaceArticleChunks(ctx context.Context, articleID string, points []vectordb.ChunkPoint) error
+	ReplaceSourceClaims(ctx context.Context, sourceID string, points []vectordb.ClaimPoint) error
+	SearchSources(ctx context.Context, embedding []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error)
+	SearchArticles(ctx context.Context, embedding []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error)
+	SearchArticleChunks(ctx context.Context, embedding []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error)
+	SearchClaims(ctx context.Context, embedding []float32, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error)
+	RecommendSources(ctx context.Context, positive, negative []string, strategy string, limit int, opts vectordb.SearchOptions) ([]vectordb.SearchResult, error)
+	FacetSources(ctx context.Context, key string, opts vectordb.SearchOptions) (map[string]int, error)
+	GetSourcesByTopic(ctx context.Context, topic string, limit int) ([]vectordb.SearchResult, error)
+	Scroll(ctx context.Context, collection, offset string, limit int) (vectordb.ScrollPage, error)
+	Count(ctx context.Context, collection string, filter vectordb.Filter) (uint64, error)
+	Exists(ctx context.Context, collection, id string) (bool, error)
+	SetPayload(ctx context.Context, collection string, ids []string, payload map[string]any) error
+	SetPayloadWhere(ctx context.Context, collection string, filter vectordb.Filter, payload map[string]any) error
+	OverwritePayload(ctx context.Context, collection string, ids []string, payload map[string]any) error
+	GetSourceVector(ctx context.Context, id, vector string) ([]float32, error)
+	GetArticleVector(ctx context.Context, id, vector string) ([]float32, error)
+	SetSourceDeleted(ctx context.Context, id string, deleted bool) error
+	DeleteSource(ctx context.Context, id string) error
+	DeleteArticle(ctx context.Context, id string) error
+	WriteStats() vectordb.WriteStats
+	// Ping checks that the vector database answers and has its collections
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ Store       = (*database.DB)(nil)
+	_ VectorStore = (*vectordb.Client)(nil)
+)
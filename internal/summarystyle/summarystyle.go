@@ -0,0 +1,208 @@
+// Package summarystyle defines the style profiles of LLM-written source
+// summaries: how long a summary is, whether it is a paragraph or bullets,
+// and who it is written for. Summarizers resolve a profile per topic or per
+// request and render its prompt; the resolved profile is stored with the
+// source, so a summary can be regenerated the same way later.
+package summarystyle
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/slug"
+)
+
+// Summary formats
+const (
+	FormatParagraph = "paragraph"
+	FormatBullets   = "bullets"
+)
+
+// Summary audiences
+const (
+	AudienceLay       = "lay"
+	AudienceTechnical = "technical"
+)
+
+// DefaultName is the profile used when neither the request nor the topic
+// selects one
+const DefaultName = "one-paragraph-lay"
+
+// Profile is a named summary style
+type Profile struct {
+	Name     string `json:"name"`
+	MaxWords int    `json:"max_words"`
+	Format   string `json:"format"`   // paragraph or bullets
+	Audience string `json:"audience"` // lay or technical
+	// Bullets is the number of bullets of the bullets format; zero leaves
+	// it to the model
+	Bullets int `json:"bullets,omitempty"`
+	// Instructions are appended to the prompt as they are
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// builtins are the profiles available without a profile file
+var builtins = []Profile{
+	{Name: "one-paragraph-lay", MaxWords: 120, Format: FormatParagraph, Audience: AudienceLay},
+	{Name: "bullet-technical", MaxWords: 150, Format: FormatBullets, Audience: AudienceTechnical, Bullets: 5},
+	{Name: "abstract-technical", MaxWords: 200, Format: FormatParagraph, Audience: AudienceTechnical},
+}
+
+// Validate checks that a profile is complete
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile has no name")
+	}
+	if p.MaxWords <= 0 {
+		return fmt.Errorf("profile %s: max_words must be positive", p.Name)
+	}
+	if p.Format != FormatParagraph && p.Format != FormatBullets {
+		return fmt.Errorf("profile %s: format must be %s or %s", p.Name, FormatParagraph, FormatBullets)
+	}
+	if p.Audience != AudienceLay && p.Audience != AudienceTechnical {
+		return fmt.Errorf("profile %s: audience must be %s or %s", p.Name, AudienceLay, AudienceTechnical)
+	}
+	if p.Bullets < 0 {
+		return fmt.Errorf("profile %s: bullets must not be negative", p.Name)
+	}
+	return nil
+}
+
+// Prompt renders the instructions of the profile for a summarizing model
+func (p Profile) Prompt() string {
+	var b strings.Builder
+	b.WriteString("Summarize the source ")
+	if p.Format == FormatBullets {
+		if p.Bullets > 0 {
+			fmt.Fprintf(&b, "as %d bullet points", p.Bullets)
+		} else {
+			b.WriteString("as bullet points")
+		}
+	} else {
+		b.WriteString("in one paragraph")
+	}
+	fmt.Fprintf(&b, " of at most %d words in total, ", p.MaxWords)
+	if p.Audience == AudienceTechnical {
+		b.WriteString("for a technical audience: keep the methods, figures and terminology of the source.")
+	} else {
+		b.WriteString("for a general audience: explain terms a non-specialist would not know, and leave out jargon.")
+	}
+	if p.Instructions != "" {
+		b.WriteString(" ")
+		b.WriteString(strings.TrimSpace(p.Instructions))
+	}
+	return b.String()
+}
+
+// Registry holds the available profiles and the profile of each topic
+type Registry struct {
+	profiles map[string]Profile
+	topics   map[string]string
+	def      string
+}
+
+// Defaults returns a registry of the built-in profiles, with
+// one-paragraph-lay as the default
+func Defaults() *Registry {
+	r := &Registry{profiles: make(map[string]Profile), topics: make(map[string]string), def: DefaultName}
+	for _, p := range builtins {
+		r.profiles[p.Name] = p
+	}
+	return r
+}
+
+// file is the format of a profile file
+type file struct {
+	Default  string            `json:"default"`
+	Profiles []Profile         `json:"profiles"`
+	Topics   map[string]string `json:"topics"` // Topic slug to profile name
+}
+
+// Load reads a JSON profile file. Its profiles are added to the built-in
+// ones (replacing those of the same name), and its topics and default
+// select among them.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary styles: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse summary styles %s: %w", path, err)
+	}
+	r := Defaults()
+	for _, p := range f.Profiles {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("summary styles %s: %w", path, err)
+		}
+		r.profiles[p.Name] = p
+	}
+	for topic, name := range f.Topics {
+		if _, ok := r.profiles[name]; !ok {
+			return nil, fmt.Errorf("summary styles %s: topic %s uses unknown profile %q", path, topic, name)
+		}
+		r.topics[slug.Make(topic)] = name
+	}
+	if f.Default != "" {
+		if _, ok := r.profiles[f.Default]; !ok {
+			return nil, fmt.Errorf("summary styles %s: unknown default profile %q", path, f.Default)
+		}
+		r.def = f.Default
+	}
+	return r, nil
+}
+
+// FromEnv loads the profile file named by KB_SUMMARY_STYLES, or returns the
+// built-in profiles when it is unset
+func FromEnv() (*Registry, error) {
+	if path := os.Getenv("KB_SUMMARY_STYLES"); path != "" {
+		return Load(path)
+	}
+	return Defaults(), nil
+}
+
+// Get returns the profile of a name
+func (r *Registry) Get(name string) (Profile, bool) {
+	p, ok := r.profiles[name]
+	return p, ok
+}
+
+// Resolve returns the profile a summary of a source in a topic is written
+// with: the named one when name is set, else the profile of the topic, else
+// the default
+func (r *Registry) Resolve(name, topic string) (Profile, error) {
+	if name == "" {
+		name = r.topics[topic]
+	}
+	if name == "" {
+		name = r.def
+	}
+	p, ok := r.profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown summary style %q", name)
+	}
+	return p, nil
+}
+
+// Profiles returns the available profiles, sorted by name
+func (r *Registry) Profiles() []Profile {
+	profiles := make([]Profile, 0, len(r.profiles))
+	for _, name := range slices.Sorted(maps.Keys(r.profiles)) {
+		profiles = append(profiles, r.profiles[name])
+	}
+	return profiles
+}
+
+// Topics returns the profile name of each topic that has one
+func (r *Registry) Topics() map[string]string {
+	return maps.Clone(r.topics)
+}
+
+// Default returns the name of the default profile
+func (r *Registry) Default() string {
+	return r.def
+}
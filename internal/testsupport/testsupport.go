@@ -0,0 +1,145 @@
+// Package testsupport provides deterministic fixtures for integration tests:
+// hash embeddings instead of a model server, the in-memory vector store
+// instead of Qdrant, and the full HTTP API served in-process on top of
+// them.
+package testsupport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/demo"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/store/memstore"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+)
+
+// Dimensions is the size of the fixture embeddings
+const Dimensions = embedding.DefaultHashDimensions
+
+// Embedders returns an embedding set of the given models (none for the
+// default model) that embeds by feature hashing: the same text always gets
+// the same vector, and texts sharing words are close
+func Embedders(models ...string) *embedding.Set {
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	return embedding.NewSetWithProvider(embedding.NewHashProvider(Dimensions), models...)
+}
+
+// Vectors returns an empty in-memory vector store with one vector space
+// per model, or a single unnamed vector without models
+func Vectors(models ...string) *memstore.Vectors {
+	spaces := make([]vectordb.VectorSpace, len(models))
+	for i, model := range models {
+		spaces[i] = vectordb.VectorSpace{Name: model, Size: Dimensions}
+	}
+	return memstore.NewVectors(spaces...)
+}
+
+// Options configure a test server
+type Options struct {
+	// Embedding models, one vector space each; none stores a single
+	// unnamed vector
+	Models []string
+	// Store records in a SQLite database in a temporary directory rather
+	// than in memory, to exercise the SQL queries
+	SQLite bool
+	// Load the demo corpus (see internal/demo) before serving
+	Demo bool
+	// Adjusts the dependencies before the server starts, e.g. to set a
+	// Generator or ReadOnly
+	Configure func(*api.Deps)
+}
+
+// Server is the HTTP API served in-process, with the stores behind it
+type Server struct {
+	URL       string
+	DB        store.Store
+	Vectors   *memstore.Vectors
+	Embedders *embedding.Set
+	Client    *http.Client
+}
+
+// NewServer starts the HTTP API on fixture stores; it is stopped, and its
+// database closed, when the test ends
+func NewServer(tb testing.TB, opts Options) *Server {
+	tb.Helper()
+	s := &Server{
+		Vectors:   Vectors(opts.Models...),
+		Embedders: Embedders(opts.Models...),
+	}
+	if opts.SQLite {
+		db, err := database.Open(filepath.Join(tb.TempDir(), "knowledge.sqlite"))
+		if err != nil {
+			tb.Fatalf("open database: %v", err)
+		}
+		tb.Cleanup(func() { db.Close() })
+		s.DB = db
+	} else {
+		s.DB = memstore.New()
+	}
+	if opts.Demo {
+		if _, err := demo.Seed(context.Background(), s.DB, s.Vectors); err != nil {
+			tb.Fatalf("seed demo corpus: %v", err)
+		}
+	}
+
+	deps := api.Deps{DB: s.DB, VectorDB: s.Vectors, Embedders: s.Embedders}
+	if opts.Configure != nil {
+		opts.Configure(&deps)
+	}
+	ts := httptest.NewServer(api.NewServer(deps))
+	tb.Cleanup(ts.Close)
+	s.URL = ts.URL
+	s.Client = ts.Client()
+	return s
+}
+
+// Do sends a request with body encoded as JSON (none if nil) and returns
+// the response status, its body decoded into out when out is not nil
+func (s *Server) Do(tb testing.TB, method, path string, body, out any) int {
+	tb.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatalf("encode %s %s body: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		tb.Fatalf("%s %s: %v", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.DoRequest(tb, req, out)
+}
+
+// DoRequest sends a request, e.g. one with headers, and returns the
+// response status, its body decoded into out when out is not nil
+func (s *Server) DoRequest(tb testing.TB, req *http.Request, out any) int {
+	tb.Helper()
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		tb.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			tb.Fatalf("decode %s %s response (status %d): %v", req.Method, req.URL.Path, resp.StatusCode, err)
+		}
+	}
+	return resp.StatusCode
+}
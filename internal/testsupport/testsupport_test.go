@@ -0,0 +1,47 @@
+package testsupport_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gitopedia/knowledge-base/internal/api"
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/testsupport"
+)
+
+// TestServer checks the fixture wiring: a source written through the
+// in-process API is read back from the store, and found by a search that
+// embeds the query with the hash embedder and scores it in memory
+func TestServer(t *testing.T) {
+	for _, sqlite := range []bool{false, true} {
+		srv := testsupport.NewServer(t, testsupport.Options{SQLite: sqlite})
+
+		var created api.CreateSourceResponse
+		status := srv.Do(t, http.MethodPost, "/sources", api.SourceRequest{
+			URL:     "https://example.com/sleep-spindles",
+			Title:   "Sleep spindles and memory",
+			Topic:   "sleep",
+			Summary: "Sleep spindles consolidate memories during light sleep.",
+		}, &created)
+		if status != http.StatusCreated {
+			t.Fatalf("sqlite=%v: POST /sources: status %d", sqlite, status)
+		}
+
+		var src database.Source
+		if status := srv.Do(t, http.MethodGet, "/sources/"+created.ID, nil, &src); status != http.StatusOK {
+			t.Fatalf("sqlite=%v: GET /sources/%s: status %d", sqlite, created.ID, status)
+		}
+		if src.Title != "Sleep spindles and memory" || src.Topic != "sleep" {
+			t.Errorf("sqlite=%v: GET /sources/%s: got %q in %q", sqlite, created.ID, src.Title, src.Topic)
+		}
+
+		var results api.SearchResponse
+		status = srv.Do(t, http.MethodPost, "/sources/search", api.SearchRequest{Query: "sleep spindles memory"}, &results)
+		if status != http.StatusOK {
+			t.Fatalf("sqlite=%v: POST /sources/search: status %d", sqlite, status)
+		}
+		if len(results.Results) == 0 || results.Results[0].ID != created.ID {
+			t.Errorf("sqlite=%v: POST /sources/search: got %+v, want %s first", sqlite, results.Results, created.ID)
+		}
+	}
+}
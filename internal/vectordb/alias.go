@@ -0,0 +1,138 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Migrated collections are versioned: a migration (cmd/migrate-embeddings)
+// fills new collections named <collection>_<version>, then makes the
+// configured names (sources, articles, ...) aliases of them, so clients
+// switch to the new collections without a restart.
+
+// WithVersion returns a client on the collections of a migration version:
+// it creates, writes and reads <collection>_<version> instead of each
+// configured collection. It shares the connection of c.
+func (c *Client) WithVersion(version string) *Client {
+	return &Client{client: c.client, cfg: c.cfg, version: version}
+}
+
+// CollectionName returns the Qdrant name of a configured collection: the
+// collection itself, or its versioned collection with WithVersion
+func (c *Client) CollectionName(collection string) string {
+	return c.name(collection)
+}
+
+func (c *Client) name(collection string) string {
+	if c.version == "" {
+		return collection
+	}
+	return collection + "_" + c.version
+}
+
+// Aliases returns the collections that the configured collection names are
+// aliases of, by name; names that are collections themselves, or missing,
+// are left out
+func (c *Client) Aliases(ctx context.Context) (map[string]string, error) {
+	aliases, err := c.aliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for alias := range aliases {
+		if !slices.Contains(c.collections(), alias) {
+			delete(aliases, alias)
+		}
+	}
+	return aliases, nil
+}
+
+// aliases returns the collection of every alias of Qdrant, by alias
+func (c *Client) aliases(ctx context.Context) (map[string]string, error) {
+	list, err := c.client.ListAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]string, len(list))
+	for _, a := range list {
+		aliases[a.GetAliasName()] = a.GetCollectionName()
+	}
+	return aliases, nil
+}
+
+// SwitchAliases points the configured collection names at the collections
+// of a version in one request, which Qdrant applies atomically. It returns
+// the collections the names pointed to before.
+//
+// Aliases can't shadow collections: names that are still collections, before
+// the first migration, are deleted first, and are missing until the aliases
+// are created.
+func (c *Client) SwitchAliases(ctx context.Context, version string) ([]string, error) {
+	target := c.WithVersion(version)
+	existing, err := c.client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	aliases, err := c.aliases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	var previous []string
+	var actions []*qdrant.AliasOperations
+	for _, name := range c.collections() {
+		if !slices.Contains(existing, target.name(name)) {
+			return nil, fmt.Errorf("collection %s does not exist", target.name(name))
+		}
+		if old, ok := aliases[name]; ok {
+			previous = append(previous, old)
+			actions = append(actions, qdrant.NewAliasDelete(name))
+		}
+		actions = append(actions, qdrant.NewAliasCreate(name, target.name(name)))
+	}
+	for _, name := range c.collections() {
+		if slices.Contains(existing, name) {
+			log.Printf("Deleting collection %s to replace it with an alias", name)
+			if err := c.client.DeleteCollection(ctx, name); err != nil {
+				return nil, fmt.Errorf("failed to delete collection %s: %w", name, err)
+			}
+		}
+	}
+	if err := c.client.UpdateAliases(ctx, actions); err != nil {
+		return nil, fmt.Errorf("failed to switch aliases: %w", err)
+	}
+	c.mu.Lock()
+	c.distances = nil // Read again from the new collections
+	c.mu.Unlock()
+	return previous, nil
+}
+
+// DropCollections drops the configured collections that exist, the
+// versioned ones with WithVersion
+func (c *Client) DropCollections(ctx context.Context) error {
+	existing, err := c.client.ListCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+	for _, name := range c.collections() {
+		if !slices.Contains(existing, c.name(name)) {
+			continue
+		}
+		if err := c.client.DeleteCollection(ctx, c.name(name)); err != nil {
+			return fmt.Errorf("failed to delete collection %s: %w", c.name(name), err)
+		}
+	}
+	c.mu.Lock()
+	c.distances = nil
+	c.mu.Unlock()
+	return nil
+}
+
+// DeleteCollection drops a collection by its Qdrant name, such as one of
+// those returned by SwitchAliases
+func (c *Client) DeleteCollection(ctx context.Context, name string) error {
+	return c.client.DeleteCollection(ctx, name)
+}
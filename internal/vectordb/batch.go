@@ -0,0 +1,155 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchSize is the number of points sent per upsert by a Batch
+	DefaultBatchSize = 64
+	// DefaultFlushInterval is how long a Batch holds points before sending
+	// them even if it isn't full
+	DefaultFlushInterval = time.Second
+)
+
+// BatchUpserter stores many points per request
+type BatchUpserter interface {
+	UpsertSources(ctx context.Context, points []SourcePoint) error
+	UpsertArticles(ctx context.Context, points []ArticlePoint) error
+}
+
+// Batch buffers upserts and sends them in one request per collection once
+// size points are buffered, or interval after the first of them was added.
+// Errors of background flushes are returned by the next Add, Flush or
+// Close. A Batch is safe for concurrent use.
+type Batch struct {
+	ctx      context.Context
+	upserter BatchUpserter
+	size     int
+	interval time.Duration
+
+	mu       sync.Mutex
+	sources  []SourcePoint
+	articles []ArticlePoint
+	timer    *time.Timer
+	err      error
+}
+
+// NewBatch returns a batch sending points to upserter with ctx. An interval
+// of zero only flushes when the batch is full or Flush is called.
+func NewBatch(ctx context.Context, upserter BatchUpserter, size int, interval time.Duration) *Batch {
+	return &Batch{ctx: ctx, upserter: upserter, size: max(size, 1), interval: interval}
+}
+
+// NewBatch returns a batch configured by the client's BatchSize and
+// FlushInterval
+func (c *Client) NewBatch(ctx context.Context) *Batch {
+	size := c.cfg.BatchSize
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+	return NewBatch(ctx, c, size, c.cfg.FlushInterval)
+}
+
+// AddSource buffers a source, sending the batch if it is full
+func (b *Batch) AddSource(p SourcePoint) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sources = append(b.sources, p)
+	return b.added()
+}
+
+// AddArticle buffers an article, sending the batch if it is full
+func (b *Batch) AddArticle(p ArticlePoint) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.articles = append(b.articles, p)
+	return b.added()
+}
+
+// added flushes a full batch or starts the flush timer; b.mu is held
+func (b *Batch) added() error {
+	if len(b.sources)+len(b.articles) >= b.size {
+		return b.flush()
+	}
+	if b.timer == nil && b.interval > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(b.interval, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// A flush or Close stopped this timer while it fired, and sent
+			// the points; a later timer may be pending for newer ones
+			if b.timer != timer {
+				return
+			}
+			b.timer = nil
+			if err := b.send(); err != nil {
+				b.err = err
+			}
+		})
+		b.timer = timer
+	}
+	return b.takeErr()
+}
+
+// Flush sends the buffered points
+func (b *Batch) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush()
+}
+
+// Close stops the flush timer and sends the buffered points, returning the
+// error of that send or else of the last background flush. A timer firing
+// meanwhile sends nothing, so no error is lost. The batch must not be used
+// afterwards.
+func (b *Batch) Close() error {
+	return b.Flush()
+}
+
+// flush stops the timer and sends the buffered points, returning the error
+// of the send or else of the last background flush; b.mu is held
+func (b *Batch) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	err := b.send()
+	if bgErr := b.takeErr(); err == nil {
+		err = bgErr
+	}
+	return err
+}
+
+// send sends and clears the buffered points; b.mu is held. Points that fail
+// are dropped, as single upserts that fail are.
+func (b *Batch) send() error {
+	var errs []error
+	if len(b.sources) > 0 {
+		if err := b.upserter.UpsertSources(b.ctx, b.sources); err != nil {
+			errs = append(errs, fmt.Errorf("failed to upsert %d sources: %w", len(b.sources), err))
+		}
+		b.sources = nil
+	}
+	if len(b.articles) > 0 {
+		if err := b.upserter.UpsertArticles(b.ctx, b.articles); err != nil {
+			errs = append(errs, fmt.Errorf("failed to upsert %d articles: %w", len(b.articles), err))
+		}
+		b.articles = nil
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// takeErr returns and clears the error of a background flush
+func (b *Batch) takeErr() error {
+	err := b.err
+	b.err = nil
+	return err
+}
@@ -0,0 +1,206 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// ArticleChunksCollection is the collection name for the embeddings of
+// article chunks, present when Config.ArticleChunks is set
+const ArticleChunksCollection = "article_chunks"
+
+// chunkNamespace derives the point IDs of chunks, which have no ID of their own
+var chunkNamespace = uuid.MustParse("6f1c2b1e-5d0a-4c55-9a43-2c1f0d3e8b27")
+
+// ChunkPayload contains the metadata stored alongside chunk embeddings
+type ChunkPayload struct {
+	ArticleID string `json:"article_id"`
+	Title     string `json:"title"`
+	Path      string `json:"path"`
+	Category  string `json:"category"`
+	Chunk     int    `json:"chunk"`             // Position of the chunk in the article
+	Heading   string `json:"heading,omitempty"` // Headings the chunk falls under
+	Text      string `json:"text"`
+}
+
+// ChunkPoint is an article chunk to store with its embeddings
+type ChunkPoint struct {
+	Vectors Vectors
+	Payload ChunkPayload
+}
+
+// chunkPointID returns the point ID of an article's chunk
+func chunkPointID(articleID string, chunk int) string {
+	return uuid.NewSHA1(chunkNamespace, []byte(articleID+"#"+strconv.Itoa(chunk))).String()
+}
+
+// ChunksEnabled reports whether articles are also stored chunk by chunk
+func (c *Client) ChunksEnabled() bool {
+	return c.cfg.ArticleChunks
+}
+
+// ReplaceArticleChunks stores the chunks of an article, removing those of
+// its previous version
+func (c *Client) ReplaceArticleChunks(ctx context.Context, articleID string, points []ChunkPoint) error {
+	if !c.cfg.ArticleChunks {
+		return fmt.Errorf("article chunks are not enabled")
+	}
+	structs := make([]*qdrant.PointStruct, len(points))
+	for i, p := range points {
+		payload := p.Payload
+		payload.ArticleID = articleID
+		pointVectors, err := c.pointVectors(p.Vectors, payload.Title+"\n"+payload.Heading+"\n"+payload.Text)
+		if err != nil {
+			return fmt.Errorf("article %s chunk %d: %w", articleID, payload.Chunk, err)
+		}
+		structs[i] = &qdrant.PointStruct{
+			Id:      qdrant.NewID(chunkPointID(articleID, payload.Chunk)),
+			Vectors: pointVectors,
+			Payload: qdrant.NewValueMap(map[string]interface{}{
+				"article_id": payload.ArticleID,
+				"title":      payload.Title,
+				"path":       payload.Path,
+				"category":   payload.Category,
+				"chunk":      payload.Chunk,
+				"heading":    payload.Heading,
+				"text":       payload.Text,
+			}),
+		}
+	}
+
+	if err := c.deleteArticleChunks(ctx, articleID); err != nil {
+		return err
+	}
+	return c.upsert(ctx, ArticleChunksCollection, structs)
+}
+
+// deleteArticleChunks removes every chunk of an article
+func (c *Client) deleteArticleChunks(ctx context.Context, articleID string) error {
+	return c.write(ctx, func() error {
+		_, err := c.client.Delete(ctx, &qdrant.DeletePoints{
+			CollectionName: c.name(ArticleChunksCollection),
+			Wait:           c.wait(ctx),
+			Points: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+				Must: []*qdrant.Condition{qdrant.NewMatch("article_id", articleID)},
+			}),
+		})
+		return err
+	})
+}
+
+// SearchArticleChunks searches for the article chunks closest to an
+// embedding. Results are chunks, several of which may belong to the same
+// article (payload "article_id").
+func (c *Client) SearchArticleChunks(ctx context.Context, embedding []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
+	if !c.cfg.ArticleChunks {
+		return nil, fmt.Errorf("article chunks are not enabled")
+	}
+	var filter *qdrant.Filter
+	if opts.Category != "" {
+		filter = &qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatch("category", opts.Category),
+			},
+		}
+	}
+	return c.search(ctx, ArticleChunksCollection, embedding, limit, opts, filter)
+}
+
+// ChunkCandidateFactor is how many chunks SearchArticlesByChunk fetches per
+// requested article, since the best chunks often belong to a few articles
+const ChunkCandidateFactor = 5
+
+// ChunkSearcher searches article chunks
+type ChunkSearcher interface {
+	SearchArticleChunks(ctx context.Context, embedding []float32, limit int, opts SearchOptions) ([]SearchResult, error)
+}
+
+// ChunkMatch is an article chunk that matched a search
+type ChunkMatch struct {
+	Chunk   int     `json:"chunk"`
+	Heading string  `json:"heading,omitempty"`
+	Text    string  `json:"text"`
+	Score   float32 `json:"score"`
+}
+
+// ArticleMatch is an article found through its chunks: the parent of the
+// matched chunks, scored by the best of them
+type ArticleMatch struct {
+	ArticleID string
+	Title     string
+	Path      string
+	Category  string
+	Score     float32      // Score of the best chunk
+	Chunks    []ChunkMatch // Matched chunks, best first
+}
+
+// SearchArticlesByChunk searches the chunks closest to an embedding and
+// returns up to limit articles, ranked by their best chunk
+func SearchArticlesByChunk(ctx context.Context, s ChunkSearcher, embedding []float32, limit int, opts SearchOptions) ([]ArticleMatch, error) {
+	hits, err := s.SearchArticleChunks(ctx, embedding, limit*ChunkCandidateFactor, opts)
+	if err != nil {
+		return nil, err
+	}
+	return GroupChunks(hits, limit), nil
+}
+
+// GroupChunks groups chunk hits, ordered by score, by article: the first
+// limit articles are kept, each with all of its hits
+func GroupChunks(hits []SearchResult, limit int) []ArticleMatch {
+	var matches []ArticleMatch
+	index := make(map[string]int)
+	for _, h := range hits {
+		payload := ParseChunkPayload(h.Payload)
+		if payload.ArticleID == "" {
+			continue
+		}
+		i, ok := index[payload.ArticleID]
+		if !ok {
+			if len(matches) == limit {
+				continue
+			}
+			i = len(matches)
+			index[payload.ArticleID] = i
+			matches = append(matches, ArticleMatch{
+				ArticleID: payload.ArticleID,
+				Title:     payload.Title,
+				Path:      payload.Path,
+				Category:  payload.Category,
+				Score:     h.Score,
+			})
+		}
+		matches[i].Chunks = append(matches[i].Chunks, ChunkMatch{
+			Chunk:   payload.Chunk,
+			Heading: payload.Heading,
+			Text:    payload.Text,
+			Score:   h.Score,
+		})
+	}
+	return matches
+}
+
+// ParseChunkPayload reads the payload of a chunk point, as returned by
+// searches and scrolls
+func ParseChunkPayload(payload map[string]interface{}) ChunkPayload {
+	var p ChunkPayload
+	p.ArticleID, _ = payload["article_id"].(string)
+	p.Title, _ = payload["title"].(string)
+	p.Path, _ = payload["path"].(string)
+	p.Category, _ = payload["category"].(string)
+	p.Heading, _ = payload["heading"].(string)
+	p.Text, _ = payload["text"].(string)
+	// Integers come back from Qdrant as int64, and as float64 after JSON
+	switch n := payload["chunk"].(type) {
+	case int64:
+		p.Chunk = int(n)
+	case float64:
+		p.Chunk = int(n)
+	case int:
+		p.Chunk = n
+	}
+	return p
+}
@@ -0,0 +1,138 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// ClaimsCollection is the collection name for the embeddings of claims
+// extracted from source summaries, present when Config.Claims is set
+const ClaimsCollection = "claims"
+
+// claimNamespace derives the point IDs of claims, which have no ID of their own
+var claimNamespace = uuid.MustParse("b3e0a4d2-7c61-4f1a-8d52-9e07c4a1f6d3")
+
+// ClaimPayload contains the metadata stored alongside claim embeddings. The
+// visibility and owner of the source are copied so claim searches filter
+// like source searches.
+type ClaimPayload struct {
+	SourceID   string `json:"source_id"`
+	Title      string `json:"title"`
+	Topic      string `json:"topic"`
+	Claim      int    `json:"claim"` // Position of the claim in the source's claim list
+	Text       string `json:"text"`
+	Visibility string `json:"visibility,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+}
+
+// ClaimPoint is a claim to store with its embeddings
+type ClaimPoint struct {
+	Vectors Vectors
+	Payload ClaimPayload
+}
+
+// claimPointID returns the point ID of a source's claim
+func claimPointID(sourceID string, claim int) string {
+	return uuid.NewSHA1(claimNamespace, []byte(sourceID+"#"+strconv.Itoa(claim))).String()
+}
+
+// ClaimsEnabled reports whether claims are stored
+func (c *Client) ClaimsEnabled() bool {
+	return c.cfg.Claims
+}
+
+// ReplaceSourceClaims stores the claims of a source, removing those
+// extracted before
+func (c *Client) ReplaceSourceClaims(ctx context.Context, sourceID string, points []ClaimPoint) error {
+	if !c.cfg.Claims {
+		return fmt.Errorf("claims are not enabled")
+	}
+	structs := make([]*qdrant.PointStruct, len(points))
+	for i, p := range points {
+		payload := p.Payload
+		payload.SourceID = sourceID
+		pointVectors, err := c.pointVectors(p.Vectors, payload.Text)
+		if err != nil {
+			return fmt.Errorf("source %s claim %d: %w", sourceID, payload.Claim, err)
+		}
+		structs[i] = &qdrant.PointStruct{
+			Id:      qdrant.NewID(claimPointID(sourceID, payload.Claim)),
+			Vectors: pointVectors,
+			Payload: qdrant.NewValueMap(map[string]interface{}{
+				"source_id":  payload.SourceID,
+				"title":      payload.Title,
+				"topic":      payload.Topic,
+				"claim":      payload.Claim,
+				"text":       payload.Text,
+				"visibility": payload.Visibility,
+				"owner":      payload.Owner,
+			}),
+		}
+	}
+
+	if err := c.deleteSourceClaims(ctx, sourceID); err != nil {
+		return err
+	}
+	if len(structs) == 0 {
+		return nil
+	}
+	return c.upsert(ctx, ClaimsCollection, structs)
+}
+
+// deleteSourceClaims removes every claim of a source
+func (c *Client) deleteSourceClaims(ctx context.Context, sourceID string) error {
+	return c.write(ctx, func() error {
+		_, err := c.client.Delete(ctx, &qdrant.DeletePoints{
+			CollectionName: c.name(ClaimsCollection),
+			Wait:           c.wait(ctx),
+			Points: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+				Must: []*qdrant.Condition{qdrant.NewMatch("source_id", sourceID)},
+			}),
+		})
+		return err
+	})
+}
+
+// SearchClaims searches for the claims closest to an embedding among the
+// claims of live sources visible to opts.User, optionally in opts.Topic and
+// its subtopics. Several results may belong to the same source (payload
+// "source_id").
+func (c *Client) SearchClaims(ctx context.Context, embedding []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
+	if !c.cfg.Claims {
+		return nil, fmt.Errorf("claims are not enabled")
+	}
+	filter := &qdrant.Filter{
+		Must:    []*qdrant.Condition{visibilityCondition(opts.User)},
+		MustNot: notDeleted(),
+	}
+	if opts.Topic != "" {
+		filter.Must = append(filter.Must, opts.topicCondition())
+	}
+	return c.search(ctx, ClaimsCollection, embedding, limit, opts, filter)
+}
+
+// ParseClaimPayload reads the payload of a claim point, as returned by
+// searches
+func ParseClaimPayload(payload map[string]interface{}) ClaimPayload {
+	var p ClaimPayload
+	p.SourceID, _ = payload["source_id"].(string)
+	p.Title, _ = payload["title"].(string)
+	p.Topic, _ = payload["topic"].(string)
+	p.Text, _ = payload["text"].(string)
+	p.Visibility, _ = payload["visibility"].(string)
+	p.Owner, _ = payload["owner"].(string)
+	// Integers come back from Qdrant as int64, and as float64 after JSON
+	switch n := payload["claim"].(type) {
+	case int64:
+		p.Claim = int(n)
+	case float64:
+		p.Claim = int(n)
+	case int:
+		p.Claim = n
+	}
+	return p
+}
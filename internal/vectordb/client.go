@@ -5,10 +5,17 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gitopedia/knowledge-base/internal/embedding"
 	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -22,9 +29,61 @@ const (
 
 // Client provides vector database operations via Qdrant
 type Client struct {
-	client *qdrant.Client
+	client   *qdrant.Client
+	cfg      Config
+	counters writeCounters
+	version  string // Suffix of the collections of a migration (see WithVersion)
+
+	mu        sync.RWMutex
+	distances map[string]Distance // Metrics of existing collections, by name
+}
+
+// Config holds the connection and collection settings of a Client
+type Config struct {
+	Host string
+	Port int
+	// VectorSpaces lists the named dense vectors per point. When empty,
+	// points carry a single unnamed vector of VectorSize.
+	VectorSpaces []VectorSpace
+	// VectorSize is the dimension of the unnamed vector; 0 means
+	// DefaultVectorSize
+	VectorSize uint64
+	// Sparse stores a BM25 sparse vector alongside the dense vectors and
+	// enables hybrid (dense + keyword) search.
+	Sparse bool
+	// BatchSize and FlushInterval configure the batches of NewBatch
+	BatchSize     int
+	FlushInterval time.Duration
+	// Retry is the retry policy of upserts and deletes
+	Retry RetryPolicy
+	// Wait makes every upsert and delete wait until Qdrant has applied it
+	// (see also WithWait)
+	Wait bool
+	// Collection tunes the HNSW index, storage and distribution of created
+	// collections
+	Collection CollectionConfig
+	// ArticleChunks adds the article_chunks collection, which stores one
+	// point per chunk of an article body
+	ArticleChunks bool
+	// Claims adds the claims collection, which stores one point per claim
+	// extracted from a source summary
+	Claims bool
+	// Multivector adds token vectors for late-interaction search
+	// (experimental); it requires named vector spaces
+	Multivector MultivectorConfig
 }
 
+// VectorSpace describes a named vector stored on every point. The name is the
+// embedding model that produced the vector, so several models can coexist in
+// one collection.
+type VectorSpace struct {
+	Name string
+	Size uint64
+}
+
+// Vectors maps an embedding model (vector space name) to its embedding
+type Vectors map[string][]float32
+
 // SourcePayload contains the metadata stored alongside source embeddings
 type SourcePayload struct {
 	ID        string `json:"id"`
@@ -34,17 +93,44 @@ type SourcePayload struct {
 	Summary   string `json:"summary"`
 	Language  string `json:"language,omitempty"`
 	Model     string `json:"model,omitempty"`
-	CreatedAt string `json:"created_at"`
+	CreatedAt string `json:"created_at"` // RFC 3339, filtered by SearchOptions.CreatedAfter/Before
+	// RFC 3339, filtered by SearchOptions.PublishedAfter/Before
+	PublishedAt string   `json:"published_at,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Locations of the places the source is about, searched by geo radius
+	Locations  []GeoPoint `json:"location,omitempty"`
+	Visibility string     `json:"visibility,omitempty"`
+	Owner      string     `json:"owner,omitempty"`
+	Domain     string     `json:"domain,omitempty"`
+	WordCount  int        `json:"word_count,omitempty"` // Words in the summary
+	Type       string     `json:"type,omitempty"`       // paper, blog, video, or dataset
+	HasCode    bool       `json:"has_code,omitempty"`   // Links to a code repository
+	HasDataset bool       `json:"has_dataset,omitempty"`
 }
 
 // ArticlePayload contains the metadata stored alongside article embeddings
 type ArticlePayload struct {
-	ID       string   `json:"id"`
-	Title    string   `json:"title"`
-	Path     string   `json:"path"`
-	Summary  string   `json:"summary"`
-	Tags     []string `json:"tags"`
-	Category string   `json:"category"`
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Path      string   `json:"path"`
+	Summary   string   `json:"summary"`
+	Tags      []string `json:"tags"`
+	Category  string   `json:"category"`
+	WordCount int      `json:"word_count,omitempty"` // Words in the content
+}
+
+// SourcePoint is a source to store with its embeddings
+type SourcePoint struct {
+	ID      string
+	Vectors Vectors
+	Payload SourcePayload
+}
+
+// ArticlePoint is an article to store with its embeddings
+type ArticlePoint struct {
+	ID      string
+	Vectors Vectors
+	Payload ArticlePayload
 }
 
 // SearchResult represents a search result with score and payload
@@ -54,8 +140,121 @@ type SearchResult struct {
 	Payload map[string]interface{}
 }
 
-// NewClient creates a new Qdrant client
+// SearchOptions narrows a vector search
+type SearchOptions struct {
+	Vector string // Vector space to query; empty selects the default
+	Topic  string // Source topic filter
+	Domain string // Source URL domain filter
+	Type   string // Source type filter
+	// Only sources linking to code repositories or datasets
+	HasCode    bool
+	HasDataset bool
+	// Tags keeps sources with any of the tags, or all of them with AllTags
+	Tags     []string
+	AllTags  bool
+	Language string // Source language filter (e.g. de)
+	// CreatedAfter and CreatedBefore keep sources created at or after, and
+	// before, the given times; zero times are open ends
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// PublishedAfter and PublishedBefore bound the publication time the
+	// same way; undated sources never match them
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+	// Near keeps sources with a location within the circle
+	Near     *GeoRadius
+	Category string // Article category filter
+	Text     string // Query text for hybrid search (used when sparse vectors are enabled)
+	// LateInteraction is the query text whose token vectors rescore the
+	// candidates of the search (see Config.Multivector); empty skips it
+	LateInteraction string
+	User            string // Caller identity; sources are restricted to those visible to it
+	// HNSWEf is the size of the HNSW candidate list (0 for the collection's
+	// default): larger values improve recall at the cost of latency
+	HNSWEf uint64
+	// Exact scores every point instead of walking the HNSW index, for ground
+	// truth when evaluating recall
+	Exact bool
+
+	// Subtopics are matched along with Topic: the topics below it in the
+	// taxonomy and their aliases
+	Subtopics []string
+}
+
+// MatchesTopic reports whether a source topic passes the topic filter
+func (opts SearchOptions) MatchesTopic(topic string) bool {
+	return opts.Topic == "" || topic == opts.Topic || slices.Contains(opts.Subtopics, topic)
+}
+
+// topicCondition matches the topic of the filter or one of its subtopics
+func (opts SearchOptions) topicCondition() *qdrant.Condition {
+	if len(opts.Subtopics) == 0 {
+		return qdrant.NewMatch("topic", opts.Topic)
+	}
+	return qdrant.NewMatchKeywords("topic", append([]string{opts.Topic}, opts.Subtopics...)...)
+}
+
+// searchParams returns the Qdrant search parameters of opts, or nil for the
+// defaults
+func (opts SearchOptions) searchParams() *qdrant.SearchParams {
+	if opts.HNSWEf == 0 && !opts.Exact {
+		return nil
+	}
+	params := &qdrant.SearchParams{}
+	if opts.HNSWEf > 0 {
+		params.HnswEf = qdrant.PtrOf(opts.HNSWEf)
+	}
+	if opts.Exact {
+		params.Exact = qdrant.PtrOf(true)
+	}
+	return params
+}
+
+// ParseVectorSpaces parses a list of vector spaces in the form
+// "model=size,model=size" (e.g. "nomic-embed-text=768,bge-m3=1024").
+func ParseVectorSpaces(s string) ([]VectorSpace, error) {
+	var spaces []VectorSpace
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, sizeStr, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid vector space %q: expected model=size", part)
+		}
+		size, err := strconv.ParseUint(strings.TrimSpace(sizeStr), 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid vector size for %q", name)
+		}
+		spaces = append(spaces, VectorSpace{Name: strings.TrimSpace(name), Size: size})
+	}
+	return spaces, nil
+}
+
+// NewClient creates a new Qdrant client configured from the environment
 func NewClient() (*Client, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFromConfig(cfg)
+}
+
+// ConfigFromEnv reads the client configuration from environment variables.
+// When EMBEDDING_MODELS is set, collections use one named vector per model;
+// QDRANT_SPARSE=true adds BM25 sparse vectors for hybrid search.
+// QDRANT_BATCH_SIZE and QDRANT_FLUSH_INTERVAL (a duration such as "2s")
+// configure batched upserts, QDRANT_WRITE_RETRIES and QDRANT_RETRY_BACKOFF
+// the retries of failed writes, and QDRANT_WAIT=true makes writes wait
+// until they are applied. QDRANT_VECTOR_SIZE is the dimension of the
+// unnamed vector without EMBEDDING_MODELS. QDRANT_ARTICLE_CHUNKS=true adds the
+// article_chunks collection, QDRANT_CLAIMS=true the claims collection.
+// QDRANT_MULTIVECTOR ("model=size") adds token
+// vectors from the service at TOKEN_EMBEDDING_URL to the collections in
+// QDRANT_MULTIVECTOR_COLLECTIONS (default sources). See
+// collectionConfigFromEnv for the collection settings.
+func ConfigFromEnv() (Config, error) {
 	host := os.Getenv("QDRANT_HOST")
 	if host == "" {
 		host = "localhost"
@@ -69,35 +268,150 @@ func NewClient() (*Client, error) {
 		}
 	}
 
-	client, err := qdrant.NewClient(&qdrant.Config{
-		Host: host,
-		Port: port,
-	})
+	spaces, err := ParseVectorSpaces(os.Getenv("EMBEDDING_MODELS"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create qdrant client: %w", err)
+		return Config{}, fmt.Errorf("invalid EMBEDDING_MODELS: %w", err)
 	}
 
-	return &Client{client: client}, nil
+	var vectorSize uint64
+	if s := os.Getenv("QDRANT_VECTOR_SIZE"); s != "" {
+		if vectorSize, err = strconv.ParseUint(s, 10, 64); err != nil || vectorSize == 0 {
+			return Config{}, fmt.Errorf("invalid QDRANT_VECTOR_SIZE: %s", s)
+		}
+	}
+
+	sparse, _ := strconv.ParseBool(os.Getenv("QDRANT_SPARSE"))
+
+	batchSize := DefaultBatchSize
+	if s := os.Getenv("QDRANT_BATCH_SIZE"); s != "" {
+		if batchSize, err = strconv.Atoi(s); err != nil || batchSize < 1 {
+			return Config{}, fmt.Errorf("invalid QDRANT_BATCH_SIZE: %s", s)
+		}
+	}
+	flushInterval := DefaultFlushInterval
+	if s := os.Getenv("QDRANT_FLUSH_INTERVAL"); s != "" {
+		if flushInterval, err = time.ParseDuration(s); err != nil || flushInterval < 0 {
+			return Config{}, fmt.Errorf("invalid QDRANT_FLUSH_INTERVAL: %s", s)
+		}
+	}
+
+	retry, err := retryPolicyFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	wait, _ := strconv.ParseBool(os.Getenv("QDRANT_WAIT"))
+	collection, err := collectionConfigFromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+	chunks, _ := strconv.ParseBool(os.Getenv("QDRANT_ARTICLE_CHUNKS"))
+	claims, _ := strconv.ParseBool(os.Getenv("QDRANT_CLAIMS"))
+	multivector, err := multivectorConfigFromEnv(spaces)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return Config{
+		Host:          host,
+		Port:          port,
+		VectorSpaces:  spaces,
+		VectorSize:    vectorSize,
+		Sparse:        sparse,
+		BatchSize:     batchSize,
+		FlushInterval: flushInterval,
+		Retry:         retry,
+		Wait:          wait,
+		Collection:    collection,
+		ArticleChunks: chunks,
+		Claims:        claims,
+		Multivector:   multivector,
+	}, nil
 }
 
-// NewClientWithConfig creates a new Qdrant client with explicit configuration
-func NewClientWithConfig(host string, port int) (*Client, error) {
+// multivectorConfigFromEnv reads the late-interaction mode from
+// QDRANT_MULTIVECTOR and QDRANT_MULTIVECTOR_COLLECTIONS
+func multivectorConfigFromEnv(spaces []VectorSpace) (MultivectorConfig, error) {
+	var mc MultivectorConfig
+	s := os.Getenv("QDRANT_MULTIVECTOR")
+	if s == "" {
+		return mc, nil
+	}
+	parsed, err := ParseVectorSpaces(s)
+	if err != nil || len(parsed) != 1 {
+		return mc, fmt.Errorf("invalid QDRANT_MULTIVECTOR: expected one model=size, got %q", s)
+	}
+	if len(spaces) == 0 {
+		return mc, fmt.Errorf("QDRANT_MULTIVECTOR requires named vector spaces (EMBEDDING_MODELS)")
+	}
+	if parsed[0].Name == SparseVectorName || slices.ContainsFunc(spaces, func(space VectorSpace) bool { return space.Name == parsed[0].Name }) {
+		return mc, fmt.Errorf("QDRANT_MULTIVECTOR model %s is already a vector space", parsed[0].Name)
+	}
+	mc.Space = parsed[0]
+
+	mc.Collections = []string{SourcesCollection}
+	if s := os.Getenv("QDRANT_MULTIVECTOR_COLLECTIONS"); s != "" {
+		if mc.Collections, err = parseMultivectorCollections(s); err != nil {
+			return mc, fmt.Errorf("invalid QDRANT_MULTIVECTOR_COLLECTIONS: %w", err)
+		}
+	}
+	mc.Tokens = embedding.NewTokenClient(mc.Space.Name)
+	return mc, nil
+}
+
+// NewClientWithConfig creates a new Qdrant client with explicit configuration.
+// Without vector spaces, points carry a single unnamed vector.
+func NewClientWithConfig(host string, port int, spaces ...VectorSpace) (*Client, error) {
+	return NewClientFromConfig(Config{Host: host, Port: port, VectorSpaces: spaces, Retry: DefaultRetryPolicy})
+}
+
+// NewClientFromConfig creates a new Qdrant client from a Config
+func NewClientFromConfig(cfg Config) (*Client, error) {
 	client, err := qdrant.NewClient(&qdrant.Config{
-		Host: host,
-		Port: port,
+		Host: cfg.Host,
+		Port: cfg.Port,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create qdrant client: %w", err)
 	}
 
-	return &Client{client: client}, nil
+	return &Client{client: client, cfg: cfg}, nil
 }
 
-// EnsureCollections creates the required collections if they don't exist
-func (c *Client) EnsureCollections(ctx context.Context) error {
+// SparseEnabled reports whether points carry BM25 sparse vectors
+func (c *Client) SparseEnabled() bool {
+	return c.cfg.Sparse
+}
+
+// VectorSpaces returns the configured named vector spaces (empty when points
+// carry a single unnamed vector)
+func (c *Client) VectorSpaces() []VectorSpace {
+	return c.cfg.VectorSpaces
+}
+
+// VectorNames returns the names of the configured vector spaces
+func (c *Client) VectorNames() []string {
+	names := make([]string, len(c.cfg.VectorSpaces))
+	for i, space := range c.cfg.VectorSpaces {
+		names[i] = space.Name
+	}
+	return names
+}
+
+// collections returns the names of the configured collections
+func (c *Client) collections() []string {
 	collections := []string{SourcesCollection, ArticlesCollection}
+	if c.cfg.ArticleChunks {
+		collections = append(collections, ArticleChunksCollection)
+	}
+	if c.cfg.Claims {
+		collections = append(collections, ClaimsCollection)
+	}
+	return collections
+}
 
-	for _, name := range collections {
+// EnsureCollections creates the required collections if they don't exist
+func (c *Client) EnsureCollections(ctx context.Context) error {
+	for _, name := range c.collections() {
 		exists, err := c.collectionExists(ctx, name)
 		if err != nil {
 			return fmt.Errorf("failed to check collection %s: %w", name, err)
@@ -107,124 +421,615 @@ func (c *Client) EnsureCollections(ctx context.Context) error {
 			if err := c.createCollection(ctx, name); err != nil {
 				return fmt.Errorf("failed to create collection %s: %w", name, err)
 			}
+			continue
+		}
+		if err := c.checkDistance(ctx, name); err != nil {
+			return fmt.Errorf("failed to read collection %s: %w", name, err)
+		}
+	}
+
+	return c.ensurePayloadIndexes(ctx)
+}
+
+// Distance returns the metric of a collection: the one it was created with,
+// as read by EnsureCollections, otherwise the configured one
+func (c *Client) Distance(collection string) Distance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if d, ok := c.distances[collection]; ok {
+		return d
+	}
+	return c.cfg.Collection.distance(collection)
+}
+
+// checkDistance reads the metric an existing collection was created with.
+// The metric can't be changed without recreating the collection, so a
+// different configured metric only gets a warning.
+func (c *Client) checkDistance(ctx context.Context, name string) error {
+	info, err := c.client.GetCollectionInfo(ctx, c.name(name))
+	if err != nil {
+		return err
+	}
+	vectors := info.GetConfig().GetParams().GetVectorsConfig()
+	params := vectors.GetParams()
+	if m := vectors.GetParamsMap().GetMap(); m != nil {
+		if len(c.cfg.VectorSpaces) > 0 {
+			params = m[c.cfg.VectorSpaces[0].Name]
+		}
+	}
+	if params == nil {
+		return nil
+	}
+	actual := distanceFromQdrant(params.GetDistance())
+	if actual == "" {
+		return nil
+	}
+	if configured := c.cfg.Collection.distance(name); actual != configured {
+		log.Printf("Warning: collection %s uses %s distance, not the configured %s; recreate it (rebuild -embeddings) to switch",
+			name, actual, configured)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.distances == nil {
+		c.distances = make(map[string]Distance)
+	}
+	c.distances[name] = actual
+	return nil
+}
+
+// sourceIndexedFields are the source payload fields with keyword indexes,
+// used by filters and required by facet counts
+var sourceIndexedFields = []string{"topic", "domain", "type", "language", "tags"}
+
+// sourceBoolFields are the source payload flags with bool indexes
+var sourceBoolFields = []string{"has_code", "has_dataset"}
+
+// ensurePayloadIndexes creates the payload indexes of the sources
+// collection, and the article_id and source_id indexes that chunks and
+// claims are replaced and deleted by. Creating an index that already exists is a no-op.
+func (c *Client) ensurePayloadIndexes(ctx context.Context) error {
+	fields := make(map[string]qdrant.FieldType)
+	for _, field := range sourceIndexedFields {
+		fields[field] = qdrant.FieldType_FieldTypeKeyword
+	}
+	for _, field := range sourceBoolFields {
+		fields[field] = qdrant.FieldType_FieldTypeBool
+	}
+	fields["created_at"] = qdrant.FieldType_FieldTypeDatetime
+	fields["published_at"] = qdrant.FieldType_FieldTypeDatetime
+	fields["location"] = qdrant.FieldType_FieldTypeGeo
+	for field, fieldType := range fields {
+		_, err := c.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: c.name(SourcesCollection),
+			FieldName:      field,
+			FieldType:      fieldType.Enum(),
+			Wait:           qdrant.PtrOf(true),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index source field %s: %w", field, err)
+		}
+	}
+	if c.cfg.ArticleChunks {
+		_, err := c.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: c.name(ArticleChunksCollection),
+			FieldName:      "article_id",
+			FieldType:      qdrant.FieldType_FieldTypeKeyword.Enum(),
+			Wait:           qdrant.PtrOf(true),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index chunk field article_id: %w", err)
+		}
+	}
+	if c.cfg.Claims {
+		for _, field := range []string{"source_id", "topic"} {
+			_, err := c.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+				CollectionName: c.name(ClaimsCollection),
+				FieldName:      field,
+				FieldType:      qdrant.FieldType_FieldTypeKeyword.Enum(),
+				Wait:           qdrant.PtrOf(true),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to index claim field %s: %w", field, err)
+			}
 		}
 	}
+	return nil
+}
 
+// RecreateCollections drops the collections, if present, and creates them
+// empty with the current configuration. A collection name that is the alias
+// of a migrated collection is dropped with the collection it points to.
+func (c *Client) RecreateCollections(ctx context.Context) error {
+	aliases, err := c.aliases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list aliases: %w", err)
+	}
+	for _, name := range c.collections() {
+		if target, ok := aliases[c.name(name)]; ok {
+			if err := c.client.DeleteAlias(ctx, c.name(name)); err != nil {
+				return fmt.Errorf("failed to delete alias %s: %w", name, err)
+			}
+			if err := c.client.DeleteCollection(ctx, target); err != nil {
+				return fmt.Errorf("failed to delete collection %s: %w", target, err)
+			}
+		}
+		exists, err := c.collectionExists(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check collection %s: %w", name, err)
+		}
+		if exists {
+			if err := c.client.DeleteCollection(ctx, c.name(name)); err != nil {
+				return fmt.Errorf("failed to delete collection %s: %w", name, err)
+			}
+		}
+		if err := c.createCollection(ctx, name); err != nil {
+			return fmt.Errorf("failed to create collection %s: %w", name, err)
+		}
+		c.mu.Lock()
+		delete(c.distances, name) // Created with the configured metric
+		c.mu.Unlock()
+	}
+	return c.ensurePayloadIndexes(ctx)
+}
+
+// Ping checks that Qdrant answers and has every configured collection
+func (c *Client) Ping(ctx context.Context) error {
+	existing, err := c.collectionNames(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range c.collections() {
+		if !slices.Contains(existing, c.name(name)) {
+			return fmt.Errorf("collection %s does not exist", c.name(name))
+		}
+	}
 	return nil
 }
 
+// collectionExists reports whether a configured collection exists, as a
+// collection or as the alias of one
 func (c *Client) collectionExists(ctx context.Context, name string) (bool, error) {
-	collections, err := c.client.ListCollections(ctx)
+	existing, err := c.collectionNames(ctx)
 	if err != nil {
 		return false, err
 	}
+	return slices.Contains(existing, c.name(name)), nil
+}
 
-	for _, col := range collections {
-		if col == name {
-			return true, nil
-		}
+// collectionNames returns the names of the collections of Qdrant and of
+// their aliases
+func (c *Client) collectionNames(ctx context.Context) ([]string, error) {
+	names, err := c.client.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := c.aliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for alias := range aliases {
+		names = append(names, alias)
 	}
-	return false, nil
+	return names, nil
 }
 
 func (c *Client) createCollection(ctx context.Context, name string) error {
-	return c.client.CreateCollection(ctx, &qdrant.CreateCollection{
-		CollectionName: name,
-		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
-			Size:     DefaultVectorSize,
-			Distance: qdrant.Distance_Cosine,
-		}),
-	})
+	size := c.cfg.VectorSize
+	if size == 0 {
+		size = DefaultVectorSize
+	}
+	vectorsConfig := qdrant.NewVectorsConfig(c.cfg.Collection.vectorParams(name, size))
+	if len(c.cfg.VectorSpaces) > 0 {
+		params := make(map[string]*qdrant.VectorParams, len(c.cfg.VectorSpaces))
+		for _, space := range c.cfg.VectorSpaces {
+			params[space.Name] = c.cfg.Collection.vectorParams(name, space.Size)
+		}
+		if c.cfg.Multivector.enabled(name) {
+			params[c.cfg.Multivector.Space.Name] = c.multivectorParams()
+		}
+		vectorsConfig = qdrant.NewVectorsConfigMap(params)
+	}
+
+	create := &qdrant.CreateCollection{
+		CollectionName: c.name(name),
+		VectorsConfig:  vectorsConfig,
+	}
+	c.cfg.Collection.apply(create)
+	if c.cfg.Sparse {
+		create.SparseVectorsConfig = qdrant.NewSparseVectorsConfig(map[string]*qdrant.SparseVectorParams{
+			SparseVectorName: {Modifier: qdrant.Modifier_Idf.Enum()},
+		})
+	}
+
+	return c.client.CreateCollection(ctx, create)
+}
+
+// pointVectors builds the vectors of a point. With named vector spaces every
+// entry is stored under its model name; otherwise the single embedding is
+// stored as the unnamed vector. When sparse vectors are enabled, text is
+// encoded as the BM25 sparse vector.
+func (c *Client) pointVectors(vectors Vectors, text string) (*qdrant.Vectors, error) {
+	named := make(map[string]*qdrant.Vector, len(vectors)+1)
+	if len(c.cfg.VectorSpaces) == 0 {
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("expected one embedding, got %d", len(vectors))
+		}
+		for _, emb := range vectors {
+			if !c.cfg.Sparse {
+				return qdrant.NewVectorsDense(emb), nil
+			}
+			named[""] = qdrant.NewVectorDense(emb)
+		}
+	} else {
+		for name, emb := range vectors {
+			if !c.hasSpace(name) {
+				return nil, fmt.Errorf("unknown vector space: %s", name)
+			}
+			named[name] = qdrant.NewVectorDense(emb)
+		}
+	}
+
+	if c.cfg.Sparse {
+		sparse := EncodeSparse(text)
+		named[SparseVectorName] = qdrant.NewVectorSparse(sparse.Indices, sparse.Values)
+	}
+	return qdrant.NewVectorsMap(named), nil
 }
 
-// UpsertSource stores or updates a source embedding
+// using returns the vector name to query, or nil for the unnamed vector
+func (c *Client) using(vector string) (*string, error) {
+	if len(c.cfg.VectorSpaces) == 0 {
+		return nil, nil
+	}
+	if vector == "" {
+		return qdrant.PtrOf(c.cfg.VectorSpaces[0].Name), nil
+	}
+	if !c.hasSpace(vector) {
+		return nil, fmt.Errorf("unknown vector space: %s", vector)
+	}
+	return qdrant.PtrOf(vector), nil
+}
+
+func (c *Client) hasSpace(name string) bool {
+	for _, space := range c.cfg.VectorSpaces {
+		if space.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UpsertSource stores or updates a source embedding in the default vector space
 func (c *Client) UpsertSource(ctx context.Context, id string, embedding []float32, payload SourcePayload) error {
-	point := &qdrant.PointStruct{
-		Id:      qdrant.NewID(toUUID(id)),
-		Vectors: qdrant.NewVectors(embedding...),
-		Payload: qdrant.NewValueMap(map[string]interface{}{
-			"id":         payload.ID,
-			"url":        payload.URL,
-			"title":      payload.Title,
-			"topic":      payload.Topic,
-			"summary":    payload.Summary,
-			"language":   payload.Language,
-			"model":      payload.Model,
-			"created_at": payload.CreatedAt,
-		}),
-	}
-
-	_, err := c.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: SourcesCollection,
-		Points:         []*qdrant.PointStruct{point},
-	})
-	return err
+	return c.UpsertSourceVectors(ctx, id, c.defaultVectors(embedding), payload)
+}
+
+// UpsertSourceVectors stores or updates a source with one embedding per vector space
+func (c *Client) UpsertSourceVectors(ctx context.Context, id string, vectors Vectors, payload SourcePayload) error {
+	return c.UpsertSources(ctx, []SourcePoint{{ID: id, Vectors: vectors, Payload: payload}})
 }
 
-// UpsertArticle stores or updates an article embedding
+// UpsertSources stores or updates sources in a single request
+func (c *Client) UpsertSources(ctx context.Context, points []SourcePoint) error {
+	structs := make([]*qdrant.PointStruct, len(points))
+	for i, p := range points {
+		point, err := c.sourcePoint(ctx, p)
+		if err != nil {
+			return fmt.Errorf("source %s: %w", p.ID, err)
+		}
+		structs[i] = point
+	}
+	return c.upsert(ctx, SourcesCollection, structs)
+}
+
+func (c *Client) sourcePoint(ctx context.Context, p SourcePoint) (*qdrant.PointStruct, error) {
+	payload := p.Payload
+	text := payload.Title + "\n" + payload.Summary
+	pointVectors, err := c.pointVectors(p.Vectors, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addMultivector(ctx, SourcesCollection, pointVectors, text); err != nil {
+		return nil, err
+	}
+
+	return &qdrant.PointStruct{
+		Id:      qdrant.NewID(toUUID(p.ID)),
+		Vectors: pointVectors,
+		Payload: qdrant.NewValueMap(payload.Values()),
+	}, nil
+}
+
+// UpsertArticle stores or updates an article embedding in the default vector space
 func (c *Client) UpsertArticle(ctx context.Context, id string, embedding []float32, payload ArticlePayload) error {
-	point := &qdrant.PointStruct{
-		Id:      qdrant.NewID(toUUID(id)),
-		Vectors: qdrant.NewVectors(embedding...),
-		Payload: qdrant.NewValueMap(map[string]interface{}{
-			"id":       payload.ID,
-			"title":    payload.Title,
-			"path":     payload.Path,
-			"summary":  payload.Summary,
-			"tags":     payload.Tags,
-			"category": payload.Category,
-		}),
-	}
-
-	_, err := c.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: ArticlesCollection,
-		Points:         []*qdrant.PointStruct{point},
+	return c.UpsertArticleVectors(ctx, id, c.defaultVectors(embedding), payload)
+}
+
+// UpsertArticleVectors stores or updates an article with one embedding per vector space
+func (c *Client) UpsertArticleVectors(ctx context.Context, id string, vectors Vectors, payload ArticlePayload) error {
+	return c.UpsertArticles(ctx, []ArticlePoint{{ID: id, Vectors: vectors, Payload: payload}})
+}
+
+// UpsertArticles stores or updates articles in a single request
+func (c *Client) UpsertArticles(ctx context.Context, points []ArticlePoint) error {
+	structs := make([]*qdrant.PointStruct, len(points))
+	for i, p := range points {
+		point, err := c.articlePoint(ctx, p)
+		if err != nil {
+			return fmt.Errorf("article %s: %w", p.ID, err)
+		}
+		structs[i] = point
+	}
+	return c.upsert(ctx, ArticlesCollection, structs)
+}
+
+func (c *Client) articlePoint(ctx context.Context, p ArticlePoint) (*qdrant.PointStruct, error) {
+	payload := p.Payload
+	text := payload.Title + "\n" + payload.Summary + "\n" + strings.Join(payload.Tags, " ")
+	pointVectors, err := c.pointVectors(p.Vectors, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addMultivector(ctx, ArticlesCollection, pointVectors, text); err != nil {
+		return nil, err
+	}
+
+	return &qdrant.PointStruct{
+		Id:      qdrant.NewID(toUUID(p.ID)),
+		Vectors: pointVectors,
+		Payload: qdrant.NewValueMap(payload.Values()),
+	}, nil
+}
+
+func (c *Client) upsert(ctx context.Context, collection string, points []*qdrant.PointStruct) error {
+	if len(points) == 0 {
+		return nil
+	}
+	return c.write(ctx, func() error {
+		_, err := c.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: c.name(collection),
+			Wait:           c.wait(ctx),
+			Points:         points,
+		})
+		return err
 	})
-	return err
+}
+
+// defaultVectors wraps a single embedding for the default vector space
+func (c *Client) defaultVectors(embedding []float32) Vectors {
+	name := ""
+	if len(c.cfg.VectorSpaces) > 0 {
+		name = c.cfg.VectorSpaces[0].Name
+	}
+	return Vectors{name: embedding}
 }
 
 // SearchSources searches for similar sources using vector similarity
-func (c *Client) SearchSources(ctx context.Context, embedding []float32, limit int, topicFilter string) ([]SearchResult, error) {
-	query := &qdrant.QueryPoints{
-		CollectionName: SourcesCollection,
-		Query:          qdrant.NewQuery(embedding...),
+func (c *Client) SearchSources(ctx context.Context, embedding []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
+	return c.search(ctx, SourcesCollection, embedding, limit, opts, sourceFilter(opts))
+}
+
+// recommendStrategies maps API strategy names to Qdrant recommend strategies
+var recommendStrategies = map[string]qdrant.RecommendStrategy{
+	"average_vector": qdrant.RecommendStrategy_AverageVector,
+	"best_score":     qdrant.RecommendStrategy_BestScore,
+	"sum_scores":     qdrant.RecommendStrategy_SumScores,
+}
+
+// ValidRecommendStrategy reports whether strategy is empty (the default,
+// average_vector) or a strategy RecommendSources knows
+func ValidRecommendStrategy(strategy string) bool {
+	_, ok := recommendStrategies[strategy]
+	return strategy == "" || ok
+}
+
+// RecommendSources finds sources similar to the positive examples and
+// dissimilar to the negative ones, using the stored vectors of the given
+// source IDs. The examples themselves are excluded from the results.
+func (c *Client) RecommendSources(ctx context.Context, positive, negative []string, strategy string, limit int, opts SearchOptions) ([]SearchResult, error) {
+	if len(positive) == 0 {
+		return nil, fmt.Errorf("at least one positive example is required")
+	}
+
+	using, err := c.using(opts.Vector)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &qdrant.RecommendInput{
+		Positive: idInputs(positive),
+		Negative: idInputs(negative),
+	}
+	if strategy != "" {
+		value, ok := recommendStrategies[strategy]
+		if !ok {
+			return nil, fmt.Errorf("unknown recommend strategy: %s", strategy)
+		}
+		input.Strategy = value.Enum()
+	}
+
+	results, err := c.client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: c.name(SourcesCollection),
+		Query:          qdrant.NewQueryRecommend(input),
+		Using:          using,
+		Filter:         sourceFilter(opts),
+		Params:         opts.searchParams(),
 		Limit:          qdrant.PtrOf(uint64(limit)),
 		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recommend failed: %w", err)
+	}
+
+	return convertResults(results), nil
+}
+
+// sourceFilter builds the payload filter for a source query
+func sourceFilter(opts SearchOptions) *qdrant.Filter {
+	filter := &qdrant.Filter{
+		Must:    []*qdrant.Condition{visibilityCondition(opts.User)},
+		MustNot: notDeleted(),
 	}
 
 	// Add topic filter if specified
-	if topicFilter != "" {
-		query.Filter = &qdrant.Filter{
-			Must: []*qdrant.Condition{
-				qdrant.NewMatch("topic", topicFilter),
-			},
+	if opts.Topic != "" {
+		filter.Must = append(filter.Must, opts.topicCondition())
+	}
+	if opts.Domain != "" {
+		filter.Must = append(filter.Must, qdrant.NewMatch("domain", opts.Domain))
+	}
+	if opts.Type != "" {
+		filter.Must = append(filter.Must, qdrant.NewMatch("type", opts.Type))
+	}
+	if opts.HasCode {
+		filter.Must = append(filter.Must, qdrant.NewMatchBool("has_code", true))
+	}
+	if opts.HasDataset {
+		filter.Must = append(filter.Must, qdrant.NewMatchBool("has_dataset", true))
+	}
+	if opts.Language != "" {
+		filter.Must = append(filter.Must, qdrant.NewMatch("language", opts.Language))
+	}
+	if len(opts.Tags) > 0 {
+		if opts.AllTags {
+			for _, tag := range opts.Tags {
+				filter.Must = append(filter.Must, qdrant.NewMatch("tags", tag))
+			}
+		} else {
+			filter.Must = append(filter.Must, qdrant.NewMatchKeywords("tags", opts.Tags...))
 		}
 	}
+	if cond := datetimeRange("created_at", opts.CreatedAfter, opts.CreatedBefore); cond != nil {
+		filter.Must = append(filter.Must, cond)
+	}
+	if cond := datetimeRange("published_at", opts.PublishedAfter, opts.PublishedBefore); cond != nil {
+		filter.Must = append(filter.Must, cond)
+	}
+	if opts.Near != nil {
+		filter.Must = append(filter.Must, qdrant.NewGeoRadius("location", opts.Near.Center.Lat, opts.Near.Center.Lon, float32(opts.Near.Meters)))
+	}
 
-	results, err := c.client.Query(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+	return filter
+}
+
+// datetimeRange matches a datetime field at or after after and before
+// before; zero times are open ends, and nil is returned when both are
+func datetimeRange(field string, after, before time.Time) *qdrant.Condition {
+	if after.IsZero() && before.IsZero() {
+		return nil
+	}
+	r := &qdrant.DatetimeRange{}
+	if !after.IsZero() {
+		r.Gte = timestamppb.New(after)
+	}
+	if !before.IsZero() {
+		r.Lt = timestamppb.New(before)
 	}
+	return qdrant.NewDatetimeRange(field, r)
+}
 
-	return convertResults(results), nil
+// visibilityCondition matches sources visible to the caller: public ones
+// (including points written before visibility existed), internal ones for
+// identified callers, and private ones owned by the caller
+func visibilityCondition(user string) *qdrant.Condition {
+	visible := &qdrant.Filter{
+		Should: []*qdrant.Condition{
+			qdrant.NewMatch("visibility", "public"),
+			qdrant.NewIsEmpty("visibility"),
+		},
+	}
+	if user != "" {
+		visible.Should = append(visible.Should,
+			qdrant.NewMatch("visibility", "internal"),
+			qdrant.NewFilterAsCondition(&qdrant.Filter{
+				Must: []*qdrant.Condition{
+					qdrant.NewMatch("visibility", "private"),
+					qdrant.NewMatch("owner", user),
+				},
+			}),
+		)
+	}
+	return qdrant.NewFilterAsCondition(visible)
+}
+
+// notDeleted leaves out the points of deleted sources and their claims
+// (see SetSourceDeleted)
+func notDeleted() []*qdrant.Condition {
+	return []*qdrant.Condition{qdrant.NewMatchBool("deleted", true)}
+}
+
+// idInputs converts source/article IDs into query inputs referencing the
+// stored vectors of those points
+func idInputs(ids []string) []*qdrant.VectorInput {
+	inputs := make([]*qdrant.VectorInput, len(ids))
+	for i, id := range ids {
+		inputs[i] = qdrant.NewVectorInputID(qdrant.NewID(toUUID(id)))
+	}
+	return inputs
 }
 
 // SearchArticles searches for similar articles using vector similarity
-func (c *Client) SearchArticles(ctx context.Context, embedding []float32, limit int, categoryFilter string) ([]SearchResult, error) {
+func (c *Client) SearchArticles(ctx context.Context, embedding []float32, limit int, opts SearchOptions) ([]SearchResult, error) {
+	var filter *qdrant.Filter
+
+	// Add category filter if specified
+	if opts.Category != "" {
+		filter = &qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatch("category", opts.Category),
+			},
+		}
+	}
+
+	return c.search(ctx, ArticlesCollection, embedding, limit, opts, filter)
+}
+
+// search runs a dense query against a collection. When sparse vectors are
+// enabled and opts.Text is set, it runs a hybrid query instead: dense and
+// BM25 candidates are prefetched and combined with Reciprocal Rank Fusion.
+// With opts.LateInteraction, the candidates of either are rescored by their
+// token vectors.
+func (c *Client) search(ctx context.Context, collection string, embedding []float32, limit int, opts SearchOptions, filter *qdrant.Filter) ([]SearchResult, error) {
+	using, err := c.using(opts.Vector)
+	if err != nil {
+		return nil, err
+	}
+
 	query := &qdrant.QueryPoints{
-		CollectionName: ArticlesCollection,
+		CollectionName: c.name(collection),
 		Query:          qdrant.NewQuery(embedding...),
+		Using:          using,
+		Filter:         filter,
+		Params:         opts.searchParams(),
 		Limit:          qdrant.PtrOf(uint64(limit)),
 		WithPayload:    qdrant.NewWithPayload(true),
 	}
 
-	// Add category filter if specified
-	if categoryFilter != "" {
-		query.Filter = &qdrant.Filter{
-			Must: []*qdrant.Condition{
-				qdrant.NewMatch("category", categoryFilter),
+	if c.cfg.Sparse && opts.Text != "" {
+		sparse := EncodeSparseQuery(opts.Text)
+		prefetchLimit := qdrant.PtrOf(uint64(limit * hybridPrefetchFactor))
+		query.Prefetch = []*qdrant.PrefetchQuery{
+			{
+				Query:  qdrant.NewQuery(embedding...),
+				Using:  using,
+				Filter: filter,
+				Params: opts.searchParams(),
+				Limit:  prefetchLimit,
+			},
+			{
+				Query:  qdrant.NewQuerySparse(sparse.Indices, sparse.Values),
+				Using:  qdrant.PtrOf(SparseVectorName),
+				Filter: filter,
+				Limit:  prefetchLimit,
 			},
 		}
+		query.Query = qdrant.NewQueryFusion(qdrant.Fusion_RRF)
+		query.Using = nil
+	}
+	if opts.LateInteraction != "" {
+		if err := c.lateInteraction(ctx, collection, query, opts.LateInteraction, limit); err != nil {
+			return nil, err
+		}
 	}
 
 	results, err := c.client.Query(ctx, query)
@@ -235,47 +1040,154 @@ func (c *Client) SearchArticles(ctx context.Context, embedding []float32, limit
 	return convertResults(results), nil
 }
 
-// GetSourcesByTopic retrieves all sources for a specific topic
-func (c *Client) GetSourcesByTopic(ctx context.Context, topic string, limit int) ([]SearchResult, error) {
-	// Use scroll to get all sources with topic filter (no vector needed)
-	points, err := c.client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: SourcesCollection,
-		Filter: &qdrant.Filter{
-			Must: []*qdrant.Condition{
-				qdrant.NewMatch("topic", topic),
-			},
-		},
-		Limit:       qdrant.PtrOf(uint32(limit)),
-		WithPayload: qdrant.NewWithPayload(true),
+// FacetSources counts the sources matching opts by the values of a payload
+// key (one of the indexed fields)
+func (c *Client) FacetSources(ctx context.Context, key string, opts SearchOptions) (map[string]int, error) {
+	hits, err := c.client.Facet(ctx, &qdrant.FacetCounts{
+		CollectionName: c.name(SourcesCollection),
+		Key:            key,
+		Filter:         sourceFilter(opts),
+		Limit:          qdrant.PtrOf(uint64(100)),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("scroll failed: %w", err)
+		return nil, fmt.Errorf("facet failed: %w", err)
+	}
+
+	counts := make(map[string]int, len(hits))
+	for _, hit := range hits {
+		if value := hit.Value.GetStringValue(); value != "" {
+			counts[value] = int(hit.Count)
+		}
+	}
+	return counts, nil
+}
+
+// GetSourcesByTopic retrieves up to limit sources of a topic, scrolling
+// through as many pages as that takes
+func (c *Client) GetSourcesByTopic(ctx context.Context, topic string, limit int) ([]SearchResult, error) {
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("topic", topic),
+		},
+		MustNot: notDeleted(),
 	}
 
 	var results []SearchResult
-	for _, point := range points {
-		results = append(results, SearchResult{
-			ID:      point.Id.GetUuid(),
-			Score:   1.0, // No score for scroll
-			Payload: extractPayload(point.Payload),
-		})
+	offset := ""
+	for len(results) < limit {
+		page, err := c.scroll(ctx, SourcesCollection, filter, offset, min(limit-len(results), ScrollPageSize))
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range page.Points {
+			results = append(results, SearchResult{
+				ID:      point.ID,
+				Score:   1.0, // No score for scroll
+				Payload: point.Payload,
+			})
+		}
+		if page.Next == "" {
+			break
+		}
+		offset = page.Next
 	}
 	return results, nil
 }
 
-// DeleteSource removes a source from the vector database
+// GetSourceVector returns the stored embedding of a source in the given
+// vector space (empty selects the default), or nil if the source has no point
+func (c *Client) GetSourceVector(ctx context.Context, id, vector string) ([]float32, error) {
+	return c.getVector(ctx, SourcesCollection, id, vector)
+}
+
+// GetArticleVector returns the stored embedding of an article in the given
+// vector space (empty selects the default), or nil if the article has no point
+func (c *Client) GetArticleVector(ctx context.Context, id, vector string) ([]float32, error) {
+	return c.getVector(ctx, ArticlesCollection, id, vector)
+}
+
+func (c *Client) getVector(ctx context.Context, collection, id, vector string) ([]float32, error) {
+	using, err := c.using(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := c.client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: c.name(collection),
+		Ids:            []*qdrant.PointId{qdrant.NewID(toUUID(id))},
+		WithPayload:    qdrant.NewWithPayload(false),
+		WithVectors:    qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get failed: %w", err)
+	}
+	if len(points) == 0 || points[0].Vectors == nil {
+		return nil, nil
+	}
+
+	name := ""
+	if using != nil {
+		name = *using
+	}
+	out := points[0].Vectors.GetVector()
+	if named := points[0].Vectors.GetVectors(); named != nil {
+		out = named.GetVectors()[name]
+	}
+	if dense := out.GetDenseVector(); dense != nil {
+		return dense.GetData(), nil
+	}
+	return nil, nil
+}
+
+// DeleteSource removes a source, and its claims, from the vector database
 func (c *Client) DeleteSource(ctx context.Context, id string) error {
-	_, err := c.client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: SourcesCollection,
-		Points: &qdrant.PointsSelector{
-			PointsSelectorOneOf: &qdrant.PointsSelector_Points{
-				Points: &qdrant.PointsIdsList{
-					Ids: []*qdrant.PointId{qdrant.NewID(toUUID(id))},
+	if c.cfg.Claims {
+		if err := c.deleteSourceClaims(ctx, id); err != nil {
+			return err
+		}
+	}
+	return c.delete(ctx, SourcesCollection, id)
+}
+
+// SetSourceDeleted flags the point of a soft-deleted source, and those of
+// its claims, so searches leave them out until the source is restored
+// (deleted false) or purged (see DeleteSource)
+func (c *Client) SetSourceDeleted(ctx context.Context, id string, deleted bool) error {
+	payload := map[string]any{"deleted": deleted}
+	if c.cfg.Claims {
+		if err := c.SetPayloadWhere(ctx, ClaimsCollection, Filter{"source_id": id}, payload); err != nil {
+			return err
+		}
+	}
+	return c.SetPayload(ctx, SourcesCollection, []string{id}, payload)
+}
+
+// DeleteArticle removes an article, and its chunks, from the vector database
+func (c *Client) DeleteArticle(ctx context.Context, id string) error {
+	if c.cfg.ArticleChunks {
+		if err := c.deleteArticleChunks(ctx, id); err != nil {
+			return err
+		}
+	}
+	return c.delete(ctx, ArticlesCollection, id)
+}
+
+// delete removes a point from a collection
+func (c *Client) delete(ctx context.Context, collection, id string) error {
+	return c.write(ctx, func() error {
+		_, err := c.client.Delete(ctx, &qdrant.DeletePoints{
+			CollectionName: c.name(collection),
+			Wait:           c.wait(ctx),
+			Points: &qdrant.PointsSelector{
+				PointsSelectorOneOf: &qdrant.PointsSelector_Points{
+					Points: &qdrant.PointsIdsList{
+						Ids: []*qdrant.PointId{qdrant.NewID(toUUID(id))},
+					},
 				},
 			},
-		},
+		})
+		return err
 	})
-	return err
 }
 
 // Close closes the Qdrant client connection
@@ -416,4 +1328,3 @@ func decodeULID(s string) ([]byte, error) {
 
 	return result[:], nil
 }
-
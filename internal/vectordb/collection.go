@@ -0,0 +1,163 @@
+package vectordb
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// CollectionConfig tunes the collections a client creates. Zero fields keep
+// Qdrant's defaults. Like vector spaces, the settings only apply to new
+// collections: existing ones must be recreated to change them.
+type CollectionConfig struct {
+	// HNSWM is the number of edges per node of the HNSW graph: more edges
+	// improve recall at the cost of memory
+	HNSWM uint64
+	// HNSWEfConstruct is the number of neighbours considered while building
+	// the graph: higher values improve recall at the cost of indexing time
+	HNSWEfConstruct uint64
+	HNSWOnDisk      bool // Keep the HNSW graph on disk instead of in RAM
+	VectorsOnDisk   bool // Serve vectors from disk (memmapped) instead of RAM
+	PayloadOnDisk   bool // Keep payloads on disk instead of in RAM
+	// Shards and ReplicationFactor distribute collections over a cluster
+	Shards            uint32
+	ReplicationFactor uint32
+	// Distance is the metric of every collection, DefaultDistance when
+	// empty; Distances overrides it per collection
+	Distance  Distance
+	Distances map[string]Distance
+}
+
+// distance returns the metric configured for a collection
+func (cc CollectionConfig) distance(collection string) Distance {
+	if d, ok := cc.Distances[collection]; ok {
+		return d
+	}
+	if cc.Distance != "" {
+		return cc.Distance
+	}
+	return DefaultDistance
+}
+
+// collectionConfigFromEnv reads the collection configuration from
+// QDRANT_HNSW_M, QDRANT_HNSW_EF_CONSTRUCT, QDRANT_HNSW_ON_DISK,
+// QDRANT_VECTORS_ON_DISK, QDRANT_PAYLOAD_ON_DISK, QDRANT_SHARDS,
+// QDRANT_REPLICATION_FACTOR and QDRANT_DISTANCE, which is either one metric
+// for every collection or per-collection metrics such as
+// "sources=dot,articles=cosine"
+func collectionConfigFromEnv() (CollectionConfig, error) {
+	var cfg CollectionConfig
+	if s := os.Getenv("QDRANT_DISTANCE"); s != "" {
+		if err := cfg.parseDistances(s); err != nil {
+			return cfg, fmt.Errorf("invalid QDRANT_DISTANCE: %w", err)
+		}
+	}
+	for name, dst := range map[string]*uint64{
+		"QDRANT_HNSW_M":            &cfg.HNSWM,
+		"QDRANT_HNSW_EF_CONSTRUCT": &cfg.HNSWEfConstruct,
+	} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s: %s", name, s)
+			}
+			*dst = n
+		}
+	}
+	for name, dst := range map[string]*uint32{
+		"QDRANT_SHARDS":             &cfg.Shards,
+		"QDRANT_REPLICATION_FACTOR": &cfg.ReplicationFactor,
+	} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil || n == 0 {
+				return cfg, fmt.Errorf("invalid %s: %s", name, s)
+			}
+			*dst = uint32(n)
+		}
+	}
+	for name, dst := range map[string]*bool{
+		"QDRANT_HNSW_ON_DISK":    &cfg.HNSWOnDisk,
+		"QDRANT_VECTORS_ON_DISK": &cfg.VectorsOnDisk,
+		"QDRANT_PAYLOAD_ON_DISK": &cfg.PayloadOnDisk,
+	} {
+		if s := os.Getenv(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s: %s", name, s)
+			}
+			*dst = b
+		}
+	}
+	return cfg, nil
+}
+
+// parseDistances parses a metric, or comma-separated collection=metric
+// pairs, into Distance or Distances
+func (cc *CollectionConfig) parseDistances(s string) error {
+	if !strings.Contains(s, "=") {
+		d, err := ParseDistance(s)
+		cc.Distance = d
+		return err
+	}
+	cc.Distances = make(map[string]Distance)
+	for _, part := range strings.Split(s, ",") {
+		collection, metric, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return fmt.Errorf("expected collection=metric, got %q", part)
+		}
+		switch collection {
+		case SourcesCollection, ArticlesCollection, ArticleChunksCollection, ClaimsCollection:
+		default:
+			return fmt.Errorf("unknown collection %q", collection)
+		}
+		d, err := ParseDistance(metric)
+		if err != nil {
+			return err
+		}
+		cc.Distances[collection] = d
+	}
+	return nil
+}
+
+// vectorParams returns the parameters of a dense vector space of a
+// collection
+func (cc CollectionConfig) vectorParams(collection string, size uint64) *qdrant.VectorParams {
+	params := &qdrant.VectorParams{
+		Size:     size,
+		Distance: cc.distance(collection).qdrant(),
+	}
+	if cc.VectorsOnDisk {
+		params.OnDisk = qdrant.PtrOf(true)
+	}
+	return params
+}
+
+// apply sets the collection-wide settings of a create request
+func (cc CollectionConfig) apply(create *qdrant.CreateCollection) {
+	if cc.HNSWM > 0 || cc.HNSWEfConstruct > 0 || cc.HNSWOnDisk {
+		hnsw := &qdrant.HnswConfigDiff{}
+		if cc.HNSWM > 0 {
+			hnsw.M = qdrant.PtrOf(cc.HNSWM)
+		}
+		if cc.HNSWEfConstruct > 0 {
+			hnsw.EfConstruct = qdrant.PtrOf(cc.HNSWEfConstruct)
+		}
+		if cc.HNSWOnDisk {
+			hnsw.OnDisk = qdrant.PtrOf(true)
+		}
+		create.HnswConfig = hnsw
+	}
+	if cc.PayloadOnDisk {
+		create.OnDiskPayload = qdrant.PtrOf(true)
+	}
+	if cc.Shards > 0 {
+		create.ShardNumber = qdrant.PtrOf(cc.Shards)
+	}
+	if cc.ReplicationFactor > 0 {
+		create.ReplicationFactor = qdrant.PtrOf(cc.ReplicationFactor)
+	}
+}
@@ -0,0 +1,70 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Filter selects points by payload: a point matches when its payload has the
+// given value for every key, e.g. {"topic": "physics", "has_code": true}.
+// Values are strings, bools or integers; an empty filter matches every point.
+type Filter map[string]any
+
+// qdrant converts the filter to a Qdrant payload filter, nil when empty
+func (f Filter) qdrant() (*qdrant.Filter, error) {
+	if len(f) == 0 {
+		return nil, nil
+	}
+	filter := &qdrant.Filter{}
+	for key, value := range f {
+		var cond *qdrant.Condition
+		switch v := value.(type) {
+		case string:
+			cond = qdrant.NewMatch(key, v)
+		case bool:
+			cond = qdrant.NewMatchBool(key, v)
+		case int:
+			cond = qdrant.NewMatchInt(key, int64(v))
+		case int64:
+			cond = qdrant.NewMatchInt(key, v)
+		default:
+			return nil, fmt.Errorf("unsupported filter value for %s: %T", key, value)
+		}
+		filter.Must = append(filter.Must, cond)
+	}
+	return filter, nil
+}
+
+// Count returns the exact number of points of a collection matching filter
+func (c *Client) Count(ctx context.Context, collection string, filter Filter) (uint64, error) {
+	f, err := filter.qdrant()
+	if err != nil {
+		return 0, err
+	}
+	count, err := c.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: c.name(collection),
+		Filter:         f,
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	return count, nil
+}
+
+// Exists reports whether a collection has a point for the source or article
+// with the given ID
+func (c *Client) Exists(ctx context.Context, collection, id string) (bool, error) {
+	points, err := c.client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: c.name(collection),
+		Ids:            []*qdrant.PointId{qdrant.NewID(toUUID(id))},
+		WithPayload:    qdrant.NewWithPayload(false),
+		WithVectors:    qdrant.NewWithVectors(false),
+	})
+	if err != nil {
+		return false, fmt.Errorf("get point failed: %w", err)
+	}
+	return len(points) > 0, nil
+}
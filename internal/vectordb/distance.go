@@ -0,0 +1,101 @@
+package vectordb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Distance is the metric the vectors of a collection are compared with. It
+// decides what search scores mean: similarities for cosine and dot, where
+// higher is closer, and distances for euclid, where lower is closer.
+type Distance string
+
+// Distance metrics
+const (
+	DistanceCosine Distance = "cosine"
+	DistanceDot    Distance = "dot"
+	DistanceEuclid Distance = "euclid"
+)
+
+// DefaultDistance is the metric of collections without a configured one
+const DefaultDistance = DistanceCosine
+
+// ParseDistance parses a metric name, ignoring case
+func ParseDistance(s string) (Distance, error) {
+	switch d := Distance(strings.ToLower(strings.TrimSpace(s))); d {
+	case DistanceCosine, DistanceDot, DistanceEuclid:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown distance %q: expected cosine, dot or euclid", s)
+}
+
+// qdrant returns the Qdrant distance of the metric
+func (d Distance) qdrant() qdrant.Distance {
+	switch d {
+	case DistanceDot:
+		return qdrant.Distance_Dot
+	case DistanceEuclid:
+		return qdrant.Distance_Euclid
+	default:
+		return qdrant.Distance_Cosine
+	}
+}
+
+// distanceFromQdrant returns the metric of a Qdrant distance, or "" for
+// metrics the client doesn't configure
+func distanceFromQdrant(d qdrant.Distance) Distance {
+	switch d {
+	case qdrant.Distance_Cosine:
+		return DistanceCosine
+	case qdrant.Distance_Dot:
+		return DistanceDot
+	case qdrant.Distance_Euclid:
+		return DistanceEuclid
+	}
+	return ""
+}
+
+// HigherIsBetter reports whether higher scores mean closer vectors
+func (d Distance) HigherIsBetter() bool {
+	return d != DistanceEuclid
+}
+
+// Reaches reports whether a score is at least as close as threshold: at or
+// above it for similarities, at or below it for distances
+func (d Distance) Reaches(score, threshold float32) bool {
+	if d.HigherIsBetter() {
+		return score >= threshold
+	}
+	return score <= threshold
+}
+
+// ScoreSemantics tells clients how to read the scores of search results, so
+// thresholds carry over between deployments with different metrics
+type ScoreSemantics struct {
+	// Metric is cosine, dot or euclid, or rrf for fused hybrid scores
+	Metric         string `json:"metric"`
+	HigherIsBetter bool   `json:"higher_is_better"`
+	// Min and Max bound the scores; absent bounds are unbounded
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// Scores returns the semantics of the scores of a search under the metric
+func (d Distance) Scores() ScoreSemantics {
+	switch d {
+	case DistanceDot:
+		return ScoreSemantics{Metric: string(d), HigherIsBetter: true}
+	case DistanceEuclid:
+		return ScoreSemantics{Metric: string(d), Min: qdrant.PtrOf(0.0)}
+	default:
+		return ScoreSemantics{Metric: string(DistanceCosine), HigherIsBetter: true, Min: qdrant.PtrOf(-1.0), Max: qdrant.PtrOf(1.0)}
+	}
+}
+
+// FusedScores returns the semantics of hybrid search scores: Reciprocal
+// Rank Fusion sums rank-based scores, whatever the metric
+func FusedScores() ScoreSemantics {
+	return ScoreSemantics{Metric: "rrf", HigherIsBetter: true, Min: qdrant.PtrOf(0.0)}
+}
@@ -0,0 +1,51 @@
+package vectordb
+
+import "math"
+
+// earthRadiusMeters is the mean radius of the Earth
+const earthRadiusMeters = 6371008.8
+
+// GeoPoint is a location stored in the location payload of a source
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// GeoPoints converts latitude/longitude pairs to geo points
+func GeoPoints(coords [][2]float64) []GeoPoint {
+	points := make([]GeoPoint, len(coords))
+	for i, c := range coords {
+		points[i] = GeoPoint{Lat: c[0], Lon: c[1]}
+	}
+	return points
+}
+
+// GeoRadius is a circle on the globe
+type GeoRadius struct {
+	Center GeoPoint
+	Meters float64
+}
+
+// Contains reports whether a point lies within the circle (haversine
+// distance)
+func (r GeoRadius) Contains(p GeoPoint) bool {
+	return GreatCircleDistance(r.Center, p) <= r.Meters
+}
+
+// GreatCircleDistance returns the great-circle distance between two points in meters
+func GreatCircleDistance(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// geoList converts geo points to a list Qdrant payloads accept
+func geoList(points []GeoPoint) []any {
+	list := make([]any, len(points))
+	for i, p := range points {
+		list[i] = map[string]any{"lat": p.Lat, "lon": p.Lon}
+	}
+	return list
+}
@@ -0,0 +1,129 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// lateInteractionPrefetchFactor controls how many candidates the dense (or
+// hybrid) query passes to the late-interaction rescoring, relative to the
+// requested limit
+const lateInteractionPrefetchFactor = 8
+
+// TokenEmbedder generates token-level embeddings for the multivector space
+// (see embedding.TokenClient)
+type TokenEmbedder interface {
+	EmbedTokens(ctx context.Context, text string, query bool) ([][]float32, error)
+}
+
+// MultivectorConfig describes the experimental late-interaction mode: points
+// of the listed collections also carry one vector per token of their text,
+// compared to the query tokens with Qdrant's MaxSim comparator
+type MultivectorConfig struct {
+	// Space is the token model and its vector size; an empty name disables
+	// the mode
+	Space VectorSpace
+	// Collections are the collections whose points carry token vectors
+	Collections []string
+	// Tokens embeds the texts of points and queries
+	Tokens TokenEmbedder
+}
+
+// enabled reports whether the points of a collection carry token vectors
+func (mc MultivectorConfig) enabled(collection string) bool {
+	if mc.Space.Name == "" {
+		return false
+	}
+	for _, c := range mc.Collections {
+		if c == collection {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMultivectorCollections parses a comma-separated list of collections
+// that can carry token vectors
+func parseMultivectorCollections(s string) ([]string, error) {
+	var collections []string
+	for _, part := range strings.Split(s, ",") {
+		switch name := strings.TrimSpace(part); name {
+		case "":
+		case SourcesCollection, ArticlesCollection:
+			collections = append(collections, name)
+		default:
+			return nil, fmt.Errorf("unknown collection %q", name)
+		}
+	}
+	return collections, nil
+}
+
+// LateInteractionEnabled reports whether searches of a collection can be
+// rescored with token vectors (SearchOptions.LateInteraction)
+func (c *Client) LateInteractionEnabled(collection string) bool {
+	return c.cfg.Multivector.enabled(collection)
+}
+
+// multivectorParams returns the parameters of the token vector space. The
+// space is only used to rescore candidates, so it has no HNSW graph.
+func (c *Client) multivectorParams() *qdrant.VectorParams {
+	params := &qdrant.VectorParams{
+		Size:              c.cfg.Multivector.Space.Size,
+		Distance:          qdrant.Distance_Cosine,
+		MultivectorConfig: &qdrant.MultiVectorConfig{Comparator: qdrant.MultiVectorComparator_MaxSim},
+		HnswConfig:        &qdrant.HnswConfigDiff{M: qdrant.PtrOf(uint64(0))},
+	}
+	if c.cfg.Collection.VectorsOnDisk {
+		params.OnDisk = qdrant.PtrOf(true)
+	}
+	return params
+}
+
+// addMultivector embeds the tokens of a point's text into its vectors when
+// its collection carries token vectors
+func (c *Client) addMultivector(ctx context.Context, collection string, vectors *qdrant.Vectors, text string) error {
+	if !c.cfg.Multivector.enabled(collection) {
+		return nil
+	}
+	tokens, err := c.cfg.Multivector.Tokens.EmbedTokens(ctx, text, false)
+	if err != nil {
+		return fmt.Errorf("token embedding failed: %w", err)
+	}
+	vectors.GetVectors().Vectors[c.cfg.Multivector.Space.Name] = qdrant.NewVectorMulti(tokens)
+	return nil
+}
+
+// lateInteraction wraps a query so its candidates are rescored by the
+// MaxSim of their token vectors with those of text
+func (c *Client) lateInteraction(ctx context.Context, collection string, query *qdrant.QueryPoints, text string, limit int) error {
+	if !c.cfg.Multivector.enabled(collection) {
+		return fmt.Errorf("late interaction is not enabled for %s", collection)
+	}
+	tokens, err := c.cfg.Multivector.Tokens.EmbedTokens(ctx, text, true)
+	if err != nil {
+		return fmt.Errorf("token embedding failed: %w", err)
+	}
+
+	query.Prefetch = []*qdrant.PrefetchQuery{{
+		Prefetch: query.Prefetch,
+		Query:    query.Query,
+		Using:    query.Using,
+		Filter:   query.Filter,
+		Params:   query.Params,
+		Limit:    qdrant.PtrOf(uint64(limit * lateInteractionPrefetchFactor)),
+	}}
+	query.Query = qdrant.NewQueryMulti(tokens)
+	query.Using = qdrant.PtrOf(c.cfg.Multivector.Space.Name)
+	query.Params = nil
+	return nil
+}
+
+// LateInteractionScores returns the semantics of late-interaction scores:
+// MaxSim sums, over the query tokens, the best cosine similarity with a
+// document token, so the range grows with the query length
+func LateInteractionScores() ScoreSemantics {
+	return ScoreSemantics{Metric: "max_sim", HigherIsBetter: true}
+}
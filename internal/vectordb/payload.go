@@ -0,0 +1,147 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// NewSourcePayload returns the payload of a source's points. Every writer of
+// source points uses it, so a source gets the same payload whichever path
+// stored it.
+func NewSourcePayload(src database.Source) SourcePayload {
+	return SourcePayload{
+		ID:          src.ID,
+		URL:         src.URL,
+		Title:       src.Title,
+		Topic:       src.Topic,
+		Summary:     src.Summary,
+		Language:    src.Language,
+		Model:       src.Model,
+		CreatedAt:   src.CreatedAt,
+		PublishedAt: src.PublishedAt,
+		Tags:        src.Tags,
+		Locations:   GeoPoints(src.Coordinates()),
+		Visibility:  src.Visibility,
+		Owner:       src.Owner,
+		Domain:      database.URLDomain(src.URL),
+		WordCount:   database.WordCount(src.Summary),
+		Type:        database.NormalizeSourceType(src.Type),
+		HasCode:     src.HasLink(database.LinkCode),
+		HasDataset:  src.HasLink(database.LinkDataset),
+	}
+}
+
+// Values returns the payload as stored in Qdrant
+func (p SourcePayload) Values() map[string]any {
+	visibility := p.Visibility
+	if visibility == "" {
+		visibility = "public"
+	}
+	values := map[string]any{
+		"id":          p.ID,
+		"url":         p.URL,
+		"title":       p.Title,
+		"topic":       p.Topic,
+		"summary":     p.Summary,
+		"language":    p.Language,
+		"model":       p.Model,
+		"created_at":  p.CreatedAt,
+		"tags":        stringList(p.Tags),
+		"location":    geoList(p.Locations),
+		"visibility":  visibility,
+		"owner":       p.Owner,
+		"domain":      p.Domain,
+		"word_count":  p.WordCount,
+		"type":        p.Type,
+		"has_code":    p.HasCode,
+		"has_dataset": p.HasDataset,
+	}
+	// Undated sources have no published_at, rather than an empty string
+	// that isn't a datetime
+	if p.PublishedAt != "" {
+		values["published_at"] = p.PublishedAt
+	}
+	return values
+}
+
+// Values returns the payload as stored in Qdrant
+func (p ArticlePayload) Values() map[string]any {
+	return map[string]any{
+		"id":         p.ID,
+		"title":      p.Title,
+		"path":       p.Path,
+		"summary":    p.Summary,
+		"tags":       stringList(p.Tags),
+		"category":   p.Category,
+		"word_count": p.WordCount,
+	}
+}
+
+// stringList converts strings to a list Qdrant payloads accept, which
+// excludes []string
+func stringList(values []string) []any {
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
+
+// SetPayload sets the given payload keys of the points of sources or
+// articles, keeping their other keys and their vectors. Only the payload is
+// sent, so metadata edits don't resend the embeddings.
+func (c *Client) SetPayload(ctx context.Context, collection string, ids []string, payload map[string]any) error {
+	return c.setPayload(ctx, collection, pointsSelector(ids), payload, false)
+}
+
+// SetPayloadWhere sets the given payload keys of every point of a
+// collection matching filter, e.g. to retag all sources of a topic
+func (c *Client) SetPayloadWhere(ctx context.Context, collection string, filter Filter, payload map[string]any) error {
+	f, err := filter.qdrant()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("an empty filter would update every point")
+	}
+	return c.setPayload(ctx, collection, qdrant.NewPointsSelectorFilter(f), payload, false)
+}
+
+// OverwritePayload replaces the whole payload of the points of sources or
+// articles, keeping their vectors
+func (c *Client) OverwritePayload(ctx context.Context, collection string, ids []string, payload map[string]any) error {
+	return c.setPayload(ctx, collection, pointsSelector(ids), payload, true)
+}
+
+func (c *Client) setPayload(ctx context.Context, collection string, selector *qdrant.PointsSelector, payload map[string]any, overwrite bool) error {
+	values, err := qdrant.TryValueMap(payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return c.write(ctx, func() error {
+		req := &qdrant.SetPayloadPoints{
+			CollectionName: c.name(collection),
+			Wait:           c.wait(ctx),
+			Payload:        values,
+			PointsSelector: selector,
+		}
+		if overwrite {
+			_, err = c.client.OverwritePayload(ctx, req)
+		} else {
+			_, err = c.client.SetPayload(ctx, req)
+		}
+		return err
+	})
+}
+
+// pointsSelector selects the points of sources or articles by ID
+func pointsSelector(ids []string) *qdrant.PointsSelector {
+	pointIDs := make([]*qdrant.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrant.NewID(toUUID(id))
+	}
+	return qdrant.NewPointsSelector(pointIDs...)
+}
@@ -0,0 +1,131 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy configures how writes (upserts and deletes) that fail with a
+// transient error are retried
+type RetryPolicy struct {
+	Retries int           // Retries after the first attempt; 0 disables them
+	Backoff time.Duration // Delay before the first retry, doubled for each next one
+}
+
+// DefaultRetryPolicy retries twice, after 200ms and 400ms
+var DefaultRetryPolicy = RetryPolicy{Retries: 2, Backoff: 200 * time.Millisecond}
+
+// retryPolicyFromEnv reads QDRANT_WRITE_RETRIES and QDRANT_RETRY_BACKOFF,
+// defaulting to DefaultRetryPolicy
+func retryPolicyFromEnv() (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	if s := os.Getenv("QDRANT_WRITE_RETRIES"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return policy, fmt.Errorf("invalid QDRANT_WRITE_RETRIES: %s", s)
+		}
+		policy.Retries = n
+	}
+	if s := os.Getenv("QDRANT_RETRY_BACKOFF"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return policy, fmt.Errorf("invalid QDRANT_RETRY_BACKOFF: %s", s)
+		}
+		policy.Backoff = d
+	}
+	return policy, nil
+}
+
+// WriteStats counts the writes of a client since it was created
+type WriteStats struct {
+	Writes   int64 `json:"writes"`
+	Retries  int64 `json:"retries"`
+	Failures int64 `json:"failures"` // Writes that failed after every attempt
+}
+
+// writeCounters are the atomic counters behind WriteStats
+type writeCounters struct {
+	writes, retries, failures atomic.Int64
+}
+
+func (w *writeCounters) stats() WriteStats {
+	return WriteStats{
+		Writes:   w.writes.Load(),
+		Retries:  w.retries.Load(),
+		Failures: w.failures.Load(),
+	}
+}
+
+// WriteStats returns the client's write, retry and failure counts
+func (c *Client) WriteStats() WriteStats {
+	return c.counters.stats()
+}
+
+type waitKey struct{}
+
+// WithWait marks writes made with the returned context as critical: they
+// wait until Qdrant has applied them, whatever the client's Wait setting,
+// so the points are searchable when the write returns
+func WithWait(ctx context.Context) context.Context {
+	return context.WithValue(ctx, waitKey{}, true)
+}
+
+// wait reports whether a write made with ctx waits for Qdrant to apply it
+func (c *Client) wait(ctx context.Context) *bool {
+	critical, _ := ctx.Value(waitKey{}).(bool)
+	return qdrant.PtrOf(c.cfg.Wait || critical)
+}
+
+// write runs a write operation, retrying transient failures under the
+// client's retry policy
+func (c *Client) write(ctx context.Context, op func() error) error {
+	c.counters.writes.Add(1)
+	backoff := c.cfg.Retry.Backoff
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.cfg.Retry.Retries || !transient(err) {
+			c.counters.failures.Add(1)
+			return err
+		}
+
+		c.counters.retries.Add(1)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			c.counters.failures.Add(1)
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// Unavailable reports whether a request failed because Qdrant can't be
+// reached or didn't answer in time, rather than because it refused it
+func Unavailable(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && (st.Code() == codes.Unavailable || st.Code() == codes.DeadlineExceeded)
+}
+
+// transient reports whether a failed request may succeed when retried
+func transient(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// ScrollPageSize is the number of points fetched per page by ScrollAll
+const ScrollPageSize = 256
+
+// Point is a stored point without its vectors
+type Point struct {
+	ID      string                 `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// ScrollPage is a page of points in ID order
+type ScrollPage struct {
+	Points []Point
+	Next   string // Offset of the next page; empty after the last page
+}
+
+// Scroller pages through the points of a collection
+type Scroller interface {
+	Scroll(ctx context.Context, collection, offset string, limit int) (ScrollPage, error)
+}
+
+// Scroll returns up to limit points of a collection, starting at offset: the
+// Next of a previous page, or empty for the first page
+func (c *Client) Scroll(ctx context.Context, collection, offset string, limit int) (ScrollPage, error) {
+	return c.scroll(ctx, collection, nil, offset, limit)
+}
+
+func (c *Client) scroll(ctx context.Context, collection string, filter *qdrant.Filter, offset string, limit int) (ScrollPage, error) {
+	req := &qdrant.ScrollPoints{
+		CollectionName: c.name(collection),
+		Filter:         filter,
+		Limit:          qdrant.PtrOf(uint32(limit)),
+		WithPayload:    qdrant.NewWithPayload(true),
+	}
+	if offset != "" {
+		req.Offset = qdrant.NewID(toUUID(offset))
+	}
+
+	points, next, err := c.client.ScrollAndOffset(ctx, req)
+	if err != nil {
+		return ScrollPage{}, fmt.Errorf("scroll failed: %w", err)
+	}
+
+	page := ScrollPage{Points: make([]Point, len(points))}
+	for i, point := range points {
+		page.Points[i] = Point{ID: point.Id.GetUuid(), Payload: extractPayload(point.Payload)}
+	}
+	if next != nil {
+		page.Next = next.GetUuid()
+	}
+	return page, nil
+}
+
+// ScrollAll calls fn for every point of a collection from offset (empty for
+// the start), fetching ScrollPageSize points at a time so that collections
+// of any size can be streamed. It stops at the first error of fn.
+func ScrollAll(ctx context.Context, s Scroller, collection, offset string, fn func(Point) error) error {
+	for {
+		page, err := s.Scroll(ctx, collection, offset, ScrollPageSize)
+		if err != nil {
+			return err
+		}
+		for _, point := range page.Points {
+			if err := fn(point); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			return nil
+		}
+		offset = page.Next
+	}
+}
@@ -0,0 +1,90 @@
+package vectordb
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SparseVectorName is the name of the BM25 sparse vector stored on every point
+const SparseVectorName = "bm25"
+
+// BM25 term-frequency parameters. Qdrant applies the IDF component itself
+// (the sparse vector is configured with the IDF modifier), so only the
+// saturated term frequency is computed here.
+const (
+	bm25K1        = 1.2
+	bm25B         = 0.75
+	bm25AvgDocLen = 256
+)
+
+// hybridPrefetchFactor controls how many candidates each branch of a hybrid
+// query contributes to fusion, relative to the requested limit
+const hybridPrefetchFactor = 4
+
+// SparseVector is a sparse term-weight vector
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// EncodeSparse converts document text into a BM25 sparse vector. Terms are
+// lowercased word tokens hashed into the 32-bit index space.
+func EncodeSparse(text string) SparseVector {
+	tokens := tokenize(text)
+	tf := make(map[uint32]float32)
+	for _, token := range tokens {
+		tf[hashToken(token)]++
+	}
+
+	docLen := float32(len(tokens))
+	norm := bm25K1 * (1 - bm25B + bm25B*docLen/bm25AvgDocLen)
+	return newSparseVector(tf, func(f float32) float32 {
+		return f * (bm25K1 + 1) / (f + norm)
+	})
+}
+
+// EncodeSparseQuery converts query text into a sparse vector where every
+// distinct term has weight 1, leaving term importance to the IDF modifier
+func EncodeSparseQuery(text string) SparseVector {
+	tf := make(map[uint32]float32)
+	for _, token := range tokenize(text) {
+		tf[hashToken(token)] = 1
+	}
+	return newSparseVector(tf, func(f float32) float32 { return f })
+}
+
+func newSparseVector(tf map[uint32]float32, weight func(float32) float32) SparseVector {
+	indices := make([]uint32, 0, len(tf))
+	for idx := range tf {
+		indices = append(indices, idx)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	values := make([]float32, len(indices))
+	for i, idx := range indices {
+		values[i] = weight(tf[idx])
+	}
+	return SparseVector{Indices: indices, Values: values}
+}
+
+// tokenize splits text into lowercased word tokens, dropping single characters
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 1 {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+func hashToken(token string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(token))
+	return h.Sum32()
+}
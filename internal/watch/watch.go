@@ -0,0 +1,341 @@
+// Package watch compares the sources created in the knowledge-base with the
+// watches users register with POST /watches, so they learn of new material
+// on their interests without searching again. Each watch follows the event
+// log from its creation; a created source whose vector is at least the
+// watch's threshold similar to the watch's embedding is recorded as a match,
+// streamed by GET /watches/stream and POSTed, signed, to the watch's URL if
+// it has one. Like webhooks, matching follows the log rather than the API
+// handlers, so sources of the CLIs (ingest) are compared too.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/embedding"
+	"github.com/gitopedia/knowledge-base/internal/store"
+	"github.com/gitopedia/knowledge-base/internal/vectordb"
+	"github.com/gitopedia/knowledge-base/internal/webhook"
+)
+
+// EventMatched is the event type of match deliveries and stream events
+const EventMatched = "watch.matched"
+
+const (
+	// batchSize is how many events are read from the log at a time
+	batchSize = 100
+	// maxEventsPerRun bounds the events one watch compares per run
+	maxEventsPerRun = 1000
+	// vectorGrace is how long a created source may lack its vector, which
+	// is written after the record, before it is passed over
+	vectorGrace = 2 * time.Minute
+	// MaxAttempts is how many times a match is POSTed before it is given
+	// up; attempts back off from minBackoff
+	MaxAttempts = 5
+	minBackoff  = 30 * time.Second
+)
+
+// Notification is a match as delivered and streamed: the source and the
+// watch it matched
+type Notification struct {
+	Seq       int64   `json:"seq"`
+	WatchID   string  `json:"watch_id"`
+	WatchName string  `json:"watch_name"`
+	Score     float64 `json:"score"`
+	MatchedAt string  `json:"matched_at"`
+	SourceID  string  `json:"source_id"`
+	Title     string  `json:"title"`
+	URL       string  `json:"url"`
+	Topic     string  `json:"topic"`
+	Summary   string  `json:"summary"`
+}
+
+// NewNotification describes a match of a watch with a source
+func NewNotification(m database.WatchMatch, w database.Watch, src database.Source) Notification {
+	return Notification{
+		Seq:       m.Seq,
+		WatchID:   w.ID,
+		WatchName: w.Name,
+		Score:     m.Score,
+		MatchedAt: m.MatchedAt,
+		SourceID:  src.ID,
+		Title:     src.Title,
+		URL:       src.URL,
+		Topic:     src.Topic,
+		Summary:   src.Summary,
+	}
+}
+
+// Stats summarizes a run
+type Stats struct {
+	Watches   int // Watches with events compared
+	Compared  int // Created sources compared with a watch
+	Matches   int
+	Delivered int
+	Failed    int // Failed deliveries, to be retried
+}
+
+// Run compares the sources created since each watch's cursor with it, then
+// delivers the matches not delivered yet
+func Run(ctx context.Context, db store.Store, vectors store.VectorStore) (Stats, error) {
+	var stats Stats
+	watches, err := db.ListWatches(ctx, "")
+	if err != nil {
+		return stats, fmt.Errorf("failed to list watches: %w", err)
+	}
+	r := &run{db: db, vectors: vectors, created: make(map[int64]bool),
+		sources: make(map[string]*database.Source), vecs: make(map[string][]float32)}
+	for _, w := range watches {
+		compared, matches, err := r.compare(ctx, w)
+		if compared > 0 {
+			stats.Watches++
+		}
+		stats.Compared += compared
+		stats.Matches += matches
+		if err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			log.Printf("Watch %s failed: %v", w.ID, err)
+		}
+	}
+
+	delivered, failed, err := deliver(ctx, db)
+	stats.Delivered, stats.Failed = delivered, failed
+	return stats, err
+}
+
+// run caches what the watches of a run share: which events are source
+// creations, and the sources and vectors compared
+type run struct {
+	db      store.Store
+	vectors store.VectorStore
+	created map[int64]bool
+	sources map[string]*database.Source
+	vecs    map[string][]float32 // Keyed by vector name and source ID
+}
+
+// compare compares a watch with the sources created after its cursor, up to
+// maxEventsPerRun events, and records its matches and new cursor. A source
+// whose vector isn't written yet stops the watch until a later run.
+func (r *run) compare(ctx context.Context, w database.Watch) (int, int, error) {
+	vector := ""
+	if spaces := r.vectors.VectorSpaces(); len(spaces) > 0 {
+		if !slices.ContainsFunc(spaces, func(s vectordb.VectorSpace) bool { return s.Name == w.Model }) {
+			return 0, 0, fmt.Errorf("model %s has no vector space", w.Model)
+		}
+		vector = w.Model
+	}
+
+	cursor, compared := w.Cursor, 0
+	var matches []database.WatchMatch
+	save := func() error {
+		if cursor == w.Cursor {
+			return nil
+		}
+		return r.db.AdvanceWatch(ctx, w.ID, cursor, matches)
+	}
+	for handled := 0; handled < maxEventsPerRun; {
+		events, err := r.db.EventsSince(ctx, cursor, batchSize)
+		if err != nil {
+			return compared, 0, fmt.Errorf("failed to read events: %w", err)
+		}
+		for _, ev := range events {
+			handled++
+			created, err := r.isCreation(ctx, ev)
+			if err != nil {
+				return compared, 0, fmt.Errorf("failed to read events: %w", err)
+			}
+			if !created {
+				cursor = ev.Seq
+				continue
+			}
+			src, err := r.source(ctx, ev.EntityID)
+			if err != nil {
+				return compared, 0, err
+			}
+			if src == nil || !src.VisibleTo(w.UserID) || (w.Topic != "" && src.Topic != w.Topic) {
+				cursor = ev.Seq
+				continue
+			}
+			vec, err := r.vector(ctx, src.ID, vector)
+			if err != nil {
+				return compared, 0, err
+			}
+			if vec == nil {
+				if at, err := time.Parse(time.RFC3339Nano, ev.CreatedAt); err == nil && time.Since(at) < vectorGrace {
+					return compared, len(matches), save()
+				}
+				log.Printf("Watch %s: source %s has no vector, skipped", w.ID, src.ID)
+				cursor = ev.Seq
+				continue
+			}
+
+			compared++
+			if len(vec) == len(w.Embedding) {
+				score := float64(embedding.CosineSimilarity(w.Embedding, vec))
+				if score >= w.Threshold {
+					matches = append(matches, database.WatchMatch{
+						WatchID:   w.ID,
+						UserID:    w.UserID,
+						SourceID:  src.ID,
+						Score:     math.Round(score*1000) / 1000,
+						MatchedAt: time.Now().UTC().Format(time.RFC3339),
+					})
+				}
+			}
+			cursor = ev.Seq
+		}
+		if len(events) < batchSize {
+			break
+		}
+	}
+	return compared, len(matches), save()
+}
+
+// isCreation reports whether an event created a source
+func (r *run) isCreation(ctx context.Context, ev database.Event) (bool, error) {
+	if ev.Type != database.EventSourceUpserted {
+		return false, nil
+	}
+	if created, ok := r.created[ev.Seq]; ok {
+		return created, nil
+	}
+	eventType, err := webhook.EventType(ctx, r.db, ev)
+	if err != nil {
+		return false, err
+	}
+	r.created[ev.Seq] = eventType == webhook.EventSourceCreated
+	return r.created[ev.Seq], nil
+}
+
+// source returns a source as it is now, nil if it was deleted since
+func (r *run) source(ctx context.Context, id string) (*database.Source, error) {
+	if src, ok := r.sources[id]; ok {
+		return src, nil
+	}
+	src, err := r.db.GetSource(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source %s: %w", id, err)
+	}
+	r.sources[id] = src
+	return src, nil
+}
+
+// vector returns the stored vector of a source, nil if it has none yet
+func (r *run) vector(ctx context.Context, id, vector string) ([]float32, error) {
+	key := vector + "\x00" + id
+	if vec, ok := r.vecs[key]; ok {
+		return vec, nil
+	}
+	vec, err := r.vectors.GetSourceVector(ctx, id, vector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vector of source %s: %w", id, err)
+	}
+	if vec != nil {
+		r.vecs[key] = vec
+	}
+	return vec, nil
+}
+
+// deliver POSTs the undelivered matches of watches with a URL whose backoff
+// has passed, returning how many were delivered and how many failed
+func deliver(ctx context.Context, db store.Store) (int, int, error) {
+	matches, err := db.UndeliveredWatchMatches(ctx, MaxAttempts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list undelivered matches: %w", err)
+	}
+	watches := make(map[string]*database.Watch)
+	delivered, failed := 0, 0
+	now := time.Now().UTC()
+	for _, m := range matches {
+		if m.Attempts > 0 {
+			last, err := time.Parse(time.RFC3339, m.LastAttemptAt)
+			if err == nil && now.Before(last.Add(minBackoff<<(m.Attempts-1))) {
+				continue
+			}
+		}
+		w, ok := watches[m.WatchID]
+		if !ok {
+			if w, err = db.GetWatch(ctx, m.WatchID); err != nil {
+				return delivered, failed, err
+			}
+			watches[m.WatchID] = w
+		}
+		src, err := db.GetSource(ctx, m.SourceID)
+		if err != nil {
+			return delivered, failed, err
+		}
+		if w == nil || src == nil {
+			continue
+		}
+
+		sendErr := Send(ctx, *w, NewNotification(m, *w, *src))
+		if ctx.Err() != nil {
+			return delivered, failed, ctx.Err()
+		}
+		errMsg := ""
+		if sendErr != nil {
+			errMsg = sendErr.Error()
+			failed++
+			log.Printf("Watch %s: delivery of match %d failed: %v", w.ID, m.Seq, sendErr)
+		} else {
+			delivered++
+		}
+		if err := db.RecordWatchDelivery(ctx, m.Seq, time.Now().UTC().Format(time.RFC3339), errMsg); err != nil {
+			return delivered, failed, err
+		}
+	}
+	return delivered, failed, nil
+}
+
+// Send POSTs a notification to a watch's URL as a webhook delivery of type
+// watch.matched, signed with the watch's secret
+func Send(ctx context.Context, w database.Watch, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = webhook.Send(ctx, database.Webhook{ID: w.ID, URL: w.URL, Secret: w.Secret}, webhook.Payload{
+		Type:       EventMatched,
+		Seq:        n.Seq,
+		EntityID:   n.SourceID,
+		OccurredAt: n.MatchedAt,
+		Data:       data,
+	})
+	return err
+}
+
+// Schedule compares new sources with the watches every interval until ctx
+// is cancelled
+func Schedule(ctx context.Context, interval time.Duration, db store.Store, vectors store.VectorStore) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			stats, err := Run(ctx, db, vectors)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Watch matching failed: %v", err)
+				}
+				continue
+			}
+			// Most runs find nothing new
+			if stats.Compared > 0 || stats.Delivered > 0 || stats.Failed > 0 {
+				log.Printf("Watches: %d sources compared with %d watches, %d matches, %d delivered, %d failed (%s)",
+					stats.Compared, stats.Watches, stats.Matches, stats.Delivered, stats.Failed, time.Since(start).Round(time.Millisecond))
+			}
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package webhook delivers the events of the event log to the endpoints
+// registered with POST /webhooks, so downstream services don't have to poll
+// GET /events. Each endpoint receives the events it subscribes to in log
+// order, as JSON signed with its secret; a failing endpoint is retried with
+// backoff and resumes where it stopped. Deliveries follow the log rather
+// than the API handlers, so writes of the CLIs (ingest, indexer) are
+// delivered too.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gitopedia/knowledge-base/internal/database"
+	"github.com/gitopedia/knowledge-base/internal/store"
+)
+
+// Webhook event types. Most are the event log types; source upserts are
+// split into creations and updates, and article upserts are "indexed".
+const (
+	EventSourceCreated    = "source.created"
+	EventSourceUpdated    = "source.updated"
+	EventSourceDeleted    = "source.deleted"
+	EventSourcePurged     = "source.purged"
+	EventArticleIndexed   = "article.indexed"
+	EventArticleDeleted   = "article.deleted"
+	EventAliasAdded       = "alias.added"
+	EventEntityAliasAdded = "entity_alias.added"
+	EventLinkReviewed     = "link.reviewed"
+	EventCategoryUpdated  = "category.updated"
+	EventClaimsExtracted  = "claims.extracted"
+	EventTopicUpdated     = "topic.updated"
+	EventTopicDeleted     = "topic.deleted"
+	// EventPing is sent by POST /webhooks/{id}/ping only
+	EventPing = "ping"
+)
+
+// EventTypes lists the event types webhooks may subscribe to
+var EventTypes = []string{
+	EventSourceCreated, EventSourceUpdated, EventSourceDeleted, EventSourcePurged,
+	EventArticleIndexed, EventArticleDeleted,
+	EventAliasAdded, EventEntityAliasAdded, EventLinkReviewed,
+	EventCategoryUpdated, EventClaimsExtracted,
+	EventTopicUpdated, EventTopicDeleted,
+}
+
+// Headers of a delivery
+const (
+	HeaderEvent     = "X-KB-Event"
+	HeaderDelivery  = "X-KB-Delivery" // The event's sequence number
+	HeaderTimestamp = "X-KB-Timestamp"
+	HeaderSignature = "X-KB-Signature"
+)
+
+const (
+	// batchSize is how many events are read from the log at a time
+	batchSize = 100
+	// maxEventsPerRun bounds the events one webhook handles per run, so a
+	// webhook catching up doesn't hold back the others
+	maxEventsPerRun = 1000
+	// minBackoff and maxBackoff bound the wait before retrying a failing
+	// webhook, doubling with each consecutive failure
+	minBackoff = 10 * time.Second
+	maxBackoff = time.Hour
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	Type       string          `json:"type"`
+	Seq        int64           `json:"seq"` // Event log sequence number; receivers dedupe by it
+	EntityID   string          `json:"entity_id,omitempty"`
+	OccurredAt string          `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"` // The record after the change
+	// Redacted is set when Data is withheld: events about sources that
+	// aren't public carry their ID only
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// Sign returns the signature of a delivery: "sha256=" and the hex HMAC-SHA256
+// of the timestamp header, a dot and the body, keyed with the webhook's
+// secret. Receivers recompute it, and reject stale timestamps to stop
+// replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// EventType returns the webhook event type of a logged event, looking up
+// the entity's earlier events to tell a source's creation from an update
+func EventType(ctx context.Context, db store.EventLog, ev database.Event) (string, error) {
+	switch ev.Type {
+	case database.EventSourceUpserted:
+		prev, err := db.PreviousEvent(ctx, ev.EntityID, ev.Seq, database.EventSourceUpserted, database.EventSourceDeleted)
+		if err != nil {
+			return "", err
+		}
+		if prev == nil || prev.Type == database.EventSourceDeleted {
+			return EventSourceCreated, nil
+		}
+		return EventSourceUpdated, nil
+	case database.EventArticleUpserted:
+		return EventArticleIndexed, nil
+	case database.EventCategoryUpserted:
+		return EventCategoryUpdated, nil
+	case database.EventTopicUpserted:
+		return EventTopicUpdated, nil
+	}
+	return ev.Type, nil
+}
+
+// Stats summarizes a delivery run
+type Stats struct {
+	Webhooks  int // Webhooks with events to deliver
+	Delivered int
+	Failed    int // Webhooks whose delivery failed, to be retried
+	Waiting   int // Failing webhooks skipped until their backoff passes
+}
+
+// Run delivers the pending events of every webhook
+func Run(ctx context.Context, db store.Store) (Stats, error) {
+	var stats Stats
+	hooks, err := db.ListWebhooks(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	now := time.Now().UTC()
+	for _, hook := range hooks {
+		if waiting(hook, now) {
+			stats.Waiting++
+			continue
+		}
+		delivered, err := deliverPending(ctx, db, hook)
+		if delivered > 0 || err != nil {
+			stats.Webhooks++
+		}
+		stats.Delivered += delivered
+		if err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			stats.Failed++
+			log.Printf("Webhook %s failed: %v", hook.ID, err)
+		}
+	}
+	return stats, nil
+}
+
+// waiting reports whether a failing webhook is still backing off
+func waiting(hook database.Webhook, now time.Time) bool {
+	if hook.Failures == 0 {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, hook.LastDeliveryAt)
+	if err != nil {
+		return false
+	}
+	backoff := maxBackoff
+	if hook.Failures <= 10 {
+		backoff = min(minBackoff<<(hook.Failures-1), maxBackoff)
+	}
+	return now.Before(last.Add(backoff))
+}
+
+// deliverPending sends a webhook the events after its cursor, in order,
+// up to maxEventsPerRun. It stops at the first failed delivery, which is
+// retried on a later run.
+func deliverPending(ctx context.Context, db store.Store, hook database.Webhook) (int, error) {
+	cursor := hook.Cursor
+	delivered := 0
+	defer func() {
+		// Record the events skipped since the last delivery
+		if cursor > hook.Cursor && ctx.Err() == nil {
+			if err := db.RecordWebhookAttempt(ctx, hook.ID, database.WebhookAttempt{Cursor: cursor}); err != nil {
+				log.Printf("Webhook %s: %v", hook.ID, err)
+			}
+		}
+	}()
+
+	for handled := 0; handled < maxEventsPerRun; {
+		events, err := db.EventsSince(ctx, cursor, batchSize)
+		if err != nil {
+			return delivered, fmt.Errorf("failed to read events: %w", err)
+		}
+		for _, ev := range events {
+			handled++
+			eventType, err := EventType(ctx, db, ev)
+			if err != nil {
+				return delivered, fmt.Errorf("failed to read events: %w", err)
+			}
+			if !hook.Subscribes(eventType) {
+				cursor = ev.Seq
+				continue
+			}
+
+			payload := Payload{Type: eventType, Seq: ev.Seq, EntityID: ev.EntityID, OccurredAt: ev.CreatedAt, Data: ev.Data}
+			// Endpoints act for no user, so they only see public sources
+			public, err := database.EventVisibleTo(ctx, db, ev, "")
+			if err != nil {
+				return delivered, fmt.Errorf("failed to read events: %w", err)
+			}
+			if !public {
+				payload.Data, payload.Redacted = nil, true
+			}
+			status, sendErr := Send(ctx, hook, payload)
+			attempt := database.WebhookAttempt{
+				Cursor:    cursor,
+				At:        time.Now().UTC().Format(time.RFC3339),
+				Status:    status,
+				Delivered: true,
+			}
+			if sendErr != nil {
+				attempt.Error = sendErr.Error()
+			} else {
+				attempt.Cursor = ev.Seq
+			}
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			if err := db.RecordWebhookAttempt(ctx, hook.ID, attempt); err != nil {
+				return delivered, err
+			}
+			hook.Cursor = attempt.Cursor
+			if sendErr != nil {
+				return delivered, fmt.Errorf("event %d: %w", ev.Seq, sendErr)
+			}
+			cursor = ev.Seq
+			delivered++
+		}
+		if len(events) < batchSize {
+			break
+		}
+	}
+	return delivered, nil
+}
+
+// Send POSTs a signed payload to a webhook, returning the HTTP status of
+// the response (0 if there was none). Statuses other than 2xx are errors.
+func Send(ctx context.Context, hook database.Webhook, payload Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gitopedia-knowledge-base")
+	req.Header.Set(HeaderEvent, payload.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(payload.Seq, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Schedule delivers pending events every interval until ctx is cancelled
+func Schedule(ctx context.Context, interval time.Duration, db store.Store) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			stats, err := Run(ctx, db)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Webhook delivery failed: %v", err)
+				}
+				continue
+			}
+			// Most runs find nothing to deliver
+			if stats.Webhooks > 0 {
+				log.Printf("Webhooks: %d events delivered to %d webhooks, %d failed (%s)",
+					stats.Delivered, stats.Webhooks, stats.Failed, time.Since(start).Round(time.Millisecond))
+			}
+		}
+	}
+}
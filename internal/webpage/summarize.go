@@ -0,0 +1,37 @@
+package webpage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gitopedia/knowledge-base/internal/generation"
+	"github.com/gitopedia/knowledge-base/internal/summarystyle"
+)
+
+// maxSummaryInputRunes bounds the page text a summary is written from, so
+// long pages fit the context of small local models
+const maxSummaryInputRunes = 12000
+
+// summarySystem follows the prompt of the summary style profile
+const summarySystem = " Work only from the text of the source; do not add facts it doesn't state. Answer with the summary only, without a heading or preamble."
+
+// Summarize writes the summary of a page with a generation model, in a
+// summary style profile
+func Summarize(ctx context.Context, g generation.Generator, profile summarystyle.Profile, page Page) (string, error) {
+	text := page.Text
+	if runes := []rune(text); len(runes) > maxSummaryInputRunes {
+		text = string(runes[:maxSummaryInputRunes])
+	}
+	var prompt strings.Builder
+	if page.Title != "" {
+		fmt.Fprintf(&prompt, "Title: %s\n", page.Title)
+	}
+	fmt.Fprintf(&prompt, "URL: %s\n\n%s", page.URL, text)
+
+	summary, err := g.Generate(ctx, prompt.String(), generation.Options{System: profile.Prompt() + summarySystem})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
@@ -0,0 +1,355 @@
+// Package webpage fetches web pages and extracts their main text the way
+// reader modes do: navigation, footers, comments and other boilerplate are
+// dropped, and the block of the page holding most of the prose is kept.
+// Ingest summarizes the text of sources given only as a URL (-fetch).
+package webpage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxPageBytes bounds the HTML read from a page
+const maxPageBytes = 5 << 20
+
+// Page is the extracted content of a web page
+type Page struct {
+	URL         string // After redirects
+	Title       string
+	Description string // The page's meta description, if any
+	Language    string // ISO 639-1 code of the html lang attribute, if any
+	// PublishedAt is the publication time of article metadata, as the page
+	// gives it
+	PublishedAt string
+	// Text is the main text, paragraphs separated by blank lines
+	Text string
+}
+
+// Fetcher downloads web pages
+type Fetcher struct {
+	httpClient *http.Client
+}
+
+// NewFetcher creates a fetcher with a 30 second timeout per page
+func NewFetcher() *Fetcher {
+	return &Fetcher{httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Fetch downloads a page and extracts its content. Plain text pages are
+// kept as they are; other content types are refused.
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) (Page, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "gitopedia-knowledge-base")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9")
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return Page{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Page{}, fmt.Errorf("page returned status %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, maxPageBytes)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var page Page
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "":
+		if page, err = Extract(body); err != nil {
+			return Page{}, err
+		}
+	case "text/plain":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return Page{}, fmt.Errorf("failed to read page: %w", err)
+		}
+		page.Text = strings.TrimSpace(string(data))
+	default:
+		return Page{}, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	page.URL = resp.Request.URL.String()
+	if !utf8.ValidString(page.Text) {
+		page.Text = strings.ToValidUTF8(page.Text, "")
+	}
+	if page.Text == "" {
+		return Page{}, fmt.Errorf("no text found")
+	}
+	return page, nil
+}
+
+// Extract reads the metadata and the main text of an HTML page
+func Extract(r io.Reader) (Page, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to parse page: %w", err)
+	}
+	var page Page
+	readMetadata(doc, &page)
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	prune(body)
+	root := mainBlock(body)
+	if root == nil {
+		root = body
+	}
+	page.Text = blockText(root)
+	return page, nil
+}
+
+// readMetadata reads the title, description, language and publication
+// time, preferring Open Graph and article metadata
+func readMetadata(doc *html.Node, page *Page) {
+	var title string
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Html:
+			// en-US and en_US are en
+			lang, _, _ := strings.Cut(strings.ReplaceAll(attr(n, "lang"), "_", "-"), "-")
+			page.Language = strings.ToLower(strings.TrimSpace(lang))
+		case atom.Title:
+			if title == "" {
+				title = collapseSpace(textOf(n))
+			}
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property") + attr(n, "name"))
+			content := collapseSpace(attr(n, "content"))
+			switch key {
+			case "og:title":
+				page.Title = content
+			case "description", "og:description":
+				if page.Description == "" {
+					page.Description = content
+				}
+			case "article:published_time", "datepublished", "date", "dc.date":
+				if page.PublishedAt == "" {
+					page.PublishedAt = content
+				}
+			}
+		}
+	}
+	if page.Title == "" {
+		page.Title = title
+	}
+}
+
+// boilerplateTags are elements that never hold the main text
+var boilerplateTags = []atom.Atom{
+	atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Iframe,
+	atom.Nav, atom.Header, atom.Footer, atom.Aside, atom.Form, atom.Button,
+	atom.Select, atom.Textarea, atom.Object, atom.Embed, atom.Canvas,
+}
+
+var (
+	// unlikelyPattern matches the class and id of boilerplate blocks
+	unlikelyPattern = regexp.MustCompile(`(?i)comment|sidebar|footer|header|menu|navbar|breadcrumb|share|social|advert|sponsor|promo|related|recommend|newsletter|subscribe|cookie|consent|banner|popup|modal|masthead|pagination|disqus`)
+	// likelyPattern matches the class and id of content blocks, which are
+	// kept even when they also match unlikelyPattern
+	likelyPattern = regexp.MustCompile(`(?i)article|content|main|post|entry|story|body|text|prose`)
+)
+
+// prune removes the boilerplate elements and hidden elements
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && isBoilerplate(c) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if slices.Contains(boilerplateTags, n.DataAtom) {
+		return true
+	}
+	if _, hidden := attrOK(n, "hidden"); hidden || attr(n, "aria-hidden") == "true" ||
+		strings.Contains(strings.ReplaceAll(attr(n, "style"), " ", ""), "display:none") {
+		return true
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "complementary" || role == "banner" || role == "contentinfo" {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyPattern.MatchString(names) && !likelyPattern.MatchString(names)
+}
+
+// minParagraphRunes is the length below which a paragraph doesn't count
+// towards the score of its block
+const minParagraphRunes = 25
+
+// mainBlock returns the element holding most of the prose: paragraphs
+// score their parent, and half as much their grandparent, by their length
+// and commas; blocks named like content score more, and links count
+// against them. Nil means no paragraph was found.
+func mainBlock(body *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node // In document order, so ties go to the first
+	add := func(n *html.Node, score float64) {
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	for n := range body.Descendants() {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td && n.DataAtom != atom.Blockquote) {
+			continue
+		}
+		text := collapseSpace(textOf(n))
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphRunes {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+		if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+			add(parent, score)
+			if grandparent := parent.Parent; grandparent != nil && grandparent.Type == html.ElementNode {
+				add(grandparent, score/2)
+			}
+		}
+	}
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		names := attr(n, "class") + " " + attr(n, "id")
+		if likelyPattern.MatchString(names) {
+			score += 25
+		}
+		if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			score += 25
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// linkDensity is the share of an element's text inside links
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(textOf(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == atom.A {
+			links += utf8.RuneCountInString(textOf(d))
+		}
+	}
+	return float64(links) / float64(total)
+}
+
+// blockElements start a new paragraph of the extracted text
+var blockElements = []atom.Atom{
+	atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Li, atom.Ul, atom.Ol,
+	atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Pre, atom.Blockquote,
+	atom.Table, atom.Tr, atom.Dd, atom.Dt, atom.Figcaption, atom.Br, atom.Hr,
+}
+
+// blockText returns the text of an element, one paragraph per block
+// element. Whitespace is collapsed within paragraphs, except in pre.
+func blockText(root *html.Node) string {
+	var paragraphs []string
+	var cur strings.Builder
+	end := func() {
+		if p := collapseSpace(cur.String()); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+		cur.Reset()
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			cur.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if n.DataAtom == atom.Pre {
+				end()
+				if p := strings.TrimSpace(textOf(n)); p != "" {
+					paragraphs = append(paragraphs, p)
+				}
+				return
+			}
+			if slices.Contains(blockElements, n.DataAtom) {
+				end()
+				defer end()
+			}
+			if n.DataAtom == atom.Li {
+				cur.WriteString("- ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	end()
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// findFirst returns the first element of a type
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == a {
+			return d
+		}
+	}
+	return nil
+}
+
+// textOf returns the text inside a node
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			b.WriteString(d.Data)
+		}
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
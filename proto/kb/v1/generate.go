@@ -0,0 +1,6 @@
+// Package kbv1 is the gRPC API of the knowledge base, generated from
+// kb.proto. Serve it with api.NewGRPCServer; clients use
+// NewKnowledgeBaseClient.
+package kbv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative kb.proto
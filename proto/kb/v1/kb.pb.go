@@ -0,0 +1,1475 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: kb.proto
+
+// gRPC API of the knowledge base. The operations are those of the HTTP API
+// with the same validation, auth and limits: each call is served by the HTTP
+// handler of its endpoint, and messages use the field names of its JSON.
+//
+// Metadata: "authorization" ("Bearer kb_...") carries an API key, and
+// "x-user-id" the user of personalized searches and private sources, as the
+// HTTP headers do. HTTP errors map to gRPC status codes (400 to
+// INVALID_ARGUMENT, 404 to NOT_FOUND, 429 to RESOURCE_EXHAUSTED, ...), with
+// the field errors of a 400 as google.rpc.BadRequest details.
+
+package kbv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CreateSourceRequest is the source to store
+type CreateSourceRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url       string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Title     string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Topic     string                 `protobuf:"bytes,4,opt,name=topic,proto3" json:"topic,omitempty"`
+	Summary   string                 `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	Language  string                 `protobuf:"bytes,6,opt,name=language,proto3" json:"language,omitempty"`
+	Model     string                 `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	CreatedAt string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the source was published: an RFC 3339 time or a date (2006-01-02,
+	// 2006-01 or 2006)
+	PublishedAt string   `protobuf:"bytes,9,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	Tags        []string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	// public (default), internal, or private to the caller
+	Visibility string `protobuf:"bytes,11,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// paper, blog, video, or dataset
+	Type         string      `protobuf:"bytes,12,opt,name=type,proto3" json:"type,omitempty"`
+	People       []string    `protobuf:"bytes,13,rep,name=people,proto3" json:"people,omitempty"`
+	Orgs         []string    `protobuf:"bytes,14,rep,name=orgs,proto3" json:"orgs,omitempty"`
+	Places       []string    `protobuf:"bytes,15,rep,name=places,proto3" json:"places,omitempty"`
+	Locations    []*Location `protobuf:"bytes,16,rep,name=locations,proto3" json:"locations,omitempty"`
+	SummaryStyle string      `protobuf:"bytes,17,opt,name=summary_style,json=summaryStyle,proto3" json:"summary_style,omitempty"`
+	// Full text of the page, indexed for keyword search
+	Content       string `protobuf:"bytes,18,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSourceRequest) Reset() {
+	*x = CreateSourceRequest{}
+	mi := &file_kb_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSourceRequest) ProtoMessage() {}
+
+func (x *CreateSourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSourceRequest.ProtoReflect.Descriptor instead.
+func (*CreateSourceRequest) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateSourceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetPublishedAt() string {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateSourceRequest) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetPeople() []string {
+	if x != nil {
+		return x.People
+	}
+	return nil
+}
+
+func (x *CreateSourceRequest) GetOrgs() []string {
+	if x != nil {
+		return x.Orgs
+	}
+	return nil
+}
+
+func (x *CreateSourceRequest) GetPlaces() []string {
+	if x != nil {
+		return x.Places
+	}
+	return nil
+}
+
+func (x *CreateSourceRequest) GetLocations() []*Location {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *CreateSourceRequest) GetSummaryStyle() string {
+	if x != nil {
+		return x.SummaryStyle
+	}
+	return ""
+}
+
+func (x *CreateSourceRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// Location is where a place mentioned by a source is
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Place         string                 `protobuf:"bytes,1,opt,name=place,proto3" json:"place,omitempty"`
+	Lat           float64                `protobuf:"fixed64,2,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,3,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_kb_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Location) GetPlace() string {
+	if x != nil {
+		return x.Place
+	}
+	return ""
+}
+
+func (x *Location) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Location) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+type CreateSourceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Sensitive data flagged or redacted in the summary or content
+	Findings      []*Finding `protobuf:"bytes,2,rep,name=findings,proto3" json:"findings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSourceResponse) Reset() {
+	*x = CreateSourceResponse{}
+	mi := &file_kb_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSourceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSourceResponse) ProtoMessage() {}
+
+func (x *CreateSourceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSourceResponse.ProtoReflect.Descriptor instead.
+func (*CreateSourceResponse) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateSourceResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateSourceResponse) GetFindings() []*Finding {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+// Finding is a sensitive string found by the scan of KB_SCAN_MODE
+type Finding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // Byte offset in the scanned text
+	Hint          string                 `protobuf:"bytes,3,opt,name=hint,proto3" json:"hint,omitempty"`      // Masked excerpt
+	Field         string                 `protobuf:"bytes,4,opt,name=field,proto3" json:"field,omitempty"`    // The scanned field when it isn't the summary
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Finding) Reset() {
+	*x = Finding{}
+	mi := &file_kb_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Finding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Finding) ProtoMessage() {}
+
+func (x *Finding) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Finding.ProtoReflect.Descriptor instead.
+func (*Finding) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Finding) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Finding) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Finding) GetHint() string {
+	if x != nil {
+		return x.Hint
+	}
+	return ""
+}
+
+func (x *Finding) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+// SearchSourcesRequest is a vector search of the sources. The options are
+// those of POST /sources/search except facets.
+type SearchSourcesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"` // Text to embed and search
+	// Little-endian float32 embedding, an alternative to query
+	Embedding       []byte   `protobuf:"bytes,2,opt,name=embedding,proto3" json:"embedding,omitempty"`
+	Limit           int32    `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Topic           string   `protobuf:"bytes,4,opt,name=topic,proto3" json:"topic,omitempty"`
+	Domain          string   `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	Type            string   `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	Model           string   `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	Language        string   `protobuf:"bytes,8,opt,name=language,proto3" json:"language,omitempty"`
+	Hybrid          bool     `protobuf:"varint,9,opt,name=hybrid,proto3" json:"hybrid,omitempty"`
+	LateInteraction bool     `protobuf:"varint,10,opt,name=late_interaction,json=lateInteraction,proto3" json:"late_interaction,omitempty"`
+	Rerank          bool     `protobuf:"varint,11,opt,name=rerank,proto3" json:"rerank,omitempty"`
+	HnswEf          int32    `protobuf:"varint,12,opt,name=hnsw_ef,json=hnswEf,proto3" json:"hnsw_ef,omitempty"`
+	Exact           bool     `protobuf:"varint,13,opt,name=exact,proto3" json:"exact,omitempty"`
+	HasCode         bool     `protobuf:"varint,14,opt,name=has_code,json=hasCode,proto3" json:"has_code,omitempty"`
+	HasDataset      bool     `protobuf:"varint,15,opt,name=has_dataset,json=hasDataset,proto3" json:"has_dataset,omitempty"`
+	Tags            []string `protobuf:"bytes,16,rep,name=tags,proto3" json:"tags,omitempty"`
+	TagsMatch       string   `protobuf:"bytes,17,opt,name=tags_match,json=tagsMatch,proto3" json:"tags_match,omitempty"`
+	SourceLanguage  string   `protobuf:"bytes,18,opt,name=source_language,json=sourceLanguage,proto3" json:"source_language,omitempty"`
+	CreatedAfter    string   `protobuf:"bytes,19,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`
+	CreatedBefore   string   `protobuf:"bytes,20,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"`
+	PublishedAfter  string   `protobuf:"bytes,21,opt,name=published_after,json=publishedAfter,proto3" json:"published_after,omitempty"`
+	PublishedBefore string   `protobuf:"bytes,22,opt,name=published_before,json=publishedBefore,proto3" json:"published_before,omitempty"`
+	Near            string   `protobuf:"bytes,23,opt,name=near,proto3" json:"near,omitempty"`
+	NearPlace       string   `protobuf:"bytes,24,opt,name=near_place,json=nearPlace,proto3" json:"near_place,omitempty"`
+	RadiusKm        float64  `protobuf:"fixed64,25,opt,name=radius_km,json=radiusKm,proto3" json:"radius_km,omitempty"`
+	Personalize     bool     `protobuf:"varint,26,opt,name=personalize,proto3" json:"personalize,omitempty"`
+	ProfileWeight   float32  `protobuf:"fixed32,27,opt,name=profile_weight,json=profileWeight,proto3" json:"profile_weight,omitempty"`
+	Fields          []string `protobuf:"bytes,28,rep,name=fields,proto3" json:"fields,omitempty"`
+	SummaryMaxChars int32    `protobuf:"varint,29,opt,name=summary_max_chars,json=summaryMaxChars,proto3" json:"summary_max_chars,omitempty"`
+	Autocorrect     bool     `protobuf:"varint,30,opt,name=autocorrect,proto3" json:"autocorrect,omitempty"`
+	Duplicates      bool     `protobuf:"varint,31,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SearchSourcesRequest) Reset() {
+	*x = SearchSourcesRequest{}
+	mi := &file_kb_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSourcesRequest) ProtoMessage() {}
+
+func (x *SearchSourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSourcesRequest.ProtoReflect.Descriptor instead.
+func (*SearchSourcesRequest) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchSourcesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetEmbedding() []byte {
+	if x != nil {
+		return x.Embedding
+	}
+	return nil
+}
+
+func (x *SearchSourcesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchSourcesRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetHybrid() bool {
+	if x != nil {
+		return x.Hybrid
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetLateInteraction() bool {
+	if x != nil {
+		return x.LateInteraction
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetRerank() bool {
+	if x != nil {
+		return x.Rerank
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetHnswEf() int32 {
+	if x != nil {
+		return x.HnswEf
+	}
+	return 0
+}
+
+func (x *SearchSourcesRequest) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetHasCode() bool {
+	if x != nil {
+		return x.HasCode
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetHasDataset() bool {
+	if x != nil {
+		return x.HasDataset
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *SearchSourcesRequest) GetTagsMatch() string {
+	if x != nil {
+		return x.TagsMatch
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetSourceLanguage() string {
+	if x != nil {
+		return x.SourceLanguage
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetCreatedAfter() string {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetCreatedBefore() string {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetPublishedAfter() string {
+	if x != nil {
+		return x.PublishedAfter
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetPublishedBefore() string {
+	if x != nil {
+		return x.PublishedBefore
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetNear() string {
+	if x != nil {
+		return x.Near
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetNearPlace() string {
+	if x != nil {
+		return x.NearPlace
+	}
+	return ""
+}
+
+func (x *SearchSourcesRequest) GetRadiusKm() float64 {
+	if x != nil {
+		return x.RadiusKm
+	}
+	return 0
+}
+
+func (x *SearchSourcesRequest) GetPersonalize() bool {
+	if x != nil {
+		return x.Personalize
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetProfileWeight() float32 {
+	if x != nil {
+		return x.ProfileWeight
+	}
+	return 0
+}
+
+func (x *SearchSourcesRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *SearchSourcesRequest) GetSummaryMaxChars() int32 {
+	if x != nil {
+		return x.SummaryMaxChars
+	}
+	return 0
+}
+
+func (x *SearchSourcesRequest) GetAutocorrect() bool {
+	if x != nil {
+		return x.Autocorrect
+	}
+	return false
+}
+
+func (x *SearchSourcesRequest) GetDuplicates() bool {
+	if x != nil {
+		return x.Duplicates
+	}
+	return false
+}
+
+type SearchSourcesResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Results        []*SearchResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Count          int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	EmbeddingModel string                 `protobuf:"bytes,3,opt,name=embedding_model,json=embeddingModel,proto3" json:"embedding_model,omitempty"`
+	Hybrid         bool                   `protobuf:"varint,4,opt,name=hybrid,proto3" json:"hybrid,omitempty"`
+	Scores         *ScoreSemantics        `protobuf:"bytes,5,opt,name=scores,proto3" json:"scores,omitempty"`
+	Personalized   bool                   `protobuf:"varint,6,opt,name=personalized,proto3" json:"personalized,omitempty"`
+	RerankModel    string                 `protobuf:"bytes,7,opt,name=rerank_model,json=rerankModel,proto3" json:"rerank_model,omitempty"`
+	DidYouMean     string                 `protobuf:"bytes,8,opt,name=did_you_mean,json=didYouMean,proto3" json:"did_you_mean,omitempty"`
+	Corrected      bool                   `protobuf:"varint,9,opt,name=corrected,proto3" json:"corrected,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchSourcesResponse) Reset() {
+	*x = SearchSourcesResponse{}
+	mi := &file_kb_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSourcesResponse) ProtoMessage() {}
+
+func (x *SearchSourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSourcesResponse.ProtoReflect.Descriptor instead.
+func (*SearchSourcesResponse) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchSourcesResponse) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SearchSourcesResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SearchSourcesResponse) GetEmbeddingModel() string {
+	if x != nil {
+		return x.EmbeddingModel
+	}
+	return ""
+}
+
+func (x *SearchSourcesResponse) GetHybrid() bool {
+	if x != nil {
+		return x.Hybrid
+	}
+	return false
+}
+
+func (x *SearchSourcesResponse) GetScores() *ScoreSemantics {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+func (x *SearchSourcesResponse) GetPersonalized() bool {
+	if x != nil {
+		return x.Personalized
+	}
+	return false
+}
+
+func (x *SearchSourcesResponse) GetRerankModel() string {
+	if x != nil {
+		return x.RerankModel
+	}
+	return ""
+}
+
+func (x *SearchSourcesResponse) GetDidYouMean() string {
+	if x != nil {
+		return x.DidYouMean
+	}
+	return ""
+}
+
+func (x *SearchSourcesResponse) GetCorrected() bool {
+	if x != nil {
+		return x.Corrected
+	}
+	return false
+}
+
+type SearchResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url            string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Title          string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Topic          string                 `protobuf:"bytes,4,opt,name=topic,proto3" json:"topic,omitempty"`
+	Summary        string                 `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	Score          float32                `protobuf:"fixed32,6,opt,name=score,proto3" json:"score,omitempty"`
+	Tags           []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Language       string                 `protobuf:"bytes,8,opt,name=language,proto3" json:"language,omitempty"`
+	Model          string                 `protobuf:"bytes,9,opt,name=model,proto3" json:"model,omitempty"`
+	CreatedAt      string                 `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Type           string                 `protobuf:"bytes,11,opt,name=type,proto3" json:"type,omitempty"`
+	Linked         bool                   `protobuf:"varint,12,opt,name=linked,proto3" json:"linked,omitempty"`
+	Alternates     []*Alternate           `protobuf:"bytes,13,rep,name=alternates,proto3" json:"alternates,omitempty"`
+	WordCount      int32                  `protobuf:"varint,14,opt,name=word_count,json=wordCount,proto3" json:"word_count,omitempty"`
+	ReadingMinutes int32                  `protobuf:"varint,15,opt,name=reading_minutes,json=readingMinutes,proto3" json:"reading_minutes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	mi := &file_kb_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *SearchResult) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *SearchResult) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SearchResult) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *SearchResult) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SearchResult) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *SearchResult) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *SearchResult) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SearchResult) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *SearchResult) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *SearchResult) GetLinked() bool {
+	if x != nil {
+		return x.Linked
+	}
+	return false
+}
+
+func (x *SearchResult) GetAlternates() []*Alternate {
+	if x != nil {
+		return x.Alternates
+	}
+	return nil
+}
+
+func (x *SearchResult) GetWordCount() int32 {
+	if x != nil {
+		return x.WordCount
+	}
+	return 0
+}
+
+func (x *SearchResult) GetReadingMinutes() int32 {
+	if x != nil {
+		return x.ReadingMinutes
+	}
+	return 0
+}
+
+// Alternate is a duplicate collapsed into a search result
+type Alternate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Score         float32                `protobuf:"fixed32,4,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alternate) Reset() {
+	*x = Alternate{}
+	mi := &file_kb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alternate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alternate) ProtoMessage() {}
+
+func (x *Alternate) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alternate.ProtoReflect.Descriptor instead.
+func (*Alternate) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Alternate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Alternate) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Alternate) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Alternate) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+// ScoreSemantics tells how to read the scores of a search
+type ScoreSemantics struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Metric         string                 `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	HigherIsBetter bool                   `protobuf:"varint,2,opt,name=higher_is_better,json=higherIsBetter,proto3" json:"higher_is_better,omitempty"`
+	Min            *float64               `protobuf:"fixed64,3,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max            *float64               `protobuf:"fixed64,4,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ScoreSemantics) Reset() {
+	*x = ScoreSemantics{}
+	mi := &file_kb_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreSemantics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreSemantics) ProtoMessage() {}
+
+func (x *ScoreSemantics) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreSemantics.ProtoReflect.Descriptor instead.
+func (*ScoreSemantics) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ScoreSemantics) GetMetric() string {
+	if x != nil {
+		return x.Metric
+	}
+	return ""
+}
+
+func (x *ScoreSemantics) GetHigherIsBetter() bool {
+	if x != nil {
+		return x.HigherIsBetter
+	}
+	return false
+}
+
+func (x *ScoreSemantics) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *ScoreSemantics) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+type GetArticleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetArticleRequest) Reset() {
+	*x = GetArticleRequest{}
+	mi := &file_kb_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetArticleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArticleRequest) ProtoMessage() {}
+
+func (x *GetArticleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArticleRequest.ProtoReflect.Descriptor instead.
+func (*GetArticleRequest) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetArticleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Article struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Path           string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Author         string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	Summary        string                 `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	Tags           []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	Meta           *structpb.Struct       `protobuf:"bytes,7,opt,name=meta,proto3" json:"meta,omitempty"` // Frontmatter fields
+	Content        string                 `protobuf:"bytes,8,opt,name=content,proto3" json:"content,omitempty"`
+	Sources        []string               `protobuf:"bytes,9,rep,name=sources,proto3" json:"sources,omitempty"`
+	WordCount      int32                  `protobuf:"varint,10,opt,name=word_count,json=wordCount,proto3" json:"word_count,omitempty"`
+	ReadingMinutes int32                  `protobuf:"varint,11,opt,name=reading_minutes,json=readingMinutes,proto3" json:"reading_minutes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Article) Reset() {
+	*x = Article{}
+	mi := &file_kb_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Article) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Article) ProtoMessage() {}
+
+func (x *Article) ProtoReflect() protoreflect.Message {
+	mi := &file_kb_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Article.ProtoReflect.Descriptor instead.
+func (*Article) Descriptor() ([]byte, []int) {
+	return file_kb_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Article) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Article) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Article) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Article) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Article) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Article) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Article) GetMeta() *structpb.Struct {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Article) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Article) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *Article) GetWordCount() int32 {
+	if x != nil {
+		return x.WordCount
+	}
+	return 0
+}
+
+func (x *Article) GetReadingMinutes() int32 {
+	if x != nil {
+		return x.ReadingMinutes
+	}
+	return 0
+}
+
+var File_kb_proto protoreflect.FileDescriptor
+
+const file_kb_proto_rawDesc = "" +
+	"\n" +
+	"\bkb.proto\x12\x0fgitopedia.kb.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xf5\x03\n" +
+	"\x13CreateSourceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05topic\x18\x04 \x01(\tR\x05topic\x12\x18\n" +
+	"\asummary\x18\x05 \x01(\tR\asummary\x12\x1a\n" +
+	"\blanguage\x18\x06 \x01(\tR\blanguage\x12\x14\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12!\n" +
+	"\fpublished_at\x18\t \x01(\tR\vpublishedAt\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\v \x01(\tR\n" +
+	"visibility\x12\x12\n" +
+	"\x04type\x18\f \x01(\tR\x04type\x12\x16\n" +
+	"\x06people\x18\r \x03(\tR\x06people\x12\x12\n" +
+	"\x04orgs\x18\x0e \x03(\tR\x04orgs\x12\x16\n" +
+	"\x06places\x18\x0f \x03(\tR\x06places\x127\n" +
+	"\tlocations\x18\x10 \x03(\v2\x19.gitopedia.kb.v1.LocationR\tlocations\x12#\n" +
+	"\rsummary_style\x18\x11 \x01(\tR\fsummaryStyle\x12\x18\n" +
+	"\acontent\x18\x12 \x01(\tR\acontent\"D\n" +
+	"\bLocation\x12\x14\n" +
+	"\x05place\x18\x01 \x01(\tR\x05place\x12\x10\n" +
+	"\x03lat\x18\x02 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x03 \x01(\x01R\x03lon\"\\\n" +
+	"\x14CreateSourceResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x124\n" +
+	"\bfindings\x18\x02 \x03(\v2\x18.gitopedia.kb.v1.FindingR\bfindings\"_\n" +
+	"\aFinding\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x12\n" +
+	"\x04hint\x18\x03 \x01(\tR\x04hint\x12\x14\n" +
+	"\x05field\x18\x04 \x01(\tR\x05field\"\xb5\a\n" +
+	"\x14SearchSourcesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1c\n" +
+	"\tembedding\x18\x02 \x01(\fR\tembedding\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x14\n" +
+	"\x05topic\x18\x04 \x01(\tR\x05topic\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\tR\x04type\x12\x14\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12\x1a\n" +
+	"\blanguage\x18\b \x01(\tR\blanguage\x12\x16\n" +
+	"\x06hybrid\x18\t \x01(\bR\x06hybrid\x12)\n" +
+	"\x10late_interaction\x18\n" +
+	" \x01(\bR\x0flateInteraction\x12\x16\n" +
+	"\x06rerank\x18\v \x01(\bR\x06rerank\x12\x17\n" +
+	"\ahnsw_ef\x18\f \x01(\x05R\x06hnswEf\x12\x14\n" +
+	"\x05exact\x18\r \x01(\bR\x05exact\x12\x19\n" +
+	"\bhas_code\x18\x0e \x01(\bR\ahasCode\x12\x1f\n" +
+	"\vhas_dataset\x18\x0f \x01(\bR\n" +
+	"hasDataset\x12\x12\n" +
+	"\x04tags\x18\x10 \x03(\tR\x04tags\x12\x1d\n" +
+	"\n" +
+	"tags_match\x18\x11 \x01(\tR\ttagsMatch\x12'\n" +
+	"\x0fsource_language\x18\x12 \x01(\tR\x0esourceLanguage\x12#\n" +
+	"\rcreated_after\x18\x13 \x01(\tR\fcreatedAfter\x12%\n" +
+	"\x0ecreated_before\x18\x14 \x01(\tR\rcreatedBefore\x12'\n" +
+	"\x0fpublished_after\x18\x15 \x01(\tR\x0epublishedAfter\x12)\n" +
+	"\x10published_before\x18\x16 \x01(\tR\x0fpublishedBefore\x12\x12\n" +
+	"\x04near\x18\x17 \x01(\tR\x04near\x12\x1d\n" +
+	"\n" +
+	"near_place\x18\x18 \x01(\tR\tnearPlace\x12\x1b\n" +
+	"\tradius_km\x18\x19 \x01(\x01R\bradiusKm\x12 \n" +
+	"\vpersonalize\x18\x1a \x01(\bR\vpersonalize\x12%\n" +
+	"\x0eprofile_weight\x18\x1b \x01(\x02R\rprofileWeight\x12\x16\n" +
+	"\x06fields\x18\x1c \x03(\tR\x06fields\x12*\n" +
+	"\x11summary_max_chars\x18\x1d \x01(\x05R\x0fsummaryMaxChars\x12 \n" +
+	"\vautocorrect\x18\x1e \x01(\bR\vautocorrect\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x1f \x01(\bR\n" +
+	"duplicates\"\xe7\x02\n" +
+	"\x15SearchSourcesResponse\x127\n" +
+	"\aresults\x18\x01 \x03(\v2\x1d.gitopedia.kb.v1.SearchResultR\aresults\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12'\n" +
+	"\x0fembedding_model\x18\x03 \x01(\tR\x0eembeddingModel\x12\x16\n" +
+	"\x06hybrid\x18\x04 \x01(\bR\x06hybrid\x127\n" +
+	"\x06scores\x18\x05 \x01(\v2\x1f.gitopedia.kb.v1.ScoreSemanticsR\x06scores\x12\"\n" +
+	"\fpersonalized\x18\x06 \x01(\bR\fpersonalized\x12!\n" +
+	"\frerank_model\x18\a \x01(\tR\vrerankModel\x12 \n" +
+	"\fdid_you_mean\x18\b \x01(\tR\n" +
+	"didYouMean\x12\x1c\n" +
+	"\tcorrected\x18\t \x01(\bR\tcorrected\"\xa1\x03\n" +
+	"\fSearchResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05topic\x18\x04 \x01(\tR\x05topic\x12\x18\n" +
+	"\asummary\x18\x05 \x01(\tR\asummary\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\x02R\x05score\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12\x1a\n" +
+	"\blanguage\x18\b \x01(\tR\blanguage\x12\x14\n" +
+	"\x05model\x18\t \x01(\tR\x05model\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\tR\tcreatedAt\x12\x12\n" +
+	"\x04type\x18\v \x01(\tR\x04type\x12\x16\n" +
+	"\x06linked\x18\f \x01(\bR\x06linked\x12:\n" +
+	"\n" +
+	"alternates\x18\r \x03(\v2\x1a.gitopedia.kb.v1.AlternateR\n" +
+	"alternates\x12\x1d\n" +
+	"\n" +
+	"word_count\x18\x0e \x01(\x05R\twordCount\x12'\n" +
+	"\x0freading_minutes\x18\x0f \x01(\x05R\x0ereadingMinutes\"Y\n" +
+	"\tAlternate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x02R\x05score\"\x90\x01\n" +
+	"\x0eScoreSemantics\x12\x16\n" +
+	"\x06metric\x18\x01 \x01(\tR\x06metric\x12(\n" +
+	"\x10higher_is_better\x18\x02 \x01(\bR\x0ehigherIsBetter\x12\x15\n" +
+	"\x03min\x18\x03 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x04 \x01(\x01H\x01R\x03max\x88\x01\x01B\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"#\n" +
+	"\x11GetArticleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb2\x02\n" +
+	"\aArticle\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12\x18\n" +
+	"\asummary\x18\x05 \x01(\tR\asummary\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12+\n" +
+	"\x04meta\x18\a \x01(\v2\x17.google.protobuf.StructR\x04meta\x12\x18\n" +
+	"\acontent\x18\b \x01(\tR\acontent\x12\x18\n" +
+	"\asources\x18\t \x03(\tR\asources\x12\x1d\n" +
+	"\n" +
+	"word_count\x18\n" +
+	" \x01(\x05R\twordCount\x12'\n" +
+	"\x0freading_minutes\x18\v \x01(\x05R\x0ereadingMinutes2\x98\x02\n" +
+	"\rKnowledgeBase\x12[\n" +
+	"\fCreateSource\x12$.gitopedia.kb.v1.CreateSourceRequest\x1a%.gitopedia.kb.v1.CreateSourceResponse\x12^\n" +
+	"\rSearchSources\x12%.gitopedia.kb.v1.SearchSourcesRequest\x1a&.gitopedia.kb.v1.SearchSourcesResponse\x12J\n" +
+	"\n" +
+	"GetArticle\x12\".gitopedia.kb.v1.GetArticleRequest\x1a\x18.gitopedia.kb.v1.ArticleB6Z4github.com/gitopedia/knowledge-base/proto/kb/v1;kbv1b\x06proto3"
+
+var (
+	file_kb_proto_rawDescOnce sync.Once
+	file_kb_proto_rawDescData []byte
+)
+
+func file_kb_proto_rawDescGZIP() []byte {
+	file_kb_proto_rawDescOnce.Do(func() {
+		file_kb_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kb_proto_rawDesc), len(file_kb_proto_rawDesc)))
+	})
+	return file_kb_proto_rawDescData
+}
+
+var file_kb_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_kb_proto_goTypes = []any{
+	(*CreateSourceRequest)(nil),   // 0: gitopedia.kb.v1.CreateSourceRequest
+	(*Location)(nil),              // 1: gitopedia.kb.v1.Location
+	(*CreateSourceResponse)(nil),  // 2: gitopedia.kb.v1.CreateSourceResponse
+	(*Finding)(nil),               // 3: gitopedia.kb.v1.Finding
+	(*SearchSourcesRequest)(nil),  // 4: gitopedia.kb.v1.SearchSourcesRequest
+	(*SearchSourcesResponse)(nil), // 5: gitopedia.kb.v1.SearchSourcesResponse
+	(*SearchResult)(nil),          // 6: gitopedia.kb.v1.SearchResult
+	(*Alternate)(nil),             // 7: gitopedia.kb.v1.Alternate
+	(*ScoreSemantics)(nil),        // 8: gitopedia.kb.v1.ScoreSemantics
+	(*GetArticleRequest)(nil),     // 9: gitopedia.kb.v1.GetArticleRequest
+	(*Article)(nil),               // 10: gitopedia.kb.v1.Article
+	(*structpb.Struct)(nil),       // 11: google.protobuf.Struct
+}
+var file_kb_proto_depIdxs = []int32{
+	1,  // 0: gitopedia.kb.v1.CreateSourceRequest.locations:type_name -> gitopedia.kb.v1.Location
+	3,  // 1: gitopedia.kb.v1.CreateSourceResponse.findings:type_name -> gitopedia.kb.v1.Finding
+	6,  // 2: gitopedia.kb.v1.SearchSourcesResponse.results:type_name -> gitopedia.kb.v1.SearchResult
+	8,  // 3: gitopedia.kb.v1.SearchSourcesResponse.scores:type_name -> gitopedia.kb.v1.ScoreSemantics
+	7,  // 4: gitopedia.kb.v1.SearchResult.alternates:type_name -> gitopedia.kb.v1.Alternate
+	11, // 5: gitopedia.kb.v1.Article.meta:type_name -> google.protobuf.Struct
+	0,  // 6: gitopedia.kb.v1.KnowledgeBase.CreateSource:input_type -> gitopedia.kb.v1.CreateSourceRequest
+	4,  // 7: gitopedia.kb.v1.KnowledgeBase.SearchSources:input_type -> gitopedia.kb.v1.SearchSourcesRequest
+	9,  // 8: gitopedia.kb.v1.KnowledgeBase.GetArticle:input_type -> gitopedia.kb.v1.GetArticleRequest
+	2,  // 9: gitopedia.kb.v1.KnowledgeBase.CreateSource:output_type -> gitopedia.kb.v1.CreateSourceResponse
+	5,  // 10: gitopedia.kb.v1.KnowledgeBase.SearchSources:output_type -> gitopedia.kb.v1.SearchSourcesResponse
+	10, // 11: gitopedia.kb.v1.KnowledgeBase.GetArticle:output_type -> gitopedia.kb.v1.Article
+	9,  // [9:12] is the sub-list for method output_type
+	6,  // [6:9] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_kb_proto_init() }
+func file_kb_proto_init() {
+	if File_kb_proto != nil {
+		return
+	}
+	file_kb_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kb_proto_rawDesc), len(file_kb_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kb_proto_goTypes,
+		DependencyIndexes: file_kb_proto_depIdxs,
+		MessageInfos:      file_kb_proto_msgTypes,
+	}.Build()
+	File_kb_proto = out.File
+	file_kb_proto_goTypes = nil
+	file_kb_proto_depIdxs = nil
+}
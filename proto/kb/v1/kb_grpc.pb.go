@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.29.3
+// source: kb.proto
+
+// gRPC API of the knowledge base. The operations are those of the HTTP API
+// with the same validation, auth and limits: each call is served by the HTTP
+// handler of its endpoint, and messages use the field names of its JSON.
+//
+// Metadata: "authorization" ("Bearer kb_...") carries an API key, and
+// "x-user-id" the user of personalized searches and private sources, as the
+// HTTP headers do. HTTP errors map to gRPC status codes (400 to
+// INVALID_ARGUMENT, 404 to NOT_FOUND, 429 to RESOURCE_EXHAUSTED, ...), with
+// the field errors of a 400 as google.rpc.BadRequest details.
+
+package kbv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	KnowledgeBase_CreateSource_FullMethodName  = "/gitopedia.kb.v1.KnowledgeBase/CreateSource"
+	KnowledgeBase_SearchSources_FullMethodName = "/gitopedia.kb.v1.KnowledgeBase/SearchSources"
+	KnowledgeBase_GetArticle_FullMethodName    = "/gitopedia.kb.v1.KnowledgeBase/GetArticle"
+)
+
+// KnowledgeBaseClient is the client API for KnowledgeBase service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KnowledgeBaseClient interface {
+	// CreateSource stores a source and indexes it for search (POST /sources)
+	CreateSource(ctx context.Context, in *CreateSourceRequest, opts ...grpc.CallOption) (*CreateSourceResponse, error)
+	// SearchSources finds the sources most similar to a query (POST
+	// /sources/search)
+	SearchSources(ctx context.Context, in *SearchSourcesRequest, opts ...grpc.CallOption) (*SearchSourcesResponse, error)
+	// GetArticle returns an article by ID, following renames (GET
+	// /articles/{id})
+	GetArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*Article, error)
+}
+
+type knowledgeBaseClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKnowledgeBaseClient(cc grpc.ClientConnInterface) KnowledgeBaseClient {
+	return &knowledgeBaseClient{cc}
+}
+
+func (c *knowledgeBaseClient) CreateSource(ctx context.Context, in *CreateSourceRequest, opts ...grpc.CallOption) (*CreateSourceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateSourceResponse)
+	err := c.cc.Invoke(ctx, KnowledgeBase_CreateSource_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *knowledgeBaseClient) SearchSources(ctx context.Context, in *SearchSourcesRequest, opts ...grpc.CallOption) (*SearchSourcesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchSourcesResponse)
+	err := c.cc.Invoke(ctx, KnowledgeBase_SearchSources_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *knowledgeBaseClient) GetArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*Article, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Article)
+	err := c.cc.Invoke(ctx, KnowledgeBase_GetArticle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KnowledgeBaseServer is the server API for KnowledgeBase service.
+// All implementations must embed UnimplementedKnowledgeBaseServer
+// for forward compatibility.
+type KnowledgeBaseServer interface {
+	// CreateSource stores a source and indexes it for search (POST /sources)
+	CreateSource(context.Context, *CreateSourceRequest) (*CreateSourceResponse, error)
+	// SearchSources finds the sources most similar to a query (POST
+	// /sources/search)
+	SearchSources(context.Context, *SearchSourcesRequest) (*SearchSourcesResponse, error)
+	// GetArticle returns an article by ID, following renames (GET
+	// /articles/{id})
+	GetArticle(context.Context, *GetArticleRequest) (*Article, error)
+	mustEmbedUnimplementedKnowledgeBaseServer()
+}
+
+// UnimplementedKnowledgeBaseServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKnowledgeBaseServer struct{}
+
+func (UnimplementedKnowledgeBaseServer) CreateSource(context.Context, *CreateSourceRequest) (*CreateSourceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateSource not implemented")
+}
+func (UnimplementedKnowledgeBaseServer) SearchSources(context.Context, *SearchSourcesRequest) (*SearchSourcesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SearchSources not implemented")
+}
+func (UnimplementedKnowledgeBaseServer) GetArticle(context.Context, *GetArticleRequest) (*Article, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetArticle not implemented")
+}
+func (UnimplementedKnowledgeBaseServer) mustEmbedUnimplementedKnowledgeBaseServer() {}
+func (UnimplementedKnowledgeBaseServer) testEmbeddedByValue()                       {}
+
+// UnsafeKnowledgeBaseServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KnowledgeBaseServer will
+// result in compilation errors.
+type UnsafeKnowledgeBaseServer interface {
+	mustEmbedUnimplementedKnowledgeBaseServer()
+}
+
+func RegisterKnowledgeBaseServer(s grpc.ServiceRegistrar, srv KnowledgeBaseServer) {
+	// If the following call panics, it indicates UnimplementedKnowledgeBaseServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KnowledgeBase_ServiceDesc, srv)
+}
+
+func _KnowledgeBase_CreateSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KnowledgeBaseServer).CreateSource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KnowledgeBase_CreateSource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KnowledgeBaseServer).CreateSource(ctx, req.(*CreateSourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KnowledgeBase_SearchSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchSourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KnowledgeBaseServer).SearchSources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KnowledgeBase_SearchSources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KnowledgeBaseServer).SearchSources(ctx, req.(*SearchSourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KnowledgeBase_GetArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArticleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KnowledgeBaseServer).GetArticle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KnowledgeBase_GetArticle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KnowledgeBaseServer).GetArticle(ctx, req.(*GetArticleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KnowledgeBase_ServiceDesc is the grpc.ServiceDesc for KnowledgeBase service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KnowledgeBase_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gitopedia.kb.v1.KnowledgeBase",
+	HandlerType: (*KnowledgeBaseServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSource",
+			Handler:    _KnowledgeBase_CreateSource_Handler,
+		},
+		{
+			MethodName: "SearchSources",
+			Handler:    _KnowledgeBase_SearchSources_Handler,
+		},
+		{
+			MethodName: "GetArticle",
+			Handler:    _KnowledgeBase_GetArticle_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "kb.proto",
+}